@@ -16,6 +16,7 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -29,10 +30,17 @@ import (
 	"github.com/steveredden/KindredCard/internal/handlers"
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/middleware"
+	"github.com/steveredden/KindredCard/internal/ratelimit"
 	"github.com/steveredden/KindredCard/internal/scheduler"
+	"github.com/steveredden/KindredCard/internal/webhook"
 )
 
-var ReleaseVersion = "v0.0.0-dev"
+// Build metadata, injected at build time via -ldflags "-X main.<Name>=<value>"
+var (
+	ReleaseVersion = "v0.0.0-dev"
+	GitCommit      = "unknown"
+	BuildTime      = "unknown"
+)
 
 //	@title			KindredCard API
 //	@version		1.0
@@ -54,12 +62,49 @@ func main() {
 	// logging level defined by OS ENV LOG_LEVEL
 	logger.Init()
 
-	appKey := getEnv("APP_KEY", "missing")
-	if appKey == "missing" {
+	// Tokens and confirmations are signed with APP_KEY; a blank key would let anyone forge them
+	appKey := strings.TrimSpace(getEnv("APP_KEY", ""))
+	if appKey == "" {
 		logger.Fatal("[APP] An APP_KEY is required")
 	}
 
 	enableTwoWayCardDAV := (strings.ToUpper(getEnv("ENABLE_TWO_WAY_CARDDAV", "FALSE")) == "TRUE")
+	cardDAVSyncTokenFormat := strings.ToUpper(getEnv("CARDDAV_SYNC_TOKEN_FORMAT", "INTEGER"))
+	cardDAVURLTokenAgents := getEnv("CARDDAV_URL_TOKEN_USER_AGENTS", "")
+	cardDAVRejectNameless := (strings.ToUpper(getEnv("CARDDAV_NAMELESS_CONTACTS", "PLACEHOLDER")) == "REJECT")
+	cardDAVTombstoneStyle := strings.ToUpper(getEnv("CARDDAV_TOMBSTONE_STYLE", "404"))
+	cardDAVEmptyCardAgents := getEnv("CARDDAV_EMPTY_CARD_TOMBSTONE_USER_AGENTS", "")
+	explicitMirrorRelationships := (strings.ToUpper(getEnv("EXPLICIT_MIRROR_RELATIONSHIPS", "FALSE")) == "TRUE")
+	emptySearchListsContacts := (strings.ToUpper(getEnv("SEARCH_EMPTY_QUERY", "ERROR")) == "LIST")
+	gravatarEnabled := (strings.ToUpper(getEnv("GRAVATAR_ENABLED", "FALSE")) == "TRUE")
+	gravatarURL := getEnv("GRAVATAR_URL", "")
+	syncDebugAPIEnabled := (strings.ToUpper(getEnv("ENABLE_SYNC_DEBUG_API", "FALSE")) == "TRUE")
+
+	// Form of generated contact UIDs: empty (bare UUID), urn:uuid, or a domain (uuid@domain)
+	uidDomain := getEnv("UID_DOMAIN", "")
+
+	trashRetentionDays, err := strconv.Atoi(getEnv("CONTACT_TRASH_RETENTION_DAYS", strconv.Itoa(db.DefaultTrashRetentionDays)))
+	if err != nil || trashRetentionDays < 1 {
+		logger.Fatal("[APP] CONTACT_TRASH_RETENTION_DAYS must be a positive integer")
+	}
+
+	suggestionMaxSteps, err := strconv.Atoi(getEnv("RELATIONSHIP_SUGGESTION_MAX_STEPS", strconv.Itoa(db.DefaultSuggestionMaxSteps)))
+	if err != nil || suggestionMaxSteps < 1 {
+		logger.Fatal("[APP] RELATIONSHIP_SUGGESTION_MAX_STEPS must be a positive integer")
+	}
+
+	suggestionMaxResults, err := strconv.Atoi(getEnv("RELATIONSHIP_SUGGESTION_MAX_RESULTS", strconv.Itoa(db.DefaultSuggestionMaxResults)))
+	if err != nil || suggestionMaxResults < 1 {
+		logger.Fatal("[APP] RELATIONSHIP_SUGGESTION_MAX_RESULTS must be a positive integer")
+	}
+
+	// Only trust X-Forwarded-For / X-Real-IP when a reverse proxy sets them
+	trustProxyHeaders := (strings.ToUpper(getEnv("TRUST_PROXY_HEADERS", "FALSE")) == "TRUE")
+
+	apiRateLimit, err := strconv.Atoi(getEnv("API_RATE_LIMIT_PER_MINUTE", strconv.Itoa(ratelimit.DefaultPerMinute)))
+	if err != nil || apiRateLimit < 0 {
+		logger.Fatal("[APP] API_RATE_LIMIT_PER_MINUTE must be a non-negative integer (0 disables rate limiting)")
+	}
 
 	dbHost := getEnv("DB_HOST", "localhost")
 	dbPort := getEnv("DB_PORT", "5432")
@@ -82,16 +127,44 @@ func main() {
 	}
 	defer database.Close()
 
+	database.ExplicitMirrorRelationships = explicitMirrorRelationships
+	database.TrashRetentionDays = trashRetentionDays
+	database.SuggestionMaxSteps = suggestionMaxSteps
+	database.SuggestionMaxResults = suggestionMaxResults
+	database.UIDDomain = uidDomain
+
+	// Contact changes are pushed to the user's webhooks once they commit
+	database.ContactChangeHook = webhook.NewDispatcher(database).ContactChanged
+
 	logger.Info("[APP] Connected to database successfully")
 
 	// Initialize handlers
-	handler, err := handlers.NewHandler(database, "web/templates", baseURL, ReleaseVersion)
+	handler, err := handlers.NewHandler(database, "web/templates", baseURL, handlers.BuildInfo{
+		Version:   ReleaseVersion,
+		GitCommit: GitCommit,
+		BuildTime: BuildTime,
+	})
 	if err != nil {
 		logger.Fatal("[APP] Failed to initialize handlers: %v", err)
 	}
+	handler.EmptySearchListsContacts = emptySearchListsContacts
+	handler.GravatarEnabled = gravatarEnabled
+	handler.GravatarURL = gravatarURL
+	handler.SyncDebugAPIEnabled = syncDebugAPIEnabled
+	handler.UIDDomain = uidDomain
 
 	// Initialize CardDAV server
 	cardDAVServer := carddav.NewServer(database, !enableTwoWayCardDAV)
+	cardDAVServer.URLSyncTokens = (cardDAVSyncTokenFormat == "URL")
+	cardDAVServer.RejectNamelessContacts = cardDAVRejectNameless
+	cardDAVServer.UIDDomain = uidDomain
+	if cardDAVURLTokenAgents != "" {
+		cardDAVServer.URLSyncTokenAgents = strings.Split(cardDAVURLTokenAgents, ",")
+	}
+	cardDAVServer.EmptyCardTombstones = (cardDAVTombstoneStyle == "EMPTY_CARD")
+	if cardDAVEmptyCardAgents != "" {
+		cardDAVServer.EmptyCardTombstoneAgents = strings.Split(cardDAVEmptyCardAgents, ",")
+	}
 
 	// Initialize Scheduler Service
 	schedulerService := scheduler.NewScheduler(database, baseURL)
@@ -114,11 +187,15 @@ func main() {
 	// Logging middleware
 	r.Use(middleware.LoggingMiddleware)
 
+	// Response compression (gzip) for large text/JSON/XML bodies
+	r.Use(middleware.CompressionMiddleware)
+
 	// Setup check middleware (redirects to /setup if not complete)
 	r.Use(middleware.SetupCheckMiddleware(database))
 
 	// Public routes (no auth required)
 	r.HandleFunc("/health", handler.HandleHealth).Methods("GET")
+	r.HandleFunc("/api/v1/version", handler.HandleVersion).Methods("GET")
 	r.HandleFunc("/setup", handler.ShowSetup).Methods("GET")
 	r.HandleFunc("/setup", handler.ProcessSetup).Methods("POST")
 	r.HandleFunc("/login", handler.ShowLogin).Methods("GET")
@@ -155,14 +232,29 @@ func main() {
 
 	// Protected API routes
 	api := r.PathPrefix("/api/v1").Subrouter()
-	api.Use(middleware.APIAuthMiddleware(database))
+	// Zero disables API rate limiting
+	var apiLimiter *ratelimit.Limiter
+	if apiRateLimit > 0 {
+		apiLimiter = ratelimit.New(apiRateLimit)
+	}
+	api.Use(middleware.APIAuthMiddleware(database, apiLimiter, trustProxyHeaders))
 	api.HandleFunc("/contacts", handler.ListContactsAPI).Methods("GET")
 	api.HandleFunc("/contacts", handler.CreateContactAPI).Methods("POST")
 	api.HandleFunc("/contacts/{id:[0-9]+}", handler.GetContactAPI).Methods("GET")
 	api.HandleFunc("/contacts/{id:[0-9]+}", handler.UpdateContactAPI).Methods("PUT")
 	api.HandleFunc("/contacts/{id:[0-9]+}", handler.DeleteContactAPI).Methods("DELETE")
+	api.HandleFunc("/contacts/trash", handler.ListTrashAPI).Methods("GET")
+	api.HandleFunc("/contacts/{id:[0-9]+}/restore", handler.RestoreContactAPI).Methods("POST")
+	api.HandleFunc("/contacts/{id:[0-9]+}/archive", handler.ArchiveContactAPI).Methods("POST")
+	api.HandleFunc("/contacts/{id:[0-9]+}/unarchive", handler.UnarchiveContactAPI).Methods("POST")
+	api.HandleFunc("/contacts/{id:[0-9]+}/touch", handler.TouchContactAPI).Methods("POST")
+	api.HandleFunc("/sync/tombstones", handler.ListTombstonesAPI).Methods("GET")
+	api.HandleFunc("/sync/tombstones", handler.PurgeTombstonesAPI).Methods("DELETE")
+	api.HandleFunc("/contacts/{id:[0-9]+}/avatar", handler.GetAvatarAPI).Methods("GET")
 	api.HandleFunc("/contacts/{id:[0-9]+}/avatar", handler.UploadAvatarAPI).Methods("POST")
+	api.HandleFunc("/contacts/{id:[0-9]+}/avatar/url", handler.UploadAvatarURLAPI).Methods("POST")
 	api.HandleFunc("/contacts/{id:[0-9]+}/avatar", handler.DeleteAvatarAPI).Methods("DELETE")
+	api.HandleFunc("/contacts/avatars/backfill-gravatar", handler.BackfillGravatarAvatarsAPI).Methods("POST")
 	api.HandleFunc("/contacts/search", handler.SearchContactsAPI).Methods("GET")
 
 	// Contact PATCH
@@ -200,6 +292,10 @@ func main() {
 	api.HandleFunc("/organizations/{oid:[0-9]+}", handler.UpdateOrganizationAPI).Methods("PATCH")
 	api.HandleFunc("/contacts/{cid:[0-9]+}/organizations/{oid:[0-9]+}", handler.DeleteOrganizationAPI).Methods("DELETE")
 
+	// tags
+	api.HandleFunc("/contacts/{cid:[0-9]+}/tags", handler.AddContactTagAPI).Methods("POST")
+	api.HandleFunc("/contacts/{cid:[0-9]+}/tags/{tag}", handler.RemoveContactTagAPI).Methods("DELETE")
+
 	// notes
 	api.HandleFunc("/contacts/{cid:[0-9]+}/notes", handler.UpdateNotesAPI).Methods("PUT")
 
@@ -207,12 +303,18 @@ func main() {
 	api.HandleFunc("/user/preferences", handler.UpdatePreferencesAPI).Methods("PUT")
 
 	// custom labels
+	api.HandleFunc("/labels", handler.ListLabelsAPI).Methods("GET")
+	api.HandleFunc("/labels", handler.NewCustomLabelAPI).Methods("POST")
+	api.HandleFunc("/labels/{lid:[0-9]+}", handler.DeleteCustomLabelAPI).Methods("DELETE")
 	api.HandleFunc("/settings/labels", handler.NewCustomLabelAPI).Methods("POST")
 	api.HandleFunc("/settings/labels/{lid:[0-9]+}", handler.DeleteCustomLabelAPI).Methods("DELETE")
 
 	// Settings: Contact Management
+	api.HandleFunc("/contacts/delete-preview", handler.PreviewDeleteAllContactsAPI).Methods("GET")
 	api.HandleFunc("/contacts", handler.DeleteAllContactsAPI).Methods("DELETE")
 	api.HandleFunc("/contacts/duplicates", handler.FindDuplicatesAPI).Methods("GET")
+	api.HandleFunc("/contacts/merge", handler.MergeContactsAPI).Methods("POST")
+	api.HandleFunc("/contacts/exclude-empty", handler.ExcludeEmptyContactsAPI).Methods("POST")
 
 	//Settings: Sessions
 	api.HandleFunc("/sessions/{id:[0-9]+}", handler.DeleteUserSessionAPI).Methods("DELETE")
@@ -222,25 +324,42 @@ func main() {
 	api.HandleFunc("/events/upcoming", handler.GetUpcomingEventsAPI).Methods("GET")
 	api.HandleFunc("/events/count", handler.GetUpcomingEventsCountAPI).Methods("GET")
 	api.HandleFunc("/events/today", handler.GetTodaysEventsAPI).Methods("GET")
+	api.HandleFunc("/events/reconnect", handler.GetReconnectEventsAPI).Methods("GET")
+	api.HandleFunc("/events/types", handler.GetEventTypesAPI).Methods("GET")
+	api.HandleFunc("/events/calendar.ics", handler.GetCalendarFeedAPI).Methods("GET")
 
 	// Notifications
 	api.HandleFunc("/notification-settings", handler.ListNotificationSettingsAPI).Methods("GET")
 	api.HandleFunc("/notification-settings", handler.CreateNotificationSettingAPI).Methods("POST")
+	api.HandleFunc("/notification-settings/export", handler.ExportNotificationSettingsAPI).Methods("GET")
+	api.HandleFunc("/notification-settings/import", handler.ImportNotificationSettingsAPI).Methods("POST")
 	api.HandleFunc("/notification-settings/{id:[0-9]+}", handler.GetNotificationSettingAPI).Methods("GET")
 	api.HandleFunc("/notification-settings/{id:[0-9]+}", handler.UpdateNotificationSettingAPI).Methods("PUT")
 	api.HandleFunc("/notification-settings/{id:[0-9]+}", handler.DeleteNotificationSettingAPI).Methods("DELETE")
 	api.HandleFunc("/notification-settings/{id:[0-9]+}/test", handler.TestNotificationAPI).Methods("POST")
+	api.HandleFunc("/notification-settings/{id:[0-9]+}/matched-events", handler.MatchedEventsAPI).Methods("GET")
 
 	// Export/Import routes
 	api.HandleFunc("/contacts/{id:[0-9]+}/vcard", handler.ExportContactVCardAPI).Methods("GET")
 	api.HandleFunc("/contacts/export/vcard", handler.ExportAllVCardsAPI).Methods("GET")
 	api.HandleFunc("/contacts/export/json", handler.ExportAllJSONAPI).Methods("GET")
+	api.HandleFunc("/contacts/export/csv", handler.ExportAllCSVAPI).Methods("GET")
+	api.HandleFunc("/contacts/export/txt", handler.ExportAllTextAPI).Methods("GET")
+	api.HandleFunc("/contacts/avatars/export.zip", handler.ExportAvatarsAPI).Methods("GET")
 	api.HandleFunc("/contacts/import", handler.ImportVCardsAPI).Methods("POST")
+	api.HandleFunc("/contacts/import/csv", handler.ImportCSVAPI).Methods("POST")
 
 	// Relationship routes
 	api.HandleFunc("/relationship-types", handler.GetRelationshipTypesAPI).Methods("GET")
+	api.HandleFunc("/relationship-types/seed", handler.SeedRelationshipTypesAPI).Methods("POST")
+	api.HandleFunc("/relationship-types/{id:[0-9]+}/refresh", handler.RefreshRelationshipTypeAPI).Methods("POST")
 	api.HandleFunc("/contacts/{id:[0-9]+}/relationships", handler.AddRelationshipAPI).Methods("POST")
+	api.HandleFunc("/contacts/{id:[0-9]+}/household", handler.GetHouseholdAPI).Methods("GET")
 	api.HandleFunc("/relationships/{rel_id:[0-9]+}", handler.RemoveRelationshipAPI).Methods("DELETE")
+	api.HandleFunc("/relationships/suggestions", handler.GetRelationshipSuggestionsAPI).Methods("GET")
+	api.HandleFunc("/relationships/suggestions/accept", handler.AcceptRelationshipSuggestionAPI).Methods("POST")
+	api.HandleFunc("/suggestions/anniversaries", handler.GetAnniversarySuggestionsAPI).Methods("GET")
+	api.HandleFunc("/suggestions/anniversaries/accept", handler.AcceptAnniversarySuggestionAPI).Methods("POST")
 	api.HandleFunc("/other-relationships/{rel_id:[0-9]+}", handler.RemoveOtherRelationshipAPI).Methods("DELETE")
 	//api.HandleFunc("/relationship-types", handler.CreateRelationshipTypeAPI).Methods("POST")
 
@@ -252,9 +371,16 @@ func main() {
 	api.HandleFunc("/tokens/{id:[0-9]+}", handler.DeleteAPIToken).Methods("DELETE")
 	api.HandleFunc("/tokens/{id:[0-9]+}/revoke", handler.RevokeAPIToken).Methods("POST")
 
+	// Webhooks
+	api.HandleFunc("/webhooks", handler.ListWebhooksAPI).Methods("GET")
+	api.HandleFunc("/webhooks", handler.CreateWebhookAPI).Methods("POST")
+	api.HandleFunc("/webhooks/{id:[0-9]+}", handler.DeleteWebhookAPI).Methods("DELETE")
+
 	// immich APIs
 	api.HandleFunc("/immich/proxy/thumbnail/{personID}", handler.GetImmichThumbnailProxy).Methods("GET")
 	api.HandleFunc("/immich/link", handler.PostImmichLinkAPI).Methods("POST")
+	api.HandleFunc("/contacts/{id:[0-9]+}/immich/link", handler.LinkContactImmichAPI).Methods("POST")
+	api.HandleFunc("/contacts/{id:[0-9]+}/immich/link", handler.UnlinkContactImmichAPI).Methods("DELETE")
 
 	// CardDAV routes (Basic Auth)
 	carddav := r.PathPrefix("/carddav").Subrouter()
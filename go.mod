@@ -3,6 +3,7 @@ module github.com/steveredden/KindredCard
 go 1.25
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/emersion/go-vcard v0.0.0-20241024213814-c9703dde27ff
 	github.com/gorilla/mux v1.8.1
 	github.com/lib/pq v1.10.9
@@ -31,6 +31,7 @@ var (
 	ErrInvalidToken     = errors.New("invalid token")
 	ErrInvalidSignature = errors.New("invalid token signature")
 	ErrMalformedPayload = errors.New("malformed token payload; missing user ID")
+	ErrMissingAppKey    = errors.New("no APP_KEY to sign with")
 )
 
 // HashPassword creates a bcrypt hash of the password
@@ -126,6 +127,49 @@ func VerifyAndExtractUserID(signedToken string, appKey string) (string, int, err
 	return tokenPayload, userID, nil
 }
 
+// GenerateConfirmationToken creates a short-lived signed value binding a destructive
+// action to a user and the number of records it will affect.
+// Returns: "[ExpiryUnix].[Signature]", or ErrMissingAppKey when appKey is empty
+func GenerateConfirmationToken(appKey string, action string, userID int, count int, ttl time.Duration) (string, error) {
+	if appKey == "" {
+		return "", ErrMissingAppKey
+	}
+
+	expiry := time.Now().UTC().Add(ttl).Unix()
+	payload := fmt.Sprintf("%s:%d:%d:%d", action, userID, count, expiry)
+	return fmt.Sprintf("%d.%s", expiry, signToken(payload, appKey)), nil
+}
+
+// VerifyConfirmationToken checks a value produced by GenerateConfirmationToken.
+// Fails if the token is expired or if the action, user, or count no longer match, and always
+// when appKey is empty, since anyone could sign with an empty key.
+func VerifyConfirmationToken(token string, appKey string, action string, userID int, count int) error {
+	if appKey == "" {
+		return ErrMissingAppKey
+	}
+
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return ErrInvalidToken
+	}
+
+	expiry, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	if time.Now().UTC().Unix() > expiry {
+		return ErrInvalidToken
+	}
+
+	payload := fmt.Sprintf("%s:%d:%d:%d", action, userID, count, expiry)
+	if !hmac.Equal([]byte(parts[1]), []byte(signToken(payload, appKey))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
 // signToken creates an HMAC-SHA256 signature of the token
 func signToken(token, appKey string) string {
 	h := hmac.New(sha256.New, []byte(appKey))
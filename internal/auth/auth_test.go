@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConfirmationToken(t *testing.T) {
+	const key = "test-app-key"
+
+	token, err := GenerateConfirmationToken(key, "delete-all-contacts", 7, 42, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateConfirmationToken: %v", err)
+	}
+
+	if err := VerifyConfirmationToken(token, key, "delete-all-contacts", 7, 42); err != nil {
+		t.Errorf("valid token rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		key    string
+		action string
+		userID int
+		count  int
+		want   error
+	}{
+		{"count changed", token, key, "delete-all-contacts", 7, 43, ErrInvalidSignature},
+		{"other user", token, key, "delete-all-contacts", 8, 42, ErrInvalidSignature},
+		{"other action", token, key, "something-else", 7, 42, ErrInvalidSignature},
+		{"other key", token, "another-key", "delete-all-contacts", 7, 42, ErrInvalidSignature},
+		{"empty key", token, "", "delete-all-contacts", 7, 42, ErrMissingAppKey},
+		{"malformed", "not-a-token", key, "delete-all-contacts", 7, 42, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyConfirmationToken(tt.token, tt.key, tt.action, tt.userID, tt.count)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmationTokenExpired(t *testing.T) {
+	token, err := GenerateConfirmationToken("test-app-key", "delete-all-contacts", 7, 42, -time.Second)
+	if err != nil {
+		t.Fatalf("GenerateConfirmationToken: %v", err)
+	}
+	if err := VerifyConfirmationToken(token, "test-app-key", "delete-all-contacts", 7, 42); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired token: err = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestConfirmationTokenRequiresKey(t *testing.T) {
+	if _, err := GenerateConfirmationToken("", "delete-all-contacts", 7, 42, time.Minute); !errors.Is(err, ErrMissingAppKey) {
+		t.Errorf("err = %v, want %v", err, ErrMissingAppKey)
+	}
+}
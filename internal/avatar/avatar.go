@@ -0,0 +1,182 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+	"slices"
+
+	// Registered decoders for image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// MaxDimension is the longest side, in pixels, of a stored avatar
+const MaxDimension = 512
+
+// MaxSourcePixels caps the pixel count of an image Process will decode. Compressed data can be
+// tiny yet decode to gigabytes, so the size is read from the header before decoding
+const MaxSourcePixels = 40_000_000
+
+// jpegQuality balances size against artifacts for small portraits
+const jpegQuality = 85
+
+// ErrUnsupportedImage is returned when data isn't a JPEG, PNG, or GIF image
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+// ErrImageTooLarge is returned when an image has more than MaxSourcePixels pixels
+var ErrImageTooLarge = errors.New("image dimensions are too large")
+
+// ErrInvalidImage is returned when data looks like a supported image but can't be decoded
+var ErrInvalidImage = errors.New("invalid image data")
+
+// SupportedMimeTypes are the image formats Process accepts
+var SupportedMimeTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// DetectMimeType sniffs the MIME type of image data from its leading bytes (see
+// http.DetectContentType), so WebP, BMP, and others are told apart from the supported formats
+// rather than guessed from the base64 text
+func DetectMimeType(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// Image is an avatar ready to store
+type Image struct {
+	Data     []byte // re-encoded JPEG, at most MaxDimension on its longest side
+	MimeType string // always image/jpeg
+	Width    int
+	Height   int
+
+	OriginalMimeType string // MIME type detected from the uploaded bytes
+	Converted        bool   // Data differs from the upload, which is worth keeping as the original
+}
+
+// Process decodes an uploaded image, detecting its real format, and re-encodes it as a JPEG no
+// larger than MaxDimension x MaxDimension, preserving the aspect ratio. Transparent areas are
+// flattened onto white since JPEG has no alpha channel. Images over MaxSourcePixels are rejected
+// with ErrImageTooLarge before they're decoded
+func Process(data []byte) (*Image, error) {
+	mimeType := DetectMimeType(data)
+	if !slices.Contains(SupportedMimeTypes, mimeType) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, mimeType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if int64(config.Width)*int64(config.Height) > MaxSourcePixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	bounds := src.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), MaxDimension)
+	if width == 0 || height == 0 {
+		return nil, ErrInvalidImage
+	}
+
+	// A JPEG that already fits is stored as uploaded rather than recompressed
+	if mimeType == "image/jpeg" && width == bounds.Dx() && height == bounds.Dy() {
+		return &Image{
+			Data:             data,
+			MimeType:         "image/jpeg",
+			Width:            width,
+			Height:           height,
+			OriginalMimeType: "image/jpeg",
+		}, nil
+	}
+
+	flat := image.NewRGBA(bounds)
+	draw.Draw(flat, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, bounds, src, bounds.Min, draw.Over)
+
+	dst := flat
+	if width != bounds.Dx() || height != bounds.Dy() {
+		dst = downscale(flat, width, height)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+
+	return &Image{
+		Data:             buf.Bytes(),
+		MimeType:         "image/jpeg",
+		Width:            width,
+		Height:           height,
+		OriginalMimeType: mimeType,
+		Converted:        true,
+	}, nil
+}
+
+// fit scales width x height down so neither side exceeds limit; smaller images are unchanged
+func fit(width, height, limit int) (int, int) {
+	if width <= limit && height <= limit {
+		return width, height
+	}
+	if width >= height {
+		return limit, max(1, height*limit/width)
+	}
+	return max(1, width*limit/height), limit
+}
+
+// downscale shrinks src to width x height by averaging the source pixels covered by each
+// destination pixel (a box filter), which avoids the aliasing of nearest-neighbour sampling
+func downscale(src *image.RGBA, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcH / height
+		y1 := max(y0+1, (y+1)*srcH/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcW / width
+			x1 := max(x0+1, (x+1)*srcW/width)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[offset])
+					g += uint32(src.Pix[offset+1])
+					b += uint32(src.Pix[offset+2])
+					a += uint32(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
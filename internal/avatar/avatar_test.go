@@ -0,0 +1,146 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// encodePNG returns a blank PNG of the given size
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("encoding PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessDownscales(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		wantW, wantH  int
+	}{
+		{"square", 2000, 2000, 512, 512},
+		{"landscape", 2000, 1000, 512, 256},
+		{"portrait", 600, 1200, 256, 512},
+		{"small", 100, 50, 100, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Process(encodePNG(t, tt.width, tt.height))
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			if img.MimeType != "image/jpeg" || img.OriginalMimeType != "image/png" || !img.Converted {
+				t.Errorf("got %s from %s (converted %v), want a converted JPEG from a PNG", img.MimeType, img.OriginalMimeType, img.Converted)
+			}
+
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(img.Data))
+			if err != nil {
+				t.Fatalf("decoding stored image: %v", err)
+			}
+			if format != "jpeg" || cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Errorf("stored a %dx%d %s, want a %dx%d jpeg", cfg.Width, cfg.Height, format, tt.wantW, tt.wantH)
+			}
+			if img.Width != cfg.Width || img.Height != cfg.Height {
+				t.Errorf("Width/Height = %dx%d, want the encoded %dx%d", img.Width, img.Height, cfg.Width, cfg.Height)
+			}
+		})
+	}
+}
+
+func TestProcessKeepsSmallJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 64)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if img.Converted || !bytes.Equal(img.Data, buf.Bytes()) {
+		t.Error("a JPEG that already fits was recompressed")
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	truncated := encodePNG(t, 32, 32)
+	truncated = truncated[:len(truncated)-20]
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"text", []byte("definitely not an image"), ErrUnsupportedImage},
+		{"webp", append([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), make([]byte, 32)...), ErrUnsupportedImage},
+		{"truncated png", truncated, ErrInvalidImage},
+		{"too many pixels", pngHeader(t, 10000, 10000), ErrImageTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Process: err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// pngHeader returns a PNG that declares the given size but has no image data, the shape of a
+// decompression bomb
+func pngHeader(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+
+	data := encodePNG(t, 1, 1)
+	// The IHDR chunk follows the 8 byte signature: length, type, width, height, ..., CRC
+	binary.BigEndian.PutUint32(data[16:20], width)
+	binary.BigEndian.PutUint32(data[20:24], height)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestDetectMimeType(t *testing.T) {
+	var jpegBuf, gifBuf bytes.Buffer
+	if err := jpeg.Encode(&jpegBuf, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gifBuf, image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black}), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", encodePNG(t, 4, 4), "image/png"},
+		{"jpeg", jpegBuf.Bytes(), "image/jpeg"},
+		{"gif", gifBuf.Bytes(), "image/gif"},
+		{"webp", append([]byte("RIFF\x24\x00\x00\x00WEBPVP8 "), make([]byte, 24)...), "image/webp"},
+	}
+	for _, tt := range tests {
+		if got := DetectMimeType(tt.data); got != tt.want {
+			t.Errorf("%s: DetectMimeType = %q, want %q", tt.name, got, tt.want)
+		}
+
+		img, err := Process(tt.data)
+		supported := tt.want != "image/webp"
+		if supported && (err != nil || img.OriginalMimeType != tt.want) {
+			t.Errorf("%s: Process = %+v, %v; want the original detected as %s", tt.name, img, err, tt.want)
+		}
+		if !supported && !errors.Is(err, ErrUnsupportedImage) {
+			t.Errorf("%s: Process err = %v, want ErrUnsupportedImage", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package avatar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/logger"
+	"github.com/steveredden/KindredCard/internal/netguard"
+)
+
+// MaxFetchBytes caps how much of a remote image is downloaded
+const MaxFetchBytes = 10 << 20
+
+// ErrNotImage is returned when a URL doesn't serve a supported image
+var ErrNotImage = errors.New("URL did not return a supported image")
+
+// ErrBlockedAddress is returned when a URL resolves to a private, loopback, or otherwise internal
+// address, so avatar URLs can't be used to probe the server's network
+var ErrBlockedAddress = netguard.ErrBlockedAddress
+
+// Fetcher downloads avatar images from user-supplied URLs
+type Fetcher struct {
+	HTTPClient *http.Client
+}
+
+// NewFetcher creates a Fetcher whose connections refuse internal addresses (see netguard.NewClient)
+func NewFetcher() *Fetcher {
+	return &Fetcher{HTTPClient: netguard.NewClient(15 * time.Second)}
+}
+
+// Fetch downloads an image over http(s). The response must declare one of SupportedMimeTypes and
+// be at most MaxFetchBytes
+func (f *Fetcher) Fetch(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("URL must be an absolute http or https URL")
+	}
+
+	logger.Debug("[AVATAR] Fetching avatar from %s", u.Redacted())
+
+	resp, err := f.HTTPClient.Get(u.String())
+	if err != nil {
+		if errors.Is(err, ErrBlockedAddress) {
+			return nil, ErrBlockedAddress
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !slices.Contains(SupportedMimeTypes, contentType) {
+		return nil, fmt.Errorf("%w (content type %q)", ErrNotImage, contentType)
+	}
+	if resp.ContentLength > MaxFetchBytes {
+		return nil, fmt.Errorf("image is larger than %d bytes", MaxFetchBytes)
+	}
+
+	// Read one byte past the cap to tell a truncated image from one exactly at the limit
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxFetchBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > MaxFetchBytes {
+		return nil, fmt.Errorf("image is larger than %d bytes", MaxFetchBytes)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,64 @@
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetch(t *testing.T) {
+	pngData := encodePNG(t, 16, 16)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/avatar.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngData)
+	})
+	mux.HandleFunc("/page.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html></html>"))
+	})
+	mux.HandleFunc("/huge.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, MaxFetchBytes+1))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// The test server listens on loopback, which NewFetcher refuses
+	f := &Fetcher{HTTPClient: srv.Client()}
+
+	data, err := f.Fetch(srv.URL + "/avatar.png")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !bytes.Equal(data, pngData) {
+		t.Error("fetched bytes differ from the served PNG")
+	}
+
+	if _, err := f.Fetch(srv.URL + "/page.html"); !errors.Is(err, ErrNotImage) {
+		t.Errorf("HTML page: err = %v, want %v", err, ErrNotImage)
+	}
+	if _, err := f.Fetch(srv.URL + "/missing.png"); err == nil {
+		t.Error("a 404 response was accepted")
+	}
+	if _, err := f.Fetch(srv.URL + "/huge.png"); err == nil {
+		t.Error("an image over MaxFetchBytes was accepted")
+	}
+	if _, err := f.Fetch("ftp://example.com/avatar.png"); err == nil {
+		t.Error("a non-http URL was accepted")
+	}
+}
+
+func TestFetchBlocksInternalAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the fetcher connected to a loopback address")
+	}))
+	defer srv.Close()
+
+	if _, err := NewFetcher().Fetch(srv.URL + "/avatar.png"); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("err = %v, want %v", err, ErrBlockedAddress)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package carddav
+
+import (
+	"strings"
+
+	"github.com/steveredden/KindredCard/internal/logger"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// filterPropertyValues returns the values a contact holds for a vCard property that
+// addressbook-query filters can match on. ok is false for unsupported properties
+func filterPropertyValues(contact *models.Contact, name string) (values []string, ok bool) {
+	switch strings.ToUpper(name) {
+	case "FN":
+		if contact.FullName != "" {
+			values = append(values, contact.FullName)
+		}
+	case "NICKNAME":
+		if contact.Nickname != "" {
+			values = append(values, contact.Nickname)
+		}
+	case "UID":
+		values = append(values, contact.UID)
+	case "EMAIL":
+		for _, e := range contact.Emails {
+			values = append(values, e.Email)
+		}
+	case "TEL":
+		for _, p := range contact.Phones {
+			values = append(values, p.Phone)
+		}
+	default:
+		return nil, false
+	}
+	return values, true
+}
+
+// unsupportedPropFilters returns the prop-filters of an addressbook-query filter on properties
+// filterPropertyValues can't match, which the query must be refused for (RFC 6352 §10.5)
+func unsupportedPropFilters(filter *Filter) []PropFilter {
+	if filter == nil {
+		return nil
+	}
+
+	var unsupported []PropFilter
+	for _, pf := range filter.PropFilters {
+		if _, ok := filterPropertyValues(&models.Contact{}, pf.Name); !ok {
+			logger.Debug("[CARDDAV] Unsupported prop-filter: %s", pf.Name)
+			unsupported = append(unsupported, PropFilter{Name: pf.Name})
+		}
+	}
+	return unsupported
+}
+
+// applyAddressbookFilter returns the contacts matching an addressbook-query filter (RFC 6352 §10.5).
+// Check unsupportedPropFilters first; a filter without prop-filters matches every contact
+func applyAddressbookFilter(contacts []*models.Contact, filter *Filter) []*models.Contact {
+	if filter == nil || len(filter.PropFilters) == 0 {
+		return contacts
+	}
+	propFilters := filter.PropFilters
+
+	allOf := strings.EqualFold(filter.Test, "allof")
+
+	matched := []*models.Contact{}
+	for _, contact := range contacts {
+		if matchPropFilters(contact, propFilters, allOf) {
+			matched = append(matched, contact)
+		}
+	}
+	return matched
+}
+
+// matchPropFilters combines prop-filter results using anyof/allof semantics
+func matchPropFilters(contact *models.Contact, propFilters []PropFilter, allOf bool) bool {
+	for _, pf := range propFilters {
+		match := matchPropFilter(contact, pf)
+		if allOf && !match {
+			return false
+		}
+		if !allOf && match {
+			return true
+		}
+	}
+	return allOf
+}
+
+// matchPropFilter evaluates a single prop-filter against a contact
+func matchPropFilter(contact *models.Contact, pf PropFilter) bool {
+	values, _ := filterPropertyValues(contact, pf.Name)
+
+	if pf.IsNotDefined != nil {
+		return len(values) == 0
+	}
+	if len(values) == 0 {
+		return false
+	}
+	// A bare prop-filter only tests that the property exists
+	if len(pf.TextMatches) == 0 {
+		return true
+	}
+
+	allOf := strings.EqualFold(pf.Test, "allof")
+	for _, tm := range pf.TextMatches {
+		match := false
+		for _, v := range values {
+			if matchText(v, tm) {
+				match = true
+				break
+			}
+		}
+		if allOf && !match {
+			return false
+		}
+		if !allOf && match {
+			return true
+		}
+	}
+	return allOf
+}
+
+// matchText applies a text-match to a single property value. i;octet compares bytes
+// exactly; every other collation is treated as case-insensitive
+func matchText(value string, tm TextMatch) bool {
+	needle := tm.Value
+	if tm.Collation != "i;octet" {
+		value = strings.ToLower(value)
+		needle = strings.ToLower(needle)
+	}
+
+	var match bool
+	switch tm.MatchType {
+	case "equals":
+		match = value == needle
+	case "starts-with":
+		match = strings.HasPrefix(value, needle)
+	case "ends-with":
+		match = strings.HasSuffix(value, needle)
+	default:
+		match = strings.Contains(value, needle)
+	}
+
+	if strings.EqualFold(tm.NegateCondition, "yes") {
+		return !match
+	}
+	return match
+}
@@ -0,0 +1,58 @@
+package carddav
+
+import (
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestApplyAddressbookFilter(t *testing.T) {
+	contacts := []*models.Contact{
+		{UID: "alice", FullName: "Alice Liddell", Emails: []models.Email{{Email: "alice@example.com"}}},
+		{UID: "bob", FullName: "Bob Smith", Phones: []models.Phone{{Phone: "+15555550100"}}},
+		{UID: "carol", FullName: "Carol Alison"},
+	}
+
+	textMatch := func(value string, matchType string) []TextMatch {
+		return []TextMatch{{Value: value, MatchType: matchType}}
+	}
+
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   []string
+	}{
+		{"no filter", nil, []string{"alice", "bob", "carol"}},
+		{"FN contains", &Filter{PropFilters: []PropFilter{{Name: "FN", TextMatches: textMatch("ali", "contains")}}}, []string{"alice", "carol"}},
+		{"FN equals", &Filter{PropFilters: []PropFilter{{Name: "FN", TextMatches: textMatch("bob smith", "equals")}}}, []string{"bob"}},
+		{"EMAIL equals", &Filter{PropFilters: []PropFilter{{Name: "EMAIL", TextMatches: textMatch("ALICE@example.com", "equals")}}}, []string{"alice"}},
+		{"TEL contains", &Filter{PropFilters: []PropFilter{{Name: "TEL", TextMatches: textMatch("555", "contains")}}}, []string{"bob"}},
+		{"TEL not defined", &Filter{PropFilters: []PropFilter{{Name: "TEL", IsNotDefined: &struct{}{}}}}, []string{"alice", "carol"}},
+		{"anyof", &Filter{PropFilters: []PropFilter{
+			{Name: "FN", TextMatches: textMatch("carol", "starts-with")},
+			{Name: "EMAIL", TextMatches: textMatch("alice", "contains")},
+		}}, []string{"alice", "carol"}},
+		{"allof", &Filter{Test: "allof", PropFilters: []PropFilter{
+			{Name: "FN", TextMatches: textMatch("ali", "contains")},
+			{Name: "EMAIL"},
+		}}, []string{"alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyAddressbookFilter(contacts, tt.filter)
+			uids := make([]string, len(got))
+			for i, c := range got {
+				uids[i] = c.UID
+			}
+			if len(uids) != len(tt.want) {
+				t.Fatalf("matched %v, want %v", uids, tt.want)
+			}
+			for i := range uids {
+				if uids[i] != tt.want[i] {
+					t.Fatalf("matched %v, want %v", uids, tt.want)
+				}
+			}
+		})
+	}
+}
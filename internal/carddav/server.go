@@ -12,6 +12,7 @@ package carddav
 import (
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -24,17 +25,59 @@ import (
 	"github.com/steveredden/KindredCard/internal/db"
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/middleware"
+	"github.com/steveredden/KindredCard/internal/models"
 	"github.com/steveredden/KindredCard/internal/utils"
 )
 
+// Server answers CardDAV requests. Its fields are settings shared by every request; what depends
+// on the user or client lives in a request
 type Server struct {
-	db            *db.Database
-	ReadOnly      bool
+	db       *db.Database
+	ReadOnly bool
+
+	// URLSyncTokens emits sync-tokens as URLs ({base}/carddav/{user}/contacts/token/{n})
+	// for every client instead of bare integers
+	URLSyncTokens bool
+	// URLSyncTokenAgents enables URL-style sync-tokens only for User-Agents containing
+	// any of these (case-insensitive) substrings
+	URLSyncTokenAgents []string
+
+	// RejectNamelessContacts answers PUTs whose vCard has neither FN nor N with 400 instead of
+	// naming the contact from its organization, email, or phone
+	RejectNamelessContacts bool
+
+	// EmptyCardTombstones reports deleted contacts in sync-collection as empty vCards (200 with
+	// an ETag) instead of 404 responses, for every client
+	EmptyCardTombstones bool
+	// EmptyCardTombstoneAgents enables empty-card tombstones only for User-Agents containing
+	// any of these (case-insensitive) substrings
+	EmptyCardTombstoneAgents []string
+
+	// UIDDomain qualifies the UIDs generated for vCards PUT without one (see utils.NewUID)
+	UIDDomain string
+}
+
+// request is one CardDAV request: the shared Server plus the authenticated user and the options
+// resolved for the client. ServeHTTP builds one per request, so concurrent requests never share
+// user state
+type request struct {
+	*Server
+
 	baseURL       string
 	userPrincipal string
 	userID        int
+	// principalName is the principal's displayname (see principalDisplayName)
+	principalName string
+	// preferNickname sends nicknames as FN (the user's NicknameAsDisplayName preference)
+	preferNickname bool
+
+	useURLTokens           bool
+	useEmptyCardTombstones bool
 }
 
+// namelessPlaceholder names a PUT contact that has nothing better to go by
+const namelessPlaceholder = "Unnamed Contact"
+
 func NewServer(database *db.Database, readOnly bool) *Server {
 	return &Server{
 		db:       database,
@@ -61,9 +104,16 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.userID = user.ID
-	s.userPrincipal = user.Email
-	s.baseURL = getExternalBaseURL(r)
+	req := &request{
+		Server:                 s,
+		userID:                 user.ID,
+		userPrincipal:          user.Email,
+		principalName:          principalDisplayName(user),
+		preferNickname:         user.NicknameAsDisplayName,
+		baseURL:                getExternalBaseURL(r),
+		useURLTokens:           s.URLSyncTokens || userAgentMatches(r, s.URLSyncTokenAgents),
+		useEmptyCardTombstones: s.EmptyCardTombstones || userAgentMatches(r, s.EmptyCardTombstoneAgents),
+	}
 
 	if logger.GetLevel() == logger.TRACE {
 		if ua := r.Header.Get("User-Agent"); ua != "" {
@@ -81,23 +131,23 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case "OPTIONS":
-		s.handleOptions(w, r)
+		req.handleOptions(w, r)
 	case "PROPFIND":
-		s.handlePropfind(w, r)
+		req.handlePropfind(w, r)
 	case "REPORT":
-		s.handleReport(w, r)
+		req.handleReport(w, r)
 	case "GET", "HEAD":
-		s.handleGet(w, r)
+		req.handleGet(w, r)
 	case "PUT":
-		s.handlePut(w, r)
+		req.handlePut(w, r)
 	case "DELETE":
-		s.handleDelete(w, r)
+		req.handleDelete(w, r)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
-func (s *Server) handleOptions(w http.ResponseWriter, r *http.Request) {
+func (s *request) handleOptions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("DAV", "1, 2, 3, addressbook")
 
 	methods := []string{"OPTIONS", "GET", "HEAD", "PROPFIND", "REPORT"}
@@ -120,28 +170,29 @@ func (s *Server) handleOptions(w http.ResponseWriter, r *http.Request) {
 //		GetETag
 // ========================================
 
-func (s *Server) handlePropfind(w http.ResponseWriter, r *http.Request) {
+func (s *request) handlePropfind(w http.ResponseWriter, r *http.Request) {
 	depth := r.Header.Get("Depth")
 	if depth == "" {
 		depth = "0"
 	}
 
-	// Parse XML to determine what client is asking for
+	// Parse XML to determine what client is asking for. An empty body is an allprop request
+	// (RFC 4918 9.1), which is answered by path
 	var propfindReq Propfind
-	if err := xml.NewDecoder(r.Body).Decode(&propfindReq); err != nil {
+	if err := xml.NewDecoder(r.Body).Decode(&propfindReq); err != nil && err != io.EOF {
 		logger.Error("[CARDDAV] [PROPFIND] XML parse error: %v", err)
 		http.Error(w, "Invalid XML", http.StatusBadRequest)
 		return
 	}
 
-	// Route based on WHAT PROPERTIES ARE REQUESTED, not the path
+	// Discovery properties are answered the same wherever the client asks for them
 	if propfindReq.Prop != nil {
 		prop := propfindReq.Prop
 
 		// Is client asking for principal properties?
 		if prop.CurrentUserPrincipal != nil || prop.PrincipalURL != nil {
 			logger.Debug("[CARDDAV] [PROPFIND] -> Principal discovery")
-			s.respondPrincipal(w, r)
+			s.respondPrincipal(w, r, depth)
 			return
 		}
 
@@ -151,44 +202,65 @@ func (s *Server) handlePropfind(w http.ResponseWriter, r *http.Request) {
 			s.respondAddressbookHome(w, r)
 			return
 		}
+	}
 
-		// Is client asking for collection properties (sync-token, resourcetype with addressbook)?
-		if prop.SyncToken != nil || prop.ResourceType != nil {
-			// Check path to determine if it's the collection or a contact
-			if strings.HasSuffix(r.URL.Path, ".vcf") || strings.HasSuffix(r.URL.Path, ".vcf/") {
-				logger.Debug("[CARDDAV] [PROPFIND] -> Individual contact")
-				s.respondContact(w, r)
-			} else {
-				logger.Debug("[CARDDAV] [PROPFIND] -> Address book collection")
-				s.respondCollection(w, r, depth)
-			}
-			return
-		}
+	// Everything else (resourcetype, sync-token, getetag, allprop) describes the resource at the path
+	switch kind := classifyPath(r.URL.Path); kind {
+	case resourceContact:
+		logger.Debug("[CARDDAV] [PROPFIND] -> Individual contact")
+		s.respondContact(w, r)
+	case resourceCollection:
+		logger.Debug("[CARDDAV] [PROPFIND] -> Address book collection")
+		s.respondCollection(w, r, depth)
+	case resourceRoot, resourcePrincipal:
+		logger.Debug("[CARDDAV] [PROPFIND] -> Principal")
+		s.respondPrincipal(w, r, depth)
+	default:
+		logger.Debug("[CARDDAV] [PROPFIND] -> Unknown path %s", r.URL.Path)
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
 
-		// Is client asking for etag on collection (list contacts)?
-		if prop.GetETag != nil {
-			logger.Debug("[CARDDAV] [PROPFIND] -> ETags (contact list)")
-			s.respondCollection(w, r, depth)
-			return
+// resourceKind is the kind of CardDAV resource a request path names
+type resourceKind int
+
+const (
+	resourceUnknown    resourceKind = iota
+	resourceRoot                    // /carddav/
+	resourcePrincipal               // /carddav/{user}/
+	resourceCollection              // /carddav/{user}/contacts/
+	resourceContact                 // /carddav/{user}/contacts/{uid}.vcf
+)
+
+// classifyPath works out which resource a path names from its shape, so a missing or doubled
+// trailing slash doesn't change the answer. The {user} segment isn't checked: every path is served
+// for the authenticated user
+func classifyPath(path string) resourceKind {
+	var segments []string
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/carddav"), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
 		}
 	}
 
-	// Fallback: determine by path
-	logger.Debug("[CARDDAV] [PROPFIND] -> Fallback to path routing")
-	if strings.HasSuffix(r.URL.Path, ".vcf") || strings.HasSuffix(r.URL.Path, ".vcf/") {
-		s.respondContact(w, r)
-	} else if strings.Contains(r.URL.Path, "/contacts") {
-		s.respondCollection(w, r, depth)
-	} else {
-		s.respondPrincipal(w, r)
+	switch {
+	case len(segments) == 0:
+		return resourceRoot
+	case len(segments) == 1:
+		return resourcePrincipal
+	case len(segments) == 2 && segments[1] == "contacts":
+		return resourceCollection
+	case len(segments) == 3 && segments[1] == "contacts" && strings.HasSuffix(segments[2], ".vcf"):
+		return resourceContact
 	}
+	return resourceUnknown
 }
 
 // ========================================
 // REPORT Handler - Routes based on XML ROOT ELEMENT
 // ========================================
 
-func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+func (s *request) handleReport(w http.ResponseWriter, r *http.Request) {
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, "Error reading body", http.StatusBadRequest)
@@ -257,19 +329,25 @@ func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
 // GET, HEAD, PUT, DELETE - Still use path (no XML to parse)
 // ========================================
 
-func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+func (s *request) handleGet(w http.ResponseWriter, r *http.Request) {
 	uid := extractUIDFromPath(r.URL.Path)
 
+	var card vcard.Card
 	contact, err := s.db.GetContactByUID(s.userID, uid, true)
-	if err != nil {
+	if err == nil {
+		labelMap, err := s.db.GetLabelTypesMap()
+		if err != nil {
+			http.Error(w, "Error loading contact labels", http.StatusInternalServerError)
+			return
+		}
+		card = s.contactToVCard(contact, labelMap, false)
+	} else if contact, err = s.emptyCardTombstone(uid); err == nil {
+		card = tombstoneVCard(contact.UID)
+	} else {
 		http.Error(w, "Contact not found", http.StatusNotFound)
 		return
 	}
 
-	labelMap, _ := s.db.GetLabelMap()
-
-	card := converter.ContactToVCard(contact, labelMap, false)
-
 	var buf bytes.Buffer
 	encoder := vcard.NewEncoder(&buf)
 	if err := encoder.Encode(card); err != nil {
@@ -294,7 +372,7 @@ func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
 // PUT Handler
 // ========================================
 
-func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
+func (s *request) handlePut(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, "Error reading body", http.StatusBadRequest)
@@ -312,22 +390,25 @@ func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
 	allRelTypes, _ := s.db.GetRelationshipTypes()
 	revMap, _ := s.db.GetLabelReverseMap()
 
-	missingLabels := converter.FindNewLabels(card, revMap)
-	for category, names := range missingLabels {
-		for _, name := range names {
-			newID, err := s.db.NewLabel(name, category)
-			if err != nil {
-				continue
-			}
-			// Update the map locally so the converter can find it immediately
-			revMap[category+":"+name] = newID
-		}
-	}
+	importOpts := converter.DefaultImportOptions()
+	importOpts.CreateLabels = true
+	importOpts.UIDDomain = s.UIDDomain
 
-	contact, _ := converter.VCardToContact(card, allContacts, allRelTypes, revMap)
+	contact, _ := converter.VCardToContact(card, allContacts, allRelTypes, revMap, importOpts)
 	uid := extractUIDFromPath(r.URL.Path)
 	contact.UID = uid
 
+	if strings.TrimSpace(contact.FullName) == "" {
+		ua := r.Header.Get("User-Agent")
+		if s.RejectNamelessContacts {
+			logger.Warn("[CARDDAV] Rejecting PUT of %s with no FN or N (User-Agent: %s)", uid, ua)
+			http.Error(w, "vCard must include FN or N", http.StatusBadRequest)
+			return
+		}
+		contact.FullName = placeholderName(contact)
+		logger.Warn("[CARDDAV] PUT of %s has no FN or N; named it %q (User-Agent: %s)", uid, contact.FullName, ua)
+	}
+
 	//Debug output vcard
 	if logger.GetLevel() == logger.TRACE {
 		logger.Trace("[CARDDAV] Client->Server vCard PUT Body:")
@@ -338,9 +419,20 @@ func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
 
 	// Check if contact exists
 	existing, err := s.db.GetContactByUID(s.userID, uid, true)
+
+	// A nickname sent as FN is our own display substitution echoed back; keep the real full name
+	if s.preferNickname && contact.Nickname != "" && contact.FullName == contact.Nickname {
+		if err == nil {
+			contact.FullName = existing.FullName
+		} else {
+			contact.FullName = contact.GenerateFullName()
+		}
+	}
+
 	if err == nil {
-		// Update existing
+		// Update existing; the vCard has nothing to say about event reminders
 		contact.ID = existing.ID
+		contact.ExcludeFromEvents = existing.ExcludeFromEvents
 		if err := s.db.UpdateContact(s.userID, contact); err != nil {
 			http.Error(w, "Error updating contact", http.StatusInternalServerError)
 			return
@@ -349,6 +441,7 @@ func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	} else {
 		// Create new
+		converter.ApplyNewContactDefaults(contact)
 		if err := s.db.CreateContact(s.userID, contact); err != nil {
 			http.Error(w, "Error creating contact", http.StatusInternalServerError)
 			return
@@ -358,11 +451,38 @@ func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// placeholderName picks a name for a contact sent without one: its organization, then its first
+// email or phone, then a fixed placeholder
+func placeholderName(contact *models.Contact) string {
+	for _, org := range contact.Organizations {
+		if name := strings.TrimSpace(org.Name); name != "" {
+			return name
+		}
+	}
+	for _, email := range contact.Emails {
+		if address := strings.TrimSpace(email.Email); address != "" {
+			return address
+		}
+	}
+	for _, phone := range contact.Phones {
+		if number := strings.TrimSpace(phone.Phone); number != "" {
+			return number
+		}
+	}
+	return namelessPlaceholder
+}
+
+// contactToVCard converts a contact for the client, using the nickname as FN when the user
+// prefers it. The stored full name is untouched
+func (s *request) contactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabelType, isAppleClient bool) vcard.Card {
+	return converter.ContactToVCard(converter.NicknameAsFullName(contact, s.preferNickname), labelMap, isAppleClient)
+}
+
 // ========================================
 // DELETE Handler
 // ========================================
 
-func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+func (s *request) handleDelete(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 	parts := strings.Split(strings.TrimSuffix(path, ".vcf"), "/")
 	uid := parts[len(parts)-1]
@@ -385,7 +505,7 @@ func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
 // Response Generators
 // ========================================
 
-func (s *Server) respondPrincipal(w http.ResponseWriter, r *http.Request) {
+func (s *request) respondPrincipal(w http.ResponseWriter, r *http.Request, depth string) {
 	principalPath := fmt.Sprintf("/carddav/%s/", s.userPrincipal)
 	principalURL := s.baseURL + principalPath
 
@@ -407,7 +527,7 @@ func (s *Server) respondPrincipal(w http.ResponseWriter, r *http.Request) {
 								Href: principalURL,
 							},
 							DisplayName: &DisplayName{
-								Value: s.userPrincipal,
+								Value: s.principalName,
 							},
 						},
 						Status: "HTTP/1.1 200 OK",
@@ -417,10 +537,28 @@ func (s *Server) respondPrincipal(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	// The address book is the principal's only child
+	if depth == "1" {
+		response.Responses = append(response.Responses, s.collectionResponse())
+	}
+
 	s.writeXMLResponse(w, response)
 }
 
-func (s *Server) respondAddressbookHome(w http.ResponseWriter, r *http.Request) {
+// principalDisplayName is the user's configured CardDAV display name, falling back to their email.
+// Some clients fail discovery on an empty displayname, so it is never blank; XML escaping is left
+// to the encoder
+func principalDisplayName(user *models.User) string {
+	if name := strings.TrimSpace(user.CardDAVDisplayName); name != "" {
+		return name
+	}
+	if email := strings.TrimSpace(user.Email); email != "" {
+		return email
+	}
+	return "KindredCard"
+}
+
+func (s *request) respondAddressbookHome(w http.ResponseWriter, r *http.Request) {
 	principalPath := fmt.Sprintf("/carddav/%s/", s.userPrincipal)
 	contactsPath := principalPath + "contacts/"
 
@@ -451,37 +589,40 @@ func (s *Server) respondAddressbookHome(w http.ResponseWriter, r *http.Request)
 	s.writeXMLResponse(w, response)
 }
 
-func (s *Server) respondCollection(w http.ResponseWriter, r *http.Request, depth string) {
+// collectionResponse describes the address book collection itself
+func (s *request) collectionResponse() Response {
 	collectionPath := fmt.Sprintf("/carddav/%s/contacts/", s.userPrincipal)
 	currentToken, _ := s.db.GetAddressBookSyncToken(s.userID)
 	tokenStr := strconv.Itoa(currentToken)
 
-	responses := []Response{
-		{
-			Href: s.baseURL + collectionPath,
-			Propstat: []Propstat{
-				{
-					Prop: PropData{
-						ResourceType: &ResourceType{
-							Collection:  &Collection{},
-							AddressBook: &AddressBook{},
-						},
-						DisplayName: &DisplayName{
-							Value: "Contacts",
-						},
-						SyncToken: &SyncToken{
-							//Value: fmt.Sprintf("%s%stoken/%d", s.baseURL, collectionPath, currentToken),
-							Value: tokenStr,
-						},
-						GetCTag: &GetCTag{
-							Value: tokenStr,
-						},
+	return Response{
+		Href: s.baseURL + collectionPath,
+		Propstat: []Propstat{
+			{
+				Prop: PropData{
+					ResourceType: &ResourceType{
+						Collection:  &Collection{},
+						AddressBook: &AddressBook{},
+					},
+					DisplayName: &DisplayName{
+						Value: "Contacts",
+					},
+					SyncToken: &SyncToken{
+						Value: s.formatSyncToken(collectionPath, currentToken),
+					},
+					GetCTag: &GetCTag{
+						Value: tokenStr,
 					},
-					Status: "HTTP/1.1 200 OK",
 				},
+				Status: "HTTP/1.1 200 OK",
 			},
 		},
 	}
+}
+
+func (s *request) respondCollection(w http.ResponseWriter, r *http.Request, depth string) {
+	collectionPath := fmt.Sprintf("/carddav/%s/contacts/", s.userPrincipal)
+	responses := []Response{s.collectionResponse()}
 
 	// If depth is 1, include all contacts
 	if depth == "1" {
@@ -508,7 +649,7 @@ func (s *Server) respondCollection(w http.ResponseWriter, r *http.Request, depth
 	s.writeXMLResponse(w, Multistatus{Responses: responses})
 }
 
-func (s *Server) respondContact(w http.ResponseWriter, r *http.Request) {
+func (s *request) respondContact(w http.ResponseWriter, r *http.Request) {
 	uid := extractUIDFromPath(r.URL.Path)
 
 	contact, err := s.db.GetContactByUID(s.userID, uid, true)
@@ -540,7 +681,7 @@ func (s *Server) respondContact(w http.ResponseWriter, r *http.Request) {
 	s.writeXMLResponse(w, response)
 }
 
-func (s *Server) respondSyncCollection(w http.ResponseWriter, req SyncCollection) {
+func (s *request) respondSyncCollection(w http.ResponseWriter, req SyncCollection) {
 	currentToken, _ := s.db.GetAddressBookSyncToken(s.userID)
 
 	// Extract client token from parsed XML (not from URL string!)
@@ -556,14 +697,15 @@ func (s *Server) respondSyncCollection(w http.ResponseWriter, req SyncCollection
 		hrefURL := s.baseURL + collectionPath + contact.UID + ".vcf"
 		lastModStr := formatUnixTimestamp(contact.LastModifiedToken)
 
-		if contact.DeletedAt != nil {
+		if contact.DeletedAt != nil && !s.useEmptyCardTombstones {
 			// Deleted contact
 			responses = append(responses, Response{
 				Href:   hrefURL,
 				Status: "HTTP/1.1 404 Not Found",
 			})
 		} else {
-			// Active or modified contact
+			// Active or modified contact; with empty-card tombstones a deleted contact is
+			// reported the same way and GET/multiget serve it as an empty vCard
 			responses = append(responses, Response{
 				Href: hrefURL,
 				Propstat: []Propstat{
@@ -579,20 +721,21 @@ func (s *Server) respondSyncCollection(w http.ResponseWriter, req SyncCollection
 		}
 	}
 
-	// nextTokenURL := fmt.Sprintf("%s%stoken/%d", s.baseURL, collectionPath, currentToken)
-	tokenStr := strconv.Itoa(currentToken)
-
 	s.writeXMLResponse(w, Multistatus{
 		Responses: responses,
-		SyncToken: tokenStr,
+		SyncToken: s.formatSyncToken(collectionPath, currentToken),
 	})
 }
 
-func (s *Server) respondAddressbookMultiget(w http.ResponseWriter, req AddressBookMultiget, isAppleClient bool) {
+func (s *request) respondAddressbookMultiget(w http.ResponseWriter, req AddressBookMultiget, isAppleClient bool) {
 	collectionPath := fmt.Sprintf("/carddav/%s/contacts/", s.userPrincipal)
 	wantsAddressData := req.Prop.AddressData != nil
 
-	labelMap, _ := s.db.GetLabelMap()
+	labelMap, err := s.db.GetLabelTypesMap()
+	if err != nil {
+		http.Error(w, "Error loading contact labels", http.StatusInternalServerError)
+		return
+	}
 
 	responses := []Response{}
 
@@ -600,9 +743,13 @@ func (s *Server) respondAddressbookMultiget(w http.ResponseWriter, req AddressBo
 	for _, href := range req.Hrefs {
 		uid := extractUIDFromHref(href.Value)
 
+		deleted := false
 		contact, err := s.db.GetContactByUID(s.userID, uid, true)
 		if err != nil {
-			continue
+			if contact, err = s.emptyCardTombstone(uid); err != nil {
+				continue
+			}
+			deleted = true
 		}
 
 		propData := PropData{
@@ -611,7 +758,10 @@ func (s *Server) respondAddressbookMultiget(w http.ResponseWriter, req AddressBo
 
 		// Include vCard data if requested (determined by XML property presence!)
 		if wantsAddressData {
-			card := converter.ContactToVCard(contact, labelMap, isAppleClient)
+			card := tombstoneVCard(contact.UID)
+			if !deleted {
+				card = s.contactToVCard(contact, labelMap, isAppleClient)
+			}
 			var buf bytes.Buffer
 			encoder := vcard.NewEncoder(&buf)
 			encoder.Encode(card)
@@ -643,11 +793,22 @@ func (s *Server) respondAddressbookMultiget(w http.ResponseWriter, req AddressBo
 	s.writeXMLResponse(w, Multistatus{Responses: responses})
 }
 
-func (s *Server) respondAddressbookQuery(w http.ResponseWriter, req AddressBookQuery) {
-	// For now, return all contacts (filtering can be added based on req.Filter)
-	contacts, _ := s.db.GetAllContacts(s.userID, false)
+func (s *request) respondAddressbookQuery(w http.ResponseWriter, req AddressBookQuery) {
+	if unsupported := unsupportedPropFilters(req.Filter); len(unsupported) > 0 {
+		s.writeXMLError(w, http.StatusForbidden, Error{
+			SupportedFilter: &SupportedFilter{PropFilters: unsupported},
+		})
+		return
+	}
 
-	labelMap, _ := s.db.GetLabelMap()
+	contacts, _ := s.db.GetAllContacts(s.userID, true)
+	contacts = applyAddressbookFilter(contacts, req.Filter)
+
+	labelMap, err := s.db.GetLabelTypesMap()
+	if err != nil {
+		http.Error(w, "Error loading contact labels", http.StatusInternalServerError)
+		return
+	}
 
 	collectionPath := fmt.Sprintf("/carddav/%s/contacts/", s.userPrincipal)
 	wantsAddressData := req.Prop.AddressData != nil
@@ -660,7 +821,7 @@ func (s *Server) respondAddressbookQuery(w http.ResponseWriter, req AddressBookQ
 		}
 
 		if wantsAddressData {
-			card := converter.ContactToVCard(contact, labelMap, false)
+			card := s.contactToVCard(contact, labelMap, false)
 			var buf bytes.Buffer
 			encoder := vcard.NewEncoder(&buf)
 			encoder.Encode(card)
@@ -688,7 +849,7 @@ func (s *Server) respondAddressbookQuery(w http.ResponseWriter, req AddressBookQ
 // Helper Functions
 // ========================================
 
-func (s *Server) writeXMLResponse(w http.ResponseWriter, v interface{}) {
+func (s *request) writeXMLResponse(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
 	w.WriteHeader(http.StatusMultiStatus)
 
@@ -701,6 +862,20 @@ func (s *Server) writeXMLResponse(w http.ResponseWriter, v interface{}) {
 	}
 }
 
+// writeXMLError answers with status and a DAV:error body
+func (s *request) writeXMLError(w http.ResponseWriter, status int, davErr Error) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+
+	w.Write([]byte(xml.Header))
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(davErr); err != nil {
+		logger.Error("[CARDDAV] XML Encoding error: %v", err)
+	}
+}
+
 func extractUIDFromPath(path string) string {
 	parts := strings.Split(path, "/")
 	for i := len(parts) - 1; i >= 0; i-- {
@@ -711,7 +886,10 @@ func extractUIDFromPath(path string) string {
 	return ""
 }
 
+// extractTokenFromURL returns the numeric portion of a sync-token, accepting
+// both the bare integer form ("42") and the URL form (".../token/42")
 func extractTokenFromURL(tokenURL string) string {
+	tokenURL = strings.TrimSuffix(strings.TrimSpace(tokenURL), "/")
 	parts := strings.Split(tokenURL, "/")
 	if len(parts) > 0 {
 		return parts[len(parts)-1]
@@ -719,6 +897,49 @@ func extractTokenFromURL(tokenURL string) string {
 	return ""
 }
 
+// formatSyncToken renders the sync-token in the format the current client expects
+func (s *request) formatSyncToken(collectionPath string, token int) string {
+	if s.useURLTokens {
+		return fmt.Sprintf("%s%stoken/%d", s.baseURL, collectionPath, token)
+	}
+	return strconv.Itoa(token)
+}
+
+// emptyCardTombstone looks up a deleted contact to serve as an empty card, when this client gets
+// empty-card tombstones
+func (s *request) emptyCardTombstone(uid string) (*models.Contact, error) {
+	if !s.useEmptyCardTombstones {
+		return nil, errors.New("not found")
+	}
+	return s.db.GetSyncTombstoneByUID(s.userID, uid)
+}
+
+// tombstoneVCard is the empty card served for a deleted contact: just its UID and a blank name
+func tombstoneVCard(uid string) vcard.Card {
+	card := vcard.Card{}
+	card.SetValue(vcard.FieldVersion, "3.0")
+	card.SetValue(vcard.FieldUID, uid)
+	card.SetValue(vcard.FieldFormattedName, "")
+	card.SetValue(vcard.FieldName, ";;;;")
+	return card
+}
+
+// userAgentMatches reports whether the request's User-Agent contains any of agents (case-insensitive)
+func userAgentMatches(r *http.Request, agents []string) bool {
+	upperUA := strings.ToUpper(r.Header.Get("User-Agent"))
+	if upperUA == "" {
+		return false
+	}
+
+	for _, agent := range agents {
+		agent = strings.TrimSpace(agent)
+		if agent != "" && strings.Contains(upperUA, strings.ToUpper(agent)) {
+			return true
+		}
+	}
+	return false
+}
+
 func extractUIDFromHref(href string) string {
 	// URL decode first
 	href = strings.ReplaceAll(href, "%40", "@")
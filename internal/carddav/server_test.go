@@ -0,0 +1,592 @@
+package carddav
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/middleware"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// serve sends a CardDAV request as user to s
+func serve(s *Server, user *models.User, method string, path string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, user))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+// multistatusHrefs returns the hrefs of a multistatus response, in order
+func multistatusHrefs(t *testing.T, body []byte) []string {
+	t.Helper()
+
+	var ms struct {
+		Responses []struct {
+			Href string `xml:"href"`
+		} `xml:"response"`
+	}
+	if err := xml.Unmarshal(body, &ms); err != nil {
+		t.Fatalf("decoding multistatus: %v\n%s", err, body)
+	}
+	hrefs := make([]string, len(ms.Responses))
+	for i, resp := range ms.Responses {
+		hrefs[i] = resp.Href
+	}
+	return hrefs
+}
+
+func TestSyncTokenRoundTrip(t *testing.T) {
+	const collectionPath = "/carddav/user@example.com/contacts/"
+
+	tests := []struct {
+		name         string
+		useURLTokens bool
+		want         string
+	}{
+		{"integer", false, "42"},
+		{"url", true, "https://dav.example.com/carddav/user@example.com/contacts/token/42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &request{Server: &Server{}, baseURL: "https://dav.example.com", useURLTokens: tt.useURLTokens}
+
+			token := s.formatSyncToken(collectionPath, 42)
+			if token != tt.want {
+				t.Fatalf("formatSyncToken = %q, want %q", token, tt.want)
+			}
+			if got := extractTokenFromURL(token); got != strconv.Itoa(42) {
+				t.Errorf("extractTokenFromURL(%q) = %q, want 42", token, got)
+			}
+		})
+	}
+}
+
+func TestExtractTokenFromURLAcceptsEitherForm(t *testing.T) {
+	for _, token := range []string{"17", " 17 ", "https://dav.example.com/carddav/u/contacts/token/17", "https://dav.example.com/carddav/u/contacts/token/17/"} {
+		if got := extractTokenFromURL(token); got != "17" {
+			t.Errorf("extractTokenFromURL(%q) = %q, want 17", token, got)
+		}
+	}
+}
+
+func TestUserAgentMatches(t *testing.T) {
+	agents := []string{"DAVx5", " Thunderbird "}
+
+	tests := []struct {
+		userAgent string
+		want      bool
+	}{
+		{"DAVx5/4.3 (dav4jvm; okhttp/4.12.0) Android/14", true},
+		{"Mozilla/5.0 Thunderbird/115.0", true},
+		{"iOS/17.0 (21A329) dataaccessd/1.0", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("PROPFIND", "/carddav/", nil)
+		r.Header.Set("User-Agent", tt.userAgent)
+		if got := userAgentMatches(r, agents); got != tt.want {
+			t.Errorf("userAgentMatches(%q) = %v, want %v", tt.userAgent, got, tt.want)
+		}
+	}
+}
+
+// addressbookQuery is an addressbook-query REPORT body with the given prop-filters
+func addressbookQuery(propFilters string) string {
+	return `<?xml version="1.0" encoding="utf-8"?>
+<C:addressbook-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:carddav">
+  <D:prop><D:getetag/></D:prop>
+  <C:filter>` + propFilters + `</C:filter>
+</C:addressbook-query>`
+}
+
+func TestAddressbookQueryFiltersFN(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice Liddell"})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob Smith"})
+	malice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Malice Aforethought"})
+
+	s := NewServer(database, false)
+	w := serve(s, user, "REPORT", "/carddav/"+user.Email+"/contacts/", addressbookQuery(
+		`<C:prop-filter name="FN"><C:text-match collation="i;unicode-casemap" match-type="contains">ALICE</C:text-match></C:prop-filter>`))
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	hrefs := multistatusHrefs(t, w.Body.Bytes())
+	if len(hrefs) != 2 {
+		t.Fatalf("hrefs = %v, want the two contacts whose FN contains alice", hrefs)
+	}
+	for _, c := range []*models.Contact{alice, malice} {
+		if !slices.ContainsFunc(hrefs, func(h string) bool { return strings.HasSuffix(h, "/"+c.UID+".vcf") }) {
+			t.Errorf("hrefs %v are missing %s", hrefs, c.FullName)
+		}
+	}
+}
+
+func TestAddressbookQueryRejectsUnsupportedFilter(t *testing.T) {
+	s := NewServer(nil, false)
+	user := &models.User{ID: 1, Email: "user@example.com"}
+
+	w := serve(s, user, "REPORT", "/carddav/user@example.com/contacts/", addressbookQuery(
+		`<C:prop-filter name="X-FOO"><C:text-match>bar</C:text-match></C:prop-filter>`))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	var davErr Error
+	if err := xml.Unmarshal(w.Body.Bytes(), &davErr); err != nil {
+		t.Fatalf("decoding DAV:error: %v\n%s", err, w.Body.String())
+	}
+	if davErr.SupportedFilter == nil || len(davErr.SupportedFilter.PropFilters) != 1 || davErr.SupportedFilter.PropFilters[0].Name != "X-FOO" {
+		t.Errorf("error = %+v, want a supported-filter precondition naming X-FOO", davErr)
+	}
+}
+
+func TestContactToVCardPrefersNickname(t *testing.T) {
+	contact := &models.Contact{UID: "nickname-test", FullName: "John Doe", Nickname: "Johnny"}
+
+	s := &request{Server: &Server{}, preferNickname: true}
+	if fn := s.contactToVCard(contact, nil, false).Value(vcard.FieldFormattedName); fn != "Johnny" {
+		t.Errorf("FN = %q, want the nickname Johnny", fn)
+	}
+	if contact.FullName != "John Doe" {
+		t.Errorf("stored full name changed to %q", contact.FullName)
+	}
+
+	s.preferNickname = false
+	if fn := s.contactToVCard(contact, nil, false).Value(vcard.FieldFormattedName); fn != "John Doe" {
+		t.Errorf("FN without the preference = %q, want John Doe", fn)
+	}
+}
+
+func TestPutWithoutName(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	// UIDs are unique across users, so each run uses its own
+	uidFor := func(name string) string { return name + "-" + strconv.Itoa(user.ID) }
+	put := func(s *Server, uid string, lines ...string) *httptest.ResponseRecorder {
+		body := "BEGIN:VCARD\r\nVERSION:3.0\r\nUID:" + uid + "\r\n" + strings.Join(lines, "\r\n")
+		if len(lines) > 0 {
+			body += "\r\n"
+		}
+		return serve(s, user, http.MethodPut, "/carddav/"+user.Email+"/contacts/"+uid+".vcf", body+"END:VCARD\r\n")
+	}
+	stored := func(uid string) *models.Contact {
+		contact, err := database.GetContactByUID(user.ID, uid, false)
+		if err != nil {
+			return nil
+		}
+		return contact
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		s := NewServer(database, false)
+		s.RejectNamelessContacts = true
+
+		if w := put(s, uidFor("nameless-reject"), "EMAIL:alice@example.com"); w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+		if stored(uidFor("nameless-reject")) != nil {
+			t.Error("rejected contact was stored")
+		}
+	})
+
+	t.Run("placeholder", func(t *testing.T) {
+		s := NewServer(database, false)
+
+		if w := put(s, uidFor("nameless-email"), "EMAIL:alice@example.com"); w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+		}
+		if c := stored(uidFor("nameless-email")); c == nil || c.FullName != "alice@example.com" {
+			t.Errorf("stored %+v, want it named after its email", c)
+		}
+
+		if w := put(s, uidFor("nameless-empty")); w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+		}
+		if c := stored(uidFor("nameless-empty")); c == nil || c.FullName != namelessPlaceholder {
+			t.Errorf("stored %+v, want it named %q", c, namelessPlaceholder)
+		}
+	})
+}
+
+func TestPrincipalDisplayName(t *testing.T) {
+	tests := []struct {
+		name string
+		user models.User
+		want string
+	}{
+		{"configured", models.User{Email: "alice+work@example.com", CardDAVDisplayName: " Alice "}, "Alice"},
+		{"email", models.User{Email: "alice+work@example.com"}, "alice+work@example.com"},
+		{"blank", models.User{}, "KindredCard"},
+	}
+	for _, tt := range tests {
+		if got := principalDisplayName(&tt.user); got != tt.want {
+			t.Errorf("%s: principalDisplayName = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPropfindPrincipalDisplayNameIsValidXML(t *testing.T) {
+	s := NewServer(nil, false)
+
+	for _, displayName := range []string{"", "Alice & Bob <Home>"} {
+		user := &models.User{ID: 1, Email: "alice+work@example.com", CardDAVDisplayName: displayName}
+		r := httptest.NewRequest("PROPFIND", "/carddav/"+user.Email+"/", strings.NewReader(`<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:"><D:prop><D:displayname/></D:prop></D:propfind>`))
+		r.Header.Set("Depth", "0")
+		r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, user))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+		}
+
+		var ms struct {
+			DisplayNames []string `xml:"response>propstat>prop>displayname"`
+		}
+		if err := xml.Unmarshal(w.Body.Bytes(), &ms); err != nil {
+			t.Fatalf("response isn't valid XML: %v\n%s", err, w.Body.String())
+		}
+		want := principalDisplayName(user)
+		if len(ms.DisplayNames) != 1 || ms.DisplayNames[0] != want {
+			t.Errorf("displayname = %q, want %q", ms.DisplayNames, want)
+		}
+	}
+}
+
+func TestArchivedContactsHiddenFromCardDAV(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	if err := database.SetContactArchived(user.ID, bob.ID, true); err != nil {
+		t.Fatalf("SetContactArchived: %v", err)
+	}
+
+	s := NewServer(database, false)
+	collection := "/carddav/" + user.Email + "/contacts/"
+
+	r := httptest.NewRequest("PROPFIND", collection, nil)
+	r.Header.Set("Depth", "1")
+	r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, user))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	hrefs := multistatusHrefs(t, w.Body.Bytes())
+	if !slices.ContainsFunc(hrefs, func(h string) bool { return strings.HasSuffix(h, "/"+alice.UID+".vcf") }) {
+		t.Errorf("PROPFIND hrefs %v are missing Alice", hrefs)
+	}
+	if slices.ContainsFunc(hrefs, func(h string) bool { return strings.HasSuffix(h, "/"+bob.UID+".vcf") }) {
+		t.Errorf("PROPFIND hrefs %v include archived Bob", hrefs)
+	}
+
+	if w := serve(s, user, http.MethodGet, collection+bob.UID+".vcf", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET archived contact: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// Clients that already had Bob see him removed on their next sync
+	changed, err := database.ListContactsChangedSince(user.ID, 0, true)
+	if err != nil {
+		t.Fatalf("ListContactsChangedSince: %v", err)
+	}
+	for _, c := range changed {
+		if c.UID == bob.UID && c.DeletedAt == nil {
+			t.Error("archived contact is synced as a live contact")
+		}
+	}
+}
+
+// syncStatuses runs an initial sync-collection REPORT as user with the given User-Agent and
+// returns each contact's status line, keyed by UID
+func syncStatuses(t *testing.T, s *Server, user *models.User, userAgent string) map[string]string {
+	t.Helper()
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<D:sync-collection xmlns:D="DAV:"><D:sync-token/><D:sync-level>1</D:sync-level><D:prop><D:getetag/></D:prop></D:sync-collection>`
+	r := httptest.NewRequest("REPORT", "/carddav/"+user.Email+"/contacts/", strings.NewReader(body))
+	r.Header.Set("User-Agent", userAgent)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, user))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	var ms struct {
+		Responses []struct {
+			Href     string `xml:"href"`
+			Status   string `xml:"status"`
+			Propstat []struct {
+				Status string `xml:"status"`
+			} `xml:"propstat"`
+		} `xml:"response"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &ms); err != nil {
+		t.Fatalf("decoding multistatus: %v\n%s", err, w.Body.String())
+	}
+	statuses := make(map[string]string)
+	for _, resp := range ms.Responses {
+		uid := strings.TrimSuffix(resp.Href[strings.LastIndex(resp.Href, "/")+1:], ".vcf")
+		status := resp.Status
+		if status == "" && len(resp.Propstat) > 0 {
+			status = resp.Propstat[0].Status
+		}
+		statuses[uid] = status
+	}
+	return statuses
+}
+
+func TestExcludedContactsSyncAsTombstones(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	home, err := database.GetLabelID("home", "email")
+	if err != nil {
+		t.Fatalf("GetLabelID: %v", err)
+	}
+	if _, err := database.CreateContactEmail(user.ID, models.Email{ContactID: alice.ID, Email: "alice@example.com", Type: home}); err != nil {
+		t.Fatalf("CreateContactEmail: %v", err)
+	}
+
+	s := NewServer(database, false)
+	if got := syncStatuses(t, s, user, "DAVx5/4.3")[bob.UID]; got != "HTTP/1.1 200 OK" {
+		t.Fatalf("Bob before exclusion: status %q, want 200", got)
+	}
+
+	if n, err := database.ExcludeContactsWithoutContactMethods(user.ID); err != nil || n != 1 {
+		t.Fatalf("ExcludeContactsWithoutContactMethods = %d, %v; want 1", n, err)
+	}
+
+	// Clients that already had Bob are told he is gone
+	statuses := syncStatuses(t, s, user, "DAVx5/4.3")
+	if got := statuses[alice.UID]; got != "HTTP/1.1 200 OK" {
+		t.Errorf("Alice: status %q, want 200", got)
+	}
+	if got := statuses[bob.UID]; got != "HTTP/1.1 404 Not Found" {
+		t.Errorf("excluded Bob: status %q, want 404", got)
+	}
+
+	// Clients that need an empty card for a tombstone can fetch one
+	empty := &Server{db: database, EmptyCardTombstones: true}
+	w := serve(empty, user, http.MethodGet, "/carddav/"+user.Email+"/contacts/"+bob.UID+".vcf", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET excluded Bob with empty-card tombstones: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	card, err := vcard.NewDecoder(w.Body).Decode()
+	if err != nil {
+		t.Fatalf("decoding tombstone: %v", err)
+	}
+	if card.Value(vcard.FieldUID) != bob.UID || card.Value(vcard.FieldFormattedName) != "" {
+		t.Errorf("tombstone UID %q and FN %q, want %s and an empty name", card.Value(vcard.FieldUID), card.Value(vcard.FieldFormattedName), bob.UID)
+	}
+}
+
+func TestSyncCollectionTombstoneStyles(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	if err := database.DeleteContact(user.ID, bob.ID); err != nil {
+		t.Fatalf("DeleteContact: %v", err)
+	}
+	bobPath := "/carddav/" + user.Email + "/contacts/" + bob.UID + ".vcf"
+
+	tests := []struct {
+		name       string
+		server     *Server
+		userAgent  string
+		emptyCards bool
+	}{
+		{"404 by default", NewServer(database, false), "DAVx5/4.3", false},
+		{"empty cards for everyone", &Server{db: database, EmptyCardTombstones: true}, "DAVx5/4.3", true},
+		{"empty cards for a matching agent", &Server{db: database, EmptyCardTombstoneAgents: []string{"evolution"}}, "Evolution/3.50", true},
+		{"404 for other agents", &Server{db: database, EmptyCardTombstoneAgents: []string{"evolution"}}, "DAVx5/4.3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := syncStatuses(t, tt.server, user, tt.userAgent)
+			if got := statuses[alice.UID]; got != "HTTP/1.1 200 OK" {
+				t.Errorf("Alice: status %q, want 200", got)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, bobPath, nil)
+			r.Header.Set("User-Agent", tt.userAgent)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, user))
+			w := httptest.NewRecorder()
+			tt.server.ServeHTTP(w, r)
+
+			if !tt.emptyCards {
+				if got := statuses[bob.UID]; got != "HTTP/1.1 404 Not Found" {
+					t.Errorf("deleted Bob: status %q, want 404", got)
+				}
+				if w.Code != http.StatusNotFound {
+					t.Errorf("GET deleted Bob: status = %d, want %d", w.Code, http.StatusNotFound)
+				}
+				return
+			}
+
+			if got := statuses[bob.UID]; got != "HTTP/1.1 200 OK" {
+				t.Errorf("deleted Bob: status %q, want an empty card (200)", got)
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET deleted Bob: status = %d, want %d", w.Code, http.StatusOK)
+			}
+			card, err := vcard.NewDecoder(w.Body).Decode()
+			if err != nil {
+				t.Fatalf("decoding tombstone: %v", err)
+			}
+			if card.Value(vcard.FieldUID) != bob.UID || card.Value(vcard.FieldFormattedName) != "" {
+				t.Errorf("tombstone UID %q and FN %q, want %s and an empty name", card.Value(vcard.FieldUID), card.Value(vcard.FieldFormattedName), bob.UID)
+			}
+		})
+	}
+}
+
+func TestClassifyPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want resourceKind
+	}{
+		{"/carddav", resourceRoot},
+		{"/carddav/", resourceRoot},
+		{"/carddav/alice@example.com", resourcePrincipal},
+		{"/carddav/alice@example.com/", resourcePrincipal},
+		{"/carddav/alice@example.com/contacts", resourceCollection},
+		{"/carddav/alice@example.com/contacts/", resourceCollection},
+		{"/carddav/alice@example.com//contacts//", resourceCollection},
+		{"/carddav/alice@example.com/contacts/abc-123.vcf", resourceContact},
+		{"/carddav/alice@example.com/contacts/abc-123", resourceUnknown},
+		{"/carddav/alice@example.com/calendars/", resourceUnknown},
+		{"/carddav/alice@example.com/contacts/abc-123.vcf/extra", resourceUnknown},
+	}
+	for _, tt := range tests {
+		if got := classifyPath(tt.path); got != tt.want {
+			t.Errorf("classifyPath(%q) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPropfindPathShapes(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+
+	s := NewServer(database, false)
+	principal := "/carddav/" + user.Email
+
+	type resourceType struct {
+		Collection  *struct{} `xml:"collection"`
+		Principal   *struct{} `xml:"principal"`
+		AddressBook *struct{} `xml:"addressbook"`
+	}
+	tests := []struct {
+		path          string
+		wantPrincipal bool
+		wantBook      bool
+		wantHref      string
+	}{
+		{"/carddav/", true, false, principal + "/"},
+		{"/carddav", true, false, principal + "/"},
+		{principal + "/", true, false, principal + "/"},
+		{principal, true, false, principal + "/"},
+		{principal + "/contacts/", false, true, principal + "/contacts/"},
+		{principal + "/contacts", false, true, principal + "/contacts/"},
+		{principal + "/contacts/" + alice.UID + ".vcf", false, false, principal + "/contacts/" + alice.UID + ".vcf"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("PROPFIND", tt.path, strings.NewReader(`<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:"><D:prop><D:resourcetype/></D:prop></D:propfind>`))
+		r.Header.Set("Depth", "0")
+		r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, user))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != http.StatusMultiStatus {
+			t.Errorf("%s: status = %d, want %d", tt.path, w.Code, http.StatusMultiStatus)
+			continue
+		}
+
+		var ms struct {
+			Responses []struct {
+				Href         string       `xml:"href"`
+				ResourceType resourceType `xml:"propstat>prop>resourcetype"`
+			} `xml:"response"`
+		}
+		if err := xml.Unmarshal(w.Body.Bytes(), &ms); err != nil {
+			t.Fatalf("%s: decoding multistatus: %v\n%s", tt.path, err, w.Body.String())
+		}
+		if len(ms.Responses) != 1 {
+			t.Errorf("%s: got %d responses, want 1", tt.path, len(ms.Responses))
+			continue
+		}
+		resp := ms.Responses[0]
+		if !strings.HasSuffix(resp.Href, tt.wantHref) {
+			t.Errorf("%s: href = %s, want %s", tt.path, resp.Href, tt.wantHref)
+		}
+		rt := resp.ResourceType
+		if (rt.Principal != nil) != tt.wantPrincipal || (rt.AddressBook != nil) != tt.wantBook {
+			t.Errorf("%s: resourcetype principal %v, addressbook %v; want %v, %v",
+				tt.path, rt.Principal != nil, rt.AddressBook != nil, tt.wantPrincipal, tt.wantBook)
+		}
+	}
+
+	if w := serve(s, user, "PROPFIND", principal+"/calendars/", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown path: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestPutPreservesUnknownProperties(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+	s := NewServer(database, false)
+
+	uid := "extras-" + strconv.Itoa(user.ID)
+	path := "/carddav/" + user.Email + "/contacts/" + uid + ".vcf"
+	extras := []string{
+		"X-CUSTOM-ID:crm-4821",
+		"X-MS-MANAGER;TYPE=WORK:The Queen",
+		"X-EVOLUTION-FILE-AS:Liddell\\, Alice",
+	}
+	body := "BEGIN:VCARD\r\nVERSION:3.0\r\nUID:" + uid + "\r\nFN:Alice Liddell\r\nN:Liddell;Alice;;;\r\n" +
+		strings.Join(extras, "\r\n") + "\r\nEND:VCARD\r\n"
+
+	if w := serve(s, user, http.MethodPut, path, body); w.Code != http.StatusCreated {
+		t.Fatalf("PUT: status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	w := serve(s, user, http.MethodGet, path, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: status = %d, body %s", w.Code, w.Body.String())
+	}
+	card, err := vcard.NewDecoder(w.Body).Decode()
+	if err != nil {
+		t.Fatalf("decoding vCard: %v", err)
+	}
+
+	sent, err := vcard.NewDecoder(strings.NewReader(body)).Decode()
+	if err != nil {
+		t.Fatalf("decoding sent vCard: %v", err)
+	}
+	for _, name := range []string{"X-CUSTOM-ID", "X-MS-MANAGER", "X-EVOLUTION-FILE-AS"} {
+		want, got := sent.Get(name), card.Get(name)
+		if got == nil {
+			t.Errorf("%s is missing from the GET response", name)
+			continue
+		}
+		if got.Value != want.Value || got.Params.Get(vcard.ParamType) != want.Params.Get(vcard.ParamType) {
+			t.Errorf("%s = %q (TYPE %q), want %q (TYPE %q)", name, got.Value, got.Params.Get(vcard.ParamType), want.Value, want.Params.Get(vcard.ParamType))
+		}
+	}
+}
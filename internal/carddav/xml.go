@@ -106,12 +106,36 @@ type SyncCollection struct {
 
 type Filter struct {
 	XMLName     xml.Name     `xml:"urn:ietf:params:xml:ns:carddav filter"`
+	Test        string       `xml:"test,attr,omitempty"` // "anyof" (default) or "allof"
 	PropFilters []PropFilter `xml:"prop-filter,omitempty"`
 }
 
 type PropFilter struct {
-	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:carddav prop-filter"`
-	Name    string   `xml:"name,attr"`
+	XMLName      xml.Name    `xml:"urn:ietf:params:xml:ns:carddav prop-filter"`
+	Name         string      `xml:"name,attr"`
+	Test         string      `xml:"test,attr,omitempty"` // "anyof" (default) or "allof"
+	IsNotDefined *struct{}   `xml:"is-not-defined,omitempty"`
+	TextMatches  []TextMatch `xml:"text-match,omitempty"`
+}
+
+// Error is a DAV:error body naming the precondition a request failed
+type Error struct {
+	XMLName         xml.Name         `xml:"DAV: error"`
+	SupportedFilter *SupportedFilter `xml:"urn:ietf:params:xml:ns:carddav supported-filter,omitempty"`
+}
+
+// SupportedFilter is the CARDDAV:supported-filter precondition, listing the prop-filters the
+// server can't evaluate
+type SupportedFilter struct {
+	PropFilters []PropFilter `xml:"urn:ietf:params:xml:ns:carddav prop-filter"`
+}
+
+type TextMatch struct {
+	XMLName         xml.Name `xml:"urn:ietf:params:xml:ns:carddav text-match"`
+	Collation       string   `xml:"collation,attr,omitempty"`
+	NegateCondition string   `xml:"negate-condition,attr,omitempty"` // "yes" or "no"
+	MatchType       string   `xml:"match-type,attr,omitempty"`       // equals, contains (default), starts-with, ends-with
+	Value           string   `xml:",chardata"`
 }
 
 // ========================================
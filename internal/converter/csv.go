@@ -0,0 +1,446 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package converter
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/logger"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+// CSVMultiValueSeparator joins multi-valued fields (e.g. several emails) into one CSV column
+const CSVMultiValueSeparator = "; "
+
+// CSVHeader is the header row of the flattened contacts CSV export
+var CSVHeader = []string{"full_name", "given_name", "family_name", "email", "phone", "birthday", "notes"}
+
+// ContactToCSVRecord flattens a contact into a CSV row matching CSVHeader.
+// Multi-valued fields list the primary value first, followed by the rest
+func ContactToCSVRecord(contact *models.Contact) []string {
+	emails := make([]string, 0, len(contact.Emails))
+	for _, e := range contact.Emails {
+		if e.IsPrimary {
+			emails = append([]string{e.Email}, emails...)
+		} else {
+			emails = append(emails, e.Email)
+		}
+	}
+
+	phones := make([]string, 0, len(contact.Phones))
+	for _, p := range contact.Phones {
+		if p.IsPrimary {
+			phones = append([]string{p.Phone}, phones...)
+		} else {
+			phones = append(phones, p.Phone)
+		}
+	}
+
+	return []string{
+		contact.FullName,
+		contact.GivenName,
+		contact.FamilyName,
+		strings.Join(emails, CSVMultiValueSeparator),
+		strings.Join(phones, CSVMultiValueSeparator),
+		formatCSVBirthday(contact),
+		contact.Notes,
+	}
+}
+
+// googleMultiValueSeparator separates multiple values inside one Google Contacts CSV cell
+const googleMultiValueSeparator = " ::: "
+
+// csvIndexedColumn matches Google's numbered columns, e.g. "E-mail 1 - Value" or "Address 2 - City"
+var csvIndexedColumn = regexp.MustCompile(`^(e-mail|phone|address|organization|website) (\d+) - (.+)$`)
+
+// csvColumnAliases maps single-valued CSV headers (lowercased) onto contact fields.
+// Covers both Google Contacts layouts and the KindredCard CSV export
+var csvColumnAliases = map[string]string{
+	"name":                    "full_name",
+	"full_name":               "full_name",
+	"given name":              "given_name",
+	"first name":              "given_name",
+	"given_name":              "given_name",
+	"additional name":         "middle_name",
+	"middle name":             "middle_name",
+	"family name":             "family_name",
+	"last name":               "family_name",
+	"family_name":             "family_name",
+	"name prefix":             "prefix",
+	"name suffix":             "suffix",
+	"nickname":                "nickname",
+	"maiden name":             "maiden_name",
+	"salutation":              "salutation",
+	"given name yomi":         "phonetic_first_name",
+	"phonetic first name":     "phonetic_first_name",
+	"additional name yomi":    "phonetic_middle_name",
+	"phonetic middle name":    "phonetic_middle_name",
+	"family name yomi":        "phonetic_last_name",
+	"phonetic last name":      "phonetic_last_name",
+	"gender":                  "gender",
+	"birthday":                "birthday",
+	"notes":                   "notes",
+	"email":                   "email",
+	"phone":                   "phone",
+	"organization name":       "org_name",
+	"organization title":      "org_title",
+	"organization department": "org_department",
+}
+
+// csvIndexedGroup collects the columns of one numbered Google group (e.g. all "Phone 2 - *" columns)
+type csvIndexedGroup struct {
+	kind    string
+	columns map[string]int // sub-field ("value", "type", "city", ...) -> column index
+}
+
+// csvLayout is the column mapping detected from a CSV header row
+type csvLayout struct {
+	fields map[string]int
+	groups []*csvIndexedGroup
+}
+
+// CSVImportResult is the outcome of parsing a contacts CSV
+type CSVImportResult struct {
+	Contacts []*models.Contact
+	Skipped  int
+}
+
+// CSVToContacts reads a contacts CSV and maps each row onto a new contact. The header row is
+// auto-detected: Google Contacts exports (both the "Given Name" and "First Name" layouts) and the
+// KindredCard CSV export are supported. Unknown columns are ignored; rows that fail to parse or
+// have neither a name, email, nor phone are counted as skipped
+func CSVToContacts(r io.Reader, revMap map[string]int) (*CSVImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("empty CSV file")
+		}
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	layout := detectCSVLayout(header)
+	if len(layout.fields) == 0 && len(layout.groups) == 0 {
+		return nil, errors.New("unrecognized CSV layout; expected a Google Contacts or KindredCard export")
+	}
+
+	result := &CSVImportResult{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Debug("[CONVERTER] Skipping unreadable CSV row: %v", err)
+			result.Skipped++
+			continue
+		}
+
+		contact := csvRecordToContact(record, layout, revMap)
+		if contact == nil {
+			result.Skipped++
+			continue
+		}
+		result.Contacts = append(result.Contacts, contact)
+	}
+
+	return result, nil
+}
+
+// detectCSVLayout maps header names onto contact fields and numbered groups
+func detectCSVLayout(header []string) csvLayout {
+	layout := csvLayout{fields: make(map[string]int)}
+	groups := make(map[string]*csvIndexedGroup)
+
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+
+		if field, ok := csvColumnAliases[name]; ok {
+			if _, seen := layout.fields[field]; !seen {
+				layout.fields[field] = i
+			}
+			continue
+		}
+
+		if m := csvIndexedColumn.FindStringSubmatch(name); m != nil {
+			key := m[1] + " " + m[2]
+			group, ok := groups[key]
+			if !ok {
+				group = &csvIndexedGroup{kind: m[1], columns: make(map[string]int)}
+				groups[key] = group
+				layout.groups = append(layout.groups, group)
+			}
+			group.columns[m[3]] = i
+		}
+	}
+
+	return layout
+}
+
+// csvRecordToContact maps one CSV row onto a contact, or returns nil if the row is empty
+func csvRecordToContact(record []string, layout csvLayout, revMap map[string]int) *models.Contact {
+	get := func(field string) string {
+		if i, ok := layout.fields[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	contact := &models.Contact{
+		FullName:           get("full_name"),
+		GivenName:          get("given_name"),
+		MiddleName:         get("middle_name"),
+		FamilyName:         get("family_name"),
+		Prefix:             get("prefix"),
+		Suffix:             get("suffix"),
+		Nickname:           get("nickname"),
+		MaidenName:         get("maiden_name"),
+		Salutation:         get("salutation"),
+		PhoneticFirstName:  get("phonetic_first_name"),
+		PhoneticMiddleName: get("phonetic_middle_name"),
+		PhoneticLastName:   get("phonetic_last_name"),
+		Notes:              get("notes"),
+	}
+
+	if gender, ok := utils.NormalizeGender(get("gender")); ok {
+		contact.Gender = gender
+	}
+
+	parseCSVBirthday(contact, get("birthday"))
+
+	// KindredCard export: multi-valued columns, primary first
+	for _, v := range splitCSVMultiValue(get("email")) {
+		contact.Emails = append(contact.Emails, models.Email{Email: v, Type: revMap[getLabelKey("email", "home")]})
+	}
+	for _, v := range splitCSVMultiValue(get("phone")) {
+		contact.Phones = append(contact.Phones, models.Phone{Phone: v, Type: revMap[getLabelKey("phone", "cell")]})
+	}
+
+	// Google "First Name" layout: single organization columns
+	if name := get("org_name"); name != "" || get("org_title") != "" {
+		contact.Organizations = append(contact.Organizations, models.Organization{
+			Name:       name,
+			Title:      get("org_title"),
+			Department: get("org_department"),
+		})
+	}
+
+	for _, group := range layout.groups {
+		appendCSVGroup(contact, record, group, revMap)
+	}
+
+	hasName := contact.FullName != "" || contact.GivenName != "" || contact.FamilyName != "" ||
+		contact.MiddleName != "" || contact.Nickname != ""
+
+	switch {
+	case hasName:
+		if contact.FullName == "" {
+			contact.FullName = contact.GenerateFullName()
+		}
+	case len(contact.Emails) > 0:
+		// Name-less rows are still worth keeping; fall back to how they can be reached
+		contact.FullName = contact.Emails[0].Email
+	case len(contact.Phones) > 0:
+		contact.FullName = contact.Phones[0].Phone
+	default:
+		return nil
+	}
+
+	ensureCSVPrimary(contact)
+
+	return contact
+}
+
+// appendCSVGroup adds the values of one numbered Google group to the contact
+func appendCSVGroup(contact *models.Contact, record []string, group *csvIndexedGroup, revMap map[string]int) {
+	get := func(sub string) string {
+		if i, ok := group.columns[sub]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	// Older exports use "Type", newer ones "Label"; a leading "* " marks the primary value
+	label := get("type")
+	if label == "" {
+		label = get("label")
+	}
+	primary := strings.HasPrefix(label, "* ")
+	label = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(label, "* ")))
+
+	switch group.kind {
+	case "e-mail":
+		for _, v := range splitCSVMultiValue(get("value")) {
+			contact.Emails = append(contact.Emails, models.Email{
+				Email:     v,
+				Type:      csvLabelID(revMap, "email", label, "home"),
+				IsPrimary: primary,
+			})
+			primary = false
+		}
+
+	case "phone":
+		if label == "mobile" {
+			label = "cell"
+		}
+		for _, v := range splitCSVMultiValue(get("value")) {
+			contact.Phones = append(contact.Phones, models.Phone{
+				Phone:     v,
+				Type:      csvLabelID(revMap, "phone", label, "cell"),
+				IsPrimary: primary,
+			})
+			primary = false
+		}
+
+	case "website":
+		for _, v := range splitCSVMultiValue(get("value")) {
+			contact.URLs = append(contact.URLs, models.URL{
+				URL:       v,
+				Type:      csvLabelID(revMap, "url", label, "home"),
+				IsPrimary: primary,
+			})
+			primary = false
+		}
+
+	case "address":
+		address := models.Address{
+			Street:         get("street"),
+			ExtendedStreet: get("extended address"),
+			City:           get("city"),
+			State:          get("region"),
+			PostalCode:     get("postal code"),
+			Country:        get("country"),
+			IsPrimary:      primary,
+		}
+		if address.Street == "" {
+			address.Street = get("po box")
+		}
+		if address.Street == "" && address.City == "" && address.State == "" &&
+			address.PostalCode == "" && address.Country == "" {
+			// Only a formatted address is available; keep it as the street line
+			address.Street = strings.ReplaceAll(get("formatted"), "\n", ", ")
+		}
+		if address.Street == "" {
+			return
+		}
+		address.Type = csvLabelID(revMap, "address", label, "home")
+		contact.Addresses = append(contact.Addresses, address)
+
+	case "organization":
+		org := models.Organization{
+			Name:         get("name"),
+			PhoneticName: get("yomi name"),
+			Title:        get("title"),
+			Department:   get("department"),
+			IsPrimary:    primary,
+		}
+		if org.Name == "" && org.Title == "" {
+			return
+		}
+		contact.Organizations = append(contact.Organizations, org)
+	}
+}
+
+// csvLabelID resolves a CSV label to a label ID, falling back like the vCard importer does
+func csvLabelID(revMap map[string]int, category, label, fallback string) int {
+	if label != "" {
+		if id, ok := revMap[getLabelKey(category, label)]; ok {
+			return id
+		}
+		if id, ok := revMap[getLabelKey(category, strings.Fields(label)[0])]; ok {
+			return id
+		}
+	}
+	return revMap[getLabelKey(category, fallback)]
+}
+
+// splitCSVMultiValue splits a cell holding several values (Google " ::: " or KindredCard "; ")
+func splitCSVMultiValue(value string) []string {
+	sep := CSVMultiValueSeparator
+	if strings.Contains(value, googleMultiValueSeparator) {
+		sep = googleMultiValueSeparator
+	}
+
+	var values []string
+	for _, v := range strings.Split(value, sep) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// formatCSVBirthday writes a full birthday as YYYY-MM-DD and a month/day-only one as --MM-DD, the
+// forms parseCSVBirthday reads back
+func formatCSVBirthday(contact *models.Contact) string {
+	if contact.Birthday != nil {
+		return utils.FormatDate(contact.Birthday)
+	}
+	if contact.BirthdayMonth != nil && contact.BirthdayDay != nil {
+		return fmt.Sprintf("--%02d-%02d", *contact.BirthdayMonth, *contact.BirthdayDay)
+	}
+	return ""
+}
+
+// parseCSVBirthday accepts YYYY-MM-DD and the year-less --MM-DD form Google exports
+func parseCSVBirthday(contact *models.Contact, value string) {
+	if value == "" {
+		return
+	}
+
+	if strings.HasPrefix(value, "--") {
+		if t, err := time.Parse("01-02", strings.TrimPrefix(value, "--")); err == nil {
+			contact.BirthdayMonth = utils.IntPtr(int(t.Month()))
+			contact.BirthdayDay = utils.IntPtr(t.Day())
+		}
+		return
+	}
+
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		contact.Birthday = &t
+	}
+}
+
+// ensureCSVPrimary marks the first email, phone, and organization primary when none is flagged
+func ensureCSVPrimary(contact *models.Contact) {
+	hasPrimary := false
+	for _, e := range contact.Emails {
+		hasPrimary = hasPrimary || e.IsPrimary
+	}
+	if !hasPrimary && len(contact.Emails) > 0 {
+		contact.Emails[0].IsPrimary = true
+	}
+
+	hasPrimary = false
+	for _, p := range contact.Phones {
+		hasPrimary = hasPrimary || p.IsPrimary
+	}
+	if !hasPrimary && len(contact.Phones) > 0 {
+		contact.Phones[0].IsPrimary = true
+	}
+
+	hasPrimary = false
+	for _, o := range contact.Organizations {
+		hasPrimary = hasPrimary || o.IsPrimary
+	}
+	if !hasPrimary && len(contact.Organizations) > 0 {
+		contact.Organizations[0].IsPrimary = true
+	}
+}
@@ -0,0 +1,145 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+func TestContactToCSVRecordCollapsesEmails(t *testing.T) {
+	birthday := time.Date(1990, time.June, 15, 0, 0, 0, 0, time.UTC)
+	contact := &models.Contact{
+		FullName:   "Alice Liddell",
+		GivenName:  "Alice",
+		FamilyName: "Liddell",
+		Emails: []models.Email{
+			{Email: "alice@work.example.com"},
+			{Email: "alice@example.com", IsPrimary: true},
+		},
+		Phones:   []models.Phone{{Phone: "+15555550100", IsPrimary: true}},
+		Birthday: &birthday,
+		Notes:    "Met at the tea party, \"mad\" hatter's",
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(CSVHeader)
+	w.Write(ContactToCSVRecord(contact))
+	w.Flush()
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV back: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want a header and one contact row", len(rows))
+	}
+
+	want := []string{
+		"Alice Liddell", "Alice", "Liddell",
+		"alice@example.com; alice@work.example.com",
+		"+15555550100",
+		"1990-06-15",
+		"Met at the tea party, \"mad\" hatter's",
+	}
+	if got := rows[1]; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("row = %q, want %q", got, want)
+	}
+}
+
+func TestCSVBirthdayRoundTrip(t *testing.T) {
+	partial := &models.Contact{FullName: "Partial", BirthdayMonth: utils.IntPtr(2), BirthdayDay: utils.IntPtr(29)}
+	if got := formatCSVBirthday(partial); got != "--02-29" {
+		t.Fatalf("formatCSVBirthday = %q, want --02-29", got)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(CSVHeader)
+	w.Write(ContactToCSVRecord(partial))
+	w.Flush()
+
+	result, err := CSVToContacts(&buf, nil)
+	if err != nil {
+		t.Fatalf("CSVToContacts: %v", err)
+	}
+	if len(result.Contacts) != 1 {
+		t.Fatalf("imported %d contacts, want 1", len(result.Contacts))
+	}
+	got := result.Contacts[0]
+	if got.Birthday != nil || got.BirthdayMonth == nil || *got.BirthdayMonth != 2 || got.BirthdayDay == nil || *got.BirthdayDay != 29 {
+		t.Errorf("birthday = %v %v/%v, want month/day 2/29 without a year", got.Birthday, intValue(got.BirthdayMonth), intValue(got.BirthdayDay))
+	}
+}
+
+func TestCSVToContactsGoogleLayout(t *testing.T) {
+	_, revMap := testLabels()
+
+	input := strings.Join([]string{
+		"Name,Given Name,Family Name,Favourite Colour,E-mail 1 - Type,E-mail 1 - Value,Phone 1 - Type,Phone 1 - Value,Notes",
+		`"Liddell, Alice",Alice,Liddell,blue,* Home,alice@example.com ::: alice@work.example.com,Mobile,+15555550100,"Tea, no sugar"`,
+		`,Bob,Builder,red,,,* Work,+15555550101,`,
+	}, "\n")
+
+	result, err := CSVToContacts(strings.NewReader(input), revMap)
+	if err != nil {
+		t.Fatalf("CSVToContacts: %v", err)
+	}
+	if result.Skipped != 0 {
+		t.Errorf("skipped %d rows, want 0", result.Skipped)
+	}
+	if len(result.Contacts) != 2 {
+		t.Fatalf("imported %d contacts, want 2", len(result.Contacts))
+	}
+
+	alice := result.Contacts[0]
+	if alice.FullName != "Liddell, Alice" || alice.GivenName != "Alice" || alice.FamilyName != "Liddell" {
+		t.Errorf("name = %q (%q %q), want \"Liddell, Alice\" (Alice Liddell)", alice.FullName, alice.GivenName, alice.FamilyName)
+	}
+	if alice.Notes != "Tea, no sugar" {
+		t.Errorf("notes = %q, want %q", alice.Notes, "Tea, no sugar")
+	}
+	if len(alice.Emails) != 2 || alice.Emails[0].Email != "alice@example.com" || !alice.Emails[0].IsPrimary ||
+		alice.Emails[1].Email != "alice@work.example.com" || alice.Emails[1].IsPrimary {
+		t.Errorf("emails = %+v, want alice@example.com (primary) and alice@work.example.com", alice.Emails)
+	}
+	if alice.Emails[0].Type != 4 {
+		t.Errorf("email label = %d, want 4 (home)", alice.Emails[0].Type)
+	}
+	if len(alice.Phones) != 1 || alice.Phones[0].Type != 2 || !alice.Phones[0].IsPrimary {
+		t.Errorf("phones = %+v, want one primary cell phone", alice.Phones)
+	}
+
+	// No Name column value: the full name is built from the parts
+	bob := result.Contacts[1]
+	if bob.FullName != "Bob Builder" {
+		t.Errorf("full name = %q, want %q", bob.FullName, "Bob Builder")
+	}
+	if len(bob.Emails) != 0 {
+		t.Errorf("emails = %+v, want none", bob.Emails)
+	}
+	if len(bob.Phones) != 1 || bob.Phones[0].Phone != "+15555550101" || bob.Phones[0].Type != 1 || !bob.Phones[0].IsPrimary {
+		t.Errorf("phones = %+v, want one primary work phone", bob.Phones)
+	}
+}
+
+func TestCSVToContactsSkipsEmptyRows(t *testing.T) {
+	input := "Name,E-mail 1 - Value,Phone 1 - Value\nAlice,,\n,,\n"
+
+	result, err := CSVToContacts(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("CSVToContacts: %v", err)
+	}
+	if len(result.Contacts) != 1 || result.Skipped != 1 {
+		t.Errorf("imported %d, skipped %d; want 1 and 1", len(result.Contacts), result.Skipped)
+	}
+
+	if _, err := CSVToContacts(strings.NewReader("Colour,Shape\nblue,round\n"), nil); err == nil {
+		t.Error("CSVToContacts accepted a CSV without any known columns")
+	}
+}
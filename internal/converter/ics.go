@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package converter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// icsDateFormat is the iCalendar DATE value format used for all-day events
+const icsDateFormat = "20060102"
+
+// icsTextEscaper escapes TEXT values per RFC 5545 3.3.11
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// ContactDatesToICS renders contact dates as an iCalendar feed with one yearly recurring all-day
+// event per date. Dates with a known year start on that date; partial dates are anchored to the
+// current year
+func ContactDatesToICS(dates []models.ContactDate, baseURL string, now time.Time) string {
+	var sb strings.Builder
+
+	writeICSLine(&sb, "BEGIN:VCALENDAR")
+	writeICSLine(&sb, "VERSION:2.0")
+	writeICSLine(&sb, "PRODID:-//KindredCard//Contact Events//EN")
+	writeICSLine(&sb, "CALSCALE:GREGORIAN")
+	writeICSLine(&sb, "METHOD:PUBLISH")
+	writeICSLine(&sb, "X-WR-CALNAME:KindredCard Events")
+
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	for _, date := range dates {
+		year := now.Year()
+		if date.Year != nil && *date.Year > 0 {
+			year = *date.Year
+		} else if date.Month == 2 && date.Day == 29 {
+			// Anchor partial leap-day dates to the latest leap year so the event exists
+			for !isLeapYear(year) {
+				year--
+			}
+		}
+
+		start := time.Date(year, time.Month(date.Month), date.Day, 0, 0, 0, 0, time.UTC)
+		if start.Month() != time.Month(date.Month) {
+			// Invalid day for the month; skip it
+			continue
+		}
+
+		writeICSLine(&sb, "BEGIN:VEVENT")
+		writeICSLine(&sb, "UID:"+icsEventUID(date))
+		writeICSLine(&sb, "DTSTAMP:"+stamp)
+		writeICSLine(&sb, "DTSTART;VALUE=DATE:"+start.Format(icsDateFormat))
+		writeICSLine(&sb, "DTEND;VALUE=DATE:"+start.AddDate(0, 0, 1).Format(icsDateFormat))
+		writeICSLine(&sb, "RRULE:FREQ=YEARLY")
+		writeICSLine(&sb, "SUMMARY:"+icsTextEscaper.Replace(icsEventSummary(date)))
+		writeICSLine(&sb, "CATEGORIES:"+icsTextEscaper.Replace(date.EventType))
+		if baseURL != "" {
+			contactURL := fmt.Sprintf("%s/contacts/%d", baseURL, date.ContactID)
+			writeICSLine(&sb, "URL:"+contactURL)
+			writeICSLine(&sb, "DESCRIPTION:"+icsTextEscaper.Replace(contactURL))
+		}
+		writeICSLine(&sb, "TRANSP:TRANSPARENT")
+		writeICSLine(&sb, "END:VEVENT")
+	}
+
+	writeICSLine(&sb, "END:VCALENDAR")
+
+	return sb.String()
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// icsEventSummary is the event title, e.g. "John Doe's birthday"
+func icsEventSummary(date models.ContactDate) string {
+	switch date.EventType {
+	case "birthday":
+		return fmt.Sprintf("🎂 %s's birthday", date.FullName)
+	case "anniversary":
+		return fmt.Sprintf("💍 %s's anniversary", date.FullName)
+	default:
+		return fmt.Sprintf("📅 %s: %s", date.FullName, date.EventType)
+	}
+}
+
+// icsEventUID is stable across polls so calendar clients update events rather than duplicating them
+func icsEventUID(date models.ContactDate) string {
+	eventType := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '@' {
+			return '-'
+		}
+		return r
+	}, strings.ToLower(date.EventType))
+	return fmt.Sprintf("%s-%s-%02d%02d@kindredcard", date.ContactUID, eventType, date.Month, date.Day)
+}
+
+// writeICSLine writes a CRLF-terminated content line, folding it at 75 octets without
+// splitting UTF-8 characters (RFC 5545 3.1)
+func writeICSLine(sb *strings.Builder, line string) {
+	const maxOctets = 75
+
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > maxOctets {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += size
+	}
+	sb.WriteString("\r\n")
+}
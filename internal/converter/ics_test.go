@@ -0,0 +1,101 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+// icsEvents splits an unfolded feed into the content lines of each VEVENT
+func icsEvents(t *testing.T, feed string) [][]string {
+	t.Helper()
+
+	if !strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(feed, "END:VCALENDAR\r\n") {
+		t.Fatalf("feed is not a VCALENDAR:\n%s", feed)
+	}
+
+	var events [][]string
+	var current []string
+	for _, line := range strings.Split(strings.ReplaceAll(feed, "\r\n ", ""), "\r\n") {
+		switch {
+		case line == "BEGIN:VEVENT":
+			current = []string{}
+		case line == "END:VEVENT":
+			events = append(events, current)
+			current = nil
+		case current != nil:
+			current = append(current, line)
+		}
+	}
+	return events
+}
+
+func TestContactDatesToICSPartialBirthday(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	dates := []models.ContactDate{
+		{ContactID: 7, ContactUID: "alice-uid", FullName: "Alice Liddell", EventType: "birthday", Month: 6, Day: 15},
+		{ContactID: 7, ContactUID: "alice-uid", FullName: "Alice Liddell", EventType: "anniversary", Year: utils.IntPtr(2010), Month: 9, Day: 12},
+		{ContactID: 8, ContactUID: "bob-uid", FullName: "Bob", EventType: "birthday", Month: 2, Day: 29},
+	}
+
+	events := icsEvents(t, ContactDatesToICS(dates, "http://kindred.test", now))
+	if len(events) != len(dates) {
+		t.Fatalf("got %d events, want %d", len(events), len(dates))
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"partial birthday", []string{
+			"UID:alice-uid-birthday-0615@kindredcard",
+			// Anchored to the current year
+			"DTSTART;VALUE=DATE:20260615",
+			"DTEND;VALUE=DATE:20260616",
+			"RRULE:FREQ=YEARLY",
+			"SUMMARY:🎂 Alice Liddell's birthday",
+			"URL:http://kindred.test/contacts/7",
+		}},
+		{"full anniversary", []string{
+			"DTSTART;VALUE=DATE:20100912",
+			"RRULE:FREQ=YEARLY",
+			"SUMMARY:💍 Alice Liddell's anniversary",
+		}},
+		{"partial leap day", []string{
+			// 2026 has no Feb 29, so the latest leap year is used
+			"DTSTART;VALUE=DATE:20240229",
+			"RRULE:FREQ=YEARLY",
+		}},
+	}
+	for i, tt := range tests {
+		lines := strings.Join(events[i], "\n")
+		for _, want := range tt.want {
+			if !strings.Contains("\n"+lines+"\n", "\n"+want+"\n") {
+				t.Errorf("%s: event is missing %q:\n%s", tt.name, want, lines)
+			}
+		}
+	}
+}
+
+func TestContactDatesToICSFoldsLongLines(t *testing.T) {
+	dates := []models.ContactDate{{
+		ContactUID: "uid",
+		FullName:   strings.Repeat("Émilie ", 20),
+		EventType:  "birthday",
+		Month:      1,
+		Day:        2,
+	}}
+
+	feed := ContactDatesToICS(dates, "", time.Now())
+	for _, line := range strings.Split(feed, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line is %d octets, want at most 75: %q", len(line), line)
+		}
+	}
+	if events := icsEvents(t, feed); len(events) != 1 || !strings.Contains(strings.Join(events[0], "\n"), "SUMMARY:🎂 "+dates[0].FullName+"'s birthday") {
+		t.Errorf("unfolded summary doesn't match the full name: %q", events)
+	}
+}
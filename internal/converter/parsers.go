@@ -12,9 +12,12 @@ package converter
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/emersion/go-vcard"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
 )
 
 // photoParts holds the extracted MIME type and Base64 content.
@@ -122,6 +125,107 @@ func parseVCardPhotoProperty(field *vcard.Field) (photoParts, error) {
 	return photoParts{}, fmt.Errorf("vcard photo: unrecognized photo property format or missing ENCODING=B")
 }
 
+// formatReducedPrecisionDate renders a year-only ("1985") or year+month ("1985-06") date
+// https://datatracker.ietf.org/doc/html/rfc6350#section-4.3.1
+func formatReducedPrecisionDate(year *int, month *int) string {
+	if year == nil {
+		return ""
+	}
+	if month != nil {
+		return fmt.Sprintf("%04d-%02d", *year, *month)
+	}
+	return fmt.Sprintf("%04d", *year)
+}
+
+// parseReducedPrecisionDate parses "YYYY", "YYYY-MM" or "YYYYMM" (without a day)
+// Returns ok=false when the value is not a reduced-precision date
+func parseReducedPrecisionDate(value string) (year *int, month *int, ok bool) {
+	switch {
+	case len(value) == 4:
+		year = utils.ParseIntPtr(value)
+	case len(value) == 7 && value[4] == '-':
+		year = utils.ParseIntPtr(value[0:4])
+		month = utils.ParseIntPtr(value[5:7])
+	case len(value) == 6 && !strings.HasPrefix(value, "--"):
+		year = utils.ParseIntPtr(value[0:4])
+		month = utils.ParseIntPtr(value[4:6])
+	default:
+		return nil, nil, false
+	}
+
+	if year == nil || (len(value) > 4 && (month == nil || *month < 1 || *month > 12)) {
+		return nil, nil, false
+	}
+	return year, month, true
+}
+
+// formatGeoValue renders a vCard 4.0 GEO URI ("geo:37.386,-122.084")
+// https://datatracker.ietf.org/doc/html/rfc6350#section-6.5.2
+func formatGeoValue(latitude float64, longitude float64) string {
+	return "geo:" + strconv.FormatFloat(latitude, 'f', -1, 64) + "," + strconv.FormatFloat(longitude, 'f', -1, 64)
+}
+
+// parseGeoValue parses a GEO URI ("geo:37.386,-122.084;u=35") or the vCard 3.0 form ("37.386;-122.084")
+// Returns ok=false for anything else, including out of range coordinates
+func parseGeoValue(value string) (latitude float64, longitude float64, ok bool) {
+	value = strings.TrimSpace(value)
+
+	var lat, lon string
+	if rest, found := strings.CutPrefix(strings.ToLower(value), "geo:"); found {
+		rest, _, _ = strings.Cut(rest, ";") // drop uncertainty and other URI parameters
+		parts := strings.Split(rest, ",")
+		if len(parts) < 2 {
+			return 0, 0, false
+		}
+		lat, lon = parts[0], parts[1]
+	} else {
+		var found bool
+		if lat, lon, found = strings.Cut(value, ";"); !found {
+			return 0, 0, false
+		}
+	}
+
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return 0, 0, false
+	}
+	longitude, err = strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return 0, 0, false
+	}
+	return latitude, longitude, true
+}
+
+// parseLanguages collects the LANG values, most preferred (lowest PREF) first; values without
+// PREF keep their card order after those with one
+func parseLanguages(card vcard.Card) []string {
+	type language struct {
+		tag  string
+		pref int
+	}
+
+	var found []language
+	for _, field := range card[vcard.FieldLanguage] {
+		tag := strings.TrimSpace(field.Value)
+		if tag == "" {
+			continue
+		}
+		pref, err := strconv.Atoi(field.Params.Get(vcard.ParamPreferred))
+		if err != nil || pref < 1 {
+			pref = 101 // PREF ranges 1-100
+		}
+		found = append(found, language{tag, pref})
+	}
+
+	slices.SortStableFunc(found, func(a, b language) int { return a.pref - b.pref })
+
+	languages := make([]string, 0, len(found))
+	for _, l := range found {
+		languages = append(languages, l.tag)
+	}
+	return languages
+}
+
 // extractCustomLabel looks for a grouped X-ABLABEL and cleans it
 func extractCustomLabel(card vcard.Card, group string) string {
 	if group == "" {
@@ -137,6 +241,66 @@ func extractCustomLabel(card vcard.Card, group string) string {
 	return ""
 }
 
+// imppSchemes maps messaging services to the URI scheme used in an IMPP value;
+// services without a registered scheme use Apple's generic "x-apple"
+var imppSchemes = map[string]string{
+	"aim":      "aim",
+	"facebook": "xmpp",
+	"icq":      "icq",
+	"irc":      "irc",
+	"jabber":   "xmpp",
+	"matrix":   "matrix",
+	"msn":      "msnim",
+	"sip":      "sip",
+	"skype":    "skype",
+	"xmpp":     "xmpp",
+	"yahoo":    "ymsgr",
+}
+
+// formatIMPPValue builds the IMPP URI (e.g. "xmpp:me@example.com") for a service/handle pair
+func formatIMPPValue(service string, handle string) string {
+	scheme, ok := imppSchemes[strings.ToLower(service)]
+	if !ok {
+		scheme = "x-apple"
+	}
+	return scheme + ":" + handle
+}
+
+// parseIMPPValue splits an IMPP URI into a service guess (from the scheme) and the handle
+// The X-SERVICE-TYPE parameter, when present, should take precedence over the guess
+func parseIMPPValue(value string) (service string, handle string) {
+	value = strings.TrimSpace(value)
+	scheme, rest, found := strings.Cut(value, ":")
+	if !found || strings.ContainsAny(scheme, "@/ ") {
+		return "", value
+	}
+
+	switch strings.ToLower(scheme) {
+	case "x-apple":
+		return "", rest
+	case "xmpp":
+		return "Jabber", rest
+	case "msnim":
+		return "MSN", rest
+	case "ymsgr":
+		return "Yahoo", rest
+	}
+
+	// capitalize the scheme as a reasonable service name (skype -> Skype)
+	return strings.ToUpper(scheme[:1]) + strings.ToLower(scheme[1:]), rest
+}
+
+// getGroupedField returns the first field of the given name sharing the group
+// (an empty group matches ungrouped fields)
+func getGroupedField(card vcard.Card, name string, group string) *vcard.Field {
+	for _, field := range card[name] {
+		if field.Group == group {
+			return field
+		}
+	}
+	return nil
+}
+
 // addCustomLabel adds an X-ABLABEL field to the card linked to a group
 func addCustomLabel(card vcard.Card, group string, label string) {
 	if label == "" {
@@ -150,6 +314,57 @@ func addCustomLabel(card vcard.Card, group string, label string) {
 	card.Add(XLabelField, labelField)
 }
 
+// typeLabelFromParams builds a label name from every TYPE on a field, so that
+// TEL;TYPE=work,cell becomes the composite label "work cell". The "pref" type is
+// reported separately, "internet" is ignored, and "voice" (the implied default for
+// TEL) is dropped when combined with a more specific type
+func typeLabelFromParams(params vcard.Params) (label string, pref bool) {
+	var types []string
+	for _, t := range params.Types() {
+		t = strings.ToLower(strings.TrimSpace(t))
+		switch t {
+		case "", "internet":
+			continue
+		case "pref":
+			pref = true
+			continue
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+
+	if len(types) > 1 {
+		types = slices.DeleteFunc(types, func(t string) bool { return t == "voice" })
+	}
+
+	return strings.Join(types, " "), pref
+}
+
+// compositeLabelTypes splits a custom label such as "work cell" back into its vCard
+// TYPE values when every part is a system label of the same category; otherwise nil
+func compositeLabelTypes(label models.ContactLabelType, labelMap map[int]models.ContactLabelType) []string {
+	parts := strings.Fields(label.Name)
+	if len(parts) < 2 {
+		return nil
+	}
+
+	for _, part := range parts {
+		found := false
+		for _, l := range labelMap {
+			if l.IsSystem && l.Category == label.Category && strings.EqualFold(l.Name, part) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	return parts
+}
+
 func getLabelKey(category string, name string) string {
 	return strings.ToLower(category + ":" + name)
 }
@@ -167,57 +382,3 @@ func SanitizeForVCard(s string) string {
 	)
 	return replacer.Replace(s)
 }
-
-func FindNewLabels(card vcard.Card, revMap map[string]int) map[string][]string {
-	newLabels := make(map[string][]string)
-
-	// Define the fields we want to scan and their categories
-	mappings := []struct {
-		Field    string
-		Category string
-	}{
-		{vcard.FieldTelephone, "phone"},
-		{vcard.FieldEmail, "email"},
-		{vcard.FieldAddress, "address"},
-		{vcard.FieldURL, "url"},
-	}
-
-	for _, m := range mappings {
-		for _, field := range card[m.Field] {
-			// 1. Try Custom Label (Grouped X-ABLABEL)
-			label := strings.ToLower(extractCustomLabel(card, field.Group))
-
-			// 2. Fallback to Standard vCard TYPEs if no custom label
-			if label == "" {
-				for _, t := range field.Params.Types() {
-					t = strings.ToLower(t)
-					if t == "pref" || t == "internet" { // Skip non-label types
-						continue
-					}
-					label = t
-					break // Take the first valid type found
-				}
-			}
-
-			// 3. Last Resort Fallback (Matches your UI logic)
-			if label == "" {
-				if m.Category == "phone" {
-					label = "cell"
-				} else {
-					label = "home"
-				}
-			}
-
-			// 4. Check if we need to create it
-			key := m.Category + ":" + label
-			if _, exists := revMap[key]; !exists {
-				// Check if already added to our "new" list to avoid duplicates
-				if !slices.Contains(newLabels[m.Category], label) {
-					newLabels[m.Category] = append(newLabels[m.Category], label)
-				}
-			}
-		}
-	}
-
-	return newLabels
-}
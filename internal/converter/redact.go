@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package converter
+
+import (
+	"fmt"
+
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// RedactContact returns a copy of the contact with personal data replaced by placeholders
+// Field presence, labels, primary flags, dates, and relationship types are preserved so the
+// resulting vCard has the same shape as the original (useful when reproducing sync bugs)
+func RedactContact(contact *models.Contact) *models.Contact {
+	redacted := *contact
+
+	redacted.GivenName = placeholder(contact.GivenName, "Given")
+	redacted.FamilyName = placeholder(contact.FamilyName, "Family")
+	redacted.MiddleName = placeholder(contact.MiddleName, "Middle")
+	redacted.Nickname = placeholder(contact.Nickname, "Nickname")
+	redacted.MaidenName = placeholder(contact.MaidenName, "Maiden")
+	redacted.Salutation = placeholder(contact.Salutation, "Salutation")
+	redacted.PhoneticFirstName = placeholder(contact.PhoneticFirstName, "Phonetic Given")
+	redacted.PronunciationFirstName = placeholder(contact.PronunciationFirstName, "Pronunciation Given")
+	redacted.PhoneticMiddleName = placeholder(contact.PhoneticMiddleName, "Phonetic Middle")
+	redacted.PhoneticLastName = placeholder(contact.PhoneticLastName, "Phonetic Family")
+	redacted.PronunciationLastName = placeholder(contact.PronunciationLastName, "Pronunciation Family")
+	redacted.FullName = redacted.GenerateFullName()
+	redacted.Notes = placeholder(contact.Notes, "Redacted notes")
+
+	// A location pinpoints the contact; keep the GEO property but not where it points
+	if contact.Latitude != nil && contact.Longitude != nil {
+		zero := 0.0
+		redacted.Latitude = &zero
+		redacted.Longitude = &zero
+	}
+
+	// Unknown properties could hold anything
+	redacted.RawVCardExtras = ""
+
+	// Photos are inherently identifying; drop them entirely
+	redacted.AvatarBase64 = ""
+	redacted.AvatarMimeType = ""
+
+	redacted.Emails = make([]models.Email, len(contact.Emails))
+	for i, email := range contact.Emails {
+		email.Email = fmt.Sprintf("email%d@example.invalid", i+1)
+		redacted.Emails[i] = email
+	}
+
+	redacted.Phones = make([]models.Phone, len(contact.Phones))
+	for i, phone := range contact.Phones {
+		phone.Phone = fmt.Sprintf("+1-555-01%02d", (i+1)%100)
+		redacted.Phones[i] = phone
+	}
+
+	redacted.Addresses = make([]models.Address, len(contact.Addresses))
+	for i, addr := range contact.Addresses {
+		addr.Street = placeholder(addr.Street, fmt.Sprintf("%d Redacted St", i+1))
+		addr.ExtendedStreet = placeholder(addr.ExtendedStreet, "Unit 1")
+		addr.City = placeholder(addr.City, "City")
+		addr.State = placeholder(addr.State, "State")
+		addr.PostalCode = placeholder(addr.PostalCode, "00000")
+		addr.Country = placeholder(addr.Country, "Country")
+		redacted.Addresses[i] = addr
+	}
+
+	redacted.Organizations = make([]models.Organization, len(contact.Organizations))
+	for i, org := range contact.Organizations {
+		org.Name = placeholder(org.Name, fmt.Sprintf("Organization %d", i+1))
+		org.PhoneticName = placeholder(org.PhoneticName, "Phonetic Organization")
+		org.Title = placeholder(org.Title, "Title")
+		org.Role = placeholder(org.Role, "Role")
+		org.Department = placeholder(org.Department, "Department")
+		redacted.Organizations[i] = org
+	}
+
+	redacted.URLs = make([]models.URL, len(contact.URLs))
+	for i, u := range contact.URLs {
+		u.URL = fmt.Sprintf("https://example.invalid/%d", i+1)
+		redacted.URLs[i] = u
+	}
+
+	redacted.IMPPs = make([]models.IMPP, len(contact.IMPPs))
+	for i, impp := range contact.IMPPs {
+		impp.Handle = fmt.Sprintf("handle%d", i+1)
+		redacted.IMPPs[i] = impp
+	}
+
+	redacted.Relationships = make([]models.Relationship, len(contact.Relationships))
+	for i, rel := range contact.Relationships {
+		if rel.RelatedContact != nil {
+			rel.RelatedContact = &models.Contact{
+				ID:       rel.RelatedContact.ID,
+				FullName: fmt.Sprintf("Related Contact %d", i+1),
+			}
+		}
+		redacted.Relationships[i] = rel
+	}
+
+	redacted.OtherRelationships = make([]models.OtherRelationship, len(contact.OtherRelationships))
+	for i, rel := range contact.OtherRelationships {
+		rel.RelatedContactName = placeholder(rel.RelatedContactName, fmt.Sprintf("Other Related %d", i+1))
+		redacted.OtherRelationships[i] = rel
+	}
+
+	return &redacted
+}
+
+// placeholder returns replacement when value is set, keeping empty fields empty
+func placeholder(value string, replacement string) string {
+	if value == "" {
+		return ""
+	}
+	return replacement
+}
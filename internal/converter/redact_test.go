@@ -0,0 +1,81 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestRedactContactRemovesPII(t *testing.T) {
+	labels := map[int]models.ContactLabelType{
+		1: {ID: 1, Name: "home", Category: "email", IsSystem: true},
+		2: {ID: 2, Name: "cell", Category: "phone", IsSystem: true},
+		3: {ID: 3, Name: "beach house", Category: "address"},
+	}
+
+	contact := &models.Contact{
+		UID:        "redact-test",
+		GivenName:  "Alice",
+		FamilyName: "Liddell",
+		Nickname:   "Ally",
+		Notes:      "Allergic to peanuts",
+		Emails: []models.Email{
+			{Email: "alice@wonderland.test", Type: 1, IsPrimary: true},
+			{Email: "ally@rabbit-hole.test", Type: 1},
+		},
+		Phones:    []models.Phone{{Phone: "+44 20 7946 0958", Type: 2}},
+		Addresses: []models.Address{{Street: "7 Looking Glass Ln", City: "Oxford", Type: 3}},
+		Relationships: []models.Relationship{{
+			RelatedContact:   &models.Contact{ID: 42, FullName: "Lorina Liddell"},
+			RelationshipType: &models.RelationshipType{Name: "Sister"},
+		}},
+	}
+	contact.FullName = contact.GenerateFullName()
+
+	var buf bytes.Buffer
+	if err := vcard.NewEncoder(&buf).Encode(ContactToVCard(RedactContact(contact), labels, false)); err != nil {
+		t.Fatalf("encoding vCard: %v", err)
+	}
+	text := buf.String()
+
+	for _, pii := range []string{
+		"Alice", "Liddell", "Ally", "peanuts", "alice@wonderland.test", "ally@rabbit-hole.test",
+		"7946", "Looking Glass", "Oxford", "Lorina",
+	} {
+		if strings.Contains(text, pii) {
+			t.Errorf("redacted vCard still contains %q:\n%s", pii, text)
+		}
+	}
+
+	card, err := vcard.NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("decoding vCard: %v", err)
+	}
+
+	if n := len(card[vcard.FieldEmail]); n != 2 {
+		t.Errorf("got %d EMAIL properties, want 2", n)
+	} else if card[vcard.FieldEmail][0].Params.Get(vcard.ParamPreferred) != "1" {
+		t.Error("primary email lost its PREF")
+	}
+	if tel := card.Get(vcard.FieldTelephone); tel == nil || !tel.Params.HasType("cell") {
+		t.Errorf("TEL = %+v, want one with TYPE=cell", tel)
+	}
+	adr := card.Get(vcard.FieldAddress)
+	if adr == nil || adr.Group == "" {
+		t.Fatalf("ADR = %+v, want a grouped address", adr)
+	}
+	if label := extractCustomLabel(card, adr.Group); label != "beach house" {
+		t.Errorf("address label = %q, want %q", label, "beach house")
+	}
+	if related := card.Get(XRelatedNamesField); related == nil {
+		t.Error("relationship was dropped")
+	} else if label := extractCustomLabel(card, related.Group); !strings.EqualFold(label, "Sister") {
+		t.Errorf("relationship label = %q, want %q", label, "Sister")
+	}
+	if card.Value(vcard.FieldNote) == "" {
+		t.Error("NOTE was dropped instead of replaced")
+	}
+}
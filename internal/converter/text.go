@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+// WriteTextDirectory writes contacts as a human-readable phone book: sorted by name, grouped under
+// a heading per initial letter (names not starting with a letter go under "#"), with each contact's
+// phones, emails, addresses, and birthday indented beneath their name and a blank line after.
+// Output is written as it goes
+func WriteTextDirectory(w io.Writer, contacts []*models.Contact) error {
+	sorted := make([]*models.Contact, len(contacts))
+	copy(sorted, contacts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToLower(textDirectoryName(sorted[i])) < strings.ToLower(textDirectoryName(sorted[j]))
+	})
+
+	bw := bufio.NewWriter(w)
+	group := ""
+	for _, contact := range sorted {
+		name := textDirectoryName(contact)
+		if initial := textDirectoryGroup(name); initial != group {
+			group = initial
+			fmt.Fprintf(bw, "== %s ==\n\n", group)
+		}
+
+		fmt.Fprintln(bw, name)
+		for _, p := range contact.Phones {
+			writeTextLine(bw, "Phone", p.TypeLabel, p.Phone)
+		}
+		for _, e := range contact.Emails {
+			writeTextLine(bw, "Email", e.TypeLabel, e.Email)
+		}
+		for _, a := range contact.Addresses {
+			writeTextLine(bw, "Address", a.TypeLabel, formatTextAddress(a))
+		}
+		writeTextLine(bw, "Birthday", "", formatTextBirthday(contact))
+		fmt.Fprintln(bw)
+	}
+
+	return bw.Flush()
+}
+
+// textDirectoryName is the name a contact is listed under
+func textDirectoryName(contact *models.Contact) string {
+	if name := strings.TrimSpace(contact.DisplayName); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(contact.FullName); name != "" {
+		return name
+	}
+	return "(No name)"
+}
+
+// textDirectoryGroup is the heading a name is listed under: its uppercased first letter, or "#"
+func textDirectoryGroup(name string) string {
+	for _, r := range name {
+		if unicode.IsLetter(r) {
+			return string(unicode.ToUpper(r))
+		}
+		break
+	}
+	return "#"
+}
+
+// writeTextLine writes an indented "Field (label): value" line, skipping empty values
+func writeTextLine(w io.Writer, field string, label string, value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	if label != "" {
+		field = fmt.Sprintf("%s (%s)", field, label)
+	}
+	fmt.Fprintf(w, "  %s: %s\n", field, value)
+}
+
+// formatTextAddress joins an address onto one line, e.g. "1 Main St, Springfield, IL 62701, USA"
+func formatTextAddress(a models.Address) string {
+	parts := []string{}
+	for _, part := range []string{a.Street, a.ExtendedStreet, a.City, strings.TrimSpace(a.State + " " + a.PostalCode), a.Country} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatTextBirthday formats a full birthday as "March 15, 1976" and a month/day one as "March 15"
+func formatTextBirthday(contact *models.Contact) string {
+	if contact.Birthday != nil {
+		return utils.FormatBirthdayMedium(contact.Birthday)
+	}
+	return utils.FormatPartialDate(contact.BirthdayMonth, contact.BirthdayDay)
+}
@@ -0,0 +1,55 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestWriteTextDirectory(t *testing.T) {
+	born := time.Date(1976, time.March, 15, 0, 0, 0, 0, time.UTC)
+	month, day := 7, 4
+	contacts := []*models.Contact{
+		{
+			FullName:      "bob builder",
+			Emails:        []models.Email{{Email: "bob@example.com", TypeLabel: "work"}},
+			BirthdayMonth: &month,
+			BirthdayDay:   &day,
+		},
+		{
+			FullName: "Alice Liddell",
+			Phones:   []models.Phone{{Phone: "+1 555 0100", TypeLabel: "cell"}},
+			Emails:   []models.Email{{Email: "alice@example.com", TypeLabel: "home"}},
+			Addresses: []models.Address{{
+				Street: "1 Main St", City: "Springfield", State: "IL", PostalCode: "62701", Country: "USA", TypeLabel: "home",
+			}},
+			Birthday: &born,
+		},
+	}
+
+	var out strings.Builder
+	if err := WriteTextDirectory(&out, contacts); err != nil {
+		t.Fatalf("WriteTextDirectory: %v", err)
+	}
+
+	want := `== A ==
+
+Alice Liddell
+  Phone (cell): +1 555 0100
+  Email (home): alice@example.com
+  Address (home): 1 Main St, Springfield, IL 62701, USA
+  Birthday: March 15, 1976
+
+== B ==
+
+bob builder
+  Email (work): bob@example.com
+  Birthday: July 4
+
+`
+	if got := out.String(); got != want {
+		t.Errorf("directory =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -10,14 +10,15 @@
 package converter
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
 
 	"github.com/emersion/go-vcard"
-	"github.com/google/uuid"
 	"github.com/steveredden/KindredCard/internal/models"
 	"github.com/steveredden/KindredCard/internal/utils"
 )
@@ -33,14 +34,30 @@ const (
 	XRelatedNamesField       = "X-ABRELATEDNAMES"
 	XSocialProfileField      = "X-SOCIALPROFILE"
 	XMaidenNameField         = "X-MAIDENNAME"
+	XSalutationField         = "X-SALUTATION"
+	XShowAsField             = "X-ABSHOWAS"
+	XShowAsCompany           = "COMPANY"
 	XPhoneticFirstField      = "X-PHONETIC-FIRST-NAME"
 	XPronunciationFirstField = "X-PRONUNCIATION-FIRST-NAME"
 	XPhoneticLastField       = "X-PHONETIC-LAST-NAME"
 	XPronunciationLastField  = "X-PRONUNCIATION-LAST-NAME"
 	XPhoneticMiddleField     = "X-PHONETIC-MIDDLE-NAME"
 	XPhoneticOrgField        = "X-PHONETIC-ORG"
+	XServiceTypeParam        = "X-SERVICE-TYPE"
 )
 
+// NicknameAsFullName returns a copy of contact with its nickname as the full name (FN) when
+// preferNickname is set and it has one, as CardDAV clients are served for users who prefer
+// nicknames; otherwise contact itself
+func NicknameAsFullName(contact *models.Contact, preferNickname bool) *models.Contact {
+	if !preferNickname || contact.Nickname == "" {
+		return contact
+	}
+	display := *contact
+	display.FullName = contact.Nickname
+	return &display
+}
+
 // ContactToVCard converts a Contact model to a vCard
 func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabelType, isAppleClient bool) vcard.Card {
 	var extraItemIndex int = 1 //function-global extra item index
@@ -92,6 +109,25 @@ func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabe
 		card.Add(XMaidenNameField, &vcard.Field{Value: contact.MaidenName})
 	}
 
+	// Salutation
+	if contact.Salutation != "" {
+		card.Add(XSalutationField, &vcard.Field{Value: contact.Salutation})
+	}
+
+	// Company card
+	if contact.IsOrganization {
+		card.Add(XShowAsField, &vcard.Field{Value: XShowAsCompany})
+	}
+
+	// Kind - individual is the default and left out
+	kind := contact.Kind
+	if contact.IsOrganization && (kind == "" || kind == models.ContactKindIndividual) {
+		kind = models.ContactKindOrg
+	}
+	if kind != "" && kind != models.ContactKindIndividual {
+		card.SetValue(vcard.FieldKind, kind)
+	}
+
 	// Phonetics & Pronunciation
 	if contact.PhoneticFirstName != "" {
 		card.Add(XPhoneticFirstField, &vcard.Field{Value: contact.PhoneticFirstName})
@@ -114,6 +150,25 @@ func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabe
 		card.SetValue(vcard.FieldGender, contact.Gender)
 	}
 
+	// Geolocation
+	if contact.Latitude != nil && contact.Longitude != nil {
+		card.SetValue(vcard.FieldGeolocation, formatGeoValue(*contact.Latitude, *contact.Longitude))
+	}
+
+	// Timezone
+	if contact.Timezone != "" {
+		card.SetValue(vcard.FieldTimezone, contact.Timezone)
+	}
+
+	// Languages - PREF keeps their order when there is more than one
+	for i, lang := range contact.Languages {
+		field := &vcard.Field{Value: lang, Params: make(vcard.Params)}
+		if len(contact.Languages) > 1 {
+			field.Params.Set(vcard.ParamPreferred, strconv.Itoa(i+1))
+		}
+		card.Add(vcard.FieldLanguage, field)
+	}
+
 	// Birthday - try full date first, then partial
 	// https://datatracker.ietf.org/doc/html/rfc6350#section-6.2.5
 	if contact.Birthday != nil {
@@ -133,6 +188,9 @@ func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabe
 		}
 
 		card.Add(vcard.FieldBirthday, field)
+	} else if contact.BirthdayYear != nil {
+		// Reduced precision birthday: YYYY or YYYY-MM
+		card.SetValue(vcard.FieldBirthday, formatReducedPrecisionDate(contact.BirthdayYear, contact.BirthdayMonth))
 	}
 
 	// Anniversary - try full date first, then partial
@@ -175,6 +233,9 @@ func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabe
 			// Partial anniversary: --MMDD format
 			card.SetValue(vcard.FieldAnniversary, fmt.Sprintf("--%02d%02d", *contact.AnniversaryMonth, *contact.AnniversaryDay))
 		}
+	} else if contact.AnniversaryYear != nil {
+		// Reduced precision anniversary: YYYY or YYYY-MM
+		card.SetValue(vcard.FieldAnniversary, formatReducedPrecisionDate(contact.AnniversaryYear, contact.AnniversaryMonth))
 	}
 
 	// Other Dates
@@ -232,6 +293,11 @@ func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabe
 		if label, ok := labelMap[email.Type]; ok {
 			if label.IsSystem {
 				field.Params.Add(vcard.ParamType, label.Name)
+			} else if types := compositeLabelTypes(label, labelMap); types != nil {
+				// composite of standard types (e.g. "work cell") -> TYPE=work,cell
+				for _, t := range types {
+					field.Params.Add(vcard.ParamType, t)
+				}
 			} else {
 				itemKey := "item" + strconv.Itoa(extraItemIndex)
 				extraItemIndex++
@@ -261,6 +327,11 @@ func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabe
 		if label, ok := labelMap[phone.Type]; ok {
 			if label.IsSystem {
 				field.Params.Add(vcard.ParamType, label.Name)
+			} else if types := compositeLabelTypes(label, labelMap); types != nil {
+				// composite of standard types (e.g. "work cell") -> TYPE=work,cell
+				for _, t := range types {
+					field.Params.Add(vcard.ParamType, t)
+				}
 			} else {
 				itemKey := "item" + strconv.Itoa(extraItemIndex)
 				extraItemIndex++
@@ -317,24 +388,33 @@ func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabe
 		card.AddAddress(address)
 	}
 
-	// Organizations -- only do the first for now
-	if len(contact.Organizations) > 0 {
-		org := contact.Organizations[0]
+	// Organizations -- the first is written ungrouped (what most clients read);
+	// any others are grouped with their TITLE/ROLE/X-PHONETIC-ORG via itemN
+	for i, org := range contact.Organizations {
+		group := ""
+		if i > 0 {
+			group = "item" + strconv.Itoa(extraItemIndex)
+			extraItemIndex++
+		}
 
 		if org.Name != "" || org.Department != "" {
 			orgValue := org.Name
 			if org.Department != "" {
 				orgValue += ";" + org.Department
 			}
-			card.SetValue(vcard.FieldOrganization, orgValue)
+			card.Add(vcard.FieldOrganization, &vcard.Field{Value: orgValue, Group: group})
 		}
 
 		if org.Title != "" {
-			card.SetValue(vcard.FieldTitle, org.Title)
+			card.Add(vcard.FieldTitle, &vcard.Field{Value: org.Title, Group: group})
+		}
+
+		if org.Role != "" {
+			card.Add(vcard.FieldRole, &vcard.Field{Value: org.Role, Group: group})
 		}
 
 		if org.PhoneticName != "" {
-			card.Add(XPhoneticOrgField, &vcard.Field{Value: org.PhoneticName})
+			card.Add(XPhoneticOrgField, &vcard.Field{Value: org.PhoneticName, Group: group})
 		}
 	}
 
@@ -366,6 +446,23 @@ func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabe
 		card.Add(vcard.FieldURL, field)
 	}
 
+	// Instant Messaging
+	for _, impp := range contact.IMPPs {
+		field := &vcard.Field{
+			Value:  formatIMPPValue(impp.Service, impp.Handle),
+			Params: make(vcard.Params),
+		}
+
+		if impp.Service != "" {
+			field.Params.Set(XServiceTypeParam, impp.Service)
+		}
+
+		if impp.IsPrimary {
+			field.Params.Set(vcard.ParamPreferred, "1")
+		}
+		card.Add(vcard.FieldIMPP, field)
+	}
+
 	// Notes
 	if contact.Notes != "" {
 		card.SetValue(vcard.FieldNote, contact.Notes)
@@ -429,19 +526,70 @@ func ContactToVCard(contact *models.Contact, labelMap map[int]models.ContactLabe
 		}
 	}
 
+	// Tags - one CATEGORIES property per tag, since the encoder escapes the comma separator
+	for _, tag := range contact.Tags {
+		card.Add(vcard.FieldCategories, &vcard.Field{Value: tag})
+	}
+
+	// Properties we don't model, as the client last sent them
+	addVCardExtras(card, contact.RawVCardExtras, &extraItemIndex)
+
 	// Revision
 	card.SetValue(vcard.FieldRevision, contact.UpdatedAt.Format(time.RFC3339))
 
 	return card
 }
 
+// ImportOptions tunes how VCardToContact maps ambiguous vCard data
+type ImportOptions struct {
+	// MergeAnniversaryDate collapses an X-ABDATE labeled "Anniversary" into the
+	// dedicated anniversary field (Apple behavior). When false, it is kept as a
+	// separate other date named "Anniversary"
+	MergeAnniversaryDate bool
+
+	// CreateLabels keeps phone, email, address, and URL labels that aren't in the reverse label
+	// map, so custom labels like "beach house" survive the import: the record gets label id 0 and
+	// the label's name in TypeLabel, and CreateContact/UpdateContact create it in the contact's
+	// transaction. When false, unknown labels fall back to a default
+	CreateLabels bool
+
+	// UIDDomain qualifies the UID generated for a vCard without one (see utils.NewUID)
+	UIDDomain string
+}
+
+// DefaultImportOptions returns the options used by CardDAV and by imports that
+// don't specify otherwise
+func DefaultImportOptions() ImportOptions {
+	return ImportOptions{
+		MergeAnniversaryDate: true,
+	}
+}
+
+// resolveLabelID returns the id of the label in category. A missing label is returned as id 0 with
+// its name as newLabel when opts.CreateLabels is set, for the database to create. Otherwise a
+// composite label such as "work cell" keeps its first type, and anything else gets fallback
+func resolveLabelID(revMap map[string]int, opts ImportOptions, category, label, fallback string) (id int, newLabel string) {
+	if id, ok := revMap[getLabelKey(category, label)]; ok {
+		return id, ""
+	}
+	if opts.CreateLabels {
+		return 0, strings.ToLower(label)
+	}
+	if fields := strings.Fields(label); len(fields) > 1 {
+		if id, ok := revMap[getLabelKey(category, fields[0])]; ok {
+			return id, ""
+		}
+	}
+	return revMap[getLabelKey(category, fallback)], ""
+}
+
 // VCardToContact converts a vCard to a Contact model
-func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelationshipTypes []models.RelationshipType, revMap map[string]int) (*models.Contact, error) {
+func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelationshipTypes []models.RelationshipType, revMap map[string]int, opts ImportOptions) (*models.Contact, error) {
 	uid := ""
 	if field := card.Get(vcard.FieldUID); field != nil && field.Value != "" {
 		uid = field.Value
 	} else {
-		uid = uuid.New().String() // generate a new UUID
+		uid = utils.NewUID(opts.UIDDomain)
 	}
 
 	contact := &models.Contact{
@@ -461,11 +609,6 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 		contact.Suffix = n.HonorificSuffix
 	}
 
-	// If FullName is empty, generate it
-	if contact.FullName == "" {
-		contact.FullName = contact.GenerateFullName()
-	}
-
 	// Nickname
 	if nick := card.Get(vcard.FieldNickname); nick != nil {
 		contact.Nickname = nick.Value
@@ -476,21 +619,29 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 		contact.MaidenName = maiden.Value
 	}
 
+	// Salutation
+	if salutation := card.Get(XSalutationField); salutation != nil {
+		contact.Salutation = salutation.Value
+	}
+
+	// Tags - always non-nil so that categories removed by a client are removed on update
+	contact.Tags = parseCategories(card)
+
 	// Phonetics & Pronunciation
 	if phoneticFirst := card.Get(XPhoneticFirstField); phoneticFirst != nil {
 		contact.PhoneticFirstName = phoneticFirst.Value
 	}
-	if pronuncFirst := card.Get(XPhoneticFirstField); pronuncFirst != nil {
-		contact.PhoneticFirstName = pronuncFirst.Value
+	if pronuncFirst := card.Get(XPronunciationFirstField); pronuncFirst != nil {
+		contact.PronunciationFirstName = pronuncFirst.Value
 	}
-	if phoneticMiddle := card.Get(XPhoneticFirstField); phoneticMiddle != nil {
-		contact.PhoneticFirstName = phoneticMiddle.Value
+	if phoneticMiddle := card.Get(XPhoneticMiddleField); phoneticMiddle != nil {
+		contact.PhoneticMiddleName = phoneticMiddle.Value
 	}
-	if phoneticLast := card.Get(XPhoneticFirstField); phoneticLast != nil {
-		contact.PhoneticFirstName = phoneticLast.Value
+	if phoneticLast := card.Get(XPhoneticLastField); phoneticLast != nil {
+		contact.PhoneticLastName = phoneticLast.Value
 	}
-	if pronuncLast := card.Get(XPhoneticFirstField); pronuncLast != nil {
-		contact.PhoneticFirstName = pronuncLast.Value
+	if pronuncLast := card.Get(XPronunciationLastField); pronuncLast != nil {
+		contact.PronunciationLastName = pronuncLast.Value
 	}
 
 	// Gender
@@ -498,6 +649,22 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 		contact.Gender = gender.Value
 	}
 
+	// Geolocation
+	if geo := card.Get(vcard.FieldGeolocation); geo != nil {
+		if latitude, longitude, ok := parseGeoValue(geo.Value); ok {
+			contact.Latitude = &latitude
+			contact.Longitude = &longitude
+		}
+	}
+
+	// Timezone
+	if tz := card.Get(vcard.FieldTimezone); tz != nil {
+		contact.Timezone = strings.TrimSpace(tz.Value)
+	}
+
+	// Languages
+	contact.Languages = parseLanguages(card)
+
 	// Birthday
 	if bday := card.Get(vcard.FieldBirthday); bday != nil {
 		birthday := bday.Value
@@ -509,6 +676,10 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 				contact.BirthdayMonth = month
 				contact.BirthdayDay = day
 			}
+		} else if year, month, ok := parseReducedPrecisionDate(birthday); ok {
+			// Reduced precision: YYYY or YYYY-MM
+			contact.BirthdayYear = year
+			contact.BirthdayMonth = month
 		} else {
 			// Full date format: YYYYMMDD or YYYY-MM-DD
 			t, _ := time.Parse("20060102", birthday)
@@ -541,6 +712,10 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 				contact.AnniversaryMonth = month
 				contact.AnniversaryDay = day
 			}
+		} else if year, month, ok := parseReducedPrecisionDate(anniversary); ok {
+			// Reduced precision: YYYY or YYYY-MM
+			contact.AnniversaryYear = year
+			contact.AnniversaryMonth = month
 		} else {
 			// Full date format: YYYYMMDD or YYYY-MM-DD
 			t, _ := time.Parse("20060102", anniversary)
@@ -567,18 +742,12 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 			labelToUse = label
 		}
 
-		// if none, look at the standard types
+		// if none, combine the standard types (e.g., "work", "work cell")
 		if labelToUse == "" {
-			for _, t := range field.Params.Types() {
-				t = strings.ToLower(t)
-				if t == "pref" {
-					email.IsPrimary = true
-					continue
-				}
-				// Take the first non-pref type we find (e.g., "work", "home")
-				if labelToUse == "" {
-					labelToUse = t
-				}
+			label, pref := typeLabelFromParams(field.Params)
+			labelToUse = label
+			if pref {
+				email.IsPrimary = true
 			}
 		}
 
@@ -587,14 +756,7 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 			labelToUse = "home"
 		}
 
-		key := getLabelKey("email", labelToUse)
-		if id, ok := revMap[key]; ok {
-			email.Type = id
-		} else {
-			// Here you could choose to auto-create the label in the DB
-			// Or default to 'other'
-			email.Type = revMap[getLabelKey("email", "home")]
-		}
+		email.Type, email.TypeLabel = resolveLabelID(revMap, opts, "email", labelToUse, "home")
 
 		contact.Emails = append(contact.Emails, email)
 	}
@@ -613,18 +775,12 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 			labelToUse = label
 		}
 
-		// if none, look at the standard types
+		// if none, combine the standard types (e.g., "work", "work cell")
 		if labelToUse == "" {
-			for _, t := range field.Params.Types() {
-				t = strings.ToLower(t)
-				if t == "pref" {
-					phone.IsPrimary = true
-					continue
-				}
-				// Take the first non-pref type we find (e.g., "work", "home")
-				if labelToUse == "" {
-					labelToUse = t
-				}
+			label, pref := typeLabelFromParams(field.Params)
+			labelToUse = label
+			if pref {
+				phone.IsPrimary = true
 			}
 		}
 
@@ -633,14 +789,7 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 			labelToUse = "cell"
 		}
 
-		key := getLabelKey("phone", labelToUse)
-		if id, ok := revMap[key]; ok {
-			phone.Type = id
-		} else {
-			// Here you could choose to auto-create the label in the DB
-			// Or default to 'other'
-			phone.Type = revMap[getLabelKey("phone", "cell")]
-		}
+		phone.Type, phone.TypeLabel = resolveLabelID(revMap, opts, "phone", labelToUse, "cell")
 
 		contact.Phones = append(contact.Phones, phone)
 	}
@@ -684,24 +833,17 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 			labelToUse = "home"
 		}
 
-		key := getLabelKey("address", labelToUse)
-		if id, ok := revMap[key]; ok {
-			address.Type = id
-		} else {
-			// Here you could choose to auto-create the label in the DB
-			// Or default to 'other'
-			address.Type = revMap[getLabelKey("address", "home")]
-		}
+		address.Type, address.TypeLabel = resolveLabelID(revMap, opts, "address", labelToUse, "home")
 		contact.Addresses = append(contact.Addresses, address)
 	}
 
-	// Organization
-	if org := card.Get(vcard.FieldOrganization); org != nil {
+	// Organizations -- TITLE, ROLE, and X-PHONETIC-ORG are matched by group
+	for i, org := range card[vcard.FieldOrganization] {
 		organization := models.Organization{
-			IsPrimary: true,
+			IsPrimary: i == 0,
 		}
 
-		if phoneticOrg := card.Get(XPhoneticOrgField); phoneticOrg != nil {
+		if phoneticOrg := getGroupedField(card, XPhoneticOrgField, org.Group); phoneticOrg != nil {
 			organization.PhoneticName = phoneticOrg.Value
 		}
 
@@ -713,12 +855,39 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 			organization.Department = parts[1] // Department
 		}
 
-		if title := card.Get(vcard.FieldTitle); title != nil {
+		if title := getGroupedField(card, vcard.FieldTitle, org.Group); title != nil {
 			organization.Title = title.Value
 		}
+		if role := getGroupedField(card, vcard.FieldRole, org.Group); role != nil {
+			organization.Role = role.Value
+		}
 		contact.Organizations = append(contact.Organizations, organization)
 	}
 
+	// Company card -- Apple flags these with X-ABShowAs and usually leaves N empty
+	if showAs := card.Get(XShowAsField); showAs != nil && strings.EqualFold(showAs.Value, XShowAsCompany) {
+		contact.IsOrganization = true
+	}
+
+	// Kind -- KIND:org is a company card too
+	contact.Kind = models.ContactKindIndividual
+	if field := card.Get(vcard.FieldKind); field != nil {
+		if kind, ok := models.NormalizeContactKind(field.Value); ok {
+			contact.Kind = kind
+		}
+	}
+	if contact.IsOrganization && contact.Kind == models.ContactKindIndividual {
+		contact.Kind = models.ContactKindOrg
+	}
+	if contact.Kind == models.ContactKindOrg {
+		contact.IsOrganization = true
+	}
+
+	// If FullName is empty, generate it
+	if contact.FullName == "" {
+		contact.FullName = fallbackFullName(contact, card)
+	}
+
 	// URLs
 	for _, field := range card[vcard.FieldURL] {
 		url := models.URL{URL: field.Value}
@@ -750,18 +919,35 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 			labelToUse = "home"
 		}
 
-		key := getLabelKey("url", labelToUse)
-		if id, ok := revMap[key]; ok {
-			url.Type = id
-		} else {
-			// Here you could choose to auto-create the label in the DB
-			// Or default to 'other'
-			url.Type = revMap[getLabelKey("url", "home")]
-		}
+		url.Type, url.TypeLabel = resolveLabelID(revMap, opts, "url", labelToUse, "home")
 
 		contact.URLs = append(contact.URLs, url)
 	}
 
+	// Instant Messaging
+	for _, field := range card[vcard.FieldIMPP] {
+		service, handle := parseIMPPValue(field.Value)
+		if handle == "" {
+			continue
+		}
+		if st := field.Params.Get(XServiceTypeParam); st != "" {
+			service = st
+		}
+
+		impp := models.IMPP{
+			Service:   service,
+			Handle:    handle,
+			IsPrimary: field.Params.Get(vcard.ParamPreferred) == "1",
+		}
+		for _, t := range field.Params.Types() {
+			if strings.EqualFold(t, "pref") {
+				impp.IsPrimary = true
+			}
+		}
+
+		contact.IMPPs = append(contact.IMPPs, impp)
+	}
+
 	// Notes
 	if note := card.Get(vcard.FieldNote); note != nil {
 		contact.Notes = note.Value
@@ -809,18 +995,14 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 			labelToUse = "profile"
 		}
 
-		key := getLabelKey("phone", labelToUse)
-		if id, ok := revMap[key]; ok {
-			url.Type = id
-		} else {
-			// Here you could choose to auto-create the label in the DB
-			// Or default to 'other'
-			url.Type = revMap[getLabelKey("url", "profile")]
-		}
+		url.Type, url.TypeLabel = resolveLabelID(revMap, opts, "url", labelToUse, "profile")
 
 		contact.URLs = append(contact.URLs, url)
 	}
 
+	// Everything else is kept verbatim so it survives the round trip
+	contact.RawVCardExtras = extractVCardExtras(card)
+
 	// X-ABLABELS and X-ABRELATEDNAME and X-ABDATE
 
 	fieldsOfInterest := map[string]struct{}{
@@ -931,7 +1113,7 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 		hasPartialDate := otherDate.EventDateMonth != nil && otherDate.EventDateDay != nil
 
 		if hasFullDate || hasPartialDate {
-			if strings.EqualFold(otherDate.EventName, "Anniversary") {
+			if opts.MergeAnniversaryDate && strings.EqualFold(otherDate.EventName, "Anniversary") {
 				if hasFullDate {
 					contact.Anniversary = otherDate.EventDate
 				} else {
@@ -982,13 +1164,61 @@ func VCardToContact(card vcard.Card, allContacts []*models.Contact, allRelations
 	return contact, nil
 }
 
-// VCardToContactShell converts a vCard to a limited Contact model: UID, FullName, and Gender
-func VCardToContactShell(card vcard.Card) (*models.Contact, error) {
+// DedupeVCardsByUID drops cards whose UID repeats an earlier card in the same import, which would
+// otherwise overwrite it (last write wins). The first card for each UID is kept; each dropped card
+// yields a warning naming both. Cards without a UID are always kept
+func DedupeVCardsByUID(cards []vcard.Card) ([]vcard.Card, []string) {
+	kept := make([]vcard.Card, 0, len(cards))
+	var warnings []string
+	firstSeen := make(map[string]int) // UID -> 1-based position of the kept card
+
+	for i, card := range cards {
+		uid := ""
+		if field := card.Get(vcard.FieldUID); field != nil {
+			uid = strings.TrimSpace(field.Value)
+		}
+		if uid == "" {
+			kept = append(kept, card)
+			continue
+		}
+
+		if first, ok := firstSeen[uid]; ok {
+			warnings = append(warnings, fmt.Sprintf("card %d (%s) has the same UID as card %d (%s) and was skipped: %s",
+				i+1, cardDisplayName(card), first, cardDisplayName(cards[first-1]), uid))
+			continue
+		}
+		firstSeen[uid] = i + 1
+		kept = append(kept, card)
+	}
+
+	return kept, warnings
+}
+
+// cardDisplayName names a card in messages: its FN, or "unnamed"
+func cardDisplayName(card vcard.Card) string {
+	if fn := strings.TrimSpace(card.PreferredValue(vcard.FieldFormattedName)); fn != "" {
+		return fn
+	}
+	return "unnamed"
+}
+
+// ApplyNewContactDefaults sets what a contact created from a vCard starts out with. Organizations
+// have no birthdays to remind about, so they're excluded from events. Call it only when creating:
+// on update the user's own choice is kept
+func ApplyNewContactDefaults(contact *models.Contact) {
+	if contact.Kind == models.ContactKindOrg {
+		contact.ExcludeFromEvents = true
+	}
+}
+
+// VCardToContactShell converts a vCard to a limited Contact model: UID, FullName, and Gender. A
+// card without a UID gets one qualified by uidDomain
+func VCardToContactShell(card vcard.Card, uidDomain string) (*models.Contact, error) {
 	uid := ""
 	if field := card.Get(vcard.FieldUID); field != nil && field.Value != "" {
 		uid = field.Value
 	} else {
-		uid = uuid.New().String() // generate a new UUID
+		uid = utils.NewUID(uidDomain)
 	}
 
 	contact := &models.Contact{
@@ -1010,7 +1240,7 @@ func VCardToContactShell(card vcard.Card) (*models.Contact, error) {
 
 	// If FullName is empty, generate it
 	if contact.FullName == "" {
-		contact.FullName = contact.GenerateFullName()
+		contact.FullName = fallbackFullName(contact, card)
 	}
 
 	// Gender
@@ -1020,3 +1250,164 @@ func VCardToContactShell(card vcard.Card) (*models.Contact, error) {
 
 	return contact, nil
 }
+
+// fallbackFullName builds a name for cards without FN: the company for company cards or cards with
+// no personal name (company-style cards often put the name only in ORG), otherwise the structured name
+func fallbackFullName(contact *models.Contact, card vcard.Card) string {
+	hasPersonalName := contact.GivenName != "" || contact.FamilyName != "" || contact.MiddleName != "" ||
+		contact.Nickname != ""
+
+	if contact.IsOrganization || !hasPersonalName {
+		if org := card.Get(vcard.FieldOrganization); org != nil {
+			if company := strings.TrimSpace(strings.Split(org.Value, ";")[0]); company != "" {
+				return company
+			}
+		}
+	}
+
+	return contact.GenerateFullName()
+}
+
+// parseCategories collects the comma-separated values of every CATEGORIES property, dropping
+// blanks and case-insensitive duplicates
+func parseCategories(card vcard.Card) []string {
+	tags := []string{}
+	seen := make(map[string]bool)
+	for _, field := range card[vcard.FieldCategories] {
+		for _, tag := range strings.Split(field.Value, ",") {
+			tag = strings.TrimSpace(tag)
+			key := strings.ToLower(tag)
+			if tag == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// knownProperties are the vCard properties VCardToContact maps or ContactToVCard generates. Any
+// other property is passed through in RawVCardExtras
+var knownProperties = map[string]bool{
+	vcard.FieldVersion:       true,
+	vcard.FieldKind:          true,
+	vcard.FieldUID:           true,
+	vcard.FieldFormattedName: true,
+	vcard.FieldName:          true,
+	vcard.FieldNickname:      true,
+	vcard.FieldGender:        true,
+	vcard.FieldGeolocation:   true,
+	vcard.FieldTimezone:      true,
+	vcard.FieldLanguage:      true,
+	vcard.FieldBirthday:      true,
+	vcard.FieldAnniversary:   true,
+	vcard.FieldEmail:         true,
+	vcard.FieldTelephone:     true,
+	vcard.FieldAddress:       true,
+	vcard.FieldOrganization:  true,
+	vcard.FieldTitle:         true,
+	vcard.FieldRole:          true,
+	vcard.FieldURL:           true,
+	vcard.FieldIMPP:          true,
+	vcard.FieldNote:          true,
+	vcard.FieldPhoto:         true,
+	vcard.FieldCategories:    true,
+	vcard.FieldRevision:      true,
+	XLabelField:              true,
+	XDateField:               true,
+	XRelatedNamesField:       true,
+	XSocialProfileField:      true,
+	XMaidenNameField:         true,
+	XSalutationField:         true,
+	XShowAsField:             true,
+	XPhoneticFirstField:      true,
+	XPronunciationFirstField: true,
+	XPhoneticLastField:       true,
+	XPronunciationLastField:  true,
+	XPhoneticMiddleField:     true,
+	XPhoneticOrgField:        true,
+}
+
+// extractVCardExtras returns the card's unknown properties as JSON, "{}" when there are none. An
+// X-ABLABEL naming only unknown properties of its group is kept with them
+func extractVCardExtras(card vcard.Card) string {
+	extras := make(vcard.Card)
+	knownGroups := make(map[string]bool)
+	for name, fields := range card {
+		known := knownProperties[strings.ToUpper(name)]
+		for _, field := range fields {
+			if known && name != XLabelField && field.Group != "" {
+				knownGroups[field.Group] = true
+			}
+		}
+		if !known {
+			extras[name] = fields
+		}
+	}
+
+	for _, label := range card[XLabelField] {
+		if label.Group != "" && !knownGroups[label.Group] && hasGroupedField(extras, label.Group) {
+			extras[XLabelField] = append(extras[XLabelField], label)
+		}
+	}
+
+	data, err := json.Marshal(extras)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// addVCardExtras re-adds the properties saved by extractVCardExtras. Grouped properties are moved
+// to fresh itemN groups (kept together) so they can't collide with the groups ContactToVCard uses
+func addVCardExtras(card vcard.Card, extrasJSON string, extraItemIndex *int) {
+	if extrasJSON == "" {
+		return
+	}
+
+	var extras vcard.Card
+	if err := json.Unmarshal([]byte(extrasJSON), &extras); err != nil {
+		return
+	}
+
+	names := make([]string, 0, len(extras))
+	for name := range extras {
+		// properties supported since they were saved are written from the contact instead
+		if !knownProperties[strings.ToUpper(name)] || name == XLabelField {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	groups := make(map[string]string)
+	for _, name := range names {
+		for _, field := range extras[name] {
+			if field == nil {
+				continue
+			}
+			if field.Group != "" {
+				group, ok := groups[field.Group]
+				if !ok {
+					group = "item" + strconv.Itoa(*extraItemIndex)
+					*extraItemIndex++
+					groups[field.Group] = group
+				}
+				field.Group = group
+			}
+			card.Add(name, field)
+		}
+	}
+}
+
+// hasGroupedField reports whether any property of card is in group
+func hasGroupedField(card vcard.Card, group string) bool {
+	for _, fields := range card {
+		for _, field := range fields {
+			if field.Group == group {
+				return true
+			}
+		}
+	}
+	return false
+}
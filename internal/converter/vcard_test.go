@@ -0,0 +1,761 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+// roundTrip encodes contact as a vCard, decodes the text again and imports it
+func roundTrip(t *testing.T, contact *models.Contact, isAppleClient bool) (vcard.Card, *models.Contact) {
+	t.Helper()
+
+	if contact.UID == "" {
+		contact.UID = "test-uid"
+	}
+	if contact.FullName == "" {
+		contact.FullName = "Test Contact"
+	}
+
+	var buf bytes.Buffer
+	if err := vcard.NewEncoder(&buf).Encode(ContactToVCard(contact, nil, isAppleClient)); err != nil {
+		t.Fatalf("encoding vCard: %v", err)
+	}
+	card, err := vcard.NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("decoding vCard: %v", err)
+	}
+
+	imported, err := VCardToContact(card, nil, nil, nil, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("importing vCard: %v", err)
+	}
+	return card, imported
+}
+
+func intValue(p *int) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func floatValue(p *float64) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func TestDateGranularityRoundTrip(t *testing.T) {
+	full := time.Date(1985, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		year        *int
+		month       *int
+		day         *int
+		date        *time.Time
+		wantBday    string
+		wantAnniver string
+	}{
+		{name: "full date", date: &full, wantBday: "19850615", wantAnniver: "19850615"},
+		{name: "month and day", month: utils.IntPtr(6), day: utils.IntPtr(15), wantBday: "--0615", wantAnniver: "--0615"},
+		{name: "year and month", year: utils.IntPtr(1985), month: utils.IntPtr(6), wantBday: "1985-06", wantAnniver: "1985-06"},
+		{name: "year only", year: utils.IntPtr(1985), wantBday: "1985", wantAnniver: "1985"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contact := &models.Contact{
+				Birthday:         tt.date,
+				BirthdayYear:     tt.year,
+				BirthdayMonth:    tt.month,
+				BirthdayDay:      tt.day,
+				Anniversary:      tt.date,
+				AnniversaryYear:  tt.year,
+				AnniversaryMonth: tt.month,
+				AnniversaryDay:   tt.day,
+			}
+
+			card, got := roundTrip(t, contact, false)
+
+			if v := card.Value(vcard.FieldBirthday); v != tt.wantBday {
+				t.Errorf("BDAY = %q, want %q", v, tt.wantBday)
+			}
+			if v := card.Value(vcard.FieldAnniversary); v != tt.wantAnniver {
+				t.Errorf("ANNIVERSARY = %q, want %q", v, tt.wantAnniver)
+			}
+
+			if (got.Birthday == nil) != (tt.date == nil) || (got.Birthday != nil && !got.Birthday.Equal(*tt.date)) {
+				t.Errorf("Birthday = %v, want %v", got.Birthday, tt.date)
+			}
+			if (got.Anniversary == nil) != (tt.date == nil) || (got.Anniversary != nil && !got.Anniversary.Equal(*tt.date)) {
+				t.Errorf("Anniversary = %v, want %v", got.Anniversary, tt.date)
+			}
+
+			checks := []struct {
+				field     string
+				got, want *int
+			}{
+				{"BirthdayYear", got.BirthdayYear, tt.year},
+				{"BirthdayMonth", got.BirthdayMonth, tt.month},
+				{"BirthdayDay", got.BirthdayDay, tt.day},
+				{"AnniversaryYear", got.AnniversaryYear, tt.year},
+				{"AnniversaryMonth", got.AnniversaryMonth, tt.month},
+				{"AnniversaryDay", got.AnniversaryDay, tt.day},
+			}
+			for _, c := range checks {
+				if intValue(c.got) != intValue(c.want) {
+					t.Errorf("%s = %v, want %v", c.field, intValue(c.got), intValue(c.want))
+				}
+			}
+		})
+	}
+}
+
+// parseCard decodes a single vCard from its text, with CRLF line endings added
+func parseCard(t *testing.T, lines ...string) vcard.Card {
+	t.Helper()
+
+	text := "BEGIN:VCARD\r\nVERSION:3.0\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCARD\r\n"
+	card, err := vcard.NewDecoder(strings.NewReader(text)).Decode()
+	if err != nil {
+		t.Fatalf("decoding vCard: %v", err)
+	}
+	return card
+}
+
+func TestImportAnniversaryOtherDate(t *testing.T) {
+	card := parseCard(t,
+		"UID:anniversary-test",
+		"FN:Alice",
+		"item1.X-ABDATE:2015-09-12",
+		"item1.X-ABLABEL:_$!<Anniversary>!$_",
+	)
+	wedding := time.Date(2015, time.September, 12, 0, 0, 0, 0, time.UTC)
+
+	t.Run("merge", func(t *testing.T) {
+		opts := DefaultImportOptions()
+		opts.MergeAnniversaryDate = true
+
+		contact, err := VCardToContact(card, nil, nil, nil, opts)
+		if err != nil {
+			t.Fatalf("VCardToContact: %v", err)
+		}
+		if contact.Anniversary == nil || !contact.Anniversary.Equal(wedding) {
+			t.Errorf("Anniversary = %v, want %v", contact.Anniversary, wedding)
+		}
+		if len(contact.OtherDates) != 0 {
+			t.Errorf("OtherDates = %+v, want none", contact.OtherDates)
+		}
+	})
+
+	t.Run("keep separate", func(t *testing.T) {
+		opts := DefaultImportOptions()
+		opts.MergeAnniversaryDate = false
+
+		contact, err := VCardToContact(card, nil, nil, nil, opts)
+		if err != nil {
+			t.Fatalf("VCardToContact: %v", err)
+		}
+		if contact.Anniversary != nil {
+			t.Errorf("Anniversary = %v, want none", contact.Anniversary)
+		}
+		if len(contact.OtherDates) != 1 {
+			t.Fatalf("got %d other dates, want 1", len(contact.OtherDates))
+		}
+		date := contact.OtherDates[0]
+		if date.EventName != "Anniversary" {
+			t.Errorf("EventName = %q, want %q", date.EventName, "Anniversary")
+		}
+		if date.EventDate == nil || !date.EventDate.Equal(wedding) {
+			t.Errorf("EventDate = %v, want %v", date.EventDate, wedding)
+		}
+	})
+}
+
+func TestPhoneticFieldsRoundTrip(t *testing.T) {
+	card := parseCard(t,
+		"UID:phonetic-test",
+		"FN:Test Contact",
+		"X-PHONETIC-FIRST-NAME:first",
+		"X-PRONUNCIATION-FIRST-NAME:first-pron",
+		"X-PHONETIC-MIDDLE-NAME:middle",
+		"X-PHONETIC-LAST-NAME:last",
+		"X-PRONUNCIATION-LAST-NAME:last-pron",
+	)
+
+	contact, err := VCardToContact(card, nil, nil, nil, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+
+	fields := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{XPhoneticFirstField, contact.PhoneticFirstName, "first"},
+		{XPronunciationFirstField, contact.PronunciationFirstName, "first-pron"},
+		{XPhoneticMiddleField, contact.PhoneticMiddleName, "middle"},
+		{XPhoneticLastField, contact.PhoneticLastName, "last"},
+		{XPronunciationLastField, contact.PronunciationLastName, "last-pron"},
+	}
+	for _, f := range fields {
+		if f.got != f.want {
+			t.Errorf("%s imported as %q, want %q", f.name, f.got, f.want)
+		}
+	}
+
+	exported, reimported := roundTrip(t, contact, false)
+	for _, f := range fields {
+		if v := exported.Value(f.name); v != f.want {
+			t.Errorf("%s exported as %q, want %q", f.name, v, f.want)
+		}
+	}
+	if reimported.PhoneticFirstName != contact.PhoneticFirstName ||
+		reimported.PronunciationFirstName != contact.PronunciationFirstName ||
+		reimported.PhoneticMiddleName != contact.PhoneticMiddleName ||
+		reimported.PhoneticLastName != contact.PhoneticLastName ||
+		reimported.PronunciationLastName != contact.PronunciationLastName {
+		t.Errorf("re-imported phonetics %+v differ from the original %+v", reimported, contact)
+	}
+}
+
+// testLabels returns a label map with a few system labels and custom labels, and the matching
+// reverse map used on import
+func testLabels() (map[int]models.ContactLabelType, map[string]int) {
+	labels := map[int]models.ContactLabelType{
+		1: {ID: 1, Name: "work", Category: "phone", IsSystem: true},
+		2: {ID: 2, Name: "cell", Category: "phone", IsSystem: true},
+		3: {ID: 3, Name: "work cell", Category: "phone"},
+		4: {ID: 4, Name: "home", Category: "email", IsSystem: true},
+		5: {ID: 5, Name: "beach house", Category: "address"},
+		6: {ID: 6, Name: "home", Category: "address", IsSystem: true},
+	}
+	revMap := make(map[string]int, len(labels))
+	for id, l := range labels {
+		revMap[getLabelKey(l.Category, l.Name)] = id
+	}
+	return labels, revMap
+}
+
+func TestMultiTypePhoneRoundTrip(t *testing.T) {
+	labels, revMap := testLabels()
+
+	card := parseCard(t,
+		"UID:multi-type-test",
+		"FN:Test Contact",
+		"TEL;TYPE=work,cell:+15555550100",
+	)
+	contact, err := VCardToContact(card, nil, nil, revMap, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+	if len(contact.Phones) != 1 || contact.Phones[0].Type != 3 {
+		t.Fatalf("phones = %+v, want one with the \"work cell\" label", contact.Phones)
+	}
+
+	exported := ContactToVCard(contact, labels, false)
+	tel := exported.Get(vcard.FieldTelephone)
+	if tel == nil {
+		t.Fatal("TEL was not exported")
+	}
+	if types := tel.Params.Types(); len(types) != 2 || types[0] != "work" || types[1] != "cell" {
+		t.Errorf("TEL TYPE = %v, want [work cell]", types)
+	}
+	if tel.Group != "" {
+		t.Errorf("TEL was exported in group %q with a custom label instead of as types", tel.Group)
+	}
+
+	reimported, err := VCardToContact(exported, nil, nil, revMap, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("re-importing: %v", err)
+	}
+	if len(reimported.Phones) != 1 || reimported.Phones[0].Type != 3 {
+		t.Errorf("re-imported phones = %+v, want one with the \"work cell\" label", reimported.Phones)
+	}
+}
+
+func TestMultipleOrganizationsRoundTrip(t *testing.T) {
+	orgs := []models.Organization{
+		{Name: "Acme Corp", Department: "Engineering", Title: "CTO"},
+		{Name: "Example Foundation", Title: "Board Member", Role: "Treasurer"},
+		{Name: "Widgets Inc", Department: "Sales"},
+	}
+
+	card, got := roundTrip(t, &models.Contact{Organizations: orgs}, false)
+
+	if n := len(card[vcard.FieldOrganization]); n != len(orgs) {
+		t.Errorf("got %d ORG properties, want %d", n, len(orgs))
+	}
+	if len(got.Organizations) != len(orgs) {
+		t.Fatalf("re-imported %d organizations, want %d: %+v", len(got.Organizations), len(orgs), got.Organizations)
+	}
+	for i, want := range orgs {
+		o := got.Organizations[i]
+		if o.Name != want.Name || o.Department != want.Department || o.Title != want.Title || o.Role != want.Role {
+			t.Errorf("organization %d = %+v, want %+v", i, o, want)
+		}
+	}
+}
+
+func TestIMPPRoundTrip(t *testing.T) {
+	impps := []models.IMPP{
+		{Service: "Signal", Handle: "+15555550100", IsPrimary: true},
+		{Service: "Matrix", Handle: "@alice:example.org"},
+	}
+
+	card, got := roundTrip(t, &models.Contact{IMPPs: impps}, false)
+
+	if n := len(card[vcard.FieldIMPP]); n != len(impps) {
+		t.Errorf("got %d IMPP properties, want %d", n, len(impps))
+	}
+	if len(got.IMPPs) != len(impps) {
+		t.Fatalf("re-imported %d IMPPs, want %d: %+v", len(got.IMPPs), len(impps), got.IMPPs)
+	}
+	for i, want := range impps {
+		if got.IMPPs[i] != want {
+			t.Errorf("IMPP %d = %+v, want %+v", i, got.IMPPs[i], want)
+		}
+	}
+}
+
+func TestSalutationRoundTrip(t *testing.T) {
+	card, got := roundTrip(t, &models.Contact{Prefix: "Dr.", FamilyName: "Smith", Salutation: "Dr. Smith"}, false)
+
+	if v := card.Value(XSalutationField); v != "Dr. Smith" {
+		t.Errorf("%s = %q, want %q", XSalutationField, v, "Dr. Smith")
+	}
+	if got.Salutation != "Dr. Smith" || got.Prefix != "Dr." {
+		t.Errorf("re-imported salutation %q and prefix %q, want %q and %q", got.Salutation, got.Prefix, "Dr. Smith", "Dr.")
+	}
+}
+
+func TestCompanyCardRoundTrip(t *testing.T) {
+	company := &models.Contact{
+		FullName:       "Acme Corp",
+		IsOrganization: true,
+		Organizations:  []models.Organization{{Name: "Acme Corp", Department: "Sales"}},
+	}
+
+	card, got := roundTrip(t, company, true)
+
+	if v := card.Value(XShowAsField); v != XShowAsCompany {
+		t.Errorf("%s = %q, want %q", XShowAsField, v, XShowAsCompany)
+	}
+	if !got.IsOrganization || got.Kind != models.ContactKindOrg {
+		t.Errorf("re-imported IsOrganization %v and kind %q, want a company card", got.IsOrganization, got.Kind)
+	}
+	if got.FullName != "Acme Corp" {
+		t.Errorf("FullName = %q, want %q", got.FullName, "Acme Corp")
+	}
+	if len(got.Organizations) != 1 || got.Organizations[0].Name != "Acme Corp" || got.Organizations[0].Department != "Sales" {
+		t.Errorf("organizations = %+v, want Acme Corp / Sales", got.Organizations)
+	}
+}
+
+func TestImportCompanyCardWithoutName(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		lines []string
+	}{
+		{"X-ABShowAs", []string{"N:;;;;", "ORG:Acme Corp;Sales", "X-ABShowAs:COMPANY"}},
+		{"no personal name", []string{"N:;;;;", "ORG:Acme Corp;Sales"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			card := parseCard(t, append([]string{"UID:company-test"}, tt.lines...)...)
+
+			contact, err := VCardToContact(card, nil, nil, nil, DefaultImportOptions())
+			if err != nil {
+				t.Fatalf("VCardToContact: %v", err)
+			}
+			if contact.FullName != "Acme Corp" {
+				t.Errorf("FullName = %q, want the company name", contact.FullName)
+			}
+			if len(contact.Organizations) != 1 || contact.Organizations[0].Name != "Acme Corp" {
+				t.Errorf("organizations = %+v, want Acme Corp", contact.Organizations)
+			}
+		})
+	}
+
+	// A card with a personal name keeps it even when it also has an ORG
+	card := parseCard(t, "UID:person-test", "N:Liddell;Alice;;;", "ORG:Acme Corp")
+	contact, err := VCardToContact(card, nil, nil, nil, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+	if contact.FullName != "Alice Liddell" || contact.IsOrganization {
+		t.Errorf("got %q (organization %v), want the person Alice Liddell", contact.FullName, contact.IsOrganization)
+	}
+}
+
+func TestCategoriesRoundTrip(t *testing.T) {
+	card, got := roundTrip(t, &models.Contact{Tags: []string{"friends", "book club"}}, false)
+
+	if n := len(card[vcard.FieldCategories]); n != 2 {
+		t.Errorf("got %d CATEGORIES properties, want one per tag", n)
+	}
+	if strings.Join(got.Tags, "|") != "friends|book club" {
+		t.Errorf("re-imported tags = %q, want friends and book club", got.Tags)
+	}
+
+	card = parseCard(t, "UID:categories-test", "FN:Alice", "CATEGORIES:Family,Work, family", "CATEGORIES:Book Club")
+	contact, err := VCardToContact(card, nil, nil, nil, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+	if strings.Join(contact.Tags, "|") != "Family|Work|Book Club" {
+		t.Errorf("tags = %q, want Family, Work, and Book Club without the duplicate", contact.Tags)
+	}
+}
+
+func TestCategoriesKeepOrder(t *testing.T) {
+	card := parseCard(t, "UID:categories-order-test", "FN:Alice", "CATEGORIES:Work,Friends")
+	contact, err := VCardToContact(card, nil, nil, nil, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+
+	_, got := roundTrip(t, contact, false)
+	if strings.Join(got.Tags, ",") != "Work,Friends" {
+		t.Errorf("re-exported categories = %q, want Work,Friends in that order", got.Tags)
+	}
+}
+
+func TestNicknameAsFullName(t *testing.T) {
+	tests := []struct {
+		name           string
+		nickname       string
+		preferNickname bool
+		want           string
+	}{
+		{"preferred", "Johnny", true, "Johnny"},
+		{"no nickname", "", true, "John Doe"},
+		{"not preferred", "Johnny", false, "John Doe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contact := &models.Contact{UID: "nickname-test", FullName: "John Doe", Nickname: tt.nickname}
+
+			card := ContactToVCard(NicknameAsFullName(contact, tt.preferNickname), nil, false)
+			if fn := card.Value(vcard.FieldFormattedName); fn != tt.want {
+				t.Errorf("FN = %q, want %q", fn, tt.want)
+			}
+			if contact.FullName != "John Doe" {
+				t.Errorf("stored full name changed to %q", contact.FullName)
+			}
+		})
+	}
+}
+
+func TestVCardToContactUIDDomain(t *testing.T) {
+	opts := DefaultImportOptions()
+	opts.UIDDomain = "example.com"
+
+	contact, err := VCardToContact(parseCard(t, "FN:Alice"), nil, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+	if !strings.HasSuffix(contact.UID, "@example.com") {
+		t.Errorf("generated UID = %q, want it qualified by example.com", contact.UID)
+	}
+
+	contact, err = VCardToContact(parseCard(t, "UID:3f2b9c1e-bare", "FN:Bob"), nil, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+	if contact.UID != "3f2b9c1e-bare" {
+		t.Errorf("UID = %q, want the card's bare UID kept", contact.UID)
+	}
+}
+
+func TestTitleAndRoleRoundTrip(t *testing.T) {
+	org := models.Organization{Name: "Acme Corp", Title: "Senior Engineer", Role: "Fire Warden"}
+
+	card, got := roundTrip(t, &models.Contact{Organizations: []models.Organization{org}}, false)
+
+	if title := card.Value(vcard.FieldTitle); title != org.Title {
+		t.Errorf("TITLE = %q, want %q", title, org.Title)
+	}
+	if role := card.Value(vcard.FieldRole); role != org.Role {
+		t.Errorf("ROLE = %q, want %q", role, org.Role)
+	}
+	if len(got.Organizations) != 1 {
+		t.Fatalf("re-imported %d organizations, want 1", len(got.Organizations))
+	}
+	if o := got.Organizations[0]; o.Title != org.Title || o.Role != org.Role {
+		t.Errorf("re-imported title %q and role %q, want %q and %q", o.Title, o.Role, org.Title, org.Role)
+	}
+}
+
+func TestDedupeVCardsByUID(t *testing.T) {
+	card := func(uid, fn string) vcard.Card {
+		c := vcard.Card{}
+		c.SetValue(vcard.FieldFormattedName, fn)
+		if uid != "" {
+			c.SetValue(vcard.FieldUID, uid)
+		}
+		return c
+	}
+	cards := []vcard.Card{
+		card("uid-1", "Alice"),
+		card("uid-2", "Bob"),
+		card(" uid-1 ", "Alice Again"),
+		card("", "No UID"),
+		card("", "Also No UID"),
+	}
+
+	kept, warnings := DedupeVCardsByUID(cards)
+
+	var names []string
+	for _, c := range kept {
+		names = append(names, c.PreferredValue(vcard.FieldFormattedName))
+	}
+	if want := []string{"Alice", "Bob", "No UID", "Also No UID"}; strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("kept %q, want %q", names, want)
+	}
+	want := "card 3 (Alice Again) has the same UID as card 1 (Alice) and was skipped: uid-1"
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("warnings = %q, want [%q]", warnings, want)
+	}
+}
+
+func TestGeoTimezoneLanguagesRoundTrip(t *testing.T) {
+	card := parseCard(t,
+		"UID:geo-test",
+		"FN:Alice Liddell",
+		"GEO:geo:37.386,-122.084",
+		"TZ:America/Los_Angeles",
+		"LANG;PREF=2:fr",
+		"LANG;PREF=1:en",
+	)
+
+	imported, err := VCardToContact(card, nil, nil, nil, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+
+	check := func(stage string, contact *models.Contact) {
+		t.Helper()
+		if contact.Latitude == nil || contact.Longitude == nil || *contact.Latitude != 37.386 || *contact.Longitude != -122.084 {
+			t.Errorf("%s: location = %v, %v, want 37.386, -122.084", stage, floatValue(contact.Latitude), floatValue(contact.Longitude))
+		}
+		if contact.Timezone != "America/Los_Angeles" {
+			t.Errorf("%s: Timezone = %q, want America/Los_Angeles", stage, contact.Timezone)
+		}
+		if len(contact.Languages) != 2 || contact.Languages[0] != "en" || contact.Languages[1] != "fr" {
+			t.Errorf("%s: Languages = %q, want [en fr] by preference", stage, contact.Languages)
+		}
+	}
+	check("import", imported)
+
+	exported, got := roundTrip(t, imported, false)
+	if v := exported.Value(vcard.FieldGeolocation); v != "geo:37.386,-122.084" {
+		t.Errorf("GEO = %q, want geo:37.386,-122.084", v)
+	}
+	check("round trip", got)
+}
+
+func TestParseGeoValue(t *testing.T) {
+	tests := []struct {
+		value     string
+		latitude  float64
+		longitude float64
+		ok        bool
+	}{
+		{"geo:37.386,-122.084", 37.386, -122.084, true},
+		{"geo:37.386,-122.084;u=35", 37.386, -122.084, true},
+		{"GEO:-33.8688,151.2093", -33.8688, 151.2093, true},
+		{"37.386;-122.084", 37.386, -122.084, true}, // vCard 3.0
+		{"geo:91,0", 0, 0, false},
+		{"geo:0,181", 0, 0, false},
+		{"geo:37.386", 0, 0, false},
+		{"somewhere", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		latitude, longitude, ok := parseGeoValue(tt.value)
+		if ok != tt.ok || latitude != tt.latitude || longitude != tt.longitude {
+			t.Errorf("parseGeoValue(%q) = %v, %v, %v; want %v, %v, %v", tt.value, latitude, longitude, ok, tt.latitude, tt.longitude, tt.ok)
+		}
+	}
+}
+
+func TestUnknownPropertiesRoundTrip(t *testing.T) {
+	card := parseCard(t,
+		"UID:extras-test",
+		"FN:Alice Liddell",
+		"X-CUSTOM-ID:crm-4821",
+		"X-MS-MANAGER;TYPE=WORK:The Queen",
+		"item7.X-WONDERLAND-HANDLE:@alice",
+		"item7.X-ABLABEL:Wonderland",
+		"item8.EMAIL;TYPE=INTERNET:alice@example.com",
+		"item8.X-ABLABEL:Rabbit Hole",
+	)
+
+	imported, err := VCardToContact(card, nil, nil, nil, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+
+	exported, _ := roundTrip(t, imported, false)
+
+	if f := exported.Get("X-CUSTOM-ID"); f == nil || f.Value != "crm-4821" {
+		t.Errorf("X-CUSTOM-ID = %+v, want crm-4821", f)
+	}
+	if f := exported.Get("X-MS-MANAGER"); f == nil || f.Value != "The Queen" || f.Params.Get(vcard.ParamType) != "WORK" {
+		t.Errorf("X-MS-MANAGER = %+v, want The Queen with TYPE=WORK", f)
+	}
+
+	handle := exported.Get("X-WONDERLAND-HANDLE")
+	if handle == nil || handle.Value != "@alice" || handle.Group == "" {
+		t.Fatalf("X-WONDERLAND-HANDLE = %+v, want @alice in an item group", handle)
+	}
+	// The label of an unknown grouped property follows it to its new group
+	var labels []string
+	for _, label := range exported[XLabelField] {
+		if label.Group == handle.Group {
+			labels = append(labels, label.Value)
+		}
+	}
+	if len(labels) != 1 || labels[0] != "Wonderland" {
+		t.Errorf("labels in %s = %q, want [Wonderland]", handle.Group, labels)
+	}
+
+	// Known properties aren't duplicated from the extras
+	if n := len(exported[vcard.FieldEmail]); n != 1 {
+		t.Errorf("got %d EMAIL properties, want 1", n)
+	}
+}
+
+func TestImportKind(t *testing.T) {
+	tests := []struct {
+		kind       string
+		want       string
+		isOrg      bool
+		newExclude bool
+	}{
+		{"org", models.ContactKindOrg, true, true},
+		{"ORGANIZATION", models.ContactKindOrg, true, true},
+		{"group", models.ContactKindGroup, false, false},
+		{"individual", models.ContactKindIndividual, false, false},
+		{"location", models.ContactKindIndividual, false, false},
+	}
+
+	for _, tt := range tests {
+		card := parseCard(t, "UID:kind-test", "FN:Acme Corp", "KIND:"+tt.kind)
+
+		contact, err := VCardToContact(card, nil, nil, nil, DefaultImportOptions())
+		if err != nil {
+			t.Fatalf("KIND:%s: VCardToContact: %v", tt.kind, err)
+		}
+		if contact.Kind != tt.want || contact.IsOrganization != tt.isOrg {
+			t.Errorf("KIND:%s: kind %q (organization %v), want %q (%v)", tt.kind, contact.Kind, contact.IsOrganization, tt.want, tt.isOrg)
+		}
+		// Parsing alone leaves event reminders to the caller
+		if contact.ExcludeFromEvents {
+			t.Errorf("KIND:%s: VCardToContact excluded the contact from events", tt.kind)
+		}
+
+		ApplyNewContactDefaults(contact)
+		if contact.ExcludeFromEvents != tt.newExclude {
+			t.Errorf("KIND:%s: ExcludeFromEvents = %v for a new contact, want %v", tt.kind, contact.ExcludeFromEvents, tt.newExclude)
+		}
+	}
+}
+
+func TestKindRoundTrip(t *testing.T) {
+	card, got := roundTrip(t, &models.Contact{FullName: "Book Club", Kind: models.ContactKindGroup}, false)
+	if v := card.Value(vcard.FieldKind); v != models.ContactKindGroup {
+		t.Errorf("KIND = %q, want %q", v, models.ContactKindGroup)
+	}
+	if got.Kind != models.ContactKindGroup {
+		t.Errorf("re-imported kind %q, want %q", got.Kind, models.ContactKindGroup)
+	}
+
+	// Individuals are the default and aren't written out
+	card, _ = roundTrip(t, &models.Contact{FullName: "Alice", Kind: models.ContactKindIndividual}, false)
+	if f := card.Get(vcard.FieldKind); f != nil {
+		t.Errorf("KIND = %q for an individual, want none", f.Value)
+	}
+}
+
+func TestCustomPhoneLabelExportsXABLabel(t *testing.T) {
+	labels, revMap := testLabels()
+	labels[7] = models.ContactLabelType{ID: 7, Name: "school", Category: "phone"}
+	revMap[getLabelKey("phone", "school")] = 7
+
+	contact := &models.Contact{
+		UID:      "custom-label-test",
+		FullName: "Alice",
+		Phones:   []models.Phone{{Phone: "+15555550100", Type: 7}},
+	}
+
+	var buf bytes.Buffer
+	if err := vcard.NewEncoder(&buf).Encode(ContactToVCard(contact, labels, false)); err != nil {
+		t.Fatalf("encoding vCard: %v", err)
+	}
+	text := buf.String()
+	for _, want := range []string{"item1.TEL:+15555550100\r\n", "item1.X-ABLABEL:school\r\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("vCard is missing %q:\n%s", want, text)
+		}
+	}
+
+	card, err := vcard.NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("decoding vCard: %v", err)
+	}
+	reimported, err := VCardToContact(card, nil, nil, revMap, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+	if len(reimported.Phones) != 1 || reimported.Phones[0].Type != 7 {
+		t.Errorf("re-imported phones = %+v, want one with the school label", reimported.Phones)
+	}
+}
+
+func TestImportUnknownLabel(t *testing.T) {
+	_, revMap := testLabels()
+	card := parseCard(t,
+		"UID:unknown-label-test",
+		"FN:Alice",
+		"item1.EMAIL;TYPE=INTERNET:alice@example.com",
+		"item1.X-ABLABEL:Beach House",
+		"item2.EMAIL;TYPE=INTERNET:alice@example.org",
+		"item2.X-ABLABEL:home",
+	)
+
+	opts := DefaultImportOptions()
+	opts.CreateLabels = true
+	contact, err := VCardToContact(card, nil, nil, revMap, opts)
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+	if len(contact.Emails) != 2 {
+		t.Fatalf("got %d emails, want 2", len(contact.Emails))
+	}
+	// The unknown label is left for the database to create; a known one is used as is
+	if e := contact.Emails[0]; e.Type != 0 || e.TypeLabel != "beach house" {
+		t.Errorf("first email label %d %q, want a new \"beach house\" label", e.Type, e.TypeLabel)
+	}
+	if e := contact.Emails[1]; e.Type != 4 || e.TypeLabel != "" {
+		t.Errorf("second email label %d %q, want the existing home label 4", e.Type, e.TypeLabel)
+	}
+
+	// Without CreateLabels the unknown label falls back to home
+	contact, err = VCardToContact(card, nil, nil, revMap, DefaultImportOptions())
+	if err != nil {
+		t.Fatalf("VCardToContact: %v", err)
+	}
+	if e := contact.Emails[0]; e.Type != 4 || e.TypeLabel != "" {
+		t.Errorf("first email label %d %q without CreateLabels, want the home label 4", e.Type, e.TypeLabel)
+	}
+}
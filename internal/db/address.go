@@ -18,7 +18,14 @@ func (d *Database) CreateContactAddress(userID int, body models.Address) (int, e
 		utils.Dump(body)
 	}
 
-	err := d.db.QueryRow(
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		"INSERT INTO addresses (contact_id, street, extended_street, city, state, postal_code, country, label_type_id, is_primary) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id",
 		body.ContactID, body.Street, body.ExtendedStreet, body.City, body.State, body.PostalCode, body.Country, body.Type, body.IsPrimary,
 	).Scan(&body.ID)
@@ -31,16 +38,17 @@ func (d *Database) CreateContactAddress(userID int, body models.Address) (int, e
 		return 0, fmt.Errorf("failed to create address: %w", err)
 	}
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return body.ID, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, body.ContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return 0, err
 	}
 
-	if err := d.bumpContactSyncToken(body.ContactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return 0, err
 	}
 
+	d.contactsUpdated(userID, body.ContactID)
+
 	return body.ID, nil
 }
 
@@ -123,8 +131,15 @@ func (d *Database) UpdateContactAddress(userID int, body models.AddressJSONPatch
 
 	args = append(args, body.ID, userID)
 
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var contactID int
-	err := d.db.QueryRow(query, args...).Scan(&contactID)
+	err = tx.QueryRow(query, args...).Scan(&contactID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			logger.Error("No rows patched: %v", err)
@@ -134,37 +149,45 @@ func (d *Database) UpdateContactAddress(userID int, body models.AddressJSONPatch
 		return nil, fmt.Errorf("failed to patch address: %w", err)
 	}
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return nil, err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
+	d.contactsUpdated(userID, contactID)
+
 	return d.getAddresses(contactID)
 }
 
 func (d *Database) DeleteContactAddress(userID int, contactID int, addressID int) error {
 	logger.Debug("[DATABASE] Begin DeleteContactAddress(userID:%d, contactID:%d, addressID:%d)", userID, contactID, addressID)
 
-	_, err := d.db.Exec("DELETE FROM addresses WHERE id = $1 AND contact_id = $2", addressID, contactID)
+	tx, err := d.db.Begin()
 	if err != nil {
-		logger.Error("[DATABASE] Error deleting Address: %v", err)
+		logger.Error("[DATABASE] Error starting tx: %v", err)
 		return err
 	}
+	defer tx.Rollback()
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	_, err = tx.Exec("DELETE FROM addresses WHERE id = $1 AND contact_id = $2", addressID, contactID)
 	if err != nil {
-		return fmt.Errorf("failed to increment sync token: %w", err)
+		logger.Error("[DATABASE] Error deleting Address: %v", err)
+		return err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	d.contactsUpdated(userID, contactID)
 	return nil
 }
@@ -22,6 +22,7 @@ import (
 
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
 )
 
 // GenerateAPIToken generates a cryptographically secure random token
@@ -63,10 +64,11 @@ func HashToken(token string) string {
 	return fmt.Sprintf("%x", hash)
 }
 
-// CreateAPIToken creates a new API token for a user
+// CreateAPIToken creates a new API token for a user with the given scope (models.APITokenScopeRead
+// or models.APITokenScopeWrite)
 // Returns the token WITH the raw token (only time it's exposed)
-func (d *Database) CreateAPIToken(userID int, name string, expiresAt *time.Time) (*models.APITokenWithRaw, error) {
-	logger.Debug("[DATABASE] Begin CreateAPIToken(userID:%d, name:%s, expiresAt:%v)", userID, name, expiresAt)
+func (d *Database) CreateAPIToken(userID int, name string, scope string, expiresAt *time.Time) (*models.APITokenWithRaw, error) {
+	logger.Debug("[DATABASE] Begin CreateAPIToken(userID:%d, name:%s, scope:%s, expiresAt:%v)", userID, name, scope, expiresAt)
 
 	// Get APP_KEY for HMAC signing
 	appKey := os.Getenv("APP_KEY")
@@ -88,16 +90,16 @@ func (d *Database) CreateAPIToken(userID int, name string, expiresAt *time.Time)
 
 	// Insert into database
 	query := `
-		INSERT INTO api_tokens (user_id, token_hash, token_signature, name, expires_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, user_id, token_hash, name, last_used_at, created_at, expires_at, is_active
+		INSERT INTO api_tokens (user_id, token_hash, token_signature, name, expires_at, scope)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, user_id, token_hash, name, last_used_at, created_at, expires_at, is_active, scope
 	`
 
 	var token models.APIToken
 	var lastUsedAt sql.NullTime
 	var expiresAtDB sql.NullTime
 
-	err = d.db.QueryRow(query, userID, tokenHash, signature, name, expiresAt).Scan(
+	err = d.db.QueryRow(query, userID, tokenHash, signature, name, expiresAt, scope).Scan(
 		&token.ID,
 		&token.UserID,
 		&token.TokenHash,
@@ -106,6 +108,7 @@ func (d *Database) CreateAPIToken(userID int, name string, expiresAt *time.Time)
 		&token.CreatedAt,
 		&expiresAtDB,
 		&token.IsActive,
+		&token.Scope,
 	)
 	if err != nil {
 		logger.Error("[DATABASE] Error inserting api token: %v", err)
@@ -127,28 +130,28 @@ func (d *Database) CreateAPIToken(userID int, name string, expiresAt *time.Time)
 	}, nil
 }
 
-// ValidateAPIToken checks if a token is valid and returns the associated user ID
-// Also updates the last_used_at timestamp
+// ValidateAPIToken checks if a token is valid and returns the associated user ID and the token's scope
+// Usage is recorded separately with RecordAPITokenUse
 // Now includes HMAC signature verification for extra security
-func (d *Database) ValidateAPIToken(rawToken string) (int, error) {
+func (d *Database) ValidateAPIToken(rawToken string) (int, string, error) {
 	logger.Debug("[DATABASE] Begin ValidateAPIToken(rawToken:--)")
 
 	// Get APP_KEY for HMAC verification
 	appKey := os.Getenv("APP_KEY")
 	if appKey == "" {
-		return 0, fmt.Errorf("APP_KEY not set")
+		return 0, "", fmt.Errorf("APP_KEY not set")
 	}
 
 	// Validate token format
 	if !strings.HasPrefix(rawToken, "kc_live_") {
-		return 0, fmt.Errorf("invalid token format")
+		return 0, "", fmt.Errorf("invalid token format")
 	}
 
 	tokenHash := HashToken(rawToken)
 
 	// Query to get token details including signature
 	query := `
-		SELECT user_id, token_signature
+		SELECT user_id, token_signature, scope
 		FROM api_tokens
 		WHERE token_hash = $1
 			AND is_active = true
@@ -157,33 +160,40 @@ func (d *Database) ValidateAPIToken(rawToken string) (int, error) {
 
 	var userID int
 	var storedSignature string
-	err := d.db.QueryRow(query, tokenHash).Scan(&userID, &storedSignature)
+	var scope string
+	err := d.db.QueryRow(query, tokenHash).Scan(&userID, &storedSignature, &scope)
 	if err == sql.ErrNoRows {
-		return 0, fmt.Errorf("invalid or expired token")
+		return 0, "", fmt.Errorf("invalid or expired token")
 	}
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting api token: %v", err)
-		return 0, fmt.Errorf("failed to validate token: %w", err)
+		return 0, "", fmt.Errorf("failed to validate token: %w", err)
 	}
 
 	// Verify HMAC signature
 	if !VerifyTokenSignature(rawToken, storedSignature, appKey) {
-		return 0, fmt.Errorf("invalid token signature")
+		return 0, "", fmt.Errorf("invalid token signature")
 	}
 
-	// Update last_used_at
-	updateQuery := `
+	return userID, scope, nil
+}
+
+// RecordAPITokenUse stamps a token with the time, client IP, and User-Agent of a request made with it
+func (d *Database) RecordAPITokenUse(rawToken string, ip string, userAgent string) error {
+	logger.Debug("[DATABASE] Begin RecordAPITokenUse(rawToken:--, ip:%s)", ip)
+
+	query := `
 		UPDATE api_tokens
-		SET last_used_at = CURRENT_TIMESTAMP
+		SET last_used_at = CURRENT_TIMESTAMP, last_used_ip = $2, last_used_user_agent = $3
 		WHERE token_hash = $1
 	`
-	_, err = d.db.Exec(updateQuery, tokenHash)
+	_, err := d.db.Exec(query, HashToken(rawToken), utils.ToNullString(ip), utils.ToNullString(userAgent))
 	if err != nil {
-		// Log but don't fail - usage tracking is non-critical
 		logger.Error("[DATABASE] Error updating api token: %v", err)
+		return err
 	}
 
-	return userID, nil
+	return nil
 }
 
 // GetAPITokensByUserID retrieves all API tokens for a user
@@ -196,13 +206,16 @@ func (d *Database) GetAPITokensByUserID(userID int) ([]models.APITokenListRespon
 			name, 
 			token_hash,
 			last_used_at, 
+			last_used_ip,
+			last_used_user_agent,
 			created_at, 
 			expires_at, 
 			is_active,
 			CASE 
 				WHEN expires_at IS NOT NULL AND expires_at <= CURRENT_TIMESTAMP THEN true
 				ELSE false
-			END as is_expired
+			END as is_expired,
+			scope
 		FROM api_tokens
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -219,6 +232,7 @@ func (d *Database) GetAPITokensByUserID(userID int) ([]models.APITokenListRespon
 	for rows.Next() {
 		var token models.APITokenListResponse
 		var lastUsedAt sql.NullTime
+		var lastUsedIP, lastUsedUserAgent sql.NullString
 		var expiresAt sql.NullTime
 		var tokenHash string
 
@@ -227,10 +241,13 @@ func (d *Database) GetAPITokensByUserID(userID int) ([]models.APITokenListRespon
 			&token.Name,
 			&tokenHash,
 			&lastUsedAt,
+			&lastUsedIP,
+			&lastUsedUserAgent,
 			&token.CreatedAt,
 			&expiresAt,
 			&token.IsActive,
 			&token.IsExpired,
+			&token.Scope,
 		)
 		if err != nil {
 			logger.Error("[DATABASE] Error scanning api token: %v", err)
@@ -241,6 +258,8 @@ func (d *Database) GetAPITokensByUserID(userID int) ([]models.APITokenListRespon
 		if lastUsedAt.Valid {
 			token.LastUsedAt = &lastUsedAt.Time
 		}
+		token.LastUsedIP = utils.ScanNullString(lastUsedIP)
+		token.LastUsedUserAgent = utils.ScanNullString(lastUsedUserAgent)
 		if expiresAt.Valid {
 			token.ExpiresAt = &expiresAt.Time
 		}
@@ -326,19 +345,23 @@ func (d *Database) GetAPITokenByID(userID int, tokenID int) (*models.APITokenLis
 			name, 
 			token_hash,
 			last_used_at, 
+			last_used_ip,
+			last_used_user_agent,
 			created_at, 
 			expires_at, 
 			is_active,
 			CASE 
 				WHEN expires_at IS NOT NULL AND expires_at <= CURRENT_TIMESTAMP THEN true
 				ELSE false
-			END as is_expired
+			END as is_expired,
+			scope
 		FROM api_tokens
 		WHERE id = $1 AND user_id = $2
 	`
 
 	var token models.APITokenListResponse
 	var lastUsedAt sql.NullTime
+	var lastUsedIP, lastUsedUserAgent sql.NullString
 	var expiresAt sql.NullTime
 	var tokenHash string
 
@@ -347,10 +370,13 @@ func (d *Database) GetAPITokenByID(userID int, tokenID int) (*models.APITokenLis
 		&token.Name,
 		&tokenHash,
 		&lastUsedAt,
+		&lastUsedIP,
+		&lastUsedUserAgent,
 		&token.CreatedAt,
 		&expiresAt,
 		&token.IsActive,
 		&token.IsExpired,
+		&token.Scope,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("token not found")
@@ -364,6 +390,8 @@ func (d *Database) GetAPITokenByID(userID int, tokenID int) (*models.APITokenLis
 	if lastUsedAt.Valid {
 		token.LastUsedAt = &lastUsedAt.Time
 	}
+	token.LastUsedIP = utils.ScanNullString(lastUsedIP)
+	token.LastUsedUserAgent = utils.ScanNullString(lastUsedUserAgent)
 	if expiresAt.Valid {
 		token.ExpiresAt = &expiresAt.Time
 	}
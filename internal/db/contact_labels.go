@@ -4,12 +4,22 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/models"
 )
 
+// ErrLabelSystem and ErrLabelInUse are returned by DeleteLabel for labels that can't be deleted
+var (
+	ErrLabelSystem = errors.New("cannot delete system labels")
+	ErrLabelInUse  = errors.New("cannot delete label in use")
+)
+
+// ListLabels returns every label with how many emails, phones, addresses, and urls use it
 func (d *Database) ListLabels() ([]models.ContactLabelType, error) {
 	logger.Debug("[DATABASE] Begin ListLabels()")
 
@@ -60,32 +70,42 @@ func (d *Database) ListLabels() ([]models.ContactLabelType, error) {
 	return labels, nil
 }
 
+// GetLabelID returns the id of the label with the given name and category, from the label cache
 func (d *Database) GetLabelID(name string, category string) (int, error) {
 	logger.Debug("[DATABASE] Begin GetLabelID(name:%s, category:%s)", name, category)
 
-	query := `
-		SELECT id
-		FROM contact_label_types
-		WHERE name = $1 AND category = $2
-	`
+	if err := d.loadLabels(); err != nil {
+		return 0, err
+	}
 
-	var labelID int
+	d.labels.mu.RLock()
+	defer d.labels.mu.RUnlock()
 
-	err := d.db.QueryRow(query, name, category).Scan(&labelID)
-	if err == sql.ErrNoRows {
+	labelID, ok := d.labels.byKey[labelKey(category, name)]
+	if !ok {
 		return 0, fmt.Errorf("unknown name/category combination")
 	}
-	if err != nil {
-		logger.Error("[DATABASE] Error selecting api token: %v", err)
-		return 0, fmt.Errorf("failed to validate token: %w", err)
-	}
 
 	return labelID, nil
 }
 
-func (d *Database) NewLabel(name string, category string) (int, error) {
-	logger.Debug("[DATABASE] Begin NewLabel(name:%s, category:%s)", name, category)
+// CreateLabel adds a custom label, lowercased, and returns its id. An existing label with the same
+// name and category is reused
+func (d *Database) CreateLabel(name string, category string) (int, error) {
+	logger.Debug("[DATABASE] Begin CreateLabel(name:%s, category:%s)", name, category)
+
+	id, err := createLabel(d.db, name, category)
+	if err != nil {
+		return 0, err
+	}
+
+	d.invalidateLabels()
+	return id, nil
+}
 
+// createLabel inserts a custom label, or returns the id of the existing one, on q. Callers
+// invalidate the label cache once the label is committed
+func createLabel(q dbExecutor, name string, category string) (int, error) {
 	var id int
 
 	// We use DO UPDATE SET name=EXCLUDED.name as a "no-op" trick
@@ -97,67 +117,170 @@ func (d *Database) NewLabel(name string, category string) (int, error) {
         DO UPDATE SET name = EXCLUDED.name 
         RETURNING id`
 
-	err := d.db.QueryRow(query, strings.ToLower(name), strings.ToLower(category)).Scan(&id)
+	err := q.QueryRow(query, strings.ToLower(name), strings.ToLower(category)).Scan(&id)
 	if err != nil {
-		logger.Error("[DATABASE] Error in NewLabel: %v", err)
+		logger.Error("[DATABASE] Error in CreateLabel: %v", err)
 		return 0, err
 	}
 
 	return id, nil
 }
 
-func (d *Database) GetLabelMap() (map[int]models.ContactLabelType, error) {
-	m, _, _, err := d.getLabelMetadata()
-	return m, err
+// createNewLabels creates the labels an import left on the contact's phones, emails, addresses,
+// and urls (label id 0 with the name in TypeLabel) on tx, and fills in their ids. Creating them in
+// the contact's transaction means a rejected save leaves no orphan labels. Reports whether any
+// label was created, so the caller can invalidate the label cache after committing
+func createNewLabels(tx dbExecutor, contact *models.Contact) (bool, error) {
+	created := false
+	resolve := func(id *int, name string, category string) error {
+		if *id != 0 || name == "" {
+			return nil
+		}
+		newID, err := createLabel(tx, name, category)
+		if err != nil {
+			return err
+		}
+		*id = newID
+		created = true
+		return nil
+	}
+
+	for i := range contact.Phones {
+		if err := resolve(&contact.Phones[i].Type, contact.Phones[i].TypeLabel, "phone"); err != nil {
+			return false, err
+		}
+	}
+	for i := range contact.Emails {
+		if err := resolve(&contact.Emails[i].Type, contact.Emails[i].TypeLabel, "email"); err != nil {
+			return false, err
+		}
+	}
+	for i := range contact.Addresses {
+		if err := resolve(&contact.Addresses[i].Type, contact.Addresses[i].TypeLabel, "address"); err != nil {
+			return false, err
+		}
+	}
+	for i := range contact.URLs {
+		if err := resolve(&contact.URLs[i].Type, contact.URLs[i].TypeLabel, "url"); err != nil {
+			return false, err
+		}
+	}
+	return created, nil
+}
+
+// GetLabelTypesMap returns every label by id, for ContactToVCard. The map is the caller's to modify
+func (d *Database) GetLabelTypesMap() (map[int]models.ContactLabelType, error) {
+	if err := d.loadLabels(); err != nil {
+		return nil, err
+	}
+
+	d.labels.mu.RLock()
+	defer d.labels.mu.RUnlock()
+	return maps.Clone(d.labels.byID), nil
 }
 
+// GetLabelReverseMap returns label ids by "category:name", for VCardToContact. The map is the
+// caller's to modify (importers add labels they create to it)
 func (d *Database) GetLabelReverseMap() (map[string]int, error) {
-	_, m, _, err := d.getLabelMetadata()
-	return m, err
+	if err := d.loadLabels(); err != nil {
+		return nil, err
+	}
+
+	d.labels.mu.RLock()
+	defer d.labels.mu.RUnlock()
+	return maps.Clone(d.labels.byKey), nil
 }
 
+// GetLabelUIMap returns the labels of each category, system labels first, for the UI templates
 func (d *Database) GetLabelUIMap() (map[string][]models.ContactLabelType, error) {
-	_, _, m, err := d.getLabelMetadata()
-	return m, err
+	if err := d.loadLabels(); err != nil {
+		return nil, err
+	}
+
+	d.labels.mu.RLock()
+	defer d.labels.mu.RUnlock()
+
+	uiMap := make(map[string][]models.ContactLabelType, len(d.labels.byCategory))
+	for category, labels := range d.labels.byCategory {
+		uiMap[category] = slices.Clone(labels)
+	}
+	return uiMap, nil
 }
 
-func (d *Database) getLabelMetadata() (
-	map[int]models.ContactLabelType, // ID -> Struct (for Export)
-	map[string]int, // Key -> ID (for Import)
-	map[string][]models.ContactLabelType, // Category -> Slice (for UI)
-	error,
-) {
-	labelMap := make(map[int]models.ContactLabelType)
-	revMap := make(map[string]int)
-	uiMap := make(map[string][]models.ContactLabelType)
+// labelCache holds contact_label_types in memory. Labels are few and only change when a custom
+// label is created or deleted, which invalidates the cache
+type labelCache struct {
+	mu         sync.RWMutex
+	loaded     bool
+	byID       map[int]models.ContactLabelType
+	byKey      map[string]int                       // "category:name", lowercased
+	byCategory map[string][]models.ContactLabelType // system labels first, then by name
+}
+
+// labelKey is the labelCache.byKey key of a label
+func labelKey(category string, name string) string {
+	return strings.ToLower(category + ":" + name)
+}
+
+// loadLabels fills the label cache unless it is already loaded
+func (d *Database) loadLabels() error {
+	d.labels.mu.RLock()
+	loaded := d.labels.loaded
+	d.labels.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	d.labels.mu.Lock()
+	defer d.labels.mu.Unlock()
+	if d.labels.loaded {
+		return nil
+	}
+
+	logger.Debug("[DATABASE] Loading contact label types")
 
 	rows, err := d.db.Query("SELECT id, name, category, is_system FROM contact_label_types ORDER BY is_system DESC, name ASC")
 	if err != nil {
-		return nil, nil, nil, err
+		logger.Error("[DATABASE] Error selecting contact label types: %v", err)
+		return err
 	}
 	defer rows.Close()
 
+	byID := make(map[int]models.ContactLabelType)
+	byKey := make(map[string]int)
+	byCategory := make(map[string][]models.ContactLabelType)
+
 	for rows.Next() {
 		var l models.ContactLabelType
 		if err := rows.Scan(&l.ID, &l.Name, &l.Category, &l.IsSystem); err != nil {
-			return nil, nil, nil, err
+			logger.Error("[DATABASE] Error scanning contact label types: %v", err)
+			return err
 		}
 
-		// 1. For ContactToVCard
-		labelMap[l.ID] = l
-
-		// 2. For VCardToContact (Key format: "phone:home")
-		key := strings.ToLower(l.Category + ":" + l.Name)
-		revMap[key] = l.ID
-
-		// 3. For the UI Templates
-		uiMap[l.Category] = append(uiMap[l.Category], l)
+		byID[l.ID] = l
+		byKey[labelKey(l.Category, l.Name)] = l.ID
+		byCategory[l.Category] = append(byCategory[l.Category], l)
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	d.labels.byID, d.labels.byKey, d.labels.byCategory = byID, byKey, byCategory
+	d.labels.loaded = true
+	return nil
+}
 
-	return labelMap, revMap, uiMap, nil
+// invalidateLabels makes the next label lookup reload the cache
+func (d *Database) invalidateLabels() {
+	d.labels.mu.Lock()
+	d.labels.loaded = false
+	d.labels.mu.Unlock()
 }
 
-func (d *Database) DeleteContactLabel(labelID int) error {
+// DeleteLabel removes a custom label nothing uses. Returns "not found", ErrLabelSystem, or ErrLabelInUse
+func (d *Database) DeleteLabel(labelID int) error {
+	logger.Debug("[DATABASE] Begin DeleteLabel(labelID:%d)", labelID)
+
 	var isSystem bool
 	var count int
 	err := d.db.QueryRow(`
@@ -167,14 +290,27 @@ func (d *Database) DeleteContactLabel(labelID int) error {
 		(SELECT COUNT(*) FROM addresses WHERE label_type_id = $1) +
         (SELECT COUNT(*) FROM urls WHERE label_type_id = $1) AS count
         FROM contact_label_types WHERE id = $1`, labelID).Scan(&isSystem, &count)
+	if err == sql.ErrNoRows {
+		return errors.New("not found")
+	}
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting label: %v", err)
+		return err
+	}
 
 	if isSystem {
-		return errors.New("cannot delete system labels")
+		return ErrLabelSystem
 	}
 	if count > 0 {
-		return fmt.Errorf("cannot delete label: used by %d records", count)
+		return fmt.Errorf("%w: used by %d records", ErrLabelInUse, count)
 	}
 
 	_, err = d.db.Exec("DELETE FROM contact_label_types WHERE id = $1", labelID)
-	return err
+	if err != nil {
+		logger.Error("[DATABASE] Error deleting label: %v", err)
+		return err
+	}
+
+	d.invalidateLabels()
+	return nil
 }
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// labelRows returns the system labels the migrations seed, plus any extra rows
+func labelRows(extra ...[]driver.Value) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "name", "category", "is_system"}).
+		AddRow(1, "cell", "phone", true).
+		AddRow(2, "work", "phone", true).
+		AddRow(3, "home", "email", true)
+	for _, row := range extra {
+		rows.AddRow(row...)
+	}
+	return rows
+}
+
+func TestLabelCache(t *testing.T) {
+	tdb, mock := newMockTracedDB(t)
+	d := &Database{db: tdb}
+
+	const selectLabels = "SELECT id, name, category, is_system FROM contact_label_types"
+
+	// Every lookup before the insert is served by a single query
+	mock.ExpectQuery(selectLabels).WillReturnRows(labelRows())
+
+	labels, err := d.GetLabelTypesMap()
+	if err != nil {
+		t.Fatalf("GetLabelTypesMap: %v", err)
+	}
+	if len(labels) != 3 || labels[2].Name != "work" {
+		t.Errorf("labels = %+v, want the three seeded labels", labels)
+	}
+
+	// The returned map is a copy; changing it leaves the cache alone
+	delete(labels, 2)
+
+	if id, err := d.GetLabelID("Work", "phone"); err != nil || id != 2 {
+		t.Errorf("GetLabelID(Work, phone) = %d, %v; want 2", id, err)
+	}
+	if _, err := d.GetLabelID("school", "phone"); err == nil {
+		t.Error("GetLabelID found a label that doesn't exist")
+	}
+	if revMap, err := d.GetLabelReverseMap(); err != nil || revMap["email:home"] != 3 {
+		t.Errorf("GetLabelReverseMap = %v, %v; want email:home -> 3", revMap, err)
+	}
+	if labels, err := d.GetLabelTypesMap(); err != nil || len(labels) != 3 {
+		t.Errorf("GetLabelTypesMap again = %d labels, %v; want 3 from the cache", len(labels), err)
+	}
+
+	// Creating a label invalidates the cache, so the next lookup reloads it
+	mock.ExpectQuery("INSERT INTO contact_label_types").
+		WithArgs("school", "phone").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(selectLabels).WillReturnRows(labelRows([]driver.Value{4, "school", "phone", false}))
+
+	if id, err := d.CreateLabel("School", "Phone"); err != nil || id != 4 {
+		t.Fatalf("CreateLabel = %d, %v; want 4", id, err)
+	}
+	if id, err := d.GetLabelID("school", "phone"); err != nil || id != 4 {
+		t.Errorf("GetLabelID(school, phone) after CreateLabel = %d, %v; want 4", id, err)
+	}
+	if labels, err := d.GetLabelTypesMap(); err != nil || len(labels) != 4 || labels[4].IsSystem {
+		t.Errorf("GetLabelTypesMap after CreateLabel = %+v, %v; want the custom school label added", labels, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/models"
 	"github.com/steveredden/KindredCard/internal/utils"
@@ -40,10 +41,10 @@ func (d *Database) CreateContact(userID int, contact *models.Contact) error {
 
 	// Generate UID if not provided
 	if contact.UID == "" {
-		contact.UID = fmt.Sprintf("%d-%d", time.Now().Unix(), time.Now().Nanosecond())
+		contact.UID = utils.NewUID(d.UIDDomain)
 	}
 
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	newSyncToken, err := incrementSyncToken(tx, userID)
 	if err != nil {
 		logger.Error("[DATABASE] Error incrementing CardDAV sync token: %v", err)
 		return fmt.Errorf("failed to increment sync token: %w", err)
@@ -57,8 +58,10 @@ func (d *Database) CreateContact(userID int, contact *models.Contact) error {
 			nickname, maiden_name, phonetic_first_name, pronunciation_first_name, phonetic_middle_name,
 			phonetic_last_name, pronunciation_last_name, gender, birthday, birthday_month, birthday_day,
 			anniversary, anniversary_month, anniversary_day, notes, avatar_base64, avatar_mime_type,
-			exclude_from_sync, etag, user_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
+			exclude_from_sync, etag, user_id, birthday_year, anniversary_year, salutation, is_organization,
+			exclude_from_events, latitude, longitude, timezone, languages, raw_vcard_extras, kind)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32,
+			$33, $34, $35, $36, $37, $38)
 		RETURNING id, created_at, updated_at`
 
 	err = tx.QueryRow(query,
@@ -69,7 +72,10 @@ func (d *Database) CreateContact(userID int, contact *models.Contact) error {
 		contact.Gender, contact.Birthday, contact.BirthdayMonth, contact.BirthdayDay,
 		contact.Anniversary, contact.AnniversaryMonth, contact.AnniversaryDay,
 		contact.Notes, contact.AvatarBase64, contact.AvatarMimeType,
-		contact.ExcludeFromSync, contact.ETag, userID,
+		contact.ExcludeFromSync, contact.ETag, userID, contact.BirthdayYear, contact.AnniversaryYear,
+		contact.Salutation, contact.IsOrganization, contact.ExcludeFromEvents,
+		contact.Latitude, contact.Longitude, utils.ToNullString(contact.Timezone), pq.Array(contact.Languages),
+		utils.ToNullString(contact.RawVCardExtras), contactKind(contact),
 	).Scan(&contact.ID, &contact.CreatedAt, &contact.UpdatedAt)
 
 	if err != nil {
@@ -77,6 +83,11 @@ func (d *Database) CreateContact(userID int, contact *models.Contact) error {
 		return err
 	}
 
+	labelsCreated, err := createNewLabels(tx, contact)
+	if err != nil {
+		return err
+	}
+
 	// Insert related data
 	if err := d.insertEmails(tx, contact.ID, contact.Emails); err != nil {
 		return err
@@ -93,6 +104,9 @@ func (d *Database) CreateContact(userID int, contact *models.Contact) error {
 	if err := d.insertURLs(tx, contact.ID, contact.URLs); err != nil {
 		return err
 	}
+	if err := d.insertIMPPs(tx, contact.ID, contact.IMPPs); err != nil {
+		return err
+	}
 	if err := d.insertOtherDates(tx, contact.ID, contact.OtherDates); err != nil {
 		return err
 	}
@@ -102,19 +116,38 @@ func (d *Database) CreateContact(userID int, contact *models.Contact) error {
 	if err := d.insertOtherRelationships(tx, contact.ID, contact.OtherRelationships); err != nil {
 		return err
 	}
+	if err := insertTags(tx, userID, contact.ID, contact.Tags); err != nil {
+		return err
+	}
 
-	err = tx.Commit()
-	if err != nil {
+	// Stamp the contact in the same transaction so the new token and the change commit together
+	if err := setContactSyncToken(tx, contact.ID, newSyncToken); err != nil {
 		return err
 	}
 
-	if err := d.bumpContactSyncToken(contact.ID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if labelsCreated {
+		d.invalidateLabels()
 	}
 
+	d.contactChanged(userID, models.WebhookEventContactCreated, contact.ID)
 	return nil
 }
 
+// contactKind is the kind stored for contact: its Kind, or for callers that don't set one, org for
+// company cards and individual otherwise
+func contactKind(contact *models.Contact) string {
+	if contact.Kind != "" {
+		return contact.Kind
+	}
+	if contact.IsOrganization {
+		return models.ContactKindOrg
+	}
+	return models.ContactKindIndividual
+}
+
 // GetAllContactsAbbrv retrieves abbreviated contact information
 // scoped to a specific user and optionally filtered by the exclude_from_sync flag.
 func (d *Database) GetAllContactsAbbrv(userID int, excludeFromSync bool) ([]*models.Contact, error) {
@@ -122,13 +155,13 @@ func (d *Database) GetAllContactsAbbrv(userID int, excludeFromSync bool) ([]*mod
 
 	var queryBuilder strings.Builder
 
-	queryBuilder.WriteString(`SELECT uid, id, full_name, given_name, family_name, nickname, etag FROM contacts WHERE user_id = $1 AND deleted_at IS NULL`)
+	queryBuilder.WriteString(`SELECT uid, id, full_name, given_name, family_name, nickname, etag, exclude_from_events FROM contacts WHERE user_id = $1 AND deleted_at IS NULL`)
 
 	params := []interface{}{userID}
 
 	// Add the optional filter for exclude_from_sync
 	if excludeFromSync {
-		queryBuilder.WriteString(" AND exclude_from_sync != true")
+		queryBuilder.WriteString(" AND exclude_from_sync != true AND archived = false")
 	}
 
 	// Append the sorting and finalize the query string
@@ -149,7 +182,7 @@ func (d *Database) GetAllContactsAbbrv(userID int, excludeFromSync bool) ([]*mod
 		contact := &models.Contact{}
 		err := rows.Scan(
 			&contact.UID, &contact.ID, &contact.FullName, &contact.GivenName, &contact.FamilyName,
-			&contact.Nickname, &contact.ETag,
+			&contact.Nickname, &contact.ETag, &contact.ExcludeFromEvents,
 		)
 		if err != nil {
 			logger.Error("[DATABASE] Error scanning contacts: %v", err)
@@ -166,32 +199,199 @@ func (d *Database) GetAllContactsAbbrv(userID int, excludeFromSync bool) ([]*mod
 	return contacts, nil
 }
 
-// GetAllContacts retrieves * from all contacts (and related data)
-// basically just a wrapper for other calls
+// GetAllContacts retrieves every contact (with related data) for a user, ordered by full_name
+// Related tables are batch-loaded once for the whole set rather than per contact
 func (d *Database) GetAllContacts(userID int, excludeFromSync bool) ([]*models.Contact, error) {
 	logger.Debug("[DATABASE] Begin GetAllContacts(userID:%d, excludeFromSync:%v)", userID, excludeFromSync)
 
-	listContacts, err := d.GetAllContactsAbbrv(userID, excludeFromSync)
+	var queryBuilder strings.Builder
+
+	queryBuilder.WriteString(`SELECT ` + contactColumns + ` FROM contacts WHERE user_id = $1 AND deleted_at IS NULL`)
+
+	if excludeFromSync {
+		queryBuilder.WriteString(" AND exclude_from_sync != true AND archived = false")
+	}
+
+	queryBuilder.WriteString(" ORDER BY full_name")
+
+	rows, err := d.db.Query(queryBuilder.String(), userID)
 	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts: %v", err)
 		return nil, err
 	}
+	defer rows.Close()
 
-	var contacts []*models.Contact
-	for _, ix := range listContacts {
-		contact, err := d.GetContactByID(userID, ix.ID)
+	contacts := []*models.Contact{}
+	ids := []int{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
 		if err != nil {
+			logger.Error("[DATABASE] Error scanning contacts: %v", err)
 			return nil, err
 		}
+		contact.UserID = userID
 		contacts = append(contacts, contact)
+		ids = append(ids, contact.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := d.loadContactRelations(contacts, ids); err != nil {
+		return nil, err
 	}
 
 	return contacts, nil
 }
 
+// relatedContactsFilter selects the IDs of contacts on either side of a relationship
+const relatedContactsFilter = `
+	SELECT contact_id FROM relationships
+	UNION
+	SELECT related_contact_id FROM relationships`
+
+// relationshipTypeFilter selects the IDs of contacts with a relationship of the type bound to the
+// formatted placeholder. Stored rows match by name; computed reverses match by the same gendered
+// reverse name getAllRelationshipsByContacts shows
+const relationshipTypeFilter = `
+	SELECT r.contact_id FROM relationships r
+	JOIN relationship_types rt ON r.relationship_type_id = rt.id
+	WHERE LOWER(rt.name) = LOWER($%[1]d)
+	UNION
+	SELECT r.related_contact_id FROM relationships r
+	JOIN relationship_types rt ON r.relationship_type_id = rt.id
+	JOIN contacts c ON r.contact_id = c.id
+	WHERE LOWER(CASE
+		WHEN c.gender IN ('M', 'male') THEN rt.reverse_name_male
+		WHEN c.gender IN ('F', 'female') THEN rt.reverse_name_female
+		ELSE rt.reverse_name_neutral
+	END) = LOWER($%[1]d)`
+
+// contactSortColumns maps the accepted sort keys to their ORDER BY column
+var contactSortColumns = map[string]string{
+	"full_name":   "full_name",
+	"given_name":  "given_name",
+	"family_name": "family_name",
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+}
+
+// ListContactsPaginated retrieves one page of contacts (with related data) and the total count
+// sort is a key from contactSortColumns, optionally prefixed with "-" for descending order;
+// unknown keys fall back to full_name. filter limits results by tag and relationships
+func (d *Database) ListContactsPaginated(userID int, limit int, offset int, sort string, filter models.ContactFilter) ([]*models.Contact, int, error) {
+	logger.Debug("[DATABASE] Begin ListContactsPaginated(userID:%d, limit:%d, offset:%d, sort:%s, filter:%+v)", userID, limit, offset, sort, filter)
+
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = strings.TrimPrefix(sort, "-")
+	}
+	column, ok := contactSortColumns[sort]
+	if !ok {
+		column = "full_name"
+	}
+
+	where := "WHERE user_id = $1 AND deleted_at IS NULL"
+	args := []interface{}{userID}
+	if !filter.IncludeArchived {
+		where += " AND archived = false"
+	}
+	if tag := strings.TrimSpace(filter.Tag); tag != "" {
+		where += " AND id IN (" + contactTagFilter + ")"
+		args = append(args, tag)
+	}
+	if filter.HasRelationship != nil {
+		if *filter.HasRelationship {
+			where += " AND id IN (" + relatedContactsFilter + ")"
+		} else {
+			where += " AND id NOT IN (" + relatedContactsFilter + ")"
+		}
+	}
+	if relType := strings.TrimSpace(filter.RelationshipType); relType != "" {
+		args = append(args, relType)
+		where += " AND id IN (" + fmt.Sprintf(relationshipTypeFilter, len(args)) + ")"
+	}
+
+	var total int
+	err := d.db.QueryRow("SELECT COUNT(*) FROM contacts "+where, args...).Scan(&total)
+	if err != nil {
+		logger.Error("[DATABASE] Error counting contacts: %v", err)
+		return nil, 0, err
+	}
+
+	// id is a tiebreaker so pages are stable when the sort column has duplicates
+	query := fmt.Sprintf(`SELECT `+contactColumns+` FROM contacts
+		%s
+		ORDER BY %s %s, id %s
+		LIMIT $%d OFFSET $%d`, where, column, direction, direction, len(args)+1, len(args)+2)
+
+	rows, err := d.db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	contacts := []*models.Contact{}
+	ids := []int{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			logger.Error("[DATABASE] Error scanning contacts: %v", err)
+			return nil, 0, err
+		}
+		contact.UserID = userID
+		contacts = append(contacts, contact)
+		ids = append(ids, contact.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := d.loadContactRelations(contacts, ids); err != nil {
+		return nil, 0, err
+	}
+
+	return contacts, total, nil
+}
+
 // GetContact retrieves a contact by ID
 func (d *Database) GetContactByID(userID int, contactID int) (*models.Contact, error) {
 	logger.Debug("[DATABASE] Begin GetContactByID(userID:%d, contactID:%d)", userID, contactID)
 
+	query := `SELECT ` + contactColumns + ` FROM contacts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	contact, err := scanContact(d.db.QueryRow(query, contactID, userID))
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts: %v", err)
+		return nil, err
+	}
+
+	// Load related data
+	d.loadContactRelations([]*models.Contact{contact}, []int{contact.ID})
+
+	contact.UserID = userID
+
+	return contact, nil
+}
+
+// contactColumns is the column list scanned by scanContact; keep the two in sync
+const contactColumns = `id, uid, full_name, given_name, family_name, middle_name, prefix, suffix,
+	nickname, maiden_name, phonetic_first_name, pronunciation_first_name, phonetic_middle_name,
+	phonetic_last_name, pronunciation_last_name, gender, birthday, birthday_month, birthday_day,
+	anniversary, anniversary_month, anniversary_day, notes, avatar_base64, avatar_mime_type,
+	exclude_from_sync, last_modified_token, created_at, updated_at, etag, birthday_year, anniversary_year, reminder_lead_days,
+	salutation, is_organization, exclude_from_events, archived, last_contacted_at, contact_frequency_days,
+	latitude, longitude, timezone, languages, raw_vcard_extras, kind`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanContact scans a row selected with contactColumns into a Contact (without related data)
+func scanContact(row rowScanner) (*models.Contact, error) {
 	contact := &models.Contact{}
 
 	var avatarBase64 sql.NullString
@@ -201,6 +401,7 @@ func (d *Database) GetContactByID(userID int, contactID int) (*models.Contact, e
 	var middle_name sql.NullString
 	var nickname sql.NullString
 	var maiden_name sql.NullString
+	var salutation sql.NullString
 	var phonetic_first_name sql.NullString
 	var pronunciation_first_name sql.NullString
 	var phonetic_middle_name sql.NullString
@@ -214,29 +415,33 @@ func (d *Database) GetContactByID(userID int, contactID int) (*models.Contact, e
 	var anniversary_month sql.NullInt64
 	var birthday_day sql.NullInt64
 	var birthday_month sql.NullInt64
+	var anniversary_year sql.NullInt64
+	var birthday_year sql.NullInt64
+	var reminder_lead_days sql.NullInt64
+	var contact_frequency_days sql.NullInt64
+
+	var latitude sql.NullFloat64
+	var longitude sql.NullFloat64
+	var timezone sql.NullString
+	var raw_vcard_extras sql.NullString
 
 	var anniversary sql.NullTime
 	var birthday sql.NullTime
+	var last_contacted_at sql.NullTime
 
-	query := `
-		SELECT id, uid, full_name, given_name, family_name, middle_name, prefix, suffix,
-			nickname, maiden_name, phonetic_first_name, pronunciation_first_name, phonetic_middle_name,
-			phonetic_last_name, pronunciation_last_name, gender, birthday, birthday_month, birthday_day, anniversary, 
-			anniversary_month, anniversary_day, notes, avatar_base64, avatar_mime_type,
-			exclude_from_sync, last_modified_token, created_at, updated_at, etag
-		FROM contacts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
-
-	err := d.db.QueryRow(query, contactID, userID).Scan(
+	err := row.Scan(
 		&contact.ID, &contact.UID, &contact.FullName, &contact.GivenName, &family_name,
 		&middle_name, &prefix, &suffix, &nickname, &maiden_name, &phonetic_first_name,
 		&pronunciation_first_name, &phonetic_middle_name, &phonetic_last_name, &pronunciation_last_name,
 		&gender, &birthday, &birthday_month, &birthday_day, &anniversary, &anniversary_month,
 		&anniversary_day, &notes, &avatarBase64, &avatarMimeType, &contact.ExcludeFromSync, &contact.LastModifiedToken,
-		&contact.CreatedAt, &contact.UpdatedAt, &contact.ETag,
+		&contact.CreatedAt, &contact.UpdatedAt, &contact.ETag, &birthday_year, &anniversary_year,
+		&reminder_lead_days, &salutation, &contact.IsOrganization, &contact.ExcludeFromEvents, &contact.Archived,
+		&last_contacted_at, &contact_frequency_days,
+		&latitude, &longitude, &timezone, pq.Array(&contact.Languages), &raw_vcard_extras,
+		&contact.Kind,
 	)
-
 	if err != nil {
-		logger.Error("[DATABASE] Error selecting contacts: %v", err)
 		return nil, err
 	}
 
@@ -248,6 +453,7 @@ func (d *Database) GetContactByID(userID int, contactID int) (*models.Contact, e
 	contact.MiddleName = utils.ScanNullString(middle_name)
 	contact.Nickname = utils.ScanNullString(nickname)
 	contact.MaidenName = utils.ScanNullString(maiden_name)
+	contact.Salutation = utils.ScanNullString(salutation)
 	contact.Notes = utils.ScanNullString(notes)
 	contact.Prefix = utils.ScanNullString(prefix)
 	contact.Suffix = utils.ScanNullString(suffix)
@@ -256,32 +462,97 @@ func (d *Database) GetContactByID(userID int, contactID int) (*models.Contact, e
 	contact.PhoneticMiddleName = utils.ScanNullString(phonetic_middle_name)
 	contact.PhoneticLastName = utils.ScanNullString(phonetic_last_name)
 	contact.PronunciationLastName = utils.ScanNullString(pronunciation_last_name)
+	contact.Timezone = utils.ScanNullString(timezone)
+	contact.RawVCardExtras = utils.ScanNullString(raw_vcard_extras)
 
 	// Load int conversions
 	contact.AnniversaryDay = utils.ScanNullInt(anniversary_day)
 	contact.AnniversaryMonth = utils.ScanNullInt(anniversary_month)
 	contact.BirthdayDay = utils.ScanNullInt(birthday_day)
 	contact.BirthdayMonth = utils.ScanNullInt(birthday_month)
+	contact.AnniversaryYear = utils.ScanNullInt(anniversary_year)
+	contact.BirthdayYear = utils.ScanNullInt(birthday_year)
+	contact.ReminderLeadDays = utils.ScanNullInt(reminder_lead_days)
+	contact.ContactFrequencyDays = utils.ScanNullInt(contact_frequency_days)
 
 	// Load time conversions
 	contact.Anniversary = utils.ScanNullTime(anniversary)
 	contact.Birthday = utils.ScanNullTime(birthday)
+	contact.LastContactedAt = utils.ScanNullTime(last_contacted_at)
 
-	// Load related data
-	contact.Emails, _ = d.getEmails(contact.ID)
-	contact.Phones, _ = d.getPhones(contact.ID)
-	contact.Addresses, _ = d.getAddresses(contact.ID)
-	contact.Organizations, _ = d.getOrganizations(contact.ID)
-	contact.URLs, _ = d.getURLs(contact.ID)
-	contact.OtherDates, _ = d.getOtherDates(contact.ID)
-	contact.Relationships, _ = d.getAllRelationships(contact.ID)
-	contact.OtherRelationships, _ = d.getOtherRelationships(contact.ID)
-
-	contact.UserID = userID
+	// GEO is only kept as a pair
+	if latitude.Valid && longitude.Valid {
+		contact.Latitude = &latitude.Float64
+		contact.Longitude = &longitude.Float64
+	}
 
 	return contact, nil
 }
 
+// loadContactRelations batch-loads emails, phones, addresses, organizations, URLs, IMPPs, dates,
+// relationships, and tags for the given contacts (one query per table)
+func (d *Database) loadContactRelations(contacts []*models.Contact, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	emails, err := d.getEmailsByContacts(ids)
+	if err != nil {
+		return err
+	}
+	phones, err := d.getPhonesByContacts(ids)
+	if err != nil {
+		return err
+	}
+	addresses, err := d.getAddressesByContacts(ids)
+	if err != nil {
+		return err
+	}
+	orgs, err := d.getOrganizationsByContacts(ids)
+	if err != nil {
+		return err
+	}
+	urls, err := d.getURLsByContacts(ids)
+	if err != nil {
+		return err
+	}
+	impps, err := d.getIMPPsByContacts(ids)
+	if err != nil {
+		return err
+	}
+	otherDates, err := d.getOtherDatesByContacts(ids)
+	if err != nil {
+		return err
+	}
+	relationships, err := d.getAllRelationshipsByContacts(ids)
+	if err != nil {
+		return err
+	}
+	otherRelationships, err := d.getOtherRelationshipsByContacts(ids)
+	if err != nil {
+		return err
+	}
+	tags, err := d.getTagsByContacts(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, contact := range contacts {
+		contact.Emails = emails[contact.ID]
+		contact.Phones = phones[contact.ID]
+		contact.Addresses = addresses[contact.ID]
+		contact.Organizations = orgs[contact.ID]
+		contact.URLs = urls[contact.ID]
+		contact.IMPPs = impps[contact.ID]
+		contact.OtherDates = otherDates[contact.ID]
+		contact.Relationships = relationships[contact.ID]
+		contact.OtherRelationships = otherRelationships[contact.ID]
+		contact.Tags = tags[contact.ID]
+	}
+
+	return nil
+}
+
 // GetContact retrieves a contact by ID
 func (d *Database) GetContactNameByID(userID int, contactID int) (*models.Contact, error) {
 	logger.Debug("[DATABASE] Begin GetContactNameByID(userID:%d, contactID:%d)", userID, contactID)
@@ -390,63 +661,21 @@ func (d *Database) GetContactIDByUID(userID int, uid string) (int, bool) {
 func (d *Database) GetContactByUID(userID int, uid string, excludeFromSync bool) (*models.Contact, error) {
 	logger.Debug("[DATABASE] Begin GetContactByUID(userID:%d, uid:%s, excludeFromSync:%v)", userID, uid, excludeFromSync)
 
-	contact := &models.Contact{}
-
 	var queryBuilder strings.Builder
 
-	var avatarBase64 sql.NullString
-	var avatarMimeType sql.NullString
-	var family_name sql.NullString
-	var gender sql.NullString
-	var middle_name sql.NullString
-	var nickname sql.NullString
-	var maiden_name sql.NullString
-	var notes sql.NullString
-	var prefix sql.NullString
-	var suffix sql.NullString
-	var phonetic_first_name sql.NullString
-	var pronunciation_first_name sql.NullString
-	var phonetic_middle_name sql.NullString
-	var phonetic_last_name sql.NullString
-	var pronunciation_last_name sql.NullString
-
-	var anniversary_day sql.NullInt64
-	var anniversary_month sql.NullInt64
-	var birthday_day sql.NullInt64
-	var birthday_month sql.NullInt64
-
-	var anniversary sql.NullTime
-	var birthday sql.NullTime
-
-	queryBuilder.WriteString(`
-		SELECT id, uid, full_name, given_name, family_name, middle_name, prefix, suffix,
-			nickname, maiden_name, phonetic_first_name, pronunciation_first_name, phonetic_middle_name,
-			phonetic_last_name, pronunciation_last_name, gender, birthday, birthday_month, birthday_day,
-			anniversary, anniversary_month, anniversary_day, notes, avatar_base64, avatar_mime_type,
-			exclude_from_sync, last_modified_token, created_at, updated_at, etag
-		FROM contacts WHERE uid = $1 AND deleted_at IS NULL AND user_id = $2
-	`)
+	queryBuilder.WriteString(`SELECT ` + contactColumns + ` FROM contacts WHERE uid = $1 AND deleted_at IS NULL AND user_id = $2`)
 
 	params := []interface{}{uid, userID}
 
 	if excludeFromSync {
-		queryBuilder.WriteString(" AND exclude_from_sync != true")
+		queryBuilder.WriteString(" AND exclude_from_sync != true AND archived = false")
 	}
 
 	queryBuilder.WriteString(" LIMIT 1")
 
 	query := queryBuilder.String()
 
-	err := d.db.QueryRow(query, params...).Scan(
-		&contact.ID, &contact.UID, &contact.FullName, &contact.GivenName,
-		&family_name, &middle_name, &prefix, &suffix, &nickname, &maiden_name, &phonetic_first_name,
-		&pronunciation_first_name, &phonetic_middle_name, &phonetic_last_name, &pronunciation_last_name,
-		&gender, &birthday, &birthday_month, &birthday_day, &anniversary,
-		&anniversary_month, &anniversary_day, &notes, &avatarBase64,
-		&avatarMimeType, &contact.ExcludeFromSync, &contact.LastModifiedToken,
-		&contact.CreatedAt, &contact.UpdatedAt, &contact.ETag,
-	)
-
+	contact, err := scanContact(d.db.QueryRow(query, params...))
 	if err == sql.ErrNoRows {
 		return nil, errors.New("not found")
 	} else if err != nil {
@@ -454,47 +683,40 @@ func (d *Database) GetContactByUID(userID int, uid string, excludeFromSync bool)
 		return nil, err
 	}
 
-	// Load string conversions
-	contact.AvatarBase64 = utils.ScanNullString(avatarBase64)
-	contact.AvatarMimeType = utils.ScanNullString(avatarMimeType)
-	contact.Gender = utils.ScanNullString(gender)
-	contact.FamilyName = utils.ScanNullString(family_name)
-	contact.MiddleName = utils.ScanNullString(middle_name)
-	contact.Nickname = utils.ScanNullString(nickname)
-	contact.MaidenName = utils.ScanNullString(maiden_name)
-	contact.Notes = utils.ScanNullString(notes)
-	contact.Prefix = utils.ScanNullString(prefix)
-	contact.Suffix = utils.ScanNullString(suffix)
-	contact.PhoneticFirstName = utils.ScanNullString(phonetic_first_name)
-	contact.PronunciationFirstName = utils.ScanNullString(pronunciation_first_name)
-	contact.PhoneticMiddleName = utils.ScanNullString(phonetic_middle_name)
-	contact.PhoneticLastName = utils.ScanNullString(phonetic_last_name)
-	contact.PronunciationLastName = utils.ScanNullString(pronunciation_last_name)
+	// Load related data
+	d.loadContactRelations([]*models.Contact{contact}, []int{contact.ID})
 
-	// Load int conversions
-	contact.AnniversaryDay = utils.ScanNullInt(anniversary_day)
-	contact.AnniversaryMonth = utils.ScanNullInt(anniversary_month)
-	contact.BirthdayDay = utils.ScanNullInt(birthday_day)
-	contact.BirthdayMonth = utils.ScanNullInt(birthday_month)
+	return contact, nil
+}
 
-	// Load time conversions
-	contact.Anniversary = utils.ScanNullTime(anniversary)
-	contact.Birthday = utils.ScanNullTime(birthday)
+// GetSyncTombstoneByUID returns the UID, ETag, and last-modified token of a contact CardDAV
+// clients should treat as deleted: trashed, archived, or excluded from sync (see
+// ListContactsChangedSince)
+func (d *Database) GetSyncTombstoneByUID(userID int, uid string) (*models.Contact, error) {
+	logger.Debug("[DATABASE] Begin GetSyncTombstoneByUID(userID:%d, uid:%s)", userID, uid)
 
-	// Load related data
-	contact.Emails, _ = d.getEmails(contact.ID)
-	contact.Phones, _ = d.getPhones(contact.ID)
-	contact.Addresses, _ = d.getAddresses(contact.ID)
-	contact.Organizations, _ = d.getOrganizations(contact.ID)
-	contact.URLs, _ = d.getURLs(contact.ID)
-	contact.OtherDates, _ = d.getOtherDates(contact.ID)
-	contact.Relationships, _ = d.getAllRelationships(contact.ID)
-	contact.OtherRelationships, _ = d.getOtherRelationships(contact.ID)
+	contact := &models.Contact{}
+	err := d.db.QueryRow(`
+		SELECT uid, etag, last_modified_token FROM contacts
+		WHERE uid = $1 AND user_id = $2
+		AND (deleted_at IS NOT NULL OR archived OR exclude_from_sync)
+		ORDER BY deleted_at DESC NULLS FIRST
+		LIMIT 1`, uid, userID).Scan(&contact.UID, &contact.ETag, &contact.LastModifiedToken)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("not found")
+	}
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting tombstone: %v", err)
+		return nil, err
+	}
 
 	return contact, nil
 }
 
-// UpdateContact updates an existing contact
+// UpdateContact updates an existing contact. An empty Kind keeps the stored kind, except that it
+// follows IsOrganization to and from org the way PatchContact does; contact.Kind is set to the
+// kind stored. Like tags and vCard extras, an omitted GEO pair, timezone, or (nil) language list
+// keeps the stored value; empty Languages clears it
 func (d *Database) UpdateContact(userID int, contact *models.Contact) error {
 	logger.Debug("[DATABASE] Begin UpdateContact(userID:%d, contact:--)", userID)
 
@@ -513,7 +735,7 @@ func (d *Database) UpdateContact(userID int, contact *models.Contact) error {
 	// Update ETag
 	contact.ETag = fmt.Sprintf("%x", time.Now().UnixNano())
 
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	newSyncToken, err := incrementSyncToken(tx, userID)
 	if err != nil {
 		logger.Error("[DATABASE] Error incrementing CardDAV sync token: %v", err)
 		return fmt.Errorf("failed to increment sync token: %w", err)
@@ -526,19 +748,33 @@ func (d *Database) UpdateContact(userID int, contact *models.Contact) error {
 			pronunciation_first_name = $10, phonetic_middle_name = $11, phonetic_last_name = $12,
 			pronunciation_last_name = $13, gender = $14, birthday = $15, birthday_month = $16,
 			birthday_day = $17, anniversary = $18, anniversary_month = $19, anniversary_day = $20, 
-			notes = $21, exclude_from_sync = $22, etag = $23
-		WHERE id = $24
+			notes = $21, exclude_from_sync = $22, etag = $23, birthday_year = $24, anniversary_year = $25,
+			salutation = $26, is_organization = $27, exclude_from_events = $28,
+			latitude = COALESCE($29, latitude), longitude = COALESCE($30, longitude),
+			timezone = COALESCE($31, timezone), languages = COALESCE($32, languages),
+			raw_vcard_extras = COALESCE($33, raw_vcard_extras),
+			kind = COALESCE(NULLIF($34, ''), CASE WHEN $27 THEN 'org' WHEN kind = 'org' THEN 'individual' ELSE kind END)
+		WHERE id = $35
+		RETURNING kind
 	`
 
-	_, err = tx.Exec(query,
+	// GEO is only written as a pair
+	latitude, longitude := contact.Latitude, contact.Longitude
+	if latitude == nil || longitude == nil {
+		latitude, longitude = nil, nil
+	}
+
+	err = tx.QueryRow(query,
 		contact.FullName, contact.GivenName, contact.FamilyName, contact.MiddleName, contact.Prefix,
 		contact.Suffix, contact.Nickname, contact.MaidenName, contact.PhoneticFirstName,
 		contact.PronunciationFirstName, contact.PhoneticMiddleName, contact.PhoneticLastName,
 		contact.PronunciationLastName, contact.Gender, contact.Birthday, contact.BirthdayMonth,
 		contact.BirthdayDay, contact.Anniversary, contact.AnniversaryMonth,
 		contact.AnniversaryDay, contact.Notes, contact.ExcludeFromSync, contact.ETag,
-		contact.ID,
-	)
+		contact.BirthdayYear, contact.AnniversaryYear, contact.Salutation, contact.IsOrganization, contact.ExcludeFromEvents,
+		latitude, longitude, utils.ToNullString(contact.Timezone), pq.Array(contact.Languages),
+		utils.ToNullString(contact.RawVCardExtras), contact.Kind, contact.ID,
+	).Scan(&contact.Kind)
 
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting contacts: %v", err)
@@ -546,7 +782,7 @@ func (d *Database) UpdateContact(userID int, contact *models.Contact) error {
 	}
 
 	// Delete and re-insert related data
-	tables := []string{"emails", "phones", "addresses", "organizations", "urls", "other_dates", "other_relationships"}
+	tables := []string{"emails", "phones", "addresses", "organizations", "urls", "impps", "other_dates", "other_relationships"}
 
 	// Quick fix for #6 - if saved from the GUI then don't delete and insert relationships
 	if contact.Metadata == "skip relationships" {
@@ -555,6 +791,11 @@ func (d *Database) UpdateContact(userID int, contact *models.Contact) error {
 		tables = append(tables, "relationships")
 	}
 
+	// Tags are only replaced when the caller sent them, so clients unaware of tags don't clear them
+	if contact.Tags != nil {
+		tables = append(tables, "contact_tags")
+	}
+
 	for _, table := range tables {
 		query := fmt.Sprintf("DELETE FROM %s WHERE contact_id = $1", table)
 		if _, err := tx.Exec(query, contact.ID); err != nil {
@@ -563,6 +804,11 @@ func (d *Database) UpdateContact(userID int, contact *models.Contact) error {
 		}
 	}
 
+	labelsCreated, err := createNewLabels(tx, contact)
+	if err != nil {
+		return err
+	}
+
 	if err := d.insertEmails(tx, contact.ID, contact.Emails); err != nil {
 		return err
 	}
@@ -578,6 +824,9 @@ func (d *Database) UpdateContact(userID int, contact *models.Contact) error {
 	if err := d.insertURLs(tx, contact.ID, contact.URLs); err != nil {
 		return err
 	}
+	if err := d.insertIMPPs(tx, contact.ID, contact.IMPPs); err != nil {
+		return err
+	}
 	if err := d.insertOtherDates(tx, contact.ID, contact.OtherDates); err != nil {
 		return err
 	}
@@ -590,6 +839,9 @@ func (d *Database) UpdateContact(userID int, contact *models.Contact) error {
 	if err := d.insertOtherRelationships(tx, contact.ID, contact.OtherRelationships); err != nil {
 		return err
 	}
+	if err := insertTags(tx, userID, contact.ID, contact.Tags); err != nil {
+		return err
+	}
 
 	if contact.AvatarBase64 != "" && contact.AvatarMimeType != "" {
 		_, err := tx.Exec(`
@@ -602,19 +854,24 @@ func (d *Database) UpdateContact(userID int, contact *models.Contact) error {
 		}
 	}
 
-	err = tx.Commit()
-	if err != nil {
+	// Stamp the contact in the same transaction so the new token and the change commit together
+	if err := setContactSyncToken(tx, contact.ID, newSyncToken); err != nil {
 		return err
 	}
 
-	if err := d.bumpContactSyncToken(contact.ID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if labelsCreated {
+		d.invalidateLabels()
 	}
 
+	d.contactChanged(userID, models.WebhookEventContactUpdated, contact.ID)
 	return nil
 }
 
-// DeleteContact deletes a contact
+// DeleteContact moves a contact to the trash and removes its relationships, which RestoreContact
+// does not bring back. The contacts it was linked to are stamped and reported as updated
 func (d *Database) DeleteContact(userID int, contactID int) error {
 	logger.Debug("[DATABASE] Begin DeleteContact(userID:%d, contactID:%d)", userID, contactID)
 
@@ -628,7 +885,7 @@ func (d *Database) DeleteContact(userID int, contactID int) error {
 	defer tx.Rollback()
 
 	// --- STEP A: Increment the User's Global Sync Token (Get the new revision number) ---
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	newSyncToken, err := incrementSyncToken(tx, userID)
 	if err != nil {
 		logger.Error("[DATABASE] Error incrementing CardDAV sync token: %v", err)
 		return fmt.Errorf("failed to increment sync token: %w", err)
@@ -636,15 +893,6 @@ func (d *Database) DeleteContact(userID int, contactID int) error {
 
 	// --- STEP B: Soft-Delete the Contact and Stamp it with the New Token ---
 
-	// Delete any associated records - we can't rely on `ON DELETE CASCADE` if we soft delete...
-	tables := []string{"emails", "phones", "addresses", "organizations", "urls", "other_dates", "relationships", "other_relationships"}
-	for _, table := range tables {
-		query := fmt.Sprintf("DELETE FROM %s WHERE contact_id = $1", table)
-		if _, err := tx.Exec(query, contactID); err != nil {
-			return fmt.Errorf("failed to delete from %s: %w", table, err)
-		}
-	}
-
 	// We update the contact's row, setting both the deleted_at timestamp
 	// and the last_modified_token to the new value.
 	contactQuery := `
@@ -672,60 +920,235 @@ func (d *Database) DeleteContact(userID int, contactID int) error {
 		return sql.ErrNoRows
 	}
 
-	// --- STEP C: Commit the Transaction ---
+	// --- STEP C: Unlink the Contact ---
+
+	// The contact's own details are kept so it can be restored from the trash (purging cascades),
+	// but links to other contacts go now - they would otherwise show on the living contacts. Those
+	// contacts' cards lose the link, so they are stamped with the new token too
+	rows, err := tx.Query(`
+		WITH deleted AS (
+			DELETE FROM relationships WHERE contact_id = $1 OR related_contact_id = $1
+			RETURNING CASE WHEN contact_id = $1 THEN related_contact_id ELSE contact_id END AS id
+		)
+		SELECT DISTINCT id FROM deleted WHERE id <> $1`, contactID)
+	if err != nil {
+		return fmt.Errorf("failed to delete from relationships: %w", err)
+	}
+	var relatedIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			logger.Error("[DATABASE] Error scanning relationships: %v", err)
+			return err
+		}
+		relatedIDs = append(relatedIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to delete from relationships: %w", err)
+	}
+
+	for _, id := range relatedIDs {
+		if err := setContactSyncToken(tx, id, newSyncToken); err != nil {
+			return err
+		}
+	}
+
+	// --- STEP D: Commit the Transaction ---
 	if err := tx.Commit(); err != nil {
 		logger.Error("[DATABASE] Error committing contact tx: %v", err)
 		return fmt.Errorf("failed to commit deletion transaction: %w", err)
 	}
 
+	d.contactChanged(userID, models.WebhookEventContactDeleted, contactID)
+	d.contactsUpdated(userID, relatedIDs...)
 	return nil
 }
 
-// SearchContacts searches for contacts by name or email
-func (d *Database) SearchContacts(userID int, query string) ([]*models.Contact, error) {
-	logger.Debug("[DATABASE] Begin SearchContacts(userID:%d, query:%s)", userID, query)
+// contactSearchField is what a search field matches: a join it needs (if any) and its conditions on $1
+type contactSearchField struct {
+	join       string
+	conditions []string
+}
 
-	var avatarBase64 sql.NullString
-	var avatarMimeType sql.NullString
-	var family_name sql.NullString
-	var middle_name sql.NullString
-	var nickname sql.NullString
-	var maiden_name sql.NullString
-	var notes sql.NullString
-	var prefix sql.NullString
-	var suffix sql.NullString
+// contactSearchFields are the fields SearchContacts can match, keyed by their ?fields= name
+var contactSearchFields = map[string]contactSearchField{
+	"name": {conditions: []string{
+		"c.full_name ILIKE $1", "c.given_name ILIKE $1", "c.family_name ILIKE $1",
+		"c.nickname ILIKE $1", "c.maiden_name ILIKE $1",
+	}},
+	"email":   {join: "LEFT JOIN emails e ON c.id = e.contact_id", conditions: []string{"e.email ILIKE $1"}},
+	"phone":   {join: "LEFT JOIN phones p ON c.id = p.contact_id", conditions: []string{"p.phone ILIKE $1"}},
+	"address": {join: "LEFT JOIN addresses a ON c.id = a.contact_id", conditions: []string{"a.street ILIKE $1", "a.city ILIKE $1"}},
+	"notes":   {conditions: []string{"c.notes ILIKE $1"}},
+}
 
-	searchQuery := `
-		SELECT DISTINCT c.id, c.uid, c.full_name, c.given_name, c.family_name, c.middle_name,
-			c.prefix, c.suffix, c.nickname, c.maiden_name, c.birthday, c.anniversary, c.notes, c.avatar_base64,
-			c.avatar_mime_type, c.exclude_from_sync, c.created_at, c.updated_at, c.etag
+// contactSearchFieldOrder keeps the generated SQL stable
+var contactSearchFieldOrder = []string{"name", "email", "phone", "address", "notes"}
+
+// IsContactSearchField reports whether SearchContacts can search the named field
+func IsContactSearchField(name string) bool {
+	_, ok := contactSearchFields[name]
+	return ok
+}
+
+// contactSearchRank orders substring matches: an exact name or nickname ($3) first, then names
+// starting with the query ($4), then names containing it, then matches in other fields. A
+// nickname that appears nowhere in the real name would otherwise sort by full name alone
+const contactSearchRank = `CASE
+			WHEN LOWER(c.nickname) = LOWER($3) OR LOWER(c.full_name) = LOWER($3)
+				OR LOWER(c.given_name) = LOWER($3) OR LOWER(c.family_name) = LOWER($3) THEN 0
+			WHEN c.nickname ILIKE $4 OR c.full_name ILIKE $4 OR c.given_name ILIKE $4 OR c.family_name ILIKE $4 THEN 1
+			WHEN c.nickname ILIKE $1 OR c.full_name ILIKE $1 OR c.given_name ILIKE $1
+				OR c.family_name ILIKE $1 OR c.maiden_name ILIKE $1 THEN 2
+			ELSE 3
+		END`
+
+// fuzzySearchThreshold is the minimum pg_trgm similarity for a fuzzy name match
+const fuzzySearchThreshold = 0.3
+
+// contactSearchColumns is the column list scanned by scanSearchResults
+const contactSearchColumns = `c.id, c.uid, c.full_name, c.given_name, c.family_name, c.middle_name,
+	c.prefix, c.suffix, c.nickname, c.maiden_name, c.birthday, c.anniversary, c.notes, c.avatar_base64,
+	c.avatar_mime_type, c.exclude_from_sync, c.archived, c.created_at, c.updated_at, c.etag`
+
+// SearchContacts searches contacts by name, email, phone, street/city, and notes. opts.Fields
+// limits the search to those fields (see contactSearchFields); empty searches all of them.
+// Archived contacts are skipped unless opts.IncludeArchived is set. When the substring search
+// finds nothing, or opts.Fuzzy is set, names are matched by trigram similarity instead. Without
+// pg_trgm that falls back to no results (or the substring search when opts.Fuzzy is set)
+func (d *Database) SearchContacts(userID int, query string, opts models.ContactSearchOptions) ([]*models.Contact, error) {
+	logger.Debug("[DATABASE] Begin SearchContacts(userID:%d, query:%s, opts:%+v)", userID, query, opts)
+
+	selected := make(map[string]bool)
+	for _, field := range opts.Fields {
+		selected[field] = true
+	}
+	searchNames := len(selected) == 0 || selected["name"]
+
+	archivedFilter := " AND c.archived = false"
+	if opts.IncludeArchived {
+		archivedFilter = ""
+	}
+
+	if !opts.Fuzzy {
+		contacts, err := d.searchContactsBySubstring(userID, query, selected, archivedFilter)
+		if err != nil || len(contacts) > 0 || !searchNames {
+			return contacts, err
+		}
+	}
+
+	contacts, err := d.searchContactsByName(userID, query, archivedFilter)
+	if isUndefinedFunction(err) {
+		// pg_trgm may be unavailable (see migration 019); fuzzy matching is best-effort
+		logger.Warn("[DATABASE] Fuzzy search unavailable: %v", err)
+		if opts.Fuzzy {
+			return d.searchContactsBySubstring(userID, query, selected, archivedFilter)
+		}
+		return []*models.Contact{}, nil
+	}
+	if err != nil {
+		logger.Error("[DATABASE] Error searching contacts by name: %v", err)
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// isUndefinedFunction reports whether err is Postgres' undefined_function (42883), which is what
+// similarity() raises when the pg_trgm extension isn't installed
+func isUndefinedFunction(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "42883"
+}
+
+// searchContactsBySubstring matches the query anywhere in the selected fields (all when empty)
+func (d *Database) searchContactsBySubstring(userID int, query string, selected map[string]bool, archivedFilter string) ([]*models.Contact, error) {
+	joins := []string{}
+	conditions := []string{}
+	for _, name := range contactSearchFieldOrder {
+		if len(selected) > 0 && !selected[name] {
+			continue
+		}
+		field := contactSearchFields[name]
+		if field.join != "" {
+			joins = append(joins, field.join)
+		}
+		conditions = append(conditions, field.conditions...)
+	}
+	if len(conditions) == 0 {
+		return []*models.Contact{}, nil
+	}
+
+	searchQuery := fmt.Sprintf(`
+		SELECT `+contactSearchColumns+`
 		FROM contacts c
-		LEFT JOIN emails e ON c.id = e.contact_id
-		WHERE c.user_id = $2 AND c.deleted_at IS NULL AND (
-				c.full_name ILIKE $1 
-				OR c.given_name ILIKE $1 
-				OR c.family_name ILIKE $1 
-				OR c.nickname ILIKE $1
-				OR c.maiden_name ILIKE $1
-				OR e.email ILIKE $1
-			)
-		ORDER BY c.full_name`
+		WHERE c.id IN (
+			SELECT c.id
+			FROM contacts c
+			%s
+			WHERE c.user_id = $2 AND c.deleted_at IS NULL%s AND (%s)
+		)
+		ORDER BY `+contactSearchRank+`, c.full_name`, strings.Join(joins, "\n\t\t\t"), archivedFilter, strings.Join(conditions, " OR "))
 
 	searchPattern := "%" + query + "%"
-	rows, err := d.db.Query(searchQuery, searchPattern, userID)
+	rows, err := d.db.Query(searchQuery, searchPattern, userID, query, query+"%")
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting contacts: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanSearchResults(rows)
+}
+
+// searchContactsByName matches names by pg_trgm similarity, best match first, so a typo like
+// "Catherine" still finds "Katherine"
+func (d *Database) searchContactsByName(userID int, query string, archivedFilter string) ([]*models.Contact, error) {
+	searchQuery := `
+		SELECT ` + contactSearchColumns + `
+		FROM (
+			SELECT c.*, GREATEST(
+				word_similarity($1, c.full_name),
+				similarity(COALESCE(c.given_name, ''), $1),
+				similarity(COALESCE(c.family_name, ''), $1),
+				similarity(COALESCE(c.nickname, ''), $1),
+				similarity(COALESCE(c.maiden_name, ''), $1)
+			) AS score
+			FROM contacts c
+			WHERE c.user_id = $2 AND c.deleted_at IS NULL` + archivedFilter + `
+		) c
+		WHERE c.score >= $3
+		ORDER BY c.score DESC, c.full_name`
+
+	rows, err := d.db.Query(searchQuery, query, userID, fuzzySearchThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSearchResults(rows)
+}
+
+// scanSearchResults scans rows selected with contactSearchColumns
+func scanSearchResults(rows *sql.Rows) ([]*models.Contact, error) {
+	var avatarBase64 sql.NullString
+	var avatarMimeType sql.NullString
+	var family_name sql.NullString
+	var middle_name sql.NullString
+	var nickname sql.NullString
+	var maiden_name sql.NullString
+	var notes sql.NullString
+	var prefix sql.NullString
+	var suffix sql.NullString
+
 	var contacts []*models.Contact
 	for rows.Next() {
 		contact := &models.Contact{}
 		err := rows.Scan(
 			&contact.ID, &contact.UID, &contact.FullName, &contact.GivenName, &family_name,
 			&middle_name, &prefix, &suffix, &nickname, &maiden_name, &contact.Birthday, &contact.Anniversary,
-			&notes, &avatarBase64, &avatarMimeType, &contact.ExcludeFromSync,
+			&notes, &avatarBase64, &avatarMimeType, &contact.ExcludeFromSync, &contact.Archived,
 			&contact.CreatedAt, &contact.UpdatedAt, &contact.ETag,
 		)
 		if err != nil {
@@ -746,7 +1169,7 @@ func (d *Database) SearchContacts(userID int, query string) ([]*models.Contact,
 		contacts = append(contacts, contact)
 	}
 
-	return contacts, nil
+	return contacts, rows.Err()
 }
 
 // GetContactCount returns the total number of contacts
@@ -817,23 +1240,52 @@ func (d *Database) GetRecentlyEditedContacts(userID int, limit int) ([]*models.C
 func (d *Database) UpdateAvatar(userID int, contactID int, avatarBase64 string, mimeType string) error {
 	logger.Debug("[DATABASE] Begin UpdateAvatar(userID:%d, contactID:%d, avatarBase64:--, mimeType:%s)", userID, contactID, mimeType)
 
-	_, err := d.db.Exec(`
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
 		UPDATE contacts 
 		SET avatar_base64 = $1, avatar_mime_type = $2, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $3`,
-		avatarBase64, mimeType, contactID)
+		WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL`,
+		avatarBase64, mimeType, contactID, userID)
 	if err != nil {
 		logger.Error("[DATABASE] Error updating avatar: %v", err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("not found")
 	}
 
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		logger.Error("[DATABASE] Error incrementing CardDAV sync token: %v", err)
-		return fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactChanged(userID, models.WebhookEventContactUpdated, contactID)
+	return nil
+}
+
+// SetAvatarOriginal keeps the full-resolution image behind a resized avatar. Call it after
+// UpdateAvatar, which clears any previous original. The original isn't synced, so the sync
+// token is left alone
+func (d *Database) SetAvatarOriginal(userID int, contactID int, originalBase64 string, mimeType string) error {
+	logger.Debug("[DATABASE] Begin SetAvatarOriginal(userID:%d, contactID:%d, originalBase64:--, mimeType:%s)", userID, contactID, mimeType)
+
+	_, err := d.db.Exec(`
+		UPDATE contacts
+		SET avatar_original_base64 = $1, avatar_original_mime_type = $2
+		WHERE id = $3 AND user_id = $4`,
+		originalBase64, mimeType, contactID, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error updating avatar original: %v", err)
 	}
 
 	return err
@@ -843,26 +1295,141 @@ func (d *Database) UpdateAvatar(userID int, contactID int, avatarBase64 string,
 func (d *Database) DeleteAvatar(userID int, contactID int) error {
 	logger.Debug("[DATABASE] Begin DeleteAvatar(userID:%d, contactID:%d)", userID, contactID)
 
-	_, err := d.db.Exec(`
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
 		UPDATE contacts 
 		SET avatar_base64 = NULL, avatar_mime_type = NULL, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1`,
-		contactID)
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		contactID, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error deleting avatar: %v", err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
+
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactChanged(userID, models.WebhookEventContactUpdated, contactID)
+	return nil
+}
+
+// GetAvatar returns a contact's stored avatar, MIME type, and ETag without loading the rest of
+// the contact. The avatar is empty when none is stored
+func (d *Database) GetAvatar(userID int, contactID int) (string, string, string, error) {
+	logger.Debug("[DATABASE] Begin GetAvatar(userID:%d, contactID:%d)", userID, contactID)
+
+	var avatarBase64, mimeType sql.NullString
+	var etag string
+	err := d.db.QueryRow(`
+		SELECT avatar_base64, avatar_mime_type, etag
+		FROM contacts
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		contactID, userID).Scan(&avatarBase64, &mimeType, &etag)
+	if err == sql.ErrNoRows {
+		return "", "", "", errors.New("not found")
+	}
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting avatar: %v", err)
+		return "", "", "", err
+	}
+
+	return utils.ScanNullString(avatarBase64), utils.ScanNullString(mimeType), etag, nil
+}
+
+// GetPrimaryEmail returns the email marked primary on a contact; empty when none is
+func (d *Database) GetPrimaryEmail(userID int, contactID int) (string, error) {
+	logger.Debug("[DATABASE] Begin GetPrimaryEmail(userID:%d, contactID:%d)", userID, contactID)
+
+	var email string
+	err := d.db.QueryRow(`
+		SELECT e.email FROM emails e
+		JOIN contacts c ON e.contact_id = c.id
+		WHERE e.contact_id = $1 AND c.user_id = $2 AND e.is_primary
+		ORDER BY e.id
+		LIMIT 1`,
+		contactID, userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting email: %v", err)
+		return "", err
+	}
+
+	return email, nil
+}
+
+// GetAvatarOriginal returns a contact's full-resolution avatar and MIME type. When no separate
+// original was kept, the stored avatar is the original and is returned instead
+func (d *Database) GetAvatarOriginal(userID int, contactID int) (string, string, error) {
+	logger.Debug("[DATABASE] Begin GetAvatarOriginal(userID:%d, contactID:%d)", userID, contactID)
+
+	var avatarBase64, mimeType sql.NullString
+	err := d.db.QueryRow(`
+		SELECT
+			COALESCE(avatar_original_base64, avatar_base64),
+			CASE WHEN avatar_original_base64 IS NOT NULL THEN avatar_original_mime_type ELSE avatar_mime_type END
+		FROM contacts
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		contactID, userID).Scan(&avatarBase64, &mimeType)
+	if err == sql.ErrNoRows {
+		return "", "", errors.New("not found")
+	}
 	if err != nil {
-		logger.Error("[DATABASE] Error deleting avatar: %v", err)
+		logger.Error("[DATABASE] Error selecting avatar: %v", err)
+		return "", "", err
 	}
 
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	return utils.ScanNullString(avatarBase64), utils.ScanNullString(mimeType), nil
+}
+
+// EachAvatar calls fn with the UID, full-resolution avatar, and MIME type of every contact that
+// has one, reading rows one at a time so exports don't hold every image in memory
+func (d *Database) EachAvatar(userID int, fn func(uid, avatarBase64, mimeType string) error) error {
+	logger.Debug("[DATABASE] Begin EachAvatar(userID:%d)", userID)
+
+	rows, err := d.db.Query(`
+		SELECT uid,
+			COALESCE(avatar_original_base64, avatar_base64),
+			CASE WHEN avatar_original_base64 IS NOT NULL THEN avatar_original_mime_type ELSE avatar_mime_type END
+		FROM contacts
+		WHERE user_id = $1 AND deleted_at IS NULL
+		AND avatar_base64 IS NOT NULL AND avatar_base64 != ''
+		ORDER BY uid`, userID)
 	if err != nil {
-		logger.Error("[DATABASE] Error incrementing CardDAV sync token: %v", err)
-		return fmt.Errorf("failed to increment sync token: %w", err)
+		logger.Error("[DATABASE] Error selecting avatars: %v", err)
+		return err
 	}
+	defer rows.Close()
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	for rows.Next() {
+		var uid, avatarBase64 string
+		var mimeType sql.NullString
+		if err := rows.Scan(&uid, &avatarBase64, &mimeType); err != nil {
+			logger.Error("[DATABASE] Error scanning avatars: %v", err)
+			return err
+		}
+		if err := fn(uid, avatarBase64, utils.ScanNullString(mimeType)); err != nil {
+			return err
+		}
 	}
 
-	return err
+	return rows.Err()
 }
 
 // ListContactsChangedSince fetches all contacts (including soft-deleted ones)
@@ -876,18 +1443,21 @@ func (d *Database) ListContactsChangedSince(userID int, clientToken int64, exclu
 
 	// CRITICAL CHANGE: We now select the 'deleted_at' column.
 	// We do NOT use WHERE deleted_at IS NULL, because we need the deleted records (tombstones).
+	// Archived contacts and those excluded from sync are hidden from CardDAV, so they are reported
+	// as deleted there; a client that already has them removes them
+	deletedColumn := "deleted_at"
+	if excludeFromSync {
+		deletedColumn = "COALESCE(deleted_at, CASE WHEN archived OR exclude_from_sync THEN updated_at END)"
+	}
+
 	queryBuilder.WriteString(`
-        SELECT uid, etag, last_modified_token, deleted_at, version_token
+        SELECT uid, etag, last_modified_token, ` + deletedColumn + `, version_token
         FROM contacts 
         WHERE user_id = $1 AND version_token > $2 
 	`)
 
 	params := []interface{}{userID, clientToken}
 
-	if excludeFromSync {
-		queryBuilder.WriteString(" AND exclude_from_sync != true")
-	}
-
 	queryBuilder.WriteString(" ORDER BY version_token ASC")
 	query := queryBuilder.String()
 
@@ -944,6 +1514,9 @@ func (d *Database) PatchContact(userID int, contactID int, patch *models.Contact
 		{patch.Suffix, "suffix"},
 		{patch.Nickname, "nickname"},
 		{patch.MaidenName, "maiden_name"},
+		{patch.Salutation, "salutation"},
+		{patch.IsOrganization, "is_organization"},
+		{patch.Kind, "kind"},
 		{patch.PhoneticFirstName, "phonetic_first_name"},
 		{patch.PronunciationFirstName, "pronunciation_first_name"},
 		{patch.PhoneticMiddleName, "phonetic_middle_name"},
@@ -954,6 +1527,31 @@ func (d *Database) PatchContact(userID int, contactID int, patch *models.Contact
 		{patch.AvatarBase64, "avatar_base64"},
 		{patch.AvatarMimeType, "avatar_mime_type"},
 		{patch.ExcludeFromSync, "exclude_from_sync"},
+		{patch.ExcludeFromEvents, "exclude_from_events"},
+		{patch.ReminderLeadDays, "reminder_lead_days"},
+		{patch.ContactFrequencyDays, "contact_frequency_days"},
+		{patch.Timezone, "timezone"},
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the row so the name and gender the patch is applied to stay current until it commits
+	var currentGender sql.NullString
+	err = tx.QueryRow(
+		"SELECT gender FROM contacts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE",
+		contactID, userID,
+	).Scan(&currentGender)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("contact not found")
+	}
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts: %v", err)
+		return nil, err
 	}
 
 	// ensure we can calculate a proper full_name based on this
@@ -980,6 +1578,10 @@ func (d *Database) PatchContact(userID int, contactID int, patch *models.Contact
 
 	newName := current.GenerateFullName()
 
+	// Reverse relationship labels (e.g. "Parent" -> "Father") depend on this contact's
+	// gender, so a change must also refresh the cards of everyone related to them
+	genderChanged := patch.Gender != nil && utils.ScanNullString(currentGender) != *patch.Gender
+
 	updates := []string{}
 	args := []interface{}{}
 	argIndex := 1
@@ -1002,6 +1604,57 @@ func (d *Database) PatchContact(userID int, contactID int, patch *models.Contact
 		}
 	}
 
+	// The company card flag and KIND:org go together
+	if patch.IsOrganization != nil && patch.Kind == nil {
+		if *patch.IsOrganization {
+			updates = append(updates, "kind = 'org'")
+		} else {
+			updates = append(updates, "kind = CASE WHEN kind = 'org' THEN 'individual' ELSE kind END")
+		}
+	}
+
+	// GEO is written as a pair; LANG needs array encoding
+	if patch.Latitude != nil && patch.Longitude != nil {
+		updates = append(updates, fmt.Sprintf("latitude = $%d", argIndex), fmt.Sprintf("longitude = $%d", argIndex+1))
+		args = append(args, *patch.Latitude, *patch.Longitude)
+		argIndex += 2
+	}
+	if patch.Languages != nil {
+		updates = append(updates, fmt.Sprintf("languages = $%d", argIndex))
+		args = append(args, pq.Array(*patch.Languages))
+		argIndex++
+	}
+
+	// A full date and a month/day pair are alternatives; writing one clears the other in the
+	// same statement
+	dateUpdates := []struct {
+		column     string
+		full       *string
+		month, day *int
+	}{
+		{"birthday", patch.Birthday, patch.BirthdayMonth, patch.BirthdayDay},
+		{"anniversary", patch.Anniversary, patch.AnniversaryMonth, patch.AnniversaryDay},
+	}
+	for _, date := range dateUpdates {
+		if date.full != nil {
+			updates = append(updates, fmt.Sprintf("%s = $%d", date.column, argIndex))
+			args = append(args, *date.full)
+			argIndex++
+
+			updates = append(updates, date.column+"_month = NULL", date.column+"_day = NULL", date.column+"_year = NULL")
+		} else if date.month != nil && date.day != nil {
+			updates = append(updates, date.column+" = NULL", date.column+"_year = NULL")
+
+			updates = append(updates, fmt.Sprintf("%s_month = $%d", date.column, argIndex))
+			args = append(args, *date.month)
+			argIndex++
+
+			updates = append(updates, fmt.Sprintf("%s_day = $%d", date.column, argIndex))
+			args = append(args, *date.day)
+			argIndex++
+		}
+	}
+
 	// Add WHERE clause parameters
 	//    WHERE id = $%d AND user_id = $%d
 	args = append(args, contactID, userID)
@@ -1013,7 +1666,17 @@ func (d *Database) PatchContact(userID int, contactID int, patch *models.Contact
 		WHERE id = $%d AND user_id = $%d
 	`, strings.Join(updates, ", "), argIndex, argIndex+1)
 
-	result, err := d.db.Exec(query, args...)
+	// Related contacts show gendered reverse names, so their cards change with this one's gender
+	touched := []int{contactID}
+	if genderChanged {
+		relatedIDs, err := getRelatedContactIDs(tx, contactID)
+		if err != nil {
+			return nil, err
+		}
+		touched = append(touched, relatedIDs...)
+	}
+
+	result, err := tx.Exec(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to patch contact: %w", err)
 	}
@@ -1023,21 +1686,47 @@ func (d *Database) PatchContact(userID int, contactID int, patch *models.Contact
 	}
 
 	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, touched...); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return nil, err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
+	d.contactsUpdated(userID, touched...)
 	return d.GetContactByID(userID, contactID)
 }
 
+// getRelatedContactIDs returns the IDs of every contact linked to contactID by a
+// relationship, in either direction
+func getRelatedContactIDs(tx *tracedTx, contactID int) ([]int, error) {
+	rows, err := tx.Query(`
+		SELECT related_contact_id FROM relationships WHERE contact_id = $1
+		UNION
+		SELECT contact_id FROM relationships WHERE related_contact_id = $1`, contactID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting relationships: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			logger.Error("[DATABASE] Error scanning relationships: %v", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // Helper functions for related data
 
-func (d *Database) insertEmails(tx *sql.Tx, contactID int, emails []models.Email) error {
+func (d *Database) insertEmails(tx *tracedTx, contactID int, emails []models.Email) error {
 	for _, email := range emails {
 		_, err := tx.Exec(
 			"INSERT INTO emails (contact_id, email, label_type_id, is_primary) VALUES ($1, $2, $3, $4)",
@@ -1051,7 +1740,7 @@ func (d *Database) insertEmails(tx *sql.Tx, contactID int, emails []models.Email
 	return nil
 }
 
-func (d *Database) insertPhones(tx *sql.Tx, contactID int, phones []models.Phone) error {
+func (d *Database) insertPhones(tx *tracedTx, contactID int, phones []models.Phone) error {
 	for _, phone := range phones {
 		_, err := tx.Exec(
 			"INSERT INTO phones (contact_id, phone, label_type_id, is_primary) VALUES ($1, $2, $3, $4)",
@@ -1065,7 +1754,7 @@ func (d *Database) insertPhones(tx *sql.Tx, contactID int, phones []models.Phone
 	return nil
 }
 
-func (d *Database) insertAddresses(tx *sql.Tx, contactID int, addresses []models.Address) error {
+func (d *Database) insertAddresses(tx *tracedTx, contactID int, addresses []models.Address) error {
 	for _, addr := range addresses {
 		_, err := tx.Exec(
 			"INSERT INTO addresses (contact_id, street, extended_street, city, state, postal_code, country, label_type_id, is_primary) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
@@ -1079,11 +1768,11 @@ func (d *Database) insertAddresses(tx *sql.Tx, contactID int, addresses []models
 	return nil
 }
 
-func (d *Database) insertOrganizations(tx *sql.Tx, contactID int, orgs []models.Organization) error {
+func (d *Database) insertOrganizations(tx *tracedTx, contactID int, orgs []models.Organization) error {
 	for _, org := range orgs {
 		_, err := tx.Exec(
-			"INSERT INTO organizations (contact_id, name, title, department, is_primary) VALUES ($1, $2, $3, $4, $5)",
-			contactID, org.Name, org.Title, org.Department, org.IsPrimary,
+			"INSERT INTO organizations (contact_id, name, title, role, department, is_primary) VALUES ($1, $2, $3, $4, $5, $6)",
+			contactID, org.Name, org.Title, org.Role, org.Department, org.IsPrimary,
 		)
 		if err != nil {
 			logger.Error("[DATABASE] Error inserting Organizations: %v", err)
@@ -1093,7 +1782,7 @@ func (d *Database) insertOrganizations(tx *sql.Tx, contactID int, orgs []models.
 	return nil
 }
 
-func (d *Database) insertURLs(tx *sql.Tx, contactID int, urls []models.URL) error {
+func (d *Database) insertURLs(tx *tracedTx, contactID int, urls []models.URL) error {
 	for _, url := range urls {
 		_, err := tx.Exec(
 			"INSERT INTO urls (contact_id, url, label_type_id) VALUES ($1, $2, $3)",
@@ -1107,7 +1796,21 @@ func (d *Database) insertURLs(tx *sql.Tx, contactID int, urls []models.URL) erro
 	return nil
 }
 
-func (d *Database) insertOtherDates(tx *sql.Tx, contactID int, otherDates []models.OtherDate) error {
+func (d *Database) insertIMPPs(tx *tracedTx, contactID int, impps []models.IMPP) error {
+	for _, impp := range impps {
+		_, err := tx.Exec(
+			"INSERT INTO impps (contact_id, service, handle, is_primary) VALUES ($1, $2, $3, $4)",
+			contactID, impp.Service, impp.Handle, impp.IsPrimary,
+		)
+		if err != nil {
+			logger.Error("[DATABASE] Error inserting IMPPs: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Database) insertOtherDates(tx *tracedTx, contactID int, otherDates []models.OtherDate) error {
 	for _, otherDate := range otherDates {
 		_, err := tx.Exec(`
 			INSERT INTO other_dates (contact_id, event_name, event_date, event_date_month, event_date_day)
@@ -1123,7 +1826,7 @@ func (d *Database) insertOtherDates(tx *sql.Tx, contactID int, otherDates []mode
 	return nil
 }
 
-func (d *Database) insertRelationships(tx *sql.Tx, contactID int, relationships []models.Relationship) error {
+func (d *Database) insertRelationships(tx *tracedTx, contactID int, relationships []models.Relationship) error {
 	for _, relationship := range relationships {
 		relatedID := relationship.RelatedContact.ID
 		typeID := relationship.RelationshipType.ID
@@ -1136,7 +1839,11 @@ func (d *Database) insertRelationships(tx *sql.Tx, contactID int, relationships
 
 		reverseTypeID, err := d.GetReverseRelationshipType(typeID, myGender)
 
-		if err == nil {
+		if err == nil && d.ExplicitMirrorRelationships {
+			if err := insertMirrorRelationship(tx, relatedID, contactID, reverseTypeID); err != nil {
+				return err
+			}
+		} else if err == nil {
 			// Check if the mirror already exists
 			var exists bool
 			tx.QueryRow(`
@@ -1165,7 +1872,20 @@ func (d *Database) insertRelationships(tx *sql.Tx, contactID int, relationships
 	return nil
 }
 
-func (d *Database) insertOtherRelationships(tx *sql.Tx, contactID int, otherRelationships []models.OtherRelationship) error {
+// insertMirrorRelationship writes the explicit reverse row (relatedID's view of contactID)
+func insertMirrorRelationship(q dbExecutor, contactID int, relatedID int, reverseTypeID int) error {
+	_, err := q.Exec(`
+		INSERT INTO relationships (contact_id, related_contact_id, relationship_type_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (contact_id, related_contact_id, relationship_type_id) DO NOTHING`,
+		contactID, relatedID, reverseTypeID)
+	if err != nil {
+		logger.Error("[DATABASE] Error inserting mirror relationship: %v", err)
+	}
+	return err
+}
+
+func (d *Database) insertOtherRelationships(tx *tracedTx, contactID int, otherRelationships []models.OtherRelationship) error {
 	for _, otherRelationship := range otherRelationships {
 		_, err := tx.Exec(`
 			INSERT INTO other_relationships (contact_id, related_contact_name, relationship_name)
@@ -1181,76 +1901,94 @@ func (d *Database) insertOtherRelationships(tx *sql.Tx, contactID int, otherRela
 }
 
 func (d *Database) getEmails(contactID int) ([]models.Email, error) {
+	emails, err := d.getEmailsByContacts([]int{contactID})
+	return emails[contactID], err
+}
+
+// getEmailsByContacts loads emails for several contacts at once, keyed by contact ID
+func (d *Database) getEmailsByContacts(contactIDs []int) (map[int][]models.Email, error) {
 	query := `
 	SELECT e.id, e.contact_id, e.email, e.label_type_id, l.name as type_label, e.is_primary
 	FROM emails e
 	JOIN contact_label_types l on e.label_type_id = l.id
-	WHERE e.contact_id = $1
+	WHERE e.contact_id = ANY($1)
 	`
 
-	rows, err := d.db.Query(query, contactID)
+	rows, err := d.db.Query(query, pq.Array(contactIDs))
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting Emails: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var emails []models.Email
+	emails := make(map[int][]models.Email)
 	for rows.Next() {
 		var email models.Email
 		if err := rows.Scan(&email.ID, &email.ContactID, &email.Email, &email.Type, &email.TypeLabel, &email.IsPrimary); err != nil {
 			logger.Error("[DATABASE] Error scanning Emails: %v", err)
 			return nil, err
 		}
-		emails = append(emails, email)
+		emails[email.ContactID] = append(emails[email.ContactID], email)
 	}
 	return emails, nil
 }
 
 func (d *Database) getPhones(contactID int) ([]models.Phone, error) {
+	phones, err := d.getPhonesByContacts([]int{contactID})
+	return phones[contactID], err
+}
+
+// getPhonesByContacts loads phones for several contacts at once, keyed by contact ID
+func (d *Database) getPhonesByContacts(contactIDs []int) (map[int][]models.Phone, error) {
 
 	query := `
 	SELECT p.id, p.contact_id, p.phone, p.label_type_id, l.name as type_label, p.is_primary
     FROM phones p
 	JOIN contact_label_types l on p.label_type_id = l.id
-	WHERE p.contact_id = $1
+	WHERE p.contact_id = ANY($1)
 	`
 
-	rows, err := d.db.Query(query, contactID)
+	rows, err := d.db.Query(query, pq.Array(contactIDs))
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting Phones: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var phones []models.Phone
+	phones := make(map[int][]models.Phone)
 	for rows.Next() {
 		var phone models.Phone
 		if err := rows.Scan(&phone.ID, &phone.ContactID, &phone.Phone, &phone.Type, &phone.TypeLabel, &phone.IsPrimary); err != nil {
 			logger.Error("[DATABASE] Error scanning Phones: %v", err)
 			return nil, err
 		}
-		phones = append(phones, phone)
+		phones[phone.ContactID] = append(phones[phone.ContactID], phone)
 	}
 	return phones, nil
 }
 
 func (d *Database) getAddresses(contactID int) ([]models.Address, error) {
+	addresses, err := d.getAddressesByContacts([]int{contactID})
+	return addresses[contactID], err
+}
+
+// getAddressesByContacts loads addresses for several contacts at once, keyed by contact ID
+func (d *Database) getAddressesByContacts(contactIDs []int) (map[int][]models.Address, error) {
 	query := `
 	SELECT a.id, a.contact_id, a.street, a.extended_street, a.city, a.state, a.postal_code, a.country, a.label_type_id, l.name as type_label, a.is_primary
 	FROM addresses a
 	JOIN contact_label_types l on a.label_type_id = l.id
-	WHERE a.contact_id = $1
+	WHERE a.contact_id = ANY($1)
 	`
 
-	rows, err := d.db.Query(query, contactID)
+	rows, err := d.db.Query(query, pq.Array(contactIDs))
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting Addresses: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var addresses []models.Address
+	addresses := make(map[int][]models.Address)
 	for rows.Next() {
 		var addr models.Address
 		var extendedStreet sql.NullString
@@ -1259,61 +1997,106 @@ func (d *Database) getAddresses(contactID int) ([]models.Address, error) {
 			return nil, err
 		}
 		addr.ExtendedStreet = utils.ScanNullString(extendedStreet)
-		addresses = append(addresses, addr)
+		addresses[addr.ContactID] = append(addresses[addr.ContactID], addr)
 	}
 	return addresses, nil
 }
 
 func (d *Database) getOrganizations(contactID int) ([]models.Organization, error) {
-	rows, err := d.db.Query("SELECT id, contact_id, name, phonetic_name, title, department, is_primary FROM organizations WHERE contact_id = $1", contactID)
+	orgs, err := d.getOrganizationsByContacts([]int{contactID})
+	return orgs[contactID], err
+}
+
+// getOrganizationsByContacts loads organizations for several contacts at once, keyed by contact ID
+func (d *Database) getOrganizationsByContacts(contactIDs []int) (map[int][]models.Organization, error) {
+	rows, err := d.db.Query("SELECT id, contact_id, name, phonetic_name, title, role, department, is_primary FROM organizations WHERE contact_id = ANY($1)", pq.Array(contactIDs))
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting Organizations: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var orgs []models.Organization
+	orgs := make(map[int][]models.Organization)
 	for rows.Next() {
 		var org models.Organization
 		var phonetic_name sql.NullString
-		if err := rows.Scan(&org.ID, &org.ContactID, &org.Name, &phonetic_name, &org.Title, &org.Department, &org.IsPrimary); err != nil {
+		if err := rows.Scan(&org.ID, &org.ContactID, &org.Name, &phonetic_name, &org.Title, &org.Role, &org.Department, &org.IsPrimary); err != nil {
 			logger.Error("[DATABASE] Error scanning Organizations: %v", err)
 			return nil, err
 		}
 		org.PhoneticName = utils.ScanNullString(phonetic_name)
-		orgs = append(orgs, org)
+		orgs[org.ContactID] = append(orgs[org.ContactID], org)
 	}
 	return orgs, nil
 }
 
 func (d *Database) getURLs(contactID int) ([]models.URL, error) {
+	urls, err := d.getURLsByContacts([]int{contactID})
+	return urls[contactID], err
+}
+
+// getURLsByContacts loads URLs for several contacts at once, keyed by contact ID
+func (d *Database) getURLsByContacts(contactIDs []int) (map[int][]models.URL, error) {
 	query := `
 	SELECT u.id, u.contact_id, u.url, u.label_type_id, l.name as type_label
 	FROM urls u
 	JOIN contact_label_types l on u.label_type_id = l.id
-	WHERE contact_id = $1
+	WHERE contact_id = ANY($1)
 	`
 
-	rows, err := d.db.Query(query, contactID)
+	rows, err := d.db.Query(query, pq.Array(contactIDs))
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting URLs: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var urls []models.URL
+	urls := make(map[int][]models.URL)
 	for rows.Next() {
 		var url models.URL
 		if err := rows.Scan(&url.ID, &url.ContactID, &url.URL, &url.Type, &url.TypeLabel); err != nil {
 			logger.Error("[DATABASE] Error scanning URLs: %v", err)
 			return nil, err
 		}
-		urls = append(urls, url)
+		urls[url.ContactID] = append(urls[url.ContactID], url)
 	}
 	return urls, nil
 }
 
+// getIMPPsByContacts loads instant messaging handles for several contacts at once, keyed by contact ID
+func (d *Database) getIMPPsByContacts(contactIDs []int) (map[int][]models.IMPP, error) {
+	rows, err := d.db.Query(`
+		SELECT id, contact_id, service, handle, is_primary
+		FROM impps
+		WHERE contact_id = ANY($1)
+		ORDER BY id`, pq.Array(contactIDs))
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting IMPPs: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	impps := make(map[int][]models.IMPP)
+	for rows.Next() {
+		var impp models.IMPP
+		var service sql.NullString
+		if err := rows.Scan(&impp.ID, &impp.ContactID, &service, &impp.Handle, &impp.IsPrimary); err != nil {
+			logger.Error("[DATABASE] Error scanning IMPPs: %v", err)
+			return nil, err
+		}
+		impp.Service = utils.ScanNullString(service)
+		impps[impp.ContactID] = append(impps[impp.ContactID], impp)
+	}
+	return impps, nil
+}
+
 func (d *Database) getOtherDates(contactID int) ([]models.OtherDate, error) {
+	otherDates, err := d.getOtherDatesByContacts([]int{contactID})
+	return otherDates[contactID], err
+}
+
+// getOtherDatesByContacts loads other dates for several contacts at once, keyed by contact ID
+func (d *Database) getOtherDatesByContacts(contactIDs []int) (map[int][]models.OtherDate, error) {
 
 	var event_date sql.NullTime
 	var event_date_day sql.NullInt64
@@ -1322,14 +2105,14 @@ func (d *Database) getOtherDates(contactID int) ([]models.OtherDate, error) {
 	rows, err := d.db.Query(`
 		SELECT id, contact_id, event_name, event_date, event_date_month, event_date_day
 		FROM other_dates
-		WHERE contact_id = $1`, contactID)
+		WHERE contact_id = ANY($1)`, pq.Array(contactIDs))
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting Other Dates: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var otherDates []models.OtherDate
+	otherDates := make(map[int][]models.OtherDate)
 	for rows.Next() {
 		var otherDate models.OtherDate
 		if err := rows.Scan(
@@ -1344,24 +2127,30 @@ func (d *Database) getOtherDates(contactID int) ([]models.OtherDate, error) {
 		otherDate.EventDateMonth = utils.ScanNullInt(event_date_month)
 		otherDate.EventDateDay = utils.ScanNullInt(event_date_day)
 
-		otherDates = append(otherDates, otherDate)
+		otherDates[otherDate.ContactID] = append(otherDates[otherDate.ContactID], otherDate)
 	}
 	return otherDates, nil
 }
 
 func (d *Database) getOtherRelationships(contactID int) ([]models.OtherRelationship, error) {
+	otherRelationships, err := d.getOtherRelationshipsByContacts([]int{contactID})
+	return otherRelationships[contactID], err
+}
+
+// getOtherRelationshipsByContacts loads other relationships for several contacts at once, keyed by contact ID
+func (d *Database) getOtherRelationshipsByContacts(contactIDs []int) (map[int][]models.OtherRelationship, error) {
 
 	rows, err := d.db.Query(`
 		SELECT id, contact_id, related_contact_name, relationship_name, created_at
 		FROM other_relationships
-		WHERE contact_id = $1`, contactID)
+		WHERE contact_id = ANY($1)`, pq.Array(contactIDs))
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting Other Dates: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var otherRelationships []models.OtherRelationship
+	otherRelationships := make(map[int][]models.OtherRelationship)
 	for rows.Next() {
 		var otherRelationship models.OtherRelationship
 		if err := rows.Scan(
@@ -1372,12 +2161,19 @@ func (d *Database) getOtherRelationships(contactID int) ([]models.OtherRelations
 			return nil, err
 		}
 
-		otherRelationships = append(otherRelationships, otherRelationship)
+		otherRelationships[otherRelationship.ContactID] = append(otherRelationships[otherRelationship.ContactID], otherRelationship)
 	}
 	return otherRelationships, nil
 }
 
 func (d *Database) getAllRelationships(contactID int) ([]models.Relationship, error) {
+	relationships, err := d.getAllRelationshipsByContacts([]int{contactID})
+	return relationships[contactID], err
+}
+
+// getAllRelationshipsByContacts loads relationships in both directions for several contacts
+// at once, keyed by the contact they are viewed from
+func (d *Database) getAllRelationshipsByContacts(contactIDs []int) (map[int][]models.Relationship, error) {
 	rows, err := d.db.Query(`
 		SELECT r.id,
 		       r.contact_id AS contact_id,
@@ -1393,7 +2189,7 @@ func (d *Database) getAllRelationships(contactID int) ([]models.Relationship, er
 		FROM relationships r
 		JOIN relationship_types rt ON r.relationship_type_id = rt.id
 		JOIN contacts c ON r.related_contact_id = c.id
-		WHERE r.contact_id = $1
+		WHERE r.contact_id = ANY($1)
 
 		UNION ALL
 
@@ -1411,17 +2207,18 @@ func (d *Database) getAllRelationships(contactID int) ([]models.Relationship, er
 		FROM relationships r
 		JOIN relationship_types rt ON r.relationship_type_id = rt.id
 		JOIN contacts c ON r.contact_id = c.id
-		WHERE r.related_contact_id = $1
+		WHERE r.related_contact_id = ANY($1)
 
 		ORDER BY relationship_name
-	`, contactID)
+	`, pq.Array(contactIDs))
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting Relationships: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var relationships []models.Relationship
+	relationships := make(map[int][]models.Relationship)
+	seen := make(map[string]int) // key -> index in relationships[contactID]
 	for rows.Next() {
 		var rel models.Relationship
 		rel.RelationshipType = &models.RelationshipType{}
@@ -1449,6 +2246,7 @@ func (d *Database) getAllRelationships(contactID int) ([]models.Relationship, er
 		}
 
 		// If this is a reverse relationship, use the appropriate reverse name based on related contact's gender
+		rel.IsReverse = isReverse
 		if isReverse {
 			gender := ""
 			if relatedGender.Valid {
@@ -1465,13 +2263,25 @@ func (d *Database) getAllRelationships(contactID int) ([]models.Relationship, er
 			}
 		}
 
-		relationships = append(relationships, rel)
+		// An explicit mirror row and the computed reverse of its partner describe the same link;
+		// keep the stored row
+		key := fmt.Sprintf("%d|%d|%s", rel.ContactID, rel.RelatedContactID, rel.RelationshipType.Name)
+		if i, ok := seen[key]; ok {
+			if !rel.IsReverse {
+				relationships[rel.ContactID][i] = rel
+			}
+			continue
+		}
+		seen[key] = len(relationships[rel.ContactID])
+
+		relationships[rel.ContactID] = append(relationships[rel.ContactID], rel)
 	}
 
 	return relationships, nil
 }
 
-func (d *Database) bumpContactSyncToken(contactID int, token int) error {
+// setContactSyncToken stamps a contact with a sync token and fresh ETag
+func setContactSyncToken(q dbExecutor, contactID int, token int) error {
 	newETag := fmt.Sprintf("%x", time.Now().UnixNano())
 	currentTimestamp := int(time.Now().Unix())
 
@@ -1483,7 +2293,7 @@ func (d *Database) bumpContactSyncToken(contactID int, token int) error {
 		WHERE id = $4
 	`
 
-	_, err := d.db.Exec(query, token, currentTimestamp, newETag, contactID)
+	_, err := q.Exec(query, token, currentTimestamp, newETag, contactID)
 
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting contacts: %v", err)
@@ -1493,6 +2303,184 @@ func (d *Database) bumpContactSyncToken(contactID int, token int) error {
 	return nil
 }
 
+// ListDeletedContacts retrieves abbreviated trashed contacts, most recently deleted first.
+// They remain restorable until DeleteOldContacts purges them
+func (d *Database) ListDeletedContacts(userID int) ([]*models.Contact, error) {
+	logger.Debug("[DATABASE] Begin ListDeletedContacts(userID:%d)", userID)
+
+	rows, err := d.db.Query(`
+		SELECT id, uid, COALESCE(full_name, ''), COALESCE(given_name, ''), COALESCE(family_name, ''), deleted_at
+		FROM contacts
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting deleted contacts: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []*models.Contact{}
+	for rows.Next() {
+		contact := &models.Contact{UserID: userID}
+		if err := rows.Scan(&contact.ID, &contact.UID, &contact.FullName, &contact.GivenName, &contact.FamilyName, &contact.DeletedAt); err != nil {
+			logger.Error("[DATABASE] Error scanning deleted contacts: %v", err)
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	return contacts, rows.Err()
+}
+
+// RestoreContact takes a contact out of the trash. It gets a fresh sync token, ETag, and
+// version_token so CardDAV clients that saw the deletion add it back
+func (d *Database) RestoreContact(userID int, contactID int) error {
+	logger.Debug("[DATABASE] Begin RestoreContact(userID:%d, contactID:%d)", userID, contactID)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE contacts SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL",
+		contactID, userID,
+	)
+	if err != nil {
+		logger.Error("[DATABASE] Error restoring contact: %v", err)
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
+
+	newSyncToken, err := incrementSyncToken(tx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment sync token: %w", err)
+	}
+
+	if err := setContactSyncToken(tx, contactID, newSyncToken); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactChanged(userID, models.WebhookEventContactUpdated, contactID)
+	return nil
+}
+
+// SetContactArchived archives or unarchives a contact. Either way it gets a fresh sync token so
+// CardDAV clients drop or re-add it
+func (d *Database) SetContactArchived(userID int, contactID int, archived bool) error {
+	logger.Debug("[DATABASE] Begin SetContactArchived(userID:%d, contactID:%d, archived:%v)", userID, contactID, archived)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE contacts SET archived = $1, updated_at = NOW() WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL",
+		archived, contactID, userID,
+	)
+	if err != nil {
+		logger.Error("[DATABASE] Error archiving contact: %v", err)
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
+
+	newSyncToken, err := incrementSyncToken(tx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment sync token: %w", err)
+	}
+
+	if err := setContactSyncToken(tx, contactID, newSyncToken); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactChanged(userID, models.WebhookEventContactUpdated, contactID)
+	return nil
+}
+
+// TouchContact records that the user reached out to a contact now. last_contacted_at isn't part of
+// the vCard, so the contact keeps its sync token
+func (d *Database) TouchContact(userID int, contactID int) error {
+	logger.Debug("[DATABASE] Begin TouchContact(userID:%d, contactID:%d)", userID, contactID)
+
+	res, err := d.db.Exec(
+		"UPDATE contacts SET last_contacted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL",
+		contactID, userID,
+	)
+	if err != nil {
+		logger.Error("[DATABASE] Error touching contact: %v", err)
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
+
+	return nil
+}
+
+// ListTombstones lists the user's soft-deleted contacts with the version_token clients sync them by,
+// most recently deleted first
+func (d *Database) ListTombstones(userID int) ([]models.Tombstone, error) {
+	logger.Debug("[DATABASE] Begin ListTombstones(userID:%d)", userID)
+
+	rows, err := d.db.Query(`
+		SELECT id, uid, COALESCE(full_name, ''), COALESCE(version_token, 0), deleted_at
+		FROM contacts
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting tombstones: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tombstones := []models.Tombstone{}
+	for rows.Next() {
+		var t models.Tombstone
+		if err := rows.Scan(&t.ID, &t.UID, &t.FullName, &t.VersionToken, &t.DeletedAt); err != nil {
+			logger.Error("[DATABASE] Error scanning tombstones: %v", err)
+			return nil, err
+		}
+		tombstones = append(tombstones, t)
+	}
+
+	return tombstones, rows.Err()
+}
+
+// PurgeTombstones permanently deletes the user's contacts that have been in the trash for more
+// than olderThanDays days, ahead of DeleteOldContacts. Returns the number purged
+func (d *Database) PurgeTombstones(userID int, olderThanDays int) (int64, error) {
+	logger.Debug("[DATABASE] Begin PurgeTombstones(userID:%d, olderThanDays:%d)", userID, olderThanDays)
+
+	result, err := d.db.Exec(`
+		DELETE FROM contacts
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		AND deleted_at <= NOW() - make_interval(days => $2)`,
+		userID, olderThanDays)
+	if err != nil {
+		logger.Error("[DATABASE] Error purging tombstones: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
 // GetContactsByURL retrieves contacts that have a matching URL
 func (d *Database) GetContactsByURL(userID int, baseURL string, urlLabelID int) ([]*models.Contact, error) {
 	logger.Debug("[DATABASE] Begin GetContactsByURL(userID:%d, baseURL: %s, urlLabelID:%d)", userID, baseURL, urlLabelID)
@@ -1512,7 +2500,7 @@ func (d *Database) GetContactsByURL(userID int, baseURL string, urlLabelID int)
 			u.id, u.url, u.label_type_id
 		FROM urls u
 		JOIN contacts c on u.contact_id = c.id
-		WHERE c.user_id = $1
+		WHERE c.user_id = $1 AND c.deleted_at IS NULL
 	`
 	args = append(args, userID)
 	argIndex := 2
@@ -1645,7 +2633,7 @@ func (d *Database) GetLinkedImmichIDs(userID int) (map[string]bool, error) {
 		SELECT u.url 
 		FROM urls u
 		JOIN contacts c ON u.contact_id = c.id
-		WHERE c.user_id = $1 
+		WHERE c.user_id = $1 AND c.deleted_at IS NULL
 		AND label_type_id = $2;
 	`
 
@@ -1670,13 +2658,13 @@ func (d *Database) GetLinkedImmichIDs(userID int) (map[string]bool, error) {
 	return linkedMap, nil
 }
 
-// DeleteOldContacts removes expired contacts from the database
+// DeleteOldContacts removes contacts that have been in the trash longer than TrashRetentionDays
 func (d *Database) DeleteOldContacts() error {
-	logger.Debug("[DATABASE] Begin CleanupExpiredSessions()")
+	logger.Debug("[DATABASE] Begin DeleteOldContacts(retentionDays:%d)", d.TrashRetentionDays)
 
-	query := `DELETE FROM contacts WHERE deleted_at < NOW() - INTERVAL '30 days';`
+	query := `DELETE FROM contacts WHERE deleted_at < NOW() - make_interval(days => $1);`
 
-	result, err := d.db.Exec(query)
+	result, err := d.db.Exec(query, d.TrashRetentionDays)
 	if err != nil {
 		logger.Error("[DATABASE] Error cleaning up deleted contacts: %v", err)
 		return err
@@ -1698,15 +2686,16 @@ func (d *Database) GetContactAnniversaryByID(userID int, contactID int) (*models
 
 	var anniversary_day sql.NullInt64
 	var anniversary_month sql.NullInt64
+	var anniversary_year sql.NullInt64
 	var anniversary sql.NullTime
 
 	query := `
-		SELECT id, full_name, anniversary, anniversary_month, anniversary_day
+		SELECT id, full_name, anniversary, anniversary_month, anniversary_day, anniversary_year
 		FROM contacts WHERE id = $1 AND deleted_at IS NULL AND user_id = $2
 	`
 
 	err := d.db.QueryRow(query, contactID, userID).Scan(
-		&contact.ID, &contact.FullName, &anniversary, &anniversary_month, &anniversary_day,
+		&contact.ID, &contact.FullName, &anniversary, &anniversary_month, &anniversary_day, &anniversary_year,
 	)
 
 	if err == sql.ErrNoRows {
@@ -1719,6 +2708,7 @@ func (d *Database) GetContactAnniversaryByID(userID int, contactID int) (*models
 	// Load int conversions
 	contact.AnniversaryDay = utils.ScanNullInt(anniversary_day)
 	contact.AnniversaryMonth = utils.ScanNullInt(anniversary_month)
+	contact.AnniversaryYear = utils.ScanNullInt(anniversary_year)
 
 	// Load time conversions
 	contact.Anniversary = utils.ScanNullTime(anniversary)
@@ -1726,24 +2716,18 @@ func (d *Database) GetContactAnniversaryByID(userID int, contactID int) (*models
 	return contact, nil
 }
 
-// GetContactByUID retrieves a contact by UID
+// GetContactAddressesByID retrieves a contact's ID and addresses, scoped to the owning user
 func (d *Database) GetContactAddressesByID(userID int, contactID int) (*models.Contact, error) {
-	logger.Debug("[DATABASE] Begin GetContactByUID(userID:%d, contactID:%d)", userID, contactID)
+	logger.Debug("[DATABASE] Begin GetContactAddressesByID(userID:%d, contactID:%d)", userID, contactID)
 
 	contact := &models.Contact{}
 
-	var anniversary_day sql.NullInt64
-	var anniversary_month sql.NullInt64
-	var anniversary sql.NullTime
-
 	query := `
-		SELECT id, anniversary, anniversary_month, anniversary_day
+		SELECT id
 		FROM contacts WHERE id = $1 AND deleted_at IS NULL AND user_id = $2
 	`
 
-	err := d.db.QueryRow(query, contactID).Scan(
-		&contact.ID, &anniversary, &anniversary_month, &anniversary_day,
-	)
+	err := d.db.QueryRow(query, contactID, userID).Scan(&contact.ID)
 
 	if err == sql.ErrNoRows {
 		return nil, errors.New("not found")
@@ -1752,12 +2736,11 @@ func (d *Database) GetContactAddressesByID(userID int, contactID int) (*models.C
 		return nil, err
 	}
 
-	// Load int conversions
-	contact.AnniversaryDay = utils.ScanNullInt(anniversary_day)
-	contact.AnniversaryMonth = utils.ScanNullInt(anniversary_month)
-
-	// Load time conversions
-	contact.Anniversary = utils.ScanNullTime(anniversary)
+	contact.Addresses, err = d.getAddresses(contact.ID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting addresses: %v", err)
+		return nil, err
+	}
 
 	return contact, nil
 }
@@ -1771,23 +2754,39 @@ func (d *Database) UpdateContactNotes(userID int, body models.NotesJSONPut) erro
 		utils.Dump(body)
 	}
 
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
 	// Build and execute query
 	query := `
 		UPDATE contacts
-		SET notes = $1
-		WHERE id = $2 AND user_id = $3
+		SET notes = $1, updated_at = NOW()
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
 	`
 
-	_, err := d.db.Exec(query, body.Notes, body.ContactID, userID)
+	result, err := tx.Exec(query, body.Notes, body.ContactID, userID)
 	if err != nil {
+		logger.Error("[DATABASE] Error updating notes: %v", err)
 		return err
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
 
 	// Increment sync token so CardDAV clients pull the change
-	newToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err == nil {
-		_ = d.bumpContactSyncToken(body.ContactID, newToken)
+	if err := bumpSyncTokens(tx, userID, body.ContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	d.contactsUpdated(userID, body.ContactID)
 	return nil
 }
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/steveredden/KindredCard/internal/logger"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestSearchContactsWithoutTrigramExtension(t *testing.T) {
+	captureLog(t, logger.ERROR)
+	missingTrgm := &pq.Error{Code: "42883", Message: "function similarity(text, unknown) does not exist"}
+
+	t.Run("fallback after no substring matches", func(t *testing.T) {
+		tdb, mock := newMockTracedDB(t)
+		d := &Database{db: tdb}
+
+		mock.ExpectQuery("ILIKE").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("similarity").WillReturnError(missingTrgm)
+
+		contacts, err := d.SearchContacts(1, "Catherine", models.ContactSearchOptions{})
+		if err != nil || len(contacts) != 0 {
+			t.Errorf("got %d contacts (err %v), want no results and no error", len(contacts), err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("fuzzy falls back to substring", func(t *testing.T) {
+		tdb, mock := newMockTracedDB(t)
+		d := &Database{db: tdb}
+
+		mock.ExpectQuery("similarity").WillReturnError(missingTrgm)
+		mock.ExpectQuery("ILIKE").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		if _, err := d.SearchContacts(1, "Catherine", models.ContactSearchOptions{Fuzzy: true}); err != nil {
+			t.Errorf("SearchContacts: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("other errors are returned", func(t *testing.T) {
+		tdb, mock := newMockTracedDB(t)
+		d := &Database{db: tdb}
+
+		mock.ExpectQuery("similarity").WillReturnError(&pq.Error{Code: "57014", Message: "canceling statement"})
+
+		if _, err := d.SearchContacts(1, "Catherine", models.ContactSearchOptions{Fuzzy: true}); err == nil {
+			t.Error("SearchContacts swallowed a query error")
+		}
+	})
+}
+
+func TestPatchContactGenderFailsWhenRelatedLookupFails(t *testing.T) {
+	captureLog(t, logger.ERROR)
+	tdb, mock := newMockTracedDB(t)
+	d := &Database{db: tdb}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT gender FROM contacts .* FOR UPDATE").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"gender"}).AddRow("male"))
+	mock.ExpectQuery("SELECT id, uid, given_name").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uid", "given_name", "family_name", "middle_name", "prefix", "suffix", "nickname"}).
+			AddRow(7, "uid-7", "Alex", "Smith", nil, nil, nil, nil))
+	mock.ExpectQuery("SELECT related_contact_id FROM relationships").
+		WithArgs(7).
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	gender := "female"
+	if _, err := d.PatchContact(1, 7, &models.ContactJSONPatch{Gender: &gender}); err == nil {
+		t.Error("PatchContact succeeded without the related contacts whose cards change with the gender")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
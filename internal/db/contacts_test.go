@@ -0,0 +1,673 @@
+package db_test
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db"
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestGetContactAddressesByID(t *testing.T) {
+	database := dbtest.Open(t)
+	owner := dbtest.NewUser(t, database)
+	other := dbtest.NewUser(t, database)
+
+	contact := dbtest.NewContact(t, database, owner.ID, &models.Contact{
+		FullName:  "Alice",
+		Addresses: []models.Address{{Street: "1 Main St", City: "Springfield", TypeLabel: "home"}},
+	})
+
+	got, err := database.GetContactAddressesByID(owner.ID, contact.ID)
+	if err != nil {
+		t.Fatalf("GetContactAddressesByID as owner: %v", err)
+	}
+	if len(got.Addresses) != 1 || got.Addresses[0].Street != "1 Main St" {
+		t.Errorf("addresses = %+v, want the contact's one address", got.Addresses)
+	}
+
+	got, err = database.GetContactAddressesByID(other.ID, contact.ID)
+	if err == nil || err.Error() != "not found" {
+		t.Errorf("GetContactAddressesByID as another user: err = %v, want not found", err)
+	}
+	if got != nil {
+		t.Errorf("another user got %+v, want nothing", got)
+	}
+}
+
+func TestPatchGenderBumpsRelatedContacts(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	parent := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Pat"})
+	child := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Kid"})
+	if _, _, err := database.AddRelationship(user.ID, child.ID, parent.ID, dbtest.RelationshipTypeID(t, database, "Parent")); err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+
+	before := dbtest.VersionToken(t, database, user.ID, child.UID)
+
+	gender := "F"
+	if _, err := database.PatchContact(user.ID, parent.ID, &models.ContactJSONPatch{Gender: &gender}); err != nil {
+		t.Fatalf("PatchContact: %v", err)
+	}
+
+	if after := dbtest.VersionToken(t, database, user.ID, child.UID); after <= before {
+		t.Errorf("related contact's version_token = %d after the gender change, want more than %d", after, before)
+	}
+}
+
+func TestConcurrentUpdatesGetUniqueSyncTokens(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	const writers = 25
+	contacts := make([]*models.Contact, writers)
+	for i := range contacts {
+		contacts[i] = dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: fmt.Sprintf("Contact %02d", i)})
+	}
+
+	start, err := database.GetAddressBookSyncToken(user.ID)
+	if err != nil {
+		t.Fatalf("GetAddressBookSyncToken: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i, c := range contacts {
+		wg.Add(1)
+		go func(i int, c *models.Contact) {
+			defer wg.Done()
+			notes := fmt.Sprintf("update %d", i)
+			if _, err := database.PatchContact(user.ID, c.ID, &models.ContactJSONPatch{Notes: &notes}); err != nil {
+				errs <- err
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("PatchContact: %v", err)
+	}
+
+	end, err := database.GetAddressBookSyncToken(user.ID)
+	if err != nil {
+		t.Fatalf("GetAddressBookSyncToken: %v", err)
+	}
+	if end != start+writers {
+		t.Errorf("sync token = %d after %d updates from %d, want %d", end, writers, start, start+writers)
+	}
+
+	// Every update must have been issued its own token, with none skipped or repeated
+	tokens := make([]int, 0, writers)
+	for _, c := range contacts {
+		tokens = append(tokens, dbtest.VersionToken(t, database, user.ID, c.UID))
+	}
+	sort.Ints(tokens)
+	for i, token := range tokens {
+		if token != start+i+1 {
+			t.Fatalf("version_tokens = %v, want each of %d..%d exactly once", tokens, start+1, end)
+		}
+	}
+}
+
+func TestRestoreContact(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+	other := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	if err := database.DeleteContact(user.ID, alice.ID); err != nil {
+		t.Fatalf("DeleteContact: %v", err)
+	}
+
+	trash, err := database.ListDeletedContacts(user.ID)
+	if err != nil {
+		t.Fatalf("ListDeletedContacts: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != alice.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("trash = %+v, want Alice", trash)
+	}
+
+	tombstone := changedContact(t, database, user.ID, 0, alice.UID)
+	if tombstone == nil || tombstone.DeletedAt == nil {
+		t.Fatalf("deleted contact isn't reported as a tombstone: %+v", tombstone)
+	}
+
+	if err := database.RestoreContact(other.ID, alice.ID); err == nil || err.Error() != "not found" {
+		t.Errorf("RestoreContact as another user: err = %v, want not found", err)
+	}
+	if err := database.RestoreContact(user.ID, alice.ID); err != nil {
+		t.Fatalf("RestoreContact: %v", err)
+	}
+	if err := database.RestoreContact(user.ID, alice.ID); err == nil || err.Error() != "not found" {
+		t.Errorf("restoring a contact that isn't deleted: err = %v, want not found", err)
+	}
+
+	all, err := database.GetAllContacts(user.ID, false)
+	if err != nil {
+		t.Fatalf("GetAllContacts: %v", err)
+	}
+	if len(all) != 1 || all[0].ID != alice.ID {
+		t.Errorf("GetAllContacts = %d contacts, want Alice back", len(all))
+	}
+
+	// A client that synced the deletion sees her again, with a new ETag
+	restored := changedContact(t, database, user.ID, int64(tombstone.VersionToken), alice.UID)
+	if restored == nil {
+		t.Fatal("restored contact isn't reported as changed since the deletion")
+	}
+	if restored.DeletedAt != nil {
+		t.Errorf("restored contact is still reported deleted at %v", restored.DeletedAt)
+	}
+	if restored.ETag == tombstone.ETag {
+		t.Errorf("ETag %q wasn't regenerated on restore", restored.ETag)
+	}
+
+	if trash, _ := database.ListDeletedContacts(user.ID); len(trash) != 0 {
+		t.Errorf("trash = %+v after restoring, want empty", trash)
+	}
+}
+
+func TestDeleteContactUnlinksRelatedContacts(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	if _, _, err := database.AddRelationship(user.ID, alice.ID, bob.ID, dbtest.RelationshipTypeID(t, database, "Spouse")); err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+
+	// Another user can't reach the relationships through a contact that isn't theirs
+	other := dbtest.NewUser(t, database)
+	if err := database.DeleteContact(other.ID, alice.ID); err == nil {
+		t.Error("another user deleted the contact")
+	}
+	if got, _ := database.GetContactByID(user.ID, bob.ID); got == nil || len(got.Relationships) != 1 {
+		t.Fatal("a failed delete removed the relationship")
+	}
+
+	before := dbtest.VersionToken(t, database, user.ID, bob.UID)
+	var events []string
+	database.ContactChangeHook = func(userID int, event string, contactID int) {
+		events = append(events, fmt.Sprintf("%s %d", event, contactID))
+	}
+	if err := database.DeleteContact(user.ID, alice.ID); err != nil {
+		t.Fatalf("DeleteContact: %v", err)
+	}
+
+	got, err := database.GetContactByID(user.ID, bob.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if len(got.Relationships) != 0 {
+		t.Errorf("relationships = %+v, want the link to the deleted contact gone", got.Relationships)
+	}
+	if after := dbtest.VersionToken(t, database, user.ID, bob.UID); after <= before {
+		t.Errorf("related contact's version_token = %d, want it past %d", after, before)
+	}
+	want := []string{
+		fmt.Sprintf("%s %d", models.WebhookEventContactDeleted, alice.ID),
+		fmt.Sprintf("%s %d", models.WebhookEventContactUpdated, bob.ID),
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestDeleteOldContactsRetention(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	if err := database.DeleteContact(user.ID, alice.ID); err != nil {
+		t.Fatalf("DeleteContact: %v", err)
+	}
+	if err := database.BackdateDeletion(alice.ID, time.Now().AddDate(0, 0, -10)); err != nil {
+		t.Fatalf("BackdateDeletion: %v", err)
+	}
+
+	inTrash := func() bool {
+		trash, err := database.ListDeletedContacts(user.ID)
+		if err != nil {
+			t.Fatalf("ListDeletedContacts: %v", err)
+		}
+		return len(trash) == 1
+	}
+
+	database.TrashRetentionDays = 30
+	if err := database.DeleteOldContacts(); err != nil {
+		t.Fatalf("DeleteOldContacts: %v", err)
+	}
+	if !inTrash() {
+		t.Fatal("contact deleted 10 days ago was purged with 30 days retention")
+	}
+
+	database.TrashRetentionDays = 7
+	if err := database.DeleteOldContacts(); err != nil {
+		t.Fatalf("DeleteOldContacts: %v", err)
+	}
+	if inTrash() {
+		t.Error("contact deleted 10 days ago was kept with 7 days retention")
+	}
+}
+
+func TestTombstones(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+	other := dbtest.NewUser(t, database)
+
+	old := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Old"})
+	recent := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Recent"})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alive"})
+	for _, c := range []*models.Contact{old, recent} {
+		if err := database.DeleteContact(user.ID, c.ID); err != nil {
+			t.Fatalf("DeleteContact(%s): %v", c.FullName, err)
+		}
+	}
+	if err := database.BackdateDeletion(old.ID, time.Now().AddDate(0, 0, -10)); err != nil {
+		t.Fatalf("BackdateDeletion: %v", err)
+	}
+
+	tombstones, err := database.ListTombstones(user.ID)
+	if err != nil {
+		t.Fatalf("ListTombstones: %v", err)
+	}
+	if len(tombstones) != 2 || tombstones[0].UID != recent.UID || tombstones[1].UID != old.UID {
+		t.Fatalf("tombstones = %+v, want Recent then Old", tombstones)
+	}
+	for _, ts := range tombstones {
+		if want := dbtest.VersionToken(t, database, user.ID, ts.UID); ts.VersionToken != want {
+			t.Errorf("%s: version_token = %d, want %d as clients see it", ts.FullName, ts.VersionToken, want)
+		}
+	}
+
+	if purged, err := database.PurgeTombstones(other.ID, 0); err != nil || purged != 0 {
+		t.Errorf("another user's purge: purged %d (err %v), want 0", purged, err)
+	}
+
+	purged, err := database.PurgeTombstones(user.ID, 7)
+	if err != nil {
+		t.Fatalf("PurgeTombstones: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d tombstones older than 7 days, want 1", purged)
+	}
+	if tombstones, _ := database.ListTombstones(user.ID); len(tombstones) != 1 || tombstones[0].UID != recent.UID {
+		t.Errorf("tombstones after purging = %+v, want only Recent", tombstones)
+	}
+}
+
+func TestPatchContactBirthdayToPartial(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	born := time.Date(1990, time.March, 14, 0, 0, 0, 0, time.UTC)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice", Birthday: &born})
+
+	month, day := 4, 30
+	if _, err := database.PatchContact(user.ID, alice.ID, &models.ContactJSONPatch{BirthdayMonth: &month, BirthdayDay: &day}); err != nil {
+		t.Fatalf("PatchContact: %v", err)
+	}
+
+	got, err := database.GetContactByID(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if got.Birthday != nil {
+		t.Errorf("birthday = %v, want NULL after patching month/day", got.Birthday)
+	}
+	if got.BirthdayMonth == nil || *got.BirthdayMonth != 4 || got.BirthdayDay == nil || *got.BirthdayDay != 30 {
+		t.Errorf("birthday month/day = %v/%v, want 4/30", got.BirthdayMonth, got.BirthdayDay)
+	}
+	if got.FullName != "Alice" {
+		t.Errorf("full name = %q, want it untouched", got.FullName)
+	}
+
+	// And back to a full date, which clears the month/day
+	full := "1991-05-01"
+	if _, err := database.PatchContact(user.ID, alice.ID, &models.ContactJSONPatch{Birthday: &full}); err != nil {
+		t.Fatalf("PatchContact: %v", err)
+	}
+	got, err = database.GetContactByID(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if got.Birthday == nil || got.Birthday.Format("2006-01-02") != full || got.BirthdayMonth != nil || got.BirthdayDay != nil {
+		t.Errorf("birthday = %v, month/day %v/%v; want only %s", got.Birthday, got.BirthdayMonth, got.BirthdayDay, full)
+	}
+}
+
+func TestSearchContactsMatchesAddress(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	joe := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:  "Joe Pipes",
+		Notes:     "Fixed the water heater",
+		Phones:    []models.Phone{{Phone: "555-867-5309", TypeLabel: "cell"}},
+		Addresses: []models.Address{{Street: "12 Oak Street", City: "Springfield", TypeLabel: "home"}},
+	})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob Smith"})
+
+	tests := []struct {
+		query  string
+		fields []string
+		want   []string
+	}{
+		{"oak street", nil, []string{joe.FullName}},
+		{"oak street", []string{"address"}, []string{joe.FullName}},
+		{"oak street", []string{"name", "notes"}, nil},
+		{"springfield", []string{"address"}, []string{joe.FullName}},
+		{"water heater", []string{"notes"}, []string{joe.FullName}},
+		{"867-5309", []string{"phone"}, []string{joe.FullName}},
+	}
+	for _, tt := range tests {
+		found, err := database.SearchContacts(user.ID, tt.query, models.ContactSearchOptions{Fields: tt.fields})
+		if err != nil {
+			t.Fatalf("SearchContacts(%q, %v): %v", tt.query, tt.fields, err)
+		}
+		var names []string
+		for _, c := range found {
+			names = append(names, c.FullName)
+		}
+		if fmt.Sprint(names) != fmt.Sprint(tt.want) {
+			t.Errorf("SearchContacts(%q, %v) = %v, want %v", tt.query, tt.fields, names, tt.want)
+		}
+	}
+}
+
+func TestSearchContactsToleratesTypos(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Katherine Smith", GivenName: "Katherine", FamilyName: "Smith"})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob Jones", GivenName: "Bob", FamilyName: "Jones"})
+
+	for _, fuzzy := range []bool{true, false} {
+		found, err := database.SearchContacts(user.ID, "Catherine", models.ContactSearchOptions{Fuzzy: fuzzy})
+		if err != nil {
+			t.Fatalf("SearchContacts(fuzzy %v): %v", fuzzy, err)
+		}
+		if len(found) != 1 || found[0].FullName != "Katherine Smith" {
+			t.Errorf("fuzzy %v: found %d contacts, want Katherine Smith", fuzzy, len(found))
+		}
+	}
+}
+
+func TestCreateContactUIDDomain(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	// A contact created before UID_DOMAIN was set keeps its bare UID
+	old := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Old"})
+
+	database.UIDDomain = "urn:uuid"
+	urn := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "URN"})
+	if !strings.HasPrefix(urn.UID, "urn:uuid:") {
+		t.Errorf("UID = %q, want a urn:uuid URN", urn.UID)
+	}
+
+	database.UIDDomain = "example.com"
+	qualified := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Qualified"})
+	if !strings.HasSuffix(qualified.UID, "@example.com") {
+		t.Errorf("UID = %q, want it qualified by example.com", qualified.UID)
+	}
+
+	for _, c := range []*models.Contact{old, urn, qualified} {
+		got, err := database.GetContactByUID(user.ID, c.UID, false)
+		if err != nil {
+			t.Errorf("GetContactByUID(%q): %v", c.UID, err)
+			continue
+		}
+		if got.ID != c.ID {
+			t.Errorf("GetContactByUID(%q) = contact %d, want %d", c.UID, got.ID, c.ID)
+		}
+	}
+}
+
+// changedContact returns the contact with uid from ListContactsChangedSince, or nil
+func changedContact(t *testing.T, database *db.Database, userID int, since int64, uid string) *models.Contact {
+	t.Helper()
+
+	changed, err := database.ListContactsChangedSince(userID, since, false)
+	if err != nil {
+		t.Fatalf("ListContactsChangedSince: %v", err)
+	}
+	for i := range changed {
+		if changed[i].UID == uid {
+			return &changed[i]
+		}
+	}
+	return nil
+}
+
+// BenchmarkGetAllContacts compares batch loading related rows with the old path of loading each
+// contact (and its related rows) separately
+func BenchmarkGetAllContacts(b *testing.B) {
+	database := dbtest.Open(b)
+	user := dbtest.NewUser(b, database)
+
+	for i := range 1000 {
+		dbtest.NewContact(b, database, user.ID, &models.Contact{
+			FullName: fmt.Sprintf("Contact %04d", i),
+			Emails:   []models.Email{{Email: fmt.Sprintf("contact%d@example.com", i), TypeLabel: "home"}},
+			Phones:   []models.Phone{{Phone: fmt.Sprintf("+1555555%04d", i), TypeLabel: "mobile"}},
+		})
+	}
+
+	b.Run("batched", func(b *testing.B) {
+		for b.Loop() {
+			contacts, err := database.GetAllContacts(user.ID, false)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(contacts) != 1000 {
+				b.Fatalf("got %d contacts, want 1000", len(contacts))
+			}
+		}
+	})
+
+	b.Run("per-contact", func(b *testing.B) {
+		for b.Loop() {
+			abbrv, err := database.GetAllContactsAbbrv(user.ID, false)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, c := range abbrv {
+				if _, err := database.GetContactByID(user.ID, c.ID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func TestOrganizationRole(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	contact := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:      "Alice",
+		Organizations: []models.Organization{{Name: "Acme Corp", Title: "Senior Engineer", Role: "Fire Warden"}},
+	})
+
+	got, err := database.GetContactByID(user.ID, contact.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if len(got.Organizations) != 1 {
+		t.Fatalf("got %d organizations, want 1", len(got.Organizations))
+	}
+	org := got.Organizations[0]
+	if org.Title != "Senior Engineer" || org.Role != "Fire Warden" {
+		t.Errorf("title %q and role %q, want Senior Engineer and Fire Warden", org.Title, org.Role)
+	}
+
+	// Patching the role leaves the title alone
+	role := "First Aider"
+	orgs, err := database.UpdateContactOrganization(user.ID, models.OrganizationJSONPatch{ID: org.ID, Role: &role})
+	if err != nil {
+		t.Fatalf("UpdateContactOrganization: %v", err)
+	}
+	if len(orgs) != 1 || orgs[0].Title != "Senior Engineer" || orgs[0].Role != role {
+		t.Errorf("after patching the role: %+v", orgs)
+	}
+}
+
+func TestMutationsBumpSyncTokens(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	friend := dbtest.RelationshipTypeID(t, database, "Friend")
+
+	var relationshipID int
+	nickname := "Al"
+	mutations := []struct {
+		name   string
+		mutate func() error
+	}{
+		{"update avatar", func() error {
+			return database.UpdateAvatar(user.ID, alice.ID, "aGVsbG8=", "image/png")
+		}},
+		{"delete avatar", func() error { return database.DeleteAvatar(user.ID, alice.ID) }},
+		{"update notes", func() error {
+			return database.UpdateContactNotes(user.ID, models.NotesJSONPut{ContactID: alice.ID, Notes: "Met at the conference"})
+		}},
+		{"patch", func() error {
+			_, err := database.PatchContact(user.ID, alice.ID, &models.ContactJSONPatch{Nickname: &nickname})
+			return err
+		}},
+		{"add relationship", func() error {
+			rel, _, err := database.AddRelationship(user.ID, alice.ID, bob.ID, friend)
+			if rel != nil {
+				relationshipID = rel.ID
+			}
+			return err
+		}},
+		{"remove relationship", func() error { return database.RemoveRelationship(user.ID, relationshipID) }},
+	}
+
+	for _, m := range mutations {
+		globalBefore, err := database.GetAddressBookSyncToken(user.ID)
+		if err != nil {
+			t.Fatalf("GetAddressBookSyncToken: %v", err)
+		}
+		before := dbtest.VersionToken(t, database, user.ID, alice.UID)
+
+		if err := m.mutate(); err != nil {
+			t.Fatalf("%s: %v", m.name, err)
+		}
+
+		globalAfter, err := database.GetAddressBookSyncToken(user.ID)
+		if err != nil {
+			t.Fatalf("GetAddressBookSyncToken: %v", err)
+		}
+		if globalAfter <= globalBefore {
+			t.Errorf("%s: address book token = %d, want more than %d", m.name, globalAfter, globalBefore)
+		}
+
+		changed := changedContact(t, database, user.ID, int64(globalBefore), alice.UID)
+		if changed == nil {
+			t.Errorf("%s: Alice is missing from the changes since token %d", m.name, globalBefore)
+			continue
+		}
+		if changed.VersionToken <= before {
+			t.Errorf("%s: version_token = %d, want more than %d", m.name, changed.VersionToken, before)
+		}
+	}
+}
+
+func TestSearchContactsRanksNicknames(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	for _, c := range []*models.Contact{
+		{FullName: "Aaron Bobson", GivenName: "Aaron", FamilyName: "Bobson"},
+		{FullName: "Abe Jacobs", GivenName: "Abe", FamilyName: "Jacobs", Notes: "Bob's neighbour"},
+		{FullName: "Bobby Tables", GivenName: "Bobby", FamilyName: "Tables"},
+		{FullName: "Robert Smith", GivenName: "Robert", FamilyName: "Smith", Nickname: "Bob"},
+	} {
+		dbtest.NewContact(t, database, user.ID, c)
+	}
+
+	found, err := database.SearchContacts(user.ID, "bob", models.ContactSearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchContacts: %v", err)
+	}
+	var names []string
+	for _, c := range found {
+		names = append(names, c.FullName)
+	}
+	if len(names) < 3 || names[0] != "Robert Smith" || names[1] != "Bobby Tables" || names[2] != "Aaron Bobson" {
+		t.Errorf("search for bob ranked %q; want the exact nickname, then the prefix, then the substring match first", names)
+	}
+}
+
+func TestGeoTimezoneLanguages(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	latitude, longitude := 37.386, -122.084
+	contact := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:  "Alice",
+		Latitude:  &latitude,
+		Longitude: &longitude,
+		Timezone:  "America/Los_Angeles",
+		Languages: []string{"en", "fr"},
+	})
+
+	got, err := database.GetContactByID(user.ID, contact.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if got.Latitude == nil || got.Longitude == nil || *got.Latitude != latitude || *got.Longitude != longitude {
+		t.Errorf("location was not stored as %v, %v", latitude, longitude)
+	}
+	if got.Timezone != "America/Los_Angeles" || strings.Join(got.Languages, ",") != "en,fr" {
+		t.Errorf("timezone %q and languages %q, want America/Los_Angeles and [en fr]", got.Timezone, got.Languages)
+	}
+
+	got.Timezone = "Europe/Paris"
+	got.Languages = []string{"fr"}
+	if err := database.UpdateContact(user.ID, got); err != nil {
+		t.Fatalf("UpdateContact: %v", err)
+	}
+
+	languages := []string{"fr", "de"}
+	patched, err := database.PatchContact(user.ID, contact.ID, &models.ContactJSONPatch{Languages: &languages})
+	if err != nil {
+		t.Fatalf("PatchContact: %v", err)
+	}
+	if patched.Timezone != "Europe/Paris" || strings.Join(patched.Languages, ",") != "fr,de" {
+		t.Errorf("after update and patch: timezone %q and languages %q, want Europe/Paris and [fr de]", patched.Timezone, patched.Languages)
+	}
+	if patched.Latitude == nil || *patched.Latitude != latitude {
+		t.Errorf("an unrelated patch changed the latitude, want %v", latitude)
+	}
+
+	// An update that leaves them out keeps them; an empty language list clears the languages
+	omitted := &models.Contact{ID: contact.ID, FullName: "Alice", Languages: []string{}}
+	if err := database.UpdateContact(user.ID, omitted); err != nil {
+		t.Fatalf("UpdateContact: %v", err)
+	}
+	got, err = database.GetContactByID(user.ID, contact.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if got.Latitude == nil || *got.Latitude != latitude || got.Timezone != "Europe/Paris" {
+		t.Errorf("update without them: location %v and timezone %q, want them kept", got.Latitude, got.Timezone)
+	}
+	if len(got.Languages) != 0 {
+		t.Errorf("languages = %q, want them cleared", got.Languages)
+	}
+}
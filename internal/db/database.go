@@ -22,9 +22,56 @@ import (
 )
 
 type Database struct {
-	db *sql.DB
+	db *tracedDB
+
+	// ExplicitMirrorRelationships stores the reverse of every new relationship as its own row
+	// (e.g. adding "Son" also writes "Father"), instead of computing reverses when reading
+	ExplicitMirrorRelationships bool
+
+	// TrashRetentionDays is how long soft-deleted contacts are kept before DeleteOldContacts purges them
+	TrashRetentionDays int
+
+	// SuggestionMaxSteps caps how many candidate pairs GetRelationshipSuggestions examines, and
+	// SuggestionMaxResults how many suggestions it returns, so large family trees stay cheap
+	SuggestionMaxSteps   int
+	SuggestionMaxResults int
+
+	// UIDDomain qualifies the UIDs CreateContact generates (see utils.NewUID)
+	UIDDomain string
+
+	// ContactChangeHook, when set, is called after a change to a contact's card commits, with one
+	// of the models.WebhookEvent* events. Every write that moves a contact's sync token reports
+	// it, including edits to phones, emails, tags and relationships. It runs on the caller's goroutine
+	ContactChangeHook func(userID int, event string, contactID int)
+
+	labels labelCache
+}
+
+// contactChanged reports a committed contact change to ContactChangeHook, if one is set
+func (d *Database) contactChanged(userID int, event string, contactID int) {
+	if d.ContactChangeHook != nil {
+		d.ContactChangeHook(userID, event, contactID)
+	}
+}
+
+// contactsUpdated reports WebhookEventContactUpdated for each contact whose card a committed
+// write changed
+func (d *Database) contactsUpdated(userID int, contactIDs ...int) {
+	for _, id := range contactIDs {
+		d.contactChanged(userID, models.WebhookEventContactUpdated, id)
+	}
 }
 
+// DefaultTrashRetentionDays is the soft-delete retention window used when none is configured
+const DefaultTrashRetentionDays = 30
+
+// DefaultSuggestionMaxSteps and DefaultSuggestionMaxResults bound relationship suggestions when no
+// limits are configured
+const (
+	DefaultSuggestionMaxSteps   = 20000
+	DefaultSuggestionMaxResults = 500
+)
+
 // New creates a new database connection
 func New(host, port, user, password, dbname string) (*Database, error) {
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
@@ -40,11 +87,24 @@ func New(host, port, user, password, dbname string) (*Database, error) {
 	}
 
 	// Run migrations automatically on startup
-	d := &Database{db: db}
+	d := &Database{
+		db:                   &tracedDB{DB: db},
+		TrashRetentionDays:   DefaultTrashRetentionDays,
+		SuggestionMaxSteps:   DefaultSuggestionMaxSteps,
+		SuggestionMaxResults: DefaultSuggestionMaxResults,
+	}
 	if err := d.Migrate(); err != nil {
 		return nil, err
 	}
 
+	inserted, repaired, err := d.SeedRelationshipTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed relationship types: %w", err)
+	}
+	if inserted > 0 || repaired > 0 {
+		logger.Info("[DATABASE] Seeded %d and repaired %d relationship types", inserted, repaired)
+	}
+
 	d.db.Exec("SET TIME ZONE 'UTC'")
 
 	return d, nil
@@ -95,11 +155,11 @@ func (d *Database) GetUserByEmail(email string) (*models.User, error) {
 
 	user := &models.User{}
 	err := d.db.QueryRow(`
-		SELECT id, email, password_hash, is_setup_complete, theme, created_at, updated_at
+		SELECT id, email, password_hash, is_setup_complete, theme, nickname_as_display_name, carddav_display_name, default_event_lookahead_days, created_at, updated_at
 		FROM users WHERE email = $1`,
 		email,
 	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.IsSetupComplete,
-		&user.Theme, &user.CreatedAt, &user.UpdatedAt)
+		&user.Theme, &user.NicknameAsDisplayName, &user.CardDAVDisplayName, &user.EventLookaheadDays, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		logger.Error("[DATABASE] Error getting user by email: %v", err)
@@ -114,11 +174,11 @@ func (d *Database) GetUserByID(userID int) (*models.User, error) {
 
 	user := &models.User{}
 	err := d.db.QueryRow(`
-		SELECT id, email, password_hash, is_setup_complete, theme, created_at, updated_at
+		SELECT id, email, password_hash, is_setup_complete, theme, nickname_as_display_name, carddav_display_name, default_event_lookahead_days, created_at, updated_at
 		FROM users WHERE id = $1`,
 		userID,
 	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.IsSetupComplete,
-		&user.Theme, &user.CreatedAt, &user.UpdatedAt)
+		&user.Theme, &user.NicknameAsDisplayName, &user.CardDAVDisplayName, &user.EventLookaheadDays, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting user by ID: %v", err)
@@ -220,20 +280,34 @@ func (d *Database) GetAddressBookSyncToken(userID int) (int, error) {
 	return token, nil
 }
 
-// IncrementAndGetNewSyncToken atomically increments the user's sync token and returns the new value.
-// This is critical for preventing race conditions during contact creation/update.
-func (d *Database) IncrementAndGetNewSyncToken(userID int) (int, error) {
-	logger.Debug("[DATABASE] Begin IncrementAndGetNewSyncToken(userID:%d)", userID)
+// dbExecutor is satisfied by both tracedDB and tracedTx (and *sql.DB and *sql.Tx), so helpers can run inside a caller's transaction
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
 
-	// 1. Begin a transaction
-	tx, err := d.db.Begin()
+// bumpSyncTokens increments the user's sync token and stamps each contact with it. Run it on the
+// transaction that made the change so the collection CTag and the contacts' version_tokens become
+// visible together; otherwise a client syncing in between sees the new CTag but misses the change
+func bumpSyncTokens(q dbExecutor, userID int, contactIDs ...int) error {
+	newSyncToken, err := incrementSyncToken(q, userID)
 	if err != nil {
-		return 0, err
+		return fmt.Errorf("failed to increment sync token: %w", err)
 	}
-	defer tx.Rollback() // Rollback if not committed
 
-	// 2. Increment the token and return the new value (RETURNING is PostgreSQL syntax)
-	// For SQLite or MySQL, you might need two separate UPDATE/SELECT statements within the transaction.
+	for _, contactID := range contactIDs {
+		if err := setContactSyncToken(q, contactID, newSyncToken); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// incrementSyncToken bumps the user's sync token in a single UPDATE ... RETURNING, so concurrent
+// writers can never read the same value. Run it on the caller's transaction to keep the user row
+// locked until the change commits; tokens then become visible in the order they were issued
+func incrementSyncToken(q dbExecutor, userID int) (int, error) {
 	var newToken int
 	query := `
         UPDATE users 
@@ -241,15 +315,9 @@ func (d *Database) IncrementAndGetNewSyncToken(userID int) (int, error) {
         WHERE id = $1
         RETURNING addressbook_sync_token`
 
-	err = tx.QueryRow(query, userID).Scan(&newToken)
+	err := q.QueryRow(query, userID).Scan(&newToken)
 	if err != nil {
-		logger.Error("[DATABASE] Error selecting users: %v", err)
-		return 0, err
-	}
-
-	// 3. Commit the transaction
-	if err := tx.Commit(); err != nil {
-		logger.Error("[DATABASE] Error commiting user tx: %v", err)
+		logger.Error("[DATABASE] Error incrementing sync token: %v", err)
 		return 0, err
 	}
 
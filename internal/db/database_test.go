@@ -0,0 +1,28 @@
+package db
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestIncrementSyncTokenIsAtomic(t *testing.T) {
+	tdb, mock := newMockTracedDB(t)
+
+	// A single UPDATE ... RETURNING, rather than a read followed by a write
+	mock.ExpectQuery(regexp.QuoteMeta("SET addressbook_sync_token = addressbook_sync_token + 1")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"addressbook_sync_token"}).AddRow(42))
+
+	token, err := incrementSyncToken(tdb, 7)
+	if err != nil {
+		t.Fatalf("incrementSyncToken: %v", err)
+	}
+	if token != 42 {
+		t.Errorf("token = %d, want 42", token)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -33,7 +34,14 @@ func (d *Database) CreateContactOtherDate(userID int, body models.ContactDateJSO
 		args = append(args, body.DateDay)
 	}
 
-	err := d.db.QueryRow(query, args...).Scan(&newID)
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(query, args...).Scan(&newID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			logger.Error("No other dates inserted: %v", err)
@@ -43,75 +51,111 @@ func (d *Database) CreateContactOtherDate(userID int, body models.ContactDateJSO
 		return 0, fmt.Errorf("failed to create other date: %w", err)
 	}
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return newID, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, body.ContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return 0, err
 	}
 
-	if err := d.bumpContactSyncToken(body.ContactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return 0, err
 	}
 
+	d.contactsUpdated(userID, body.ContactID)
+
 	return newID, nil
 }
 
-// UpdateContactDate specifically updates other_dates on a contact
-func (d *Database) UpdateContactOtherDate(userID int, body models.ContactDateJSONPatch) error {
-	logger.Debug("[DATABASE] Begin UpdateContactOtherDate(userID:%d, body:--)", userID)
+// UpdateOtherDate updates only the provided fields of an other date owned by the user. Setting a
+// full date clears the partial month/day and vice versa. Returns "not found" when the other date
+// doesn't exist or belongs to another user
+func (d *Database) UpdateOtherDate(userID int, eventID int, patch *models.OtherDateJSONPatch) (*models.OtherDate, error) {
+	logger.Debug("[DATABASE] Begin UpdateOtherDate(userID:%d, eventID:%d, patch:--)", userID, eventID)
 
 	if logger.GetLevel() == logger.TRACE {
-		logger.Trace("[DATABSE] Dump of ContactDateJSONPatch:")
-		utils.Dump(body)
+		logger.Trace("[DATABSE] Dump of OtherDateJSONPatch:")
+		utils.Dump(patch)
 	}
 
 	updates := []string{}
 	args := []interface{}{}
 	argIndex := 1
 
-	if body.DateType != "" {
+	if patch.EventName != nil {
 		updates = append(updates, fmt.Sprintf("event_name = $%d", argIndex))
-		args = append(args, body.DateType)
+		args = append(args, *patch.EventName)
 		argIndex++
 	}
 
-	if body.Date != nil {
+	if patch.EventDate != nil {
 		updates = append(updates, fmt.Sprintf("event_date = $%d", argIndex))
-		args = append(args, body.Date)
+		args = append(args, *patch.EventDate)
 		argIndex++
-	} else if body.DateMonth != nil && body.DateDay != nil {
+
+		updates = append(updates, "event_date_month = NULL", "event_date_day = NULL")
+	} else if patch.EventDateMonth != nil && patch.EventDateDay != nil {
+		updates = append(updates, "event_date = NULL")
+
 		updates = append(updates, fmt.Sprintf("event_date_month = $%d", argIndex))
-		args = append(args, body.DateMonth)
+		args = append(args, *patch.EventDateMonth)
 		argIndex++
 
 		updates = append(updates, fmt.Sprintf("event_date_day = $%d", argIndex))
-		args = append(args, body.DateDay)
+		args = append(args, *patch.EventDateDay)
 		argIndex++
 	}
 
-	// Add WHERE clause parameters
-	//    WHERE id = $%d
-	args = append(args, body.ContactID)
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+
+	args = append(args, eventID, userID)
 
-	// Build and execute query
 	query := fmt.Sprintf(`
 		UPDATE other_dates
 		SET %s
 		WHERE id = $%d
-	`, strings.Join(updates, ", "), argIndex)
+		AND contact_id IN (SELECT id FROM contacts WHERE user_id = $%d AND deleted_at IS NULL)
+		RETURNING id, contact_id, event_name, event_date, event_date_month, event_date_day
+	`, strings.Join(updates, ", "), argIndex, argIndex+1)
 
-	_, err := d.db.Exec(query, args...)
+	var otherDate models.OtherDate
+	var eventName sql.NullString
+	var eventDate sql.NullTime
+	var eventMonth, eventDay sql.NullInt64
+
+	tx, err := d.db.Begin()
 	if err != nil {
-		return err
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	// Increment sync token so CardDAV clients pull the change
-	newToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err == nil {
-		_ = d.bumpContactSyncToken(body.ContactID, newToken)
+	err = tx.QueryRow(query, args...).Scan(&otherDate.ID, &otherDate.ContactID, &eventName, &eventDate, &eventMonth, &eventDay)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("not found")
+	}
+	if err != nil {
+		logger.Error("[DATABASE] Error updating other date: %v", err)
+		return nil, err
 	}
 
-	return nil
+	otherDate.EventName = utils.ScanNullString(eventName)
+	otherDate.EventDate = utils.ScanNullTime(eventDate)
+	otherDate.EventDateMonth = utils.ScanNullInt(eventMonth)
+	otherDate.EventDateDay = utils.ScanNullInt(eventDay)
+
+	if err := bumpSyncTokens(tx, userID, otherDate.ContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	d.contactsUpdated(userID, otherDate.ContactID)
+
+	return &otherDate, nil
 }
 
 // UpdateContactDate specifically updates formal dates (birthday / anniversary) on a contact
@@ -141,6 +185,7 @@ func (d *Database) UpdateContactDate(userID int, body models.ContactDateJSONPatc
 
 			updates = append(updates, fmt.Sprintf("%s_month = NULL", body.DateType))
 			updates = append(updates, fmt.Sprintf("%s_day = NULL", body.DateType))
+			updates = append(updates, fmt.Sprintf("%s_year = NULL", body.DateType))
 		} else {
 			// 2. If body.Date is nil, it means either we are clearing EVERYTHING,
 			// or we are using partial dates (Month/Day).
@@ -156,6 +201,11 @@ func (d *Database) UpdateContactDate(userID int, body models.ContactDateJSONPatc
 			updates = append(updates, fmt.Sprintf("%s_day = $%d", body.DateType, argIndex))
 			args = append(args, body.DateDay) // driver handles nil as SQL NULL
 			argIndex++
+
+			// Reduced precision: year only, or year+month (day is nil)
+			updates = append(updates, fmt.Sprintf("%s_year = $%d", body.DateType, argIndex))
+			args = append(args, body.DateYear) // driver handles nil as SQL NULL
+			argIndex++
 		}
 	}
 
@@ -170,38 +220,122 @@ func (d *Database) UpdateContactDate(userID int, body models.ContactDateJSONPatc
 		WHERE id = $%d AND user_id = $%d
 	`, tableName, strings.Join(updates, ", "), argIndex, argIndex+1)
 
-	_, err := d.db.Exec(query, args...)
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		logger.Error("[DATABASE] Error updating %s: %v", body.DateType, err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
+
+	if err := bumpSyncTokens(tx, userID, body.ContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactsUpdated(userID, body.ContactID)
+	return nil
+}
+
+// AcceptAnniversarySuggestion applies a suggestion from GetAnniversarySuggestions by copying the
+// source spouse's anniversary, full or partial, onto the target. Returns "not found" unless it is
+// currently suggested, so an anniversary the target already has is never overwritten
+func (d *Database) AcceptAnniversarySuggestion(userID int, accept models.AnniversarySuggestionAccept) error {
+	logger.Debug("[DATABASE] Begin AcceptAnniversarySuggestion(userID:%d, targetID:%d, sourceID:%d)", userID, accept.TargetID, accept.SourceID)
+
+	suggestions, err := d.GetAnniversarySuggestions(userID)
 	if err != nil {
+		logger.Error("[DATABASE] Error loading anniversary suggestions: %v", err)
 		return err
 	}
 
-	// Increment sync token so CardDAV clients pull the change
-	newToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err == nil {
-		_ = d.bumpContactSyncToken(body.ContactID, newToken)
+	suggested := false
+	for _, suggestion := range suggestions {
+		if suggestion.TargetID == accept.TargetID && suggestion.ProposedID == accept.SourceID {
+			suggested = true
+			break
+		}
+	}
+	if !suggested {
+		return errors.New("not found")
 	}
 
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE contacts t
+		SET anniversary = s.anniversary,
+			anniversary_month = s.anniversary_month,
+			anniversary_day = s.anniversary_day,
+			anniversary_year = s.anniversary_year,
+			updated_at = NOW()
+		FROM contacts s
+		WHERE t.id = $1 AND t.user_id = $3 AND t.deleted_at IS NULL
+		AND s.id = $2 AND s.user_id = $3 AND s.deleted_at IS NULL`,
+		accept.TargetID, accept.SourceID, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error copying anniversary: %v", err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
+
+	if err := bumpSyncTokens(tx, userID, accept.TargetID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactsUpdated(userID, accept.TargetID)
 	return nil
 }
 
 func (d *Database) DeleteContactOtherDate(userID int, contactID int, otherDateID int) error {
 	logger.Debug("[DATABASE] Begin DeleteContactOtherDate(userID:%d, contactID:%d, emailID:%d)", userID, contactID, otherDateID)
 
-	_, err := d.db.Exec("DELETE FROM other_dates WHERE id = $1 AND contact_id = $2", otherDateID, contactID)
+	tx, err := d.db.Begin()
 	if err != nil {
-		logger.Error("[DATABASE] Error deleting Other Date: %v", err)
+		logger.Error("[DATABASE] Error starting tx: %v", err)
 		return err
 	}
+	defer tx.Rollback()
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	_, err = tx.Exec("DELETE FROM other_dates WHERE id = $1 AND contact_id = $2", otherDateID, contactID)
 	if err != nil {
-		return fmt.Errorf("failed to increment sync token: %w", err)
+		logger.Error("[DATABASE] Error deleting Other Date: %v", err)
+		return err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	d.contactsUpdated(userID, contactID)
 	return nil
 }
@@ -0,0 +1,105 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestUpdateOtherDate(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+	other := dbtest.NewUser(t, database)
+
+	graduated := time.Date(2015, time.June, 12, 0, 0, 0, 0, time.UTC)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:   "Alice",
+		OtherDates: []models.OtherDate{{EventName: "Graduation", EventDate: &graduated}},
+	})
+	stored, err := database.GetContactByID(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if len(stored.OtherDates) != 1 {
+		t.Fatalf("stored %d other dates, want 1", len(stored.OtherDates))
+	}
+	eventID := stored.OtherDates[0].ID
+	before := dbtest.VersionToken(t, database, user.ID, alice.UID)
+
+	name := "College Graduation"
+	if _, err := database.UpdateOtherDate(other.ID, eventID, &models.OtherDateJSONPatch{EventName: &name}); err == nil || err.Error() != "not found" {
+		t.Errorf("patching another user's date: err = %v, want not found", err)
+	}
+
+	updated, err := database.UpdateOtherDate(user.ID, eventID, &models.OtherDateJSONPatch{EventName: &name})
+	if err != nil {
+		t.Fatalf("UpdateOtherDate: %v", err)
+	}
+	if updated.EventName != name {
+		t.Errorf("event_name = %q, want %q", updated.EventName, name)
+	}
+	if updated.EventDate == nil || !updated.EventDate.Equal(graduated) {
+		t.Errorf("event_date = %v, want it untouched at %v", updated.EventDate, graduated)
+	}
+	if updated.EventDateMonth != nil || updated.EventDateDay != nil {
+		t.Errorf("month/day = %v/%v, want them still unset", updated.EventDateMonth, updated.EventDateDay)
+	}
+	if after := dbtest.VersionToken(t, database, user.ID, alice.UID); after <= before {
+		t.Errorf("version_token = %d after the patch, want more than %d", after, before)
+	}
+
+	// Switching to a partial date clears the full one
+	month, day := 6, 12
+	updated, err = database.UpdateOtherDate(user.ID, eventID, &models.OtherDateJSONPatch{EventDateMonth: &month, EventDateDay: &day})
+	if err != nil {
+		t.Fatalf("UpdateOtherDate: %v", err)
+	}
+	if updated.EventDate != nil || updated.EventDateMonth == nil || *updated.EventDateMonth != 6 || updated.EventDateDay == nil || *updated.EventDateDay != 12 {
+		t.Errorf("got date %v, month/day %v/%v; want only June 12", updated.EventDate, updated.EventDateMonth, updated.EventDateDay)
+	}
+	if updated.EventName != name {
+		t.Errorf("event_name = %q after patching the date, want %q", updated.EventName, name)
+	}
+}
+
+func TestUpdateContactDateBirthdayToPartial(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	born := time.Date(1990, time.March, 14, 0, 0, 0, 0, time.UTC)
+	married := time.Date(2015, time.June, 12, 0, 0, 0, 0, time.UTC)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice", Birthday: &born, Anniversary: &married})
+	before := dbtest.VersionToken(t, database, user.ID, alice.UID)
+
+	month, day := 3, 14
+	if err := database.UpdateContactDate(user.ID, models.ContactDateJSONPatch{
+		ContactID: alice.ID, DateType: "birthday", DateMonth: &month, DateDay: &day,
+	}); err != nil {
+		t.Fatalf("UpdateContactDate: %v", err)
+	}
+
+	got, err := database.GetContactByID(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if got.Birthday != nil {
+		t.Errorf("birthday = %v, want NULL after switching to a partial date", got.Birthday)
+	}
+	if got.BirthdayMonth == nil || *got.BirthdayMonth != 3 || got.BirthdayDay == nil || *got.BirthdayDay != 14 || got.BirthdayYear != nil {
+		t.Errorf("birthday month/day/year = %v/%v/%v, want 3/14 without a year", got.BirthdayMonth, got.BirthdayDay, got.BirthdayYear)
+	}
+	if got.Anniversary == nil || !got.Anniversary.Equal(married) {
+		t.Errorf("anniversary = %v, want it untouched at %v", got.Anniversary, married)
+	}
+	if after := dbtest.VersionToken(t, database, user.ID, alice.UID); after <= before {
+		t.Errorf("version_token = %d after the patch, want more than %d", after, before)
+	}
+
+	if err := database.UpdateContactDate(user.ID, models.ContactDateJSONPatch{
+		ContactID: -1, DateType: "birthday", DateMonth: &month, DateDay: &day,
+	}); err == nil || err.Error() != "not found" {
+		t.Errorf("patching a missing contact: err = %v, want not found", err)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+// Package dbtest connects tests to a scratch PostgreSQL database. Tests that use it are skipped
+// unless KINDREDCARD_TEST_DB_NAME is set; KINDREDCARD_TEST_DB_HOST, _PORT, _USER, and _PASSWORD
+// default to the docker-compose values. Each test gets its own user, so tests don't see each
+// other's contacts
+package dbtest
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+var userSeq atomic.Int64
+
+// Open connects to the test database (running migrations) or skips the test when none is configured
+func Open(t testing.TB) *db.Database {
+	t.Helper()
+
+	name := os.Getenv("KINDREDCARD_TEST_DB_NAME")
+	if name == "" {
+		t.Skip("KINDREDCARD_TEST_DB_NAME not set; skipping database test")
+	}
+
+	database, err := db.New(
+		getEnv("KINDREDCARD_TEST_DB_HOST", "localhost"),
+		getEnv("KINDREDCARD_TEST_DB_PORT", "5432"),
+		getEnv("KINDREDCARD_TEST_DB_USER", "kindredcard"),
+		getEnv("KINDREDCARD_TEST_DB_PASSWORD", "kindredcardsecretpassword"),
+		name,
+	)
+	if err != nil {
+		t.Fatalf("connecting to test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+// NewUser creates a user that is deleted, with everything it owns, when the test ends
+func NewUser(t testing.TB, database *db.Database) *models.User {
+	t.Helper()
+
+	email := fmt.Sprintf("test-%d-%d@example.com", time.Now().UnixNano(), userSeq.Add(1))
+	user, err := database.CreateUser(email, "not-a-real-hash")
+	if err != nil {
+		t.Fatalf("creating test user: %v", err)
+	}
+	t.Cleanup(func() { database.DeleteUser(user.ID) })
+	return user
+}
+
+// NewContact creates contact for the user, failing the test on error
+func NewContact(t testing.TB, database *db.Database, userID int, contact *models.Contact) *models.Contact {
+	t.Helper()
+
+	if err := database.CreateContact(userID, contact); err != nil {
+		t.Fatalf("creating contact %q: %v", contact.FullName, err)
+	}
+	return contact
+}
+
+// RelationshipTypeID returns the id of a seeded relationship type such as "Spouse"
+func RelationshipTypeID(t testing.TB, database *db.Database, name string) int {
+	t.Helper()
+
+	types, err := database.GetRelationshipTypes()
+	if err != nil {
+		t.Fatalf("loading relationship types: %v", err)
+	}
+	for _, rt := range types {
+		if rt.Name == name {
+			return rt.ID
+		}
+	}
+	t.Fatalf("no relationship type %q", name)
+	return 0
+}
+
+// VersionToken returns the contact's current version_token, the sync token CardDAV clients compare
+func VersionToken(t testing.TB, database *db.Database, userID int, uid string) int {
+	t.Helper()
+
+	changed, err := database.ListContactsChangedSince(userID, 0, false)
+	if err != nil {
+		t.Fatalf("listing changed contacts: %v", err)
+	}
+	for _, c := range changed {
+		if c.UID == uid {
+			return c.VersionToken
+		}
+	}
+	t.Fatalf("no contact with uid %q", uid)
+	return 0
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -12,23 +12,41 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/models"
 )
 
 // GetUpcomingEventsByDays gets events in the next N days (1-14)
+// Reduced precision dates (year-only or year+month) have no day and are never matched
+// Feb 29 events are observed on Feb 28 in common years (see observed_event_date)
 func (d *Database) GetUpcomingEventsByDays(userID int, days int) ([]models.UpcomingEvent, error) {
 	logger.Debug("[DATABASE] Begin GetUpcomingEventsByDays(userID:%d, days:%d)", userID, days)
 
+	return d.getUpcomingEventsByDays(userID, days, nil)
+}
+
+// GetUpcomingEventsByDaysFrom gets events in the N days starting at date, as GetUpcomingEventsByDays
+// would have returned them on that date
+func (d *Database) GetUpcomingEventsByDaysFrom(userID int, days int, date time.Time) ([]models.UpcomingEvent, error) {
+	logger.Debug("[DATABASE] Begin GetUpcomingEventsByDaysFrom(userID:%d, days:%d, date:%s)", userID, days, date.Format("2006-01-02"))
+
+	return d.getUpcomingEventsByDays(userID, days, date.Format("2006-01-02"))
+}
+
+// getUpcomingEventsByDays runs the upcoming events query from baseDate (YYYY-MM-DD), or from the
+// database's CURRENT_DATE when baseDate is nil
+func (d *Database) getUpcomingEventsByDays(userID int, days int, baseDate interface{}) ([]models.UpcomingEvent, error) {
 	query := `
 	WITH upcoming_dates AS (
 		SELECT 
-			CURRENT_DATE + n * INTERVAL '1 day' as target_date,
-			EXTRACT(MONTH FROM CURRENT_DATE + n * INTERVAL '1 day')::integer as target_month,
-			EXTRACT(DAY FROM CURRENT_DATE + n * INTERVAL '1 day')::integer as target_day,
+			base.d + n * INTERVAL '1 day' as target_date,
+			EXTRACT(YEAR FROM base.d + n * INTERVAL '1 day')::integer as target_year,
 			n as days_offset
-		FROM generate_series(0, $1) as n
+		FROM (SELECT COALESCE($3::date, CURRENT_DATE) as d) base
+		CROSS JOIN generate_series(0, $1) as n
 	),
 	birthdays AS (
 		-- Birthdays with full dates
@@ -39,14 +57,14 @@ func (d *Database) GetUpcomingEventsByDays(userID int, days int) ([]models.Upcom
 			c.birthday as event_date,
 			ud.target_date as this_year_date,
 			ud.days_offset as days_until,
-			EXTRACT(YEAR FROM CURRENT_DATE)::integer - EXTRACT(YEAR FROM c.birthday)::integer as age_years
+			ud.target_year - EXTRACT(YEAR FROM c.birthday)::integer as age_years
 		FROM contacts c
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.birthday IS NOT NULL
-			AND EXTRACT(MONTH FROM c.birthday) = ud.target_month
-			AND EXTRACT(DAY FROM c.birthday) = ud.target_day
+			AND observed_event_date(ud.target_year, EXTRACT(MONTH FROM c.birthday)::integer, EXTRACT(DAY FROM c.birthday)::integer) = ud.target_date
 		
 		UNION ALL
 		
@@ -63,10 +81,10 @@ func (d *Database) GetUpcomingEventsByDays(userID int, days int) ([]models.Upcom
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.birthday_month IS NOT NULL
 			AND c.birthday_day IS NOT NULL
-			AND c.birthday_month = ud.target_month
-			AND c.birthday_day = ud.target_day
+			AND observed_event_date(ud.target_year, c.birthday_month, c.birthday_day) = ud.target_date
 	),
 	anniversaries AS (
 		-- Anniversaries with full dates
@@ -77,14 +95,14 @@ func (d *Database) GetUpcomingEventsByDays(userID int, days int) ([]models.Upcom
 			c.anniversary as event_date,
 			ud.target_date as this_year_date,
 			ud.days_offset as days_until,
-			EXTRACT(YEAR FROM CURRENT_DATE)::integer - EXTRACT(YEAR FROM c.anniversary)::integer as age_years
+			ud.target_year - EXTRACT(YEAR FROM c.anniversary)::integer as age_years
 		FROM contacts c
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.anniversary IS NOT NULL
-			AND EXTRACT(MONTH FROM c.anniversary) = ud.target_month
-			AND EXTRACT(DAY FROM c.anniversary) = ud.target_day
+			AND observed_event_date(ud.target_year, EXTRACT(MONTH FROM c.anniversary)::integer, EXTRACT(DAY FROM c.anniversary)::integer) = ud.target_date
 		
 		UNION ALL
 		
@@ -101,10 +119,10 @@ func (d *Database) GetUpcomingEventsByDays(userID int, days int) ([]models.Upcom
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.anniversary_month IS NOT NULL
 			AND c.anniversary_day IS NOT NULL
-			AND c.anniversary_month = ud.target_month
-			AND c.anniversary_day = ud.target_day
+			AND observed_event_date(ud.target_year, c.anniversary_month, c.anniversary_day) = ud.target_date
 	),
 	other_events AS (
         -- Other events with full dates
@@ -115,15 +133,15 @@ func (d *Database) GetUpcomingEventsByDays(userID int, days int) ([]models.Upcom
             od.event_date,
             ud.target_date as this_year_date,
             ud.days_offset as days_until,
-            EXTRACT(YEAR FROM CURRENT_DATE)::integer - EXTRACT(YEAR FROM od.event_date)::integer as age_years
+            ud.target_year - EXTRACT(YEAR FROM od.event_date)::integer as age_years
         FROM other_dates od
         JOIN contacts c ON od.contact_id = c.id
         CROSS JOIN upcoming_dates ud
         WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
             AND od.event_date IS NOT NULL
-            AND EXTRACT(MONTH FROM od.event_date) = ud.target_month
-            AND EXTRACT(DAY FROM od.event_date) = ud.target_day
+            AND observed_event_date(ud.target_year, EXTRACT(MONTH FROM od.event_date)::integer, EXTRACT(DAY FROM od.event_date)::integer) = ud.target_date
         
         UNION ALL
         
@@ -141,10 +159,10 @@ func (d *Database) GetUpcomingEventsByDays(userID int, days int) ([]models.Upcom
         CROSS JOIN upcoming_dates ud
         WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
             AND od.event_date_month IS NOT NULL
             AND od.event_date_day IS NOT NULL
-            AND od.event_date_month = ud.target_month
-            AND od.event_date_day = ud.target_day
+            AND observed_event_date(ud.target_year, od.event_date_month, od.event_date_day) = ud.target_date
     )
 	SELECT 
 		contact_id,
@@ -169,7 +187,7 @@ func (d *Database) GetUpcomingEventsByDays(userID int, days int) ([]models.Upcom
 	ORDER BY days_until, full_name, event_type
 	`
 
-	rows, err := d.db.Query(query, days, userID)
+	rows, err := d.db.Query(query, days, userID, baseDate)
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting events: %v", err)
 		return nil, fmt.Errorf("query error: %w", err)
@@ -218,16 +236,37 @@ func (d *Database) GetUpcomingEventsByDays(userID int, days int) ([]models.Upcom
 }
 
 // GetUpcomingEventsByMonths gets events in the next N months (1-6)
+// Reduced precision dates (year-only or year+month) have no day and are never matched
+// Feb 29 events are observed on Feb 28 in common years (see observed_event_date)
 func (d *Database) GetUpcomingEventsByMonths(userID int, months int) ([]models.UpcomingEvent, error) {
 	logger.Debug("[DATABASE] Begin GetUpcomingEventsByMonths(userID:%d, months:%d)", userID, months)
 
+	return d.getUpcomingEventsByMonths(userID, months, nil)
+}
+
+// GetUpcomingEventsByMonthsFrom gets events in the N months starting with date's month, as
+// GetUpcomingEventsByMonths would have returned them on that date
+func (d *Database) GetUpcomingEventsByMonthsFrom(userID int, months int, date time.Time) ([]models.UpcomingEvent, error) {
+	logger.Debug("[DATABASE] Begin GetUpcomingEventsByMonthsFrom(userID:%d, months:%d, date:%s)", userID, months, date.Format("2006-01-02"))
+
+	return d.getUpcomingEventsByMonths(userID, months, date.Format("2006-01-02"))
+}
+
+// getUpcomingEventsByMonths runs the month-window events query from baseDate (YYYY-MM-DD), or from
+// the database's CURRENT_DATE when baseDate is nil. Each month of the window carries its own
+// year, counted from the first of the base month so the window rolls over into January with the
+// right year; ages are those reached in that year
+func (d *Database) getUpcomingEventsByMonths(userID int, months int, baseDate interface{}) ([]models.UpcomingEvent, error) {
 	query := `
 	WITH upcoming_months AS (
 		SELECT 
-			EXTRACT(MONTH FROM CURRENT_DATE + n * INTERVAL '1 month')::integer as target_month,
-			EXTRACT(YEAR FROM CURRENT_DATE + n * INTERVAL '1 month')::integer as target_year,
+			EXTRACT(MONTH FROM month_start.m)::integer as target_month,
+			EXTRACT(YEAR FROM month_start.m)::integer as target_year,
+			base.d as base_date,
 			n as month_offset
-		FROM generate_series(0, $1 - 1) as n
+		FROM (SELECT COALESCE($3::date, CURRENT_DATE) as d) base
+		CROSS JOIN generate_series(0, $1 - 1) as n
+		CROSS JOIN LATERAL (SELECT date_trunc('month', base.d::timestamp) + n * INTERVAL '1 month' as m) month_start
 	),
 	birthdays AS (
 		-- Birthdays with full dates
@@ -236,21 +275,22 @@ func (d *Database) GetUpcomingEventsByMonths(userID int, months int) ([]models.U
 			c.full_name,
 			'birthday' as event_type,
 			c.birthday as event_date,
-			MAKE_DATE(
+			observed_event_date(
 				um.target_year,
 				EXTRACT(MONTH FROM c.birthday)::integer,
 				EXTRACT(DAY FROM c.birthday)::integer
 			) as this_year_date,
-			EXTRACT(YEAR FROM CURRENT_DATE)::integer - EXTRACT(YEAR FROM c.birthday)::integer as age_years,
-			MAKE_DATE(
+			um.target_year - EXTRACT(YEAR FROM c.birthday)::integer as age_years,
+			observed_event_date(
 				um.target_year,
 				EXTRACT(MONTH FROM c.birthday)::integer,
 				EXTRACT(DAY FROM c.birthday)::integer
-			) - CURRENT_DATE as days_until
+			) - um.base_date as days_until
 		FROM contacts c
 		CROSS JOIN upcoming_months um
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.birthday IS NOT NULL
 			AND EXTRACT(MONTH FROM c.birthday)::integer = um.target_month
 		
@@ -262,13 +302,14 @@ func (d *Database) GetUpcomingEventsByMonths(userID int, months int) ([]models.U
 			c.full_name,
 			'birthday' as event_type,
 			NULL as event_date,
-			MAKE_DATE(um.target_year, c.birthday_month, c.birthday_day) as this_year_date,
+			observed_event_date(um.target_year, c.birthday_month, c.birthday_day) as this_year_date,
 			NULL::integer as age_years,
-			MAKE_DATE(um.target_year, c.birthday_month, c.birthday_day) - CURRENT_DATE as days_until
+			observed_event_date(um.target_year, c.birthday_month, c.birthday_day) - um.base_date as days_until
 		FROM contacts c
 		CROSS JOIN upcoming_months um
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.birthday_month IS NOT NULL
 			AND c.birthday_day IS NOT NULL
 			AND c.birthday_month = um.target_month
@@ -280,21 +321,22 @@ func (d *Database) GetUpcomingEventsByMonths(userID int, months int) ([]models.U
 			c.full_name,
 			'anniversary' as event_type,
 			c.anniversary as event_date,
-			MAKE_DATE(
+			observed_event_date(
 				um.target_year,
 				EXTRACT(MONTH FROM c.anniversary)::integer,
 				EXTRACT(DAY FROM c.anniversary)::integer
 			) as this_year_date,
-			EXTRACT(YEAR FROM CURRENT_DATE)::integer - EXTRACT(YEAR FROM c.anniversary)::integer as age_years,
-			MAKE_DATE(
+			um.target_year - EXTRACT(YEAR FROM c.anniversary)::integer as age_years,
+			observed_event_date(
 				um.target_year,
 				EXTRACT(MONTH FROM c.anniversary)::integer,
 				EXTRACT(DAY FROM c.anniversary)::integer
-			) - CURRENT_DATE as days_until
+			) - um.base_date as days_until
 		FROM contacts c
 		CROSS JOIN upcoming_months um
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.anniversary IS NOT NULL
 			AND EXTRACT(MONTH FROM c.anniversary)::integer = um.target_month
 		
@@ -306,13 +348,14 @@ func (d *Database) GetUpcomingEventsByMonths(userID int, months int) ([]models.U
 			c.full_name,
 			'anniversary' as event_type,
 			NULL as event_date,
-			MAKE_DATE(um.target_year, c.anniversary_month, c.anniversary_day) as this_year_date,
+			observed_event_date(um.target_year, c.anniversary_month, c.anniversary_day) as this_year_date,
 			NULL::integer as age_years,
-			MAKE_DATE(um.target_year, c.anniversary_month, c.anniversary_day) - CURRENT_DATE as days_until
+			observed_event_date(um.target_year, c.anniversary_month, c.anniversary_day) - um.base_date as days_until
 		FROM contacts c
 		CROSS JOIN upcoming_months um
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.anniversary_month IS NOT NULL
 			AND c.anniversary_day IS NOT NULL
 			AND c.anniversary_month = um.target_month
@@ -324,22 +367,23 @@ func (d *Database) GetUpcomingEventsByMonths(userID int, months int) ([]models.U
 			c.full_name,
 			od.event_name as event_type,
 			od.event_date,
-			MAKE_DATE(
+			observed_event_date(
 				um.target_year,
 				EXTRACT(MONTH FROM od.event_date)::integer,
 				EXTRACT(DAY FROM od.event_date)::integer
 			) as this_year_date,
-			EXTRACT(YEAR FROM CURRENT_DATE)::integer - EXTRACT(YEAR FROM od.event_date)::integer as age_years,
-			MAKE_DATE(
+			um.target_year - EXTRACT(YEAR FROM od.event_date)::integer as age_years,
+			observed_event_date(
 				um.target_year,
 				EXTRACT(MONTH FROM od.event_date)::integer,
 				EXTRACT(DAY FROM od.event_date)::integer
-			) - CURRENT_DATE as days_until
+			) - um.base_date as days_until
 		FROM other_dates od
 		JOIN contacts c ON od.contact_id = c.id
 		CROSS JOIN upcoming_months um
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND od.event_date IS NOT NULL
 			AND EXTRACT(MONTH FROM od.event_date)::integer = um.target_month
 		
@@ -351,14 +395,15 @@ func (d *Database) GetUpcomingEventsByMonths(userID int, months int) ([]models.U
 			c.full_name,
 			od.event_name as event_type,
 			NULL as event_date,
-			MAKE_DATE(um.target_year, od.event_date_month, od.event_date_day) as this_year_date,
+			observed_event_date(um.target_year, od.event_date_month, od.event_date_day) as this_year_date,
 			NULL::integer as age_years,
-			MAKE_DATE(um.target_year, od.event_date_month, od.event_date_day) - CURRENT_DATE as days_until
+			observed_event_date(um.target_year, od.event_date_month, od.event_date_day) - um.base_date as days_until
 		FROM other_dates od
 		JOIN contacts c ON od.contact_id = c.id
 		CROSS JOIN upcoming_months um
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND od.event_date_month IS NOT NULL
 			AND od.event_date_day IS NOT NULL
 			AND od.event_date_month = um.target_month
@@ -389,7 +434,7 @@ func (d *Database) GetUpcomingEventsByMonths(userID int, months int) ([]models.U
 	ORDER BY this_year_date, full_name, event_type
 	`
 
-	rows, err := d.db.Query(query, months, userID)
+	rows, err := d.db.Query(query, months, userID, baseDate)
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting events: %v", err)
 		return nil, fmt.Errorf("query error: %w", err)
@@ -445,8 +490,8 @@ func (d *Database) GetUpcomingEventsCount(userID int, days int) (int, error) {
 	query := `
 	WITH upcoming_dates AS (
 		SELECT 
-			EXTRACT(MONTH FROM CURRENT_DATE + n * INTERVAL '1 day')::integer as target_month,
-			EXTRACT(DAY FROM CURRENT_DATE + n * INTERVAL '1 day')::integer as target_day
+			CURRENT_DATE + n * INTERVAL '1 day' as target_date,
+			EXTRACT(YEAR FROM CURRENT_DATE + n * INTERVAL '1 day')::integer as target_year
 		FROM generate_series(0, $1) as n
 	)
 	SELECT COUNT(*) FROM (
@@ -456,9 +501,9 @@ func (d *Database) GetUpcomingEventsCount(userID int, days int) (int, error) {
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.birthday IS NOT NULL
-			AND EXTRACT(MONTH FROM c.birthday) = ud.target_month
-			AND EXTRACT(DAY FROM c.birthday) = ud.target_day
+			AND observed_event_date(ud.target_year, EXTRACT(MONTH FROM c.birthday)::integer, EXTRACT(DAY FROM c.birthday)::integer) = ud.target_date
 		
 		UNION ALL
 		
@@ -468,8 +513,8 @@ func (d *Database) GetUpcomingEventsCount(userID int, days int) (int, error) {
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
-			AND c.birthday_month = ud.target_month
-			AND c.birthday_day = ud.target_day
+			AND c.exclude_from_events = false AND c.archived = false
+			AND observed_event_date(ud.target_year, c.birthday_month, c.birthday_day) = ud.target_date
 		
 		UNION ALL
 		
@@ -479,9 +524,9 @@ func (d *Database) GetUpcomingEventsCount(userID int, days int) (int, error) {
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.anniversary IS NOT NULL
-			AND EXTRACT(MONTH FROM c.anniversary) = ud.target_month
-			AND EXTRACT(DAY FROM c.anniversary) = ud.target_day
+			AND observed_event_date(ud.target_year, EXTRACT(MONTH FROM c.anniversary)::integer, EXTRACT(DAY FROM c.anniversary)::integer) = ud.target_date
 		
 		UNION ALL
 		
@@ -491,8 +536,8 @@ func (d *Database) GetUpcomingEventsCount(userID int, days int) (int, error) {
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
-			AND c.anniversary_month = ud.target_month
-			AND c.anniversary_day = ud.target_day
+			AND c.exclude_from_events = false AND c.archived = false
+			AND observed_event_date(ud.target_year, c.anniversary_month, c.anniversary_day) = ud.target_date
 		
 		UNION ALL
 		
@@ -503,9 +548,9 @@ func (d *Database) GetUpcomingEventsCount(userID int, days int) (int, error) {
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND od.event_date IS NOT NULL
-			AND EXTRACT(MONTH FROM od.event_date) = ud.target_month
-			AND EXTRACT(DAY FROM od.event_date) = ud.target_day
+			AND observed_event_date(ud.target_year, EXTRACT(MONTH FROM od.event_date)::integer, EXTRACT(DAY FROM od.event_date)::integer) = ud.target_date
 		
 		UNION ALL
 		
@@ -515,8 +560,9 @@ func (d *Database) GetUpcomingEventsCount(userID int, days int) (int, error) {
 		JOIN contacts c ON od.contact_id = c.id
 		CROSS JOIN upcoming_dates ud
 		WHERE c.user_id = $2
-			AND od.event_date_month = ud.target_month
-			AND od.event_date_day = ud.target_day
+			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
+			AND observed_event_date(ud.target_year, od.event_date_month, od.event_date_day) = ud.target_date
 	) all_events
 	`
 
@@ -533,6 +579,7 @@ func (d *Database) GetUpcomingEventsCount(userID int, days int) (int, error) {
 // GetRecentPastEventsByDays gets events that occurred in the past N days (lookback)
 // Returns events with negative days_until values (e.g., -1 for yesterday, -7 for a week ago)
 // Useful for "last chance" reminders or missed event notifications
+// Feb 29 events are observed on Feb 28 in common years (see observed_event_date)
 func (d *Database) GetRecentPastEventsByDays(userID int, lookbackDays int) ([]models.UpcomingEvent, error) {
 	logger.Debug("[DATABASE] Begin GetRecentPastEventsByDays(userID:%d, lookbackDays:%d)", userID, lookbackDays)
 
@@ -540,8 +587,7 @@ func (d *Database) GetRecentPastEventsByDays(userID int, lookbackDays int) ([]mo
 	WITH past_dates AS (
 		SELECT 
 			CURRENT_DATE - n * INTERVAL '1 day' as target_date,
-			EXTRACT(MONTH FROM CURRENT_DATE - n * INTERVAL '1 day')::integer as target_month,
-			EXTRACT(DAY FROM CURRENT_DATE - n * INTERVAL '1 day')::integer as target_day,
+			EXTRACT(YEAR FROM CURRENT_DATE - n * INTERVAL '1 day')::integer as target_year,
 			-n as days_offset
 		FROM generate_series(1, $1) as n
 	),
@@ -554,14 +600,14 @@ func (d *Database) GetRecentPastEventsByDays(userID int, lookbackDays int) ([]mo
 			c.birthday as event_date,
 			pd.target_date as this_year_date,
 			pd.days_offset as days_until,
-			EXTRACT(YEAR FROM CURRENT_DATE)::integer - EXTRACT(YEAR FROM c.birthday)::integer as age_years
+			pd.target_year - EXTRACT(YEAR FROM c.birthday)::integer as age_years
 		FROM contacts c
 		CROSS JOIN past_dates pd
 		WHERE c.user_id = $2
 	        AND c.deleted_at IS NULL
+	        AND c.exclude_from_events = false AND c.archived = false
 			AND c.birthday IS NOT NULL
-			AND EXTRACT(MONTH FROM c.birthday) = pd.target_month
-			AND EXTRACT(DAY FROM c.birthday) = pd.target_day
+			AND observed_event_date(pd.target_year, EXTRACT(MONTH FROM c.birthday)::integer, EXTRACT(DAY FROM c.birthday)::integer) = pd.target_date
 		
 		UNION ALL
 		
@@ -578,10 +624,10 @@ func (d *Database) GetRecentPastEventsByDays(userID int, lookbackDays int) ([]mo
 		CROSS JOIN past_dates pd
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.birthday_month IS NOT NULL
 			AND c.birthday_day IS NOT NULL
-			AND c.birthday_month = pd.target_month
-			AND c.birthday_day = pd.target_day
+			AND observed_event_date(pd.target_year, c.birthday_month, c.birthday_day) = pd.target_date
 	),
 	anniversaries AS (
 		-- Anniversaries with full dates
@@ -592,14 +638,14 @@ func (d *Database) GetRecentPastEventsByDays(userID int, lookbackDays int) ([]mo
 			c.anniversary as event_date,
 			pd.target_date as this_year_date,
 			pd.days_offset as days_until,
-			EXTRACT(YEAR FROM CURRENT_DATE)::integer - EXTRACT(YEAR FROM c.anniversary)::integer as age_years
+			pd.target_year - EXTRACT(YEAR FROM c.anniversary)::integer as age_years
 		FROM contacts c
 		CROSS JOIN past_dates pd
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.anniversary IS NOT NULL
-			AND EXTRACT(MONTH FROM c.anniversary) = pd.target_month
-			AND EXTRACT(DAY FROM c.anniversary) = pd.target_day
+			AND observed_event_date(pd.target_year, EXTRACT(MONTH FROM c.anniversary)::integer, EXTRACT(DAY FROM c.anniversary)::integer) = pd.target_date
 		
 		UNION ALL
 		
@@ -616,10 +662,10 @@ func (d *Database) GetRecentPastEventsByDays(userID int, lookbackDays int) ([]mo
 		CROSS JOIN past_dates pd
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND c.anniversary_month IS NOT NULL
 			AND c.anniversary_day IS NOT NULL
-			AND c.anniversary_month = pd.target_month
-			AND c.anniversary_day = pd.target_day
+			AND observed_event_date(pd.target_year, c.anniversary_month, c.anniversary_day) = pd.target_date
 	),
 	other_events AS (
 		-- Other events with full dates
@@ -630,15 +676,15 @@ func (d *Database) GetRecentPastEventsByDays(userID int, lookbackDays int) ([]mo
 			od.event_date,
 			pd.target_date as this_year_date,
 			pd.days_offset as days_until,
-			EXTRACT(YEAR FROM CURRENT_DATE)::integer - EXTRACT(YEAR FROM od.event_date)::integer as age_years
+			pd.target_year - EXTRACT(YEAR FROM od.event_date)::integer as age_years
 		FROM other_dates od
 		JOIN contacts c ON od.contact_id = c.id
 		CROSS JOIN past_dates pd
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND od.event_date IS NOT NULL
-			AND EXTRACT(MONTH FROM od.event_date) = pd.target_month
-			AND EXTRACT(DAY FROM od.event_date) = pd.target_day
+			AND observed_event_date(pd.target_year, EXTRACT(MONTH FROM od.event_date)::integer, EXTRACT(DAY FROM od.event_date)::integer) = pd.target_date
 		
 		UNION ALL
 		
@@ -656,10 +702,10 @@ func (d *Database) GetRecentPastEventsByDays(userID int, lookbackDays int) ([]mo
 		CROSS JOIN past_dates pd
 		WHERE c.user_id = $2
 			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
 			AND od.event_date_month IS NOT NULL
 			AND od.event_date_day IS NOT NULL
-			AND od.event_date_month = pd.target_month
-			AND od.event_date_day = pd.target_day
+			AND observed_event_date(pd.target_year, od.event_date_month, od.event_date_day) = pd.target_date
 	)
 	SELECT 
 		contact_id,
@@ -729,6 +775,170 @@ func (d *Database) GetRecentPastEventsByDays(userID int, lookbackDays int) ([]mo
 	return events, nil
 }
 
+// GetEventTypeCounts returns every distinct event type in use (birthday, anniversary,
+// and each other_dates event name) with the number of dates of that type
+func (d *Database) GetEventTypeCounts(userID int) ([]models.EventTypeCount, error) {
+	logger.Debug("[DATABASE] Begin GetEventTypeCounts(userID:%d)", userID)
+
+	query := `
+	SELECT event_type, COUNT(*) AS event_count
+	FROM (
+		SELECT 'birthday' AS event_type
+		FROM contacts c
+		WHERE c.user_id = $1
+			AND c.deleted_at IS NULL
+			AND (c.birthday IS NOT NULL OR c.birthday_month IS NOT NULL OR c.birthday_year IS NOT NULL)
+
+		UNION ALL
+
+		SELECT 'anniversary' AS event_type
+		FROM contacts c
+		WHERE c.user_id = $1
+			AND c.deleted_at IS NULL
+			AND (c.anniversary IS NOT NULL OR c.anniversary_month IS NOT NULL OR c.anniversary_year IS NOT NULL)
+
+		UNION ALL
+
+		SELECT od.event_name AS event_type
+		FROM other_dates od
+		JOIN contacts c ON od.contact_id = c.id
+		WHERE c.user_id = $1
+			AND c.deleted_at IS NULL
+			AND od.event_name IS NOT NULL
+			AND od.event_name != ''
+	) all_types
+	GROUP BY event_type
+	ORDER BY event_count DESC, event_type
+	`
+
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting event types: %v", err)
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	types := []models.EventTypeCount{}
+	for rows.Next() {
+		var t models.EventTypeCount
+		if err := rows.Scan(&t.EventType, &t.Count); err != nil {
+			logger.Error("[DATABASE] Error scanning event types: %v", err)
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		types = append(types, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error("[DATABASE] Error for event types: %v", err)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return types, nil
+}
+
+// GetReminderLeadDayOverrides returns contactID -> reminder_lead_days for every contact
+// of the user that has a positive per-contact override
+func (d *Database) GetReminderLeadDayOverrides(userID int) (map[int]int, error) {
+	logger.Debug("[DATABASE] Begin GetReminderLeadDayOverrides(userID:%d)", userID)
+
+	rows, err := d.db.Query(`
+		SELECT id, reminder_lead_days
+		FROM contacts
+		WHERE user_id = $1 AND deleted_at IS NULL AND reminder_lead_days > 0
+	`, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting reminder overrides: %v", err)
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[int]int)
+	for rows.Next() {
+		var contactID, leadDays int
+		if err := rows.Scan(&contactID, &leadDays); err != nil {
+			logger.Error("[DATABASE] Error scanning reminder overrides: %v", err)
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		overrides[contactID] = leadDays
+	}
+
+	return overrides, rows.Err()
+}
+
+// GetContactsDueForContact returns a reconnect event for every contact with a contact_frequency_days
+// whose last contact (or creation, if never contacted) is at least that many days ago. The event's
+// ThisYearDate is the day the contact became due, so DaysUntil is zero or negative, most overdue first
+func (d *Database) GetContactsDueForContact(userID int) ([]models.UpcomingEvent, error) {
+	logger.Debug("[DATABASE] Begin GetContactsDueForContact(userID:%d)", userID)
+
+	query := `
+	SELECT
+		contact_id,
+		full_name,
+		last_contacted_at,
+		due_date,
+		days_until,
+		CASE
+			WHEN days_until = 0 THEN 'Today'
+			WHEN days_until = -1 THEN 'Yesterday'
+			ELSE ABS(days_until) || ' days ago'
+		END as time_description
+	FROM (
+		SELECT
+			c.id as contact_id,
+			c.full_name,
+			c.last_contacted_at,
+			(COALESCE(c.last_contacted_at, c.created_at) + c.contact_frequency_days * INTERVAL '1 day')::date as due_date,
+			(COALESCE(c.last_contacted_at, c.created_at) + c.contact_frequency_days * INTERVAL '1 day')::date - CURRENT_DATE as days_until
+		FROM contacts c
+		WHERE c.user_id = $1
+			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
+			AND c.contact_frequency_days > 0
+			AND COALESCE(c.last_contacted_at, c.created_at) + c.contact_frequency_days * INTERVAL '1 day' <= NOW()
+	) due
+	ORDER BY days_until, full_name
+	`
+
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts due for contact: %v", err)
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.UpcomingEvent{}
+	for rows.Next() {
+		event := models.UpcomingEvent{EventType: models.EventTypeReconnect}
+		var lastContacted sql.NullTime
+
+		err := rows.Scan(
+			&event.ContactID,
+			&event.FullName,
+			&lastContacted,
+			&event.ThisYearDate,
+			&event.DaysUntil,
+			&event.TimeDescription,
+		)
+		if err != nil {
+			logger.Error("[DATABASE] Error scanning contacts due for contact: %v", err)
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+
+		if lastContacted.Valid {
+			event.EventDate = &lastContacted.Time
+		}
+
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return events, nil
+}
+
 // GetLastWeeksPastEvents is a convenience function for getting events from the past week
 func (d *Database) GetLastWeeksPastEvents(userID int) ([]models.UpcomingEvent, error) {
 	return d.GetRecentPastEventsByDays(userID, 7)
@@ -745,3 +955,112 @@ func (d *Database) GetThisWeeksEvents(userID int) ([]models.UpcomingEvent, error
 	// For longer periods, use the months function
 	return d.GetUpcomingEventsByDays(userID, 7)
 }
+
+// GetContactAddressNames returns how each contact should be addressed in notifications:
+// the salutation when set, otherwise the given name, otherwise the full name
+func (d *Database) GetContactAddressNames(userID int, contactIDs []int) (map[int]string, error) {
+	logger.Debug("[DATABASE] Begin GetContactAddressNames(userID:%d, contactIDs:%v)", userID, contactIDs)
+
+	names := make(map[int]string)
+	if len(contactIDs) == 0 {
+		return names, nil
+	}
+
+	query := `
+		SELECT id, COALESCE(NULLIF(salutation, ''), NULLIF(given_name, ''), full_name)
+		FROM contacts
+		WHERE user_id = $1 AND id = ANY($2)
+	`
+
+	rows, err := d.db.Query(query, userID, pq.Array(contactIDs))
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contact address names: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			logger.Error("[DATABASE] Error scanning contact address names: %v", err)
+			return nil, err
+		}
+		names[id] = name
+	}
+
+	return names, rows.Err()
+}
+
+// GetContactDates returns every recurring date (birthdays, anniversaries, and other dates) of the
+// user's contacts, for calendar feeds. Partial dates have no year; reduced precision dates with no
+// day can't recur and are left out
+func (d *Database) GetContactDates(userID int) ([]models.ContactDate, error) {
+	logger.Debug("[DATABASE] Begin GetContactDates(userID:%d)", userID)
+
+	query := `
+		SELECT c.id, c.uid, c.full_name, 'birthday' AS event_type,
+			EXTRACT(YEAR FROM c.birthday)::integer,
+			COALESCE(EXTRACT(MONTH FROM c.birthday)::integer, c.birthday_month),
+			COALESCE(EXTRACT(DAY FROM c.birthday)::integer, c.birthday_day)
+		FROM contacts c
+		WHERE c.user_id = $1
+			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
+			AND (c.birthday IS NOT NULL OR (c.birthday_month IS NOT NULL AND c.birthday_day IS NOT NULL))
+
+		UNION ALL
+
+		SELECT c.id, c.uid, c.full_name, 'anniversary' AS event_type,
+			EXTRACT(YEAR FROM c.anniversary)::integer,
+			COALESCE(EXTRACT(MONTH FROM c.anniversary)::integer, c.anniversary_month),
+			COALESCE(EXTRACT(DAY FROM c.anniversary)::integer, c.anniversary_day)
+		FROM contacts c
+		WHERE c.user_id = $1
+			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
+			AND (c.anniversary IS NOT NULL OR (c.anniversary_month IS NOT NULL AND c.anniversary_day IS NOT NULL))
+
+		UNION ALL
+
+		SELECT c.id, c.uid, c.full_name, od.event_name AS event_type,
+			EXTRACT(YEAR FROM od.event_date)::integer,
+			COALESCE(EXTRACT(MONTH FROM od.event_date)::integer, od.event_date_month),
+			COALESCE(EXTRACT(DAY FROM od.event_date)::integer, od.event_date_day)
+		FROM other_dates od
+		JOIN contacts c ON od.contact_id = c.id
+		WHERE c.user_id = $1
+			AND c.deleted_at IS NULL
+			AND c.exclude_from_events = false AND c.archived = false
+			AND (od.event_date IS NOT NULL OR (od.event_date_month IS NOT NULL AND od.event_date_day IS NOT NULL))
+
+		ORDER BY 1, 4
+	`
+
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contact dates: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	dates := []models.ContactDate{}
+	for rows.Next() {
+		var date models.ContactDate
+		var eventType sql.NullString
+		var year sql.NullInt64
+		if err := rows.Scan(&date.ContactID, &date.ContactUID, &date.FullName, &eventType,
+			&year, &date.Month, &date.Day); err != nil {
+			logger.Error("[DATABASE] Error scanning contact dates: %v", err)
+			return nil, err
+		}
+		date.EventType = eventType.String
+		if year.Valid {
+			y := int(year.Int64)
+			date.Year = &y
+		}
+		dates = append(dates, date)
+	}
+
+	return dates, rows.Err()
+}
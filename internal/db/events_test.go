@@ -0,0 +1,268 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+func TestExcludeFromEvents(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	birthdayOn := func(name string, date time.Time, excluded bool) *models.Contact {
+		return dbtest.NewContact(t, database, user.ID, &models.Contact{
+			FullName:          name,
+			BirthdayMonth:     utils.IntPtr(int(date.Month())),
+			BirthdayDay:       utils.IntPtr(date.Day()),
+			ExcludeFromEvents: excluded,
+		})
+	}
+	friend := birthdayOn("Friend", now, false)
+	business := birthdayOn("Business", now, true)
+	pastFriend := birthdayOn("Past Friend", yesterday, false)
+	pastBusiness := birthdayOn("Past Business", yesterday, false)
+
+	// Opting out through PATCH works like setting it on create
+	excluded := true
+	if _, err := database.PatchContact(user.ID, pastBusiness.ID, &models.ContactJSONPatch{ExcludeFromEvents: &excluded}); err != nil {
+		t.Fatalf("PatchContact: %v", err)
+	}
+
+	check := func(name string, events []models.UpcomingEvent, err error, want, excluded int) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		found := false
+		for _, e := range events {
+			found = found || e.ContactID == want
+			if e.ContactID == excluded {
+				t.Errorf("%s includes excluded contact %d", name, excluded)
+			}
+		}
+		if !found {
+			t.Errorf("%s = %+v, want it to include contact %d", name, events, want)
+		}
+	}
+
+	events, err := database.GetTodaysEvents(user.ID)
+	check("GetTodaysEvents", events, err, friend.ID, business.ID)
+
+	events, err = database.GetUpcomingEventsByMonths(user.ID, 1)
+	check("GetUpcomingEventsByMonths", events, err, friend.ID, business.ID)
+
+	events, err = database.GetRecentPastEventsByDays(user.ID, 1)
+	check("GetRecentPastEventsByDays", events, err, pastFriend.ID, pastBusiness.ID)
+
+	count, err := database.GetUpcomingEventsCount(user.ID, 0)
+	if err != nil {
+		t.Fatalf("GetUpcomingEventsCount: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("GetUpcomingEventsCount = %d, want 1", count)
+	}
+}
+
+func TestUpcomingEventsByMonthsCrossesNewYear(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	born := time.Date(1990, time.January, 5, 0, 0, 0, 0, time.UTC)
+	january := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "January", Birthday: &born})
+	partial := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:      "Partial January",
+		BirthdayMonth: utils.IntPtr(1),
+		BirthdayDay:   utils.IntPtr(5),
+	})
+
+	from := time.Date(2025, time.December, 15, 0, 0, 0, 0, time.UTC)
+	events, err := database.GetUpcomingEventsByMonthsFrom(user.ID, 3, from)
+	if err != nil {
+		t.Fatalf("GetUpcomingEventsByMonthsFrom: %v", err)
+	}
+
+	found := make(map[int]models.UpcomingEvent)
+	for _, e := range events {
+		if e.EventType == "birthday" {
+			found[e.ContactID] = e
+		}
+	}
+	for _, c := range []*models.Contact{january, partial} {
+		e, ok := found[c.ID]
+		if !ok {
+			t.Errorf("%s's January 5 birthday is missing from the window starting December 15: %+v", c.FullName, events)
+			continue
+		}
+		if e.DaysUntil != 21 || e.ThisYearDate.Format("2006-01-02") != "2026-01-05" {
+			t.Errorf("%s: %d days until %s, want 21 days until 2026-01-05", c.FullName, e.DaysUntil, e.ThisYearDate.Format("2006-01-02"))
+		}
+	}
+	if e := found[january.ID]; e.AgeOrYears == nil || *e.AgeOrYears != 36 {
+		t.Errorf("January turns %v, want 36", e.AgeOrYears)
+	}
+}
+
+func TestLeapDayEventsObservedOnFebruary28(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	born := time.Date(2000, time.February, 29, 0, 0, 0, 0, time.UTC)
+	full := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Leap Full", Birthday: &born})
+	partial := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:      "Leap Partial",
+		BirthdayMonth: utils.IntPtr(2),
+		BirthdayDay:   utils.IntPtr(29),
+	})
+
+	// observedDates lists the dates each contact's birthday is observed on
+	observedDates := func(name string, events []models.UpcomingEvent, err error) map[int][]string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		dates := make(map[int][]string)
+		for _, e := range events {
+			if e.EventType == "birthday" {
+				dates[e.ContactID] = append(dates[e.ContactID], e.ThisYearDate.Format("2006-01-02"))
+			}
+		}
+		return dates
+	}
+	check := func(name string, dates map[int][]string, want string) {
+		t.Helper()
+		for _, c := range []*models.Contact{full, partial} {
+			if got := dates[c.ID]; len(got) != 1 || got[0] != want {
+				t.Errorf("%s: %s observed on %q, want once on %s", name, c.FullName, got, want)
+			}
+		}
+	}
+
+	events, err := database.GetUpcomingEventsByDaysFrom(user.ID, 14, time.Date(2027, time.February, 20, 0, 0, 0, 0, time.UTC))
+	check("days in 2027", observedDates("GetUpcomingEventsByDaysFrom", events, err), "2027-02-28")
+
+	events, err = database.GetUpcomingEventsByMonthsFrom(user.ID, 1, time.Date(2027, time.February, 1, 0, 0, 0, 0, time.UTC))
+	check("months in 2027", observedDates("GetUpcomingEventsByMonthsFrom", events, err), "2027-02-28")
+
+	events, err = database.GetUpcomingEventsByDaysFrom(user.ID, 14, time.Date(2028, time.February, 20, 0, 0, 0, 0, time.UTC))
+	check("days in 2028", observedDates("GetUpcomingEventsByDaysFrom", events, err), "2028-02-29")
+
+	// GetRecentPastEventsByDays always runs from today, so check the rule it shares directly
+	for _, tt := range []struct {
+		year, month, day int
+		want             string
+	}{
+		{2027, 2, 29, "2027-02-28"},
+		{2028, 2, 29, "2028-02-29"},
+		{2027, 2, 28, "2027-02-28"},
+		{2027, 3, 1, "2027-03-01"},
+	} {
+		got, err := database.ObservedEventDate(tt.year, tt.month, tt.day)
+		if err != nil {
+			t.Fatalf("ObservedEventDate(%d, %d, %d): %v", tt.year, tt.month, tt.day, err)
+		}
+		if got.Format("2006-01-02") != tt.want {
+			t.Errorf("ObservedEventDate(%d, %d, %d) = %s, want %s", tt.year, tt.month, tt.day, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
+
+func TestGetContactsDueForContact(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	everyMonth := func(name string, lastContacted time.Time) *models.Contact {
+		contact := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: name})
+		if _, err := database.PatchContact(user.ID, contact.ID, &models.ContactJSONPatch{ContactFrequencyDays: utils.IntPtr(30)}); err != nil {
+			t.Fatalf("PatchContact(%s): %v", name, err)
+		}
+		if err := database.BackdateLastContacted(contact.ID, lastContacted); err != nil {
+			t.Fatalf("BackdateLastContacted(%s): %v", name, err)
+		}
+		return contact
+	}
+
+	now := time.Now()
+	overdue := everyMonth("Overdue", now.AddDate(0, 0, -40))
+	recent := everyMonth("Recent", now.AddDate(0, 0, -5))
+	// Without a frequency a contact is never due, however long ago they were contacted
+	unscheduled := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Unscheduled"})
+	if err := database.BackdateLastContacted(unscheduled.ID, now.AddDate(-1, 0, 0)); err != nil {
+		t.Fatalf("BackdateLastContacted: %v", err)
+	}
+
+	due, err := database.GetContactsDueForContact(user.ID)
+	if err != nil {
+		t.Fatalf("GetContactsDueForContact: %v", err)
+	}
+	if len(due) != 1 || due[0].ContactID != overdue.ID {
+		t.Fatalf("due = %+v, want only contact %d (not %d or %d)", due, overdue.ID, recent.ID, unscheduled.ID)
+	}
+	if got := due[0]; got.EventType != models.EventTypeReconnect || got.DaysUntil != -10 || got.TimeDescription != "10 days ago" {
+		t.Errorf("due event = %+v, want a reconnect that became due 10 days ago", got)
+	}
+
+	// Reaching out resets the clock
+	if err := database.TouchContact(user.ID, overdue.ID); err != nil {
+		t.Fatalf("TouchContact: %v", err)
+	}
+	due, err = database.GetContactsDueForContact(user.ID)
+	if err != nil {
+		t.Fatalf("GetContactsDueForContact: %v", err)
+	}
+	if len(due) != 0 {
+		t.Errorf("due after TouchContact = %+v, want none", due)
+	}
+}
+
+func TestUpcomingEventsByDaysAge(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	born := time.Date(1990, time.June, 15, 0, 0, 0, 0, time.UTC)
+	married := time.Date(2016, time.January, 2, 0, 0, 0, 0, time.UTC)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice", Birthday: &born, Anniversary: &married})
+
+	tests := []struct {
+		name      string
+		from      time.Time
+		days      int
+		eventType string
+		wantDays  int
+		wantAge   int
+	}{
+		{"two weeks before", time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC), 30, "birthday", 14, 36},
+		{"on the day", time.Date(2026, time.June, 15, 0, 0, 0, 0, time.UTC), 0, "birthday", 0, 36},
+		// The age comes from the year the event falls in, not the year the window starts in
+		{"window starting the year before", time.Date(2025, time.December, 30, 0, 0, 0, 0, time.UTC), 200, "birthday", 167, 36},
+		{"anniversary after New Year", time.Date(2025, time.December, 30, 0, 0, 0, 0, time.UTC), 7, "anniversary", 3, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := database.GetUpcomingEventsByDaysFrom(user.ID, tt.days, tt.from)
+			if err != nil {
+				t.Fatalf("GetUpcomingEventsByDaysFrom: %v", err)
+			}
+
+			for _, e := range events {
+				if e.ContactID != alice.ID || e.EventType != tt.eventType {
+					continue
+				}
+				if e.DaysUntil != tt.wantDays {
+					t.Errorf("DaysUntil = %d, want %d", e.DaysUntil, tt.wantDays)
+				}
+				if e.AgeOrYears == nil || *e.AgeOrYears != tt.wantAge {
+					t.Errorf("AgeOrYears = %v, want %d", e.AgeOrYears, tt.wantAge)
+				}
+				return
+			}
+			t.Errorf("no %s event for Alice in %+v", tt.eventType, events)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package db
+
+import "time"
+
+// BackdateDeletion moves a trashed contact's deleted_at, so tests can age it past the retention window
+func (d *Database) BackdateDeletion(contactID int, deletedAt time.Time) error {
+	_, err := d.db.Exec(`UPDATE contacts SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NOT NULL`, deletedAt, contactID)
+	return err
+}
+
+// BlankReverseNames clears a relationship type's reverse names, as a bad migration might leave them
+func (d *Database) BlankReverseNames(typeName string) error {
+	_, err := d.db.Exec(`
+		UPDATE relationship_types
+		SET reverse_name_male = NULL, reverse_name_female = NULL, reverse_name_neutral = NULL
+		WHERE name = $1`, typeName)
+	return err
+}
+
+// ObservedEventDate runs the observed_event_date SQL function the event queries share
+func (d *Database) ObservedEventDate(year, month, day int) (time.Time, error) {
+	var date time.Time
+	err := d.db.QueryRow(`SELECT observed_event_date($1, $2, $3)`, year, month, day).Scan(&date)
+	return date, err
+}
+
+// BackdateLastContacted sets when a contact was last reached, as if TouchContact ran at that time
+func (d *Database) BackdateLastContacted(contactID int, lastContactedAt time.Time) error {
+	_, err := d.db.Exec(`UPDATE contacts SET last_contacted_at = $1 WHERE id = $2`, lastContactedAt, contactID)
+	return err
+}
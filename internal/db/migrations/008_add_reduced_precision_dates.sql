@@ -0,0 +1,9 @@
+-- Year-only ("1985") and year+month ("1985-06") birthdays/anniversaries
+-- Combined with the existing *_month/*_day columns:
+--   year only        -> *_year set, *_month NULL, *_day NULL
+--   year + month     -> *_year set, *_month set,  *_day NULL
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS birthday_year INTEGER;
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS anniversary_year INTEGER;
+
+COMMENT ON COLUMN contacts.birthday_year IS 'Year when only the year (or year+month) of the birthday is known';
+COMMENT ON COLUMN contacts.anniversary_year IS 'Year when only the year (or year+month) of the anniversary is known';
@@ -0,0 +1,4 @@
+-- Per-contact override of the notifier's days_look_ahead
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS reminder_lead_days INTEGER;
+
+COMMENT ON COLUMN contacts.reminder_lead_days IS 'Days before an event to start reminding; extends (never shortens) a notifier''s days_look_ahead';
@@ -0,0 +1,12 @@
+-- Instant messaging handles (vCard IMPP)
+CREATE TABLE IF NOT EXISTS impps (
+    id SERIAL PRIMARY KEY,
+    contact_id INTEGER REFERENCES contacts(id) ON DELETE CASCADE,
+    service VARCHAR(50),
+    handle TEXT NOT NULL,
+    is_primary BOOLEAN DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_impps_contact_id ON impps(contact_id);
+
+COMMENT ON COLUMN impps.service IS 'Messaging service name from X-SERVICE-TYPE (e.g. Signal, Matrix, WhatsApp, Telegram)';
@@ -0,0 +1,4 @@
+-- How to address a contact in notifications ("Dr. Smith", "Aunt Linda"), separate from the structured prefix
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS salutation VARCHAR(255);
+
+COMMENT ON COLUMN contacts.salutation IS 'How to address the contact in notifications; falls back to given name when empty';
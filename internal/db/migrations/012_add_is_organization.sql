@@ -0,0 +1,4 @@
+-- Company contacts (Apple's X-ABShowAs:COMPANY) are displayed and sorted by their organization name
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS is_organization BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN contacts.is_organization IS 'Contact represents a company rather than a person';
@@ -0,0 +1,4 @@
+-- Per-contact opt-out from upcoming events and notification reminders (e.g. businesses)
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS exclude_from_events BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN contacts.exclude_from_events IS 'Omit this contact from upcoming/past event lists and reminders';
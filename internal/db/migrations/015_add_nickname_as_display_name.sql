@@ -0,0 +1,4 @@
+-- User preference to show a contact's nickname instead of the full name in lists, search, and CardDAV FN
+ALTER TABLE users ADD COLUMN IF NOT EXISTS nickname_as_display_name BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN users.nickname_as_display_name IS 'Display contacts by nickname when set; the stored full_name is unchanged';
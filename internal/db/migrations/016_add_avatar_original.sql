@@ -0,0 +1,23 @@
+-- Full-resolution avatar kept when the stored avatar is a resized copy; NULL means avatar_base64 is the original
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS avatar_original_base64 TEXT;
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS avatar_original_mime_type VARCHAR(50);
+
+COMMENT ON COLUMN contacts.avatar_original_base64 IS 'Original avatar bytes (base64) when avatar_base64 holds a resized copy';
+COMMENT ON COLUMN contacts.avatar_original_mime_type IS 'MIME type of avatar_original_base64';
+
+-- Replacing or removing the avatar without supplying a new original drops the stale one
+CREATE OR REPLACE FUNCTION clear_stale_avatar_original()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.avatar_base64 IS DISTINCT FROM OLD.avatar_base64
+        AND NEW.avatar_original_base64 IS NOT DISTINCT FROM OLD.avatar_original_base64 THEN
+        NEW.avatar_original_base64 = NULL;
+        NEW.avatar_original_mime_type = NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS clear_stale_avatar_original ON contacts;
+CREATE TRIGGER clear_stale_avatar_original BEFORE UPDATE ON contacts
+    FOR EACH ROW EXECUTE FUNCTION clear_stale_avatar_original();
@@ -0,0 +1,4 @@
+-- Friendly name CardDAV clients show for the account; empty falls back to the email address
+ALTER TABLE users ADD COLUMN IF NOT EXISTS carddav_display_name VARCHAR(100) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN users.carddav_display_name IS 'CardDAV principal display name; empty uses the email address';
@@ -0,0 +1,4 @@
+-- Archived contacts are kept but hidden from default lists, search, events, and CardDAV
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN contacts.archived IS 'Hide from default lists, search, events, and CardDAV without deleting';
@@ -0,0 +1,9 @@
+-- Trigram similarity for typo-tolerant name search. Creating an extension may need elevated
+-- privileges; without them fuzzy search is skipped instead of failing startup
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_trgm;
+EXCEPTION WHEN insufficient_privilege THEN
+    RAISE NOTICE 'pg_trgm could not be enabled; fuzzy contact search is disabled';
+END
+$$;
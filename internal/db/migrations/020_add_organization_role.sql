@@ -0,0 +1,4 @@
+-- vCard ROLE (the function a person serves) alongside TITLE (their job title)
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS role VARCHAR(255) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN organizations.role IS 'Function or part played in the organization (vCard ROLE), distinct from title';
@@ -0,0 +1,4 @@
+-- How many days ahead the dashboard and events page count as "upcoming"
+ALTER TABLE users ADD COLUMN IF NOT EXISTS default_event_lookahead_days INTEGER NOT NULL DEFAULT 7;
+
+COMMENT ON COLUMN users.default_event_lookahead_days IS 'Upcoming-events window in days (1-365) for the dashboard and events page';
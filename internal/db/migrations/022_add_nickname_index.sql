@@ -0,0 +1,11 @@
+-- Nickname lookups for search: exact and prefix matches on the lowercased nickname
+CREATE INDEX IF NOT EXISTS idx_contacts_nickname ON contacts (LOWER(nickname));
+
+-- Substring (ILIKE '%...%') matches need a trigram index, which requires pg_trgm (see 019)
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+        CREATE INDEX IF NOT EXISTS idx_contacts_nickname_trgm ON contacts USING GIN (nickname gin_trgm_ops);
+    END IF;
+END
+$$;
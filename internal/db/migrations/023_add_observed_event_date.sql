@@ -0,0 +1,15 @@
+-- Date a recurring month/day event is observed in a given year. Feb 29 falls on Feb 28 in common
+-- years, so leap-day birthdays and anniversaries still show up (and MAKE_DATE doesn't throw)
+CREATE OR REPLACE FUNCTION observed_event_date(event_year INTEGER, event_month INTEGER, event_day INTEGER)
+RETURNS DATE AS $$
+    SELECT MAKE_DATE(
+        event_year,
+        event_month,
+        CASE
+            WHEN event_month = 2 AND event_day = 29
+                AND NOT (event_year % 4 = 0 AND (event_year % 100 <> 0 OR event_year % 400 = 0))
+            THEN 28
+            ELSE event_day
+        END
+    );
+$$ LANGUAGE sql IMMUTABLE;
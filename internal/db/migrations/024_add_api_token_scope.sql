@@ -0,0 +1,4 @@
+-- What an API token may do: 'read' tokens are limited to GET requests, 'write' tokens may do anything
+ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS scope VARCHAR(10) NOT NULL DEFAULT 'write';
+
+COMMENT ON COLUMN api_tokens.scope IS 'Token scope: read (GET only) or write (full access)';
@@ -0,0 +1,6 @@
+-- Where an API token was last used from, to help spot a leaked token
+ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS last_used_ip VARCHAR(45);
+ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS last_used_user_agent TEXT;
+
+COMMENT ON COLUMN api_tokens.last_used_ip IS 'Client IP of the most recent request made with the token';
+COMMENT ON COLUMN api_tokens.last_used_user_agent IS 'User-Agent of the most recent request made with the token';
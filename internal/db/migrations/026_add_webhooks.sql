@@ -0,0 +1,14 @@
+-- Outbound webhooks: contact changes are POSTed to url, signed with secret
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id);
+
+COMMENT ON COLUMN webhooks.secret IS 'HMAC-SHA256 key for the X-KindredCard-Signature header';
+COMMENT ON COLUMN webhooks.events IS 'Events delivered (contact.created, contact.updated, contact.deleted)';
@@ -0,0 +1,6 @@
+-- Last-contacted tracking for "reconnect" reminders
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS last_contacted_at TIMESTAMPTZ;
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS contact_frequency_days INTEGER;
+
+COMMENT ON COLUMN contacts.last_contacted_at IS 'When the user last reached out to the contact';
+COMMENT ON COLUMN contacts.contact_frequency_days IS 'Desired days between contacts; the contact is due to reconnect once last_contacted_at is this far in the past';
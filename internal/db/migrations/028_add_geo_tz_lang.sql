@@ -0,0 +1,9 @@
+-- vCard GEO, TZ, and LANG
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS timezone VARCHAR(64);
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS languages TEXT[];
+
+COMMENT ON COLUMN contacts.latitude IS 'vCard GEO latitude; set together with longitude';
+COMMENT ON COLUMN contacts.timezone IS 'vCard TZ as given: an IANA zone name (America/Los_Angeles) or a UTC offset (-0800)';
+COMMENT ON COLUMN contacts.languages IS 'vCard LANG language tags, most preferred first';
@@ -0,0 +1,4 @@
+-- vCard properties KindredCard doesn't model, kept so CardDAV round trips don't drop them
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS raw_vcard_extras TEXT;
+
+COMMENT ON COLUMN contacts.raw_vcard_extras IS 'JSON of the unrecognized properties of the last vCard imported or PUT, re-emitted verbatim on export';
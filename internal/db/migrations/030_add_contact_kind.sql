@@ -0,0 +1,7 @@
+-- vCard KIND: individual, org, or group
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'individual';
+
+-- company cards flagged before KIND was stored
+UPDATE contacts SET kind = 'org' WHERE is_organization = true AND kind = 'individual';
+
+COMMENT ON COLUMN contacts.kind IS 'vCard KIND: individual, org, or group';
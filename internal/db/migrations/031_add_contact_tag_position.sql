@@ -0,0 +1,4 @@
+-- Order of a contact's tags, so vCard CATEGORIES round-trip in the order the client sent them
+ALTER TABLE contact_tags ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN contact_tags.position IS 'Order of the tag among the contact''s tags; ties (tags added before this column) sort by name';
@@ -0,0 +1,3 @@
+-- Nickname search uses ILIKE, which a btree on LOWER(nickname) can't serve; the trigram index
+-- from 022 covers it where pg_trgm is available
+DROP INDEX IF EXISTS idx_contacts_nickname;
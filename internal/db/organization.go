@@ -18,9 +18,16 @@ func (d *Database) CreateContactOrganization(userID int, body models.Organizatio
 		utils.Dump(body)
 	}
 
-	err := d.db.QueryRow(
-		"INSERT INTO organizations (contact_id, name, title, department, phonetic_name) VALUES ($1, $2, $3, $4, $5) RETURNING id",
-		body.ContactID, body.Name, body.Title, body.Department, body.PhoneticName,
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		"INSERT INTO organizations (contact_id, name, title, role, department, phonetic_name) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		body.ContactID, body.Name, body.Title, body.Role, body.Department, body.PhoneticName,
 	).Scan(&body.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -31,16 +38,17 @@ func (d *Database) CreateContactOrganization(userID int, body models.Organizatio
 		return 0, fmt.Errorf("failed to create organization: %w", err)
 	}
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return body.ID, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, body.ContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return 0, err
 	}
 
-	if err := d.bumpContactSyncToken(body.ContactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return 0, err
 	}
 
+	d.contactsUpdated(userID, body.ContactID)
+
 	return body.ID, nil
 }
 
@@ -69,6 +77,12 @@ func (d *Database) UpdateContactOrganization(userID int, body models.Organizatio
 		argIdx++
 	}
 
+	if body.Role != nil {
+		columns = append(columns, fmt.Sprintf("role = $%d", argIdx))
+		args = append(args, *body.Role)
+		argIdx++
+	}
+
 	if body.Department != nil {
 		columns = append(columns, fmt.Sprintf("department = $%d", argIdx))
 		args = append(args, *body.Department)
@@ -99,8 +113,15 @@ func (d *Database) UpdateContactOrganization(userID int, body models.Organizatio
 
 	args = append(args, body.ID, userID)
 
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var contactID int
-	err := d.db.QueryRow(query, args...).Scan(&contactID)
+	err = tx.QueryRow(query, args...).Scan(&contactID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			logger.Error("No rows patched: %v", err)
@@ -110,37 +131,45 @@ func (d *Database) UpdateContactOrganization(userID int, body models.Organizatio
 		return nil, fmt.Errorf("failed to patch organization: %w", err)
 	}
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return nil, err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
+	d.contactsUpdated(userID, contactID)
+
 	return d.getOrganizations(contactID)
 }
 
 func (d *Database) DeleteContactOrganization(userID int, contactID int, organizationID int) error {
 	logger.Debug("[DATABASE] Begin DeleteContactOrganization(userID:%d, contactID:%d, organizationID:%d)", userID, contactID, organizationID)
 
-	_, err := d.db.Exec("DELETE FROM organizations WHERE id = $1 AND contact_id = $2", organizationID, contactID)
+	tx, err := d.db.Begin()
 	if err != nil {
-		logger.Error("[DATABASE] Error deleting Organization: %v", err)
+		logger.Error("[DATABASE] Error starting tx: %v", err)
 		return err
 	}
+	defer tx.Rollback()
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	_, err = tx.Exec("DELETE FROM organizations WHERE id = $1 AND contact_id = $2", organizationID, contactID)
 	if err != nil {
-		return fmt.Errorf("failed to increment sync token: %w", err)
+		logger.Error("[DATABASE] Error deleting Organization: %v", err)
+		return err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	d.contactsUpdated(userID, contactID)
 	return nil
 }
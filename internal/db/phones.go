@@ -18,7 +18,14 @@ func (d *Database) CreateContactPhone(userID int, body models.Phone) (int, error
 		utils.Dump(body)
 	}
 
-	err := d.db.QueryRow(
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		"INSERT INTO phones (contact_id, phone, label_type_id, is_primary) VALUES ($1, $2, $3, $4) RETURNING id",
 		body.ContactID, body.Phone, body.Type, body.IsPrimary,
 	).Scan(&body.ID)
@@ -31,16 +38,17 @@ func (d *Database) CreateContactPhone(userID int, body models.Phone) (int, error
 		return 0, fmt.Errorf("failed to create phone: %w", err)
 	}
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return body.ID, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, body.ContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return 0, err
 	}
 
-	if err := d.bumpContactSyncToken(body.ContactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return 0, err
 	}
 
+	d.contactsUpdated(userID, body.ContactID)
+
 	return body.ID, nil
 }
 
@@ -95,8 +103,15 @@ func (d *Database) UpdateContactPhone(userID int, body models.PhoneJSONPatch) ([
 
 	args = append(args, body.ID, userID)
 
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var contactID int
-	err := d.db.QueryRow(query, args...).Scan(&contactID)
+	err = tx.QueryRow(query, args...).Scan(&contactID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			logger.Error("No rows patched: %v", err)
@@ -106,37 +121,45 @@ func (d *Database) UpdateContactPhone(userID int, body models.PhoneJSONPatch) ([
 		return nil, fmt.Errorf("failed to patch phone: %w", err)
 	}
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return nil, err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
+	d.contactsUpdated(userID, contactID)
+
 	return d.getPhones(contactID)
 }
 
 func (d *Database) DeleteContactPhone(userID int, contactID int, phoneID int) error {
 	logger.Debug("[DATABASE] Begin DeleteContactPhone(userID:%d, contactID:%d, phoneID:%d)", userID, contactID, phoneID)
 
-	_, err := d.db.Exec("DELETE FROM phones WHERE id = $1 AND contact_id = $2", phoneID, contactID)
+	tx, err := d.db.Begin()
 	if err != nil {
-		logger.Error("[DATABASE] Error deleting Phone: %v", err)
+		logger.Error("[DATABASE] Error starting tx: %v", err)
 		return err
 	}
+	defer tx.Rollback()
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	_, err = tx.Exec("DELETE FROM phones WHERE id = $1 AND contact_id = $2", phoneID, contactID)
 	if err != nil {
-		return fmt.Errorf("failed to increment sync token: %w", err)
+		logger.Error("[DATABASE] Error deleting Phone: %v", err)
+		return err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	d.contactsUpdated(userID, contactID)
 	return nil
 }
@@ -10,7 +10,10 @@
 package db
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/models"
@@ -112,90 +115,393 @@ func (d *Database) CreateRelationshipType(relationshipType *models.RelationshipT
 	return newID, err
 }
 
+// standardRelationshipTypes are the system relationship types seeded by the initial migration
+var standardRelationshipTypes = []models.RelationshipType{
+	{Name: "Mother", ReverseNameMale: "Son", ReverseNameFemale: "Daughter", ReverseNameNeutral: "Child", IsSystem: true},
+	{Name: "Father", ReverseNameMale: "Son", ReverseNameFemale: "Daughter", ReverseNameNeutral: "Child", IsSystem: true},
+	{Name: "Son", ReverseNameMale: "Father", ReverseNameFemale: "Mother", ReverseNameNeutral: "Parent", IsSystem: true},
+	{Name: "Daughter", ReverseNameMale: "Father", ReverseNameFemale: "Mother", ReverseNameNeutral: "Parent", IsSystem: true},
+	{Name: "Parent", ReverseNameMale: "Son", ReverseNameFemale: "Daughter", ReverseNameNeutral: "Child", IsSystem: true},
+	{Name: "Child", ReverseNameMale: "Father", ReverseNameFemale: "Mother", ReverseNameNeutral: "Parent", IsSystem: true},
+	{Name: "Brother", ReverseNameMale: "Brother", ReverseNameFemale: "Sister", ReverseNameNeutral: "Sibling", IsSystem: true},
+	{Name: "Sister", ReverseNameMale: "Brother", ReverseNameFemale: "Sister", ReverseNameNeutral: "Sibling", IsSystem: true},
+	{Name: "Sibling", ReverseNameMale: "Brother", ReverseNameFemale: "Sister", ReverseNameNeutral: "Sibling", IsSystem: true},
+	{Name: "Husband", ReverseNameMale: "Husband", ReverseNameFemale: "Wife", ReverseNameNeutral: "Spouse", IsSystem: true},
+	{Name: "Wife", ReverseNameMale: "Husband", ReverseNameFemale: "Wife", ReverseNameNeutral: "Spouse", IsSystem: true},
+	{Name: "Spouse", ReverseNameMale: "Husband", ReverseNameFemale: "Wife", ReverseNameNeutral: "Spouse", IsSystem: true},
+	{Name: "Partner", ReverseNameMale: "Partner", ReverseNameFemale: "Partner", ReverseNameNeutral: "Partner", IsSystem: true},
+	{Name: "Ex-Husband", ReverseNameMale: "Ex-Husband", ReverseNameFemale: "Ex-Wife", ReverseNameNeutral: "Ex-Spouse", IsSystem: true},
+	{Name: "Ex-Wife", ReverseNameMale: "Ex-Husband", ReverseNameFemale: "Ex-Wife", ReverseNameNeutral: "Ex-Spouse", IsSystem: true},
+	{Name: "Ex-Spouse", ReverseNameMale: "Ex-Husband", ReverseNameFemale: "Ex-Wife", ReverseNameNeutral: "Ex-Spouse", IsSystem: true},
+	{Name: "Ex-Partner", ReverseNameMale: "Ex-Husband", ReverseNameFemale: "Ex-Wife", ReverseNameNeutral: "Ex-Partner", IsSystem: true},
+	{Name: "Boyfriend", ReverseNameMale: "Boyfriend", ReverseNameFemale: "Girlfriend", ReverseNameNeutral: "Significant Other", IsSystem: true},
+	{Name: "Girlfriend", ReverseNameMale: "Boyfriend", ReverseNameFemale: "Girlfriend", ReverseNameNeutral: "Significant Other", IsSystem: true},
+	{Name: "Significant Other", ReverseNameMale: "Significant Other", ReverseNameFemale: "Significant Other", ReverseNameNeutral: "Significant Other", IsSystem: true},
+	{Name: "Step-Mother", ReverseNameMale: "Step-Son", ReverseNameFemale: "Step-Daughter", ReverseNameNeutral: "Step-Child", IsSystem: true},
+	{Name: "Step-Father", ReverseNameMale: "Step-Son", ReverseNameFemale: "Step-Daughter", ReverseNameNeutral: "Step-Child", IsSystem: true},
+	{Name: "Step-Son", ReverseNameMale: "Step-Father", ReverseNameFemale: "Step-Mother", ReverseNameNeutral: "Step-Parent", IsSystem: true},
+	{Name: "Step-Daughter", ReverseNameMale: "Step-Father", ReverseNameFemale: "Step-Mother", ReverseNameNeutral: "Step-Parent", IsSystem: true},
+	{Name: "Grandfather", ReverseNameMale: "Grandson", ReverseNameFemale: "Granddaughter", ReverseNameNeutral: "Grandchild", IsSystem: true},
+	{Name: "Grandmother", ReverseNameMale: "Grandson", ReverseNameFemale: "Granddaughter", ReverseNameNeutral: "Grandchild", IsSystem: true},
+	{Name: "Grandson", ReverseNameMale: "Grandfather", ReverseNameFemale: "Grandmother", ReverseNameNeutral: "Grandparent", IsSystem: true},
+	{Name: "Granddaughter", ReverseNameMale: "Grandfather", ReverseNameFemale: "Grandmother", ReverseNameNeutral: "Grandparent", IsSystem: true},
+	{Name: "Grandparent", ReverseNameMale: "Grandfather", ReverseNameFemale: "Grandmother", ReverseNameNeutral: "Grandchild", IsSystem: true},
+	{Name: "Grandchild", ReverseNameMale: "Grandfather", ReverseNameFemale: "Grandmother", ReverseNameNeutral: "Grandparent", IsSystem: true},
+	{Name: "Uncle", ReverseNameMale: "Nephew", ReverseNameFemale: "Niece", ReverseNameNeutral: "Nibling", IsSystem: true},
+	{Name: "Aunt", ReverseNameMale: "Nephew", ReverseNameFemale: "Niece", ReverseNameNeutral: "Nibling", IsSystem: true},
+	{Name: "Nephew", ReverseNameMale: "Uncle", ReverseNameFemale: "Aunt", ReverseNameNeutral: "Pibling", IsSystem: true},
+	{Name: "Niece", ReverseNameMale: "Uncle", ReverseNameFemale: "Aunt", ReverseNameNeutral: "Pibling", IsSystem: true},
+	{Name: "Cousin", ReverseNameMale: "Cousin", ReverseNameFemale: "Cousin", ReverseNameNeutral: "Cousin", IsSystem: true},
+	{Name: "Mother-in-Law", ReverseNameMale: "Son-in-Law", ReverseNameFemale: "Daughter-in-Law", ReverseNameNeutral: "Child-in-Law", IsSystem: true},
+	{Name: "Father-in-Law", ReverseNameMale: "Son-in-Law", ReverseNameFemale: "Daughter-in-Law", ReverseNameNeutral: "Child-in-Law", IsSystem: true},
+	{Name: "Son-in-Law", ReverseNameMale: "Father-in-Law", ReverseNameFemale: "Mother-in-Law", ReverseNameNeutral: "Parent-in-Law", IsSystem: true},
+	{Name: "Daughter-in-Law", ReverseNameMale: "Father-in-Law", ReverseNameFemale: "Mother-in-Law", ReverseNameNeutral: "Parent-in-Law", IsSystem: true},
+	{Name: "Brother-in-Law", ReverseNameMale: "Brother-in-Law", ReverseNameFemale: "Sister-in-Law", ReverseNameNeutral: "Sibling-in-Law", IsSystem: true},
+	{Name: "Sister-in-Law", ReverseNameMale: "Brother-in-Law", ReverseNameFemale: "Sister-in-Law", ReverseNameNeutral: "Sibling-in-Law", IsSystem: true},
+	{Name: "Friend", ReverseNameMale: "Friend", ReverseNameFemale: "Friend", ReverseNameNeutral: "Friend", IsSystem: true},
+	{Name: "Best Friend", ReverseNameMale: "Best Friend", ReverseNameFemale: "Best Friend", ReverseNameNeutral: "Best Friend", IsSystem: true},
+	{Name: "Acquaintance", ReverseNameMale: "Acquaintance", ReverseNameFemale: "Acquaintance", ReverseNameNeutral: "Acquaintance", IsSystem: true},
+	{Name: "Neighbor", ReverseNameMale: "Neighbor", ReverseNameFemale: "Neighbor", ReverseNameNeutral: "Neighbor", IsSystem: true},
+	{Name: "Roommate", ReverseNameMale: "Roommate", ReverseNameFemale: "Roommate", ReverseNameNeutral: "Roommate", IsSystem: true},
+	{Name: "Manager", ReverseNameMale: "Direct Report", ReverseNameFemale: "Direct Report", ReverseNameNeutral: "Direct Report", IsSystem: true},
+	{Name: "Direct Report", ReverseNameMale: "Manager", ReverseNameFemale: "Manager", ReverseNameNeutral: "Manager", IsSystem: true},
+	{Name: "Assistant", ReverseNameMale: "Manager", ReverseNameFemale: "Manager", ReverseNameNeutral: "Manager", IsSystem: true},
+	{Name: "Colleague", ReverseNameMale: "Colleague", ReverseNameFemale: "Colleague", ReverseNameNeutral: "Colleague", IsSystem: true},
+	{Name: "Mentor", ReverseNameMale: "Mentee", ReverseNameFemale: "Mentee", ReverseNameNeutral: "Mentee", IsSystem: true},
+	{Name: "Coworker", ReverseNameMale: "Coworker", ReverseNameFemale: "Coworker", ReverseNameNeutral: "Coworker", IsSystem: true},
+	{Name: "Boss", ReverseNameMale: "Employee", ReverseNameFemale: "Employee", ReverseNameNeutral: "Employee", IsSystem: true},
+	{Name: "Client", ReverseNameMale: "Service Provider", ReverseNameFemale: "Service Provider", ReverseNameNeutral: "Service Provider", IsSystem: true},
+	{Name: "Customer", ReverseNameMale: "Vendor", ReverseNameFemale: "Vendor", ReverseNameNeutral: "Vendor", IsSystem: true},
+	{Name: "Emergency Contact", ReverseNameMale: "Person", ReverseNameFemale: "Person", ReverseNameNeutral: "Person", IsSystem: true},
+	{Name: "Referred By", ReverseNameMale: "Referral", ReverseNameFemale: "Referral", ReverseNameNeutral: "Referral", IsSystem: true},
+}
+
+// SeedRelationshipTypes inserts any missing standard relationship types and repairs the reverse
+// names of existing ones. System types are reset to the standard reverse names; custom types only
+// have blank reverse names filled in. Safe to run repeatedly
+func (d *Database) SeedRelationshipTypes() (inserted int, repaired int, err error) {
+	logger.Debug("[DATABASE] Begin SeedRelationshipTypes()")
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	for _, standard := range standardRelationshipTypes {
+		var existing models.RelationshipType
+		err := tx.QueryRow(`
+			SELECT id, COALESCE(reverse_name_male, ''), COALESCE(reverse_name_female, ''), COALESCE(reverse_name_neutral, ''), COALESCE(is_system, FALSE)
+			FROM relationship_types WHERE name = $1`, standard.Name,
+		).Scan(&existing.ID, &existing.ReverseNameMale, &existing.ReverseNameFemale, &existing.ReverseNameNeutral, &existing.IsSystem)
+
+		if err == sql.ErrNoRows {
+			if _, err := tx.Exec(`
+				INSERT INTO relationship_types (name, reverse_name_male, reverse_name_female, reverse_name_neutral, is_system)
+				VALUES ($1, $2, $3, $4, TRUE)
+				ON CONFLICT (name) DO NOTHING`,
+				standard.Name, standard.ReverseNameMale, standard.ReverseNameFemale, standard.ReverseNameNeutral,
+			); err != nil {
+				logger.Error("[DATABASE] Error inserting relationship type: %v", err)
+				return 0, 0, err
+			}
+			inserted++
+			continue
+		}
+		if err != nil {
+			logger.Error("[DATABASE] Error selecting relationship type: %v", err)
+			return 0, 0, err
+		}
+
+		male, female, neutral := existing.ReverseNameMale, existing.ReverseNameFemale, existing.ReverseNameNeutral
+		if existing.IsSystem || male == "" {
+			male = standard.ReverseNameMale
+		}
+		if existing.IsSystem || female == "" {
+			female = standard.ReverseNameFemale
+		}
+		if existing.IsSystem || neutral == "" {
+			neutral = standard.ReverseNameNeutral
+		}
+		if male == existing.ReverseNameMale && female == existing.ReverseNameFemale && neutral == existing.ReverseNameNeutral {
+			continue
+		}
+
+		if _, err := tx.Exec(`
+			UPDATE relationship_types
+			SET reverse_name_male = $1, reverse_name_female = $2, reverse_name_neutral = $3
+			WHERE id = $4`,
+			male, female, neutral, existing.ID,
+		); err != nil {
+			logger.Error("[DATABASE] Error updating relationship type: %v", err)
+			return 0, 0, err
+		}
+		repaired++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+
+	return inserted, repaired, nil
+}
+
+// RefreshRelationshipTypeContacts bumps the sync tokens of every contact of the user on either side
+// of a relationship of the given type. Relationships reference types by ID, so renaming a type
+// changes what the cards should say without touching the contacts; this makes CardDAV clients
+// re-download them. Returns the number of contacts refreshed, or "not found" for an unknown type
+func (d *Database) RefreshRelationshipTypeContacts(userID int, relationshipTypeID int) (int, error) {
+	logger.Debug("[DATABASE] Begin RefreshRelationshipTypeContacts(userID:%d, relationshipTypeID:%d)", userID, relationshipTypeID)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM relationship_types WHERE id = $1)", relationshipTypeID).Scan(&exists)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting relationship type: %v", err)
+		return 0, err
+	}
+	if !exists {
+		return 0, errors.New("not found")
+	}
+
+	rows, err := tx.Query(`
+		SELECT c.id FROM contacts c
+		WHERE c.user_id = $2 AND c.deleted_at IS NULL
+		AND EXISTS (
+			SELECT 1 FROM relationships r
+			WHERE r.relationship_type_id = $1
+			AND (r.contact_id = c.id OR r.related_contact_id = c.id)
+		)
+		ORDER BY c.id`, relationshipTypeID, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting related contacts: %v", err)
+		return 0, err
+	}
+
+	var contactIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			logger.Error("[DATABASE] Error scanning related contacts: %v", err)
+			return 0, err
+		}
+		contactIDs = append(contactIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// Nothing to refresh, so leave the collection's sync token alone
+	if len(contactIDs) == 0 {
+		return 0, nil
+	}
+
+	if err := bumpSyncTokens(tx, userID, contactIDs...); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	d.contactsUpdated(userID, contactIDs...)
+
+	return len(contactIDs), nil
+}
+
 // AddRelationship creates a relationship between two contacts
-func (d *Database) AddRelationship(userID int, contactID int, relatedContactID int, relationshipTypeID int) error {
+// Returns the relationship row and whether it was newly created; if the relationship
+// (or its mirror) already exists, the existing row is returned with created=false.
+// Returns "not found" unless both contacts are the user's and not in the trash
+func (d *Database) AddRelationship(userID int, contactID int, relatedContactID int, relationshipTypeID int) (*models.Relationship, bool, error) {
 	logger.Debug("[DATABASE] Begin AddRelationship(userID:%d, contactID:%d, relatedContactID:%d, relationshipTypeID:%d)", userID, contactID, relatedContactID, relationshipTypeID)
 
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// Both ends must be the user's live contacts; the rows found or created below (and the
+	// tokens bumped) are then theirs too
+	var myGender sql.NullString
+	err = tx.QueryRow(
+		"SELECT gender FROM contacts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL",
+		contactID, userID,
+	).Scan(&myGender)
+	if err == sql.ErrNoRows {
+		return nil, false, errors.New("not found")
+	}
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts: %v", err)
+		return nil, false, err
+	}
+	var relatedExists bool
+	err = tx.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM contacts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)",
+		relatedContactID, userID,
+	).Scan(&relatedExists)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts: %v", err)
+		return nil, false, err
+	}
+	if !relatedExists {
+		return nil, false, errors.New("not found")
+	}
+
 	// Mirror Detection: Check if the inverse already exists
-	var myGender string
-	_ = d.db.QueryRow("SELECT gender FROM contacts WHERE id = $1", contactID).Scan(&myGender)
-
-	reverseTypeID, err := d.GetReverseRelationshipType(relationshipTypeID, myGender)
-	if err == nil {
-		var exists bool
-		d.db.QueryRow(`
-            SELECT EXISTS(
-                SELECT 1 FROM relationships 
-                WHERE contact_id = $1 AND related_contact_id = $2 AND relationship_type_id = $3
-            )`, relatedContactID, contactID, reverseTypeID).Scan(&exists)
-
-		if exists {
+	reverseTypeID, err := d.GetReverseRelationshipType(relationshipTypeID, utils.ScanNullString(myGender))
+	hasReverseType := err == nil
+	if hasReverseType && !d.ExplicitMirrorRelationships {
+		var mirrorID int
+		err = tx.QueryRow(`
+            SELECT id FROM relationships 
+            WHERE contact_id = $1 AND related_contact_id = $2 AND relationship_type_id = $3`,
+			relatedContactID, contactID, reverseTypeID).Scan(&mirrorID)
+
+		if err == nil {
 			logger.Info("[DATABASE] Relationship mirror already exists. Skipping redundant insert.")
-			return nil
+			tx.Rollback()
+			rel, err := d.getRelationshipByID(mirrorID)
+			return rel, false, err
 		}
 	}
 
 	// Perform the Insert
-	_, err = d.db.Exec(`
+	var newID int
+	err = tx.QueryRow(`
         INSERT INTO relationships (contact_id, related_contact_id, relationship_type_id)
         VALUES ($1, $2, $3)
-        ON CONFLICT (contact_id, related_contact_id, relationship_type_id) DO NOTHING`,
-		contactID, relatedContactID, relationshipTypeID)
-
+        ON CONFLICT (contact_id, related_contact_id, relationship_type_id) DO NOTHING
+        RETURNING id`,
+		contactID, relatedContactID, relationshipTypeID).Scan(&newID)
+
+	if err == sql.ErrNoRows {
+		// Conflict: the exact relationship is already present
+		var existingID int
+		err = tx.QueryRow(`
+            SELECT id FROM relationships
+            WHERE contact_id = $1 AND related_contact_id = $2 AND relationship_type_id = $3`,
+			contactID, relatedContactID, relationshipTypeID).Scan(&existingID)
+		if err != nil {
+			logger.Error("[DATABASE] Error selecting Relationship: %v", err)
+			return nil, false, err
+		}
+		logger.Info("[DATABASE] Relationship already exists. Skipping redundant insert.")
+		tx.Rollback()
+		rel, err := d.getRelationshipByID(existingID)
+		return rel, false, err
+	}
 	if err != nil {
 		logger.Error("[DATABASE] Error inserting Relationships: %v", err)
-		return err
+		return nil, false, err
 	}
 
-	// Sync Token Management
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		logger.Error("[DATABASE] Error incrementing CardDAV sync token: %v", err)
-		return fmt.Errorf("failed to increment sync token: %w", err)
+	if hasReverseType && d.ExplicitMirrorRelationships {
+		if err := insertMirrorRelationship(tx, relatedContactID, contactID, reverseTypeID); err != nil {
+			return nil, false, err
+		}
 	}
 
 	// Bump both contacts so CardDAV clients see the update for both people
-	_ = d.bumpContactSyncToken(contactID, newSyncToken)
-	_ = d.bumpContactSyncToken(relatedContactID, newSyncToken)
+	if err := bumpSyncTokens(tx, userID, contactID, relatedContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return nil, false, err
+	}
 
-	return nil
-}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
 
-// RemoveRelationship removes a relationship between two contacts
-func (d *Database) RemoveRelationship(userID int, relationshipID int) error {
-	logger.Debug("[DATABASE] Begin RemoveRelationship(userID:%d, relationshipID:%d)", userID, relationshipID)
+	d.contactsUpdated(userID, contactID, relatedContactID)
 
-	rel := &models.Relationship{}
+	rel, err := d.getRelationshipByID(newID)
+	return rel, true, err
+}
+
+// getRelationshipByID loads a single relationship row along with its type
+func (d *Database) getRelationshipByID(relationshipID int) (*models.Relationship, error) {
+	rel := &models.Relationship{RelationshipType: &models.RelationshipType{}}
 
 	err := d.db.QueryRow(`
-		SELECT contact_id, related_contact_id
-		FROM relationships
-		WHERE id = $1
-		LIMIT 1`, relationshipID).Scan(&rel.ContactID, &rel.RelatedContactID)
+		SELECT r.id, r.contact_id, r.related_contact_id, r.created_at,
+		       rt.id, rt.name, rt.reverse_name_male, rt.reverse_name_female, rt.reverse_name_neutral, rt.is_system
+		FROM relationships r
+		JOIN relationship_types rt ON r.relationship_type_id = rt.id
+		WHERE r.id = $1`, relationshipID).Scan(
+		&rel.ID, &rel.ContactID, &rel.RelatedContactID, &rel.CreatedAt,
+		&rel.RelationshipType.ID, &rel.RelationshipType.Name, &rel.RelationshipType.ReverseNameMale,
+		&rel.RelationshipType.ReverseNameFemale, &rel.RelationshipType.ReverseNameNeutral, &rel.RelationshipType.IsSystem,
+	)
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting Relationship: %v", err)
+		return nil, err
+	}
+
+	return rel, nil
+}
+
+// RemoveRelationship removes a relationship between two contacts along with its mirror, if any
+func (d *Database) RemoveRelationship(userID int, relationshipID int) error {
+	logger.Debug("[DATABASE] Begin RemoveRelationship(userID:%d, relationshipID:%d)", userID, relationshipID)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
 		return err
 	}
+	defer tx.Rollback()
 
-	_, err = d.db.Exec("DELETE FROM relationships WHERE id = $1", relationshipID)
+	var typeID int
+	rel := &models.Relationship{}
+	err = tx.QueryRow(`
+		DELETE FROM relationships r
+		USING contacts c
+		WHERE r.id = $1 AND r.contact_id = c.id AND c.user_id = $2
+		RETURNING r.contact_id, r.related_contact_id, r.relationship_type_id`, relationshipID, userID).Scan(&rel.ContactID, &rel.RelatedContactID, &typeID)
+	if err == sql.ErrNoRows {
+		return errors.New("not found")
+	}
 	if err != nil {
 		logger.Error("[DATABASE] Error deleting Relationships: %v", err)
 		return err
 	}
 
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		logger.Error("[DATABASE] Error incrementing CardDAV sync token: %v", err)
-		return fmt.Errorf("failed to increment sync token: %w", err)
+	// Remove the mirror too (the same pair the other way round, typed with any of this type's
+	// reverse names), or the relationship would still show from the other side. Matching every
+	// gendered reverse name catches mirrors created before a gender change
+	if _, err := tx.Exec(`
+		DELETE FROM relationships r
+		USING relationship_types rt, relationship_types mt
+		WHERE r.contact_id = $1 AND r.related_contact_id = $2
+		AND rt.id = $3 AND mt.id = r.relationship_type_id
+		AND mt.name IN (rt.reverse_name_male, rt.reverse_name_female, rt.reverse_name_neutral)`,
+		rel.RelatedContactID, rel.ContactID, typeID); err != nil {
+		logger.Error("[DATABASE] Error deleting mirror Relationships: %v", err)
+		return err
 	}
 
-	if err := d.bumpContactSyncToken(rel.ContactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := bumpSyncTokens(tx, userID, rel.ContactID, rel.RelatedContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
 	}
-	if err := d.bumpContactSyncToken(rel.RelatedContactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	d.contactsUpdated(userID, rel.ContactID, rel.RelatedContactID)
 	return nil
 }
 
@@ -203,26 +509,207 @@ func (d *Database) RemoveRelationship(userID int, relationshipID int) error {
 func (d *Database) RemoveOtherRelationship(userID int, otherRelationshipID int) error {
 	logger.Debug("[DATABASE] Begin RemoveOtherRelationship(userID:%d, otherRelationshipID:%d)", userID, otherRelationshipID)
 
-	rel := &models.Relationship{}
-
-	_, err := d.db.Exec("DELETE FROM other_relationships WHERE id = $1", otherRelationshipID)
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Other relationships only name the related person, so only the owning contact changes
+	var contactID int
+	err = tx.QueryRow(`
+		DELETE FROM other_relationships o
+		USING contacts c
+		WHERE o.id = $1 AND o.contact_id = c.id AND c.user_id = $2
+		RETURNING o.contact_id`, otherRelationshipID, userID).Scan(&contactID)
+	if err == sql.ErrNoRows {
+		return errors.New("not found")
+	}
 	if err != nil {
 		logger.Error("[DATABASE] Error deleting Other Relationships: %v", err)
 		return err
 	}
 
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactsUpdated(userID, contactID)
+	return nil
+}
+
+// DefaultHouseholdDepth is how many relationship hops GetHousehold follows by default
+const DefaultHouseholdDepth = 2
+
+// MaxHouseholdDepth bounds the household search so a large family graph can't be walked entirely
+const MaxHouseholdDepth = 5
+
+// familyRelationship describes a family relationship type as seen from the contact holding it
+type familyRelationship struct {
+	generation int  // generation of the related contact relative to the holder
+	household  bool // spouses, parents, and children; everything else is extended family
+}
+
+// familyRelationships maps lowercased relationship type names, including reverse names, to their
+// place in the family. Types not listed (friends, colleagues, ...) never join a household
+var familyRelationships = map[string]familyRelationship{
+	"husband": {0, true}, "wife": {0, true}, "spouse": {0, true}, "partner": {0, true},
+	"son": {1, true}, "daughter": {1, true}, "child": {1, true},
+	"step-son": {1, true}, "step-daughter": {1, true}, "step-child": {1, true},
+	"mother": {-1, true}, "father": {-1, true}, "parent": {-1, true},
+	"step-mother": {-1, true}, "step-father": {-1, true}, "step-parent": {-1, true},
+
+	"brother": {0, false}, "sister": {0, false}, "sibling": {0, false}, "cousin": {0, false},
+	"brother-in-law": {0, false}, "sister-in-law": {0, false}, "sibling-in-law": {0, false},
+	"grandfather": {-2, false}, "grandmother": {-2, false}, "grandparent": {-2, false},
+	"grandson": {2, false}, "granddaughter": {2, false}, "grandchild": {2, false},
+	"uncle": {-1, false}, "aunt": {-1, false}, "pibling": {-1, false},
+	"nephew": {1, false}, "niece": {1, false}, "nibling": {1, false},
+	"mother-in-law": {-1, false}, "father-in-law": {-1, false}, "parent-in-law": {-1, false},
+	"son-in-law": {1, false}, "daughter-in-law": {1, false}, "child-in-law": {1, false},
+}
+
+// householdEdge is a relationship from one contact's point of view: other is name of the contact
+type householdEdge struct {
+	other int
+	name  string
+}
+
+// GetHousehold groups a contact with the family living with them, found by a breadth-first
+// search of at most depth hops over the user's relationships. The household is the contact's
+// spouses/partners and their children; a contact without either is grouped with their parents
+// and siblings instead. extended also follows siblings, grandparents, in-laws, and other family
+// across every generation. The requested contact is the first member
+func (d *Database) GetHousehold(userID int, contactID int, extended bool, depth int) ([]models.HouseholdMember, error) {
+	logger.Debug("[DATABASE] Begin GetHousehold(userID:%d, contactID:%d, extended:%t, depth:%d)", userID, contactID, extended, depth)
+
+	var fullName string
+	err := d.db.QueryRow(
+		"SELECT full_name FROM contacts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL",
+		contactID, userID,
+	).Scan(&fullName)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("not found")
+	}
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contact: %v", err)
+		return nil, err
+	}
+
+	// Each stored row is an edge both ways: the related contact is rt.name of the contact, and
+	// the contact is the gendered reverse name of the related contact
+	rows, err := d.db.Query(`
+		SELECT r.contact_id, c.full_name, r.related_contact_id, rc.full_name, rt.name,
+			COALESCE(CASE
+				WHEN c.gender IN ('M', 'male') THEN rt.reverse_name_male
+				WHEN c.gender IN ('F', 'female') THEN rt.reverse_name_female
+				ELSE rt.reverse_name_neutral
+			END, '')
+		FROM relationships r
+		JOIN relationship_types rt ON r.relationship_type_id = rt.id
+		JOIN contacts c ON r.contact_id = c.id
+		JOIN contacts rc ON r.related_contact_id = rc.id
+		WHERE c.user_id = $1 AND c.deleted_at IS NULL AND rc.deleted_at IS NULL`, userID)
 	if err != nil {
-		logger.Error("[DATABASE] Error incrementing CardDAV sync token: %v", err)
-		return fmt.Errorf("failed to increment sync token: %w", err)
+		logger.Error("[DATABASE] Error selecting relationships: %v", err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	if err := d.bumpContactSyncToken(rel.ContactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	names := map[int]string{contactID: fullName}
+	edges := make(map[int][]householdEdge)
+	for rows.Next() {
+		var fromID, toID int
+		var fromName, toName, name, reverseName string
+		if err := rows.Scan(&fromID, &fromName, &toID, &toName, &name, &reverseName); err != nil {
+			logger.Error("[DATABASE] Error scanning relationships: %v", err)
+			return nil, err
+		}
+		names[fromID], names[toID] = fromName, toName
+		edges[fromID] = append(edges[fromID], householdEdge{other: toID, name: name})
+		if reverseName != "" {
+			edges[toID] = append(edges[toID], householdEdge{other: fromID, name: reverseName})
+		}
 	}
-	if err := d.bumpContactSyncToken(rel.RelatedContactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return householdMembers(contactID, names, edges, extended, depth), nil
+}
+
+// householdMembers runs the bounded breadth-first search behind GetHousehold
+func householdMembers(start int, names map[int]string, edges map[int][]householdEdge, extended bool, depth int) []models.HouseholdMember {
+	// Without a partner or children of their own, a contact belongs to their parents' household
+	anchor := 0
+	if !extended {
+		anchor = -1
+		for _, edge := range edges[start] {
+			if rel, ok := familyRelationships[strings.ToLower(edge.name)]; ok && rel.household && rel.generation >= 0 {
+				anchor = 0
+				break
+			}
+		}
+	}
+
+	type visit struct {
+		id         int
+		generation int
+		hops       int
+	}
+
+	members := []models.HouseholdMember{{ContactID: start, FullName: names[start]}}
+	seen := map[int]bool{start: true}
+	queue := []visit{{id: start}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.hops >= depth {
+			continue
+		}
+
+		for _, edge := range edges[current.id] {
+			rel, ok := familyRelationships[strings.ToLower(edge.name)]
+			if !ok || seen[edge.other] {
+				continue
+			}
+			generation := current.generation + rel.generation
+
+			if !extended {
+				if !rel.household || generation < anchor || generation > anchor+1 {
+					continue
+				}
+				// Only the contact's own parents are followed upward, and only the heads of the
+				// household bring in partners; a grown child's spouse or a child's other
+				// parent lives elsewhere
+				if rel.generation < 0 && current.id != start {
+					continue
+				}
+				if rel.generation == 0 && current.generation != anchor {
+					continue
+				}
+			}
+
+			seen[edge.other] = true
+			member := models.HouseholdMember{
+				ContactID:  edge.other,
+				FullName:   names[edge.other],
+				Generation: generation,
+			}
+			if current.id == start {
+				member.Relationship = edge.name
+			}
+			members = append(members, member)
+			queue = append(queue, visit{id: edge.other, generation: generation, hops: current.hops + 1})
+		}
+	}
+
+	return members
 }
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"sort"
+	"testing"
+)
+
+// Contacts in the test family
+const (
+	dad = iota + 1
+	mom
+	son
+	daughter
+	grandma
+	uncle
+	friend
+)
+
+// testFamily returns names and relationship edges for a family: Dad and Mom with a son and a
+// daughter, Dad's mother and brother, and a friend of Dad's
+func testFamily() (map[int]string, map[int][]householdEdge) {
+	names := map[int]string{
+		dad: "Dad", mom: "Mom", son: "Son", daughter: "Daughter",
+		grandma: "Grandma", uncle: "Uncle", friend: "Friend",
+	}
+	edges := make(map[int][]householdEdge)
+	relate := func(from, to int, name, reverse string) {
+		edges[from] = append(edges[from], householdEdge{other: to, name: name})
+		edges[to] = append(edges[to], householdEdge{other: from, name: reverse})
+	}
+	relate(dad, mom, "Wife", "Husband")
+	relate(dad, son, "Son", "Father")
+	relate(dad, daughter, "Daughter", "Father")
+	relate(mom, son, "Son", "Mother")
+	relate(mom, daughter, "Daughter", "Mother")
+	relate(son, daughter, "Sister", "Brother")
+	relate(dad, grandma, "Mother", "Son")
+	relate(dad, uncle, "Brother", "Brother")
+	relate(dad, friend, "Friend", "Friend")
+	return names, edges
+}
+
+func TestHouseholdMembers(t *testing.T) {
+	names, edges := testFamily()
+
+	tests := []struct {
+		name     string
+		start    int
+		extended bool
+		depth    int
+		want     []string
+	}{
+		{"parent", dad, false, DefaultHouseholdDepth, []string{"Dad", "Daughter", "Mom", "Son"}},
+		{"child joins the parents' household", son, false, DefaultHouseholdDepth, []string{"Dad", "Daughter", "Mom", "Son"}},
+		{"depth bounds the search", son, false, 1, []string{"Dad", "Mom", "Son"}},
+		{"extended family", dad, true, DefaultHouseholdDepth, []string{"Dad", "Daughter", "Grandma", "Mom", "Son", "Uncle"}},
+		{"extended from a child", son, true, 1, []string{"Dad", "Daughter", "Mom", "Son"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := householdMembers(tt.start, names, edges, tt.extended, tt.depth)
+			if members[0].ContactID != tt.start {
+				t.Errorf("first member = %d, want the requested contact %d", members[0].ContactID, tt.start)
+			}
+
+			var got []string
+			for _, m := range members {
+				got = append(got, m.FullName)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("household = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("household = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHouseholdMemberRelationships(t *testing.T) {
+	names, edges := testFamily()
+
+	want := map[int]struct {
+		relationship string
+		generation   int
+	}{
+		dad:      {"", 0},
+		mom:      {"Wife", 0},
+		son:      {"Son", 1},
+		daughter: {"Daughter", 1},
+		grandma:  {"Mother", -1},
+		uncle:    {"Brother", 0},
+	}
+	for _, m := range householdMembers(dad, names, edges, true, DefaultHouseholdDepth) {
+		w, ok := want[m.ContactID]
+		if !ok {
+			t.Errorf("unexpected member %s", m.FullName)
+			continue
+		}
+		if m.Relationship != w.relationship || m.Generation != w.generation {
+			t.Errorf("%s: relationship %q, generation %d; want %q, %d", m.FullName, m.Relationship, m.Generation, w.relationship, w.generation)
+		}
+	}
+}
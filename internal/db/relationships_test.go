@@ -0,0 +1,261 @@
+package db_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/db"
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestExplicitMirrorRelationships(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	parentType := dbtest.RelationshipTypeID(t, database, "Parent")
+	sonType, err := database.GetReverseRelationshipType(parentType, "M")
+	if err != nil {
+		t.Fatalf("GetReverseRelationshipType: %v", err)
+	}
+
+	// hasStoredMirror reports whether mom -> kid "Son" is stored as its own row. Adding it again
+	// with mirrors materialized skips the read-time mirror lookup, so it only finds a stored row
+	hasStoredMirror := func(mom, kid *models.Contact) bool {
+		t.Helper()
+		database.ExplicitMirrorRelationships = true
+		rel, created, err := database.AddRelationship(user.ID, mom.ID, kid.ID, sonType)
+		if err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+		if !created && rel.ContactID != mom.ID {
+			t.Fatalf("AddRelationship returned %+v, want mom's own row", rel)
+		}
+		return !created
+	}
+
+	for _, tt := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"AddRelationship", true},
+		{"AddRelationship", false},
+		{"CreateContact", true},
+		{"CreateContact", false},
+	} {
+		database.ExplicitMirrorRelationships = tt.enabled
+
+		mom := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Mom", Gender: "F"})
+		kid := &models.Contact{FullName: "Kid", Gender: "M"}
+		if tt.name == "CreateContact" {
+			kid.Relationships = []models.Relationship{{
+				RelatedContact:   &models.Contact{ID: mom.ID},
+				RelationshipType: &models.RelationshipType{ID: parentType},
+			}}
+			dbtest.NewContact(t, database, user.ID, kid)
+		} else {
+			dbtest.NewContact(t, database, user.ID, kid)
+			if _, _, err := database.AddRelationship(user.ID, kid.ID, mom.ID, parentType); err != nil {
+				t.Fatalf("AddRelationship: %v", err)
+			}
+		}
+
+		if got := hasStoredMirror(mom, kid); got != tt.enabled {
+			t.Errorf("%s with explicit mirrors %v: stored mirror row = %v, want %v", tt.name, tt.enabled, got, tt.enabled)
+		}
+
+		// Either way, Mom sees Kid exactly once
+		relationships := 0
+		loaded, err := database.GetContactByID(user.ID, mom.ID)
+		if err != nil {
+			t.Fatalf("GetContactByID: %v", err)
+		}
+		for _, rel := range loaded.Relationships {
+			if rel.RelatedContactID == kid.ID {
+				relationships++
+			}
+		}
+		if relationships != 1 {
+			t.Errorf("%s with explicit mirrors %v: Mom has %d relationships to Kid, want 1", tt.name, tt.enabled, relationships)
+		}
+	}
+}
+
+func TestSeedRelationshipTypesIsIdempotent(t *testing.T) {
+	database := dbtest.Open(t)
+
+	if err := database.BlankReverseNames("Mother"); err != nil {
+		t.Fatalf("BlankReverseNames: %v", err)
+	}
+
+	if _, repaired, err := database.SeedRelationshipTypes(); err != nil {
+		t.Fatalf("first SeedRelationshipTypes: %v", err)
+	} else if repaired < 1 {
+		t.Errorf("first run repaired %d types, want Mother's blank reverse names fixed", repaired)
+	}
+
+	inserted, repaired, err := database.SeedRelationshipTypes()
+	if err != nil {
+		t.Fatalf("second SeedRelationshipTypes: %v", err)
+	}
+	if inserted != 0 || repaired != 0 {
+		t.Errorf("second run inserted %d and repaired %d types, want nothing to do", inserted, repaired)
+	}
+
+	types, err := database.GetRelationshipTypes()
+	if err != nil {
+		t.Fatalf("GetRelationshipTypes: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, rt := range types {
+		if seen[rt.Name] {
+			t.Errorf("relationship type %q is duplicated", rt.Name)
+		}
+		seen[rt.Name] = true
+
+		if rt.Name == "Mother" && (rt.ReverseNameMale != "Son" || rt.ReverseNameFemale != "Daughter" || rt.ReverseNameNeutral != "Child") {
+			t.Errorf("Mother's reverse names = %q/%q/%q, want Son/Daughter/Child", rt.ReverseNameMale, rt.ReverseNameFemale, rt.ReverseNameNeutral)
+		}
+	}
+	if !seen["Mother"] || !seen["Referred By"] {
+		t.Error("standard relationship types are missing after seeding")
+	}
+}
+
+func TestGetHousehold(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	dad := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Dad", Gender: "M"})
+	mom := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Mom", Gender: "F"})
+	kid := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Kid", Gender: "M"})
+	friend := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Friend"})
+
+	for _, rel := range []struct {
+		from, to *models.Contact
+		kind     string
+	}{
+		{dad, mom, "Wife"},
+		{dad, kid, "Son"},
+		{mom, kid, "Son"},
+		{dad, friend, "Friend"},
+	} {
+		if _, _, err := database.AddRelationship(user.ID, rel.from.ID, rel.to.ID, dbtest.RelationshipTypeID(t, database, rel.kind)); err != nil {
+			t.Fatalf("AddRelationship(%s -> %s): %v", rel.from.FullName, rel.to.FullName, err)
+		}
+	}
+
+	// The kid has no household of their own, so they're grouped with their parents, found
+	// through the reverse (Father/Mother) of the stored Son rows
+	for _, start := range []*models.Contact{dad, kid} {
+		members, err := database.GetHousehold(user.ID, start.ID, false, db.DefaultHouseholdDepth)
+		if err != nil {
+			t.Fatalf("GetHousehold(%s): %v", start.FullName, err)
+		}
+		var got []string
+		for _, m := range members {
+			got = append(got, m.FullName)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != "Dad,Kid,Mom" {
+			t.Errorf("%s's household = %v, want Dad, Kid, and Mom", start.FullName, got)
+		}
+	}
+
+	other := dbtest.NewUser(t, database)
+	if _, err := database.GetHousehold(other.ID, dad.ID, false, db.DefaultHouseholdDepth); err == nil || err.Error() != "not found" {
+		t.Errorf("another user's household: err = %v, want not found", err)
+	}
+}
+
+func TestRefreshRelationshipTypeContacts(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	carol := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Carol"})
+	dave := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Dave"})
+
+	friend := dbtest.RelationshipTypeID(t, database, "Friend")
+	if _, _, err := database.AddRelationship(user.ID, alice.ID, bob.ID, friend); err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+	if _, _, err := database.AddRelationship(user.ID, carol.ID, dave.ID, dbtest.RelationshipTypeID(t, database, "Sibling")); err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+
+	before := make(map[string]int)
+	for _, c := range []*models.Contact{alice, bob, carol} {
+		before[c.UID] = dbtest.VersionToken(t, database, user.ID, c.UID)
+	}
+
+	refreshed, err := database.RefreshRelationshipTypeContacts(user.ID, friend)
+	if err != nil {
+		t.Fatalf("RefreshRelationshipTypeContacts: %v", err)
+	}
+	if refreshed != 2 {
+		t.Errorf("refreshed %d contacts, want Alice and Bob", refreshed)
+	}
+
+	for _, c := range []*models.Contact{alice, bob} {
+		if after := dbtest.VersionToken(t, database, user.ID, c.UID); after <= before[c.UID] {
+			t.Errorf("%s: version_token = %d, want more than %d", c.FullName, after, before[c.UID])
+		}
+	}
+	if after := dbtest.VersionToken(t, database, user.ID, carol.UID); after != before[carol.UID] {
+		t.Errorf("unrelated Carol: version_token = %d, want %d", after, before[carol.UID])
+	}
+
+	if _, err := database.RefreshRelationshipTypeContacts(user.ID, -1); err == nil || err.Error() != "not found" {
+		t.Errorf("unknown type: err = %v, want not found", err)
+	}
+}
+
+func TestRemoveRelationshipRemovesMirror(t *testing.T) {
+	database := dbtest.Open(t)
+	database.ExplicitMirrorRelationships = true
+	user := dbtest.NewUser(t, database)
+
+	a := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Adam", Gender: "M"})
+	b := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Beth", Gender: "F"})
+
+	rel, _, err := database.AddRelationship(user.ID, a.ID, b.ID, dbtest.RelationshipTypeID(t, database, "Spouse"))
+	if err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+	relationships := func(contactID int) []models.Relationship {
+		contact, err := database.GetContactByID(user.ID, contactID)
+		if err != nil {
+			t.Fatalf("GetContactByID: %v", err)
+		}
+		return contact.Relationships
+	}
+	if n := len(relationships(b.ID)); n != 2 {
+		t.Fatalf("Beth has %d relationships before the removal, want the relationship and its mirror", n)
+	}
+
+	beforeA := dbtest.VersionToken(t, database, user.ID, a.UID)
+	beforeB := dbtest.VersionToken(t, database, user.ID, b.UID)
+
+	if err := database.RemoveRelationship(user.ID, rel.ID); err != nil {
+		t.Fatalf("RemoveRelationship: %v", err)
+	}
+
+	for _, c := range []*models.Contact{a, b} {
+		if got := relationships(c.ID); len(got) != 0 {
+			t.Errorf("%s still has relationships %+v", c.FullName, got)
+		}
+	}
+	if after := dbtest.VersionToken(t, database, user.ID, a.UID); after <= beforeA {
+		t.Errorf("Adam's version_token = %d, want more than %d", after, beforeA)
+	}
+	if after := dbtest.VersionToken(t, database, user.ID, b.UID); after <= beforeB {
+		t.Errorf("Beth's version_token = %d, want more than %d", after, beforeB)
+	}
+
+	if err := database.RemoveRelationship(user.ID, rel.ID); err == nil || err.Error() != "not found" {
+		t.Errorf("repeated removal: err = %v, want not found", err)
+	}
+}
@@ -11,6 +11,7 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -40,6 +41,68 @@ func (d *Database) DeleteAllContacts(userID int) error {
 	return nil
 }
 
+// ExcludeContactsWithoutContactMethods sets exclude_from_sync on every contact that has
+// no email, phone, or address. Returns the number of contacts updated
+func (d *Database) ExcludeContactsWithoutContactMethods(userID int) (int, error) {
+	logger.Debug("[DATABASE] Begin ExcludeContactsWithoutContactMethods(userID:%d)", userID)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE contacts c SET exclude_from_sync = true, updated_at = NOW()
+		WHERE c.user_id = $1
+			AND c.deleted_at IS NULL
+			AND c.exclude_from_sync = false
+			AND NOT EXISTS (SELECT 1 FROM emails e WHERE e.contact_id = c.id)
+			AND NOT EXISTS (SELECT 1 FROM phones p WHERE p.contact_id = c.id)
+			AND NOT EXISTS (SELECT 1 FROM addresses a WHERE a.contact_id = c.id)
+		RETURNING c.id
+	`, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error updating contacts: %v", err)
+		return 0, err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			logger.Error("[DATABASE] Error scanning contacts: %v", err)
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	// The transaction can't run the sync token update while the rows are open
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logger.Error("[DATABASE] Error updating contacts: %v", err)
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := bumpSyncTokens(tx, userID, ids...); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	d.contactsUpdated(userID, ids...)
+
+	return len(ids), nil
+}
+
 // FindDuplicateContacts finds potential duplicate contacts
 func (d *Database) FindDuplicateContacts(userID int) ([]models.DuplicateGroup, error) {
 	logger.Debug("[DATABASE] Begin FindDuplicateContacts(userID:%d)", userID)
@@ -60,13 +123,15 @@ func (d *Database) FindDuplicateContacts(userID int) ([]models.DuplicateGroup, e
 				END as match_type
 			FROM contacts c1
 			JOIN contacts c2 ON c1.user_id = c2.user_id AND c1.id < c2.id
-			LEFT JOIN contact_emails e1 ON e1.contact_id = c1.id
-			LEFT JOIN contact_emails e2 ON e2.contact_id = c2.id
+			LEFT JOIN emails e1 ON e1.contact_id = c1.id
+			LEFT JOIN emails e2 ON e2.contact_id = c2.id AND LOWER(e2.email) = LOWER(e1.email)
 			WHERE c1.user_id = $1
+			AND c1.deleted_at IS NULL
+			AND c2.deleted_at IS NULL
 			AND (
 				(LOWER(c1.given_name) = LOWER(c2.given_name) 
 				 AND LOWER(COALESCE(c1.family_name, '')) = LOWER(COALESCE(c2.family_name, '')))
-				OR (e1.email IS NOT NULL AND LOWER(e1.email) = LOWER(e2.email))
+				OR (e1.email IS NOT NULL AND e1.email <> '' AND e2.id IS NOT NULL)
 			)
 		)
 		SELECT DISTINCT contact1_id, name1, contact2_id, name2, match_type
@@ -93,6 +158,262 @@ func (d *Database) FindDuplicateContacts(userID int) ([]models.DuplicateGroup, e
 	return duplicates, nil
 }
 
+// mergeChildRows moves a secondary contact's detail rows to the primary ($1 primary, $2 secondary),
+// skipping rows the primary already has. is_primary is kept only when the primary has no primary
+// row of that kind. Skipped duplicates stay with the soft-deleted secondary
+var mergeChildRows = []struct {
+	table string
+	query string
+}{
+	{"emails", `
+		UPDATE emails s SET contact_id = $1,
+			is_primary = s.is_primary AND NOT EXISTS (SELECT 1 FROM emails p WHERE p.contact_id = $1 AND p.is_primary)
+		WHERE s.contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM emails p WHERE p.contact_id = $1 AND LOWER(p.email) = LOWER(s.email))`},
+	{"phones", `
+		UPDATE phones s SET contact_id = $1,
+			is_primary = s.is_primary AND NOT EXISTS (SELECT 1 FROM phones p WHERE p.contact_id = $1 AND p.is_primary)
+		WHERE s.contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM phones p WHERE p.contact_id = $1
+			AND regexp_replace(p.phone, '\D', '', 'g') = regexp_replace(s.phone, '\D', '', 'g'))`},
+	{"addresses", `
+		UPDATE addresses s SET contact_id = $1,
+			is_primary = s.is_primary AND NOT EXISTS (SELECT 1 FROM addresses p WHERE p.contact_id = $1 AND p.is_primary)
+		WHERE s.contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM addresses p WHERE p.contact_id = $1
+			AND p.street IS NOT DISTINCT FROM s.street
+			AND p.extended_street IS NOT DISTINCT FROM s.extended_street
+			AND p.city IS NOT DISTINCT FROM s.city
+			AND p.state IS NOT DISTINCT FROM s.state
+			AND p.postal_code IS NOT DISTINCT FROM s.postal_code
+			AND p.country IS NOT DISTINCT FROM s.country)`},
+	{"urls", `
+		UPDATE urls s SET contact_id = $1
+		WHERE s.contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM urls p WHERE p.contact_id = $1 AND p.url = s.url)`},
+	{"organizations", `
+		UPDATE organizations s SET contact_id = $1,
+			is_primary = s.is_primary AND NOT EXISTS (SELECT 1 FROM organizations p WHERE p.contact_id = $1 AND p.is_primary)
+		WHERE s.contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM organizations p WHERE p.contact_id = $1
+			AND p.name IS NOT DISTINCT FROM s.name
+			AND p.title IS NOT DISTINCT FROM s.title
+			AND p.department IS NOT DISTINCT FROM s.department
+			AND p.role IS NOT DISTINCT FROM s.role)`},
+	{"impps", `
+		UPDATE impps s SET contact_id = $1,
+			is_primary = s.is_primary AND NOT EXISTS (SELECT 1 FROM impps p WHERE p.contact_id = $1 AND p.is_primary)
+		WHERE s.contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM impps p WHERE p.contact_id = $1
+			AND p.service IS NOT DISTINCT FROM s.service AND p.handle = s.handle)`},
+	{"other_dates", `
+		UPDATE other_dates s SET contact_id = $1
+		WHERE s.contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM other_dates p WHERE p.contact_id = $1
+			AND p.event_name IS NOT DISTINCT FROM s.event_name
+			AND p.event_date IS NOT DISTINCT FROM s.event_date
+			AND p.event_date_month IS NOT DISTINCT FROM s.event_date_month
+			AND p.event_date_day IS NOT DISTINCT FROM s.event_date_day)`},
+	{"other_relationships", `
+		UPDATE other_relationships s SET contact_id = $1
+		WHERE s.contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM other_relationships p WHERE p.contact_id = $1
+			AND p.related_contact_name IS NOT DISTINCT FROM s.related_contact_name
+			AND p.relationship_name IS NOT DISTINCT FROM s.relationship_name)`},
+	{"contact_tags", `
+		INSERT INTO contact_tags (contact_id, tag_id, position)
+		SELECT $1, tag_id, (SELECT COALESCE(MAX(position) + 1, 0) FROM contact_tags WHERE contact_id = $1) + position
+		FROM contact_tags WHERE contact_id = $2
+		ON CONFLICT DO NOTHING`},
+}
+
+// mergeScalarFields fills the primary contact's empty fields ($1) from the secondary ($2). Dates and
+// the avatar are copied as a whole so a full date isn't mixed with another contact's partial one
+const mergeScalarFields = `
+	UPDATE contacts p SET
+		full_name = COALESCE(NULLIF(p.full_name, ''), s.full_name),
+		given_name = COALESCE(NULLIF(p.given_name, ''), s.given_name),
+		family_name = COALESCE(NULLIF(p.family_name, ''), s.family_name),
+		middle_name = COALESCE(NULLIF(p.middle_name, ''), s.middle_name),
+		prefix = COALESCE(NULLIF(p.prefix, ''), s.prefix),
+		suffix = COALESCE(NULLIF(p.suffix, ''), s.suffix),
+		nickname = COALESCE(NULLIF(p.nickname, ''), s.nickname),
+		maiden_name = COALESCE(NULLIF(p.maiden_name, ''), s.maiden_name),
+		salutation = COALESCE(NULLIF(p.salutation, ''), s.salutation),
+		phonetic_first_name = COALESCE(NULLIF(p.phonetic_first_name, ''), s.phonetic_first_name),
+		pronunciation_first_name = COALESCE(NULLIF(p.pronunciation_first_name, ''), s.pronunciation_first_name),
+		phonetic_middle_name = COALESCE(NULLIF(p.phonetic_middle_name, ''), s.phonetic_middle_name),
+		phonetic_last_name = COALESCE(NULLIF(p.phonetic_last_name, ''), s.phonetic_last_name),
+		pronunciation_last_name = COALESCE(NULLIF(p.pronunciation_last_name, ''), s.pronunciation_last_name),
+		gender = COALESCE(NULLIF(p.gender, ''), s.gender),
+		notes = COALESCE(NULLIF(p.notes, ''), s.notes),
+		reminder_lead_days = COALESCE(p.reminder_lead_days, s.reminder_lead_days),
+		contact_frequency_days = COALESCE(p.contact_frequency_days, s.contact_frequency_days),
+		last_contacted_at = GREATEST(p.last_contacted_at, s.last_contacted_at),
+		latitude = CASE WHEN p.latitude IS NULL THEN s.latitude ELSE p.latitude END,
+		longitude = CASE WHEN p.latitude IS NULL THEN s.longitude ELSE p.longitude END,
+		timezone = COALESCE(NULLIF(p.timezone, ''), s.timezone),
+		raw_vcard_extras = COALESCE(p.raw_vcard_extras, s.raw_vcard_extras),
+		kind = CASE WHEN p.kind = 'individual' THEN s.kind ELSE p.kind END,
+		languages = CASE WHEN COALESCE(cardinality(p.languages), 0) = 0 THEN s.languages ELSE p.languages END,
+		birthday = CASE WHEN ` + mergeBirthdayEmpty + ` THEN s.birthday ELSE p.birthday END,
+		birthday_month = CASE WHEN ` + mergeBirthdayEmpty + ` THEN s.birthday_month ELSE p.birthday_month END,
+		birthday_day = CASE WHEN ` + mergeBirthdayEmpty + ` THEN s.birthday_day ELSE p.birthday_day END,
+		birthday_year = CASE WHEN ` + mergeBirthdayEmpty + ` THEN s.birthday_year ELSE p.birthday_year END,
+		anniversary = CASE WHEN ` + mergeAnniversaryEmpty + ` THEN s.anniversary ELSE p.anniversary END,
+		anniversary_month = CASE WHEN ` + mergeAnniversaryEmpty + ` THEN s.anniversary_month ELSE p.anniversary_month END,
+		anniversary_day = CASE WHEN ` + mergeAnniversaryEmpty + ` THEN s.anniversary_day ELSE p.anniversary_day END,
+		anniversary_year = CASE WHEN ` + mergeAnniversaryEmpty + ` THEN s.anniversary_year ELSE p.anniversary_year END,
+		avatar_base64 = CASE WHEN ` + mergeAvatarEmpty + ` THEN s.avatar_base64 ELSE p.avatar_base64 END,
+		avatar_mime_type = CASE WHEN ` + mergeAvatarEmpty + ` THEN s.avatar_mime_type ELSE p.avatar_mime_type END,
+		avatar_original_base64 = CASE WHEN ` + mergeAvatarEmpty + ` THEN s.avatar_original_base64 ELSE p.avatar_original_base64 END,
+		avatar_original_mime_type = CASE WHEN ` + mergeAvatarEmpty + ` THEN s.avatar_original_mime_type ELSE p.avatar_original_mime_type END,
+		updated_at = NOW()
+	FROM contacts s
+	WHERE p.id = $1 AND s.id = $2`
+
+const (
+	mergeBirthdayEmpty    = `(p.birthday IS NULL AND p.birthday_month IS NULL AND p.birthday_year IS NULL)`
+	mergeAnniversaryEmpty = `(p.anniversary IS NULL AND p.anniversary_month IS NULL AND p.anniversary_year IS NULL)`
+	mergeAvatarEmpty      = `COALESCE(p.avatar_base64, '') = ''`
+)
+
+// MergeContacts folds a duplicate (secondary) contact into the primary one: detail rows, tags and
+// relationships move over without duplicating what the primary has, empty fields on the primary
+// are filled from the secondary, and the secondary goes to the trash without its moved rows.
+// Returns "not found" unless both are the user's live contacts. Once the merge commits, the
+// secondary is reported to ContactChangeHook as deleted and every contact it touched as updated
+func (d *Database) MergeContacts(userID int, primaryID int, secondaryID int) error {
+	logger.Debug("[DATABASE] Begin MergeContacts(userID:%d, primaryID:%d, secondaryID:%d)", userID, primaryID, secondaryID)
+
+	if primaryID == secondaryID {
+		return errors.New("cannot merge a contact into itself")
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT id FROM contacts WHERE id IN ($1, $2) AND user_id = $3 AND deleted_at IS NULL FOR UPDATE
+		) c`, primaryID, secondaryID, userID,
+	).Scan(&found)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts: %v", err)
+		return err
+	}
+	if found != 2 {
+		return errors.New("not found")
+	}
+
+	// Everyone linked to the secondary sees the link change, so their cards change too
+	rows, err := tx.Query(`
+		SELECT DISTINCT CASE WHEN contact_id = $1 THEN related_contact_id ELSE contact_id END
+		FROM relationships
+		WHERE (contact_id = $1 OR related_contact_id = $1)
+		AND NOT (contact_id = $2 OR related_contact_id = $2)`, secondaryID, primaryID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting relationships: %v", err)
+		return err
+	}
+	affectedIDs := []int{primaryID}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			logger.Error("[DATABASE] Error scanning relationships: %v", err)
+			return err
+		}
+		affectedIDs = append(affectedIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, child := range mergeChildRows {
+		if _, err := tx.Exec(child.query, primaryID, secondaryID); err != nil {
+			logger.Error("[DATABASE] Error merging %s: %v", child.table, err)
+			return fmt.Errorf("failed to merge %s: %w", child.table, err)
+		}
+	}
+
+	if _, err := tx.Exec(mergeScalarFields, primaryID, secondaryID); err != nil {
+		logger.Error("[DATABASE] Error merging contact fields: %v", err)
+		return fmt.Errorf("failed to merge contact fields: %w", err)
+	}
+
+	// Links between the two would become self-links; links the primary already has are left
+	// behind and dropped with the secondary's below
+	if _, err := tx.Exec(`
+		DELETE FROM relationships
+		WHERE (contact_id = $1 AND related_contact_id = $2) OR (contact_id = $2 AND related_contact_id = $1)`,
+		primaryID, secondaryID); err != nil {
+		logger.Error("[DATABASE] Error deleting relationships: %v", err)
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE relationships s SET contact_id = $1
+		WHERE s.contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM relationships p WHERE p.contact_id = $1
+			AND p.related_contact_id = s.related_contact_id AND p.relationship_type_id = s.relationship_type_id)`,
+		primaryID, secondaryID); err != nil {
+		logger.Error("[DATABASE] Error moving relationships: %v", err)
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE relationships s SET related_contact_id = $1
+		WHERE s.related_contact_id = $2
+		AND NOT EXISTS (SELECT 1 FROM relationships p WHERE p.related_contact_id = $1
+			AND p.contact_id = s.contact_id AND p.relationship_type_id = s.relationship_type_id)`,
+		primaryID, secondaryID); err != nil {
+		logger.Error("[DATABASE] Error moving relationships: %v", err)
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM relationships WHERE contact_id = $1 OR related_contact_id = $1", secondaryID); err != nil {
+		logger.Error("[DATABASE] Error deleting relationships: %v", err)
+		return err
+	}
+
+	newSyncToken, err := incrementSyncToken(tx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment sync token: %w", err)
+	}
+
+	for _, id := range affectedIDs {
+		if err := setContactSyncToken(tx, id, newSyncToken); err != nil {
+			return err
+		}
+	}
+
+	// Soft-delete the secondary as DeleteContact does, so clients see it removed. Its detail rows,
+	// tags, and relationships now belong to the primary, so restoring it from the trash brings
+	// back only its own fields
+	if _, err := tx.Exec(`
+		UPDATE contacts
+		SET deleted_at = NOW(), version_token = $1, last_modified_token = $1, etag = $2
+		WHERE id = $3`,
+		newSyncToken, fmt.Sprintf("DEL-%d", newSyncToken), secondaryID); err != nil {
+		logger.Error("[DATABASE] Error soft-deleting contact: %v", err)
+		return fmt.Errorf("failed to soft-delete contact: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, id := range affectedIDs {
+		d.contactChanged(userID, models.WebhookEventContactUpdated, id)
+	}
+	d.contactChanged(userID, models.WebhookEventContactDeleted, secondaryID)
+	return nil
+}
+
 // ========================================
 // NOTIFICATION SETTINGS
 // ========================================
@@ -261,7 +582,7 @@ func (d *Database) UpdateNotificationSetting(userID int, notifier *models.Notifi
 			name = $1,
 			provider_type = $2,
 			webhook_url = $3,
-			target_address = $4
+			target_address = $4,
 			days_look_ahead = $5,
 			notification_time = $6,
 			include_birthdays = $7,
@@ -393,13 +714,13 @@ func (d *Database) GetUserPreferences(userID int) (*models.User, error) {
 	var userPrefs models.User
 
 	query := `
-		SELECT theme
+		SELECT theme, nickname_as_display_name, carddav_display_name, default_event_lookahead_days
 		FROM users
 		WHERE id = $1
 		LIMIT 1
 	`
 
-	err := d.db.QueryRow(query, userID).Scan(&userPrefs.Theme)
+	err := d.db.QueryRow(query, userID).Scan(&userPrefs.Theme, &userPrefs.NicknameAsDisplayName, &userPrefs.CardDAVDisplayName, &userPrefs.EventLookaheadDays)
 
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting user preferences: %v", err)
@@ -512,7 +833,7 @@ func (d *Database) GetContactStats(userID int) (*models.ContactStats, error) {
 	return stats, nil
 }
 
-// UpdateUserPreferences updates user theme and contacts per page
+// UpdateUserPreferences updates user theme and display preferences
 func (d *Database) UpdateUserPreferences(user models.User) error {
 	logger.Debug("[DATABASE] Begin UpdateUserPreferences(user:--)")
 
@@ -524,8 +845,11 @@ func (d *Database) UpdateUserPreferences(user models.User) error {
 	_, err := d.db.Exec(`
 		UPDATE users 
 		SET 
-			theme = $1
-		WHERE id = $2`,
-		user.Theme, user.ID)
+			theme = $1,
+			nickname_as_display_name = $2,
+			carddav_display_name = $3,
+			default_event_lookahead_days = $4
+		WHERE id = $5`,
+		user.Theme, user.NicknameAsDisplayName, user.CardDAVDisplayName, user.EventLookaheadDays, user.ID)
 	return err
 }
@@ -0,0 +1,157 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestUpdateNotificationSettingPersists(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	target := "old@example.com"
+	notifier := &models.NotificationSetting{
+		Name:             "Email digest",
+		ProviderType:     "smtp",
+		TargetAddress:    &target,
+		DaysLookAhead:    7,
+		NotificationTime: "08:00",
+		IncludeBirthdays: true,
+		Enabled:          true,
+	}
+	id, err := database.CreateNotificationSetting(user.ID, notifier)
+	if err != nil {
+		t.Fatalf("CreateNotificationSetting: %v", err)
+	}
+
+	newTarget := "new@example.com"
+	notifier.ID = id
+	notifier.TargetAddress = &newTarget
+	notifier.DaysLookAhead = 21
+	if err := database.UpdateNotificationSetting(user.ID, notifier); err != nil {
+		t.Fatalf("UpdateNotificationSetting: %v", err)
+	}
+
+	got, err := database.GetNotificationSettingByID(user.ID, id)
+	if err != nil {
+		t.Fatalf("GetNotificationSettingByID: %v", err)
+	}
+	if got.DaysLookAhead != 21 {
+		t.Errorf("DaysLookAhead = %d, want 21", got.DaysLookAhead)
+	}
+	if got.TargetAddress == nil || *got.TargetAddress != newTarget {
+		t.Errorf("TargetAddress = %v, want %q", got.TargetAddress, newTarget)
+	}
+	if got.Name != notifier.Name || got.NotificationTime != notifier.NotificationTime || !got.IncludeBirthdays {
+		t.Errorf("unchanged fields were altered: %+v", got)
+	}
+}
+
+func TestFindDuplicateContactsByEmail(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+	other := dbtest.NewUser(t, database)
+
+	shared := func(name string, email string) *models.Contact {
+		return &models.Contact{
+			FullName:  name,
+			GivenName: name,
+			Emails:    []models.Email{{Email: email, TypeLabel: "home"}},
+		}
+	}
+
+	a := dbtest.NewContact(t, database, user.ID, shared("Alice", "alice@example.com"))
+	b := dbtest.NewContact(t, database, user.ID, shared("Ally", "ALICE@example.com"))
+	deleted := dbtest.NewContact(t, database, user.ID, shared("Al", "alice@example.com"))
+	dbtest.NewContact(t, database, other.ID, shared("Someone Else", "alice@example.com"))
+
+	if err := database.DeleteContact(user.ID, deleted.ID); err != nil {
+		t.Fatalf("DeleteContact: %v", err)
+	}
+
+	dupes, err := database.FindDuplicateContacts(user.ID)
+	if err != nil {
+		t.Fatalf("FindDuplicateContacts: %v", err)
+	}
+	if len(dupes) != 1 {
+		t.Fatalf("got %d duplicate groups, want 1: %+v", len(dupes), dupes)
+	}
+	got := dupes[0]
+	if got.Contact1ID != a.ID || got.Contact2ID != b.ID || got.MatchType != "email" {
+		t.Errorf("duplicate = %+v, want contacts %d and %d matched by email", got, a.ID, b.ID)
+	}
+}
+
+func TestMergeContacts(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	primary := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:  "Alice Liddell",
+		GivenName: "Alice",
+		Emails: []models.Email{
+			{Email: "alice@example.com", TypeLabel: "home", IsPrimary: true},
+		},
+	})
+	secondary := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:  "Alice L.",
+		GivenName: "Alice",
+		Nickname:  "Ali",
+		Emails: []models.Email{
+			{Email: "ALICE@example.com", TypeLabel: "home", IsPrimary: true},
+			{Email: "alice@work.example.com", TypeLabel: "work"},
+		},
+	})
+
+	if err := database.MergeContacts(user.ID, primary.ID, secondary.ID); err != nil {
+		t.Fatalf("MergeContacts: %v", err)
+	}
+
+	merged, err := database.GetContactByID(user.ID, primary.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID(primary): %v", err)
+	}
+	var emails []string
+	primaries := 0
+	for _, e := range merged.Emails {
+		emails = append(emails, e.Email)
+		if e.IsPrimary {
+			primaries++
+		}
+	}
+	if len(emails) != 2 {
+		t.Errorf("merged emails = %q, want alice@example.com and alice@work.example.com", emails)
+	}
+	for _, want := range []string{"alice@example.com", "alice@work.example.com"} {
+		found := false
+		for _, got := range emails {
+			found = found || got == want
+		}
+		if !found {
+			t.Errorf("merged emails = %q, missing %q", emails, want)
+		}
+	}
+	if primaries != 1 {
+		t.Errorf("merged contact has %d primary emails, want 1", primaries)
+	}
+	if merged.FullName != "Alice Liddell" || merged.Nickname != "Ali" {
+		t.Errorf("merged names = %q / %q, want the primary's full name and the secondary's nickname", merged.FullName, merged.Nickname)
+	}
+
+	if _, err := database.GetContactByID(user.ID, secondary.ID); err == nil {
+		t.Error("secondary contact is still live after the merge")
+	}
+	trashed, err := database.ListDeletedContacts(user.ID)
+	if err != nil {
+		t.Fatalf("ListDeletedContacts: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != secondary.ID {
+		t.Errorf("trash = %+v, want only the secondary contact %d", trashed, secondary.ID)
+	}
+
+	if err := database.MergeContacts(user.ID, primary.ID, secondary.ID); err == nil || err.Error() != "not found" {
+		t.Errorf("merging a trashed contact: err = %v, want not found", err)
+	}
+}
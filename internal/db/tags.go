@@ -0,0 +1,267 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/steveredden/KindredCard/internal/logger"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// AddTag tags a contact, creating the tag for the user if it doesn't exist yet. Tag names are
+// matched case-insensitively; re-adding an existing tag is a no-op
+func (d *Database) AddTag(userID int, contactID int, name string) error {
+	logger.Debug("[DATABASE] Begin AddTag(userID:%d, contactID:%d, name:%s)", userID, contactID, name)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM contacts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)",
+		contactID, userID,
+	).Scan(&exists)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contact: %v", err)
+		return err
+	}
+	if !exists {
+		return errors.New("not found")
+	}
+
+	if err := insertTags(tx, userID, contactID, []string{name}); err != nil {
+		return err
+	}
+
+	newSyncToken, err := incrementSyncToken(tx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment sync token: %w", err)
+	}
+
+	if err := setContactSyncToken(tx, contactID, newSyncToken); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactsUpdated(userID, contactID)
+	return nil
+}
+
+// RemoveTag removes a tag from a contact. Returns "not found" when the contact doesn't have the tag.
+// Tags no longer used by any contact are deleted
+func (d *Database) RemoveTag(userID int, contactID int, name string) error {
+	logger.Debug("[DATABASE] Begin RemoveTag(userID:%d, contactID:%d, name:%s)", userID, contactID, name)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		DELETE FROM contact_tags
+		WHERE contact_id = $1
+		AND contact_id IN (SELECT id FROM contacts WHERE user_id = $2)
+		AND tag_id IN (SELECT id FROM tags WHERE user_id = $2 AND LOWER(name) = LOWER($3))
+	`, contactID, userID, strings.TrimSpace(name))
+	if err != nil {
+		logger.Error("[DATABASE] Error deleting contact tag: %v", err)
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM tags t
+		WHERE t.user_id = $1
+		AND NOT EXISTS (SELECT 1 FROM contact_tags ct WHERE ct.tag_id = t.id)
+	`, userID); err != nil {
+		logger.Error("[DATABASE] Error deleting unused tags: %v", err)
+		return err
+	}
+
+	newSyncToken, err := incrementSyncToken(tx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment sync token: %w", err)
+	}
+
+	if err := setContactSyncToken(tx, contactID, newSyncToken); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactsUpdated(userID, contactID)
+	return nil
+}
+
+// GetTags returns a contact's tags in the order they were added
+func (d *Database) GetTags(contactID int) ([]models.Tag, error) {
+	logger.Debug("[DATABASE] Begin GetTags(contactID:%d)", contactID)
+
+	rows, err := d.db.Query(`
+		SELECT t.id, t.name, t.created_at
+		FROM tags t
+		JOIN contact_tags ct ON ct.tag_id = t.id
+		WHERE ct.contact_id = $1
+		ORDER BY ct.position, LOWER(t.name)
+	`, contactID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting tags: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt); err != nil {
+			logger.Error("[DATABASE] Error scanning tags: %v", err)
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// ListContactsByTag retrieves the contacts (with related data) carrying a tag, sorted by name
+func (d *Database) ListContactsByTag(userID int, tag string) ([]*models.Contact, error) {
+	logger.Debug("[DATABASE] Begin ListContactsByTag(userID:%d, tag:%s)", userID, tag)
+
+	query := `SELECT ` + contactColumns + ` FROM contacts
+		WHERE user_id = $1 AND deleted_at IS NULL
+		AND id IN (` + contactTagFilter + `)
+		ORDER BY full_name, id`
+
+	rows, err := d.db.Query(query, userID, strings.TrimSpace(tag))
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []*models.Contact{}
+	ids := []int{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			logger.Error("[DATABASE] Error scanning contacts: %v", err)
+			return nil, err
+		}
+		contact.UserID = userID
+		contacts = append(contacts, contact)
+		ids = append(ids, contact.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := d.loadContactRelations(contacts, ids); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// contactTagFilter selects the IDs of contacts tagged $2 for user $1
+const contactTagFilter = `
+	SELECT ct.contact_id FROM contact_tags ct
+	JOIN tags t ON ct.tag_id = t.id
+	WHERE t.user_id = $1 AND LOWER(t.name) = LOWER($2)`
+
+// insertTags links tags to a contact after the ones it already has, in order, creating any tags
+// the user doesn't have yet
+func insertTags(tx *tracedTx, userID int, contactID int, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	var position int
+	err := tx.QueryRow(
+		"SELECT COALESCE(MAX(position) + 1, 0) FROM contact_tags WHERE contact_id = $1", contactID,
+	).Scan(&position)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting tag position: %v", err)
+		return err
+	}
+
+	for _, name := range tags {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		var tagID int
+		err := tx.QueryRow(`
+			INSERT INTO tags (user_id, name) VALUES ($1, $2)
+			ON CONFLICT (user_id, LOWER(name)) DO UPDATE SET name = tags.name
+			RETURNING id`,
+			userID, name,
+		).Scan(&tagID)
+		if err != nil {
+			logger.Error("[DATABASE] Error inserting tag: %v", err)
+			return err
+		}
+
+		if _, err := tx.Exec(
+			"INSERT INTO contact_tags (contact_id, tag_id, position) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+			contactID, tagID, position,
+		); err != nil {
+			logger.Error("[DATABASE] Error inserting contact tag: %v", err)
+			return err
+		}
+		position++
+	}
+	return nil
+}
+
+// getTagsByContacts loads tag names for several contacts at once, keyed by contact ID
+func (d *Database) getTagsByContacts(contactIDs []int) (map[int][]string, error) {
+	rows, err := d.db.Query(`
+		SELECT ct.contact_id, t.name
+		FROM contact_tags ct
+		JOIN tags t ON ct.tag_id = t.id
+		WHERE ct.contact_id = ANY($1)
+		ORDER BY ct.position, LOWER(t.name)`, pq.Array(contactIDs))
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting tags: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[int][]string)
+	for rows.Next() {
+		var contactID int
+		var name string
+		if err := rows.Scan(&contactID, &name); err != nil {
+			logger.Error("[DATABASE] Error scanning tags: %v", err)
+			return nil, err
+		}
+		tags[contactID] = append(tags[contactID], name)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,51 @@
+package db_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestTagsKeepOrder(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	contact := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice", Tags: []string{"Work", "Friends"}})
+	if err := database.AddTag(user.ID, contact.ID, "Book Club"); err != nil {
+		t.Fatalf("AddTag: %v", err)
+	}
+	want := "Work,Friends,Book Club"
+
+	loaded, err := database.GetContactByID(user.ID, contact.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if got := strings.Join(loaded.Tags, ","); got != want {
+		t.Errorf("GetContactByID tags = %q, want %q", got, want)
+	}
+
+	all, err := database.GetAllContacts(user.ID, false)
+	if err != nil {
+		t.Fatalf("GetAllContacts: %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("GetAllContacts returned %d contacts, want 1", len(all))
+	}
+	if got := strings.Join(all[0].Tags, ","); got != want {
+		t.Errorf("GetAllContacts tags = %q, want %q", got, want)
+	}
+
+	tags, err := database.GetTags(contact.ID)
+	if err != nil {
+		t.Fatalf("GetTags: %v", err)
+	}
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("GetTags = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/logger"
+)
+
+// maxTracedArgLength truncates long argument values (e.g. avatars) in trace output
+const maxTracedArgLength = 64
+
+// sensitiveSQLMarkers flag statements whose string arguments must never be logged
+var sensitiveSQLMarkers = []string{"password", "token", "secret", "webhook_url", "key_hash"}
+
+// tracedDB wraps *sql.DB and logs each Query/QueryRow/Exec with its arguments
+// when LOG_LEVEL=TRACE. Transactions it begins are traced the same way
+type tracedDB struct {
+	*sql.DB
+}
+
+// tracedTx wraps *sql.Tx and logs its statements as tracedDB does
+type tracedTx struct {
+	*sql.Tx
+}
+
+func (t *tracedDB) Begin() (*tracedTx, error) {
+	tx, err := t.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{Tx: tx}, nil
+}
+
+func (t *tracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*tracedTx, error) {
+	tx, err := t.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{Tx: tx}, nil
+}
+
+func (t *tracedDB) Query(query string, args ...any) (*sql.Rows, error) {
+	if logger.GetLevel() != logger.TRACE {
+		return t.DB.Query(query, args...)
+	}
+	start := time.Now()
+	rows, err := t.DB.Query(query, args...)
+	traceQuery("Query", query, args, start, err)
+	return rows, err
+}
+
+func (t *tracedDB) QueryRow(query string, args ...any) *sql.Row {
+	if logger.GetLevel() != logger.TRACE {
+		return t.DB.QueryRow(query, args...)
+	}
+	start := time.Now()
+	row := t.DB.QueryRow(query, args...)
+	traceQuery("QueryRow", query, args, start, row.Err())
+	return row
+}
+
+func (t *tracedDB) Exec(query string, args ...any) (sql.Result, error) {
+	if logger.GetLevel() != logger.TRACE {
+		return t.DB.Exec(query, args...)
+	}
+	start := time.Now()
+	result, err := t.DB.Exec(query, args...)
+	traceQuery("Exec", query, args, start, err)
+	return result, err
+}
+
+func (t *tracedTx) Query(query string, args ...any) (*sql.Rows, error) {
+	if logger.GetLevel() != logger.TRACE {
+		return t.Tx.Query(query, args...)
+	}
+	start := time.Now()
+	rows, err := t.Tx.Query(query, args...)
+	traceQuery("Tx.Query", query, args, start, err)
+	return rows, err
+}
+
+func (t *tracedTx) QueryRow(query string, args ...any) *sql.Row {
+	if logger.GetLevel() != logger.TRACE {
+		return t.Tx.QueryRow(query, args...)
+	}
+	start := time.Now()
+	row := t.Tx.QueryRow(query, args...)
+	traceQuery("Tx.QueryRow", query, args, start, row.Err())
+	return row
+}
+
+func (t *tracedTx) Exec(query string, args ...any) (sql.Result, error) {
+	if logger.GetLevel() != logger.TRACE {
+		return t.Tx.Exec(query, args...)
+	}
+	start := time.Now()
+	result, err := t.Tx.Exec(query, args...)
+	traceQuery("Tx.Exec", query, args, start, err)
+	return result, err
+}
+
+func (t *tracedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if logger.GetLevel() != logger.TRACE {
+		return t.Tx.ExecContext(ctx, query, args...)
+	}
+	start := time.Now()
+	result, err := t.Tx.ExecContext(ctx, query, args...)
+	traceQuery("Tx.ExecContext", query, args, start, err)
+	return result, err
+}
+
+// traceQuery logs a statement, its (redacted) arguments, duration, and error
+func traceQuery(method string, query string, args []any, start time.Time, err error) {
+	status := "ok"
+	if err != nil {
+		status = err.Error()
+	}
+	logger.Trace("[DATABASE] %s (%s) [%s] %s args=%s",
+		method, time.Since(start).Round(time.Microsecond), status, compactSQL(query), formatTraceArgs(query, args))
+}
+
+// compactSQL collapses whitespace so multi-line statements log on one line
+func compactSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// formatTraceArgs renders query arguments for logging; string arguments to statements
+// touching credentials are redacted, and long values are truncated
+func formatTraceArgs(query string, args []any) string {
+	lower := strings.ToLower(query)
+	sensitive := false
+	for _, marker := range sensitiveSQLMarkers {
+		if strings.Contains(lower, marker) {
+			sensitive = true
+			break
+		}
+	}
+
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		var s string
+		switch v := traceDeref(arg).(type) {
+		case string:
+			if sensitive {
+				s = "[REDACTED]"
+			} else {
+				s = fmt.Sprintf("%q", truncateTraceValue(v))
+			}
+		case []byte:
+			s = fmt.Sprintf("<%d bytes>", len(v))
+		default:
+			s = truncateTraceValue(fmt.Sprintf("%v", v))
+		}
+		parts[i] = fmt.Sprintf("$%d=%s", i+1, s)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// traceDeref shows the value behind common pointer arguments instead of an address
+func traceDeref(v any) any {
+	switch p := v.(type) {
+	case *string:
+		if p == nil {
+			return nil
+		}
+		return *p
+	case *int:
+		if p == nil {
+			return nil
+		}
+		return *p
+	case *bool:
+		if p == nil {
+			return nil
+		}
+		return *p
+	case *time.Time:
+		if p == nil {
+			return nil
+		}
+		return *p
+	}
+	return v
+}
+
+func truncateTraceValue(s string) string {
+	if len(s) <= maxTracedArgLength {
+		return s
+	}
+	return fmt.Sprintf("%s…(%d chars)", s[:maxTracedArgLength], len(s))
+}
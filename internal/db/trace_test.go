@@ -0,0 +1,113 @@
+package db
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/steveredden/KindredCard/internal/logger"
+)
+
+// captureLog sends log output to a buffer at level until the test ends
+func captureLog(t *testing.T, level logger.LogLevel) *bytes.Buffer {
+	t.Helper()
+
+	previous := logger.GetLevel()
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.SetLevel(level)
+	t.Cleanup(func() {
+		logger.SetLevel(previous)
+		logger.SetOutput(os.Stdout)
+	})
+	return &buf
+}
+
+// newMockTracedDB returns a tracedDB backed by sqlmock
+func newMockTracedDB(t *testing.T) (*tracedDB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return &tracedDB{DB: sqlDB}, mock
+}
+
+func TestTraceLogging(t *testing.T) {
+	const query = "UPDATE contacts SET notes = $1 WHERE id = $2"
+
+	t.Run("logged at TRACE", func(t *testing.T) {
+		tdb, mock := newMockTracedDB(t)
+		mock.ExpectExec("UPDATE contacts").WithArgs("hello", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		buf := captureLog(t, logger.TRACE)
+		if _, err := tdb.Exec(query, "hello", 7); err != nil {
+			t.Fatalf("Exec: %v", err)
+		}
+
+		out := buf.String()
+		for _, want := range []string{"[TRACE]", "[DATABASE] Exec", query, `$1="hello"`, "$2=7"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("trace output %q is missing %q", out, want)
+			}
+		}
+	})
+
+	t.Run("not logged at INFO", func(t *testing.T) {
+		tdb, mock := newMockTracedDB(t)
+		mock.ExpectExec("UPDATE contacts").WithArgs("hello", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		buf := captureLog(t, logger.INFO)
+		if _, err := tdb.Exec(query, "hello", 7); err != nil {
+			t.Fatalf("Exec: %v", err)
+		}
+
+		if buf.Len() != 0 {
+			t.Errorf("got log output at INFO: %q", buf.String())
+		}
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		tdb, mock := newMockTracedDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE contacts").WithArgs("hello", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		buf := captureLog(t, logger.TRACE)
+		tx, err := tdb.Begin()
+		if err != nil {
+			t.Fatalf("Begin: %v", err)
+		}
+		if _, err := tx.Exec(query, "hello", 7); err != nil {
+			t.Fatalf("Exec: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+
+		if !strings.Contains(buf.String(), "Tx.Exec") {
+			t.Errorf("statement in a transaction was not traced: %q", buf.String())
+		}
+	})
+
+	t.Run("sensitive values redacted", func(t *testing.T) {
+		tdb, mock := newMockTracedDB(t)
+		mock.ExpectExec("UPDATE users").WithArgs("hunter2", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		buf := captureLog(t, logger.TRACE)
+		if _, err := tdb.Exec("UPDATE users SET password_hash = $1 WHERE id = $2", "hunter2", 7); err != nil {
+			t.Fatalf("Exec: %v", err)
+		}
+
+		if strings.Contains(buf.String(), "hunter2") {
+			t.Errorf("password was logged: %q", buf.String())
+		}
+		if !strings.Contains(buf.String(), "[REDACTED]") {
+			t.Errorf("trace output %q doesn't show the redaction", buf.String())
+		}
+	})
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -18,7 +19,14 @@ func (d *Database) CreateContactURL(userID int, body models.URL) (int, error) {
 		utils.Dump(body)
 	}
 
-	err := d.db.QueryRow(
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		"INSERT INTO urls (contact_id, url, label_type_id) VALUES ($1, $2, $3) RETURNING id",
 		body.ContactID, body.URL, body.Type,
 	).Scan(&body.ID)
@@ -31,16 +39,17 @@ func (d *Database) CreateContactURL(userID int, body models.URL) (int, error) {
 		return 0, fmt.Errorf("failed to create url: %w", err)
 	}
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return body.ID, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, body.ContactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return 0, err
 	}
 
-	if err := d.bumpContactSyncToken(body.ContactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return 0, err
 	}
 
+	d.contactsUpdated(userID, body.ContactID)
+
 	return body.ID, nil
 }
 
@@ -93,8 +102,15 @@ func (d *Database) UpdateContactURL(userID int, body models.URLJSONPatch) ([]mod
 
 	args = append(args, body.ID, userID)
 
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var contactID int
-	err := d.db.QueryRow(query, args...).Scan(&contactID)
+	err = tx.QueryRow(query, args...).Scan(&contactID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			logger.Error("No rows patched: %v", err)
@@ -104,37 +120,185 @@ func (d *Database) UpdateContactURL(userID int, body models.URLJSONPatch) ([]mod
 		return nil, fmt.Errorf("failed to patch phone: %w", err)
 	}
 
-	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to increment sync token: %w", err)
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return nil, err
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
+	d.contactsUpdated(userID, contactID)
+
 	return d.getURLs(contactID)
 }
 
 func (d *Database) DeleteContactURL(userID int, contactID int, urlID int) error {
 	logger.Debug("[DATABASE] Begin DeleteContactURL(userID:%d, contactID:%d, urlID:%d)", userID, contactID, urlID)
 
-	_, err := d.db.Exec("DELETE FROM urls WHERE id = $1 AND contact_id = $2", urlID, contactID)
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM urls WHERE id = $1 AND contact_id = $2", urlID, contactID)
 	if err != nil {
 		logger.Error("[DATABASE] Error deleting URL: %v", err)
 		return err
 	}
 
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactsUpdated(userID, contactID)
+	return nil
+}
+
+// ErrImmichPersonLinked is returned when an Immich person is already linked to another contact
+var ErrImmichPersonLinked = errors.New("immich person is already linked to another contact")
+
+// LinkImmichPerson points a contact's immich URL at personURL, replacing any existing link on
+// that contact. Returns ErrImmichPersonLinked if another of the user's contacts already links
+// the same person
+func (d *Database) LinkImmichPerson(userID int, contactID int, personURL string) error {
+	logger.Debug("[DATABASE] Begin LinkImmichPerson(userID:%d, contactID:%d, personURL:%s)", userID, contactID, personURL)
+
+	immichTypeID, err := d.GetLabelID("immich", "url")
+	if err != nil {
+		return fmt.Errorf("immich url label not found: %w", err)
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM contacts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)",
+		contactID, userID,
+	).Scan(&exists)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contact: %v", err)
+		return err
+	}
+	if !exists {
+		return errors.New("not found")
+	}
+
+	// Compare person IDs rather than raw URLs so a changed IMMICH_URL still counts as linked
+	rows, err := tx.Query(`
+		SELECT u.contact_id, u.url
+		FROM urls u
+		JOIN contacts c ON u.contact_id = c.id
+		WHERE c.user_id = $1 AND c.deleted_at IS NULL AND u.label_type_id = $2 AND u.contact_id <> $3
+	`, userID, immichTypeID, contactID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting immich links: %v", err)
+		return err
+	}
+	personID := utils.ExtractIDFromImmichURL(personURL)
+	for rows.Next() {
+		var otherContactID int
+		var urlStr string
+		if err := rows.Scan(&otherContactID, &urlStr); err != nil {
+			rows.Close()
+			logger.Error("[DATABASE] Error scanning immich links: %v", err)
+			return err
+		}
+		if utils.ExtractIDFromImmichURL(urlStr) == personID {
+			rows.Close()
+			logger.Debug("[DATABASE] Immich person %s already linked to contact %d", personID, otherContactID)
+			return ErrImmichPersonLinked
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM urls WHERE contact_id = $1 AND label_type_id = $2", contactID, immichTypeID); err != nil {
+		logger.Error("[DATABASE] Error deleting immich link: %v", err)
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO urls (contact_id, url, label_type_id) VALUES ($1, $2, $3)",
+		contactID, personURL, immichTypeID,
+	); err != nil {
+		logger.Error("[DATABASE] Error inserting immich link: %v", err)
+		return err
+	}
+
 	// Sync token update
-	newSyncToken, err := d.IncrementAndGetNewSyncToken(userID)
+	newSyncToken, err := incrementSyncToken(tx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to increment sync token: %w", err)
 	}
 
-	if err := d.bumpContactSyncToken(contactID, newSyncToken); err != nil {
-		logger.Warn("[DATABASE] Failed to bump contact sync token: %v", err)
+	if err := setContactSyncToken(tx, contactID, newSyncToken); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.contactsUpdated(userID, contactID)
+	return nil
+}
+
+// UnlinkImmichPerson removes the immich URL from a contact. Returns "not found" when the
+// contact has no immich link
+func (d *Database) UnlinkImmichPerson(userID int, contactID int) error {
+	logger.Debug("[DATABASE] Begin UnlinkImmichPerson(userID:%d, contactID:%d)", userID, contactID)
+
+	immichTypeID, err := d.GetLabelID("immich", "url")
+	if err != nil {
+		return fmt.Errorf("immich url label not found: %w", err)
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		logger.Error("[DATABASE] Error starting tx: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		DELETE FROM urls
+		WHERE contact_id = $1 AND label_type_id = $2
+		AND contact_id IN (SELECT id FROM contacts WHERE user_id = $3)
+	`, contactID, immichTypeID, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error deleting immich link: %v", err)
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
+
+	if err := bumpSyncTokens(tx, userID, contactID); err != nil {
+		logger.Error("[DATABASE] Error bumping sync tokens: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	d.contactsUpdated(userID, contactID)
 	return nil
 }
@@ -11,25 +11,37 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/models"
 	"github.com/steveredden/KindredCard/internal/utils"
 )
 
-func (d *Database) GetContactsMissingGender(userID int) ([]models.Contact, error) {
-	logger.Debug("[DATABASE] Begin GetContactsMissingGender(userID:%d)", userID)
+func (d *Database) GetContactsMissingGender(userID int, limit int, offset int) ([]models.Contact, int, error) {
+	logger.Debug("[DATABASE] Begin GetContactsMissingGender(userID:%d, limit:%d, offset:%d)", userID, limit, offset)
 
+	var total int
+	err := d.db.QueryRow(`SELECT COUNT(*) FROM contacts
+	          WHERE user_id = $1 AND (gender IS NULL OR gender = '') AND deleted_at IS NULL`, userID).Scan(&total)
+	if err != nil {
+		logger.Error("[DATABASE] Error counting contacts missing gender: %v", err)
+		return nil, 0, fmt.Errorf("failed to count contacts missing gender: %w", err)
+	}
+
+	// id is a tiebreaker so pages are stable when names repeat
 	query := `SELECT id, full_name, given_name, family_name, avatar_base64, avatar_mime_type
 	          FROM contacts
 	          WHERE user_id = $1 AND (gender IS NULL OR gender = '') AND deleted_at IS NULL
-	          LIMIT 50` // Limit to 50 for page performance
+	          ORDER BY full_name, id
+	          LIMIT $2 OFFSET $3`
 
-	rows, err := d.db.Query(query, userID)
+	rows, err := d.db.Query(query, userID, limit, offset)
 	if err != nil {
 		logger.Error("[DATABASE] Error selecting contacts missing gender: %v", err)
-		return nil, fmt.Errorf("failed to get contacts missing gender: %w", err)
+		return nil, 0, fmt.Errorf("failed to get contacts missing gender: %w", err)
 	}
 	defer rows.Close()
 
@@ -44,7 +56,7 @@ func (d *Database) GetContactsMissingGender(userID int) ([]models.Contact, error
 		)
 		if err != nil {
 			logger.Error("[DATABASE] Error scanning contacts: %v", err)
-			return nil, fmt.Errorf("failed to scan contacts: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan contacts: %w", err)
 		}
 
 		c.FamilyName = utils.ScanNullString(family_name)
@@ -54,10 +66,47 @@ func (d *Database) GetContactsMissingGender(userID int) ([]models.Contact, error
 		contacts = append(contacts, c)
 	}
 
-	return contacts, nil
+	return contacts, total, nil
 
 }
 
+// GetContactsMissingAvatarWithEmail returns contacts without an avatar that have at least one email
+// Each contact carries a single email: the primary one, or the first if none is marked primary
+func (d *Database) GetContactsMissingAvatarWithEmail(userID int) ([]models.Contact, error) {
+	logger.Debug("[DATABASE] Begin GetContactsMissingAvatarWithEmail(userID:%d)", userID)
+
+	query := `
+		SELECT DISTINCT ON (c.id) c.id, c.full_name, e.id, e.email
+		FROM contacts c
+		JOIN emails e ON e.contact_id = c.id
+		WHERE c.user_id = $1 AND c.deleted_at IS NULL
+		  AND (c.avatar_base64 IS NULL OR c.avatar_base64 = '')
+		  AND e.email <> ''
+		ORDER BY c.id, e.is_primary DESC, e.id`
+
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contacts missing avatar: %v", err)
+		return nil, fmt.Errorf("failed to get contacts missing avatar: %w", err)
+	}
+	defer rows.Close()
+
+	contacts := []models.Contact{}
+	for rows.Next() {
+		var c models.Contact
+		var email models.Email
+		if err := rows.Scan(&c.ID, &c.FullName, &email.ID, &email.Email); err != nil {
+			logger.Error("[DATABASE] Error scanning contacts: %v", err)
+			return nil, fmt.Errorf("failed to scan contacts: %w", err)
+		}
+		email.ContactID = c.ID
+		c.Emails = []models.Email{email}
+		contacts = append(contacts, c)
+	}
+
+	return contacts, nil
+}
+
 func (d *Database) GetContactsWithPhones(userID int) ([]models.Contact, error) {
 	logger.Debug("[DATABASE] Begin GetContactsWithPhones(userID:%d)", userID)
 
@@ -67,7 +116,7 @@ func (d *Database) GetContactsWithPhones(userID int) ([]models.Contact, error) {
 			c.avatar_base64, c.avatar_mime_type
           FROM phones p
           JOIN contacts c on c.id = p.contact_id
-          WHERE c.user_id = $1
+          WHERE c.user_id = $1 AND c.deleted_at IS NULL
           ORDER BY p.last_formatted_at ASC NULLS FIRST, c.full_name ASC 
           LIMIT 50`
 
@@ -120,8 +169,11 @@ type internalRel struct {
 	RelName          string
 }
 
-func (d *Database) GetRelationshipSuggestions(userID int) ([]models.RelationshipSuggestion, error) {
-	var suggestions []models.RelationshipSuggestion
+// GetRelationshipSuggestions infers missing relationships from the user's family tree. The walk is
+// bounded by SuggestionMaxSteps candidate pairs and SuggestionMaxResults suggestions; truncated
+// reports that it stopped early, so the result is a partial list
+func (d *Database) GetRelationshipSuggestions(userID int) (suggestions []models.RelationshipSuggestion, truncated bool, err error) {
+	logger.Debug("[DATABASE] Begin GetRelationshipSuggestions(userID:%d)", userID)
 
 	// 1. Preparation
 	relTypes, _ := d.GetRelationshipTypes()
@@ -138,7 +190,7 @@ func (d *Database) GetRelationshipSuggestions(userID int) ([]models.Relationship
 		JOIN relationship_types rt ON r.relationship_type_id = rt.id
 		WHERE c1.user_id = $1`, userID)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
@@ -152,6 +204,15 @@ func (d *Database) GetRelationshipSuggestions(userID int) ([]models.Relationship
 	spousesOf := make(map[int][]person)
 	allPeople := make(map[int]person)
 
+	// Every related pair, in either direction, keyed lowest ID first
+	related := make(map[[2]int]bool)
+	pair := func(a, b int) [2]int {
+		if a > b {
+			a, b = b, a
+		}
+		return [2]int{a, b}
+	}
+
 	for rows.Next() {
 		var r internalRel
 		rows.Scan(&r.ContactID, &r.ContactName, &r.ContactGender, &r.RelatedContactID, &r.RelatedName, &r.RelatedGender, &r.RelName)
@@ -160,6 +221,7 @@ func (d *Database) GetRelationshipSuggestions(userID int) ([]models.Relationship
 		p2 := person{r.RelatedContactID, r.RelatedName, r.RelatedGender}
 		allPeople[p1.ID] = p1
 		allPeople[p2.ID] = p2
+		related[pair(p1.ID, p2.ID)] = true
 
 		// Indexing (Bidirectional)
 		switch r.RelName {
@@ -174,18 +236,43 @@ func (d *Database) GetRelationshipSuggestions(userID int) ([]models.Relationship
 			spousesOf[p2.ID] = append(spousesOf[p2.ID], p1)
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	// Walk people in ID order so a truncated list is the same on every request
+	ids := make([]int, 0, len(allPeople))
+	for id := range allPeople {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
 
 	// 2. Inference Engine
 	suggestedPairs := make(map[string]bool) // Key: "minID-maxID-Category"
 
-	for id, p := range allPeople {
+	// step counts a candidate pair against the limits, reporting false once the walk must stop
+	steps := 0
+	step := func() bool {
+		steps++
+		if steps > d.SuggestionMaxSteps || len(suggestions) >= d.SuggestionMaxResults {
+			truncated = true
+			return false
+		}
+		return true
+	}
+
+inference:
+	for _, id := range ids {
+		p := allPeople[id]
 
 		// SCENARIO 1: SPOUSE -> CHILD (Step-parents)
 		for _, spouse := range spousesOf[id] {
 			for _, kid := range childrenOf[spouse.ID] {
+				if !step() {
+					break inference
+				}
 				pairKey := fmt.Sprintf("%d-%d-step", id, kid.ID)
-				exists, _ := d.RelationExists(id, kid.ID)
-				if !exists && id != kid.ID && !suggestedPairs[pairKey] {
+				if !related[pair(id, kid.ID)] && id != kid.ID && !suggestedPairs[pairKey] {
 					role := inferRole(kid.Gender, "child")
 					suggestions = append(suggestions, models.RelationshipSuggestion{
 						Type: "Relationship", TargetID: kid.ID, TargetName: kid.Name,
@@ -203,6 +290,9 @@ func (d *Database) GetRelationshipSuggestions(userID int) ([]models.Relationship
 				if sib.ID == id {
 					continue
 				}
+				if !step() {
+					break inference
+				}
 				// Sort IDs to ensure Mateo/Lucas only appears once
 				idA, idB := id, sib.ID
 				if idA > idB {
@@ -210,8 +300,7 @@ func (d *Database) GetRelationshipSuggestions(userID int) ([]models.Relationship
 				}
 				pairKey := fmt.Sprintf("%d-%d-sibling", idA, idB)
 
-				exists, _ := d.RelationExists(id, sib.ID)
-				if !exists && !suggestedPairs[pairKey] {
+				if !related[pair(id, sib.ID)] && !suggestedPairs[pairKey] {
 					role := inferRole(sib.Gender, "sibling")
 					suggestions = append(suggestions, models.RelationshipSuggestion{
 						Type: "Relationship", TargetID: sib.ID, TargetName: sib.Name,
@@ -226,9 +315,11 @@ func (d *Database) GetRelationshipSuggestions(userID int) ([]models.Relationship
 		// SCENARIO 3: GRANDPARENT INFERENCE (Path: A -> B -> C)
 		for _, child := range childrenOf[id] {
 			for _, gc := range childrenOf[child.ID] {
+				if !step() {
+					break inference
+				}
 				pairKey := fmt.Sprintf("%d-%d-grand", id, gc.ID)
-				exists, _ := d.RelationExists(id, gc.ID)
-				if !exists && id != gc.ID && !suggestedPairs[pairKey] {
+				if !related[pair(id, gc.ID)] && id != gc.ID && !suggestedPairs[pairKey] {
 					role := inferRole(gc.Gender, "grandchild")
 					suggestions = append(suggestions, models.RelationshipSuggestion{
 						Type: "Relationship", TargetID: gc.ID, TargetName: gc.Name,
@@ -241,7 +332,34 @@ func (d *Database) GetRelationshipSuggestions(userID int) ([]models.Relationship
 		}
 	}
 
-	return suggestions, nil
+	if truncated {
+		logger.Warn("[DATABASE] Relationship suggestions for user %d stopped after %d steps and %d suggestions", userID, steps-1, len(suggestions))
+	}
+
+	return suggestions, truncated, nil
+}
+
+// AcceptRelationshipSuggestion applies a suggestion from GetRelationshipSuggestions by adding the
+// proposed relationship. Returns "not found" unless it is currently suggested, which also keeps
+// users to their own contacts; once added, the pair is related and is no longer suggested
+func (d *Database) AcceptRelationshipSuggestion(userID int, accept models.RelationshipSuggestionAccept) (*models.Relationship, error) {
+	logger.Debug("[DATABASE] Begin AcceptRelationshipSuggestion(userID:%d, proposedID:%d, targetID:%d, relationshipTypeID:%d)", userID, accept.ProposedID, accept.TargetID, accept.RelationshipTypeID)
+
+	suggestions, _, err := d.GetRelationshipSuggestions(userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error loading relationship suggestions: %v", err)
+		return nil, err
+	}
+
+	for _, suggestion := range suggestions {
+		if suggestion.ProposedID == accept.ProposedID && suggestion.TargetID == accept.TargetID &&
+			suggestion.RelationshipTypeID == accept.RelationshipTypeID && suggestion.RelationshipTypeID != 0 {
+			rel, _, err := d.AddRelationship(userID, accept.ProposedID, accept.TargetID, accept.RelationshipTypeID)
+			return rel, err
+		}
+	}
+
+	return nil, errors.New("not found")
 }
 
 func (d *Database) RelationExists(contactID, relatedID int) (bool, error) {
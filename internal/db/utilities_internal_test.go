@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/steveredden/KindredCard/internal/logger"
+)
+
+// expectLargeFamily mocks the queries GetRelationshipSuggestions runs for one parent with the given
+// number of sons (contact IDs 2 and up), so every pair of sons is a sibling suggestion
+func expectLargeFamily(mock sqlmock.Sqlmock, sons int) {
+	mock.ExpectQuery("FROM relationship_types").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "reverse_name_male", "reverse_name_female", "reverse_name_neutral", "is_system"}).
+			AddRow(1, "Son", "Father", "Mother", "Parent", true).
+			AddRow(2, "Brother", "Brother", "Sister", "Sibling", true))
+
+	rows := sqlmock.NewRows([]string{"contact_id", "full_name", "gender", "related_contact_id", "related_name", "related_gender", "name"})
+	for i := 0; i < sons; i++ {
+		rows.AddRow(1, "Parent", "F", i+2, fmt.Sprintf("Son %d", i+1), "M", "Son")
+	}
+	mock.ExpectQuery("FROM relationships r").WillReturnRows(rows)
+}
+
+func TestGetRelationshipSuggestionsLimits(t *testing.T) {
+	captureLog(t, logger.ERROR)
+
+	// 40 sons make 780 sibling pairs, each reached twice (once from either brother)
+	const sons, pairs = 40, 40 * 39 / 2
+
+	tests := []struct {
+		name          string
+		maxSteps      int
+		maxResults    int
+		wantCount     int
+		wantTruncated bool
+	}{
+		{"within both limits", DefaultSuggestionMaxSteps, 1000, pairs, false},
+		{"result cap", DefaultSuggestionMaxSteps, 50, 50, true},
+		{"step cap", 100, 1000, -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tdb, mock := newMockTracedDB(t)
+			d := &Database{db: tdb, SuggestionMaxSteps: tt.maxSteps, SuggestionMaxResults: tt.maxResults}
+			expectLargeFamily(mock, sons)
+
+			suggestions, truncated, err := d.GetRelationshipSuggestions(1)
+			if err != nil {
+				t.Fatalf("GetRelationshipSuggestions: %v", err)
+			}
+			if truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+			if tt.wantCount >= 0 && len(suggestions) != tt.wantCount {
+				t.Errorf("got %d suggestions, want %d", len(suggestions), tt.wantCount)
+			}
+			if len(suggestions) > tt.maxResults || len(suggestions) > tt.maxSteps {
+				t.Errorf("got %d suggestions, over the %d step and %d result limits", len(suggestions), tt.maxSteps, tt.maxResults)
+			}
+
+			// The walk runs in contact ID order, so a truncated list starts where a full one does
+			if len(suggestions) == 0 {
+				t.Fatal("no suggestions")
+			}
+			if first := suggestions[0]; first.ProposedID != 2 || first.TargetID != 3 || first.ProposedVal != "Brother" || first.RelationshipTypeID != 2 {
+				t.Errorf("first suggestion = %+v, want Son 1 as Son 2's brother", first)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package db_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestGetContactsMissingGenderPaging(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	const missing = 120
+	for i := 0; i < missing; i++ {
+		dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: fmt.Sprintf("Contact %03d", i)})
+	}
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Gendered", Gender: "F"})
+
+	seen := make(map[int]bool)
+	for offset := 0; ; offset += 50 {
+		page, total, err := database.GetContactsMissingGender(user.ID, 50, offset)
+		if err != nil {
+			t.Fatalf("GetContactsMissingGender(offset %d): %v", offset, err)
+		}
+		if total != missing {
+			t.Errorf("offset %d: total = %d, want %d", offset, total, missing)
+		}
+		if len(page) == 0 {
+			break
+		}
+		if len(page) > 50 {
+			t.Fatalf("offset %d: got %d contacts, want at most 50", offset, len(page))
+		}
+		for _, c := range page {
+			if c.FullName == "Gendered" {
+				t.Errorf("offset %d: returned a contact that has a gender", offset)
+			}
+			if seen[c.ID] {
+				t.Errorf("offset %d: contact %d was already on an earlier page", offset, c.ID)
+			}
+			seen[c.ID] = true
+		}
+	}
+
+	if len(seen) != missing {
+		t.Errorf("paged through %d contacts, want all %d", len(seen), missing)
+	}
+}
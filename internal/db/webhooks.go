@@ -0,0 +1,120 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package db
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/steveredden/KindredCard/internal/logger"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// CreateWebhook adds a webhook for the user. The returned webhook includes its secret
+func (d *Database) CreateWebhook(userID int, url string, secret string, events []string) (*models.Webhook, error) {
+	logger.Debug("[DATABASE] Begin CreateWebhook(userID:%d, url:%s, events:%v)", userID, url, events)
+
+	hook := &models.Webhook{URL: url, Secret: secret, Events: events}
+	err := d.db.QueryRow(`
+		INSERT INTO webhooks (user_id, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		userID, url, secret, pq.Array(events),
+	).Scan(&hook.ID, &hook.CreatedAt)
+	if err != nil {
+		logger.Error("[DATABASE] Error inserting webhook: %v", err)
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+// GetWebhooksByUserID lists the user's webhooks, oldest first. Secrets are not included
+func (d *Database) GetWebhooksByUserID(userID int) ([]models.Webhook, error) {
+	logger.Debug("[DATABASE] Begin GetWebhooksByUserID(userID:%d)", userID)
+
+	rows, err := d.db.Query("SELECT id, url, events, created_at FROM webhooks WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting webhooks: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []models.Webhook{}
+	for rows.Next() {
+		var hook models.Webhook
+		if err := rows.Scan(&hook.ID, &hook.URL, pq.Array(&hook.Events), &hook.CreatedAt); err != nil {
+			logger.Error("[DATABASE] Error scanning webhooks: %v", err)
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, rows.Err()
+}
+
+// GetWebhooksForEvent returns the user's webhooks subscribed to event, secrets included, for delivery
+func (d *Database) GetWebhooksForEvent(userID int, event string) ([]models.Webhook, error) {
+	logger.Debug("[DATABASE] Begin GetWebhooksForEvent(userID:%d, event:%s)", userID, event)
+
+	rows, err := d.db.Query(`
+		SELECT id, url, secret, events, created_at FROM webhooks
+		WHERE user_id = $1 AND $2 = ANY(events)
+		ORDER BY id`, userID, event)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting webhooks: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []models.Webhook{}
+	for rows.Next() {
+		var hook models.Webhook
+		if err := rows.Scan(&hook.ID, &hook.URL, &hook.Secret, pq.Array(&hook.Events), &hook.CreatedAt); err != nil {
+			logger.Error("[DATABASE] Error scanning webhooks: %v", err)
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, rows.Err()
+}
+
+// DeleteWebhook removes one of the user's webhooks. Returns "not found" when the user has no such webhook
+func (d *Database) DeleteWebhook(userID int, webhookID int) error {
+	logger.Debug("[DATABASE] Begin DeleteWebhook(userID:%d, webhookID:%d)", userID, webhookID)
+
+	result, err := d.db.Exec("DELETE FROM webhooks WHERE id = $1 AND user_id = $2", webhookID, userID)
+	if err != nil {
+		logger.Error("[DATABASE] Error deleting webhook: %v", err)
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("not found")
+	}
+
+	return nil
+}
+
+// GetContactUIDByID returns a contact's UID whether or not it is in the trash, so changes to
+// deleted contacts can still be identified
+func (d *Database) GetContactUIDByID(userID int, contactID int) (string, error) {
+	logger.Debug("[DATABASE] Begin GetContactUIDByID(userID:%d, contactID:%d)", userID, contactID)
+
+	var uid string
+	err := d.db.QueryRow("SELECT uid FROM contacts WHERE id = $1 AND user_id = $2", contactID, userID).Scan(&uid)
+	if err != nil {
+		logger.Error("[DATABASE] Error selecting contact: %v", err)
+		return "", err
+	}
+
+	return uid, nil
+}
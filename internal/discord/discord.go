@@ -115,7 +115,7 @@ func BuildTodayEventsEmbed(events []models.UpcomingEvent, baseURL string) Discor
 	if len(birthdays) > 0 {
 		var birthdayText string
 		for _, b := range birthdays {
-			nameHyperlink := makeHyperlink(b.FullName, fmt.Sprintf("%s/contacts/%d", baseURL, b.ContactID))
+			nameHyperlink := makeHyperlink(b.DisplayName(), fmt.Sprintf("%s/contacts/%d", baseURL, b.ContactID))
 			if b.AgeOrYears != nil {
 				ordinal := utils.Ordinal(*b.AgeOrYears)
 				birthdayText += fmt.Sprintf("🎂 **%s** - %s birthday %s!\n", nameHyperlink, ordinal, b.TimeDescription)
@@ -133,7 +133,7 @@ func BuildTodayEventsEmbed(events []models.UpcomingEvent, baseURL string) Discor
 	if len(anniversaries) > 0 {
 		var anniversaryText string
 		for _, a := range anniversaries {
-			nameHyperlink := makeHyperlink(a.FullName, fmt.Sprintf("%s/contacts/%d", baseURL, a.ContactID))
+			nameHyperlink := makeHyperlink(a.DisplayName(), fmt.Sprintf("%s/contacts/%d", baseURL, a.ContactID))
 			if a.AgeOrYears != nil {
 				ordinal := utils.Ordinal(*a.AgeOrYears)
 				anniversaryText += fmt.Sprintf("💍 **%s** - %s wedding anniversary %s!\n", nameHyperlink, ordinal, a.TimeDescription)
@@ -151,7 +151,7 @@ func BuildTodayEventsEmbed(events []models.UpcomingEvent, baseURL string) Discor
 	if len(others) > 0 {
 		var otherText string
 		for _, o := range others {
-			nameHyperlink := makeHyperlink(o.FullName, fmt.Sprintf("%s/contacts/%d", baseURL, o.ContactID))
+			nameHyperlink := makeHyperlink(o.DisplayName(), fmt.Sprintf("%s/contacts/%d", baseURL, o.ContactID))
 			if o.AgeOrYears != nil {
 				ordinal := utils.Ordinal(*o.AgeOrYears)
 				otherText += fmt.Sprintf("📅 **%s** - %s anniversary of %s %s!\n", nameHyperlink, ordinal, o.EventType, o.TimeDescription)
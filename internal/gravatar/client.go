@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package gravatar
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/logger"
+)
+
+// DefaultBaseURL is the public Gravatar service
+const DefaultBaseURL = "https://www.gravatar.com"
+
+// maxAvatarBytes caps how much of an avatar response is read
+const maxAvatarBytes = 5 << 20
+
+// ErrNotFound is returned when no Gravatar exists for an email (d=404)
+var ErrNotFound = errors.New("gravatar not found")
+
+// Client represents a Gravatar client
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a new Gravatar client; an empty baseURL uses DefaultBaseURL
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Hash returns the Gravatar hash for an email: md5 of the trimmed, lowercased address
+func Hash(email string) string {
+	sum := md5.Sum([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// AvatarURL builds the avatar URL for an email. d=404 makes Gravatar return 404
+// instead of a generated placeholder, so only real avatars are ever used
+func (c *Client) AvatarURL(email string, size int) string {
+	params := url.Values{}
+	params.Set("d", "404")
+	if size > 0 {
+		params.Set("s", fmt.Sprintf("%d", size))
+	}
+	return fmt.Sprintf("%s/avatar/%s?%s", c.BaseURL, Hash(email), params.Encode())
+}
+
+// FetchAvatar downloads the avatar for an email, returning the image bytes and MIME type
+// Returns ErrNotFound when the email has no Gravatar
+func (c *Client) FetchAvatar(email string, size int) ([]byte, string, error) {
+	logger.Debug("[GRAVATAR] Fetching avatar for hash %s", Hash(email))
+
+	resp, err := c.HTTPClient.Get(c.AvatarURL(email, size))
+	if err != nil {
+		return nil, "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAvatarBytes))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read avatar: %w", err)
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, "", fmt.Errorf("unexpected content type %q", mimeType)
+	}
+
+	return data, mimeType, nil
+}
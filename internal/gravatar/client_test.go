@@ -0,0 +1,76 @@
+package gravatar
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newStubServer serves pngData for the hashes in known and 404 for anything else, as Gravatar
+// does with d=404
+func newStubServer(t *testing.T, known ...string) *httptest.Server {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	pngData := buf.Bytes()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("d") != "404" {
+			t.Errorf("request %s is missing d=404", r.URL)
+		}
+		hash := strings.TrimPrefix(r.URL.Path, "/avatar/")
+		for _, email := range known {
+			if hash == Hash(email) {
+				w.Header().Set("Content-Type", "image/png")
+				w.Write(pngData)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHash(t *testing.T) {
+	// The example from Gravatar's documentation: surrounding whitespace and case are ignored
+	const want = "0bc83cb571cd1c50ba6f3e8a78ef1346"
+	for _, email := range []string{"myemailaddress@example.com", " MyEmailAddress@example.com "} {
+		if got := Hash(email); got != want {
+			t.Errorf("Hash(%q) = %s, want %s", email, got, want)
+		}
+	}
+}
+
+func TestAvatarURL(t *testing.T) {
+	c := NewClient("")
+	want := "https://www.gravatar.com/avatar/0bc83cb571cd1c50ba6f3e8a78ef1346?d=404&s=256"
+	if got := c.AvatarURL("MyEmailAddress@example.com", 256); got != want {
+		t.Errorf("AvatarURL = %s, want %s", got, want)
+	}
+}
+
+func TestFetchAvatar(t *testing.T) {
+	srv := newStubServer(t, "alice@example.com")
+	client := NewClient(srv.URL + "/")
+
+	data, mimeType, err := client.FetchAvatar("  Alice@Example.com ", 80)
+	if err != nil {
+		t.Fatalf("FetchAvatar: %v", err)
+	}
+	if mimeType != "image/png" || len(data) == 0 {
+		t.Errorf("got %d bytes of %q, want a PNG", len(data), mimeType)
+	}
+
+	if _, _, err := client.FetchAvatar("nobody@example.com", 80); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FetchAvatar for an email without a Gravatar: err = %v, want ErrNotFound", err)
+	}
+}
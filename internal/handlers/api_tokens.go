@@ -23,7 +23,7 @@ import (
 // CreateAPIToken godoc
 //
 //	@Summary		Create API token
-//	@Description	Generate a new API token for programmatic access
+//	@Description	Generate a new API token for programmatic access. A read scoped token may only make GET requests (anything else is a 403); tokens default to the write scope
 //	@Tags			tokens
 //	@Accept			json
 //	@Produce		json
@@ -55,8 +55,17 @@ func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Tokens are full access unless asked otherwise
+	if req.Scope == "" {
+		req.Scope = models.APITokenScopeWrite
+	}
+	if req.Scope != models.APITokenScopeRead && req.Scope != models.APITokenScopeWrite {
+		http.Error(w, "Token scope must be read or write", http.StatusBadRequest)
+		return
+	}
+
 	// Create the token
-	tokenWithRaw, err := h.db.CreateAPIToken(user.ID, req.Name, req.ExpiresAt)
+	tokenWithRaw, err := h.db.CreateAPIToken(user.ID, req.Name, req.Scope, req.ExpiresAt)
 	if err != nil {
 		http.Error(w, "Failed to create API token", http.StatusInternalServerError)
 		return
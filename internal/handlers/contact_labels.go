@@ -2,27 +2,62 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/steveredden/KindredCard/internal/db"
 	"github.com/steveredden/KindredCard/internal/middleware"
 	"github.com/steveredden/KindredCard/internal/models"
 )
 
+// ListLabelsAPI godoc
+//
+//	@Summary		List contact labels
+//	@Description	Lists the system and custom labels of phones, emails, addresses, and URLs, with how many records use each
+//	@Tags			labels
+//	@Produce		json
+//	@Success		200	{array}		models.ContactLabelType	"Labels"
+//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Failure		500	{object}	map[string]string		"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/labels [get]
+func (h *Handler) ListLabelsAPI(w http.ResponseWriter, r *http.Request) {
+	_, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	labels, err := h.db.ListLabels()
+	if err != nil {
+		http.Error(w, "Error loading labels", http.StatusInternalServerError)
+		return
+	}
+	if labels == nil {
+		labels = []models.ContactLabelType{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(labels)
+}
+
 // NewCustomLabelAPI godoc
 //
-//	@Summary		Get upcoming events
-//	@Description	Get birthdays, anniversaries, and other important dates coming up for all contacts
+//	@Summary		Create a custom label
+//	@Description	Adds a custom label (stored lowercased) for phones, emails, addresses, or URLs. An existing label with the same name and category is returned instead
 //	@Tags			labels
 //	@Accept			json
 //	@Produce		json
 //	@Param			label	body		models.ContactLabelJSONPost	true	"label fields"
-//	@Success		200		{array}		map[string]int				"id of label"
+//	@Success		201		{object}	models.ContactLabelType		"The label"
+//	@Failure		400		{object}	map[string]string			"Invalid name or category"
 //	@Failure		401		{object}	map[string]string			"Unauthorized"
 //	@Failure		500		{object}	map[string]string			"Internal server error"
 //	@Security		ApiTokenAuth
-//	@Router			/api/v1/settings/labels [post]
+//	@Router			/api/v1/labels [post]
 func (h *Handler) NewCustomLabelAPI(w http.ResponseWriter, r *http.Request) {
 	_, ok := middleware.GetUserFromContext(r)
 	if !ok {
@@ -36,7 +71,18 @@ func (h *Handler) NewCustomLabelAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	label, err := h.db.NewLabel(input.Name, input.Category)
+	name := strings.ToLower(strings.TrimSpace(input.Name))
+	category := strings.ToLower(strings.TrimSpace(input.Category))
+	if name == "" {
+		http.Error(w, "Label name is required", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(models.ContactLabelCategories, category) {
+		http.Error(w, "Invalid category; expected one of phone, email, address, url", http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.db.CreateLabel(name, category)
 	if err != nil {
 		http.Error(w, "Failed to create label", http.StatusInternalServerError)
 		return
@@ -44,24 +90,25 @@ func (h *Handler) NewCustomLabelAPI(w http.ResponseWriter, r *http.Request) {
 
 	// Return JSON
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]int{"id": label})
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.ContactLabelType{ID: id, Name: name, Category: category})
 }
 
-// DeleteEmailAPI godoc
+// DeleteCustomLabelAPI godoc
 //
 //	@Summary		Removes a custom contact label
-//	@Description	Removes a custom contact label using HTTP DELETE
+//	@Description	Removes a custom contact label. System labels and labels still in use can't be deleted
 //	@Tags			labels
-//	@Accept			json
 //	@Produce		json
 //	@Param			lid	path		int					true	"Label ID"
 //	@Success		200	{object}	map[string]string	"deleted"
-//	@Failure		400	{object}	map[string]string	"Invalid request body or Label ID"
+//	@Failure		400	{object}	map[string]string	"Invalid Label ID"
 //	@Failure		401	{object}	map[string]string	"Unauthorized"
 //	@Failure		404	{object}	map[string]string	"Label not found"
+//	@Failure		409	{object}	map[string]string	"System label or label in use"
 //	@Failure		500	{object}	map[string]string	"Internal server error"
 //	@Security		ApiTokenAuth
-//	@Router			/api/v1/settings/labels/{lid} [delete]
+//	@Router			/api/v1/labels/{lid} [delete]
 func (h *Handler) DeleteCustomLabelAPI(w http.ResponseWriter, r *http.Request) {
 	_, ok := middleware.GetUserFromContext(r)
 	if !ok {
@@ -72,12 +119,20 @@ func (h *Handler) DeleteCustomLabelAPI(w http.ResponseWriter, r *http.Request) {
 	// Get Label ID from URL
 	labelID, err := strconv.Atoi(mux.Vars(r)["lid"])
 	if err != nil {
-		http.Error(w, "Invalid Contact ID", http.StatusBadRequest)
+		http.Error(w, "Invalid Label ID", http.StatusBadRequest)
 		return
 	}
 
-	err = h.db.DeleteContactLabel(labelID)
-	if err != nil {
+	err = h.db.DeleteLabel(labelID)
+	switch {
+	case err == nil:
+	case errors.Is(err, db.ErrLabelSystem), errors.Is(err, db.ErrLabelInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err.Error() == "not found":
+		http.Error(w, "Label not found", http.StatusNotFound)
+		return
+	default:
 		http.Error(w, "Deletion failed", http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// createLabel POSTs a custom label and returns the response
+func createLabel(h *Handler, user *models.User, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/labels", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.NewCustomLabelAPI(w, withUser(r, user))
+	return w
+}
+
+func deleteLabel(h *Handler, user *models.User, labelID int) int {
+	r := httptest.NewRequest(http.MethodDelete, "/api/v1/labels/"+strconv.Itoa(labelID), nil)
+	r = mux.SetURLVars(withUser(r, user), map[string]string{"lid": strconv.Itoa(labelID)})
+	w := httptest.NewRecorder()
+	h.DeleteCustomLabelAPI(w, r)
+	return w.Code
+}
+
+func TestCustomLabelImport(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	// Labels are shared by every user, so each run makes its own
+	name := "Vacation Home " + strconv.Itoa(user.ID)
+	w := createLabel(h, user, `{"category": "address", "name": "`+name+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, body %s", w.Code, w.Body.String())
+	}
+	var label models.ContactLabelType
+	if err := json.NewDecoder(w.Body).Decode(&label); err != nil {
+		t.Fatalf("decoding label: %v", err)
+	}
+	if label.Name != strings.ToLower(name) || label.Category != "address" {
+		t.Errorf("label = %+v, want the lowercased name in the address category", label)
+	}
+
+	// A card using the label by name gets the existing label, in any case
+	uid := "vacation-" + strconv.Itoa(user.ID)
+	data := []byte("BEGIN:VCARD\r\nVERSION:3.0\r\nUID:" + uid + "\r\nFN:Alice\r\nN:;Alice;;;\r\n" +
+		"item1.ADR:;;1 Ocean Ave;Malibu;CA;90265;USA\r\nitem1.X-ABLABEL:" + strings.ToUpper(name) + "\r\nEND:VCARD\r\n")
+	importVCards(t, h, user, data)
+
+	contact, err := database.GetContactByUID(user.ID, uid, false)
+	if err != nil {
+		t.Fatalf("GetContactByUID: %v", err)
+	}
+	if len(contact.Addresses) != 1 || contact.Addresses[0].Type != label.ID {
+		t.Errorf("addresses = %+v, want one with label %d", contact.Addresses, label.ID)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/labels", nil)
+	lw := httptest.NewRecorder()
+	h.ListLabelsAPI(lw, withUser(r, user))
+	var labels []models.ContactLabelType
+	if err := json.NewDecoder(lw.Body).Decode(&labels); err != nil {
+		t.Fatalf("decoding labels: %v", err)
+	}
+	usage := -1
+	for _, l := range labels {
+		if l.ID == label.ID {
+			usage = l.UsageCount
+		}
+	}
+	if usage != 1 {
+		t.Errorf("usage count of the new label = %d, want 1", usage)
+	}
+
+	if code := deleteLabel(h, user, label.ID); code != http.StatusConflict {
+		t.Errorf("deleting a label in use: status = %d, want %d", code, http.StatusConflict)
+	}
+	systemID, err := database.GetLabelID("home", "email")
+	if err != nil {
+		t.Fatalf("GetLabelID: %v", err)
+	}
+	if code := deleteLabel(h, user, systemID); code != http.StatusConflict {
+		t.Errorf("deleting a system label: status = %d, want %d", code, http.StatusConflict)
+	}
+
+	w = createLabel(h, user, `{"category": "phone", "name": "Unused `+strconv.Itoa(user.ID)+`"}`)
+	var unused models.ContactLabelType
+	if err := json.NewDecoder(w.Body).Decode(&unused); err != nil {
+		t.Fatalf("decoding label: %v", err)
+	}
+	if code := deleteLabel(h, user, unused.ID); code != http.StatusOK {
+		t.Errorf("deleting an unused label: status = %d, want %d", code, http.StatusOK)
+	}
+	if code := deleteLabel(h, user, unused.ID); code != http.StatusNotFound {
+		t.Errorf("deleting it again: status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestNewCustomLabelRejectsInvalidInput(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, body := range []string{
+		`{"category": "phone", "name": "  "}`,
+		`{"category": "fax", "name": "School"}`,
+		`{"name": "School"}`,
+		`not json`,
+	} {
+		if w := createLabel(h, user, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestImportCreatesUnknownLabels(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	name := "beach house " + strconv.Itoa(user.ID)
+	if _, err := database.GetLabelID(name, "email"); err == nil {
+		t.Fatalf("label %q exists before the import", name)
+	}
+
+	uid := "beach-" + strconv.Itoa(user.ID)
+	data := []byte("BEGIN:VCARD\r\nVERSION:3.0\r\nUID:" + uid + "\r\nFN:Alice\r\nN:;Alice;;;\r\n" +
+		"item1.EMAIL;TYPE=INTERNET:alice@example.com\r\nitem1.X-ABLABEL:" + name + "\r\nEND:VCARD\r\n")
+	importVCards(t, h, user, data)
+
+	labelID, err := database.GetLabelID(name, "email")
+	if err != nil {
+		t.Fatalf("GetLabelID after import: %v", err)
+	}
+	contact, err := database.GetContactByUID(user.ID, uid, false)
+	if err != nil {
+		t.Fatalf("GetContactByUID: %v", err)
+	}
+	if len(contact.Emails) != 1 || contact.Emails[0].Type != labelID || contact.Emails[0].TypeLabel != name {
+		t.Errorf("emails = %+v, want one linked to the new label %d", contact.Emails, labelID)
+	}
+}
@@ -10,13 +10,18 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/steveredden/KindredCard/internal/avatar"
 	"github.com/steveredden/KindredCard/internal/middleware"
 	"github.com/steveredden/KindredCard/internal/models"
 	"github.com/steveredden/KindredCard/internal/utils"
@@ -34,10 +39,14 @@ func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error loading contacts", http.StatusInternalServerError)
 		return
 	}
+	if r.URL.Query().Get("include_archived") != "true" {
+		contacts = slices.DeleteFunc(contacts, func(c *models.Contact) bool { return c.Archived })
+	}
+	models.SetDisplayNames(contacts, user.NicknameAsDisplayName)
 
 	// Get counters
 	totalCount, _ := h.db.GetContactCount(user.ID)
-	upcomingEventCount, _ := h.db.GetUpcomingEventsCount(user.ID, 7)
+	upcomingEventCount, _ := h.db.GetUpcomingEventsCount(user.ID, user.EventLookaheadDays)
 	recentlyEditedCount, _ := h.db.GetRecentlyEditedCountByDays(user.ID, 7)
 	labelTypes, _ := h.db.GetLabelUIMap()
 
@@ -65,7 +74,7 @@ func (h *Handler) SearchContactsHeader(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Replace with your actual DB query logic
-	contacts, err := h.db.SearchContacts(user.ID, query)
+	contacts, err := h.db.SearchContacts(user.ID, query, models.ContactSearchOptions{})
 	if err != nil || len(contacts) == 0 {
 		w.Write([]byte("<li><span class='menu-title'>No contacts found</span></li>"))
 		return
@@ -73,6 +82,7 @@ func (h *Handler) SearchContactsHeader(w http.ResponseWriter, r *http.Request) {
 
 	// Return the HTML fragment for HTMX to inject
 	for _, c := range contacts {
+		c.SetDisplayName(user.NicknameAsDisplayName)
 		var avatarHTML string
 		// Check if we have a valid avatar
 		if c.AvatarBase64 != "" && c.AvatarMimeType != "" {
@@ -100,7 +110,7 @@ func (h *Handler) SearchContactsHeader(w http.ResponseWriter, r *http.Request) {
                         <span class="font-medium text-sm text-base-content">%s</span>
                     </div>
                 </a>
-            </li>`, c.ID, avatarHTML, c.FullName)
+            </li>`, c.ID, avatarHTML, c.DisplayName)
 	}
 }
 
@@ -156,6 +166,7 @@ type PartialDateView struct {
 	Has       bool
 	Month     int
 	Day       int
+	Year      int // 0 when unknown
 	MonthName string
 }
 
@@ -177,6 +188,7 @@ func getBirthdayView(contact models.Contact) PartialDateView {
 			Has:       true,
 			Month:     int(contact.Birthday.Month()),
 			Day:       contact.Birthday.Day(),
+			Year:      contact.Birthday.Year(),
 			MonthName: contact.Birthday.Format("January"),
 		}
 
@@ -188,6 +200,17 @@ func getBirthdayView(contact models.Contact) PartialDateView {
 			Day:       *contact.BirthdayDay,
 			MonthName: t.Format("January"),
 		}
+
+	case contact.BirthdayYear != nil:
+		// Reduced precision: year only, or year+month
+		birthday = PartialDateView{
+			Has:  true,
+			Year: *contact.BirthdayYear,
+		}
+		if contact.BirthdayMonth != nil {
+			birthday.Month = *contact.BirthdayMonth
+			birthday.MonthName = time.Month(*contact.BirthdayMonth).String()
+		}
 	}
 
 	return birthday
@@ -203,6 +226,7 @@ func getAnniversaryView(contact models.Contact) PartialDateView {
 			Has:       true,
 			Month:     int(contact.Anniversary.Month()),
 			Day:       contact.Anniversary.Day(),
+			Year:      contact.Anniversary.Year(),
 			MonthName: contact.Anniversary.Format("January"),
 		}
 
@@ -214,6 +238,17 @@ func getAnniversaryView(contact models.Contact) PartialDateView {
 			Day:       *contact.AnniversaryDay,
 			MonthName: t.Format("January"),
 		}
+
+	case contact.AnniversaryYear != nil:
+		// Reduced precision: year only, or year+month
+		anniversary = PartialDateView{
+			Has:  true,
+			Year: *contact.AnniversaryYear,
+		}
+		if contact.AnniversaryMonth != nil {
+			anniversary.Month = *contact.AnniversaryMonth
+			anniversary.MonthName = time.Month(*contact.AnniversaryMonth).String()
+		}
 	}
 
 	return anniversary
@@ -275,7 +310,7 @@ func getPartialDateView(otherDate models.OtherDate) PartialDateView {
 // PatchContactAPI godoc
 //
 //	@Summary		Partially update a contact
-//	@Description	Update specific fields of a contact using HTTP PATCH. Only provided fields will be updated.
+//	@Description	Update specific fields of a contact using HTTP PATCH. Only provided fields will be updated. Birthday and anniversary take a full date or a month/day pair; setting one clears the other
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
@@ -285,6 +320,7 @@ func getPartialDateView(otherDate models.OtherDate) PartialDateView {
 //	@Failure		400		{object}	map[string]string		"Invalid request body or contact ID"
 //	@Failure		401		{object}	map[string]string		"Unauthorized"
 //	@Failure		404		{object}	map[string]string		"Contact not found"
+//	@Failure		422		{object}	map[string]string		"Invalid avatar data or MIME type"
 //	@Failure		500		{object}	map[string]string		"Internal server error"
 //	@Security		ApiTokenAuth
 //	@Router			/api/v1/contacts/{id} [patch]
@@ -309,6 +345,44 @@ func (h *Handler) PatchContactAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Normalize gender to the vCard single-letter codes
+	if patch.Gender != nil {
+		gender, ok := utils.NormalizeGender(*patch.Gender)
+		if !ok {
+			http.Error(w, "Invalid gender; expected one of M, F, O, N, U", http.StatusBadRequest)
+			return
+		}
+		patch.Gender = &gender
+	}
+
+	// A kind change also flips the company card flag, unless that is patched too
+	if patch.Kind != nil {
+		kind, ok := models.NormalizeContactKind(*patch.Kind)
+		if !ok {
+			http.Error(w, "Invalid kind; expected one of individual, org, group", http.StatusBadRequest)
+			return
+		}
+		patch.Kind = &kind
+		if patch.IsOrganization == nil {
+			isOrganization := kind == models.ContactKindOrg
+			patch.IsOrganization = &isOrganization
+		}
+	}
+
+	if err := normalizePatchDate("birthday", patch.Birthday, patch.BirthdayMonth, patch.BirthdayDay); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := normalizePatchDate("anniversary", patch.Anniversary, patch.AnniversaryMonth, patch.AnniversaryDay); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validatePatchAvatar(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
 	// Apply patch
 	updated, err := h.db.PatchContact(user.ID, contactID, &patch)
 	if err != nil {
@@ -320,6 +394,61 @@ func (h *Handler) PatchContactAPI(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(updated)
 }
 
+// validatePatchAvatar rejects patched avatars that would break vCard export: the data must be
+// base64 encoded image bytes and the MIME type an image type matching them. A missing MIME type
+// is filled in from the data; an empty avatar_base64 clears the avatar
+func validatePatchAvatar(patch *models.ContactJSONPatch) error {
+	if patch.AvatarMimeType != nil && *patch.AvatarMimeType != "" && !strings.HasPrefix(*patch.AvatarMimeType, "image/") {
+		return fmt.Errorf("avatar_mime_type must be an image type, got %q", *patch.AvatarMimeType)
+	}
+
+	if patch.AvatarBase64 == nil || *patch.AvatarBase64 == "" {
+		return nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(*patch.AvatarBase64)
+	if err != nil {
+		return errors.New("avatar_base64 is not valid base64")
+	}
+
+	detected := avatar.DetectMimeType(data)
+	if !strings.HasPrefix(detected, "image/") {
+		return errors.New("avatar_base64 does not contain image data")
+	}
+
+	if patch.AvatarMimeType == nil || *patch.AvatarMimeType == "" {
+		patch.AvatarMimeType = &detected
+	} else if !strings.EqualFold(*patch.AvatarMimeType, detected) {
+		return fmt.Errorf("avatar_mime_type %q does not match the image data (%s)", *patch.AvatarMimeType, detected)
+	}
+
+	return nil
+}
+
+// normalizePatchDate validates a patched date given as either a full date or a month+day pair,
+// rewriting a full date to YYYY-MM-DD in place
+func normalizePatchDate(name string, full *string, month *int, day *int) error {
+	if full != nil {
+		if month != nil || day != nil {
+			return fmt.Errorf("%s: send either a full date or %s_month/%s_day, not both", name, name, name)
+		}
+		date, err := parsePatchDate(*full)
+		if err != nil {
+			return fmt.Errorf("%s must be YYYY-MM-DD", name)
+		}
+		*full = date
+		return nil
+	}
+
+	if (month == nil) != (day == nil) {
+		return fmt.Errorf("%s_month and %s_day must be set together", name, name)
+	}
+	if month != nil && (*month < 1 || *month > 12 || *day < 1 || *day > 31) {
+		return fmt.Errorf("invalid %s_month or %s_day", name, name)
+	}
+	return nil
+}
+
 // UpdateAnniversaryAPI godoc
 //
 //	@Summary		Update an anniversary
@@ -362,6 +491,10 @@ func (h *Handler) UpdateAnniversaryAPI(w http.ResponseWriter, r *http.Request) {
 
 	err = h.db.UpdateContactDate(user.ID, input)
 	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Update failed", http.StatusInternalServerError)
 		return
 	}
@@ -373,12 +506,12 @@ func (h *Handler) UpdateAnniversaryAPI(w http.ResponseWriter, r *http.Request) {
 // UpdateBirthdayAPI godoc
 //
 //	@Summary		Update a birthday
-//	@Description	Update specific fields of an birthday using HTTP PATCH. Only provided fields will be updated.
+//	@Description	Update a birthday using HTTP PATCH. Send either a full date or month/day (optionally a year alone or year+month); the complementary columns are cleared
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
 //	@Param			id		path		int							true	"Contact ID"
-//	@Param			contact	body		models.ContactDateJSONPatch	true	"Anniversary fields to update"
+//	@Param			contact	body		models.ContactDateJSONPatch	true	"Birthday fields to update"
 //	@Success		200		{object}	map[string]string			"Updated contact"
 //	@Failure		400		{object}	map[string]string			"Invalid request body or contact ID"
 //	@Failure		401		{object}	map[string]string			"Unauthorized"
@@ -412,6 +545,10 @@ func (h *Handler) UpdateBirthdayAPI(w http.ResponseWriter, r *http.Request) {
 
 	err = h.db.UpdateContactDate(user.ID, input)
 	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Update failed", http.StatusInternalServerError)
 		return
 	}
@@ -460,6 +597,10 @@ func (h *Handler) UpdateNotesAPI(w http.ResponseWriter, r *http.Request) {
 
 	err = h.db.UpdateContactNotes(user.ID, input)
 	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Update failed", http.StatusInternalServerError)
 		return
 	}
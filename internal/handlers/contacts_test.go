@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestUpdateBirthdayAPI(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+	other := dbtest.NewUser(t, database)
+
+	born := time.Date(1990, time.March, 14, 0, 0, 0, 0, time.UTC)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice", Birthday: &born})
+
+	patch := func(u *models.User, contactID int) int {
+		r := httptest.NewRequest(http.MethodPatch, "/api/v1/contacts/"+strconv.Itoa(contactID)+"/birthday", strings.NewReader(`{"date_month": 3, "date_day": 14}`))
+		w := httptest.NewRecorder()
+		h.UpdateBirthdayAPI(w, withID(withUser(r, u), contactID))
+		return w.Code
+	}
+
+	if code := patch(user, alice.ID); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	got, err := database.GetContactByID(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if got.Birthday != nil || got.BirthdayMonth == nil || *got.BirthdayMonth != 3 {
+		t.Errorf("birthday = %v, month %v; want only the partial March 14", got.Birthday, got.BirthdayMonth)
+	}
+
+	if code := patch(other, alice.ID); code != http.StatusNotFound {
+		t.Errorf("patching another user's contact: status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestNormalizePatchDate(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	strPtr := func(v string) *string { return &v }
+
+	tests := []struct {
+		name       string
+		full       *string
+		month, day *int
+		wantErr    bool
+		wantFull   string
+	}{
+		{"full date", strPtr("1990-03-14"), nil, nil, false, "1990-03-14"},
+		{"RFC 3339", strPtr("1990-03-14T00:00:00Z"), nil, nil, false, "1990-03-14"},
+		{"month and day", nil, intPtr(3), intPtr(14), false, ""},
+		{"nothing", nil, nil, nil, false, ""},
+		{"both forms", strPtr("1990-03-14"), intPtr(3), intPtr(14), true, ""},
+		{"month alone", nil, intPtr(3), nil, true, ""},
+		{"bad month", nil, intPtr(13), intPtr(1), true, ""},
+		{"bad date", strPtr("14/03/1990"), nil, nil, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizePatchDate("birthday", tt.full, tt.month, tt.day)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantFull != "" && *tt.full != tt.wantFull {
+				t.Errorf("full date = %q, want %q", *tt.full, tt.wantFull)
+			}
+		})
+	}
+}
+
+func TestValidatePatchAvatar(t *testing.T) {
+	strPtr := func(v string) *string { return &v }
+	pngData := base64.StdEncoding.EncodeToString(encodePNG(t, 4, 4))
+
+	tests := []struct {
+		name     string
+		data     *string
+		mimeType *string
+		wantErr  bool
+		wantMime string
+	}{
+		{"PNG without a MIME type", strPtr(pngData), nil, false, "image/png"},
+		{"PNG with a matching MIME type", strPtr(pngData), strPtr("image/png"), false, "image/png"},
+		{"cleared avatar", strPtr(""), nil, false, ""},
+		{"no avatar", nil, nil, false, ""},
+		{"invalid base64", strPtr("not base64!"), nil, true, ""},
+		{"not an image", strPtr(base64.StdEncoding.EncodeToString([]byte("hello, world"))), nil, true, ""},
+		{"non-image MIME type", strPtr(pngData), strPtr("text/plain"), true, ""},
+		{"mismatched MIME type", strPtr(pngData), strPtr("image/gif"), true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := &models.ContactJSONPatch{AvatarBase64: tt.data, AvatarMimeType: tt.mimeType}
+			err := validatePatchAvatar(patch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantMime != "" && (patch.AvatarMimeType == nil || *patch.AvatarMimeType != tt.wantMime) {
+				t.Errorf("avatar_mime_type = %v, want %q", patch.AvatarMimeType, tt.wantMime)
+			}
+		})
+	}
+}
+
+func TestPatchContactRejectsInvalidAvatar(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, body := range []string{
+		`{"avatar_base64": "not base64!"}`,
+		`{"avatar_base64": "aGVsbG8="}`,
+		`{"avatar_mime_type": "text/plain"}`,
+	} {
+		r := httptest.NewRequest(http.MethodPatch, "/api/v1/contacts/1", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.PatchContactAPI(w, withID(withUser(r, user), 1))
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: status = %d, want %d", body, w.Code, http.StatusUnprocessableEntity)
+		}
+	}
+}
+
+func TestUpdateContactKeepsKind(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	club := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Book Club", GivenName: "Book Club", Kind: models.ContactKindGroup})
+
+	put := func(body string) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPut, "/api/v1/contacts/"+strconv.Itoa(club.ID), strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.UpdateContactAPI(w, withID(withUser(r, user), club.ID))
+		if w.Code != http.StatusOK {
+			t.Fatalf("PUT %s: status = %d, body %s", body, w.Code, w.Body.String())
+		}
+	}
+	kind := func() string {
+		t.Helper()
+		got, err := database.GetContactByID(user.ID, club.ID)
+		if err != nil {
+			t.Fatalf("GetContactByID: %v", err)
+		}
+		return got.Kind
+	}
+
+	put(`{"given_name": "Book Club"}`)
+	if got := kind(); got != models.ContactKindGroup {
+		t.Errorf("kind after a PUT without one = %q, want %q", got, models.ContactKindGroup)
+	}
+
+	// The company flag still moves the kind to and from org
+	put(`{"given_name": "Book Club", "is_organization": true}`)
+	if got := kind(); got != models.ContactKindOrg {
+		t.Errorf("kind with is_organization = %q, want %q", got, models.ContactKindOrg)
+	}
+	put(`{"given_name": "Book Club"}`)
+	if got := kind(); got != models.ContactKindIndividual {
+		t.Errorf("kind after clearing is_organization = %q, want %q", got, models.ContactKindIndividual)
+	}
+
+	put(`{"given_name": "Book Club", "kind": "group"}`)
+	if got := kind(); got != models.ContactKindGroup {
+		t.Errorf("kind = %q, want the %q sent", got, models.ContactKindGroup)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/steveredden/KindredCard/internal/middleware"
@@ -63,17 +65,17 @@ func (h *Handler) NewOtherDateAPI(w http.ResponseWriter, r *http.Request) {
 // UpdateOtherDateAPI godoc
 //
 //	@Summary		Update an other_date for a contact
-//	@Description	Update specific fields of a phone using HTTP PATCH. Only provided fields will be updated.
+//	@Description	Update specific fields of an other date using HTTP PATCH. Only provided fields will be updated. Setting event_date clears event_date_month/event_date_day and vice versa
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
-//	@Param			oid		path		int							true	"Other Date ID"
-//	@Param			contact	body		models.ContactDateJSONPatch	true	"Other Date fields to update"
-//	@Success		200		{object}	[]models.OtherDate			"Updated other date"
-//	@Failure		400		{object}	map[string]string			"Invalid request body or contact ID"
-//	@Failure		401		{object}	map[string]string			"Unauthorized"
-//	@Failure		404		{object}	map[string]string			"Contact not found"
-//	@Failure		500		{object}	map[string]string			"Internal server error"
+//	@Param			oid			path		int							true	"Other Date ID"
+//	@Param			otherDate	body		models.OtherDateJSONPatch	true	"Other Date fields to update"
+//	@Success		200			{object}	models.OtherDate			"Updated other date"
+//	@Failure		400			{object}	map[string]string			"Invalid request body or other date ID"
+//	@Failure		401			{object}	map[string]string			"Unauthorized"
+//	@Failure		404			{object}	map[string]string			"Other date not found"
+//	@Failure		500			{object}	map[string]string			"Internal server error"
 //	@Security		ApiTokenAuth
 //	@Router			/api/v1/other-dates/{oid} [patch]
 func (h *Handler) UpdateOtherDateAPI(w http.ResponseWriter, r *http.Request) {
@@ -83,31 +85,73 @@ func (h *Handler) UpdateOtherDateAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get Contact ID from URL
 	otherDateID, err := strconv.Atoi(mux.Vars(r)["oid"])
 	if err != nil {
 		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
 
-	input := models.ContactDateJSONPatch{
-		EventID:  &otherDateID,
-		DateType: "other",
+	var patch models.OtherDateJSONPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid body", http.StatusBadRequest)
+	if patch.EventName != nil {
+		name := strings.TrimSpace(*patch.EventName)
+		if name == "" {
+			http.Error(w, "event_name cannot be empty", http.StatusBadRequest)
+			return
+		}
+		patch.EventName = &name
+	}
+
+	if patch.EventDate != nil {
+		date, err := parsePatchDate(*patch.EventDate)
+		if err != nil {
+			http.Error(w, "event_date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		patch.EventDate = &date
+	} else if (patch.EventDateMonth == nil) != (patch.EventDateDay == nil) {
+		http.Error(w, "event_date_month and event_date_day must be set together", http.StatusBadRequest)
+		return
+	} else if patch.EventDateMonth != nil {
+		if *patch.EventDateMonth < 1 || *patch.EventDateMonth > 12 || *patch.EventDateDay < 1 || *patch.EventDateDay > 31 {
+			http.Error(w, "Invalid event_date_month or event_date_day", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if patch.EventName == nil && patch.EventDate == nil && patch.EventDateMonth == nil {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
 		return
 	}
 
-	err = h.db.UpdateContactOtherDate(user.ID, input)
+	otherDate, err := h.db.UpdateOtherDate(user.ID, otherDateID, &patch)
 	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Other date not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Update failed", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(otherDate)
+}
+
+// parsePatchDate accepts a plain date or an RFC 3339 timestamp and returns it as YYYY-MM-DD
+func parsePatchDate(value string) (string, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date.Format("2006-01-02"), nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", err
+	}
+	return date.UTC().Format("2006-01-02"), nil
 }
 
 // DeleteOtherDateAPI godoc
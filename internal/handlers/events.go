@@ -16,11 +16,21 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/steveredden/KindredCard/internal/converter"
 	"github.com/steveredden/KindredCard/internal/middleware"
 	"github.com/steveredden/KindredCard/internal/models"
 	"github.com/steveredden/KindredCard/internal/utils"
 )
 
+const (
+	// defaultPastEventDays is how far back the events page looks when past_days is not supplied
+	defaultPastEventDays = 7
+	// maxPastEventDays caps the past_days lookback
+	maxPastEventDays = 365
+	// maxEventLookaheadDays caps a user's default_event_lookahead_days preference
+	maxEventLookaheadDays = 365
+)
+
 // ShowEvents displays the events page
 func (h *Handler) ShowEvents(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
@@ -28,8 +38,23 @@ func (h *Handler) ShowEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Fetch past events (last 7 days)
-	pastEvents, err := h.db.GetRecentPastEventsByDays(user.ID, 7)
+	// Past lookback window: ?past_days=N (0 hides past events entirely)
+	pastDays := defaultPastEventDays
+	if v := r.URL.Query().Get("past_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxPastEventDays {
+			http.Error(w, "Invalid past_days; expected 0-365", http.StatusBadRequest)
+			return
+		}
+		pastDays = n
+	}
+
+	// Fetch past events
+	pastEvents := []models.UpcomingEvent{}
+	var err error
+	if pastDays > 0 {
+		pastEvents, err = h.db.GetRecentPastEventsByDays(user.ID, pastDays)
+	}
 	if err != nil {
 		http.Error(w, "Failed to fetch past events", http.StatusInternalServerError)
 		return
@@ -62,27 +87,28 @@ func (h *Handler) ShowEvents(w http.ResponseWriter, r *http.Request) {
 		pastEvents = append(pastEvents, todayEvent)
 	}
 
-	// Fetch upcoming events (next 7 days)
-	upcomingEvents, err := h.db.GetUpcomingEventsByDays(user.ID, 7)
+	// Fetch upcoming events (the user's lookahead window); they're both the count and the timeline
+	upcomingEvents, err := h.db.GetUpcomingEventsByDays(user.ID, user.EventLookaheadDays)
 	if err != nil {
 		http.Error(w, "Failed to fetch upcoming events", http.StatusInternalServerError)
 		return
 	}
 	upcomingEventCount := len(upcomingEvents)
 
-	// Fetch events for next 3 months (90 days)
-	events, err := h.db.GetUpcomingEventsByMonths(user.ID, 3)
+	// Count today's events
+	todayCount := countTodayEvents(upcomingEvents)
+
+	// Contacts overdue for a reconnect are listed separately from the dated timeline
+	reconnects, err := h.db.GetContactsDueForContact(user.ID)
 	if err != nil {
-		http.Error(w, "Failed to fetch events", http.StatusInternalServerError)
+		http.Error(w, "Failed to fetch reconnects", http.StatusInternalServerError)
 		return
 	}
-	// Count today's events
-	todayCount := countTodayEvents(events)
 
 	// Combine the timeline
 	combinedEvents := make([]models.UpcomingEvent, 0)
 	combinedEvents = append(combinedEvents, pastEvents...)
-	combinedEvents = append(combinedEvents, events...)
+	combinedEvents = append(combinedEvents, upcomingEvents...)
 
 	// Group events by month
 	monthlyGroups := groupEventsByMonth(combinedEvents)
@@ -93,8 +119,11 @@ func (h *Handler) ShowEvents(w http.ResponseWriter, r *http.Request) {
 		"ActivePage":    "events",
 		"MonthlyEvents": monthlyGroups,
 		"PastCount":     pastEventCount,
+		"PastDays":      pastDays,
 		"TodayCount":    todayCount,
 		"UpcomingCount": upcomingEventCount,
+		"UpcomingDays":  user.EventLookaheadDays,
+		"Reconnects":    reconnects,
 	})
 }
 
@@ -148,6 +177,33 @@ func (h *Handler) GetUpcomingEventsAPI(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(events)
 }
 
+// GetReconnectEventsAPI godoc
+//
+//	@Summary		Get contacts due for a reconnect
+//	@Description	Lists contacts whose contact_frequency_days has passed since they were last contacted, most overdue first. event_date is the last contact (null if never) and days_until is zero or negative
+//	@Tags			events
+//	@Produce		json
+//	@Success		200	{array}		models.UpcomingEvent	"Reconnect events"
+//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Failure		500	{object}	map[string]string		"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/events/reconnect [get]
+func (h *Handler) GetReconnectEventsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	events, err := h.db.GetContactsDueForContact(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to get events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
 // GetTodaysEventsHandler handles GET /api/v1/events/today
 func (h *Handler) GetTodaysEventsAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
@@ -192,6 +248,62 @@ func (h *Handler) GetUpcomingEventsCountAPI(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// GetEventTypesAPI godoc
+//
+//	@Summary		List event types in use
+//	@Description	Get the distinct event types (birthday, anniversary, and custom other-date names) with counts
+//	@Tags			events
+//	@Produce		json
+//	@Success		200	{array}		models.EventTypeCount	"Event types with counts"
+//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Failure		500	{object}	map[string]string		"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/events/types [get]
+func (h *Handler) GetEventTypesAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	types, err := h.db.GetEventTypeCounts(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to get event types", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types)
+}
+
+// GetCalendarFeedAPI godoc
+//
+//	@Summary		iCalendar feed of contact events
+//	@Description	Yearly recurring all-day events for every birthday, anniversary, and other date. Calendar apps that can't send headers may pass an API token as ?token=
+//	@Tags			events
+//	@Produce		text/calendar
+//	@Param			token	query		string				false	"API token (for calendar subscriptions)"
+//	@Success		200		{string}	string				"iCalendar feed"
+//	@Failure		401		{object}	map[string]string	"Unauthorized"
+//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/events/calendar.ics [get]
+func (h *Handler) GetCalendarFeedAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	dates, err := h.db.GetContactDates(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to get events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="kindredcard.ics"`)
+	w.Write([]byte(converter.ContactDatesToICS(dates, h.baseURL, time.Now())))
+}
+
 // Helper functions
 
 // countTodayEvents counts events happening today (days_until == 0)
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+func TestGetEventTypesCountsCustomNames(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	birthday := time.Date(1990, time.June, 15, 0, 0, 0, 0, time.UTC)
+	graduation := time.Date(2012, time.May, 20, 0, 0, 0, 0, time.UTC)
+
+	dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:    "Alice",
+		Birthday:    &birthday,
+		Anniversary: &graduation,
+		OtherDates: []models.OtherDate{
+			{EventName: "Graduation", EventDate: &graduation},
+			{EventName: "First Date", EventDateMonth: utils.IntPtr(2), EventDateDay: utils.IntPtr(14)},
+		},
+	})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:      "Bob",
+		BirthdayMonth: utils.IntPtr(3),
+		BirthdayDay:   utils.IntPtr(1),
+		OtherDates:    []models.OtherDate{{EventName: "Graduation", EventDate: &graduation}},
+	})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Carol"})
+
+	w := httptest.NewRecorder()
+	h.GetEventTypesAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/events/types", nil), user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	var types []models.EventTypeCount
+	if err := json.NewDecoder(w.Body).Decode(&types); err != nil {
+		t.Fatalf("decoding event types: %v", err)
+	}
+
+	got := make(map[string]int)
+	for _, et := range types {
+		got[et.EventType] = et.Count
+	}
+	want := map[string]int{"birthday": 2, "anniversary": 1, "Graduation": 2, "First Date": 1}
+	if len(got) != len(want) {
+		t.Errorf("event types = %v, want %v", got, want)
+	}
+	for eventType, count := range want {
+		if got[eventType] != count {
+			t.Errorf("%s count = %d, want %d", eventType, got[eventType], count)
+		}
+	}
+}
+
+func TestShowEventsPastDays(t *testing.T) {
+	h, database := newTemplateHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	// A birthday 20 days ago is inside a 30 day lookback but not the default 7
+	past := time.Now().AddDate(0, 0, -20)
+	dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:      "Recent Birthday",
+		BirthdayMonth: utils.IntPtr(int(past.Month())),
+		BirthdayDay:   utils.IntPtr(past.Day()),
+	})
+
+	show := func(pastDays string) string {
+		w := httptest.NewRecorder()
+		h.ShowEvents(w, withUser(httptest.NewRequest(http.MethodGet, "/events?past_days="+pastDays, nil), user))
+		if w.Code != http.StatusOK {
+			t.Fatalf("past_days=%s: status = %d, body %s", pastDays, w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+
+	page := show("30")
+	if !strings.Contains(page, "Past 30 days") {
+		t.Error("past_days=30: page doesn't show the 30 day window")
+	}
+	if !strings.Contains(page, "Recent Birthday") {
+		t.Error("past_days=30: birthday 20 days ago is missing")
+	}
+
+	page = show("0")
+	if !strings.Contains(page, "Past events hidden") {
+		t.Error("past_days=0: page doesn't say past events are hidden")
+	}
+	if strings.Contains(page, "Recent Birthday") {
+		t.Error("past_days=0: birthday 20 days ago is still listed")
+	}
+}
+
+func TestShowEventsRejectsInvalidPastDays(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, pastDays := range []string{"-1", "366", "week"} {
+		w := httptest.NewRecorder()
+		h.ShowEvents(w, withUser(httptest.NewRequest(http.MethodGet, "/events?past_days="+pastDays, nil), user))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("past_days=%s: status = %d, want %d", pastDays, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestGetCalendarFeed(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:      "Alice",
+		BirthdayMonth: utils.IntPtr(6),
+		BirthdayDay:   utils.IntPtr(15),
+	})
+
+	w := httptest.NewRecorder()
+	h.GetCalendarFeedAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/events/calendar.ics", nil), user))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("Content-Type = %q, want text/calendar", ct)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"DTSTART;VALUE=DATE:" + strconv.Itoa(time.Now().Year()) + "0615\r\n",
+		"RRULE:FREQ=YEARLY\r\n",
+		"SUMMARY:🎂 Alice's birthday\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("feed is missing %q:\n%s", want, body)
+		}
+	}
+}
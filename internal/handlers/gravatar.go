@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/steveredden/KindredCard/internal/gravatar"
+	"github.com/steveredden/KindredCard/internal/logger"
+	"github.com/steveredden/KindredCard/internal/middleware"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// gravatarBackfillSize is the pixel size requested from Gravatar
+const gravatarBackfillSize = 512
+
+// gravatarBackfillWorkers bounds how many Gravatar lookups a backfill runs at once
+const gravatarBackfillWorkers = 8
+
+// BackfillGravatarAvatarsAPI godoc
+//
+//	@Summary		Backfill avatars from Gravatar
+//	@Description	For contacts without an avatar, fetch a Gravatar for their primary email (d=404, so only real avatars are stored). Requires GRAVATAR_ENABLED=TRUE
+//	@Tags			contacts
+//	@Produce		json
+//	@Success		200	{object}	map[string]int		"checked and updated counts"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		403	{object}	map[string]string	"Gravatar integration not enabled"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/avatars/backfill-gravatar [post]
+func (h *Handler) BackfillGravatarAvatarsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	if !h.GravatarEnabled {
+		http.Error(w, "Gravatar integration not enabled; set GRAVATAR_ENABLED=TRUE", http.StatusForbidden)
+		return
+	}
+
+	contacts, err := h.db.GetContactsMissingAvatarWithEmail(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load contacts", http.StatusInternalServerError)
+		return
+	}
+
+	client := gravatar.NewClient(h.GravatarURL)
+
+	// Lookups wait on Gravatar, so a few run at once; each worker takes the next contact
+	queue := make(chan models.Contact)
+	var updated atomic.Int64
+	var wg sync.WaitGroup
+	for range min(gravatarBackfillWorkers, len(contacts)) {
+		wg.Go(func() {
+			for contact := range queue {
+				data, mimeType, err := client.FetchAvatar(contact.Emails[0].Email, gravatarBackfillSize)
+				if errors.Is(err, gravatar.ErrNotFound) {
+					continue
+				}
+				if err != nil {
+					logger.Warn("[GRAVATAR] Failed to fetch avatar for contact %d: %v", contact.ID, err)
+					continue
+				}
+
+				if err := h.db.UpdateAvatar(user.ID, contact.ID, base64.StdEncoding.EncodeToString(data), mimeType); err != nil {
+					logger.Error("[GRAVATAR] Failed to store avatar for contact %d: %v", contact.ID, err)
+					continue
+				}
+				updated.Add(1)
+			}
+		})
+	}
+	for _, contact := range contacts {
+		queue <- contact
+	}
+	close(queue)
+	wg.Wait()
+
+	logger.Info("[GRAVATAR] Backfill for user %d: checked %d, updated %d", user.ID, len(contacts), updated.Load())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{
+		"checked": len(contacts),
+		"updated": int(updated.Load()),
+	})
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/gravatar"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestBackfillGravatarAvatars(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, "/avatar/") != gravatar.Hash("has-gravatar@example.com") || r.URL.Query().Get("d") != "404" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	h.GravatarEnabled = true
+	h.GravatarURL = srv.URL
+
+	withEmail := func(name string, email string) *models.Contact {
+		return &models.Contact{
+			FullName: name,
+			Emails:   []models.Email{{Email: email, TypeLabel: "home", IsPrimary: true}},
+		}
+	}
+	found := dbtest.NewContact(t, database, user.ID, withEmail("Found", "has-gravatar@example.com"))
+	missing := dbtest.NewContact(t, database, user.ID, withEmail("Missing", "no-gravatar@example.com"))
+	hasAvatar := withEmail("Has Avatar", "has-gravatar@example.com")
+	hasAvatar.AvatarBase64 = "aGVsbG8="
+	hasAvatar.AvatarMimeType = "image/png"
+	dbtest.NewContact(t, database, user.ID, hasAvatar)
+
+	w := httptest.NewRecorder()
+	h.BackfillGravatarAvatarsAPI(w, withUser(httptest.NewRequest(http.MethodPost, "/api/v1/contacts/avatars/backfill-gravatar", nil), user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp map[string]int
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp["checked"] != 2 || resp["updated"] != 1 {
+		t.Errorf("response = %v, want 2 checked and 1 updated", resp)
+	}
+
+	for _, tt := range []struct {
+		contact *models.Contact
+		want    bool
+	}{{found, true}, {missing, false}} {
+		got, err := database.GetContactByID(user.ID, tt.contact.ID)
+		if err != nil {
+			t.Fatalf("GetContactByID: %v", err)
+		}
+		if got.HasAvatar() != tt.want {
+			t.Errorf("%s: has avatar = %v, want %v", tt.contact.FullName, got.HasAvatar(), tt.want)
+		}
+	}
+}
+
+func TestBackfillGravatarAvatarsRequiresOptIn(t *testing.T) {
+	h := &Handler{}
+
+	w := httptest.NewRecorder()
+	h.BackfillGravatarAvatarsAPI(w, withUser(httptest.NewRequest(http.MethodPost, "/api/v1/contacts/avatars/backfill-gravatar", nil), &models.User{ID: 1}))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestGetAvatarGravatarFallback(t *testing.T) {
+	h, database := newTestHandler(t)
+	h.GravatarURL = "http://gravatar.test"
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName: "Alice",
+		Emails: []models.Email{
+			{Email: "alice@work.example.com", TypeLabel: "work"},
+			{Email: " Alice@Example.com ", TypeLabel: "home", IsPrimary: true},
+		},
+	})
+	noEmail := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+
+	get := func(contactID int, query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts/"+strconv.Itoa(contactID)+"/avatar"+query, nil)
+		w := httptest.NewRecorder()
+		h.GetAvatarAPI(w, withID(withUser(r, user), contactID))
+		return w
+	}
+
+	w := get(alice.ID, "?fallback=gravatar")
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+	}
+	want := "http://gravatar.test/avatar/" + gravatar.Hash("alice@example.com") + "?d=404&s=512"
+	if got := w.Header().Get("Location"); got != want {
+		t.Errorf("Location = %s, want the primary email's Gravatar %s", got, want)
+	}
+
+	// The fallback is opt-in, so no email is disclosed without it
+	if w := get(alice.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("without fallback: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := get(noEmail.ID, "?fallback=gravatar"); w.Code != http.StatusNotFound {
+		t.Errorf("without an email: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestGetAvatarRejectsUnknownFallback(t *testing.T) {
+	h := &Handler{}
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts/1/avatar?fallback=identicon", nil)
+	w := httptest.NewRecorder()
+	h.GetAvatarAPI(w, withID(withUser(r, &models.User{ID: 1}), 1))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
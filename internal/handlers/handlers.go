@@ -10,17 +10,27 @@
 package handlers
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
 	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/emersion/go-vcard"
 	"github.com/gorilla/mux"
+	"github.com/steveredden/KindredCard/internal/avatar"
 	"github.com/steveredden/KindredCard/internal/converter"
 	"github.com/steveredden/KindredCard/internal/db"
+	"github.com/steveredden/KindredCard/internal/gravatar"
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/middleware"
 	"github.com/steveredden/KindredCard/internal/models"
@@ -33,9 +43,41 @@ type Handler struct {
 	user           *models.User
 	baseURL        string
 	releaseVersion string
+	buildInfo      BuildInfo
+
+	// EmptySearchListsContacts answers searches with an empty q with the paginated contact list
+	// (as ListContactsAPI) instead of 400; ?empty=list or ?empty=error overrides it per request
+	EmptySearchListsContacts bool
+
+	// GravatarEnabled allows the Gravatar backfill; the operator opts in because looking up a
+	// Gravatar sends a hash of the contact's email to a third party
+	GravatarEnabled bool
+
+	// GravatarURL is the Gravatar base URL used for avatar fallbacks and backfills; empty means
+	// gravatar.DefaultBaseURL
+	GravatarURL string
+
+	// SyncDebugAPIEnabled allows the /sync/tombstones debugging endpoints; purging skips the
+	// trash retention, so the operator opts in
+	SyncDebugAPIEnabled bool
+
+	// UIDDomain qualifies the UIDs generated for imported vCards without one (see utils.NewUID)
+	UIDDomain string
+
+	// AvatarFetcher downloads avatars for UploadAvatarURLAPI; nil means avatar.NewFetcher()
+	AvatarFetcher *avatar.Fetcher
+}
+
+// BuildInfo describes the running binary; values are injected via ldflags
+type BuildInfo struct {
+	Version   string
+	GitCommit string
+	BuildTime string
 }
 
-func NewHandler(database *db.Database, templatesPath string, baseURL string, releaseVersion string) (*Handler, error) {
+func NewHandler(database *db.Database, templatesPath string, baseURL string, buildInfo BuildInfo) (*Handler, error) {
+	releaseVersion := buildInfo.Version
+
 	tmpl, err := template.New("").
 		Funcs(template.FuncMap{
 			"appVersion":           func() string { return releaseVersion },
@@ -86,6 +128,7 @@ func NewHandler(database *db.Database, templatesPath string, baseURL string, rel
 		templates:      tmpl,
 		baseURL:        baseURL,
 		releaseVersion: releaseVersion,
+		buildInfo:      buildInfo,
 	}, nil
 }
 
@@ -174,18 +217,32 @@ func (h *Handler) ShowSettings(w http.ResponseWriter, r *http.Request) {
 
 // API Handlers
 
-// ListContactsAPI returns all contacts as JSON
+const (
+	// defaultContactPageSize is used when ListContactsAPI gets no ?limit
+	defaultContactPageSize = 50
+	// maxContactPageSize caps ?limit on ListContactsAPI
+	maxContactPageSize = 500
+)
+
 // ListContactsAPI godoc
 //
-//	@Summary		Lists all contacts
-//	@Description	Get all contacts for the authenticated user
+//	@Summary		Lists contacts
+//	@Description	Get a page of contacts for the authenticated user. The total count is also returned in the X-Total-Count header
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
+//	@Param			limit				query		int		false	"Page size"	default(50)	minimum(1)	maximum(500)
+//	@Param			offset				query		int		false	"Number of contacts to skip"	default(0)	minimum(0)
+//	@Param			sort				query		string	false	"Sort key (full_name, given_name, family_name, created_at, updated_at); prefix with - for descending"	default(full_name)
+//	@Param			tag					query		string	false	"Only list contacts with this tag (case-insensitive)"
+//	@Param			has_relationship	query		bool	false	"Only list contacts with (true) or without (false) relationships"
+//	@Param			relationship_type	query		string	false	"Only list contacts with a relationship of this type, e.g. Spouse (case-insensitive)"
+//	@Param			include_archived	query		bool	false	"Also list archived contacts"	default(false)
 //	@Security		SessionAuth
-//	@Success		200	{array}		models.Contact
-//	@Failure		401	{object}	models.ErrorResponse
-//	@Failure		500	{object}	models.ErrorResponse
+//	@Success		200		{object}	models.ContactPage
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
 //	@Router			/contacts [get]
 func (h *Handler) ListContactsAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
@@ -193,29 +250,74 @@ func (h *Handler) ListContactsAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	contacts, err := h.db.GetAllContacts(user.ID, false)
+	query := r.URL.Query()
+
+	limit := defaultContactPageSize
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxContactPageSize)
+	}
+
+	offset := 0
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+
+	filter := models.ContactFilter{
+		Tag:              query.Get("tag"),
+		RelationshipType: query.Get("relationship_type"),
+		IncludeArchived:  query.Get("include_archived") == "true",
+	}
+	if v := query.Get("has_relationship"); v != "" {
+		hasRelationship, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid has_relationship", http.StatusBadRequest)
+			return
+		}
+		filter.HasRelationship = &hasRelationship
+	}
+
+	contacts, total, err := h.db.ListContactsPaginated(user.ID, limit, offset, query.Get("sort"), filter)
 	if err != nil {
 		http.Error(w, "Error loading contacts", http.StatusInternalServerError)
 		return
 	}
+	models.SetDisplayNames(contacts, user.NicknameAsDisplayName)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(contacts)
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	json.NewEncoder(w).Encode(models.ContactPage{
+		Contacts: contacts,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	})
 }
 
 // GetContactAPI godoc
 //
 //	@Summary		Get a single contact
-//	@Description	Retrieve detailed information about a specific contact by ID
+//	@Description	Retrieve detailed information about a specific contact by ID. The response carries an ETag; send it back in If-None-Match to get 304 Not Modified while the contact is unchanged
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int					true	"Contact ID"	minimum(1)
-//	@Success		200	{object}	models.Contact		"Contact details"
-//	@Failure		400	{object}	map[string]string	"Invalid contact ID"
-//	@Failure		401	{object}	map[string]string	"Unauthorized"
-//	@Failure		404	{object}	map[string]string	"Contact not found"
-//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Param			id				path		int					true	"Contact ID"	minimum(1)
+//	@Param			If-None-Match	header		string				false	"ETag from a previous response"
+//	@Success		200				{object}	models.Contact		"Contact details"
+//	@Success		304				"Contact unchanged since the given ETag"
+//	@Failure		400				{object}	map[string]string	"Invalid contact ID"
+//	@Failure		401				{object}	map[string]string	"Unauthorized"
+//	@Failure		404				{object}	map[string]string	"Contact not found"
+//	@Failure		500				{object}	map[string]string	"Internal server error"
 //	@Security		ApiTokenAuth
 //	@Router			/api/v1/contacts/{id} [get]
 func (h *Handler) GetContactAPI(w http.ResponseWriter, r *http.Request) {
@@ -239,10 +341,30 @@ func (h *Handler) GetContactAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Polling clients that cached this version skip re-downloading it (and its avatar)
+	etag := fmt.Sprintf(`"%s"`, contact.ETag)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(contact)
 }
 
+// etagMatches reports whether an If-None-Match header lists etag (or is "*"). Comparison is weak,
+// per RFC 9110 13.1.2, so a W/ prefix added by a proxy still matches
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // CreateContactAPI godoc
 //
 //	@Summary		Create a new contact
@@ -285,7 +407,7 @@ func (h *Handler) CreateContactAPI(w http.ResponseWriter, r *http.Request) {
 // UpdateContactAPI godoc
 //
 //	@Summary		Update a contact (full replacement)
-//	@Description	Replace all fields of an existing contact. Use PATCH for partial updates.
+//	@Description	Replace all fields of an existing contact. Use PATCH for partial updates. Omitting kind keeps the stored kind, except that is_organization moves it to and from org. Omitted latitude/longitude, timezone, languages and tags are kept; send an empty list to clear languages or tags
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
@@ -331,6 +453,15 @@ func (h *Handler) UpdateContactAPI(w http.ResponseWriter, r *http.Request) {
 		contact.Metadata = "skip relationships"
 	}
 
+	if contact.Kind != "" {
+		kind, ok := models.NormalizeContactKind(contact.Kind)
+		if !ok {
+			http.Error(w, "Invalid kind; expected one of individual, org, group", http.StatusBadRequest)
+			return
+		}
+		contact.Kind = kind
+	}
+
 	contact.ID = id
 	contact.FullName = contact.GenerateFullName()
 
@@ -354,7 +485,7 @@ func (h *Handler) UpdateContactAPI(w http.ResponseWriter, r *http.Request) {
 // DeleteContactAPI godoc
 //
 //	@Summary		Delete a contact
-//	@Description	Soft delete a contact by ID. The contact will be marked as deleted but not permanently removed.
+//	@Description	Soft delete a contact by ID. The contact will be marked as deleted but not permanently removed. Its relationships are removed and do not come back if the contact is restored; the contacts on the other side of them change too
 //	@Tags			contacts
 //	@Accept			json
 //	@Produce		json
@@ -390,22 +521,26 @@ func (h *Handler) DeleteContactAPI(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// SearchContactsAPI searches contacts
-func (h *Handler) SearchContactsAPI(w http.ResponseWriter, r *http.Request) {
+// ListTrashAPI godoc
+//
+//	@Summary		List deleted contacts
+//	@Description	Lists soft-deleted contacts (most recent first) that can still be restored. Trashed contacts are purged after CONTACT_TRASH_RETENTION_DAYS (default 30) days
+//	@Tags			contacts
+//	@Produce		json
+//	@Success		200	{array}		models.Contact		"Abbreviated deleted contacts"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/trash [get]
+func (h *Handler) ListTrashAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		return
 	}
 
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		http.Error(w, "Search query required", http.StatusBadRequest)
-		return
-	}
-
-	contacts, err := h.db.SearchContacts(user.ID, query)
+	contacts, err := h.db.ListDeletedContacts(user.ID)
 	if err != nil {
-		http.Error(w, "Error searching contacts", http.StatusInternalServerError)
+		http.Error(w, "Error loading deleted contacts", http.StatusInternalServerError)
 		return
 	}
 
@@ -413,222 +548,894 @@ func (h *Handler) SearchContactsAPI(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(contacts)
 }
 
-// GetRelationshipTypesAPI returns all available relationship types
-func (h *Handler) GetRelationshipTypesAPI(w http.ResponseWriter, r *http.Request) {
-	types, err := h.db.GetRelationshipTypes()
-	if err != nil {
-		http.Error(w, "Error loading relationship types", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(types)
-}
-
-// AddRelationshipAPI godoc
+// RestoreContactAPI godoc
 //
-//	@Summary		Add relationship to contact
-//	@Description	Create a relationship between two contacts (e.g., spouse, child, parent)
-//	@Tags			relationships
-//	@Accept			json
+//	@Summary		Restore a deleted contact
+//	@Description	Takes a soft-deleted contact out of the trash. Relationships to other contacts are not restored
+//	@Tags			contacts
 //	@Produce		json
-//	@Param			id				path		int					true	"Contact ID"	minimum(1)
-//	@Param			relationship	body		models.Relationship	true	"Relationship details"
-//	@Success		201				{object}	models.Relationship	"Created relationship"
-//	@Failure		400				{object}	map[string]string	"Invalid request body"
-//	@Failure		401				{object}	map[string]string	"Unauthorized"
-//	@Failure		404				{object}	map[string]string	"Contact not found"
-//	@Failure		409				{object}	map[string]string	"Relationship already exists"
-//	@Failure		500				{object}	map[string]string	"Internal server error"
+//	@Param			id	path		int					true	"Contact ID"	minimum(1)
+//	@Success		200	{object}	models.Contact		"The restored contact"
+//	@Failure		400	{object}	map[string]string	"Invalid contact ID"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		404	{object}	map[string]string	"Contact not in the trash"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
 //	@Security		ApiTokenAuth
-//	@Router			/api/v1/contacts/{id}/relationships [post]
-func (h *Handler) AddRelationshipAPI(w http.ResponseWriter, r *http.Request) {
+//	@Router			/api/v1/contacts/{id}/restore [post]
+func (h *Handler) RestoreContactAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		return
 	}
 
-	vars := mux.Vars(r)
-	contactIDStr := vars["id"]
-
-	contactID, err := strconv.Atoi(contactIDStr)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
 		return
 	}
 
-	var req struct {
-		RelatedContactID   int `json:"related_contact_id"`
-		RelationshipTypeID int `json:"relationship_type_id"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := h.db.RestoreContact(user.ID, id); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not in the trash", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error restoring contact", http.StatusInternalServerError)
 		return
 	}
 
-	if err := h.db.AddRelationship(user.ID, contactID, req.RelatedContactID, req.RelationshipTypeID); err != nil {
-		http.Error(w, "Error adding relationship", http.StatusInternalServerError)
+	contact, err := h.db.GetContactByID(user.ID, id)
+	if err != nil {
+		http.Error(w, "Error loading contact", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte(`{"status":"ok"}`))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contact)
 }
 
-// RemoveRelationshipAPI deletes a relationship
-func (h *Handler) RemoveRelationshipAPI(w http.ResponseWriter, r *http.Request) {
+// ArchiveContactAPI godoc
+//
+//	@Summary		Archive a contact
+//	@Description	Hides a contact from default lists, search, events, and CardDAV without deleting it. Archived contacts stay available by ID and with include_archived=true
+//	@Tags			contacts
+//	@Produce		json
+//	@Param			id	path		int					true	"Contact ID"	minimum(1)
+//	@Success		200	{object}	models.Contact		"The archived contact"
+//	@Failure		400	{object}	map[string]string	"Invalid contact ID"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		404	{object}	map[string]string	"Contact not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{id}/archive [post]
+func (h *Handler) ArchiveContactAPI(w http.ResponseWriter, r *http.Request) {
+	h.setContactArchived(w, r, true)
+}
+
+// UnarchiveContactAPI godoc
+//
+//	@Summary		Unarchive a contact
+//	@Description	Returns an archived contact to lists, search, events, and CardDAV
+//	@Tags			contacts
+//	@Produce		json
+//	@Param			id	path		int					true	"Contact ID"	minimum(1)
+//	@Success		200	{object}	models.Contact		"The unarchived contact"
+//	@Failure		400	{object}	map[string]string	"Invalid contact ID"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		404	{object}	map[string]string	"Contact not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{id}/unarchive [post]
+func (h *Handler) UnarchiveContactAPI(w http.ResponseWriter, r *http.Request) {
+	h.setContactArchived(w, r, false)
+}
+
+func (h *Handler) setContactArchived(w http.ResponseWriter, r *http.Request, archived bool) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		return
 	}
 
-	vars := mux.Vars(r)
-	relIDStr := vars["rel_id"]
-
-	relID, err := strconv.Atoi(relIDStr)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		http.Error(w, "Invalid relationship ID", http.StatusBadRequest)
-		return
-	}
-
-	if err := h.db.RemoveRelationship(user.ID, relID); err != nil {
-		http.Error(w, "Error removing relationship", http.StatusInternalServerError)
+		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
-}
-
-// RemoveRelationshipAPI deletes a relationship
-func (h *Handler) RemoveOtherRelationshipAPI(w http.ResponseWriter, r *http.Request) {
-	user, ok := middleware.GetUserFromContext(r)
-	if !ok {
+	if err := h.db.SetContactArchived(user.ID, id, archived); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error archiving contact", http.StatusInternalServerError)
 		return
 	}
 
-	vars := mux.Vars(r)
-	relIDStr := vars["rel_id"]
-
-	relID, err := strconv.Atoi(relIDStr)
+	contact, err := h.db.GetContactByID(user.ID, id)
 	if err != nil {
-		http.Error(w, "Invalid other relationship ID", http.StatusBadRequest)
-		return
-	}
-
-	if err := h.db.RemoveOtherRelationship(user.ID, relID); err != nil {
-		http.Error(w, "Error removing relationship", http.StatusInternalServerError)
+		http.Error(w, "Error loading contact", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contact)
 }
 
-// UploadAvatarAPI handles avatar uploads
-func (h *Handler) UploadAvatarAPI(w http.ResponseWriter, r *http.Request) {
+// TouchContactAPI godoc
+//
+//	@Summary		Mark a contact as contacted
+//	@Description	Sets the contact's last_contacted_at to now, restarting its contact_frequency_days reconnect countdown
+//	@Tags			contacts
+//	@Produce		json
+//	@Param			id	path		int					true	"Contact ID"	minimum(1)
+//	@Success		200	{object}	models.Contact		"The touched contact"
+//	@Failure		400	{object}	map[string]string	"Invalid contact ID"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		404	{object}	map[string]string	"Contact not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{id}/touch [post]
+func (h *Handler) TouchContactAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		return
 	}
 
-	vars := mux.Vars(r)
-	contactIDStr := vars["id"]
-
-	contactID, err := strconv.Atoi(contactIDStr)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
 		return
 	}
 
-	var req struct {
-		Avatar string `json:"avatar"` // base64 encoded
+	if err := h.db.TouchContact(user.ID, id); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error updating contact", http.StatusInternalServerError)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+	contact, err := h.db.GetContactByID(user.ID, id)
+	if err != nil {
+		http.Error(w, "Error loading contact", http.StatusInternalServerError)
 		return
 	}
 
-	if len(req.Avatar) == 0 {
-		http.Error(w, "Avatar data required", http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contact)
+}
+
+// ListTombstonesAPI godoc
+//
+//	@Summary		List sync tombstones
+//	@Description	Lists the authenticated user's soft-deleted contacts with the version tokens CardDAV clients see them by. Intended for sync debugging; requires ENABLE_SYNC_DEBUG_API=TRUE
+//	@Tags			sync
+//	@Produce		json
+//	@Success		200	{array}		models.Tombstone
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		403	{object}	map[string]string	"Sync debug API not enabled"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/sync/tombstones [get]
+func (h *Handler) ListTombstonesAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
 		return
 	}
 
-	// Detect MIME type
-	mimeType := "image/jpeg"
-	if len(req.Avatar) > 10 {
-		switch req.Avatar[0] {
-		case '/':
-			mimeType = "image/jpeg"
-		case 'i':
-			mimeType = "image/png"
-		case 'R':
-			mimeType = "image/gif"
-		}
+	if !h.SyncDebugAPIEnabled {
+		http.Error(w, "Sync debug API not enabled; set ENABLE_SYNC_DEBUG_API=TRUE", http.StatusForbidden)
+		return
 	}
 
-	err = h.db.UpdateAvatar(user.ID, contactID, req.Avatar, mimeType)
+	tombstones, err := h.db.ListTombstones(user.ID)
 	if err != nil {
-		http.Error(w, "Failed to update avatar", http.StatusInternalServerError)
+		http.Error(w, "Error loading tombstones", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tombstones)
 }
 
-// DeleteAvatarAPI handles avatar removals
-func (h *Handler) DeleteAvatarAPI(w http.ResponseWriter, r *http.Request) {
+// PurgeTombstonesAPI godoc
+//
+//	@Summary		Purge sync tombstones
+//	@Description	Permanently deletes the authenticated user's contacts that have been in the trash longer than older_than, without waiting for the retention job. Clients that haven't synced the deletion yet will keep their copy. Requires ENABLE_SYNC_DEBUG_API=TRUE
+//	@Tags			sync
+//	@Produce		json
+//	@Param			older_than	query		string				true	"Minimum age, e.g. 7days or 7 (0 purges every tombstone)"
+//	@Success		200			{object}	map[string]int		"Number of contacts purged"
+//	@Failure		400			{object}	map[string]string	"Invalid older_than"
+//	@Failure		401			{object}	map[string]string	"Unauthorized"
+//	@Failure		403			{object}	map[string]string	"Sync debug API not enabled"
+//	@Failure		500			{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/sync/tombstones [delete]
+func (h *Handler) PurgeTombstonesAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		return
 	}
 
-	vars := mux.Vars(r)
-	contactIDStr := vars["id"]
+	if !h.SyncDebugAPIEnabled {
+		http.Error(w, "Sync debug API not enabled; set ENABLE_SYNC_DEBUG_API=TRUE", http.StatusForbidden)
+		return
+	}
 
-	contactID, err := strconv.Atoi(contactIDStr)
-	if err != nil {
-		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+	olderThan := strings.TrimSuffix(strings.TrimSpace(r.URL.Query().Get("older_than")), "days")
+	days, err := strconv.Atoi(strings.TrimSpace(olderThan))
+	if err != nil || days < 0 {
+		http.Error(w, "older_than must be a number of days, e.g. 7days", http.StatusBadRequest)
 		return
 	}
 
-	err = h.db.DeleteAvatar(user.ID, contactID)
+	purged, err := h.db.PurgeTombstones(user.ID, days)
 	if err != nil {
-		http.Error(w, "Failed to delete avatar", http.StatusInternalServerError)
+		http.Error(w, "Error purging tombstones", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"purged": purged})
 }
 
-// ExportContactVCardAPI exports a single contact as vCard
-func (h *Handler) ExportContactVCardAPI(w http.ResponseWriter, r *http.Request) {
+// SearchContactsAPI searches contacts
+//
+// ?fields= takes a comma-separated subset of name, email, phone, address (street and city),
+// and notes to search; all of them are searched by default. Archived contacts are only
+// included with ?include_archived=true. Names are matched approximately when nothing matches
+// exactly, or always with ?fuzzy=true
+//
+// An empty q is a 400 unless SEARCH_EMPTY_QUERY=LIST or ?empty=list, in which case the paginated
+// contact list is returned, taking the same parameters as ListContactsAPI
+func (h *Handler) SearchContactsAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		return
 	}
 
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		listContacts := h.EmptySearchListsContacts
+		switch strings.ToLower(r.URL.Query().Get("empty")) {
+		case "":
+		case "list":
+			listContacts = true
+		case "error":
+			listContacts = false
+		default:
+			http.Error(w, "Invalid empty value; expected list or error", http.StatusBadRequest)
+			return
+		}
+
+		if listContacts {
+			h.ListContactsAPI(w, r)
+			return
+		}
+		http.Error(w, "Search query required", http.StatusBadRequest)
 		return
 	}
 
-	contact, err := h.db.GetContactByID(user.ID, id)
+	opts := models.ContactSearchOptions{
+		IncludeArchived: r.URL.Query().Get("include_archived") == "true",
+		Fuzzy:           r.URL.Query().Get("fuzzy") == "true",
+	}
+	if v := r.URL.Query().Get("fields"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			field = strings.ToLower(strings.TrimSpace(field))
+			if !db.IsContactSearchField(field) {
+				http.Error(w, "Invalid search field: "+field, http.StatusBadRequest)
+				return
+			}
+			opts.Fields = append(opts.Fields, field)
+		}
+	}
+
+	contacts, err := h.db.SearchContacts(user.ID, query, opts)
 	if err != nil {
-		logger.Error("[HANDLER] Error retriving contact: %v", err)
-		http.Error(w, "Contact not found", http.StatusNotFound)
+		http.Error(w, "Error searching contacts", http.StatusInternalServerError)
 		return
 	}
+	models.SetDisplayNames(contacts, user.NicknameAsDisplayName)
 
-	labelMap, _ := h.db.GetLabelMap()
-
-	// Convert to vCard
-	card := converter.ContactToVCard(contact, labelMap, false)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contacts)
+}
+
+// GetRelationshipTypesAPI returns all available relationship types
+func (h *Handler) GetRelationshipTypesAPI(w http.ResponseWriter, r *http.Request) {
+	types, err := h.db.GetRelationshipTypes()
+	if err != nil {
+		http.Error(w, "Error loading relationship types", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types)
+}
+
+// SeedRelationshipTypesAPI godoc
+//
+//	@Summary		Seed relationship types
+//	@Description	Inserts any missing standard relationship types and repairs missing or incorrect reverse names. Existing types are never duplicated, so this is safe to call repeatedly. The same step runs on startup
+//	@Tags			relationships
+//	@Produce		json
+//	@Success		200	{object}	map[string]int		"Counts of inserted and repaired types"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/relationship-types/seed [post]
+func (h *Handler) SeedRelationshipTypesAPI(w http.ResponseWriter, r *http.Request) {
+	if _, ok := middleware.GetUserFromContext(r); !ok {
+		return
+	}
+
+	inserted, repaired, err := h.db.SeedRelationshipTypes()
+	if err != nil {
+		http.Error(w, "Error seeding relationship types", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{
+		"inserted": inserted,
+		"repaired": repaired,
+	})
+}
+
+// RefreshRelationshipTypeAPI godoc
+//
+//	@Summary		Refresh contacts using a relationship type
+//	@Description	Bumps the sync tokens of every contact on either side of a relationship of this type, so CardDAV clients re-download their cards. Run this after renaming a relationship type: relationships follow the rename automatically, but clients keep the old name until the cards change
+//	@Tags			relationships
+//	@Produce		json
+//	@Param			id	path		int					true	"Relationship type ID"	minimum(1)
+//	@Success		200	{object}	map[string]int		"Count of refreshed contacts"
+//	@Failure		400	{object}	map[string]string	"Invalid relationship type ID"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		404	{object}	map[string]string	"Relationship type not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/relationship-types/{id}/refresh [post]
+func (h *Handler) RefreshRelationshipTypeAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	typeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid relationship type ID", http.StatusBadRequest)
+		return
+	}
+
+	refreshed, err := h.db.RefreshRelationshipTypeContacts(user.ID, typeID)
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Relationship type not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error refreshing contacts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"refreshed": refreshed})
+}
+
+// GetHouseholdAPI godoc
+//
+//	@Summary		Get a contact's household
+//	@Description	Groups a contact with their spouse/partner and children (or, without either, their parents and siblings) by following relationships. Set extended=true to also include siblings, grandparents, in-laws, and other family. The contact itself is the first member
+//	@Tags			relationships
+//	@Produce		json
+//	@Param			id			path		int							true	"Contact ID"	minimum(1)
+//	@Param			extended	query		bool						false	"Include extended family"
+//	@Param			depth		query		int							false	"Relationship hops to follow"	minimum(1)	maximum(5)	default(2)
+//	@Success		200			{array}		models.HouseholdMember		"Household members"
+//	@Failure		400			{object}	map[string]string			"Invalid contact ID or parameter"
+//	@Failure		401			{object}	map[string]string			"Unauthorized"
+//	@Failure		404			{object}	map[string]string			"Contact not found"
+//	@Failure		500			{object}	map[string]string			"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{id}/household [get]
+func (h *Handler) GetHouseholdAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	contactID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+		return
+	}
+
+	extended := false
+	if v := r.URL.Query().Get("extended"); v != "" {
+		if extended, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Invalid extended value", http.StatusBadRequest)
+			return
+		}
+	}
+
+	depth := db.DefaultHouseholdDepth
+	if v := r.URL.Query().Get("depth"); v != "" {
+		depth, err = strconv.Atoi(v)
+		if err != nil || depth < 1 || depth > db.MaxHouseholdDepth {
+			http.Error(w, fmt.Sprintf("depth must be between 1 and %d", db.MaxHouseholdDepth), http.StatusBadRequest)
+			return
+		}
+	}
+
+	members, err := h.db.GetHousehold(user.ID, contactID, extended, depth)
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error loading household", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+// AddRelationshipAPI godoc
+//
+//	@Summary		Add relationship to contact
+//	@Description	Create a relationship between two contacts (e.g., spouse, child, parent)
+//	@Tags			relationships
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int					true	"Contact ID"	minimum(1)
+//	@Param			relationship	body		models.Relationship	true	"Relationship details"
+//	@Success		200				{object}	models.Relationship	"Relationship already existed"
+//	@Success		201				{object}	models.Relationship	"Created relationship"
+//	@Failure		400				{object}	map[string]string	"Invalid request body"
+//	@Failure		401				{object}	map[string]string	"Unauthorized"
+//	@Failure		404				{object}	map[string]string	"Contact not found"
+//	@Failure		409				{object}	map[string]string	"Relationship already exists"
+//	@Failure		500				{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{id}/relationships [post]
+func (h *Handler) AddRelationshipAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	contactIDStr := vars["id"]
+
+	contactID, err := strconv.Atoi(contactIDStr)
+	if err != nil {
+		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		RelatedContactID   int `json:"related_contact_id"`
+		RelationshipTypeID int `json:"relationship_type_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rel, created, err := h.db.AddRelationship(user.ID, contactID, req.RelatedContactID, req.RelationshipTypeID)
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error adding relationship", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(rel)
+}
+
+// RemoveRelationshipAPI deletes a relationship
+func (h *Handler) RemoveRelationshipAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	relIDStr := vars["rel_id"]
+
+	relID, err := strconv.Atoi(relIDStr)
+	if err != nil {
+		http.Error(w, "Invalid relationship ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.RemoveRelationship(user.ID, relID); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Relationship not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error removing relationship", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveRelationshipAPI deletes a relationship
+func (h *Handler) RemoveOtherRelationshipAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	relIDStr := vars["rel_id"]
+
+	relID, err := strconv.Atoi(relIDStr)
+	if err != nil {
+		http.Error(w, "Invalid other relationship ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.RemoveOtherRelationship(user.ID, relID); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Relationship not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error removing relationship", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadAvatarAPI handles avatar uploads. The format is sniffed from the decoded bytes and anything
+// other than JPEG, PNG, or GIF is rejected with 415. Images are downscaled to at most
+// avatar.MaxDimension pixels and stored as JPEG; a converted upload is kept as the original for
+// ?avatar=original exports. Images over avatar.MaxSourcePixels are rejected with 413 before decoding
+func (h *Handler) UploadAvatarAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	contactIDStr := vars["id"]
+
+	contactID, err := strconv.Atoi(contactIDStr)
+	if err != nil {
+		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Avatar string `json:"avatar"` // base64 encoded
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Avatar) == 0 {
+		http.Error(w, "Avatar data required", http.StatusBadRequest)
+		return
+	}
+
+	original, err := base64.StdEncoding.DecodeString(req.Avatar)
+	if err != nil {
+		http.Error(w, "Avatar must be base64 encoded", http.StatusBadRequest)
+		return
+	}
+
+	// Store a small JPEG for the UI, vCards, and CardDAV; the upload is kept as the original
+	img, err := avatar.Process(original)
+	if errors.Is(err, avatar.ErrUnsupportedImage) {
+		http.Error(w, fmt.Sprintf("Unsupported avatar type %s; use JPEG, PNG, or GIF", avatar.DetectMimeType(original)), http.StatusUnsupportedMediaType)
+		return
+	}
+	if errors.Is(err, avatar.ErrImageTooLarge) {
+		http.Error(w, "Avatar image dimensions are too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Avatar is not a valid image", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.storeAvatar(user.ID, contactID, img, original); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update avatar", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// UploadAvatarURLAPI godoc
+//
+//	@Summary		Set avatar from a URL
+//	@Description	Downloads a JPEG, PNG, or GIF image (at most 10MB) and stores it as the contact's avatar, downscaled like uploads. URLs resolving to private or loopback addresses are refused
+//	@Tags			contacts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Contact ID"	minimum(1)
+//	@Param			request	body		map[string]string	true	"Image URL, e.g. {\"url\": \"https://...\"}"
+//	@Success		200		{object}	map[string]string	"Avatar stored"
+//	@Failure		400		{object}	map[string]string	"Invalid URL, blocked address, or not an image"
+//	@Failure		401		{object}	map[string]string	"Unauthorized"
+//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{id}/avatar/url [post]
+func (h *Handler) UploadAvatarURLAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	contactID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.URL) == "" {
+		http.Error(w, "Image URL required", http.StatusBadRequest)
+		return
+	}
+
+	fetcher := h.AvatarFetcher
+	if fetcher == nil {
+		fetcher = avatar.NewFetcher()
+	}
+	original, err := fetcher.Fetch(strings.TrimSpace(req.URL))
+	if err != nil {
+		logger.Warn("[HANDLER] Failed to fetch avatar for contact %d: %v", contactID, err)
+		http.Error(w, "Could not fetch image", http.StatusBadRequest)
+		return
+	}
+
+	img, err := avatar.Process(original)
+	if errors.Is(err, avatar.ErrImageTooLarge) {
+		http.Error(w, "Image dimensions are too large", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "URL did not return a valid JPEG, PNG, or GIF image", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.storeAvatar(user.ID, contactID, img, original); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update avatar", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok", "mime_type": img.MimeType})
+}
+
+// storeAvatar saves a processed avatar, keeping the original bytes when the stored copy was converted
+func (h *Handler) storeAvatar(userID int, contactID int, img *avatar.Image, original []byte) error {
+	if err := h.db.UpdateAvatar(userID, contactID, base64.StdEncoding.EncodeToString(img.Data), img.MimeType); err != nil {
+		return err
+	}
+
+	if img.Converted {
+		if err := h.db.SetAvatarOriginal(userID, contactID, base64.StdEncoding.EncodeToString(original), img.OriginalMimeType); err != nil {
+			logger.Warn("[HANDLER] Failed to keep original avatar for contact %d: %v", contactID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetAvatarAPI godoc
+//
+//	@Summary		Get a contact's avatar
+//	@Description	Returns the stored avatar as raw image bytes for use in <img src>, with an ETag for If-None-Match revalidation (304). Without a stored avatar, fallback=gravatar redirects to the Gravatar for the contact's primary email (d=404, so a missing Gravatar is a 404 rather than a placeholder). The fallback is opt-in per request because it discloses a hash of the email to Gravatar
+//	@Tags			contacts
+//	@Produce		image/jpeg
+//	@Produce		image/png
+//	@Produce		image/gif
+//	@Param			id				path		int					true	"Contact ID"	minimum(1)
+//	@Param			fallback		query		string				false	"Fallback when no avatar is stored"	enums(gravatar)
+//	@Param			If-None-Match	header		string				false	"ETag from a previous response"
+//	@Success		200				{file}		file				"Avatar image"
+//	@Success		302				"Redirect to Gravatar"
+//	@Success		304				"Avatar unchanged since the given ETag"
+//	@Failure		400				{object}	map[string]string	"Invalid contact ID or fallback"
+//	@Failure		401				{object}	map[string]string	"Unauthorized"
+//	@Failure		404				{object}	map[string]string	"Contact or avatar not found"
+//	@Failure		500				{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{id}/avatar [get]
+func (h *Handler) GetAvatarAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	contactID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+		return
+	}
+
+	fallback := r.URL.Query().Get("fallback")
+	if fallback != "" && fallback != "gravatar" {
+		http.Error(w, "Invalid fallback; expected gravatar", http.StatusBadRequest)
+		return
+	}
+
+	avatarBase64, mimeType, contactETag, err := h.db.GetAvatar(user.ID, contactID)
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error loading avatar", http.StatusInternalServerError)
+		return
+	}
+
+	if avatarBase64 == "" {
+		if fallback == "gravatar" {
+			if email, err := h.db.GetPrimaryEmail(user.ID, contactID); err == nil && email != "" {
+				client := gravatar.NewClient(h.GravatarURL)
+				http.Redirect(w, r, client.AvatarURL(email, gravatarBackfillSize), http.StatusFound)
+				return
+			}
+		}
+		http.Error(w, "Avatar not found", http.StatusNotFound)
+		return
+	}
+
+	data, err := base64.StdEncoding.DecodeString(avatarBase64)
+	if err != nil {
+		logger.Error("[HANDLER] Stored avatar for contact %d is not valid base64: %v", contactID, err)
+		http.Error(w, "Avatar not found", http.StatusNotFound)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s"`, contactETag)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if mimeType == "" {
+		mimeType = avatar.DetectMimeType(data)
+	}
+	w.Header().Set("Content-Type", mimeType)
+	w.Write(data)
+}
+
+// DeleteAvatarAPI handles avatar removals
+func (h *Handler) DeleteAvatarAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	contactIDStr := vars["id"]
+
+	contactID, err := strconv.Atoi(contactIDStr)
+	if err != nil {
+		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.db.DeleteAvatar(user.ID, contactID)
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete avatar", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// ExportContactVCardAPI exports a single contact as vCard
+//
+// When ?include_related=true is supplied, the cards of every related contact
+// are appended to the same .vcf so relationships remain resolvable on import
+//
+// When ?redact=true is supplied, names, contact methods, notes, and photos are
+// replaced with placeholders (see converter.RedactContact) so the card can be
+// shared in bug reports without exposing personal data
+//
+// When ?avatar=original is supplied, photos are exported at full resolution
+// instead of the (possibly resized) avatar shown in the web UI
+//
+// When ?client=apple or ?client=generic is supplied, the card is encoded exactly as
+// CardDAV serves it to that kind of client (including the nickname-as-FN preference),
+// for comparing what a client shows against the web UI
+func (h *Handler) ExportContactVCardAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+		return
+	}
+
+	contact, err := h.db.GetContactByID(user.ID, id)
+	if err != nil {
+		logger.Error("[HANDLER] Error retriving contact: %v", err)
+		http.Error(w, "Contact not found", http.StatusNotFound)
+		return
+	}
+
+	asClient, isAppleClient := false, false
+	switch r.URL.Query().Get("client") {
+	case "":
+	case "generic":
+		asClient = true
+	case "apple":
+		asClient, isAppleClient = true, true
+	default:
+		http.Error(w, "Invalid client; expected apple or generic", http.StatusBadRequest)
+		return
+	}
+
+	// Without the label map custom labels would silently export untyped
+	labelMap, err := h.db.GetLabelTypesMap()
+	if err != nil {
+		http.Error(w, "Error loading contact labels", http.StatusInternalServerError)
+		return
+	}
+
+	redact := r.URL.Query().Get("redact") == "true"
+	originalAvatar := r.URL.Query().Get("avatar") == "original"
+	if redact {
+		contact = converter.RedactContact(contact)
+	} else if originalAvatar {
+		h.useOriginalAvatar(user.ID, contact)
+	}
+
+	toVCard := func(c *models.Contact) vcard.Card {
+		if asClient {
+			c = converter.NicknameAsFullName(c, user.NicknameAsDisplayName)
+		}
+		return converter.ContactToVCard(c, labelMap, isAppleClient)
+	}
+
+	// Convert to vCard
+	card := toVCard(contact)
 
 	var buf bytes.Buffer
 	encoder := vcard.NewEncoder(&buf)
@@ -638,6 +1445,34 @@ func (h *Handler) ExportContactVCardAPI(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if r.URL.Query().Get("include_related") == "true" {
+		// Deduplicate: a contact may appear in several relationships (or both directions)
+		seen := map[int]bool{contact.ID: true}
+		for _, rel := range contact.Relationships {
+			if seen[rel.RelatedContactID] {
+				continue
+			}
+			seen[rel.RelatedContactID] = true
+
+			related, err := h.db.GetContactByID(user.ID, rel.RelatedContactID)
+			if err != nil {
+				logger.Warn("[HANDLER] Skipping related contact %d: %v", rel.RelatedContactID, err)
+				continue
+			}
+
+			if redact {
+				related = converter.RedactContact(related)
+			} else if originalAvatar {
+				h.useOriginalAvatar(user.ID, related)
+			}
+
+			if err := encoder.Encode(toVCard(related)); err != nil {
+				logger.Error("[HANDLER] Error encoding related vCard: %v", err)
+				continue
+			}
+		}
+	}
+
 	// Set headers for download
 	filename := contact.FullName
 	if filename == "" {
@@ -648,10 +1483,27 @@ func (h *Handler) ExportContactVCardAPI(w http.ResponseWriter, r *http.Request)
 	w.Write(buf.Bytes())
 }
 
+// useOriginalAvatar swaps a contact's avatar for its full-resolution original, if one was kept
+func (h *Handler) useOriginalAvatar(userID int, contact *models.Contact) {
+	if contact.AvatarBase64 == "" {
+		return
+	}
+
+	avatarBase64, mimeType, err := h.db.GetAvatarOriginal(userID, contact.ID)
+	if err != nil {
+		logger.Warn("[HANDLER] Using stored avatar for contact %d: %v", contact.ID, err)
+		return
+	}
+	if avatarBase64 != "" {
+		contact.AvatarBase64 = avatarBase64
+		contact.AvatarMimeType = mimeType
+	}
+}
+
 // ExportAllVCardsAPI godoc
 //
 //	@Summary		Export all contacts as vCard
-//	@Description	Download all contacts in vCard (.vcf) format
+//	@Description	Download all contacts in vCard (.vcf) format. Each relationship is written once, on the contact that stores it, so the file re-imports without duplicate mirrors
 //	@Tags			export
 //	@Produce		text/vcard
 //	@Success		200	{file}		file				"vCard file download"
@@ -674,9 +1526,23 @@ func (h *Handler) ExportAllVCardsAPI(w http.ResponseWriter, r *http.Request) {
 	var buf bytes.Buffer
 	encoder := vcard.NewEncoder(&buf)
 
-	labelMap, _ := h.db.GetLabelMap()
+	labelMap, err := h.db.GetLabelTypesMap()
+	if err != nil {
+		http.Error(w, "Error loading contact labels", http.StatusInternalServerError)
+		return
+	}
 
 	for _, contact := range contacts {
+		// Every related contact is in this export, so emit only stored relationships and let the
+		// import recompute reverses; emitting both directions re-imports as conflicting mirrors
+		stored := make([]models.Relationship, 0, len(contact.Relationships))
+		for _, rel := range contact.Relationships {
+			if !rel.IsReverse {
+				stored = append(stored, rel)
+			}
+		}
+		contact.Relationships = stored
+
 		card := converter.ContactToVCard(contact, labelMap, false)
 		if err := encoder.Encode(card); err != nil {
 			continue
@@ -688,6 +1554,172 @@ func (h *Handler) ExportAllVCardsAPI(w http.ResponseWriter, r *http.Request) {
 	w.Write(buf.Bytes())
 }
 
+// ExportAvatarsAPI godoc
+//
+//	@Summary		Export all avatars as a zip
+//	@Description	Download every contact avatar at full resolution, one file per contact named by its UID with an extension matching the image type. Contacts without avatars are skipped
+//	@Tags			export
+//	@Produce		application/zip
+//	@Success		200	{file}		file				"Zip file download"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/avatars/export.zip [get]
+func (h *Handler) ExportAvatarsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"kindredcard-avatars.zip\"")
+
+	// Entries are streamed as they're read; once the first one is written the status can no
+	// longer change, so later failures are only logged and the client sees a truncated zip
+	zw := zip.NewWriter(w)
+	written := 0
+	err := h.db.EachAvatar(user.ID, func(uid, avatarBase64, mimeType string) error {
+		data, err := base64.StdEncoding.DecodeString(avatarBase64)
+		if err != nil {
+			logger.Warn("[HANDLER] Skipping undecodable avatar for contact %s: %v", uid, err)
+			return nil
+		}
+
+		// Images are already compressed, so store them as-is
+		entry, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     avatarEntryName(uid) + avatarExtension(mimeType),
+			Method:   zip.Store,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(data); err != nil {
+			return err
+		}
+		written++
+		return nil
+	})
+	if err != nil {
+		logger.Error("[HANDLER] Error exporting avatars: %v", err)
+		if written == 0 {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "Error exporting avatars", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := zw.Close(); err != nil {
+		logger.Error("[HANDLER] Error finishing avatar zip: %v", err)
+	}
+}
+
+// avatarEntryName makes a contact UID safe to use as a zip entry name. UIDs come from clients and
+// can hold path separators or "..", which would let an entry land outside the folder it's
+// extracted to
+func avatarEntryName(uid string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < 0x20 || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, uid)
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+// avatarExtension maps an avatar MIME type to a file extension, ".bin" when unknown
+func avatarExtension(mimeType string) string {
+	switch strings.ToLower(mimeType) {
+	case "image/jpeg", "image/jpg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	}
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// ExportAllCSVAPI godoc
+//
+//	@Summary		Export all contacts as CSV
+//	@Description	Download all contacts as a flattened CSV (one row per contact; multi-valued fields are semicolon-joined with the primary value first)
+//	@Tags			export
+//	@Produce		text/csv
+//	@Success		200	{file}		file				"CSV file download"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/export/csv [get]
+func (h *Handler) ExportAllCSVAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	contacts, err := h.db.GetAllContacts(user.ID, false) // Get all contacts
+	if err != nil {
+		http.Error(w, "Error loading contacts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"kindredcard-contacts.csv\"")
+
+	writer := csv.NewWriter(w)
+	writer.Write(converter.CSVHeader)
+	for _, contact := range contacts {
+		if err := writer.Write(converter.ContactToCSVRecord(contact)); err != nil {
+			logger.Error("[HANDLER] Error writing CSV row for contact %d: %v", contact.ID, err)
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.Error("[HANDLER] Error flushing CSV export: %v", err)
+	}
+}
+
+// ExportAllTextAPI godoc
+//
+//	@Summary		Export all contacts as a plain-text directory
+//	@Description	Download a human-readable phone book: contacts sorted by name and grouped by initial letter, each listing their phones, emails, addresses, and birthday
+//	@Tags			export
+//	@Produce		plain
+//	@Success		200	{file}		file				"Text file download"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/export/txt [get]
+func (h *Handler) ExportAllTextAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	contacts, err := h.db.GetAllContacts(user.ID, false) // Get all contacts
+	if err != nil {
+		http.Error(w, "Error loading contacts", http.StatusInternalServerError)
+		return
+	}
+	models.SetDisplayNames(contacts, user.NicknameAsDisplayName)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"kindredcard-contacts.txt\"")
+
+	if err := converter.WriteTextDirectory(w, contacts); err != nil {
+		logger.Error("[HANDLER] Error writing text export: %v", err)
+	}
+}
+
 // ExportAllJSONAPI exports all contacts as JSON
 func (h *Handler) ExportAllJSONAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
@@ -709,7 +1741,20 @@ func (h *Handler) ExportAllJSONAPI(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// ImportVCards imports contacts from uploaded vCard file
+// ImportVCardsAPI godoc
+//
+//	@Summary		Import contacts from vCard
+//	@Description	Upload a .vcf file. An X-ABDATE labeled "Anniversary" is merged into the contact's anniversary by default (Apple compatibility); set anniversary_mode=separate to keep it as its own other date. When several cards share a UID only the first is imported, and the rest are listed in warnings
+//	@Tags			export
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			vcard				formData	file				true	"vCard file"
+//	@Param			anniversary_mode	formData	string				false	"How to treat labeled Anniversary dates"	enums(merge,separate)	default(merge)
+//	@Success		200					{object}	map[string]interface{}	"Import count and any warnings"
+//	@Failure		400					{object}	map[string]string	"Invalid file or option"
+//	@Failure		401					{object}	map[string]string	"Unauthorized"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/import [post]
 func (h *Handler) ImportVCardsAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
@@ -731,6 +1776,18 @@ func (h *Handler) ImportVCardsAPI(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	importOpts := converter.DefaultImportOptions()
+	switch r.FormValue("anniversary_mode") {
+	case "", "merge":
+	case "separate":
+		importOpts.MergeAnniversaryDate = false
+	default:
+		http.Error(w, "Invalid anniversary_mode; expected merge or separate", http.StatusBadRequest)
+		return
+	}
+	importOpts.CreateLabels = true
+	importOpts.UIDDomain = h.UIDDomain
+
 	// Read file content
 	content, _ := io.ReadAll(file)
 
@@ -747,10 +1804,24 @@ func (h *Handler) ImportVCardsAPI(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Cards sharing a UID would overwrite each other; keep the first and warn about the rest
+	cards, warnings := converter.DedupeVCardsByUID(cards)
+	for _, warning := range warnings {
+		logger.Warn("[HANDLER] vCard import: %s", warning)
+	}
+
+	// Contacts that existed before the import keep their settings; new ones get import defaults
+	existing := make(map[string]*models.Contact)
+	if before, err := h.db.GetAllContactsAbbrv(user.ID, false); err == nil {
+		for _, c := range before {
+			existing[c.UID] = c
+		}
+	}
+
 	// Pass 1: Create "Shells"
 	// We only care about UID and FullName here to satisfy FKs for relationships
 	for _, card := range cards {
-		contact, err := converter.VCardToContactShell(card)
+		contact, err := converter.VCardToContactShell(card, h.UIDDomain)
 		if err != nil {
 			continue
 		}
@@ -785,20 +1856,7 @@ func (h *Handler) ImportVCardsAPI(w http.ResponseWriter, r *http.Request) {
 	// Now converter.VCardToContact can find the related contacts in allContacts
 	imported := 0
 	for _, card := range cards {
-
-		missingLabels := converter.FindNewLabels(card, revMap)
-		for category, names := range missingLabels {
-			for _, name := range names {
-				newID, err := h.db.NewLabel(name, category)
-				if err != nil {
-					continue
-				}
-				// Update the map locally so the converter can find it immediately
-				revMap[category+":"+name] = newID
-			}
-		}
-
-		contact, err := converter.VCardToContact(card, allContacts, allRelTypes, revMap)
+		contact, err := converter.VCardToContact(card, allContacts, allRelTypes, revMap, importOpts)
 		if err != nil {
 			logger.Debug("[HANDLER] Error converting vCard to Contact: %v", err)
 			continue
@@ -808,6 +1866,11 @@ func (h *Handler) ImportVCardsAPI(w http.ResponseWriter, r *http.Request) {
 		logger.Trace("UID: %s", contact.UID)
 		if id, ok := uidToID[contact.UID]; ok {
 			contact.ID = id
+			if prev, ok := existing[contact.UID]; ok {
+				contact.ExcludeFromEvents = prev.ExcludeFromEvents
+			} else {
+				converter.ApplyNewContactDefaults(contact)
+			}
 			if err := h.db.UpdateContact(user.ID, contact); err == nil {
 				imported++
 			}
@@ -815,9 +1878,73 @@ func (h *Handler) ImportVCardsAPI(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	response := map[string]interface{}{
 		"count":  imported,
 		"status": "success",
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// ImportCSVAPI godoc
+//
+//	@Summary		Import contacts from CSV
+//	@Description	Upload a Google Contacts CSV export (or a KindredCard CSV export). The column layout is auto-detected and unknown columns are ignored. Every row becomes a new contact
+//	@Tags			export
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			csv	formData	file					true	"CSV file"
+//	@Success		200	{object}	map[string]interface{}	"Imported and skipped row counts"
+//	@Failure		400	{object}	map[string]string		"Invalid file"
+//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/import/csv [post]
+func (h *Handler) ImportCSVAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	// Parse multipart form
+	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10 MB max
+		logger.Error("[HANDLER] Error parsing multipartform: %v", err)
+		http.Error(w, "File too large", http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("csv")
+	if err != nil {
+		logger.Error("[HANDLER] Error retreiving form file: %v", err)
+		http.Error(w, "Error reading file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	revMap, _ := h.db.GetLabelReverseMap()
+
+	result, err := converter.CSVToContacts(file, revMap)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imported := 0
+	skipped := result.Skipped
+	for _, contact := range result.Contacts {
+		if err := h.db.CreateContact(user.ID, contact); err != nil {
+			logger.Debug("[HANDLER] Error creating contact from CSV row: %v", err)
+			skipped++
+			continue
+		}
+		imported++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{
+		"imported": imported,
+		"skipped":  skipped,
 	})
 }
 
@@ -828,17 +1955,33 @@ func (h *Handler) UpdatePreferencesAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var userPref models.User
+	// Decode over the current preferences so omitted fields keep their values
+	userPref, err := h.db.GetUserPreferences(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load preferences", http.StatusInternalServerError)
+		return
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&userPref); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(userPref); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
 	userPref.ID = user.ID
 
+	userPref.CardDAVDisplayName = strings.TrimSpace(userPref.CardDAVDisplayName)
+	if len(userPref.CardDAVDisplayName) > 100 {
+		http.Error(w, "carddav_display_name must be 100 characters or fewer", http.StatusBadRequest)
+		return
+	}
+
+	if userPref.EventLookaheadDays < 1 || userPref.EventLookaheadDays > maxEventLookaheadDays {
+		http.Error(w, "default_event_lookahead_days must be between 1 and 365", http.StatusBadRequest)
+		return
+	}
+
 	// Update preferences
-	err := h.db.UpdateUserPreferences(userPref)
+	err = h.db.UpdateUserPreferences(*userPref)
 	if err != nil {
 		// Handle specific DB errors if needed, otherwise send generic server error
 		http.Error(w, "Failed to update preferences", http.StatusInternalServerError)
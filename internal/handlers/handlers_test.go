@@ -0,0 +1,1256 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+	"github.com/gorilla/mux"
+	"github.com/steveredden/KindredCard/internal/avatar"
+	"github.com/steveredden/KindredCard/internal/converter"
+	"github.com/steveredden/KindredCard/internal/db"
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/middleware"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+// newTestHandler returns a Handler on the test database, without templates
+func newTestHandler(t *testing.T) (*Handler, *db.Database) {
+	t.Helper()
+	database := dbtest.Open(t)
+	return &Handler{db: database}, database
+}
+
+// newTemplateHandler returns a Handler on the test database that renders pages. renderTemplate
+// reads pages relative to the repository root, so the test runs from there
+func newTemplateHandler(t *testing.T) (*Handler, *db.Database) {
+	t.Helper()
+	database := dbtest.Open(t)
+
+	t.Chdir("../..")
+	h, err := NewHandler(database, "web/templates", "http://localhost:8080", BuildInfo{Version: "test"})
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	return h, database
+}
+
+// withUser authenticates r as user, as the auth middleware does
+func withUser(r *http.Request, user *models.User) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, user))
+}
+
+// withID sets the {id} route variable
+func withID(r *http.Request, id int) *http.Request {
+	return mux.SetURLVars(r, map[string]string{"id": strconv.Itoa(id)})
+}
+
+// decodeCards reads every vCard in body
+func decodeCards(t *testing.T, body []byte) []vcard.Card {
+	t.Helper()
+
+	var cards []vcard.Card
+	dec := vcard.NewDecoder(bytes.NewReader(body))
+	for {
+		card, err := dec.Decode()
+		if err != nil {
+			break
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+func TestExportContactVCardIncludeRelated(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	a := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	b := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	c := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Carol"})
+
+	// Bob is related to Alice twice, so his card must still only be exported once
+	for _, rel := range []struct {
+		related int
+		kind    string
+	}{{b.ID, "Friend"}, {b.ID, "Colleague"}, {c.ID, "Friend"}} {
+		if _, _, err := database.AddRelationship(user.ID, a.ID, rel.related, dbtest.RelationshipTypeID(t, database, rel.kind)); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+
+	export := func(query string) []vcard.Card {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts/"+strconv.Itoa(a.ID)+"/vcard"+query, nil)
+		w := httptest.NewRecorder()
+		h.ExportContactVCardAPI(w, withID(withUser(r, user), a.ID))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+		}
+		return decodeCards(t, w.Body.Bytes())
+	}
+
+	if cards := export(""); len(cards) != 1 {
+		t.Fatalf("without include_related got %d cards, want 1", len(cards))
+	}
+
+	counts := map[string]int{}
+	for _, card := range export("?include_related=true") {
+		counts[card.Value(vcard.FieldUID)]++
+	}
+	want := map[string]int{a.UID: 1, b.UID: 1, c.UID: 1}
+	if len(counts) != len(want) {
+		t.Fatalf("exported UIDs %v, want %v", counts, want)
+	}
+	for uid, n := range want {
+		if counts[uid] != n {
+			t.Errorf("UID %s exported %d times, want %d", uid, counts[uid], n)
+		}
+	}
+}
+
+func TestAddRelationshipIsIdempotent(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	a := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice", Gender: "F"})
+	b := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob", Gender: "M"})
+	friend := dbtest.RelationshipTypeID(t, database, "Friend")
+
+	add := func(from, to int) (int, models.Relationship) {
+		body := fmt.Sprintf(`{"related_contact_id": %d, "relationship_type_id": %d}`, to, friend)
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/contacts/"+strconv.Itoa(from)+"/relationships", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.AddRelationshipAPI(w, withID(withUser(r, user), from))
+
+		var rel models.Relationship
+		if err := json.NewDecoder(w.Body).Decode(&rel); err != nil {
+			t.Fatalf("decoding relationship: %v", err)
+		}
+		return w.Code, rel
+	}
+
+	code, created := add(a.ID, b.ID)
+	if code != http.StatusCreated {
+		t.Fatalf("first add: status = %d, want %d", code, http.StatusCreated)
+	}
+
+	code, again := add(a.ID, b.ID)
+	if code != http.StatusOK {
+		t.Errorf("repeated add: status = %d, want %d", code, http.StatusOK)
+	}
+	if again.ID != created.ID {
+		t.Errorf("repeated add returned relationship %d, want the existing %d", again.ID, created.ID)
+	}
+
+	// Bob -> Alice "Friend" is the mirror of the existing row, so it isn't stored again
+	code, mirror := add(b.ID, a.ID)
+	if code != http.StatusOK {
+		t.Errorf("mirror add: status = %d, want %d", code, http.StatusOK)
+	}
+	if mirror.ID != created.ID {
+		t.Errorf("mirror add returned relationship %d, want the existing %d", mirror.ID, created.ID)
+	}
+}
+
+func TestAddRelationshipRequiresOwnContacts(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+	other := dbtest.NewUser(t, database)
+
+	a := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	b := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	trashed := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Carol"})
+	stranger := dbtest.NewContact(t, database, other.ID, &models.Contact{FullName: "Mallory"})
+	if err := database.DeleteContact(user.ID, trashed.ID); err != nil {
+		t.Fatalf("DeleteContact: %v", err)
+	}
+	friend := dbtest.RelationshipTypeID(t, database, "Friend")
+
+	// An existing link, so the last case would otherwise be answered with its mirror row
+	if _, _, err := database.AddRelationship(user.ID, a.ID, b.ID, friend); err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		user     *models.User
+		from, to int
+	}{
+		{"related contact of another user", user, a.ID, stranger.ID},
+		{"related contact in the trash", user, a.ID, trashed.ID},
+		{"contacts of another user", other, b.ID, a.ID},
+	} {
+		body := fmt.Sprintf(`{"related_contact_id": %d, "relationship_type_id": %d}`, tt.to, friend)
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/contacts/"+strconv.Itoa(tt.from)+"/relationships", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.AddRelationshipAPI(w, withID(withUser(r, tt.user), tt.from))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+// listContacts calls ListContactsAPI with the query string and decodes the page
+func listContacts(t *testing.T, h *Handler, user *models.User, query string) (models.ContactPage, http.Header) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	h.ListContactsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts?"+query, nil), user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("list %q: status = %d, body %s", query, w.Code, w.Body.String())
+	}
+
+	var page models.ContactPage
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("decoding contact page: %v", err)
+	}
+	return page, w.Header()
+}
+
+// contactNames returns the full names on a page, in order
+func contactNames(page models.ContactPage) []string {
+	names := make([]string, 0, len(page.Contacts))
+	for _, c := range page.Contacts {
+		names = append(names, c.FullName)
+	}
+	return names
+}
+
+func TestListContactsPagination(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	for _, name := range []string{"Carol", "Alice", "Bob"} {
+		dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: name})
+	}
+
+	t.Run("page", func(t *testing.T) {
+		page, header := listContacts(t, h, user, "limit=2&offset=1")
+		if got := strings.Join(contactNames(page), ","); got != "Bob,Carol" {
+			t.Errorf("contacts = %s, want Bob,Carol", got)
+		}
+		if page.Total != 3 || page.Limit != 2 || page.Offset != 1 {
+			t.Errorf("page = total %d, limit %d, offset %d; want 3, 2, 1", page.Total, page.Limit, page.Offset)
+		}
+		if got := header.Get("X-Total-Count"); got != "3" {
+			t.Errorf("X-Total-Count = %q, want 3", got)
+		}
+	})
+
+	t.Run("offset past the end", func(t *testing.T) {
+		page, header := listContacts(t, h, user, "offset=10")
+		if len(page.Contacts) != 0 {
+			t.Errorf("contacts = %v, want none", contactNames(page))
+		}
+		if page.Contacts == nil {
+			t.Error("contacts encoded as null, want an empty array")
+		}
+		if page.Total != 3 || header.Get("X-Total-Count") != "3" {
+			t.Errorf("total = %d (header %q), want 3", page.Total, header.Get("X-Total-Count"))
+		}
+	})
+
+	t.Run("invalid sort falls back to full_name", func(t *testing.T) {
+		page, _ := listContacts(t, h, user, "sort=password_hash")
+		if got := strings.Join(contactNames(page), ","); got != "Alice,Bob,Carol" {
+			t.Errorf("contacts = %s, want Alice,Bob,Carol", got)
+		}
+	})
+
+	t.Run("descending sort", func(t *testing.T) {
+		page, _ := listContacts(t, h, user, "sort=-full_name")
+		if got := strings.Join(contactNames(page), ","); got != "Carol,Bob,Alice" {
+			t.Errorf("contacts = %s, want Carol,Bob,Alice", got)
+		}
+	})
+
+	t.Run("limit is capped", func(t *testing.T) {
+		page, _ := listContacts(t, h, user, "limit=5000")
+		if page.Limit != maxContactPageSize {
+			t.Errorf("limit = %d, want %d", page.Limit, maxContactPageSize)
+		}
+	})
+}
+
+func TestListContactsRejectsInvalidPaging(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, query := range []string{"limit=0", "limit=ten", "offset=-1"} {
+		w := httptest.NewRecorder()
+		h.ListContactsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts?"+query, nil), user))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestListContactsRelationshipFilters(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Carol"})
+	if _, _, err := database.AddRelationship(user.ID, alice.ID, bob.ID, dbtest.RelationshipTypeID(t, database, "Spouse")); err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"has_relationship=true", "Alice,Bob"},
+		{"has_relationship=false", "Carol"},
+		{"relationship_type=spouse", "Alice,Bob"},
+		{"relationship_type=Friend", ""},
+		{"has_relationship=false&relationship_type=Spouse", ""},
+	}
+	for _, tt := range tests {
+		page, _ := listContacts(t, h, user, tt.query)
+		if got := strings.Join(contactNames(page), ","); got != tt.want {
+			t.Errorf("%s: contacts = %q, want %q", tt.query, got, tt.want)
+		}
+		if page.Total != len(page.Contacts) {
+			t.Errorf("%s: total = %d, want %d", tt.query, page.Total, len(page.Contacts))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ListContactsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts?has_relationship=maybe", nil), user))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("has_relationship=maybe: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestContactsDisplayNicknames(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+	user.NicknameAsDisplayName = true
+
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "John Doe", Nickname: "Johnny"})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Jane Doe"})
+
+	page, _ := listContacts(t, h, user, "")
+	var displayed []string
+	for _, c := range page.Contacts {
+		displayed = append(displayed, c.DisplayName)
+	}
+	if got := strings.Join(displayed, ","); got != "Jane Doe,Johnny" {
+		t.Errorf("list display names = %s, want Jane Doe,Johnny", got)
+	}
+	if got := strings.Join(contactNames(page), ","); got != "Jane Doe,John Doe" {
+		t.Errorf("list full names = %s, want them unchanged", got)
+	}
+
+	w := httptest.NewRecorder()
+	h.SearchContactsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/search?q=john", nil), user))
+	var found []*models.Contact
+	if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
+		t.Fatalf("decoding search results: %v", err)
+	}
+	if len(found) != 1 || found[0].DisplayName != "Johnny" || found[0].FullName != "John Doe" {
+		t.Errorf("search results = %+v, want John Doe displayed as Johnny", found)
+	}
+}
+
+func TestTombstonesAPIRequiresOptIn(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	w := httptest.NewRecorder()
+	h.ListTombstonesAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/sync/tombstones", nil), user))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("list: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	w = httptest.NewRecorder()
+	h.PurgeTombstonesAPI(w, withUser(httptest.NewRequest(http.MethodDelete, "/api/v1/sync/tombstones?older_than=0", nil), user))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("purge: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestPurgeTombstonesRejectsInvalidAge(t *testing.T) {
+	h := &Handler{SyncDebugAPIEnabled: true}
+	user := &models.User{ID: 1}
+
+	for _, olderThan := range []string{"", "week", "-1days", "7weeks"} {
+		w := httptest.NewRecorder()
+		h.PurgeTombstonesAPI(w, withUser(httptest.NewRequest(http.MethodDelete, "/api/v1/sync/tombstones?older_than="+olderThan, nil), user))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("older_than=%q: status = %d, want %d", olderThan, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestSearchContactsRejectsUnknownFields(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	w := httptest.NewRecorder()
+	h.SearchContactsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/search?q=oak&fields=address,password_hash", nil), user))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSearchContactsEmptyQuery(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: name})
+	}
+
+	search := func(h *Handler, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.SearchContactsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/search?"+query, nil), user))
+		return w
+	}
+	page := func(w *httptest.ResponseRecorder) models.ContactPage {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+		}
+		var page models.ContactPage
+		if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+			t.Fatalf("decoding contact page: %v", err)
+		}
+		return page
+	}
+
+	// By default an empty query is an error, unless the request asks for the list
+	if w := search(h, "q="); w.Code != http.StatusBadRequest {
+		t.Errorf("default: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if got := page(search(h, "q=&empty=list&limit=2")); got.Total != 3 || len(got.Contacts) != 2 || got.Limit != 2 {
+		t.Errorf("empty=list: got %d of %d contacts (limit %d), want the first 2 of 3", len(got.Contacts), got.Total, got.Limit)
+	}
+
+	// Configured to list, an empty query pages through the contacts unless the request asks for the error
+	h.EmptySearchListsContacts = true
+	got := page(search(h, "q=&limit=2&offset=2"))
+	if got.Total != 3 || len(got.Contacts) != 1 || got.Offset != 2 {
+		t.Errorf("configured: got %d of %d contacts at offset %d, want the last of 3", len(got.Contacts), got.Total, got.Offset)
+	}
+	if w := search(h, "q=&empty=error"); w.Code != http.StatusBadRequest {
+		t.Errorf("empty=error: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSearchContactsRejectsInvalidEmptyMode(t *testing.T) {
+	h := &Handler{EmptySearchListsContacts: true}
+	user := &models.User{ID: 1}
+
+	w := httptest.NewRecorder()
+	h.SearchContactsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/search?q=&empty=all", nil), user))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestArchiveContact(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+
+	w := httptest.NewRecorder()
+	h.ArchiveContactAPI(w, withID(withUser(httptest.NewRequest(http.MethodPost, "/api/v1/contacts/"+strconv.Itoa(bob.ID)+"/archive", nil), user), bob.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("archive: status = %d, body %s", w.Code, w.Body.String())
+	}
+	var archived models.Contact
+	if err := json.NewDecoder(w.Body).Decode(&archived); err != nil || !archived.Archived {
+		t.Errorf("archive returned %+v (err %v), want Bob archived", archived, err)
+	}
+
+	page, _ := listContacts(t, h, user, "")
+	if got := strings.Join(contactNames(page), ","); got != "Alice" {
+		t.Errorf("default list = %s, want only Alice", got)
+	}
+	page, _ = listContacts(t, h, user, "include_archived=true")
+	if got := strings.Join(contactNames(page), ","); got != "Alice,Bob" {
+		t.Errorf("list with include_archived = %s, want Alice,Bob", got)
+	}
+
+	search := func(query string) int {
+		w := httptest.NewRecorder()
+		h.SearchContactsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/search?q=bob"+query, nil), user))
+		var found []*models.Contact
+		if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
+			t.Fatalf("decoding search results: %v", err)
+		}
+		return len(found)
+	}
+	if n := search(""); n != 0 {
+		t.Errorf("search found %d archived contacts, want none", n)
+	}
+	if n := search("&include_archived=true"); n != 1 {
+		t.Errorf("search with include_archived found %d contacts, want Bob", n)
+	}
+
+	// Archived contacts remain fetchable by ID
+	w = httptest.NewRecorder()
+	h.GetContactAPI(w, withID(withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/"+strconv.Itoa(bob.ID), nil), user), bob.ID))
+	if w.Code != http.StatusOK {
+		t.Errorf("get archived contact: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	h.UnarchiveContactAPI(w, withID(withUser(httptest.NewRequest(http.MethodPost, "/api/v1/contacts/"+strconv.Itoa(bob.ID)+"/unarchive", nil), user), bob.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unarchive: status = %d, body %s", w.Code, w.Body.String())
+	}
+	page, _ = listContacts(t, h, user, "")
+	if got := strings.Join(contactNames(page), ","); got != "Alice,Bob" {
+		t.Errorf("list after unarchiving = %s, want Alice,Bob", got)
+	}
+}
+
+func TestGetHouseholdRejectsInvalidParameters(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, query := range []string{"depth=0", "depth=6", "depth=two", "extended=maybe"} {
+		w := httptest.NewRecorder()
+		h.GetHouseholdAPI(w, withID(withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/1/household?"+query, nil), user), 1))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestImportCSVAPIGoogleExport(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	csv := "Name,Given Name,Family Name,E-mail 1 - Type,E-mail 1 - Value,Phone 1 - Type,Phone 1 - Value\n" +
+		"\"Liddell, Alice\",Alice,Liddell,* Home,alice@example.com,Mobile,+15555550100\n" +
+		",Bob,Builder,,,* Work,+15555550101\n" +
+		",,,,,,\n"
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("csv", "contacts.csv")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	part.Write([]byte(csv))
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/contacts/import/csv", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	h.ImportCSVAPI(w, withUser(r, user))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var counts map[string]int
+	if err := json.NewDecoder(w.Body).Decode(&counts); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if counts["imported"] != 2 || counts["skipped"] != 1 {
+		t.Errorf("response = %v, want imported 2 and skipped 1", counts)
+	}
+
+	contacts, err := database.GetAllContacts(user.ID, false)
+	if err != nil {
+		t.Fatalf("GetAllContacts: %v", err)
+	}
+	names := make(map[string]*models.Contact, len(contacts))
+	for _, c := range contacts {
+		names[c.FullName] = c
+	}
+	alice, ok := names["Liddell, Alice"]
+	if !ok || names["Bob Builder"] == nil {
+		t.Fatalf("stored contacts = %v, want \"Liddell, Alice\" and \"Bob Builder\"", names)
+	}
+	if len(alice.Emails) != 1 || alice.Emails[0].Email != "alice@example.com" {
+		t.Errorf("Alice's emails = %+v, want alice@example.com", alice.Emails)
+	}
+}
+
+// exportAllVCards calls ExportAllVCardsAPI and returns the file
+func exportAllVCards(t *testing.T, h *Handler, user *models.User) []byte {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	h.ExportAllVCardsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/export/vcard", nil), user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: status = %d, body %s", w.Code, w.Body.String())
+	}
+	return w.Body.Bytes()
+}
+
+// vcardImportResult is the response of ImportVCardsAPI
+type vcardImportResult struct {
+	Count    int      `json:"count"`
+	Warnings []string `json:"warnings"`
+}
+
+// importVCards uploads data to ImportVCardsAPI and returns its response
+func importVCards(t *testing.T, h *Handler, user *models.User, data []byte) vcardImportResult {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("vcard", "contacts.vcf")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	part.Write(data)
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/contacts/import", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	h.ImportVCardsAPI(w, withUser(r, user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	var result vcardImportResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("decoding import response: %v", err)
+	}
+	return result
+}
+
+// exportedRelationships lists the related names of every card as sorted "FN: label -> name" lines
+func exportedRelationships(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	var lines []string
+	for _, card := range decodeCards(t, data) {
+		labels := make(map[string]string)
+		for _, f := range card[converter.XLabelField] {
+			labels[f.Group] = f.Value
+		}
+		for _, f := range card[converter.XRelatedNamesField] {
+			lines = append(lines, fmt.Sprintf("%s: %s -> %s", card.Value(vcard.FieldFormattedName), labels[f.Group], f.Value))
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func TestExportAllVCardsRoundTripsFamily(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	mom := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Mom", Gender: "F"})
+	dad := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Dad", Gender: "M"})
+	kid := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Kid", Gender: "M"})
+	for _, rel := range []struct {
+		from, to int
+		kind     string
+	}{{kid.ID, mom.ID, "Parent"}, {kid.ID, dad.ID, "Parent"}, {mom.ID, dad.ID, "Spouse"}} {
+		if _, _, err := database.AddRelationship(user.ID, rel.from, rel.to, dbtest.RelationshipTypeID(t, database, rel.kind)); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+
+	first := exportAllVCards(t, h, user)
+	want := exportedRelationships(t, first)
+	if len(want) != 3 {
+		t.Fatalf("export has relationships %q, want each of the 3 stored ones once", want)
+	}
+
+	// Into a fresh account, then over itself again: the family must come back unchanged each time
+	restored := dbtest.NewUser(t, database)
+	for pass := 1; pass <= 2; pass++ {
+		source := first
+		if pass == 2 {
+			source = exportAllVCards(t, h, restored)
+		}
+		importVCards(t, h, restored, source)
+
+		got := exportedRelationships(t, exportAllVCards(t, h, restored))
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("pass %d: re-exported relationships\n%s\nwant\n%s", pass, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+
+	contacts, err := database.GetAllContacts(restored.ID, false)
+	if err != nil {
+		t.Fatalf("GetAllContacts: %v", err)
+	}
+	if len(contacts) != 3 {
+		t.Errorf("restored %d contacts, want 3", len(contacts))
+	}
+}
+
+// encodePNG returns a blank PNG of the given size
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("encoding PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// imageConfig decodes the format and size of a base64 image
+func imageConfig(t *testing.T, data string) (string, int, int) {
+	t.Helper()
+
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatalf("decoding base64 image: %v", err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("decoding image: %v", err)
+	}
+	return format, cfg.Width, cfg.Height
+}
+
+// uploadAvatar calls UploadAvatarAPI with the image and returns the response
+func uploadAvatar(h *Handler, user *models.User, contactID int, data []byte) *httptest.ResponseRecorder {
+	body := `{"avatar": "` + base64.StdEncoding.EncodeToString(data) + `"}`
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/contacts/"+strconv.Itoa(contactID)+"/avatar", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.UploadAvatarAPI(w, withID(withUser(r, user), contactID))
+	return w
+}
+
+func TestExportContactVCardOriginalAvatar(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+
+	if w := uploadAvatar(h, user, alice.ID, encodePNG(t, 1200, 800)); w.Code != http.StatusOK {
+		t.Fatalf("upload: status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	exportedPhoto := func(query string) (string, int, int) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts/"+strconv.Itoa(alice.ID)+"/vcard"+query, nil)
+		w := httptest.NewRecorder()
+		h.ExportContactVCardAPI(w, withID(withUser(r, user), alice.ID))
+		if w.Code != http.StatusOK {
+			t.Fatalf("export %q: status = %d, body %s", query, w.Code, w.Body.String())
+		}
+		cards := decodeCards(t, w.Body.Bytes())
+		if len(cards) != 1 {
+			t.Fatalf("export %q: got %d cards, want 1", query, len(cards))
+		}
+		contact, err := converter.VCardToContact(cards[0], nil, nil, nil, converter.DefaultImportOptions())
+		if err != nil {
+			t.Fatalf("export %q: reading PHOTO: %v", query, err)
+		}
+		return imageConfig(t, contact.AvatarBase64)
+	}
+
+	if format, width, height := exportedPhoto("?avatar=original"); format != "png" || width != 1200 || height != 800 {
+		t.Errorf("avatar=original exported a %dx%d %s, want the 1200x800 png upload", width, height, format)
+	}
+	if _, width, height := exportedPhoto(""); width > avatar.MaxDimension || height > avatar.MaxDimension {
+		t.Errorf("default export has a %dx%d photo, want the resized avatar", width, height)
+	}
+
+	// The web UI serves the stored thumbnail
+	stored, _, _, err := database.GetAvatar(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetAvatar: %v", err)
+	}
+	if _, width, height := imageConfig(t, stored); width > avatar.MaxDimension || height > avatar.MaxDimension {
+		t.Errorf("stored avatar is %dx%d, want at most %d pixels", width, height, avatar.MaxDimension)
+	}
+}
+
+func TestExportAvatarsZip(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Carol"})
+
+	gifData := []byte("GIF89a\x01\x00\x01\x00")
+	want := map[string][]byte{
+		alice.UID + ".png": encodePNG(t, 4, 4),
+		bob.UID + ".gif":   gifData,
+	}
+	for contact, mimeType := range map[*models.Contact]string{alice: "image/png", bob: "image/gif"} {
+		data := want[contact.UID+avatarExtension(mimeType)]
+		if err := database.UpdateAvatar(user.ID, contact.ID, base64.StdEncoding.EncodeToString(data), mimeType); err != nil {
+			t.Fatalf("UpdateAvatar(%s): %v", contact.FullName, err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ExportAvatarsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/avatars/export.zip", nil), user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("reading zip: %v", err)
+	}
+	if len(zr.File) != len(want) {
+		t.Errorf("zip has %d entries, want %d (Carol has no avatar)", len(zr.File), len(want))
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", f.Name, err)
+		}
+		if expected, ok := want[f.Name]; !ok {
+			t.Errorf("unexpected entry %s", f.Name)
+		} else if !bytes.Equal(data, expected) {
+			t.Errorf("%s doesn't hold the stored avatar", f.Name)
+		}
+	}
+}
+
+func TestAvatarEntryName(t *testing.T) {
+	tests := map[string]string{
+		"3f2b-uid":          "3f2b-uid",
+		"../../etc/passwd":  ".._.._etc_passwd",
+		`C:\Users\x`:        "C__Users_x",
+		"line\nbreak":       "line_break",
+		"":                  "_",
+		"..":                "_",
+		"urn:uuid:1234-abc": "urn_uuid_1234-abc",
+	}
+	for uid, want := range tests {
+		if got := avatarEntryName(uid); got != want {
+			t.Errorf("avatarEntryName(%q) = %q, want %q", uid, got, want)
+		}
+	}
+}
+
+func TestGetContactNotModified(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts/"+strconv.Itoa(alice.ID), nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.GetContactAPI(w, withID(withUser(r, user), alice.ID))
+		return w
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first GET: status = %d, ETag %q; want 200 with an ETag", first.Code, etag)
+	}
+
+	cached := get(etag)
+	if cached.Code != http.StatusNotModified {
+		t.Errorf("GET with the current ETag: status = %d, want %d", cached.Code, http.StatusNotModified)
+	}
+	if cached.Body.Len() != 0 {
+		t.Errorf("304 response has a %d byte body, want none", cached.Body.Len())
+	}
+
+	// Once the contact changes the cached copy is stale
+	notes := "Changed"
+	if _, err := database.PatchContact(user.ID, alice.ID, &models.ContactJSONPatch{Notes: &notes}); err != nil {
+		t.Fatalf("PatchContact: %v", err)
+	}
+	if w := get(etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("GET after a change: status = %d, ETag %q; want 200 with a new ETag", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"abc123"`
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc123"`, true},
+		{`W/"abc123"`, true},
+		{`"other", "abc123"`, true},
+		{`*`, true},
+		{`"other"`, false},
+		{`abc123`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestUploadAvatarDownscales(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+
+	if w := uploadAvatar(h, user, alice.ID, encodePNG(t, 2000, 2000)); w.Code != http.StatusOK {
+		t.Fatalf("upload: status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	stored, mimeType, _, err := database.GetAvatar(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetAvatar: %v", err)
+	}
+	format, width, height := imageConfig(t, stored)
+	if mimeType != "image/jpeg" || format != "jpeg" {
+		t.Errorf("stored a %s (%s), want a JPEG", format, mimeType)
+	}
+	if max(width, height) > avatar.MaxDimension {
+		t.Errorf("stored avatar is %dx%d, want at most %d on its longest side", width, height, avatar.MaxDimension)
+	}
+}
+
+func TestUploadAvatarRejectsBadImages(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	bomb := encodePNG(t, 1, 1)
+	binary.BigEndian.PutUint32(bomb[16:20], 10000)
+	binary.BigEndian.PutUint32(bomb[20:24], 10000)
+	binary.BigEndian.PutUint32(bomb[29:33], crc32.ChecksumIEEE(bomb[12:29]))
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"not an image", []byte("hello, world"), http.StatusUnsupportedMediaType},
+		{"corrupt png", encodePNG(t, 8, 8)[:40], http.StatusBadRequest},
+		{"too many pixels", bomb, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		if w := uploadAvatar(h, user, 1, tt.data); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestUploadAvatarRejectsUnsupportedFormats(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	webp := append([]byte("RIFF\x24\x00\x00\x00WEBPVP8 "), make([]byte, 24)...)
+	w := uploadAvatar(h, user, 1, webp)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+	if !strings.Contains(w.Body.String(), "image/webp") {
+		t.Errorf("error %q doesn't name the detected type", w.Body.String())
+	}
+}
+
+// uploadAvatarURL sets contactID's avatar from url through UploadAvatarURLAPI
+func uploadAvatarURL(h *Handler, user *models.User, contactID int, url string) *httptest.ResponseRecorder {
+	body := `{"url": "` + url + `"}`
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/contacts/"+strconv.Itoa(contactID)+"/avatar/url", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.UploadAvatarURLAPI(w, withID(withUser(r, user), contactID))
+	return w
+}
+
+// newImageServer serves a small PNG at /avatar.png and an HTML page at /page.html
+func newImageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	pngData := encodePNG(t, 32, 32)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/avatar.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngData)
+	})
+	mux.HandleFunc("/page.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUploadAvatarURL(t *testing.T) {
+	srv := newImageServer(t)
+
+	h, database := newTestHandler(t)
+	// The image server listens on loopback, which the default fetcher refuses
+	h.AvatarFetcher = &avatar.Fetcher{HTTPClient: srv.Client()}
+	user := dbtest.NewUser(t, database)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+
+	if w := uploadAvatarURL(h, user, alice.ID, srv.URL+"/avatar.png"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	stored, mimeType, _, err := database.GetAvatar(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetAvatar: %v", err)
+	}
+	if format, _, _ := imageConfig(t, stored); mimeType != "image/jpeg" || format != "jpeg" {
+		t.Errorf("stored a %s (%s), want a JPEG", format, mimeType)
+	}
+	original, originalMime, err := database.GetAvatarOriginal(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetAvatarOriginal: %v", err)
+	}
+	if format, _, _ := imageConfig(t, original); originalMime != "image/png" || format != "png" {
+		t.Errorf("kept a %s (%s) original, want the fetched PNG", format, originalMime)
+	}
+
+	if w := uploadAvatarURL(h, user, alice.ID+1000000, srv.URL+"/avatar.png"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown contact: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestUploadAvatarURLRejectsBadURLs(t *testing.T) {
+	srv := newImageServer(t)
+	user := &models.User{ID: 1}
+
+	fetching := &Handler{AvatarFetcher: &avatar.Fetcher{HTTPClient: srv.Client()}}
+	if w := uploadAvatarURL(fetching, user, 1, srv.URL+"/page.html"); w.Code != http.StatusBadRequest {
+		t.Errorf("non-image response: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	// The default fetcher refuses loopback addresses, and the error doesn't say why
+	w := uploadAvatarURL(&Handler{}, user, 1, srv.URL+"/avatar.png")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("loopback URL: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if strings.Contains(w.Body.String(), "blocked") || strings.Contains(w.Body.String(), "127.0.0.1") {
+		t.Errorf("loopback URL: response leaks the fetch error: %q", w.Body.String())
+	}
+
+	if w := uploadAvatarURL(&Handler{}, user, 1, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("empty URL: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestGetAvatarRawBytes(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+
+	// A JPEG that already fits is stored as uploaded
+	var original bytes.Buffer
+	if err := jpeg.Encode(&original, image.NewRGBA(image.Rect(0, 0, 64, 48)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if w := uploadAvatar(h, user, alice.ID, original.Bytes()); w.Code != http.StatusOK {
+		t.Fatalf("upload: status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	get := func(contactID int, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts/"+strconv.Itoa(contactID)+"/avatar", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.GetAvatarAPI(w, withID(withUser(r, user), contactID))
+		return w
+	}
+
+	w := get(alice.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Content-Type = %q, want image/jpeg", ct)
+	}
+	if !bytes.Equal(w.Body.Bytes(), original.Bytes()) {
+		t.Error("response body differs from the uploaded image")
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(w.Body.Bytes()))
+	if err != nil || format != "jpeg" || cfg.Width != 64 || cfg.Height != 48 {
+		t.Errorf("decoded a %dx%d %s (err %v), want the 64x48 jpeg", cfg.Width, cfg.Height, format, err)
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("response has no ETag")
+	}
+	if w := get(alice.ID, etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match: status = %d with %d bytes, want an empty %d", w.Code, w.Body.Len(), http.StatusNotModified)
+	}
+
+	if w := get(bob.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("no avatar: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := get(bob.ID+1000000, ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown contact: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestImportVCardsWarnsAboutDuplicateUIDs(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	uid := "dup-" + strconv.Itoa(user.ID)
+	data := []byte("BEGIN:VCARD\r\nVERSION:3.0\r\nUID:" + uid + "\r\nFN:Alice First\r\nN:First;Alice;;;\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:3.0\r\nUID:" + uid + "\r\nFN:Alice Second\r\nN:Second;Alice;;;\r\nEND:VCARD\r\n")
+
+	result := importVCards(t, h, user, data)
+	if result.Count != 1 {
+		t.Errorf("imported %d contacts, want 1", result.Count)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "Alice Second") || !strings.Contains(result.Warnings[0], uid) {
+		t.Errorf("warnings = %q, want one about the skipped Alice Second", result.Warnings)
+	}
+
+	contact, err := database.GetContactByUID(user.ID, uid, false)
+	if err != nil {
+		t.Fatalf("GetContactByUID: %v", err)
+	}
+	if contact.FullName != "Alice First" {
+		t.Errorf("imported %q, want the first card", contact.FullName)
+	}
+}
+
+func TestExportContactVCardForClient(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	avatarBase64 := base64.StdEncoding.EncodeToString(encodePNG(t, 8, 8))
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:       "Alice",
+		BirthdayMonth:  utils.IntPtr(3),
+		BirthdayDay:    utils.IntPtr(14),
+		AvatarBase64:   avatarBase64,
+		AvatarMimeType: "image/png",
+	})
+
+	export := func(client string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts/"+strconv.Itoa(alice.ID)+"/vcard?client="+client, nil)
+		w := httptest.NewRecorder()
+		h.ExportContactVCardAPI(w, withID(withUser(r, user), alice.ID))
+		return w
+	}
+	decode := func(client string) vcard.Card {
+		t.Helper()
+		w := export(client)
+		if w.Code != http.StatusOK {
+			t.Fatalf("client=%s: status = %d, body %s", client, w.Code, w.Body.String())
+		}
+		card, err := vcard.NewDecoder(w.Body).Decode()
+		if err != nil {
+			t.Fatalf("client=%s: decoding vCard: %v", client, err)
+		}
+		return card
+	}
+
+	apple := decode("apple")
+	if bday := apple.Get(vcard.FieldBirthday); bday == nil || bday.Value != "1604-03-14" || bday.Params.Get(converter.AppleOmitYearKey) != "1604" {
+		t.Errorf("apple BDAY = %+v, want 1604-03-14 with X-APPLE-OMIT-YEAR=1604", bday)
+	}
+	if photo := apple.Get(vcard.FieldPhoto); photo == nil || !strings.EqualFold(photo.Params.Get("ENCODING"), "b") || photo.Value != avatarBase64 {
+		t.Errorf("apple PHOTO = %+v, want the base64 avatar with ENCODING=b", photo)
+	}
+
+	generic := decode("generic")
+	if bday := generic.Get(vcard.FieldBirthday); bday == nil || bday.Value != "--0314" || bday.Params.Get(converter.AppleOmitYearKey) != "" {
+		t.Errorf("generic BDAY = %+v, want --0314", bday)
+	}
+	if photo := generic.Get(vcard.FieldPhoto); photo == nil || photo.Value != "data:image/png;base64,"+avatarBase64 {
+		t.Errorf("generic PHOTO = %+v, want a data URI", photo)
+	}
+
+	if w := export("android"); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown client: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestImportVCardsKindOrg(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	uid := "acme-" + strconv.Itoa(user.ID)
+	data := []byte("BEGIN:VCARD\r\nVERSION:3.0\r\nUID:" + uid + "\r\nFN:Acme Corp\r\nKIND:org\r\nORG:Acme Corp\r\nEND:VCARD\r\n")
+
+	if result := importVCards(t, h, user, data); result.Count != 1 {
+		t.Fatalf("imported %d contacts, want 1", result.Count)
+	}
+
+	contact, err := database.GetContactByUID(user.ID, uid, false)
+	if err != nil {
+		t.Fatalf("GetContactByUID: %v", err)
+	}
+	if contact.Kind != models.ContactKindOrg || !contact.IsOrganization {
+		t.Errorf("kind %q (organization %v), want an org", contact.Kind, contact.IsOrganization)
+	}
+	if !contact.ExcludeFromEvents {
+		t.Error("a new organization should start out excluded from events")
+	}
+
+	// Opting the organization back in survives a re-import
+	included := false
+	if _, err := database.PatchContact(user.ID, contact.ID, &models.ContactJSONPatch{ExcludeFromEvents: &included}); err != nil {
+		t.Fatalf("PatchContact: %v", err)
+	}
+	importVCards(t, h, user, data)
+
+	contact, err = database.GetContactByUID(user.ID, uid, false)
+	if err != nil {
+		t.Fatalf("GetContactByUID: %v", err)
+	}
+	if contact.ExcludeFromEvents {
+		t.Error("re-importing the card excluded the organization from events again")
+	}
+}
+
+func TestExportContactVCardCustomLabel(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	// CreateLabel reuses an existing label, so repeated runs share it
+	labelID, err := database.CreateLabel("School", "phone")
+	if err != nil {
+		t.Fatalf("CreateLabel: %v", err)
+	}
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName: "Alice",
+		Phones:   []models.Phone{{Phone: "+15555550100", Type: labelID, TypeLabel: "school"}},
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts/"+strconv.Itoa(alice.ID)+"/vcard", nil)
+	w := httptest.NewRecorder()
+	h.ExportContactVCardAPI(w, withID(withUser(r, user), alice.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{"item1.TEL:+15555550100\r\n", "item1.X-ABLABEL:school\r\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("vCard is missing %q:\n%s", want, body)
+		}
+	}
+}
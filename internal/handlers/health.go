@@ -5,6 +5,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"runtime"
 	"time"
 
 	"github.com/steveredden/KindredCard/internal/immich"
@@ -19,6 +20,14 @@ type HealthResponse struct {
 	Checks    map[string]string `json:"checks"`    // Individual component checks
 }
 
+// VersionResponse represents the build/version response
+type VersionResponse struct {
+	Version   string `json:"version" example:"v1.2.0"`
+	GoVersion string `json:"go_version" example:"go1.24.0"`
+	BuildTime string `json:"build_time" example:"2026-01-01T00:00:00Z"`
+	GitCommit string `json:"git_commit" example:"1a2b3c4"`
+}
+
 // HandleHealth godoc
 //
 //	@Summary		Health check endpoint
@@ -84,3 +93,23 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(response)
 }
+
+// HandleVersion godoc
+//
+//	@Summary		Version information
+//	@Description	Returns the release version, Go toolchain version, build time, and git commit of the running server
+//	@Tags			system
+//	@Produce		json
+//	@Success		200	{object}	VersionResponse	"Build information"
+//	@Router			/api/v1/version [get]
+func (h *Handler) HandleVersion(w http.ResponseWriter, r *http.Request) {
+	response := VersionResponse{
+		Version:   h.buildInfo.Version,
+		GoVersion: runtime.Version(),
+		BuildTime: h.buildInfo.BuildTime,
+		GitCommit: h.buildInfo.GitCommit,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
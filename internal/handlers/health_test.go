@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestHandleVersion(t *testing.T) {
+	build := BuildInfo{Version: "v1.2.3", GitCommit: "1a2b3c4", BuildTime: "2026-01-01T00:00:00Z"}
+	h := &Handler{releaseVersion: build.Version, buildInfo: build}
+
+	w := httptest.NewRecorder()
+	h.HandleVersion(w, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var got VersionResponse
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	want := VersionResponse{
+		Version:   build.Version,
+		GoVersion: runtime.Version(),
+		BuildTime: build.BuildTime,
+		GitCommit: build.GitCommit,
+	}
+	if got != want {
+		t.Errorf("version = %+v, want %+v", got, want)
+	}
+}
@@ -2,15 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/steveredden/KindredCard/internal/db"
 	"github.com/steveredden/KindredCard/internal/immich"
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/middleware"
-	"github.com/steveredden/KindredCard/internal/models"
 )
 
 // ===== IMMICH WEB PAGES =====
@@ -126,28 +129,103 @@ func (h *Handler) PostImmichLinkAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	url := fmt.Sprintf("%s/people/%s", os.Getenv("IMMICH_URL"), req.PersonID)
+	if !validImmichPersonID(req.PersonID) {
+		http.Error(w, "Invalid person_id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.LinkImmichPerson(user.ID, req.ContactID, immichPersonURL(req.PersonID)); err != nil {
+		writeImmichLinkError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
 
-	immichTypeID, err := h.db.GetLabelID("immich", "url")
+// LinkContactImmichAPI godoc
+//
+//	@Summary		Link a contact to an Immich person
+//	@Description	Set (or replace) the contact's Immich person link. A person can only be linked to one contact
+//	@Tags			immich
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Contact ID"
+//	@Param			body	body		object				true	"Immich person ({\"person_id\": \"...\"})"
+//	@Success		200		{object}	map[string]string	"Linked"
+//	@Failure		400		{object}	map[string]string	"Invalid request"
+//	@Failure		404		{object}	map[string]string	"Contact not found"
+//	@Failure		409		{object}	map[string]string	"Person already linked to another contact"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{id}/immich/link [post]
+func (h *Handler) LinkContactImmichAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	contactID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		http.Error(w, "Not Found", http.StatusNotFound)
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		PersonID string `json:"person_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if !validImmichPersonID(req.PersonID) {
+		http.Error(w, "Invalid person_id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.LinkImmichPerson(user.ID, contactID, immichPersonURL(req.PersonID)); err != nil {
+		writeImmichLinkError(w, err)
 		return
 	}
 
-	// Create the URL record with type ["immich"]
-	newURL := models.URL{
-		ContactID: req.ContactID,
-		URL:       url,
-		Type:      immichTypeID,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// UnlinkContactImmichAPI godoc
+//
+//	@Summary		Unlink a contact from its Immich person
+//	@Description	Remove the contact's Immich person link
+//	@Tags			immich
+//	@Produce		json
+//	@Param			id	path		int					true	"Contact ID"
+//	@Success		200	{object}	map[string]string	"Unlinked"
+//	@Failure		400	{object}	map[string]string	"Invalid ID"
+//	@Failure		404	{object}	map[string]string	"Contact has no Immich link"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{id}/immich/link [delete]
+func (h *Handler) UnlinkContactImmichAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
 	}
 
-	_, err = h.db.CreateContactURL(user.ID, newURL)
+	contactID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		http.Error(w, "Failed to save link", http.StatusInternalServerError)
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if err := h.db.UnlinkImmichPerson(user.ID, contactID); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Immich link not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to remove link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
 
 // GetImmichThumbnailProxy handles proxying authenticated image requests to Immich
@@ -170,3 +248,25 @@ func (h *Handler) GetImmichThumbnailProxy(w http.ResponseWriter, r *http.Request
 	w.Header().Set("Cache-Control", "public, max-age=3600")
 	w.Write(thumbData)
 }
+
+// immichPersonURL builds the link stored in a contact's immich URL
+func immichPersonURL(personID string) string {
+	return fmt.Sprintf("%s/people/%s", os.Getenv("IMMICH_URL"), personID)
+}
+
+// validImmichPersonID rejects empty IDs and anything that would change the URL path
+func validImmichPersonID(personID string) bool {
+	return personID != "" && !strings.ContainsAny(personID, "/?#")
+}
+
+// writeImmichLinkError maps LinkImmichPerson errors onto HTTP statuses
+func writeImmichLinkError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, db.ErrImmichPersonLinked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err.Error() == "not found":
+		http.Error(w, "Contact not found", http.StatusNotFound)
+	default:
+		http.Error(w, "Failed to save link", http.StatusInternalServerError)
+	}
+}
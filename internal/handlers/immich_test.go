@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestLinkContactImmich(t *testing.T) {
+	t.Setenv("IMMICH_URL", "http://immich.test")
+
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	a := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	b := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+
+	link := func(contactID int, personID string) int {
+		body := `{"person_id": "` + personID + `"}`
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/contacts/"+strconv.Itoa(contactID)+"/immich", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.LinkContactImmichAPI(w, withID(withUser(r, user), contactID))
+		return w.Code
+	}
+	unlink := func(contactID int) int {
+		r := httptest.NewRequest(http.MethodDelete, "/api/v1/contacts/"+strconv.Itoa(contactID)+"/immich", nil)
+		w := httptest.NewRecorder()
+		h.UnlinkContactImmichAPI(w, withID(withUser(r, user), contactID))
+		return w.Code
+	}
+	immichURLs := func(contactID int) []string {
+		contact, err := database.GetContactByID(user.ID, contactID)
+		if err != nil {
+			t.Fatalf("GetContactByID(%d): %v", contactID, err)
+		}
+		var urls []string
+		for _, u := range contact.URLs {
+			if u.TypeLabel == "immich" {
+				urls = append(urls, u.URL)
+			}
+		}
+		return urls
+	}
+
+	if code := link(a.ID, "person-1"); code != http.StatusOK {
+		t.Fatalf("link: status = %d, want %d", code, http.StatusOK)
+	}
+	if got := immichURLs(a.ID); len(got) != 1 || got[0] != "http://immich.test/people/person-1" {
+		t.Errorf("Alice's Immich URLs = %q, want [http://immich.test/people/person-1]", got)
+	}
+
+	// A person can only be linked to one contact
+	if code := link(b.ID, "person-1"); code != http.StatusConflict {
+		t.Errorf("linking a taken person: status = %d, want %d", code, http.StatusConflict)
+	}
+
+	// Relinking replaces the existing link rather than adding a second one
+	if code := link(a.ID, "person-2"); code != http.StatusOK {
+		t.Fatalf("relink: status = %d, want %d", code, http.StatusOK)
+	}
+	if got := immichURLs(a.ID); len(got) != 1 || got[0] != "http://immich.test/people/person-2" {
+		t.Errorf("Alice's Immich URLs after relink = %q, want [http://immich.test/people/person-2]", got)
+	}
+	if code := link(b.ID, "person-1"); code != http.StatusOK {
+		t.Errorf("linking the released person: status = %d, want %d", code, http.StatusOK)
+	}
+
+	if code := unlink(a.ID); code != http.StatusOK {
+		t.Errorf("unlink: status = %d, want %d", code, http.StatusOK)
+	}
+	if got := immichURLs(a.ID); len(got) != 0 {
+		t.Errorf("Alice's Immich URLs after unlink = %q, want none", got)
+	}
+	if code := unlink(a.ID); code != http.StatusNotFound {
+		t.Errorf("repeated unlink: status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestLinkContactImmichRejectsInvalidPersonID(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, personID := range []string{"", "../admin", "abc?x=1", "abc#top"} {
+		body := `{"person_id": "` + personID + `"}`
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/contacts/1/immich", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.LinkContactImmichAPI(w, withID(withUser(r, user), 1))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("person_id %q: status = %d, want %d", personID, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -11,19 +11,83 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/steveredden/KindredCard/internal/auth"
 	"github.com/steveredden/KindredCard/internal/discord"
+	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/mailer"
 	"github.com/steveredden/KindredCard/internal/middleware"
 	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/ntfy"
+	"github.com/steveredden/KindredCard/internal/scheduler"
+	"github.com/steveredden/KindredCard/internal/telegram"
+	"github.com/steveredden/KindredCard/internal/utils"
 )
 
 // Contacts Setting Page
 
+// deleteAllConfirmationTTL is how long a delete-all preview token remains valid
+const deleteAllConfirmationTTL = 5 * time.Minute
+
+// PreviewDeleteAllContactsAPI godoc
+//
+//	@Summary		Preview deleting all contacts
+//	@Description	Returns how many contacts would be deleted and a confirmation value that must be echoed to DELETE /contacts
+//	@Tags			settings
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}	"count and confirmation"
+//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Failure		500	{object}	map[string]string		"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/delete-preview [get]
+func (h *Handler) PreviewDeleteAllContactsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	count, err := h.db.GetContactCount(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to count contacts", http.StatusInternalServerError)
+		return
+	}
+
+	confirmation, err := auth.GenerateConfirmationToken(os.Getenv("APP_KEY"), "delete-all-contacts", user.ID, count, deleteAllConfirmationTTL)
+	if err != nil {
+		logger.Error("[HANDLER] Error signing delete-all confirmation: %v", err)
+		http.Error(w, "Failed to create confirmation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":        count,
+		"confirmation": confirmation,
+		"expires_in":   int(deleteAllConfirmationTTL.Seconds()),
+	})
+}
+
+// DeleteAllContactsAPI godoc
+//
+//	@Summary		Delete all contacts
+//	@Description	Deletes every contact; requires the confirmation value from GET /contacts/delete-preview
+//	@Tags			settings
+//	@Produce		json
+//	@Param			confirm	query		string				true	"Confirmation value from the preview"
+//	@Success		200		{object}	map[string]string	"Contacts deleted"
+//	@Failure		401		{object}	map[string]string	"Unauthorized"
+//	@Failure		412		{object}	map[string]string	"Missing, expired, or stale confirmation"
+//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts [delete]
 func (h *Handler) DeleteAllContactsAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
@@ -31,8 +95,27 @@ func (h *Handler) DeleteAllContactsAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	confirmation := r.URL.Query().Get("confirm")
+	if confirmation == "" {
+		http.Error(w, "Confirmation required; call GET /api/v1/contacts/delete-preview first", http.StatusPreconditionFailed)
+		return
+	}
+
+	// Re-count so the confirmation is rejected if contacts changed since the preview
+	count, err := h.db.GetContactCount(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to count contacts", http.StatusInternalServerError)
+		return
+	}
+
+	if err := auth.VerifyConfirmationToken(confirmation, os.Getenv("APP_KEY"), "delete-all-contacts", user.ID, count); err != nil {
+		logger.Warn("[HANDLER] Rejected delete-all confirmation for user %d: %v", user.ID, err)
+		http.Error(w, "Confirmation is invalid, expired, or out of date", http.StatusPreconditionFailed)
+		return
+	}
+
 	// Delete all contacts
-	err := h.db.DeleteAllContacts(user.ID)
+	err = h.db.DeleteAllContacts(user.ID)
 	if err != nil {
 		http.Error(w, "Failed to delete contacts", http.StatusInternalServerError)
 		return
@@ -44,6 +127,36 @@ func (h *Handler) DeleteAllContactsAPI(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ExcludeEmptyContactsAPI godoc
+//
+//	@Summary		Exclude contacts with no contact methods from sync
+//	@Description	Sets exclude_from_sync on every contact that has no email, phone, or address
+//	@Tags			settings
+//	@Produce		json
+//	@Success		200	{object}	map[string]int		"Number of contacts excluded"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/exclude-empty [post]
+func (h *Handler) ExcludeEmptyContactsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	count, err := h.db.ExcludeContactsWithoutContactMethods(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to update contacts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{
+		"count": count,
+	})
+}
+
 // FindDuplicatesHandler scans for duplicate contacts
 func (h *Handler) FindDuplicatesAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
@@ -66,8 +179,65 @@ func (h *Handler) FindDuplicatesAPI(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// MergeContactsAPI godoc
+//
+//	@Summary		Merge duplicate contacts
+//	@Description	Folds secondary_id into primary_id: emails, phones, addresses, URLs, organizations, IM handles, other dates, tags and relationships move to the primary without duplicating rows it already has, the primary's empty fields are filled from the secondary, and the secondary is moved to the trash (restoring it brings back only its own fields, not the moved rows)
+//	@Tags			contacts
+//	@Accept			json
+//	@Produce		json
+//	@Param			merge	body		models.MergeContactsRequest	true	"Contacts to merge"
+//	@Success		200		{object}	models.Contact				"The merged primary contact"
+//	@Failure		400		{object}	map[string]string			"Invalid request"
+//	@Failure		401		{object}	map[string]string			"Unauthorized"
+//	@Failure		404		{object}	map[string]string			"Contact not found"
+//	@Failure		500		{object}	map[string]string			"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/merge [post]
+func (h *Handler) MergeContactsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	var req models.MergeContactsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.PrimaryID <= 0 || req.SecondaryID <= 0 {
+		http.Error(w, "primary_id and secondary_id are required", http.StatusBadRequest)
+		return
+	}
+	if req.PrimaryID == req.SecondaryID {
+		http.Error(w, "Cannot merge a contact into itself", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.MergeContacts(user.ID, req.PrimaryID, req.SecondaryID); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error merging contacts", http.StatusInternalServerError)
+		return
+	}
+
+	contact, err := h.db.GetContactByID(user.ID, req.PrimaryID)
+	if err != nil {
+		http.Error(w, "Error loading contact", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contact)
+}
+
 // Notification Settings Handlers
 
+// defaultNotificationTime matches the notification_time column default
+const defaultNotificationTime = "09:00"
+
 func (h *Handler) CreateNotificationSettingAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
@@ -81,6 +251,21 @@ func (h *Handler) CreateNotificationSettingAPI(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if req.NotificationTime == "" {
+		req.NotificationTime = defaultNotificationTime
+	}
+	notificationTime, err := utils.NormalizeTimeOfDay(req.NotificationTime)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	req.NotificationTime = notificationTime
+
+	if err := req.ValidateProvider(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	notifier, err := h.db.CreateNotificationSetting(user.ID, &req)
 	if err != nil {
 		http.Error(w, "Failed to save settings", http.StatusInternalServerError)
@@ -146,6 +331,18 @@ func (h *Handler) UpdateNotificationSettingAPI(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	notificationTime, err := utils.NormalizeTimeOfDay(req.NotificationTime)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	req.NotificationTime = notificationTime
+
+	if err := req.ValidateProvider(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if err := h.db.UpdateNotificationSetting(user.ID, &req); err != nil {
 		http.Error(w, "Error updating contact", http.StatusInternalServerError)
 		return
@@ -179,6 +376,205 @@ func (h *Handler) DeleteNotificationSettingAPI(w http.ResponseWriter, r *http.Re
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
 
+// ExportNotificationSettingsAPI godoc
+//
+//	@Summary		Export notification settings
+//	@Description	Download all of the user's notifiers as JSON for backup or migration. Webhook URLs and bot tokens are masked unless include_secrets=true
+//	@Tags			settings
+//	@Produce		json
+//	@Param			include_secrets	query		bool								false	"Include webhook URLs and bot tokens"	default(false)
+//	@Success		200				{object}	models.NotificationSettingsExport
+//	@Failure		401				{object}	map[string]string	"Unauthorized"
+//	@Failure		500				{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/notification-settings/export [get]
+func (h *Handler) ExportNotificationSettingsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	includeSecrets := r.URL.Query().Get("include_secrets") == "true"
+
+	settings, err := h.db.GetAllUserNotificationSettings(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	if !includeSecrets {
+		for i := range settings {
+			settings[i].MaskSecrets()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"kindredcard-notifications.json\"")
+	json.NewEncoder(w).Encode(models.NotificationSettingsExport{
+		ExportedAt:      time.Now().UTC(),
+		SecretsIncluded: includeSecrets,
+		Settings:        settings,
+	})
+}
+
+// ImportNotificationSettingsAPI godoc
+//
+//	@Summary		Import notification settings
+//	@Description	Restore notifiers from an export. A notifier replaces an existing one with the same name and provider, otherwise it is created. Masked secrets are taken from the existing notifier; without one the notifier is skipped
+//	@Tags			settings
+//	@Accept			json
+//	@Produce		json
+//	@Param			export	body		models.NotificationSettingsExport	true	"Exported notification settings"
+//	@Success		200		{object}	map[string]interface{}				"Created, updated, and skipped counts"
+//	@Failure		400		{object}	map[string]string					"Invalid request body"
+//	@Failure		401		{object}	map[string]string					"Unauthorized"
+//	@Failure		500		{object}	map[string]string					"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/notification-settings/import [post]
+func (h *Handler) ImportNotificationSettingsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	var req models.NotificationSettingsExport
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.db.GetAllUserNotificationSettings(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	// Match on name and provider; IDs are not portable between instances
+	existingByKey := make(map[string]models.NotificationSetting, len(existing))
+	for _, s := range existing {
+		existingByKey[s.ProviderType+"|"+s.Name] = s
+	}
+
+	created, updated := 0, 0
+	skipped := []string{}
+	for _, setting := range req.Settings {
+		current, exists := existingByKey[setting.ProviderType+"|"+setting.Name]
+
+		if setting.HasMaskedSecret() {
+			if !exists {
+				skipped = append(skipped, fmt.Sprintf("%s: secret was masked in the export and no existing notifier has it", setting.Name))
+				continue
+			}
+			setting.WebhookURL = current.WebhookURL
+		}
+
+		if setting.NotificationTime == "" {
+			setting.NotificationTime = defaultNotificationTime
+		}
+		notificationTime, err := utils.NormalizeTimeOfDay(setting.NotificationTime)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", setting.Name, err))
+			continue
+		}
+		setting.NotificationTime = notificationTime
+
+		if err := setting.ValidateProvider(); err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", setting.Name, err))
+			continue
+		}
+
+		if exists {
+			setting.ID = current.ID
+			if err := h.db.UpdateNotificationSetting(user.ID, &setting); err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s: failed to update", setting.Name))
+				continue
+			}
+			updated++
+			continue
+		}
+
+		id, err := h.db.CreateNotificationSetting(user.ID, &setting)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: failed to create", setting.Name))
+			continue
+		}
+		setting.ID = id
+		existingByKey[setting.ProviderType+"|"+setting.Name] = setting
+		created++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"created": created,
+		"updated": updated,
+		"skipped": skipped,
+	})
+}
+
+// MatchedEventsAPI godoc
+//
+//	@Summary		Preview a notifier's events
+//	@Description	Runs the scheduler's event selection (look-ahead window, lead time overrides, include flags, and regex) for a notifier as of a date, without sending anything
+//	@Tags			settings
+//	@Produce		json
+//	@Param			id		path		int						true	"Notification setting ID"
+//	@Param			date	query		string					false	"Date to evaluate (YYYY-MM-DD); defaults to today"
+//	@Success		200		{object}	map[string]interface{}	"The evaluated date and matched events"
+//	@Failure		400		{object}	map[string]string		"Invalid ID or date"
+//	@Failure		401		{object}	map[string]string		"Unauthorized"
+//	@Failure		404		{object}	map[string]string		"Notification setting not found"
+//	@Failure		500		{object}	map[string]string		"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/notification-settings/{id}/matched-events [get]
+func (h *Handler) MatchedEventsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid notification setting ID", http.StatusBadRequest)
+		return
+	}
+
+	var date *time.Time
+	if v := r.URL.Query().Get("date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Invalid date; expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = &parsed
+	}
+
+	setting, err := h.db.GetNotificationSettingByID(user.ID, id)
+	if err != nil {
+		http.Error(w, "Notification setting not found", http.StatusNotFound)
+		return
+	}
+
+	events, err := scheduler.MatchEvents(h.db, *setting, date)
+	if err != nil {
+		http.Error(w, "Error loading events", http.StatusInternalServerError)
+		return
+	}
+
+	evaluated := time.Now().Format("2006-01-02")
+	if date != nil {
+		evaluated = date.Format("2006-01-02")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"setting_id": setting.ID,
+		"date":       evaluated,
+		"events":     events,
+	})
+}
+
 func (h *Handler) TestNotificationAPI(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
@@ -210,12 +606,35 @@ func (h *Handler) TestNotificationAPI(w http.ResponseWriter, r *http.Request) {
 		}
 		err = discord.SendTestNotification(*settings.WebhookURL, h.baseURL)
 
-	case "smtp":
+	case "smtp", "email":
 		if settings.TargetAddress == nil || *settings.TargetAddress == "" {
 			http.Error(w, "No email address configured", 400)
 			return
 		}
 		err = mailer.SendTestNotification(*settings.TargetAddress, h.baseURL)
+		if err != nil {
+			logger.Error("[HANDLER] Error sending test email: %v", err)
+		}
+
+	case "telegram":
+		if settings.WebhookURL == nil || *settings.WebhookURL == "" || settings.TargetAddress == nil || *settings.TargetAddress == "" {
+			http.Error(w, "No bot token or chat ID configured", 400)
+			return
+		}
+		err = telegram.SendTestNotification(*settings.WebhookURL, *settings.TargetAddress, h.baseURL)
+		if err != nil {
+			logger.Error("[HANDLER] Error sending test Telegram message: %v", err)
+		}
+
+	case "ntfy":
+		if settings.WebhookURL == nil || *settings.WebhookURL == "" {
+			http.Error(w, "No topic URL configured", 400)
+			return
+		}
+		err = ntfy.SendTestNotification(*settings.WebhookURL, h.baseURL)
+		if err != nil {
+			logger.Error("[HANDLER] Error sending test ntfy message: %v", err)
+		}
 
 	default:
 		http.Error(w, "Unknown provider type", 400)
@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+func TestDeleteAllContactsRequiresConfirmation(t *testing.T) {
+	t.Setenv("APP_KEY", "test-app-key")
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+
+	// Preview
+	w := httptest.NewRecorder()
+	h.PreviewDeleteAllContactsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/contacts/delete-preview", nil), user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview status = %d, body %s", w.Code, w.Body.String())
+	}
+	var preview struct {
+		Count        int    `json:"count"`
+		Confirmation string `json:"confirmation"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&preview); err != nil {
+		t.Fatalf("decoding preview: %v", err)
+	}
+	if preview.Count != 2 || preview.Confirmation == "" {
+		t.Fatalf("preview = %+v, want count 2 and a confirmation", preview)
+	}
+
+	deleteAll := func(confirm string) int {
+		target := "/api/v1/contacts"
+		if confirm != "" {
+			target += "?confirm=" + url.QueryEscape(confirm)
+		}
+		w := httptest.NewRecorder()
+		h.DeleteAllContactsAPI(w, withUser(httptest.NewRequest(http.MethodDelete, target, nil), user))
+		return w.Code
+	}
+	remaining := func() int {
+		count, err := database.GetContactCount(user.ID)
+		if err != nil {
+			t.Fatalf("GetContactCount: %v", err)
+		}
+		return count
+	}
+
+	for _, confirm := range []string{"", "1.forged"} {
+		if code := deleteAll(confirm); code != http.StatusPreconditionFailed {
+			t.Errorf("delete with confirm %q: status = %d, want %d", confirm, code, http.StatusPreconditionFailed)
+		}
+	}
+	if n := remaining(); n != 2 {
+		t.Fatalf("%d contacts left after rejected deletes, want 2", n)
+	}
+
+	if code := deleteAll(preview.Confirmation); code != http.StatusOK {
+		t.Fatalf("delete with confirmation: status = %d, want %d", code, http.StatusOK)
+	}
+	if n := remaining(); n != 0 {
+		t.Errorf("%d contacts left after delete, want 0", n)
+	}
+}
+
+func TestExcludeEmptyContacts(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	empty := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Empty"})
+	withEmail := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName: "Email Only",
+		Emails:   []models.Email{{Email: "email@example.com", TypeLabel: "home"}},
+	})
+	withPhone := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName: "Phone Only",
+		Phones:   []models.Phone{{Phone: "+15555550100", TypeLabel: "mobile"}},
+	})
+	withAddress := dbtest.NewContact(t, database, user.ID, &models.Contact{
+		FullName:  "Address Only",
+		Addresses: []models.Address{{Street: "1 Main St", City: "Springfield", TypeLabel: "home"}},
+	})
+
+	w := httptest.NewRecorder()
+	h.ExcludeEmptyContactsAPI(w, withUser(httptest.NewRequest(http.MethodPost, "/api/v1/contacts/exclude-empty", nil), user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Count != 1 {
+		t.Errorf("count = %d, want 1", resp.Count)
+	}
+
+	for _, tt := range []struct {
+		contact *models.Contact
+		want    bool
+	}{{empty, true}, {withEmail, false}, {withPhone, false}, {withAddress, false}} {
+		got, err := database.GetContactByID(user.ID, tt.contact.ID)
+		if err != nil {
+			t.Fatalf("GetContactByID(%d): %v", tt.contact.ID, err)
+		}
+		if got.ExcludeFromSync != tt.want {
+			t.Errorf("%s: exclude_from_sync = %v, want %v", tt.contact.FullName, got.ExcludeFromSync, tt.want)
+		}
+	}
+}
+
+func TestNotificationSettingRejectsInvalidTime(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+	}{
+		{"create", h.CreateNotificationSettingAPI, http.MethodPost},
+		{"update", h.UpdateNotificationSettingAPI, http.MethodPut},
+	} {
+		for _, notificationTime := range []string{"25:00", "9am", "12:5"} {
+			body := `{"name":"Digest","provider_type":"discord","webhook_url":"https://example.com/hook","notification_time":"` + notificationTime + `"}`
+			w := httptest.NewRecorder()
+			tt.handler(w, withID(withUser(httptest.NewRequest(tt.method, "/api/v1/notifications/1", strings.NewReader(body)), user), 1))
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("%s with %q: status = %d, want %d", tt.name, notificationTime, w.Code, http.StatusUnprocessableEntity)
+			}
+		}
+	}
+}
+
+func TestCreateNotificationSettingValidatesProvider(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, body := range []string{
+		`{"name":"Pager","provider_type":"pager","webhook_url":"https://example.com/hook"}`,
+		`{"name":"Telegram","provider_type":"telegram","webhook_url":"123:secret"}`,
+		`{"name":"Telegram","provider_type":"telegram","target_address":"-100200"}`,
+		`{"name":"ntfy","provider_type":"ntfy"}`,
+		`{"name":"Email","provider_type":"email"}`,
+	} {
+		w := httptest.NewRecorder()
+		h.CreateNotificationSettingAPI(w, withUser(httptest.NewRequest(http.MethodPost, "/api/v1/notifications", strings.NewReader(body)), user))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+// exportNotificationSettings calls ExportNotificationSettingsAPI and returns the raw export
+func exportNotificationSettings(t *testing.T, h *Handler, user *models.User, includeSecrets bool) []byte {
+	t.Helper()
+
+	path := "/api/v1/notification-settings/export"
+	if includeSecrets {
+		path += "?include_secrets=true"
+	}
+	w := httptest.NewRecorder()
+	h.ExportNotificationSettingsAPI(w, withUser(httptest.NewRequest(http.MethodGet, path, nil), user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	return w.Body.Bytes()
+}
+
+// importNotificationSettings calls ImportNotificationSettingsAPI and decodes the counts
+func importNotificationSettings(t *testing.T, h *Handler, user *models.User, export []byte) (created, updated int, skipped []string) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	h.ImportNotificationSettingsAPI(w, withUser(httptest.NewRequest(http.MethodPost, "/api/v1/notification-settings/import", strings.NewReader(string(export))), user))
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var result struct {
+		Created int      `json:"created"`
+		Updated int      `json:"updated"`
+		Skipped []string `json:"skipped"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("decoding import result: %v", err)
+	}
+	return result.Created, result.Updated, result.Skipped
+}
+
+func TestNotificationSettingsExportImport(t *testing.T) {
+	h, database := newTestHandler(t)
+	source := dbtest.NewUser(t, database)
+
+	webhook := "https://discord.example.com/api/webhooks/1/secret"
+	botToken := "123:secret"
+	chatID := "-100200"
+	originals := []models.NotificationSetting{
+		{
+			Name: "Discord digest", ProviderType: "discord", WebhookURL: &webhook,
+			DaysLookAhead: 7, NotificationTime: "08:30", IncludeBirthdays: true, IncludeEventDates: true,
+			EventRegex: "(?i)graduation", Enabled: true,
+		},
+		{
+			Name: "Telegram", ProviderType: "telegram", WebhookURL: &botToken, TargetAddress: &chatID,
+			DaysLookAhead: 0, NotificationTime: "18:00", IncludeAnniversaries: true,
+		},
+	}
+	for i := range originals {
+		if _, err := database.CreateNotificationSetting(source.ID, &originals[i]); err != nil {
+			t.Fatalf("CreateNotificationSetting: %v", err)
+		}
+	}
+
+	// Same-field comparison, ignoring what isn't portable between users
+	comparable := func(s models.NotificationSetting) models.NotificationSetting {
+		s.ID, s.UserID, s.LastSentAt = 0, 0, nil
+		s.CreatedAt, s.UpdatedAt = time.Time{}, time.Time{}
+		return s
+	}
+	assertRestored := func(user *models.User) {
+		t.Helper()
+		restored, err := database.GetAllUserNotificationSettings(user.ID)
+		if err != nil {
+			t.Fatalf("GetAllUserNotificationSettings: %v", err)
+		}
+		if len(restored) != len(originals) {
+			t.Fatalf("restored %d notifiers, want %d", len(restored), len(originals))
+		}
+		for _, want := range originals {
+			found := false
+			for _, got := range restored {
+				if got.Name == want.Name {
+					found = true
+					if !reflect.DeepEqual(comparable(got), comparable(want)) {
+						t.Errorf("restored %+v\nwant %+v", comparable(got), comparable(want))
+					}
+				}
+			}
+			if !found {
+				t.Errorf("notifier %q wasn't restored", want.Name)
+			}
+		}
+	}
+
+	t.Run("with secrets", func(t *testing.T) {
+		target := dbtest.NewUser(t, database)
+		created, updated, skipped := importNotificationSettings(t, h, target, exportNotificationSettings(t, h, source, true))
+		if created != 2 || updated != 0 || len(skipped) != 0 {
+			t.Errorf("import = %d created, %d updated, skipped %q; want 2 created", created, updated, skipped)
+		}
+		assertRestored(target)
+	})
+
+	t.Run("masked", func(t *testing.T) {
+		export := exportNotificationSettings(t, h, source, false)
+		if strings.Contains(string(export), webhook) || strings.Contains(string(export), botToken) {
+			t.Fatalf("masked export contains a secret: %s", export)
+		}
+
+		// Nothing to take the secrets from
+		target := dbtest.NewUser(t, database)
+		if created, _, skipped := importNotificationSettings(t, h, target, export); created != 0 || len(skipped) != 2 {
+			t.Errorf("masked import into a new user created %d and skipped %q, want both skipped", created, skipped)
+		}
+
+		// Restoring over the same notifiers keeps their secrets
+		if created, updated, skipped := importNotificationSettings(t, h, source, export); created != 0 || updated != 2 || len(skipped) != 0 {
+			t.Errorf("masked import over the originals = %d created, %d updated, skipped %q; want 2 updated", created, updated, skipped)
+		}
+		assertRestored(source)
+	})
+}
+
+func TestMatchedEventsAPI(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice", BirthdayMonth: utils.IntPtr(12), BirthdayDay: utils.IntPtr(28)})
+	dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob", BirthdayMonth: utils.IntPtr(1), BirthdayDay: utils.IntPtr(20)})
+
+	webhook := "https://discord.example.com/api/webhooks/1/secret"
+	setting := models.NotificationSetting{
+		Name: "Digest", ProviderType: "discord", WebhookURL: &webhook,
+		DaysLookAhead: 7, NotificationTime: "09:00", IncludeBirthdays: true, Enabled: true,
+	}
+	id, err := database.CreateNotificationSetting(user.ID, &setting)
+	if err != nil {
+		t.Fatalf("CreateNotificationSetting: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/notification-settings/"+strconv.Itoa(id)+"/matched-events?date=2026-12-25", nil)
+	w := httptest.NewRecorder()
+	h.MatchedEventsAPI(w, withID(withUser(r, user), id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var result struct {
+		SettingID int                    `json:"setting_id"`
+		Date      string                 `json:"date"`
+		Events    []models.UpcomingEvent `json:"events"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if result.SettingID != id || result.Date != "2026-12-25" {
+		t.Errorf("setting %d on %s, want %d on 2026-12-25", result.SettingID, result.Date, id)
+	}
+	if len(result.Events) != 1 || result.Events[0].ContactID != alice.ID || result.Events[0].DaysUntil != 3 {
+		t.Errorf("events = %+v, want only Alice's birthday in 3 days", result.Events)
+	}
+}
+
+func TestMatchedEventsAPIRejectsInvalidDate(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, date := range []string{"12/25/2026", "2026-13-01", "tomorrow"} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/notification-settings/1/matched-events?date="+url.QueryEscape(date), nil)
+		w := httptest.NewRecorder()
+		h.MatchedEventsAPI(w, withID(withUser(r, user), 1))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("date %q: status = %d, want %d", date, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+// dashboardEventCountPattern finds the "Upcoming Events" figure on the dashboard
+var dashboardEventCountPattern = regexp.MustCompile(`Upcoming Events</div>\s*<div class="stat-value[^"]*">(\d+)</div>`)
+
+func TestEventLookaheadPreference(t *testing.T) {
+	h, database := newTemplateHandler(t)
+	created := dbtest.NewUser(t, database)
+
+	for i, daysAhead := range []int{3, 20} {
+		date := time.Now().AddDate(0, 0, daysAhead)
+		dbtest.NewContact(t, database, created.ID, &models.Contact{
+			FullName:      "Contact " + strconv.Itoa(i),
+			BirthdayMonth: utils.IntPtr(int(date.Month())),
+			BirthdayDay:   utils.IntPtr(date.Day()),
+		})
+	}
+
+	// The middleware loads the user with their preferences on every request
+	dashboardCount := func() string {
+		t.Helper()
+		user, err := database.GetUserByID(created.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		w := httptest.NewRecorder()
+		h.Index(w, withUser(httptest.NewRequest(http.MethodGet, "/", nil), user))
+		if w.Code != http.StatusOK {
+			t.Fatalf("dashboard: status = %d", w.Code)
+		}
+		m := dashboardEventCountPattern.FindStringSubmatch(w.Body.String())
+		if m == nil {
+			t.Fatal("dashboard has no upcoming events count")
+		}
+		return m[1]
+	}
+	setLookahead := func(days int) int {
+		body := `{"default_event_lookahead_days": ` + strconv.Itoa(days) + `}`
+		w := httptest.NewRecorder()
+		h.UpdatePreferencesAPI(w, withUser(httptest.NewRequest(http.MethodPut, "/api/v1/user/preferences", strings.NewReader(body)), created))
+		return w.Code
+	}
+
+	if got := dashboardCount(); got != "1" {
+		t.Errorf("default 7 day window: dashboard counts %s events, want 1", got)
+	}
+
+	if code := setLookahead(30); code != http.StatusOK {
+		t.Fatalf("setting 30 days: status = %d", code)
+	}
+	if got := dashboardCount(); got != "2" {
+		t.Errorf("30 day window: dashboard counts %s events, want 2", got)
+	}
+
+	for _, days := range []int{0, 366} {
+		if code := setLookahead(days); code != http.StatusBadRequest {
+			t.Errorf("setting %d days: status = %d, want %d", days, code, http.StatusBadRequest)
+		}
+	}
+	user, err := database.GetUserByID(created.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.EventLookaheadDays != 30 {
+		t.Errorf("lookahead after rejected updates = %d, want 30", user.EventLookaheadDays)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/steveredden/KindredCard/internal/middleware"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// AddContactTagAPI godoc
+//
+//	@Summary		Tags a contact
+//	@Description	Adds a tag to a contact, creating the tag if needed. Tags are case-insensitive and exported as vCard CATEGORIES
+//	@Tags			contacts
+//	@Accept			json
+//	@Produce		json
+//	@Param			cid	path		int					true	"Contact ID"
+//	@Param			tag	body		models.TagJSON		true	"Tag name"
+//	@Success		200	{object}	[]models.Tag		"The contact's tags"
+//	@Failure		400	{object}	map[string]string	"Invalid request body or contact ID"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		404	{object}	map[string]string	"Contact not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{cid}/tags [post]
+func (h *Handler) AddContactTagAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get Contact ID from URL
+	contactID, err := strconv.Atoi(mux.Vars(r)["cid"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.TagJSON
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	// Commas separate values in vCard CATEGORIES, so they can't be part of a tag
+	input.Tag = strings.TrimSpace(input.Tag)
+	if input.Tag == "" || len(input.Tag) > 100 || strings.Contains(input.Tag, ",") {
+		http.Error(w, "Tag must be 1-100 characters and cannot contain commas", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.AddTag(user.ID, contactID, input.Tag); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Update failed", http.StatusInternalServerError)
+		return
+	}
+
+	tags, err := h.db.GetTags(contactID)
+	if err != nil {
+		http.Error(w, "Error loading tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// RemoveContactTagAPI godoc
+//
+//	@Summary		Removes a tag from a contact
+//	@Description	Removes a tag (case-insensitive) from a contact. Tags no longer used by any contact are deleted
+//	@Tags			contacts
+//	@Produce		json
+//	@Param			cid	path		int					true	"Contact ID"
+//	@Param			tag	path		string				true	"Tag name"
+//	@Success		200	{object}	map[string]string	"deleted"
+//	@Failure		400	{object}	map[string]string	"Invalid contact ID"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		404	{object}	map[string]string	"Contact or tag not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/contacts/{cid}/tags/{tag} [delete]
+func (h *Handler) RemoveContactTagAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	// Get Contact ID from URL
+	contactID, err := strconv.Atoi(vars["cid"])
+	if err != nil {
+		http.Error(w, "Invalid Contact ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.RemoveTag(user.ID, contactID, vars["tag"]); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Tag not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Deletion failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+}
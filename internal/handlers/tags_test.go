@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+	"github.com/gorilla/mux"
+	"github.com/steveredden/KindredCard/internal/converter"
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// addTag calls AddContactTagAPI and returns the status and the contact's tags
+func addTag(t *testing.T, h *Handler, user *models.User, contactID int, tag string) (int, []models.Tag) {
+	t.Helper()
+
+	body, _ := json.Marshal(models.TagJSON{Tag: tag})
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/contacts/"+strconv.Itoa(contactID)+"/tags", strings.NewReader(string(body)))
+	r = mux.SetURLVars(withUser(r, user), map[string]string{"cid": strconv.Itoa(contactID)})
+	w := httptest.NewRecorder()
+	h.AddContactTagAPI(w, r)
+
+	var tags []models.Tag
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&tags); err != nil {
+			t.Fatalf("decoding tags: %v", err)
+		}
+	}
+	return w.Code, tags
+}
+
+func TestContactTags(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob"})
+
+	addTag(t, h, user, alice.ID, "friends")
+	code, tags := addTag(t, h, user, alice.ID, "Work")
+	if code != http.StatusOK {
+		t.Fatalf("adding a second tag: status = %d, want %d", code, http.StatusOK)
+	}
+	if len(tags) != 2 {
+		t.Errorf("Alice's tags = %+v, want friends and Work", tags)
+	}
+	addTag(t, h, user, bob.ID, "friends")
+
+	// The filter is case-insensitive
+	page, _ := listContacts(t, h, user, "tag=work")
+	if got := contactNames(page); strings.Join(got, ",") != "Alice" {
+		t.Errorf("tag=work lists %q, want only Alice", got)
+	}
+	page, _ = listContacts(t, h, user, "tag=friends")
+	if got := contactNames(page); strings.Join(got, ",") != "Alice,Bob" {
+		t.Errorf("tag=friends lists %q, want Alice and Bob", got)
+	}
+
+	r := httptest.NewRequest(http.MethodDelete, "/api/v1/contacts/"+strconv.Itoa(alice.ID)+"/tags/Work", nil)
+	r = mux.SetURLVars(withUser(r, user), map[string]string{"cid": strconv.Itoa(alice.ID), "tag": "Work"})
+	w := httptest.NewRecorder()
+	h.RemoveContactTagAPI(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("removing a tag: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if page, _ := listContacts(t, h, user, "tag=work"); len(page.Contacts) != 0 {
+		t.Errorf("tag=work lists %q after removing the tag, want nobody", contactNames(page))
+	}
+
+	// Tags export as CATEGORIES
+	contact, err := database.GetContactByID(user.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if got := converter.ContactToVCard(contact, nil, false).Values(vcard.FieldCategories); strings.Join(got, ",") != "friends" {
+		t.Errorf("exported CATEGORIES = %q, want friends", got)
+	}
+}
+
+func TestAddContactTagRejectsInvalidTags(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, tag := range []string{"", "   ", "a,b", strings.Repeat("x", 101)} {
+		if code, _ := addTag(t, h, user, 1, tag); code != http.StatusBadRequest {
+			t.Errorf("tag %q: status = %d, want %d", tag, code, http.StatusBadRequest)
+		}
+	}
+}
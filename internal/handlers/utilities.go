@@ -10,7 +10,9 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/middleware"
@@ -18,6 +20,13 @@ import (
 	"github.com/steveredden/KindredCard/internal/utils"
 )
 
+const (
+	// defaultGenderPageSize is how many contacts the gender assigner loads per page
+	defaultGenderPageSize = 50
+	// maxGenderPageSize caps the gender assigner's limit parameter
+	maxGenderPageSize = 500
+)
+
 func (h *Handler) GenderAssignmentPage(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
@@ -25,7 +34,28 @@ func (h *Handler) GenderAssignmentPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	contacts, err := h.db.GetContactsMissingGender(user.ID)
+	// Paging: ?limit=N&offset=M
+	limit := defaultGenderPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxGenderPageSize {
+			http.Error(w, "Invalid limit; expected 1-500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	offset := 0
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+
+	contacts, total, err := h.db.GetContactsMissingGender(user.ID, limit, offset)
 	if err != nil {
 		http.Error(w, "Database error", 500)
 		return
@@ -37,10 +67,13 @@ func (h *Handler) GenderAssignmentPage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.renderTemplate(w, r, "util_gender_assign.html", map[string]interface{}{
-		"Title": "Gender Assigner",
-		"User":  user,
-		"Items": contacts,
-		"Count": len(contacts),
+		"Title":  "Gender Assigner",
+		"User":   user,
+		"Items":  contacts,
+		"Count":  len(contacts),
+		"Total":  total,
+		"Limit":  limit,
+		"Offset": offset,
 	})
 }
 
@@ -77,7 +110,7 @@ func (h *Handler) RelationshipAssignmentPage(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	suggestions, err := h.db.GetRelationshipSuggestions(user.ID)
+	suggestions, _, err := h.db.GetRelationshipSuggestions(user.ID)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -180,3 +213,145 @@ func (h *Handler) LabelsPage(w http.ResponseWriter, r *http.Request) {
 		"CurrentFilter": filter,
 	})
 }
+
+// GetRelationshipSuggestionsAPI godoc
+//
+//	@Summary		List relationship suggestions
+//	@Description	Infers missing relationships from existing ones: spouses of parents (step-children), children of the same parent (siblings), and children's children (grandchildren). Each suggestion proposes relating proposed_id to target_id with relationship_type_id. The search is bounded by RELATIONSHIP_SUGGESTION_MAX_STEPS and RELATIONSHIP_SUGGESTION_MAX_RESULTS; truncated is true when it stopped early
+//	@Tags			relationships
+//	@Produce		json
+//	@Success		200	{object}	models.RelationshipSuggestionList	"Suggestions"
+//	@Failure		401	{object}	map[string]string					"Unauthorized"
+//	@Failure		500	{object}	map[string]string					"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/relationships/suggestions [get]
+func (h *Handler) GetRelationshipSuggestionsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	suggestions, truncated, err := h.db.GetRelationshipSuggestions(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading suggestions", http.StatusInternalServerError)
+		return
+	}
+	if suggestions == nil {
+		suggestions = []models.RelationshipSuggestion{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RelationshipSuggestionList{Suggestions: suggestions, Truncated: truncated})
+}
+
+// AcceptRelationshipSuggestionAPI godoc
+//
+//	@Summary		Accept a relationship suggestion
+//	@Description	Adds the relationship a current suggestion proposes. Once added, the suggestion is no longer returned
+//	@Tags			relationships
+//	@Accept			json
+//	@Produce		json
+//	@Param			suggestion	body		models.RelationshipSuggestionAccept	true	"Suggestion to accept"
+//	@Success		201			{object}	models.Relationship					"Created relationship"
+//	@Failure		400			{object}	map[string]string					"Invalid request body"
+//	@Failure		401			{object}	map[string]string					"Unauthorized"
+//	@Failure		404			{object}	map[string]string					"No such suggestion"
+//	@Failure		500			{object}	map[string]string					"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/relationships/suggestions/accept [post]
+func (h *Handler) AcceptRelationshipSuggestionAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	var req models.RelationshipSuggestionAccept
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rel, err := h.db.AcceptRelationshipSuggestion(user.ID, req)
+	if err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Suggestion not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error accepting suggestion", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rel)
+}
+
+// GetAnniversarySuggestionsAPI godoc
+//
+//	@Summary		List anniversary suggestions
+//	@Description	Finds spouses where one has an anniversary and the other doesn't. proposed_value is the date to copy, as YYYY-MM-DD or MM-DD when only the month and day are known
+//	@Tags			contacts
+//	@Produce		json
+//	@Success		200	{array}		models.RelationshipSuggestion	"Suggestions"
+//	@Failure		401	{object}	map[string]string				"Unauthorized"
+//	@Failure		500	{object}	map[string]string				"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/suggestions/anniversaries [get]
+func (h *Handler) GetAnniversarySuggestionsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	suggestions, err := h.db.GetAnniversarySuggestions(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading suggestions", http.StatusInternalServerError)
+		return
+	}
+	if suggestions == nil {
+		suggestions = []models.RelationshipSuggestion{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestions)
+}
+
+// AcceptAnniversarySuggestionAPI godoc
+//
+//	@Summary		Accept an anniversary suggestion
+//	@Description	Copies the source contact's anniversary (full or partial) onto their spouse, the target. Only current suggestions can be accepted
+//	@Tags			contacts
+//	@Accept			json
+//	@Produce		json
+//	@Param			suggestion	body		models.AnniversarySuggestionAccept	true	"Suggestion to accept"
+//	@Success		200			{object}	map[string]string					"Anniversary copied"
+//	@Failure		400			{object}	map[string]string					"Invalid request body"
+//	@Failure		401			{object}	map[string]string					"Unauthorized"
+//	@Failure		404			{object}	map[string]string					"No such suggestion"
+//	@Failure		500			{object}	map[string]string					"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/suggestions/anniversaries/accept [post]
+func (h *Handler) AcceptAnniversarySuggestionAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	var req models.AnniversarySuggestionAccept
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.AcceptAnniversarySuggestion(user.ID, req); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Suggestion not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error accepting suggestion", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+}
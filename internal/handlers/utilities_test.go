@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestGenderAssignmentPageRejectsInvalidPaging(t *testing.T) {
+	h := &Handler{}
+	user := &models.User{ID: 1}
+
+	for _, query := range []string{"limit=0", "limit=501", "limit=all", "offset=-1", "offset=next"} {
+		w := httptest.NewRecorder()
+		h.GenderAssignmentPage(w, withUser(httptest.NewRequest(http.MethodGet, "/utilities/gender-assignment?"+query, nil), user))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestAcceptRelationshipSuggestion(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	mom := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Mom", Gender: "F"})
+	son := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Son", Gender: "M"})
+	daughter := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Daughter", Gender: "F"})
+	for _, child := range []struct {
+		contact *models.Contact
+		kind    string
+	}{{son, "Son"}, {daughter, "Daughter"}} {
+		if _, _, err := database.AddRelationship(user.ID, mom.ID, child.contact.ID, dbtest.RelationshipTypeID(t, database, child.kind)); err != nil {
+			t.Fatalf("AddRelationship: %v", err)
+		}
+	}
+
+	list := func() []models.RelationshipSuggestion {
+		w := httptest.NewRecorder()
+		h.GetRelationshipSuggestionsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/relationships/suggestions", nil), user))
+		if w.Code != http.StatusOK {
+			t.Fatalf("list: status = %d, body %s", w.Code, w.Body.String())
+		}
+		var resp models.RelationshipSuggestionList
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decoding suggestions: %v", err)
+		}
+		return resp.Suggestions
+	}
+	accept := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/relationships/suggestions/accept", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.AcceptRelationshipSuggestionAPI(w, withUser(r, user))
+		return w
+	}
+
+	suggestions := list()
+	if len(suggestions) != 1 {
+		t.Fatalf("got suggestions %+v, want only the siblings", suggestions)
+	}
+	sibling := suggestions[0]
+	pair := map[int]bool{sibling.ProposedID: true, sibling.TargetID: true}
+	if !pair[son.ID] || !pair[daughter.ID] || sibling.RelationshipTypeID == 0 {
+		t.Fatalf("suggestion %+v doesn't relate Son and Daughter", sibling)
+	}
+
+	body, _ := json.Marshal(models.RelationshipSuggestionAccept{
+		ProposedID: sibling.ProposedID, TargetID: sibling.TargetID, RelationshipTypeID: sibling.RelationshipTypeID,
+	})
+	w := accept(string(body))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("accept: status = %d, body %s", w.Code, w.Body.String())
+	}
+	var rel models.Relationship
+	if err := json.NewDecoder(w.Body).Decode(&rel); err != nil {
+		t.Fatalf("decoding relationship: %v", err)
+	}
+	if rel.ContactID != sibling.ProposedID || rel.RelatedContactID != sibling.TargetID {
+		t.Errorf("created relationship %+v, want %d to %d", rel, sibling.ProposedID, sibling.TargetID)
+	}
+
+	if got := list(); len(got) != 0 {
+		t.Errorf("suggestions after accepting = %+v, want none", got)
+	}
+	if w := accept(string(body)); w.Code != http.StatusNotFound {
+		t.Errorf("accepting again: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestAcceptAnniversarySuggestion(t *testing.T) {
+	h, database := newTestHandler(t)
+	user := dbtest.NewUser(t, database)
+
+	month, day := 6, 20
+	a := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Adam", AnniversaryMonth: &month, AnniversaryDay: &day})
+	b := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Beth"})
+	if _, _, err := database.AddRelationship(user.ID, a.ID, b.ID, dbtest.RelationshipTypeID(t, database, "Spouse")); err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+
+	list := func() []models.RelationshipSuggestion {
+		w := httptest.NewRecorder()
+		h.GetAnniversarySuggestionsAPI(w, withUser(httptest.NewRequest(http.MethodGet, "/api/v1/suggestions/anniversaries", nil), user))
+		if w.Code != http.StatusOK {
+			t.Fatalf("list: status = %d, body %s", w.Code, w.Body.String())
+		}
+		var suggestions []models.RelationshipSuggestion
+		if err := json.NewDecoder(w.Body).Decode(&suggestions); err != nil {
+			t.Fatalf("decoding suggestions: %v", err)
+		}
+		return suggestions
+	}
+	accept := func() int {
+		body := `{"target_id": ` + strconv.Itoa(b.ID) + `, "source_id": ` + strconv.Itoa(a.ID) + `}`
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/suggestions/anniversaries/accept", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.AcceptAnniversarySuggestionAPI(w, withUser(r, user))
+		return w.Code
+	}
+
+	suggestions := list()
+	if len(suggestions) != 1 || suggestions[0].TargetID != b.ID || suggestions[0].ProposedID != a.ID || suggestions[0].ProposedVal != "06-20" {
+		t.Fatalf("suggestions = %+v, want Adam's 06-20 proposed for Beth", suggestions)
+	}
+
+	before := dbtest.VersionToken(t, database, user.ID, b.UID)
+	if code := accept(); code != http.StatusOK {
+		t.Fatalf("accept: status = %d, want %d", code, http.StatusOK)
+	}
+
+	got, err := database.GetContactByID(user.ID, b.ID)
+	if err != nil {
+		t.Fatalf("GetContactByID: %v", err)
+	}
+	if got.Anniversary != nil || got.AnniversaryMonth == nil || *got.AnniversaryMonth != 6 || got.AnniversaryDay == nil || *got.AnniversaryDay != 20 {
+		t.Errorf("Beth's anniversary = %v, month %v, day %v; want only the partial June 20", got.Anniversary, got.AnniversaryMonth, got.AnniversaryDay)
+	}
+	if after := dbtest.VersionToken(t, database, user.ID, b.UID); after <= before {
+		t.Errorf("Beth's version_token = %d, want more than %d", after, before)
+	}
+
+	if got := list(); len(got) != 0 {
+		t.Errorf("suggestions after accepting = %+v, want none", got)
+	}
+	if code := accept(); code != http.StatusNotFound {
+		t.Errorf("accepting again: status = %d, want %d", code, http.StatusNotFound)
+	}
+}
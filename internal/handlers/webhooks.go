@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/steveredden/KindredCard/internal/middleware"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/netguard"
+	"github.com/steveredden/KindredCard/internal/webhook"
+)
+
+// CreateWebhookAPI godoc
+//
+//	@Summary		Add a webhook
+//	@Description	Registers a public http(s) URL to be POSTed {"event": "contact.updated", "contact": {...}} after contacts are created, updated, or deleted; the contact is as it was right after the change. Each request is signed in X-KindredCard-Signature with the base64url HMAC-SHA256 of the body keyed by the secret, which is generated when omitted and only returned here. No events subscribes to all of them
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook	body		models.CreateWebhookRequest	true	"Webhook"
+//	@Success		201		{object}	models.Webhook				"The new webhook, with its secret"
+//	@Failure		400		{object}	map[string]string			"Invalid or internal URL, or unknown event"
+//	@Failure		401		{object}	map[string]string			"Unauthorized"
+//	@Failure		500		{object}	map[string]string			"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/webhooks [post]
+func (h *Handler) CreateWebhookAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "URL must be an absolute http or https URL", http.StatusBadRequest)
+		return
+	}
+	if err := netguard.CheckHost(u.Hostname()); errors.Is(err, netguard.ErrBlockedAddress) {
+		http.Error(w, "URL must not point to a private or internal address", http.StatusBadRequest)
+		return
+	}
+
+	events := []string{}
+	for _, event := range req.Events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			http.Error(w, "Unknown event: "+event, http.StatusBadRequest)
+			return
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		events = models.WebhookEvents
+	}
+
+	if req.Secret == "" {
+		req.Secret, err = webhook.GenerateSecret()
+		if err != nil {
+			http.Error(w, "Error creating webhook", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	hook, err := h.db.CreateWebhook(user.ID, req.URL, req.Secret, events)
+	if err != nil {
+		http.Error(w, "Error creating webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hook)
+}
+
+// ListWebhooksAPI godoc
+//
+//	@Summary		List webhooks
+//	@Description	Lists the user's webhooks. Secrets are not returned
+//	@Tags			webhooks
+//	@Produce		json
+//	@Success		200	{array}		models.Webhook		"Webhooks"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/webhooks [get]
+func (h *Handler) ListWebhooksAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	hooks, err := h.db.GetWebhooksByUserID(user.ID)
+	if err != nil {
+		http.Error(w, "Error loading webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+// DeleteWebhookAPI godoc
+//
+//	@Summary		Delete a webhook
+//	@Description	Stops deliveries to a webhook and removes it
+//	@Tags			webhooks
+//	@Param			id	path	int	true	"Webhook ID"	minimum(1)
+//	@Success		204	"Webhook deleted"
+//	@Failure		400	{object}	map[string]string	"Invalid webhook ID"
+//	@Failure		401	{object}	map[string]string	"Unauthorized"
+//	@Failure		404	{object}	map[string]string	"Webhook not found"
+//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Security		ApiTokenAuth
+//	@Router			/api/v1/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhookAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.DeleteWebhook(user.ID, id); err != nil {
+		if err.Error() == "not found" {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error deleting webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -11,6 +11,7 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -102,6 +103,14 @@ func SetLevel(level LogLevel) {
 	defaultLogger.level = level
 }
 
+// SetOutput changes where log messages are written (stdout by default)
+func SetOutput(w io.Writer) {
+	if defaultLogger == nil {
+		Init()
+	}
+	defaultLogger.logger.SetOutput(w)
+}
+
 // GetLevel returns the current log level
 func GetLevel() LogLevel {
 	if defaultLogger == nil {
@@ -3,8 +3,13 @@ package mailer
 import (
 	"bytes"
 	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net/smtp"
+	"net/textproto"
 	"os"
+	"strings"
 	"text/template"
 	"time"
 
@@ -21,9 +26,11 @@ type Config struct {
 	From string
 }
 
+// EmailContent is a digest rendered as both HTML and a plain-text alternative
 type EmailContent struct {
 	Subject string
-	Body    string
+	Body    string // HTML
+	Text    string // plain text
 }
 
 func LoadConfig() Config {
@@ -36,31 +43,78 @@ func LoadConfig() Config {
 	}
 }
 
-func SendEventNotification(to, subject, body string) error {
+// SendEventNotification emails content to a single recipient as multipart/alternative
+// (plain text + HTML). SMTP_USER/SMTP_PASS are optional so unauthenticated relays work
+func SendEventNotification(to string, content EmailContent) error {
 	c := LoadConfig()
-	if c.Host == "" || c.Port == "" || c.User == "" || c.Pass == "" || c.From == "" {
+	if c.Host == "" || c.Port == "" || c.From == "" {
 		logger.Error("[MAILER] Missing required SMTP environment variables!")
-		return nil
+		return fmt.Errorf("smtp is not configured; set SMTP_HOST, SMTP_PORT and SMTP_FROM")
 	}
 
-	// Standard HTML Email Headers
-	header := make(map[string]string)
-	header["From"] = c.From
-	header["To"] = to
-	header["Subject"] = subject
-	header["MIME-Version"] = "1.0"
-	header["Content-Type"] = "text/html; charset=\"utf-8\""
-
-	var message string
-	for k, v := range header {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
+	message, err := buildMessage(c.From, to, content)
+	if err != nil {
+		return err
 	}
-	message += "\r\n" + body
 
-	auth := smtp.PlainAuth("", c.User, c.Pass, c.Host)
+	var auth smtp.Auth
+	if c.User != "" {
+		auth = smtp.PlainAuth("", c.User, c.Pass, c.Host)
+	}
 	addr := fmt.Sprintf("%s:%s", c.Host, c.Port)
 
-	return smtp.SendMail(addr, auth, c.User, []string{to}, []byte(message))
+	sender := c.User
+	if sender == "" {
+		sender = c.From
+	}
+
+	return smtp.SendMail(addr, auth, sender, []string{to}, message)
+}
+
+// buildMessage renders the headers and a multipart/alternative body
+func buildMessage(from, to string, content EmailContent) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		value       string
+	}{
+		// Least preferred first, per RFC 2046
+		{"text/plain; charset=\"utf-8\"", content.Text},
+		{"text/html; charset=\"utf-8\"", content.Body},
+	}
+	for _, p := range parts {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.value)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", to)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", content.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%q\r\n", writer.Boundary())
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
 }
 
 // BuildTodayEventsBody creates an HTML body mimicking a Discord embed
@@ -143,7 +197,7 @@ func BuildTodayEventsBody(events []models.UpcomingEvent, baseURL string) EmailCo
 		}
 
 		item := map[string]interface{}{
-			"FullName":    e.FullName,
+			"FullName":    e.DisplayName(),
 			"ContactID":   e.ContactID,
 			"Description": desc,
 		}
@@ -165,7 +219,34 @@ func BuildTodayEventsBody(events []models.UpcomingEvent, baseURL string) EmailCo
 	return EmailContent{
 		Subject: "KindredCard Event Summary",
 		Body:    out.String(),
+		Text:    buildTextBody(data.Title, baseURL, data.Birthdays, data.Anniversaries, data.Others),
+	}
+}
+
+// buildTextBody renders the plain-text alternative of the digest
+func buildTextBody(title, baseURL string, sections ...[]map[string]interface{}) string {
+	headings := []string{"Birthdays", "Anniversaries", "Other Dates"}
+
+	var sb strings.Builder
+	sb.WriteString(title + "\n")
+
+	empty := true
+	for i, items := range sections {
+		if len(items) == 0 {
+			continue
+		}
+		empty = false
+		sb.WriteString("\n" + headings[i] + "\n")
+		for _, item := range items {
+			fmt.Fprintf(&sb, "- %s - %s\n  %s/contacts/%d\n", item["FullName"], item["Description"], baseURL, item["ContactID"])
+		}
+	}
+	if empty {
+		sb.WriteString("\nNo birthdays or anniversaries today!\n")
 	}
+
+	sb.WriteString("\n--\nSent by KindredCard\n")
+	return sb.String()
 }
 
 // SendTestNotification sends a test notification with dummy data
@@ -198,5 +279,5 @@ func SendTestNotification(recipient string, baseURL string) error {
 	}
 
 	body := BuildTodayEventsBody(dummyEvents, baseURL)
-	return SendEventNotification(recipient, "KindredCard Event Summary", body.Body)
+	return SendEventNotification(recipient, body)
 }
@@ -0,0 +1,192 @@
+package mailer
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// smtpMessage is what the mock SMTP server received
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// newMockSMTPServer accepts a single SMTP session on a local port and sends what it received.
+// It answers just enough of RFC 5321 for net/smtp.SendMail without authentication or STARTTLS
+func newMockSMTPServer(t *testing.T) (host, port string, received <-chan smtpMessage) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var msg smtpMessage
+		tp.PrintfLine("220 localhost ESMTP mock")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch verb {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL":
+				msg.from = line
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				msg.to = append(msg.to, line)
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				msg.data = string(data)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				ch <- msg
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port, ch
+}
+
+// messageParts decodes the parts of a multipart/alternative message, keyed by media type
+func messageParts(t *testing.T, raw string) (*mail.Message, map[string]string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("parsing message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+
+	parts := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading part: %v", err)
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		// NextPart undoes the quoted-printable encoding
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("reading %s part: %v", partType, err)
+		}
+		parts[partType] = string(body)
+	}
+	return msg, parts
+}
+
+func TestSendEventNotification(t *testing.T) {
+	host, port, received := newMockSMTPServer(t)
+	t.Setenv("SMTP_HOST", host)
+	t.Setenv("SMTP_PORT", port)
+	t.Setenv("SMTP_FROM", "kindredcard@example.com")
+	t.Setenv("SMTP_USER", "")
+	t.Setenv("SMTP_PASS", "")
+
+	age := 36
+	years := 10
+	events := []models.UpcomingEvent{
+		{ContactID: 1, FullName: "Alice Liddell", EventType: "birthday", AgeOrYears: &age, TimeDescription: "Today"},
+		{ContactID: 2, FullName: "Bob Builder", EventType: "birthday", TimeDescription: "Today"},
+		{ContactID: 3, FullName: "Carol Singer", EventType: "anniversary", AgeOrYears: &years, TimeDescription: "Today"},
+	}
+
+	content := BuildTodayEventsBody(events, "http://kindred.test")
+	if err := SendEventNotification("me@example.com", content); err != nil {
+		t.Fatalf("SendEventNotification: %v", err)
+	}
+
+	got := <-received
+	if len(got.to) != 1 || !strings.Contains(got.to[0], "<me@example.com>") {
+		t.Errorf("RCPT = %q, want me@example.com", got.to)
+	}
+
+	msg, parts := messageParts(t, got.data)
+	if to := msg.Header.Get("To"); to != "me@example.com" {
+		t.Errorf("To = %q, want me@example.com", to)
+	}
+
+	text := parts["text/plain"]
+	for _, want := range []string{
+		"Birthdays\n- Alice Liddell - 36th birthday Today!\n  http://kindred.test/contacts/1\n",
+		"- Bob Builder - has a birthday Today!\n",
+		"Anniversaries\n- Carol Singer - 10th wedding anniversary Today!\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("plain-text body is missing %q:\n%s", want, text)
+		}
+	}
+
+	html := parts["text/html"]
+	for _, want := range []string{`href="http://kindred.test/contacts/1"`, "Alice Liddell", "Bob Builder", "Carol Singer"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML body is missing %q", want)
+		}
+	}
+}
+
+func TestSendEventNotificationRequiresConfig(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	t.Setenv("SMTP_PORT", "")
+	t.Setenv("SMTP_FROM", "")
+
+	if err := SendEventNotification("me@example.com", EmailContent{}); err == nil {
+		t.Error("SendEventNotification succeeded without SMTP configuration")
+	}
+}
+
+func TestBuildTodayEventsBodyUsesSalutation(t *testing.T) {
+	events := []models.UpcomingEvent{
+		{ContactID: 1, FullName: "Linda Jones", AddressAs: "Aunt Linda", EventType: "birthday", TimeDescription: "Today"},
+		{ContactID: 2, FullName: "Bob Builder", EventType: "birthday", TimeDescription: "Today"},
+	}
+
+	content := BuildTodayEventsBody(events, "http://kindred.test")
+
+	for _, body := range []string{content.Text, content.Body} {
+		if !strings.Contains(body, "Aunt Linda") || strings.Contains(body, "Linda Jones") {
+			t.Errorf("digest should address Linda by her salutation:\n%s", body)
+		}
+		if !strings.Contains(body, "Bob Builder") {
+			t.Errorf("digest should fall back to the full name without a salutation:\n%s", body)
+		}
+	}
+}
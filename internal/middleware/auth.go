@@ -11,14 +11,19 @@ package middleware
 
 import (
 	"context"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/steveredden/KindredCard/internal/auth"
 	"github.com/steveredden/KindredCard/internal/db"
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/ratelimit"
+	"github.com/steveredden/KindredCard/internal/session"
 )
 
 type contextKey string
@@ -108,11 +113,17 @@ func AuthMiddleware(database *db.Database) func(http.Handler) http.Handler {
 
 // APIAuthMiddleware checks for multiple auth methods (API/Bearer/Session Cookie)
 // Halts on failure by returning a 401 Unauthorized response.
-func APIAuthMiddleware(database *db.Database) func(http.Handler) http.Handler {
+// When limiter is set, valid API tokens are rate limited per token, and failed token and session
+// logins per client IP; over the limit is a 429 Too Many Requests. An IP over its budget can't try
+// any token until it refills, so a guess can't succeed then either. The client IP is the
+// connection's address unless trustProxyHeaders is set (see session.RemoteIP)
+func APIAuthMiddleware(database *db.Database, limiter *ratelimit.Limiter, trustProxyHeaders bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			var user *models.User
 			var authMethodFound bool
+			var tokenScope string
+			clientIP := session.RemoteIP(r, trustProxyHeaders)
 
 			// Get APP_KEY once
 			appKey := os.Getenv("APP_KEY")
@@ -124,11 +135,26 @@ func APIAuthMiddleware(database *db.Database) func(http.Handler) http.Handler {
 
 			// 1. TRY API TOKEN (via "session" header)
 			apiToken := r.Header.Get("session")
+			if apiToken == "" && strings.HasSuffix(r.URL.Path, ".ics") {
+				// Calendar subscriptions can't set headers; allow the token in the feed URL
+				apiToken = r.URL.Query().Get("token")
+			}
 			if apiToken != "" {
 				authMethodFound = true
-				userID, err := database.ValidateAPIToken(apiToken)
+				if limiter != nil && limiter.Exhausted("ip:"+clientIP) {
+					apiTooManyRequests(w, limiter.RetryAfter())
+					return
+				}
+				userID, scope, err := database.ValidateAPIToken(apiToken)
 				if err == nil && userID > 0 {
+					if limiter != nil && !limiter.Allow("token:"+db.HashToken(apiToken)) {
+						apiTooManyRequests(w, limiter.RetryAfter())
+						return
+					}
 					user, _ = database.GetUserByID(userID)
+					tokenScope = scope
+					// Usage tracking is non-critical; a failure is logged and the request goes on
+					database.RecordAPITokenUse(apiToken, clientIP, r.UserAgent())
 				}
 			}
 
@@ -164,6 +190,13 @@ func APIAuthMiddleware(database *db.Database) func(http.Handler) http.Handler {
 
 			// 3. HALTING LOGIC
 			if user == nil {
+				// Only failed logins count against the client's IP; valid tokens have their own
+				// budget, so clients behind one address don't use up each other's
+				if authMethodFound && limiter != nil && !limiter.Allow("ip:"+clientIP) {
+					apiTooManyRequests(w, limiter.RetryAfter())
+					return
+				}
+
 				// If any auth method was attempted, return 401, otherwise return the appropriate web redirect
 				if authMethodFound || strings.HasPrefix(r.URL.Path, "/api/") {
 					apiUnauthorized(w) // HALT with 401 JSON
@@ -173,6 +206,12 @@ func APIAuthMiddleware(database *db.Database) func(http.Handler) http.Handler {
 				return
 			}
 
+			// Read-only API tokens may look but not touch
+			if tokenScope == models.APITokenScopeRead && r.Method != http.MethodGet && r.Method != http.MethodHead {
+				apiForbidden(w)
+				return
+			}
+
 			// SUCCESS: Add user to context
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -237,3 +276,18 @@ func apiUnauthorized(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusUnauthorized)
 	w.Write([]byte(`{"error": "Unauthorized: Invalid session or API token."}`))
 }
+
+// apiForbidden sends a 403 Forbidden JSON response for a read-only token attempting a write.
+func apiForbidden(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte(`{"error": "Forbidden: This API token is read-only."}`))
+}
+
+// apiTooManyRequests sends a 429 Too Many Requests JSON response, with Retry-After in whole seconds.
+func apiTooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write([]byte(`{"error": "Too many requests. Please slow down."}`))
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/db"
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/ratelimit"
+)
+
+// serveWithToken sends a request carrying an API token through APIAuthMiddleware to a handler
+// that answers 200 with the authenticated user in context
+func serveWithToken(t *testing.T, database *db.Database, method string, path string, token string) int {
+	t.Helper()
+
+	handler := APIAuthMiddleware(database, nil, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := GetUserFromContext(r); !ok {
+			t.Errorf("%s %s: no user in context", method, path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	r := httptest.NewRequest(method, path, nil)
+	r.Header.Set("session", token)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	t.Setenv("APP_KEY", "test-app-key")
+
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	read, err := database.CreateAPIToken(user.ID, "Dashboard widget", models.APITokenScopeRead, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIToken(read): %v", err)
+	}
+	write, err := database.CreateAPIToken(user.ID, "Sync script", models.APITokenScopeWrite, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIToken(write): %v", err)
+	}
+
+	if code := serveWithToken(t, database, http.MethodGet, "/api/v1/contacts", read.RawToken); code != http.StatusOK {
+		t.Errorf("read token GET: status = %d, want %d", code, http.StatusOK)
+	}
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch} {
+		if code := serveWithToken(t, database, method, "/api/v1/contacts", read.RawToken); code != http.StatusForbidden {
+			t.Errorf("read token %s: status = %d, want %d", method, code, http.StatusForbidden)
+		}
+		if code := serveWithToken(t, database, method, "/api/v1/contacts", write.RawToken); code != http.StatusOK {
+			t.Errorf("write token %s: status = %d, want %d", method, code, http.StatusOK)
+		}
+	}
+
+	_, scope, err := database.ValidateAPIToken(read.RawToken)
+	if err != nil || scope != models.APITokenScopeRead {
+		t.Errorf("ValidateAPIToken(read) scope = %q, %v; want %q", scope, err, models.APITokenScopeRead)
+	}
+
+	tokens, err := database.GetAPITokensByUserID(user.ID)
+	if err != nil {
+		t.Fatalf("GetAPITokensByUserID: %v", err)
+	}
+	scopes := make(map[string]string)
+	for _, token := range tokens {
+		scopes[token.Name] = token.Scope
+	}
+	if scopes["Dashboard widget"] != models.APITokenScopeRead || scopes["Sync script"] != models.APITokenScopeWrite {
+		t.Errorf("listed scopes = %v, want the read and write scopes they were created with", scopes)
+	}
+}
+
+func TestAPIAuthMiddlewareRateLimitsTokenAttempts(t *testing.T) {
+	t.Setenv("APP_KEY", "test-app-key")
+
+	const perMinute = 3
+	// Malformed tokens are rejected before the database is consulted, so none is needed
+	handler := APIAuthMiddleware(nil, ratelimit.New(perMinute), false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("an invalid token reached the handler")
+	}))
+
+	request := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts", nil)
+		r.Header.Set("session", "not-a-token")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 1; i <= perMinute; i++ {
+		if w := request(); w.Code != http.StatusUnauthorized {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	w := request()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request %d: status = %d, want %d", perMinute+1, w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("Retry-After"); got != "20" {
+		t.Errorf("Retry-After = %q, want 20", got)
+	}
+}
+
+func TestAPIAuthMiddlewareRateLimitsValidTokensPerToken(t *testing.T) {
+	t.Setenv("APP_KEY", "test-app-key")
+
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	var tokens []string
+	for _, name := range []string{"Laptop", "Phone"} {
+		token, err := database.CreateAPIToken(user.ID, name, models.APITokenScopeRead, nil)
+		if err != nil {
+			t.Fatalf("CreateAPIToken(%s): %v", name, err)
+		}
+		tokens = append(tokens, token.RawToken)
+	}
+
+	const perMinute = 2
+	handler := APIAuthMiddleware(database, ratelimit.New(perMinute), false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// Every request comes from httptest's default client address
+	request := func(token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts", nil)
+		r.Header.Set("session", token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for i, token := range tokens {
+		for n := 1; n <= perMinute; n++ {
+			if code := request(token); code != http.StatusOK {
+				t.Fatalf("token %d, request %d: status = %d, want %d", i+1, n, code, http.StatusOK)
+			}
+		}
+	}
+	if code := request(tokens[0]); code != http.StatusTooManyRequests {
+		t.Errorf("token 1 over its budget: status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+
+	// The valid tokens didn't spend the IP's budget for failed attempts
+	if code := request("not-a-token"); code != http.StatusUnauthorized {
+		t.Errorf("invalid token from the same IP: status = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestAPITokenRecordsLastUse(t *testing.T) {
+	t.Setenv("APP_KEY", "test-app-key")
+
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	token, err := database.CreateAPIToken(user.ID, "Laptop", models.APITokenScopeWrite, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIToken: %v", err)
+	}
+
+	handler := APIAuthMiddleware(database, nil, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, use := range []struct{ remoteAddr, userAgent string }{
+		{"198.51.100.7:50000", "curl/8.5.0"},
+		{"203.0.113.9:41000", "HomeAssistant/2026.10"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts", nil)
+		r.RemoteAddr = use.remoteAddr
+		r.Header.Set("session", token.RawToken)
+		r.Header.Set("User-Agent", use.userAgent)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request from %s: status = %d, want %d", use.remoteAddr, w.Code, http.StatusOK)
+		}
+	}
+
+	tokens, err := database.GetAPITokensByUserID(user.ID)
+	if err != nil {
+		t.Fatalf("GetAPITokensByUserID: %v", err)
+	}
+	if len(tokens) != 1 {
+		t.Fatalf("got %d tokens, want 1", len(tokens))
+	}
+	got := tokens[0]
+	if got.LastUsedIP != "203.0.113.9" || got.LastUsedUserAgent != "HomeAssistant/2026.10" {
+		t.Errorf("last use = %q / %q, want the second request's IP and User-Agent", got.LastUsedIP, got.LastUsedUserAgent)
+	}
+	if got.LastUsedAt == nil {
+		t.Error("LastUsedAt was not set")
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// compressionThreshold is the minimum body size (bytes) worth compressing
+const compressionThreshold = 1400
+
+// compressibleTypes are the Content-Type prefixes eligible for gzip
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/xml",
+	"application/javascript",
+	"image/svg+xml",
+}
+
+// gzipResponseWriter buffers the start of a response until it can decide whether
+// the body is large enough, and of a suitable type, to be compressed
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz         *gzip.Writer
+	buf        []byte
+	statusCode int
+	decided    bool
+}
+
+func (gw *gzipResponseWriter) WriteHeader(code int) {
+	if gw.decided {
+		return
+	}
+	gw.statusCode = code
+}
+
+func (gw *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !gw.decided {
+		gw.buf = append(gw.buf, b...)
+		if len(gw.buf) < compressionThreshold {
+			return len(b), nil
+		}
+		if err := gw.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if gw.gz != nil {
+		return gw.gz.Write(b)
+	}
+	return gw.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client
+func (gw *gzipResponseWriter) Flush() {
+	if !gw.decided {
+		gw.decide()
+	}
+	if gw.gz != nil {
+		gw.gz.Flush()
+	}
+	if f, ok := gw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// decide picks compressed or plain output based on what has been buffered so far,
+// then writes the status line and the buffered body
+func (gw *gzipResponseWriter) decide() error {
+	gw.decided = true
+
+	header := gw.Header()
+	contentType := header.Get("Content-Type")
+	if contentType == "" && len(gw.buf) > 0 {
+		contentType = http.DetectContentType(gw.buf)
+		header.Set("Content-Type", contentType)
+	}
+
+	compress := len(gw.buf) >= compressionThreshold &&
+		header.Get("Content-Encoding") == "" &&
+		gw.statusCode != http.StatusNoContent &&
+		gw.statusCode != http.StatusNotModified &&
+		gw.statusCode != http.StatusPartialContent &&
+		isCompressible(contentType)
+
+	if compress {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		gw.ResponseWriter.WriteHeader(gw.statusCode)
+		gw.gz = gzip.NewWriter(gw.ResponseWriter)
+		_, err := gw.gz.Write(gw.buf)
+		gw.buf = nil
+		return err
+	}
+
+	gw.ResponseWriter.WriteHeader(gw.statusCode)
+	if len(gw.buf) == 0 {
+		return nil
+	}
+	_, err := gw.ResponseWriter.Write(gw.buf)
+	gw.buf = nil
+	return err
+}
+
+// close flushes any remaining buffered data and finalizes the gzip stream
+func (gw *gzipResponseWriter) close() {
+	if !gw.decided {
+		gw.decide()
+	}
+	if gw.gz != nil {
+		gw.gz.Close()
+	}
+}
+
+// CompressionMiddleware gzips text, JSON, and XML responses above a size threshold
+// when the client advertises gzip support. Already-compressed content (images,
+// archives) is passed through untouched
+func CompressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsGzip(r) || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		defer gw.close()
+
+		next.ServeHTTP(gw, r)
+	})
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.SplitN(enc, ";", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) == 2 && strings.ReplaceAll(strings.TrimSpace(parts[1]), " ", "") == "q=0" {
+			continue
+		}
+		if strings.EqualFold(name, "gzip") || name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func isCompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
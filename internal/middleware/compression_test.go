@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveCompressed runs body through CompressionMiddleware with the given Content-Type
+func serveCompressed(t *testing.T, acceptEncoding string, contentType string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	handler := CompressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(body)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/contacts", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestCompressionMiddlewareGzipsLargeJSON(t *testing.T) {
+	contacts := make([]map[string]string, 200)
+	for i := range contacts {
+		contacts[i] = map[string]string{"full_name": "Test Contact", "email": "test@example.com"}
+	}
+	body, err := json.Marshal(contacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := serveCompressed(t, "gzip, deflate, br", "application/json", body)
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+		t.Errorf("Vary = %q, want it to include Accept-Encoding", w.Header().Get("Vary"))
+	}
+	if w.Body.Len() >= len(body) {
+		t.Errorf("compressed body is %d bytes, not smaller than the %d byte original", w.Body.Len(), len(body))
+	}
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	got, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("reading gzip body: %v", err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Error("decompressed body does not match the original")
+	}
+}
+
+func TestCompressionMiddlewarePassesThrough(t *testing.T) {
+	large := bytes.Repeat([]byte("a"), 4*compressionThreshold)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           []byte
+	}{
+		{"not requested", "", "application/json", large},
+		{"refused", "gzip;q=0", "application/json", large},
+		{"below threshold", "gzip", "application/json", []byte(`{"ok":true}`)},
+		{"image", "gzip", "image/png", large},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveCompressed(t, tt.acceptEncoding, tt.contentType, tt.body)
+			if got := w.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			if !bytes.Equal(w.Body.Bytes(), tt.body) {
+				t.Error("body was modified")
+			}
+		})
+	}
+}
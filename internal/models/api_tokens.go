@@ -11,6 +11,12 @@ package models
 
 import "time"
 
+// API token scopes. A read token may only make GET requests; write tokens have full access
+const (
+	APITokenScopeRead  = "read"
+	APITokenScopeWrite = "write"
+)
+
 // APIToken represents an API token for programmatic access
 type APIToken struct {
 	ID         int        `json:"id"`
@@ -21,6 +27,7 @@ type APIToken struct {
 	CreatedAt  time.Time  `json:"created_at"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
 	IsActive   bool       `json:"is_active"`
+	Scope      string     `json:"scope"`
 }
 
 // APITokenWithRaw is used only during token creation to return the raw token once
@@ -33,18 +40,22 @@ type APITokenWithRaw struct {
 type CreateAPITokenRequest struct {
 	Name      string     `json:"name" binding:"required,min=1,max=255"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Scope     string     `json:"scope,omitempty" enums:"read,write" example:"write"` // Defaults to write
 }
 
 // APITokenListResponse represents a token in list view (safe to show)
 type APITokenListResponse struct {
-	ID         int        `json:"id"`
-	Name       string     `json:"name"`
-	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
-	CreatedAt  time.Time  `json:"created_at"`
-	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
-	IsActive   bool       `json:"is_active"`
-	IsExpired  bool       `json:"is_expired"`
-	Prefix     string     `json:"prefix"` // First 8 chars for identification
+	ID                int        `json:"id"`
+	Name              string     `json:"name"`
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP        string     `json:"last_used_ip,omitempty"`
+	LastUsedUserAgent string     `json:"last_used_user_agent,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	IsActive          bool       `json:"is_active"`
+	IsExpired         bool       `json:"is_expired"`
+	Prefix            string     `json:"prefix"` // First 8 chars for identification
+	Scope             string     `json:"scope"`
 }
 
 type TokenTestResponse struct {
@@ -14,11 +14,36 @@ import (
 	"time"
 )
 
+// Contact kinds, as in the vCard KIND property
+const (
+	ContactKindIndividual = "individual"
+	ContactKindOrg        = "org"
+	ContactKindGroup      = "group"
+)
+
+// ContactKinds lists every valid Contact.Kind
+var ContactKinds = []string{ContactKindIndividual, ContactKindOrg, ContactKindGroup}
+
+// NormalizeContactKind maps a KIND value onto a Contact.Kind, case-insensitively. An empty value
+// is individual (the vCard default). Returns false for other kinds, such as location
+func NormalizeContactKind(s string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", ContactKindIndividual:
+		return ContactKindIndividual, true
+	case ContactKindOrg, "organization":
+		return ContactKindOrg, true
+	case ContactKindGroup:
+		return ContactKindGroup, true
+	}
+	return "", false
+}
+
 // Contact represents a person in the CRM
 type Contact struct {
 	ID                     int                 `json:"id" example:"1"`
 	UID                    string              `json:"uid" example:"42"`
 	FullName               string              `json:"full_name" example:"Dr. John Frank Doe III"` // Computed from name parts
+	DisplayName            string              `json:"display_name,omitempty" example:"Johnny"`    // Nickname or full name per the user's preference; not stored
 	GivenName              string              `json:"given_name" example:"John"`
 	FamilyName             string              `json:"family_name" example:"Doe"`
 	MiddleName             string              `json:"middle_name" example:"Frank"`
@@ -26,22 +51,36 @@ type Contact struct {
 	Suffix                 string              `json:"suffix" example:"III"`
 	Nickname               string              `json:"nickname" example:"broheim"`
 	MaidenName             string              `json:"maiden_name" example:"Parks"`
+	Salutation             string              `json:"salutation" example:"Dr. Doe"`        // How to address the contact in notifications
+	IsOrganization         bool                `json:"is_organization" example:"false"`     // Company card; displayed by organization name
+	Kind                   string              `json:"kind" example:"individual"`           // vCard KIND: individual, org, or group
+	ExcludeFromEvents      bool                `json:"exclude_from_events" example:"false"` // Omit from upcoming events and reminders
+	Archived               bool                `json:"archived" example:"false"`            // Hidden from default lists, search, events, and CardDAV
 	PhoneticFirstName      string              `json:"phonetic_first_name" example:"Par-cor"`
 	PronunciationFirstName string              `json:"pronunciation_first_name" example:"Par-cor"`
 	PhoneticLastName       string              `json:"phonetic_last_name" example:"Par-cor"`
 	PronunciationLastName  string              `json:"pronunciation_last_name" example:"Par-cor"`
 	PhoneticMiddleName     string              `json:"phonetic_middle_name" example:"Par-cor"`
-	Gender                 string              `json:"gender,omitempty" example:"M"` // M, F, O, N, U
+	Gender                 string              `json:"gender,omitempty" example:"M"`                     // M, F, O, N, U
+	Latitude               *float64            `json:"latitude,omitempty" example:"37.386"`              // vCard GEO; set together with Longitude
+	Longitude              *float64            `json:"longitude,omitempty" example:"-122.084"`           // vCard GEO; set together with Latitude
+	Timezone               string              `json:"timezone,omitempty" example:"America/Los_Angeles"` // vCard TZ: IANA zone or UTC offset
+	Languages              []string            `json:"languages,omitempty" example:"en,fr"`              // vCard LANG, most preferred first
 	Birthday               *time.Time          `json:"birthday,omitempty" example:"1990-12-15T00:00:00Z"`
-	BirthdayMonth          *int                `json:"birthday_month,omitempty" example:"12"` // 1-12, for partial dates
-	BirthdayDay            *int                `json:"birthday_day,omitempty" example:"15"`   // 1-31, for partial dates
+	BirthdayMonth          *int                `json:"birthday_month,omitempty" example:"12"`  // 1-12, for partial dates
+	BirthdayDay            *int                `json:"birthday_day,omitempty" example:"15"`    // 1-31, for partial dates
+	BirthdayYear           *int                `json:"birthday_year,omitempty" example:"1990"` // for year-only or year+month dates
 	Anniversary            *time.Time          `json:"anniversary,omitempty" example:"2022-01-03T00:00:00Z"`
-	AnniversaryMonth       *int                `json:"anniversary_month,omitempty" example:"1"` // 1-12, for partial dates
-	AnniversaryDay         *int                `json:"anniversary_day,omitempty" example:"3"`   // 1-31, for partial dates
+	AnniversaryMonth       *int                `json:"anniversary_month,omitempty" example:"1"`   // 1-12, for partial dates
+	AnniversaryDay         *int                `json:"anniversary_day,omitempty" example:"3"`     // 1-31, for partial dates
+	AnniversaryYear        *int                `json:"anniversary_year,omitempty" example:"2022"` // for year-only or year+month dates
 	Notes                  string              `json:"notes" example:"Met at work conference 2023"`
 	AvatarBase64           string              `json:"avatar_base64,omitempty"`
 	AvatarMimeType         string              `json:"avatar_mime_type,omitempty"`
 	ExcludeFromSync        bool                `json:"exclude_from_sync"`
+	ReminderLeadDays       *int                `json:"reminder_lead_days,omitempty" example:"14"` // per-contact notifier look-ahead override
+	LastContactedAt        *time.Time          `json:"last_contacted_at,omitempty"`
+	ContactFrequencyDays   *int                `json:"contact_frequency_days,omitempty" example:"30"` // days between reconnect reminders
 	CreatedAt              time.Time           `json:"created_at"`
 	UpdatedAt              time.Time           `json:"updated_at"`
 	ETag                   string              `json:"etag"`
@@ -54,12 +93,48 @@ type Contact struct {
 	Organizations          []Organization      `json:"organizations,omitempty"`
 	OtherDates             []OtherDate         `json:"other_dates"`
 	URLs                   []URL               `json:"urls,omitempty"`
+	IMPPs                  []IMPP              `json:"impps,omitempty"`
 	Relationships          []Relationship      `json:"relationships,omitempty"`
 	OtherRelationships     []OtherRelationship `json:"other_relationships,omitempty"`
-	DeletedAt              *time.Time
+	Tags                   []string            `json:"tags,omitempty"` // Round-trips as vCard CATEGORIES
+	DeletedAt              *time.Time          `json:"deleted_at,omitempty"`
+	RawVCardExtras         string              `json:"-"` // JSON of vCard properties we don't model; empty keeps the stored ones on update
 	Metadata               string
 }
 
+// ContactPage is one page of a paginated contact listing
+type ContactPage struct {
+	Contacts []*Contact `json:"contacts"`
+	Total    int        `json:"total" example:"1234"`
+	Limit    int        `json:"limit" example:"50"`
+	Offset   int        `json:"offset" example:"0"`
+}
+
+// ContactFilter narrows a contact listing; zero values don't filter
+type ContactFilter struct {
+	Tag              string // only contacts with this tag (case-insensitive)
+	HasRelationship  *bool  // only contacts with (true) or without (false) any relationship
+	RelationshipType string // only contacts with a relationship of this type, as shown on their own record
+	IncludeArchived  bool   // also list archived contacts
+}
+
+// ContactSearchOptions tune SearchContacts; zero values search every field, skip archived
+// contacts, and only fall back to fuzzy matching when nothing else matches
+type ContactSearchOptions struct {
+	Fields          []string // limit to these fields: name, email, phone, address, notes
+	IncludeArchived bool     // also search archived contacts
+	Fuzzy           bool     // match names by similarity even when a substring search would match
+}
+
+// Tombstone is a soft-deleted contact as CardDAV clients see it during sync
+type Tombstone struct {
+	ID           int       `json:"id" example:"123"`
+	UID          string    `json:"uid"`
+	FullName     string    `json:"full_name" example:"John Doe"`
+	VersionToken int       `json:"version_token" example:"42"`
+	DeletedAt    time.Time `json:"deleted_at"`
+}
+
 // GenerateFullName computes the full name from name components
 func (c *Contact) GenerateFullName() string {
 	parts := []string{}
@@ -97,11 +172,28 @@ func (c *Contact) GenerateFullName() string {
 	return fullName.String()
 }
 
+// SetDisplayName fills DisplayName with the nickname when preferNickname is set and the contact has
+// one, otherwise with the full name
+func (c *Contact) SetDisplayName(preferNickname bool) {
+	if preferNickname && c.Nickname != "" {
+		c.DisplayName = c.Nickname
+		return
+	}
+	c.DisplayName = c.FullName
+}
+
+// SetDisplayNames calls SetDisplayName on each contact
+func SetDisplayNames(contacts []*Contact, preferNickname bool) {
+	for _, c := range contacts {
+		c.SetDisplayName(preferNickname)
+	}
+}
+
 // HasAnniversary returns true if either the full date or the partial components are set
 func (c *Contact) HasAnniversary() bool {
 	hasFullDate := c.Anniversary != nil
 	hasPartial := c.AnniversaryMonth != nil && c.AnniversaryDay != nil
-	return hasFullDate || hasPartial
+	return hasFullDate || hasPartial || c.AnniversaryYear != nil
 }
 
 // HasAvatar returns true if both an avatar and mimetype are set
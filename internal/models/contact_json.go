@@ -25,12 +25,19 @@ type ContactJSON struct {
 	Suffix                 string              `json:"suffix"`
 	Nickname               string              `json:"nickname"`
 	MaidenName             string              `json:"maiden_name"`
+	Salutation             string              `json:"salutation"`
+	IsOrganization         bool                `json:"is_organization"`
+	Kind                   string              `json:"kind,omitempty"`
 	PhoneticFirstName      string              `json:"phonetic_first_name"`
 	PronunciationFirstName string              `json:"pronunciation_first_name"`
 	PhoneticLastName       string              `json:"phonetic_last_name"`
 	PronunciationLastName  string              `json:"pronunciation_last_name"`
 	PhoneticMiddleName     string              `json:"phonetic_middle_name"`
 	Gender                 string              `json:"gender,omitempty"`
+	Latitude               *float64            `json:"latitude,omitempty"`
+	Longitude              *float64            `json:"longitude,omitempty"`
+	Timezone               string              `json:"timezone,omitempty"`
+	Languages              []string            `json:"languages,omitempty"`
 	Birthday               string              `json:"birthday,omitempty"` // String for flexible parsing
 	BirthdayMonth          *int                `json:"birthday_month,omitempty"`
 	BirthdayDay            *int                `json:"birthday_day,omitempty"`
@@ -41,6 +48,7 @@ type ContactJSON struct {
 	AvatarBase64           string              `json:"avatar_base64,omitempty"`
 	AvatarMimeType         string              `json:"avatar_mime_type,omitempty"`
 	ExcludeFromSync        bool                `json:"exclude_from_sync"`
+	ExcludeFromEvents      bool                `json:"exclude_from_events"`
 	CreatedAt              time.Time           `json:"created_at"`
 	UpdatedAt              time.Time           `json:"updated_at"`
 	ETag                   string              `json:"etag"`
@@ -52,6 +60,7 @@ type ContactJSON struct {
 	Relationships          []Relationship      `json:"relationships,omitempty"`
 	OtherRelationships     []OtherRelationship `json:"other_relationships,omitempty"`
 	OtherDates             []OtherDateJSON     `json:"other_dates,omitempty"`
+	Tags                   []string            `json:"tags,omitempty"`
 }
 
 // OtherDateJSON is used for JSON marshaling/unmarshaling of other dates
@@ -77,6 +86,9 @@ type ContactJSONPatch struct {
 	Suffix                 *string `json:"suffix" example:"Jr."`
 	Nickname               *string `json:"nickname" example:"Johnny"`
 	MaidenName             *string `json:"maiden_name" example:"Parks"`
+	Salutation             *string `json:"salutation" example:"Dr. Doe"`
+	IsOrganization         *bool   `json:"is_organization" example:"false"`
+	Kind                   *string `json:"kind,omitempty" example:"org" enums:"individual,org,group"`
 	PhoneticFirstName      *string `json:"phonetic_first_name" example:"Par-cor"`
 	PronunciationFirstName *string `json:"pronunciation_first_name" example:"Par-cor"`
 	PhoneticLastName       *string `json:"phonetic_last_name" example:"Par-cor"`
@@ -87,6 +99,23 @@ type ContactJSONPatch struct {
 	AvatarBase64           *string `json:"avatar_base64,omitempty"`
 	AvatarMimeType         *string `json:"avatar_mime_type,omitempty"`
 	ExcludeFromSync        *bool   `json:"exclude_from_sync" example:"false"`
+	ExcludeFromEvents      *bool   `json:"exclude_from_events" example:"false"`
+	ReminderLeadDays       *int    `json:"reminder_lead_days" example:"14"`
+	ContactFrequencyDays   *int    `json:"contact_frequency_days" example:"30"`
+
+	// Latitude and longitude are only written as a pair
+	Latitude  *float64  `json:"latitude,omitempty" example:"37.386"`
+	Longitude *float64  `json:"longitude,omitempty" example:"-122.084"`
+	Timezone  *string   `json:"timezone,omitempty" example:"America/Los_Angeles"`
+	Languages *[]string `json:"languages,omitempty" example:"en,fr"`
+
+	// Dates: a full date ("2006-01-02") or month+day. Setting one form clears the other
+	Birthday         *string `json:"birthday,omitempty" example:"1985-04-30"`
+	BirthdayMonth    *int    `json:"birthday_month,omitempty" example:"4"`
+	BirthdayDay      *int    `json:"birthday_day,omitempty" example:"30"`
+	Anniversary      *string `json:"anniversary,omitempty" example:"2010-06-12"`
+	AnniversaryMonth *int    `json:"anniversary_month,omitempty" example:"6"`
+	AnniversaryDay   *int    `json:"anniversary_day,omitempty" example:"12"`
 }
 
 type ContactDateJSONPatch struct {
@@ -96,6 +125,7 @@ type ContactDateJSONPatch struct {
 	Date      *time.Time `json:"date" example:"2026-04-30"`
 	DateMonth *int       `json:"date_month" example:"4"`
 	DateDay   *int       `json:"date_day" example:"30"`
+	DateYear  *int       `json:"date_year" example:"1985"` // birthday/anniversary only: year-only or year+month
 }
 
 // OtherDateJSON is used for JSON marshaling/unmarshaling of other dates
@@ -119,12 +149,19 @@ func (cj *ContactJSON) ToContact() (*Contact, error) {
 		Suffix:                 cj.Suffix,
 		Nickname:               cj.Nickname,
 		MaidenName:             cj.MaidenName,
+		Salutation:             cj.Salutation,
+		IsOrganization:         cj.IsOrganization,
+		Kind:                   cj.Kind,
 		PhoneticFirstName:      cj.PhoneticFirstName,
 		PronunciationFirstName: cj.PronunciationFirstName,
 		PhoneticLastName:       cj.PhoneticLastName,
 		PronunciationLastName:  cj.PronunciationLastName,
 		PhoneticMiddleName:     cj.PhoneticMiddleName,
 		Gender:                 cj.Gender,
+		Latitude:               cj.Latitude,
+		Longitude:              cj.Longitude,
+		Timezone:               cj.Timezone,
+		Languages:              cj.Languages,
 		BirthdayMonth:          cj.BirthdayMonth,
 		BirthdayDay:            cj.BirthdayDay,
 		AnniversaryMonth:       cj.AnniversaryMonth,
@@ -133,6 +170,7 @@ func (cj *ContactJSON) ToContact() (*Contact, error) {
 		AvatarBase64:           cj.AvatarBase64,
 		AvatarMimeType:         cj.AvatarMimeType,
 		ExcludeFromSync:        cj.ExcludeFromSync,
+		ExcludeFromEvents:      cj.ExcludeFromEvents,
 		CreatedAt:              cj.CreatedAt,
 		UpdatedAt:              cj.UpdatedAt,
 		ETag:                   cj.ETag,
@@ -143,6 +181,7 @@ func (cj *ContactJSON) ToContact() (*Contact, error) {
 		URLs:                   cj.URLs,
 		Relationships:          cj.Relationships,
 		OtherRelationships:     cj.OtherRelationships,
+		Tags:                   cj.Tags,
 	}
 
 	// Parse birthday string if provided
@@ -200,12 +239,19 @@ func FromContact(contact *Contact) *ContactJSON {
 		Suffix:                 contact.Suffix,
 		Nickname:               contact.Nickname,
 		MaidenName:             contact.MaidenName,
+		Salutation:             contact.Salutation,
+		IsOrganization:         contact.IsOrganization,
+		Kind:                   contact.Kind,
 		PhoneticFirstName:      contact.PhoneticFirstName,
 		PronunciationFirstName: contact.PronunciationFirstName,
 		PhoneticLastName:       contact.PhoneticLastName,
 		PronunciationLastName:  contact.PronunciationLastName,
 		PhoneticMiddleName:     contact.PhoneticMiddleName,
 		Gender:                 contact.Gender,
+		Latitude:               contact.Latitude,
+		Longitude:              contact.Longitude,
+		Timezone:               contact.Timezone,
+		Languages:              contact.Languages,
 		BirthdayMonth:          contact.BirthdayMonth,
 		BirthdayDay:            contact.BirthdayDay,
 		AnniversaryMonth:       contact.AnniversaryMonth,
@@ -214,6 +260,7 @@ func FromContact(contact *Contact) *ContactJSON {
 		AvatarBase64:           contact.AvatarBase64,
 		AvatarMimeType:         contact.AvatarMimeType,
 		ExcludeFromSync:        contact.ExcludeFromSync,
+		ExcludeFromEvents:      contact.ExcludeFromEvents,
 		CreatedAt:              contact.CreatedAt,
 		UpdatedAt:              contact.UpdatedAt,
 		ETag:                   contact.ETag,
@@ -224,6 +271,7 @@ func FromContact(contact *Contact) *ContactJSON {
 		URLs:                   contact.URLs,
 		Relationships:          contact.Relationships,
 		OtherRelationships:     contact.OtherRelationships,
+		Tags:                   contact.Tags,
 	}
 
 	// Format birthday as string if provided
@@ -268,6 +316,9 @@ func (p *ContactJSONPatch) HasUpdates() bool {
 		p.Suffix != nil ||
 		p.Nickname != nil ||
 		p.MaidenName != nil ||
+		p.Salutation != nil ||
+		p.IsOrganization != nil ||
+		p.Kind != nil ||
 		p.PhoneticFirstName != nil ||
 		p.PronunciationFirstName != nil ||
 		p.PhoneticLastName != nil ||
@@ -277,5 +328,17 @@ func (p *ContactJSONPatch) HasUpdates() bool {
 		p.Notes != nil ||
 		p.AvatarBase64 != nil ||
 		p.AvatarMimeType != nil ||
-		p.ExcludeFromSync != nil
+		p.ExcludeFromSync != nil ||
+		p.ExcludeFromEvents != nil ||
+		p.ReminderLeadDays != nil ||
+		p.ContactFrequencyDays != nil ||
+		(p.Latitude != nil && p.Longitude != nil) ||
+		p.Timezone != nil ||
+		p.Languages != nil ||
+		p.Birthday != nil ||
+		p.BirthdayMonth != nil ||
+		p.BirthdayDay != nil ||
+		p.Anniversary != nil ||
+		p.AnniversaryMonth != nil ||
+		p.AnniversaryDay != nil
 }
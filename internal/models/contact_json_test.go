@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestContactJSONPatchHasUpdatesForDates(t *testing.T) {
+	month, day := 4, 30
+	date := "1990-04-30"
+
+	if (&ContactJSONPatch{}).HasUpdates() {
+		t.Error("empty patch reports updates")
+	}
+	for name, patch := range map[string]*ContactJSONPatch{
+		"birthday":          {Birthday: &date},
+		"birthday_month":    {BirthdayMonth: &month},
+		"birthday_day":      {BirthdayDay: &day},
+		"anniversary":       {Anniversary: &date},
+		"anniversary_month": {AnniversaryMonth: &month},
+		"anniversary_day":   {AnniversaryDay: &day},
+	} {
+		if !patch.HasUpdates() {
+			t.Errorf("patch with only %s reports no updates", name)
+		}
+	}
+}
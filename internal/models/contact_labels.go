@@ -9,7 +9,11 @@ type ContactLabelType struct {
 	UsageCount int    `json:"usage_count"`
 }
 
+// ContactLabelCategories lists the categories custom labels can be created in
+var ContactLabelCategories = []string{"phone", "email", "address", "url"}
+
+// ContactLabelJSONPost is the request body for creating a custom label
 type ContactLabelJSONPost struct {
-	Name     string `json:"name"`
-	Category string `json:"category"`
+	Name     string `json:"name" example:"vacation home"`
+	Category string `json:"category" example:"address" enums:"phone,email,address,url"`
 }
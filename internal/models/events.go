@@ -14,17 +14,46 @@ import (
 	"time"
 )
 
+// EventTypeReconnect marks a contact whose contact_frequency_days has passed since they were last contacted
+const EventTypeReconnect = "reconnect"
+
 // UpcomingEvent represents an event (birthday, anniversary, or other date) with timing information
 type UpcomingEvent struct {
 	ContactID       int        `json:"contact_id"`
 	FullName        string     `json:"full_name"`
 	EventType       string     `json:"event_type"`  // "birthday", "anniversary", something else
 	EventLabel      string     `json:"event_label"` // custom label
-	EventDate       *time.Time `json:"event_date"`  // The actual date of the event
+	EventDate       *time.Time `json:"event_date"`  // The actual date of the event (last contact, for reconnects)
 	ThisYearDate    time.Time  `json:"this_year_date"`
-	DaysUntil       int        `json:"days_until"`       // Negative = past, 0 = today, positive = future
-	AgeOrYears      *int       `json:"age_or_years"`     // Age for birthdays, years for anniversaries (nullable)
-	TimeDescription string     `json:"time_description"` // "Yesterday", "Today", "Tomorrow", "3 days ago", "in 5 days"
+	DaysUntil       int        `json:"days_until"`           // Negative = past, 0 = today, positive = future
+	AgeOrYears      *int       `json:"age_or_years"`         // Age turned (birthdays) or years marked (anniversaries) on the event date (nullable)
+	TimeDescription string     `json:"time_description"`     // "Yesterday", "Today", "Tomorrow", "3 days ago", "in 5 days"
+	AddressAs       string     `json:"address_as,omitempty"` // Salutation or given name, filled in for notifications
+}
+
+// DisplayName is the name notifications use: AddressAs when set, otherwise the full name
+func (e UpcomingEvent) DisplayName() string {
+	if e.AddressAs != "" {
+		return e.AddressAs
+	}
+	return e.FullName
+}
+
+// ContactDate is a yearly recurring date of a contact, as used by calendar feeds
+type ContactDate struct {
+	ContactID  int    `json:"contact_id"`
+	ContactUID string `json:"contact_uid"`
+	FullName   string `json:"full_name"`
+	EventType  string `json:"event_type"` // "birthday", "anniversary", or an other_dates event name
+	Year       *int   `json:"year"`       // nil for partial (month/day only) dates
+	Month      int    `json:"month"`
+	Day        int    `json:"day"`
+}
+
+// EventTypeCount is a distinct event type in use along with how many dates use it
+type EventTypeCount struct {
+	EventType string `json:"event_type" example:"birthday"` // "birthday", "anniversary", or an other_dates event name
+	Count     int    `json:"count" example:"42"`
 }
 
 // MonthlyEventGroup represents events grouped by month
@@ -106,6 +135,8 @@ func (e *UpcomingEvent) GetFormattedEventType() string {
 		return "🎂 Birthday"
 	case "anniversary":
 		return "💍 Anniversary"
+	case EventTypeReconnect:
+		return "👋 Reconnect"
 	case "other":
 		if e.EventLabel != "" {
 			return "📅 " + e.EventLabel
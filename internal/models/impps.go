@@ -0,0 +1,10 @@
+package models
+
+// IMPP represents an instant messaging handle (Signal, Matrix, WhatsApp, Telegram, ...)
+type IMPP struct {
+	ID        int    `json:"id"`
+	ContactID int    `json:"contact_id"`
+	Service   string `json:"service" example:"Signal"`
+	Handle    string `json:"handle" example:"+15551234567"`
+	IsPrimary bool   `json:"is_primary"`
+}
@@ -7,6 +7,7 @@ type Organization struct {
 	Name         string `json:"name"`
 	PhoneticName string `json:"phonetic_name"`
 	Title        string `json:"title"`
+	Role         string `json:"role"`
 	Department   string `json:"department"`
 	IsPrimary    bool   `json:"is_primary"` //not yet used
 }
@@ -17,5 +18,6 @@ type OrganizationJSONPatch struct {
 	Name         *string `json:"name"`
 	PhoneticName *string `json:"phonetic_name"`
 	Title        *string `json:"title"`
+	Role         *string `json:"role"`
 	Department   *string `json:"department"`
 }
@@ -29,6 +29,7 @@ type Relationship struct {
 	RelatedContactID int               `json:"related_contact_id"`
 	RelatedContact   *Contact          `json:"related_contact,omitempty"`
 	RelationshipType *RelationshipType `json:"relationship_type"`
+	IsReverse        bool              `json:"is_reverse"` // Computed from the related contact's stored row rather than stored for this contact
 	CreatedAt        time.Time         `json:"created_at"`
 }
 
@@ -44,12 +45,41 @@ type OtherRelationship struct {
 
 // Suggestion defines the proposed action for the UI
 type RelationshipSuggestion struct {
-	Type               string // "Relationship"
-	TargetID           int    // The ID of the person we are ADDING the link to
-	TargetName         string
-	ProposedID         int // The ID of the person they are related to
-	SourceName         string
-	RelationshipTypeID int    // The ID for "Brother", "Father", etc.
-	ProposedVal        string // The Label (e.g., "Brother")
-	Reason             string // Your logic description
+	Type               string `json:"type"`      // "Relationship"
+	TargetID           int    `json:"target_id"` // The ID of the person we are ADDING the link to
+	TargetName         string `json:"target_name"`
+	ProposedID         int    `json:"proposed_id"` // The ID of the person they are related to
+	SourceName         string `json:"source_name"`
+	RelationshipTypeID int    `json:"relationship_type_id,omitempty"` // The ID for "Brother", "Father", etc.
+	ProposedVal        string `json:"proposed_value"`                 // The Label (e.g., "Brother")
+	Reason             string `json:"reason"`                         // Your logic description
+}
+
+// RelationshipSuggestionList is a bounded list of relationship suggestions. Truncated is set when
+// the search stopped at its configured limits, so more suggestions may exist
+type RelationshipSuggestionList struct {
+	Suggestions []RelationshipSuggestion `json:"suggestions"`
+	Truncated   bool                     `json:"truncated"`
+}
+
+// RelationshipSuggestionAccept identifies a relationship suggestion to apply
+type RelationshipSuggestionAccept struct {
+	ProposedID         int `json:"proposed_id" example:"4"`
+	TargetID           int `json:"target_id" example:"7"`
+	RelationshipTypeID int `json:"relationship_type_id" example:"7"`
+}
+
+// AnniversarySuggestionAccept identifies an anniversary suggestion to apply: copy the source
+// contact's anniversary onto the target
+type AnniversarySuggestionAccept struct {
+	TargetID int `json:"target_id" example:"7"`
+	SourceID int `json:"source_id" example:"4"`
+}
+
+// HouseholdMember is a contact grouped into another contact's household
+type HouseholdMember struct {
+	ContactID    int    `json:"contact_id"`
+	FullName     string `json:"full_name"`
+	Relationship string `json:"relationship,omitempty"` // Relationship to the requested contact when directly related
+	Generation   int    `json:"generation"`             // Relative to the requested contact: -1 parents, 0 same, 1 children
 }
@@ -9,16 +9,21 @@
 
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // NotificationSettings represents user notification preferences
 type NotificationSetting struct {
 	ID                   int        `json:"id"`
 	Name                 string     `json:"name"`
 	UserID               int        `json:"user_id"`
-	ProviderType         string     `json:"provider_type"`  // 'discord' or 'smtp'
-	WebhookURL           *string    `json:"webhook_url"`    // if using 'discord' -> the URL
-	TargetAddress        *string    `json:"target_address"` // if using 'smtp' -> the TO:
+	ProviderType         string     `json:"provider_type"`  // one of NotificationProviders
+	WebhookURL           *string    `json:"webhook_url"`    // 'discord' -> the URL; 'telegram' -> the bot token; 'ntfy' -> the topic URL
+	TargetAddress        *string    `json:"target_address"` // 'smtp' -> the TO:; 'telegram' -> the chat ID
 	DaysLookAhead        int        `json:"days_look_ahead"`
 	NotificationTime     string     `json:"notification_time"` // HH:MM format
 	IncludeBirthdays     bool       `json:"include_birthdays"`
@@ -31,6 +36,63 @@ type NotificationSetting struct {
 	CreatedAt            time.Time  `json:"created_at"`
 }
 
+// NotificationProviders lists the supported NotificationSetting.ProviderType values
+var NotificationProviders = []string{"discord", "smtp", "email", "telegram", "ntfy"}
+
+// ValidateProvider checks the provider type is supported and has the fields it needs
+func (s *NotificationSetting) ValidateProvider() error {
+	hasWebhook := s.WebhookURL != nil && *s.WebhookURL != ""
+	hasTarget := s.TargetAddress != nil && *s.TargetAddress != ""
+
+	switch s.ProviderType {
+	case "discord":
+		if !hasWebhook {
+			return errors.New("discord notifications require a webhook_url")
+		}
+	case "smtp", "email":
+		if !hasTarget {
+			return errors.New("email notifications require a target_address")
+		}
+	case "telegram":
+		if !hasWebhook || !hasTarget {
+			return errors.New("telegram notifications require a bot token (webhook_url) and chat ID (target_address)")
+		}
+	case "ntfy":
+		if !hasWebhook {
+			return errors.New("ntfy notifications require a topic URL (webhook_url)")
+		}
+	default:
+		return fmt.Errorf("unknown provider_type %q; expected one of: %s", s.ProviderType, strings.Join(NotificationProviders, ", "))
+	}
+
+	return nil
+}
+
+// MaskedSecret replaces secrets in exported notification settings
+const MaskedSecret = "********"
+
+// NotificationSettingsExport is the backup document produced by the notification settings export
+// and accepted by the import
+type NotificationSettingsExport struct {
+	ExportedAt      time.Time             `json:"exported_at"`
+	SecretsIncluded bool                  `json:"secrets_included"`
+	Settings        []NotificationSetting `json:"settings"`
+}
+
+// MaskSecrets replaces the credential-bearing webhook_url (Discord URL, Telegram bot token, ntfy
+// topic URL) with MaskedSecret
+func (s *NotificationSetting) MaskSecrets() {
+	if s.WebhookURL != nil && *s.WebhookURL != "" {
+		masked := MaskedSecret
+		s.WebhookURL = &masked
+	}
+}
+
+// HasMaskedSecret reports whether the setting came from an export without secrets
+func (s *NotificationSetting) HasMaskedSecret() bool {
+	return s.WebhookURL != nil && *s.WebhookURL == MaskedSecret
+}
+
 type ContactStats struct {
 	TotalContacts  int
 	AddedThisMonth int
@@ -44,3 +106,9 @@ type DuplicateGroup struct {
 	Contact2Name string `json:"contact2_name"`
 	MatchType    string `json:"match_type"` // "name" or "email"
 }
+
+// MergeContactsRequest names the contact to keep and the duplicate folded into it
+type MergeContactsRequest struct {
+	PrimaryID   int `json:"primary_id" example:"4"`
+	SecondaryID int `json:"secondary_id" example:"9"`
+}
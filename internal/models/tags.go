@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Tag is a user-defined label for grouping contacts (friends, work, family)
+type Tag struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name" example:"family"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagJSON is the request body for tagging a contact
+type TagJSON struct {
+	Tag string `json:"tag" example:"family"`
+}
@@ -13,12 +13,15 @@ import "time"
 
 // User represents an authenticated user
 type User struct {
-	ID              int       `json:"id"`
-	Email           string    `json:"email"`
-	PasswordHash    string    `json:"-"`
-	IsSetupComplete bool      `json:"is_setup_complete"`
-	Theme           string    `json:"theme"`
-	SyncToken       int       `json:"addressbook_sync_token"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID                    int       `json:"id"`
+	Email                 string    `json:"email"`
+	PasswordHash          string    `json:"-"`
+	IsSetupComplete       bool      `json:"is_setup_complete"`
+	Theme                 string    `json:"theme"`
+	NicknameAsDisplayName bool      `json:"nickname_as_display_name"`     // Show contacts by nickname in lists, search, and CardDAV
+	CardDAVDisplayName    string    `json:"carddav_display_name"`         // Account name shown by CardDAV clients; empty uses Email
+	EventLookaheadDays    int       `json:"default_event_lookahead_days"` // Days ahead counted as upcoming on the dashboard and events page
+	SyncToken             int       `json:"addressbook_sync_token"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
 }
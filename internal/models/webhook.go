@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package models
+
+import "time"
+
+// Webhook events, fired after the change commits
+const (
+	WebhookEventContactCreated = "contact.created"
+	WebhookEventContactUpdated = "contact.updated"
+	WebhookEventContactDeleted = "contact.deleted"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to
+var WebhookEvents = []string{WebhookEventContactCreated, WebhookEventContactUpdated, WebhookEventContactDeleted}
+
+// Webhook is an outbound URL notified of contact changes
+type Webhook struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url" example:"https://example.com/kindredcard"`
+	Secret    string    `json:"secret,omitempty"` // Only returned when the webhook is created
+	Events    []string  `json:"events" example:"contact.created,contact.updated"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateWebhookRequest is the request body for adding a webhook. An empty secret is generated,
+// and no events subscribes to all of them
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" example:"https://example.com/kindredcard"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty" example:"contact.created,contact.updated"`
+}
+
+// WebhookPayload is the JSON body POSTed to a webhook. A deleted contact carries only its id and uid
+type WebhookPayload struct {
+	Event   string   `json:"event" example:"contact.updated"`
+	Contact *Contact `json:"contact"`
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package netguard
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a user-supplied URL resolves to a private, loopback, or
+// otherwise internal address, so it can't be used to reach the server's network
+var ErrBlockedAddress = errors.New("URL resolves to a blocked address")
+
+// carrierGradeNAT (100.64.0.0/10) isn't covered by net.IP.IsPrivate
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// NewClient creates an http.Client for user-supplied URLs whose connections refuse internal
+// addresses. The check runs on the resolved IP of every connection, redirects included, so DNS
+// tricks can't get around it
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || IsBlockedIP(ip) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy would make the dial check see the proxy instead of the target
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// IsBlockedIP reports whether ip is internal: loopback, private, link-local, CGNAT, multicast, or
+// unspecified
+func IsBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || carrierGradeNAT.Contains(ip)
+}
+
+// CheckHost resolves host and returns ErrBlockedAddress if any of its addresses is internal. It
+// catches obvious mistakes up front; NewClient still checks every connection, since DNS can change
+func CheckHost(host string) error {
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if IsBlockedIP(ip) {
+			return ErrBlockedAddress
+		}
+	}
+	return nil
+}
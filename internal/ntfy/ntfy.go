@@ -0,0 +1,146 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package ntfy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+// NtfyMessage is a notification published to an ntfy topic
+type NtfyMessage struct {
+	Title string
+	Body  string
+	Tags  []string
+	Click string // URL opened when the notification is tapped
+}
+
+// SendNtfyNotification publishes a message to an ntfy topic URL (e.g. https://ntfy.sh/my-topic)
+func SendNtfyNotification(topicURL string, message NtfyMessage) error {
+	if topicURL == "" {
+		return fmt.Errorf("topic URL is empty")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, topicURL, strings.NewReader(message.Body))
+	if err != nil {
+		return fmt.Errorf("error building ntfy request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if message.Title != "" {
+		req.Header.Set("Title", message.Title)
+	}
+	if len(message.Tags) > 0 {
+		req.Header.Set("Tags", strings.Join(message.Tags, ","))
+	}
+	if message.Click != "" {
+		req.Header.Set("Click", message.Click)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to ntfy: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// BuildTodayEventsMessage creates an ntfy message for today's events. ntfy bodies are
+// plain text, so contacts are listed by name and the notification opens the dashboard
+func BuildTodayEventsMessage(events []models.UpcomingEvent, baseURL string) NtfyMessage {
+	message := NtfyMessage{
+		Title: fmt.Sprintf("Today's Events - %s", time.Now().Local().Format("Jan 2")),
+		Tags:  []string{"tada"},
+		Click: baseURL,
+	}
+
+	if len(events) == 0 {
+		message.Body = "No birthdays or anniversaries today!"
+		return message
+	}
+
+	var lines []string
+	for _, event := range events {
+		switch event.EventType {
+		case "birthday":
+			if event.AgeOrYears != nil {
+				lines = append(lines, fmt.Sprintf("🎂 %s - %s birthday %s!", event.DisplayName(), utils.Ordinal(*event.AgeOrYears), event.TimeDescription))
+			} else {
+				lines = append(lines, fmt.Sprintf("🎂 %s - has a birthday %s!", event.DisplayName(), event.TimeDescription))
+			}
+		case "anniversary":
+			if event.AgeOrYears != nil {
+				lines = append(lines, fmt.Sprintf("💍 %s - %s wedding anniversary %s!", event.DisplayName(), utils.Ordinal(*event.AgeOrYears), event.TimeDescription))
+			} else {
+				lines = append(lines, fmt.Sprintf("💍 %s - has a wedding anniversary %s!", event.DisplayName(), event.TimeDescription))
+			}
+		default:
+			if event.AgeOrYears != nil {
+				lines = append(lines, fmt.Sprintf("📅 %s - %s anniversary of %s %s!", event.DisplayName(), utils.Ordinal(*event.AgeOrYears), event.EventType, event.TimeDescription))
+			} else {
+				lines = append(lines, fmt.Sprintf("📅 %s - anniversary of %s %s!", event.DisplayName(), event.EventType, event.TimeDescription))
+			}
+		}
+	}
+
+	// A single event opens that contact directly
+	if len(events) == 1 {
+		message.Click = fmt.Sprintf("%s/contacts/%d", baseURL, events[0].ContactID)
+	}
+
+	message.Body = strings.Join(lines, "\n")
+	return message
+}
+
+// SendTestNotification sends a test notification with dummy data
+func SendTestNotification(topicURL string, baseURL string) error {
+	dummyAge1 := 30
+	dummyAge2 := 2
+
+	dummyEvents := []models.UpcomingEvent{
+		{
+			ContactID:       99999,
+			FullName:        "John Doe",
+			EventType:       "birthday",
+			AgeOrYears:      &dummyAge1,
+			TimeDescription: "Today",
+		},
+		{
+			ContactID:       99998,
+			FullName:        "Jane Smith",
+			EventType:       "anniversary",
+			AgeOrYears:      &dummyAge2,
+			TimeDescription: "Tomorrow",
+		},
+		{
+			ContactID:       99997,
+			FullName:        "Jack Jones",
+			EventType:       "Retirement",
+			AgeOrYears:      &dummyAge2,
+			TimeDescription: "in 3 days",
+		},
+	}
+
+	message := BuildTodayEventsMessage(dummyEvents, baseURL)
+	message.Title = "Test: " + message.Title
+	message.Tags = append(message.Tags, "test_tube")
+
+	return SendNtfyNotification(topicURL, message)
+}
@@ -0,0 +1,72 @@
+package ntfy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestSendNtfyNotificationPayload(t *testing.T) {
+	var method, path, body string
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, header = r.Method, r.URL.Path, r.Header
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer srv.Close()
+
+	age := 36
+	years := 10
+	events := []models.UpcomingEvent{
+		{ContactID: 1, FullName: "Alice Liddell", EventType: "birthday", AgeOrYears: &age, TimeDescription: "Today"},
+		{ContactID: 2, FullName: "Bob Builder", EventType: "anniversary", AgeOrYears: &years, TimeDescription: "Tomorrow"},
+	}
+	message := BuildTodayEventsMessage(events, "http://kindred.test")
+	if err := SendNtfyNotification(srv.URL+"/kindred-birthdays", message); err != nil {
+		t.Fatalf("SendNtfyNotification: %v", err)
+	}
+
+	if method != http.MethodPost || path != "/kindred-birthdays" {
+		t.Errorf("request = %s %s, want POST /kindred-birthdays", method, path)
+	}
+	if title := header.Get("Title"); title != message.Title || title == "" {
+		t.Errorf("Title = %q, want %q", title, message.Title)
+	}
+	if tags := header.Get("Tags"); tags != "tada" {
+		t.Errorf("Tags = %q, want tada", tags)
+	}
+	if click := header.Get("Click"); click != "http://kindred.test" {
+		t.Errorf("Click = %q, want the dashboard", click)
+	}
+	want := "🎂 Alice Liddell - 36th birthday Today!\n💍 Bob Builder - 10th wedding anniversary Tomorrow!"
+	if body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestBuildTodayEventsMessageSingleEventClick(t *testing.T) {
+	events := []models.UpcomingEvent{{ContactID: 7, FullName: "Alice Liddell", EventType: "birthday", TimeDescription: "Today"}}
+
+	message := BuildTodayEventsMessage(events, "http://kindred.test")
+	if message.Click != "http://kindred.test/contacts/7" {
+		t.Errorf("Click = %q, want the contact's page", message.Click)
+	}
+}
+
+func TestSendNtfyNotificationErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	if err := SendNtfyNotification(srv.URL+"/topic", NtfyMessage{Body: "hi"}); err == nil {
+		t.Error("SendNtfyNotification ignored a 403 from the server")
+	}
+	if err := SendNtfyNotification("", NtfyMessage{Body: "hi"}); err == nil {
+		t.Error("SendNtfyNotification accepted an empty topic URL")
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultPerMinute is the request budget used when none is configured
+const DefaultPerMinute = 120
+
+// Limiter is an in-memory token bucket per key. Each key may burst up to perMinute requests, and
+// its bucket refills continuously at perMinute per minute
+type Limiter struct {
+	perMinute int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// New creates a Limiter allowing perMinute requests per key each minute
+func New(perMinute int) *Limiter {
+	return &Limiter{
+		perMinute: perMinute,
+		buckets:   make(map[string]*bucket),
+	}
+}
+
+// Allow reports whether a request for key fits its budget, spending one token if it does
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.perMinute), updated: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, l.perMinute)
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Exhausted reports whether the next Allow for key would be denied, without spending a token
+func (l *Limiter) Exhausted(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		return false
+	}
+	b.refill(time.Now(), l.perMinute)
+	return b.tokens < 1
+}
+
+// RetryAfter is how long a denied key waits for its next token
+func (l *Limiter) RetryAfter() time.Duration {
+	return time.Minute / time.Duration(l.perMinute)
+}
+
+func (b *bucket) refill(now time.Time, perMinute int) {
+	elapsed := now.Sub(b.updated)
+	b.tokens = min(float64(perMinute), b.tokens+elapsed.Minutes()*float64(perMinute))
+	b.updated = now
+}
+
+// sweep drops buckets idle long enough to have refilled, at most once a minute, so keys from
+// one-off clients don't pile up
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowDeniesRequestOverBudget(t *testing.T) {
+	const perMinute = 5
+	limiter := New(perMinute)
+
+	for i := 1; i <= perMinute; i++ {
+		if !limiter.Allow("token:abc") {
+			t.Fatalf("request %d of %d was denied", i, perMinute)
+		}
+	}
+	if limiter.Allow("token:abc") {
+		t.Errorf("request %d within a minute was allowed", perMinute+1)
+	}
+
+	// Other keys have their own budget
+	if !limiter.Allow("token:def") {
+		t.Error("a fresh key was denied")
+	}
+}
+
+func TestAllowRefillsOverTime(t *testing.T) {
+	limiter := New(60)
+	for limiter.Allow("ip:192.0.2.1") {
+	}
+
+	// Back-date the bucket by two seconds, enough to earn two tokens back at one a second
+	limiter.buckets["ip:192.0.2.1"].updated = time.Now().Add(-2 * time.Second)
+
+	for i := 1; i <= 2; i++ {
+		if !limiter.Allow("ip:192.0.2.1") {
+			t.Fatalf("refilled request %d was denied", i)
+		}
+	}
+	if limiter.Allow("ip:192.0.2.1") {
+		t.Error("request beyond the refilled tokens was allowed")
+	}
+}
+
+func TestExhaustedDoesNotSpend(t *testing.T) {
+	limiter := New(2)
+
+	if limiter.Exhausted("ip:192.0.2.1") {
+		t.Fatal("a fresh key is exhausted")
+	}
+	for i := 1; i <= 2; i++ {
+		if limiter.Exhausted("ip:192.0.2.1") || !limiter.Allow("ip:192.0.2.1") {
+			t.Fatalf("request %d of 2 was denied", i)
+		}
+	}
+	if !limiter.Exhausted("ip:192.0.2.1") {
+		t.Error("a key over its budget isn't exhausted")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if got := New(120).RetryAfter(); got != 500*time.Millisecond {
+		t.Errorf("RetryAfter = %v, want 500ms", got)
+	}
+}
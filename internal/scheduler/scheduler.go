@@ -18,6 +18,9 @@ import (
 	"github.com/steveredden/KindredCard/internal/logger"
 	"github.com/steveredden/KindredCard/internal/mailer"
 	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/ntfy"
+	"github.com/steveredden/KindredCard/internal/telegram"
+	"github.com/steveredden/KindredCard/internal/utils"
 )
 
 // Scheduler handles scheduled notification checks
@@ -40,6 +43,7 @@ func NewScheduler(db *db.Database, baseURL string) *Scheduler {
 // Start begins the scheduler with 1-minute interval checks
 func (s *Scheduler) Start() {
 	logger.Info("[SCHEDULER] Scheduler started - checking every minute")
+	logger.Info("[SCHEDULER] Deleted contacts are purged after %d days", s.db.TrashRetentionDays)
 
 	// Run immediately on startup
 	s.globalCleanup(true)
@@ -91,8 +95,15 @@ func (s *Scheduler) checkAndSendNotifications() {
 
 	// Process each notifier setting
 	for _, setting := range notifiers {
+		// Normalize the stored time so legacy values like "9:00" still match
+		notificationTime, err := utils.NormalizeTimeOfDay(setting.NotificationTime)
+		if err != nil {
+			logger.Warn("[SCHEDULER] Skipping setting #%d: %v", setting.ID, err)
+			continue
+		}
+
 		// Check if it's time to send this notification
-		if setting.NotificationTime == currentTime {
+		if notificationTime == currentTime {
 			logger.Info("[SCHEDULER] Time match for setting #%d at %s", setting.ID, currentTime)
 			s.processNotificationSetting(setting)
 		}
@@ -109,13 +120,97 @@ func (s *Scheduler) processNotificationSetting(setting models.NotificationSettin
 		return
 	}
 
-	// Get upcoming events for this setting
-	events, err := s.db.GetUpcomingEventsByDays(setting.UserID, setting.DaysLookAhead)
+	relevantEvents, err := MatchEvents(s.db, setting, nil)
 	if err != nil {
 		logger.Error("[SCHEDULER] Error getting upcoming events: %v", err)
 		return
 	}
 
+	if len(relevantEvents) == 0 {
+		logger.Info("[SCHEDULER] No upcoming events found for webhook %s [%d]", setting.Name, setting.ID)
+		return
+	}
+
+	logger.Debug("[SCHEDULER] Found %d upcoming events", len(relevantEvents))
+
+	switch setting.ProviderType {
+	case "discord":
+		if setting.WebhookURL == nil || *setting.WebhookURL == "" {
+			logger.Warn("Discord notification enabled but no WebhookURL provided")
+			return
+		}
+		embed := discord.BuildTodayEventsEmbed(relevantEvents, s.baseURL)
+		discord.SendDiscordNotification(*setting.WebhookURL, []discord.DiscordEmbed{embed})
+	case "smtp", "email":
+		if setting.TargetAddress == nil || *setting.TargetAddress == "" {
+			logger.Warn("SMTP notification enabled but no TargetAddress provided")
+			return
+		}
+		body := mailer.BuildTodayEventsBody(relevantEvents, s.baseURL)
+		if err := mailer.SendEventNotification(*setting.TargetAddress, body); err != nil {
+			// Leave it unrecorded so the next run can retry
+			logger.Error("[SCHEDULER] Error sending email for %s [%d]: %v", setting.Name, setting.ID, err)
+			return
+		}
+	case "telegram":
+		if setting.WebhookURL == nil || *setting.WebhookURL == "" || setting.TargetAddress == nil || *setting.TargetAddress == "" {
+			logger.Warn("Telegram notification enabled but no bot token or chat ID provided")
+			return
+		}
+		text := telegram.BuildTodayEventsMessage(relevantEvents, s.baseURL)
+		if err := telegram.SendTelegramNotification(*setting.WebhookURL, *setting.TargetAddress, text); err != nil {
+			logger.Error("[SCHEDULER] Error sending Telegram message for %s [%d]: %v", setting.Name, setting.ID, err)
+			return
+		}
+	case "ntfy":
+		if setting.WebhookURL == nil || *setting.WebhookURL == "" {
+			logger.Warn("ntfy notification enabled but no topic URL provided")
+			return
+		}
+		message := ntfy.BuildTodayEventsMessage(relevantEvents, s.baseURL)
+		if err := ntfy.SendNtfyNotification(*setting.WebhookURL, message); err != nil {
+			logger.Error("[SCHEDULER] Error sending ntfy message for %s [%d]: %v", setting.Name, setting.ID, err)
+			return
+		}
+	default:
+		logger.Warn("[SCHEDULER] Unknown provider type %q for %s [%d]", setting.ProviderType, setting.Name, setting.ID)
+		return
+	}
+
+	// Record that we sent this notification
+	if err := s.db.RecordNotificationSettingSent(setting); err != nil {
+		logger.Error("[SCHEDULER] Error recording notification: %v", err)
+	}
+}
+
+// MatchEvents selects the events a notification setting sends: the look-ahead window extended by
+// per-contact lead time overrides, the include flags, and the other-event regex. A nil date means
+// today; otherwise the selection is made as if run on that date
+func MatchEvents(database *db.Database, setting models.NotificationSetting, date *time.Time) ([]models.UpcomingEvent, error) {
+	// Per-contact lead time overrides can extend the window past the notifier's default
+	overrides, err := database.GetReminderLeadDayOverrides(setting.UserID)
+	if err != nil {
+		logger.Warn("[SCHEDULER] Error getting reminder lead overrides: %v", err)
+	}
+
+	lookAhead := setting.DaysLookAhead
+	for _, leadDays := range overrides {
+		if leadDays > lookAhead {
+			lookAhead = leadDays
+		}
+	}
+
+	// Get upcoming events for this setting
+	var events []models.UpcomingEvent
+	if date != nil {
+		events, err = database.GetUpcomingEventsByDaysFrom(setting.UserID, lookAhead, *date)
+	} else {
+		events, err = database.GetUpcomingEventsByDays(setting.UserID, lookAhead)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	var re *regexp.Regexp
 	testRegex := false
 
@@ -134,6 +229,13 @@ func (s *Scheduler) processNotificationSetting(setting models.NotificationSettin
 	relevantEvents := []models.UpcomingEvent{}
 	for _, event := range events {
 
+		// Beyond the notifier's window only contacts with a long enough override qualify
+		if event.DaysUntil > setting.DaysLookAhead {
+			if leadDays, ok := overrides[event.ContactID]; !ok || event.DaysUntil > leadDays {
+				continue
+			}
+		}
+
 		include := false
 
 		if setting.IncludeBirthdays && event.EventType == "birthday" {
@@ -162,30 +264,21 @@ func (s *Scheduler) processNotificationSetting(setting models.NotificationSettin
 	}
 
 	if len(relevantEvents) == 0 {
-		logger.Info("[SCHEDULER] No upcoming events found for webhook %s [%d]", setting.Name, setting.ID)
-		return
+		return relevantEvents, nil
 	}
 
-	logger.Debug("[SCHEDULER] Found %d upcoming events", len(relevantEvents))
-
-	switch setting.ProviderType {
-	case "discord":
-		if setting.WebhookURL == nil || *setting.WebhookURL == "" {
-			logger.Warn("Discord notification enabled but no WebhookURL provided")
-		}
-		embed := discord.BuildTodayEventsEmbed(relevantEvents, s.baseURL)
-		discord.SendDiscordNotification(*setting.WebhookURL, []discord.DiscordEmbed{embed})
-	case "smtp":
-		if setting.TargetAddress == nil || *setting.TargetAddress == "" {
-			logger.Warn("SMTP notification enabled but no TargetAddress provided")
-			return
-		}
-		body := mailer.BuildTodayEventsBody(relevantEvents, s.baseURL)
-		mailer.SendEventNotification(*setting.TargetAddress, "KindredCard Event Summary", body.Body)
+	// Address contacts by salutation (or given name) in the notification
+	contactIDs := make([]int, 0, len(relevantEvents))
+	for _, event := range relevantEvents {
+		contactIDs = append(contactIDs, event.ContactID)
 	}
-
-	// Record that we sent this notification
-	if err := s.db.RecordNotificationSettingSent(setting); err != nil {
-		logger.Error("[SCHEDULER] Error recording notification: %v", err)
+	addressNames, err := database.GetContactAddressNames(setting.UserID, contactIDs)
+	if err != nil {
+		logger.Warn("[SCHEDULER] Error getting contact salutations: %v", err)
 	}
+	for i := range relevantEvents {
+		relevantEvents[i].AddressAs = addressNames[relevantEvents[i].ContactID]
+	}
+
+	return relevantEvents, nil
 }
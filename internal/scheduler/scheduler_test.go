@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/mailer"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/ntfy"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+func TestMatchEventsReminderLeadDayOverride(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	today := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	inTwoWeeks := today.AddDate(0, 0, 14)
+
+	birthdayIn := func(name string) *models.Contact {
+		return dbtest.NewContact(t, database, user.ID, &models.Contact{
+			FullName:      name,
+			BirthdayMonth: utils.IntPtr(int(inTwoWeeks.Month())),
+			BirthdayDay:   utils.IntPtr(inTwoWeeks.Day()),
+		})
+	}
+	important := birthdayIn("Important")
+	birthdayIn("Everyone Else")
+
+	if _, err := database.PatchContact(user.ID, important.ID, &models.ContactJSONPatch{ReminderLeadDays: utils.IntPtr(14)}); err != nil {
+		t.Fatalf("PatchContact: %v", err)
+	}
+
+	setting := models.NotificationSetting{
+		UserID:           user.ID,
+		DaysLookAhead:    7,
+		IncludeBirthdays: true,
+	}
+	events, err := MatchEvents(database, setting, &today)
+	if err != nil {
+		t.Fatalf("MatchEvents: %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want only the contact with the override: %+v", len(events), events)
+	}
+	if events[0].ContactID != important.ID {
+		t.Errorf("event is for contact %d, want %d", events[0].ContactID, important.ID)
+	}
+	if events[0].DaysUntil != 14 {
+		t.Errorf("DaysUntil = %d, want 14", events[0].DaysUntil)
+	}
+}
+
+func TestMatchEventsAddressesBySalutation(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	today := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	birthdayToday := func(c *models.Contact) *models.Contact {
+		c.BirthdayMonth = utils.IntPtr(int(today.Month()))
+		c.BirthdayDay = utils.IntPtr(today.Day())
+		return dbtest.NewContact(t, database, user.ID, c)
+	}
+	linda := birthdayToday(&models.Contact{FullName: "Linda Jones", GivenName: "Linda", FamilyName: "Jones", Salutation: "Aunt Linda"})
+	bob := birthdayToday(&models.Contact{FullName: "Bob Builder", GivenName: "Bob", FamilyName: "Builder"})
+	acme := birthdayToday(&models.Contact{FullName: "Acme Corp"})
+
+	setting := models.NotificationSetting{
+		UserID:           user.ID,
+		DaysLookAhead:    0,
+		IncludeBirthdays: true,
+	}
+	events, err := MatchEvents(database, setting, &today)
+	if err != nil {
+		t.Fatalf("MatchEvents: %v", err)
+	}
+
+	want := map[int]string{linda.ID: "Aunt Linda", bob.ID: "Bob", acme.ID: "Acme Corp"}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for _, e := range events {
+		if got := e.DisplayName(); got != want[e.ContactID] {
+			t.Errorf("contact %d is addressed as %q, want %q", e.ContactID, got, want[e.ContactID])
+		}
+	}
+
+	content := mailer.BuildTodayEventsBody(events, "http://kindred.test")
+	if !strings.Contains(content.Text, "- Aunt Linda - has a birthday Today!") {
+		t.Errorf("digest doesn't address Linda by her salutation:\n%s", content.Text)
+	}
+}
+
+func TestMatchEventsMatchesSentNotification(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sent = append(sent, string(body))
+	}))
+	defer srv.Close()
+
+	today := time.Now()
+	on := func(days int) (*int, *int) {
+		d := today.AddDate(0, 0, days)
+		return utils.IntPtr(int(d.Month())), utils.IntPtr(d.Day())
+	}
+	contact := func(c *models.Contact) {
+		dbtest.NewContact(t, database, user.ID, c)
+	}
+
+	month, day := on(0)
+	contact(&models.Contact{FullName: "Birthday Today", BirthdayMonth: month, BirthdayDay: day})
+	month, day = on(3)
+	contact(&models.Contact{FullName: "Graduation Soon", OtherDates: []models.OtherDate{{EventName: "Graduation", EventDateMonth: month, EventDateDay: day}}})
+	contact(&models.Contact{FullName: "Retirement Soon", OtherDates: []models.OtherDate{{EventName: "Retirement", EventDateMonth: month, EventDateDay: day}}})
+	contact(&models.Contact{FullName: "Anniversary Soon", AnniversaryMonth: month, AnniversaryDay: day})
+	month, day = on(30)
+	contact(&models.Contact{FullName: "Birthday Later", BirthdayMonth: month, BirthdayDay: day})
+
+	topic := srv.URL + "/birthdays"
+	setting := models.NotificationSetting{
+		UserID:            user.ID,
+		Name:              "Preview test",
+		ProviderType:      "ntfy",
+		WebhookURL:        &topic,
+		DaysLookAhead:     7,
+		NotificationTime:  "09:00",
+		IncludeBirthdays:  true,
+		IncludeEventDates: true,
+		EventRegex:        "graduation",
+		Enabled:           true,
+	}
+	id, err := database.CreateNotificationSetting(user.ID, &setting)
+	if err != nil {
+		t.Fatalf("CreateNotificationSetting: %v", err)
+	}
+	setting.ID = id
+
+	preview, err := MatchEvents(database, setting, &today)
+	if err != nil {
+		t.Fatalf("MatchEvents: %v", err)
+	}
+	var names []string
+	for _, e := range preview {
+		names = append(names, e.FullName)
+	}
+	if strings.Join(names, ",") != "Birthday Today,Graduation Soon" {
+		t.Errorf("preview = %q, want the birthday today and the matching graduation", names)
+	}
+
+	NewScheduler(database, "http://kindred.test").processNotificationSetting(setting)
+
+	if len(sent) != 1 {
+		t.Fatalf("sent %d notifications, want 1", len(sent))
+	}
+	if want := ntfy.BuildTodayEventsMessage(preview, "http://kindred.test").Body; sent[0] != want {
+		t.Errorf("sent body:\n%s\nwant the preview's events:\n%s", sent[0], want)
+	}
+}
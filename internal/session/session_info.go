@@ -75,6 +75,20 @@ func GetClientIP(r *http.Request) string {
 	return ip
 }
 
+// RemoteIP returns the client address for security decisions such as rate limiting. The
+// X-Forwarded-For and X-Real-IP headers are only honored with trustProxyHeaders, since without a
+// reverse proxy overwriting them clients can set them to anything
+func RemoteIP(r *http.Request, trustProxyHeaders bool) string {
+	if trustProxyHeaders {
+		return GetClientIP(r)
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
 // ParseBrowser extracts browser name and version from User-Agent
 func ParseBrowser(ua string) (name, version string) {
 	switch {
@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/utils"
+)
+
+// APIBaseURL is the Telegram Bot API endpoint; a variable so tests can point it at a stub server
+var APIBaseURL = "https://api.telegram.org"
+
+// TelegramMessage represents the sendMessage payload sent to the Bot API
+type TelegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview,omitempty"`
+}
+
+// markdownEscaper escapes the characters that are special in Telegram's legacy Markdown mode
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// linkTextEscaper drops brackets from link text, which can't be escaped inside an entity
+var linkTextEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "", "]", "")
+
+// SendTelegramNotification sends a Markdown message to a chat via the given bot token
+func SendTelegramNotification(botToken string, chatID string, text string) error {
+	if botToken == "" {
+		return fmt.Errorf("bot token is empty")
+	}
+	if chatID == "" {
+		return fmt.Errorf("chat ID is empty")
+	}
+
+	message := TelegramMessage{
+		ChatID:                chatID,
+		Text:                  text,
+		ParseMode:             "Markdown",
+		DisableWebPagePreview: true,
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshaling message: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", APIBaseURL, botToken)
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		// Don't wrap: the error text includes the URL, and with it the bot token
+		return fmt.Errorf("error posting to Telegram")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Telegram API returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// BuildTodayEventsMessage creates a Telegram Markdown message for today's events
+func BuildTodayEventsMessage(events []models.UpcomingEvent, baseURL string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("🎉 *Today's Events - %s*\n", time.Now().Local().Format("Jan 2")))
+
+	if len(events) == 0 {
+		sb.WriteString("\nNo birthdays or anniversaries today!")
+		return sb.String()
+	}
+
+	// Group by event type
+	birthdays := []models.UpcomingEvent{}
+	anniversaries := []models.UpcomingEvent{}
+	others := []models.UpcomingEvent{}
+
+	for _, event := range events {
+		switch event.EventType {
+		case "birthday":
+			birthdays = append(birthdays, event)
+		case "anniversary":
+			anniversaries = append(anniversaries, event)
+		default:
+			others = append(others, event)
+		}
+	}
+
+	if len(birthdays) > 0 {
+		sb.WriteString("\n*Birthdays*\n")
+		for _, b := range birthdays {
+			name := makeLink(b.DisplayName(), contactURL(baseURL, b.ContactID))
+			if b.AgeOrYears != nil {
+				sb.WriteString(fmt.Sprintf("🎂 %s - %s birthday %s!\n", name, utils.Ordinal(*b.AgeOrYears), b.TimeDescription))
+			} else {
+				sb.WriteString(fmt.Sprintf("🎂 %s - has a birthday %s!\n", name, b.TimeDescription))
+			}
+		}
+	}
+
+	if len(anniversaries) > 0 {
+		sb.WriteString("\n*Anniversaries*\n")
+		for _, a := range anniversaries {
+			name := makeLink(a.DisplayName(), contactURL(baseURL, a.ContactID))
+			if a.AgeOrYears != nil {
+				sb.WriteString(fmt.Sprintf("💍 %s - %s wedding anniversary %s!\n", name, utils.Ordinal(*a.AgeOrYears), a.TimeDescription))
+			} else {
+				sb.WriteString(fmt.Sprintf("💍 %s - has a wedding anniversary %s!\n", name, a.TimeDescription))
+			}
+		}
+	}
+
+	if len(others) > 0 {
+		sb.WriteString("\n*Other Dates*\n")
+		for _, o := range others {
+			name := makeLink(o.DisplayName(), contactURL(baseURL, o.ContactID))
+			eventType := markdownEscaper.Replace(o.EventType)
+			if o.AgeOrYears != nil {
+				sb.WriteString(fmt.Sprintf("📅 %s - %s anniversary of %s %s!\n", name, utils.Ordinal(*o.AgeOrYears), eventType, o.TimeDescription))
+			} else {
+				sb.WriteString(fmt.Sprintf("📅 %s - anniversary of %s %s!\n", name, eventType, o.TimeDescription))
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// SendTestNotification sends a test notification with dummy data
+func SendTestNotification(botToken string, chatID string, baseURL string) error {
+	dummyAge1 := 30
+	dummyAge2 := 2
+
+	dummyEvents := []models.UpcomingEvent{
+		{
+			ContactID:       99999,
+			FullName:        "John Doe",
+			EventType:       "birthday",
+			AgeOrYears:      &dummyAge1,
+			TimeDescription: "Today",
+		},
+		{
+			ContactID:       99998,
+			FullName:        "Jane Smith",
+			EventType:       "anniversary",
+			AgeOrYears:      &dummyAge2,
+			TimeDescription: "Tomorrow",
+		},
+		{
+			ContactID:       99997,
+			FullName:        "Jack Jones",
+			EventType:       "Retirement",
+			AgeOrYears:      &dummyAge2,
+			TimeDescription: "in 3 days",
+		},
+	}
+
+	text := "This is a 🧪 test notification from KindredCard!\n\n" + BuildTodayEventsMessage(dummyEvents, baseURL)
+
+	return SendTelegramNotification(botToken, chatID, text)
+}
+
+func contactURL(baseURL string, contactID int) string {
+	return fmt.Sprintf("%s/contacts/%d", baseURL, contactID)
+}
+
+func makeLink(display string, url string) string {
+	return fmt.Sprintf("[%s](%s)", linkTextEscaper.Replace(display), url)
+}
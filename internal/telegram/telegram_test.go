@@ -0,0 +1,78 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+func TestSendTelegramNotificationPayload(t *testing.T) {
+	var path string
+	var got TelegramMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	defaultURL := APIBaseURL
+	APIBaseURL = srv.URL
+	t.Cleanup(func() { APIBaseURL = defaultURL })
+
+	age := 36
+	events := []models.UpcomingEvent{
+		{ContactID: 1, FullName: "Alice Liddell", EventType: "birthday", AgeOrYears: &age, TimeDescription: "Today"},
+		{ContactID: 2, FullName: "Bob [Builder]", EventType: "First_Date", TimeDescription: "in 3 days"},
+	}
+	text := BuildTodayEventsMessage(events, "http://kindred.test")
+	if err := SendTelegramNotification("123:secret", "-100200", text); err != nil {
+		t.Fatalf("SendTelegramNotification: %v", err)
+	}
+
+	if path != "/bot123:secret/sendMessage" {
+		t.Errorf("path = %q, want /bot123:secret/sendMessage", path)
+	}
+	if got.ChatID != "-100200" || got.ParseMode != "Markdown" || !got.DisableWebPagePreview {
+		t.Errorf("payload = %+v, want chat -100200 in Markdown without link previews", got)
+	}
+	for _, want := range []string{
+		"\n*Birthdays*\n🎂 [Alice Liddell](http://kindred.test/contacts/1) - 36th birthday Today!\n",
+		// Brackets can't be escaped in link text, and Markdown characters elsewhere are escaped
+		"\n*Other Dates*\n📅 [Bob Builder](http://kindred.test/contacts/2) - anniversary of First\\_Date in 3 days!\n",
+	} {
+		if !strings.Contains(got.Text, want) {
+			t.Errorf("text is missing %q:\n%s", want, got.Text)
+		}
+	}
+}
+
+func TestSendTelegramNotificationErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"ok":false}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	defaultURL := APIBaseURL
+	APIBaseURL = srv.URL
+	t.Cleanup(func() { APIBaseURL = defaultURL })
+
+	if err := SendTelegramNotification("123:secret", "-100200", "hi"); err == nil {
+		t.Error("SendTelegramNotification ignored a 401 from the Bot API")
+	}
+	if err := SendTelegramNotification("", "-100200", "hi"); err == nil {
+		t.Error("SendTelegramNotification accepted an empty bot token")
+	}
+	if err := SendTelegramNotification("123:secret", "", "hi"); err == nil {
+		t.Error("SendTelegramNotification accepted an empty chat ID")
+	}
+}
@@ -41,6 +41,40 @@ func GenderFullString(s string) string {
 	return ""
 }
 
+// NormalizeGender maps common gender spellings onto the single-letter vCard codes
+// (M, F, O, N, U). An empty string clears the gender. Returns false for unknown values
+func NormalizeGender(s string) (string, bool) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "":
+		return "", true
+	case "M", "MALE", "MAN":
+		return "M", true
+	case "F", "FEMALE", "WOMAN":
+		return "F", true
+	case "O", "OTHER":
+		return "O", true
+	case "N", "NONE", "NOT APPLICABLE":
+		return "N", true
+	case "U", "UNKNOWN":
+		return "U", true
+	}
+
+	return "", false
+}
+
+// NormalizeTimeOfDay validates a 24-hour time of day and returns it as zero-padded HH:MM.
+// Accepts H:MM, HH:MM, and HH:MM:SS (seconds are dropped, as sent by some browsers)
+func NormalizeTimeOfDay(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("15:04"), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid time of day %q; expected HH:MM (24-hour)", s)
+}
+
 // Helper: monthName - conver month number to name
 func MonthName(month int) string {
 	if month < 1 || month > 12 {
@@ -0,0 +1,31 @@
+package utils
+
+import "testing"
+
+func TestNormalizeTimeOfDay(t *testing.T) {
+	valid := []struct {
+		in, want string
+	}{
+		{"09:00", "09:00"},
+		{"00:00", "00:00"},
+		{"23:59", "23:59"},
+		{" 7:30 ", "07:30"},
+		{"18:45:00", "18:45"},
+	}
+	for _, tt := range valid {
+		got, err := NormalizeTimeOfDay(tt.in)
+		if err != nil {
+			t.Errorf("NormalizeTimeOfDay(%q): unexpected error %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeTimeOfDay(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "24:00", "12:60", "9am", "9:00 PM", "noon", "12-30", "1230"} {
+		if got, err := NormalizeTimeOfDay(in); err == nil {
+			t.Errorf("NormalizeTimeOfDay(%q) = %q, want an error", in, got)
+		}
+	}
+}
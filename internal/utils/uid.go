@@ -0,0 +1,36 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package utils
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// UIDFormatURN makes NewUID produce RFC 4122 URNs (urn:uuid:...)
+const UIDFormatURN = "urn:uuid"
+
+// NewUID generates a contact UID qualified by uidDomain (UID_DOMAIN). Empty yields a bare UUID,
+// UIDFormatURN yields a URN, and anything else is treated as a domain (uuid@domain). Existing
+// UIDs are never rewritten, so bare ones keep working
+func NewUID(uidDomain string) string {
+	id := uuid.New().String()
+
+	domain := strings.TrimPrefix(strings.TrimSpace(uidDomain), "@")
+	switch {
+	case domain == "":
+		return id
+	case strings.EqualFold(domain, UIDFormatURN):
+		return UIDFormatURN + ":" + id
+	default:
+		return id + "@" + domain
+	}
+}
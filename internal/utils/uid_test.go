@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNewUID(t *testing.T) {
+	const uuidPattern = `[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`
+
+	tests := []struct {
+		uidDomain string
+		pattern   string
+	}{
+		{"", `^` + uuidPattern + `$`},
+		{"  ", `^` + uuidPattern + `$`},
+		{"urn:uuid", `^urn:uuid:` + uuidPattern + `$`},
+		{"URN:UUID", `^urn:uuid:` + uuidPattern + `$`},
+		{"example.com", `^` + uuidPattern + `@example\.com$`},
+		{"@example.com", `^` + uuidPattern + `@example\.com$`},
+	}
+	for _, tt := range tests {
+		uid := NewUID(tt.uidDomain)
+		if !regexp.MustCompile(tt.pattern).MatchString(uid) {
+			t.Errorf("NewUID(%q) = %q, want a match for %s", tt.uidDomain, uid, tt.pattern)
+		}
+	}
+
+	if NewUID("example.com") == NewUID("example.com") {
+		t.Error("NewUID returned the same UID twice")
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (C) 2026 Steve Redden
+ *
+ * KindredCard is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db"
+	"github.com/steveredden/KindredCard/internal/logger"
+	"github.com/steveredden/KindredCard/internal/models"
+	"github.com/steveredden/KindredCard/internal/netguard"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, keyed with the webhook's secret and
+// base64url encoded (see Sign)
+const SignatureHeader = "X-KindredCard-Signature"
+
+// EventHeader repeats the payload's event so receivers can route without parsing the body
+const EventHeader = "X-KindredCard-Event"
+
+// workers bounds deliveries in flight, so bulk changes (imports, delete all) don't flood the
+// receivers
+const workers = 4
+
+// queueSize bounds events waiting for a worker; events past it are dropped and logged
+const queueSize = 1000
+
+// Dispatcher delivers contact change events to the user's webhooks
+type Dispatcher struct {
+	db         *db.Database
+	HTTPClient *http.Client
+
+	// MaxAttempts is how many times a delivery is tried; RetryDelay is the wait before the first
+	// retry, doubling after each
+	MaxAttempts int
+	RetryDelay  time.Duration
+
+	queue chan change
+}
+
+// change is a queued contact change event, encoded for the webhooks subscribed to it
+type change struct {
+	event string
+	hooks []models.Webhook
+	body  []byte
+}
+
+// NewDispatcher creates a Dispatcher and starts its workers. Set its ContactChanged as the
+// database's ContactChangeHook. Deliveries refuse internal addresses (see netguard.NewClient)
+func NewDispatcher(database *db.Database) *Dispatcher {
+	d := &Dispatcher{
+		db:          database,
+		HTTPClient:  netguard.NewClient(10 * time.Second),
+		MaxAttempts: 3,
+		RetryDelay:  2 * time.Second,
+		queue:       make(chan change, queueSize),
+	}
+	for range workers {
+		go d.work()
+	}
+	return d
+}
+
+// GenerateSecret creates a random signing secret for a webhook
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Sign returns the signature sent in SignatureHeader for body
+func Sign(body []byte, secret string) string {
+	return db.SignToken(string(body), secret)
+}
+
+// ContactChanged encodes event for the user's subscribed webhooks and queues it for delivery.
+// The payload is a snapshot taken here, right after the change commits, so later edits (or the
+// contact going to the trash) don't change what an earlier event reports. When the queue is full
+// the event is dropped and logged
+func (d *Dispatcher) ContactChanged(userID int, event string, contactID int) {
+	hooks, err := d.db.GetWebhooksForEvent(userID, event)
+	if err != nil || len(hooks) == 0 {
+		return
+	}
+
+	contact, err := d.payloadContact(userID, event, contactID)
+	if err != nil {
+		logger.Error("[WEBHOOK] Unable to load contact %d for %s: %v", contactID, event, err)
+		return
+	}
+
+	body, err := json.Marshal(models.WebhookPayload{Event: event, Contact: contact})
+	if err != nil {
+		logger.Error("[WEBHOOK] Unable to encode %s payload: %v", event, err)
+		return
+	}
+
+	select {
+	case d.queue <- change{event: event, hooks: hooks, body: body}:
+	default:
+		logger.Warn("[WEBHOOK] Queue full, dropping %s for contact %d", event, contactID)
+	}
+}
+
+// work delivers queued events until the queue is closed
+func (d *Dispatcher) work() {
+	for c := range d.queue {
+		for _, hook := range c.hooks {
+			d.deliver(hook, c.event, c.body)
+		}
+	}
+}
+
+// payloadContact loads the contact as it is now; a deleted contact is identified by id and uid only
+func (d *Dispatcher) payloadContact(userID int, event string, contactID int) (*models.Contact, error) {
+	if event == models.WebhookEventContactDeleted {
+		uid, err := d.db.GetContactUIDByID(userID, contactID)
+		if err != nil {
+			return nil, err
+		}
+		return &models.Contact{ID: contactID, UID: uid}, nil
+	}
+	return d.db.GetContactByID(userID, contactID)
+}
+
+// deliver POSTs body to the webhook, retrying network errors, 429s and 5xx responses
+func (d *Dispatcher) deliver(hook models.Webhook, event string, body []byte) {
+	delay := d.RetryDelay
+	for attempt := 1; attempt <= d.MaxAttempts; attempt++ {
+		retry, err := d.post(hook, event, body)
+		if err == nil {
+			logger.Debug("[WEBHOOK] Delivered %s to webhook %d", event, hook.ID)
+			return
+		}
+		if !retry || attempt == d.MaxAttempts {
+			logger.Warn("[WEBHOOK] Giving up on %s for webhook %d after %d attempt(s): %v", event, hook.ID, attempt, err)
+			return
+		}
+
+		logger.Debug("[WEBHOOK] Attempt %d of %s for webhook %d failed, retrying in %s: %v", attempt, event, hook.ID, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post makes one delivery attempt, reporting whether a failure is worth retrying
+func (d *Dispatcher) post(hook models.Webhook, event string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "KindredCard-Webhook")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(SignatureHeader, Sign(body, hook.Secret))
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		// An internal address won't become reachable by trying again
+		return !errors.Is(err, netguard.ErrBlockedAddress), err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("receiver returned %d", resp.StatusCode)
+}
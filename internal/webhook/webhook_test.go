@@ -0,0 +1,211 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/steveredden/KindredCard/internal/db/dbtest"
+	"github.com/steveredden/KindredCard/internal/models"
+)
+
+// delivery is a request received by the test webhook endpoint
+type delivery struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+// newReceiver starts an endpoint that records each delivery and answers 204
+func newReceiver(t *testing.T) (*httptest.Server, <-chan delivery) {
+	t.Helper()
+
+	ch := make(chan delivery, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ch <- delivery{event: r.Header.Get(EventHeader), signature: r.Header.Get(SignatureHeader), body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, ch
+}
+
+// verifies checks a signature the way a receiver would, without going through Sign
+func verifies(body []byte, signature string, secret string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	want := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(want))
+}
+
+func receive(t *testing.T, ch <-chan delivery) delivery {
+	t.Helper()
+
+	select {
+	case got := <-ch:
+		return got
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a webhook delivery")
+		return delivery{}
+	}
+}
+
+func TestDispatcherDeliversSignedEvents(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+
+	srv, received := newReceiver(t)
+	const secret = "whsec_test"
+	if _, err := database.CreateWebhook(user.ID, srv.URL, secret, []string{models.WebhookEventContactCreated, models.WebhookEventContactDeleted}); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+
+	dispatcher := NewDispatcher(database)
+	// The receiver is on loopback, which the guarded default client refuses
+	dispatcher.HTTPClient = srv.Client()
+	database.ContactChangeHook = dispatcher.ContactChanged
+
+	contact := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice Liddell"})
+
+	got := receive(t, received)
+	if got.event != models.WebhookEventContactCreated {
+		t.Errorf("%s = %q, want %q", EventHeader, got.event, models.WebhookEventContactCreated)
+	}
+	if !verifies(got.body, got.signature, secret) {
+		t.Errorf("signature %q does not verify for body %s", got.signature, got.body)
+	}
+	var payload models.WebhookPayload
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	if payload.Event != models.WebhookEventContactCreated || payload.Contact == nil || payload.Contact.FullName != "Alice Liddell" {
+		t.Errorf("payload = %s, want the created contact", got.body)
+	}
+
+	if err := database.DeleteContact(user.ID, contact.ID); err != nil {
+		t.Fatalf("DeleteContact: %v", err)
+	}
+
+	got = receive(t, received)
+	if got.event != models.WebhookEventContactDeleted || !verifies(got.body, got.signature, secret) {
+		t.Errorf("delete delivery = %q with signature %q, want a signed %q", got.event, got.signature, models.WebhookEventContactDeleted)
+	}
+	payload = models.WebhookPayload{}
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	if payload.Contact == nil || payload.Contact.ID != contact.ID || payload.Contact.UID != contact.UID {
+		t.Errorf("deleted payload = %s, want contact %d identified by uid %q", got.body, contact.ID, contact.UID)
+	}
+}
+
+func TestDispatcherDeliversRelationshipEdits(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+	alice := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice Liddell"})
+	bob := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Bob Liddell"})
+
+	srv, received := newReceiver(t)
+	const secret = "whsec_test"
+	if _, err := database.CreateWebhook(user.ID, srv.URL, secret, []string{models.WebhookEventContactUpdated}); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+
+	dispatcher := NewDispatcher(database)
+	dispatcher.HTTPClient = srv.Client()
+	database.ContactChangeHook = dispatcher.ContactChanged
+
+	if _, _, err := database.AddRelationship(user.ID, alice.ID, bob.ID, dbtest.RelationshipTypeID(t, database, "Spouse")); err != nil {
+		t.Fatalf("AddRelationship: %v", err)
+	}
+
+	// Both ends of the relationship changed, and deliveries may arrive in either order
+	updated := make(map[int]bool)
+	for range 2 {
+		got := receive(t, received)
+		if got.event != models.WebhookEventContactUpdated || !verifies(got.body, got.signature, secret) {
+			t.Errorf("delivery = %q with signature %q, want a signed %q", got.event, got.signature, models.WebhookEventContactUpdated)
+		}
+		var payload models.WebhookPayload
+		if err := json.Unmarshal(got.body, &payload); err != nil {
+			t.Fatalf("decoding payload: %v", err)
+		}
+		if payload.Contact != nil {
+			updated[payload.Contact.ID] = true
+		}
+	}
+	if !updated[alice.ID] || !updated[bob.ID] {
+		t.Errorf("updated contacts = %v, want both %d and %d", updated, alice.ID, bob.ID)
+	}
+}
+
+func TestDispatcherSnapshotsPayloads(t *testing.T) {
+	database := dbtest.Open(t)
+	user := dbtest.NewUser(t, database)
+	contact := dbtest.NewContact(t, database, user.ID, &models.Contact{FullName: "Alice Liddell", GivenName: "Alice", FamilyName: "Liddell"})
+
+	srv, received := newReceiver(t)
+	if _, err := database.CreateWebhook(user.ID, srv.URL, "whsec_test", []string{models.WebhookEventContactUpdated}); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+
+	dispatcher := NewDispatcher(database)
+	dispatcher.HTTPClient = srv.Client()
+	database.ContactChangeHook = dispatcher.ContactChanged
+
+	// The update is still reported, as it was, when the contact is trashed before delivery
+	family := "Hargreaves"
+	if _, err := database.PatchContact(user.ID, contact.ID, &models.ContactJSONPatch{FamilyName: &family}); err != nil {
+		t.Fatalf("PatchContact: %v", err)
+	}
+	if err := database.DeleteContact(user.ID, contact.ID); err != nil {
+		t.Fatalf("DeleteContact: %v", err)
+	}
+
+	got := receive(t, received)
+	var payload models.WebhookPayload
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	if payload.Contact == nil || payload.Contact.FullName != "Alice Hargreaves" {
+		t.Errorf("payload = %s, want the contact as patched", got.body)
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int32
+	}{
+		{"succeeds first time", []int{http.StatusNoContent}, 1},
+		{"retries server errors", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, 3},
+		{"gives up after max attempts", []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK}, 3},
+		{"does not retry client errors", []int{http.StatusBadRequest, http.StatusOK}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			d := &Dispatcher{HTTPClient: srv.Client(), MaxAttempts: 3, RetryDelay: time.Millisecond}
+			d.deliver(models.Webhook{ID: 1, URL: srv.URL, Secret: "whsec_test"}, models.WebhookEventContactUpdated, []byte(`{}`))
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
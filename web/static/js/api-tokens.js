@@ -36,9 +36,11 @@
             const name = document.getElementById('tokenName').value;
             const hasExpiration = document.getElementById('hasExpiration').checked;
             const expiresAt = hasExpiration ? document.getElementById('expiresAt').value : null;
+            const scope = document.getElementById('tokenScope').value;
 
             const payload = {
                 name: name,
+                scope: scope,
                 expires_at: expiresAt ? new Date(expiresAt).toISOString() : null
             };
 
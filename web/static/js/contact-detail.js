@@ -524,8 +524,9 @@
                 </div>
             </div>
 
-            <div class="grid grid-cols-1 md:grid-cols-2 gap-2">
+            <div class="grid grid-cols-1 md:grid-cols-3 gap-2">
                 <input type="text" name="job_title" placeholder="Job Title" class="input input-bordered input-sm w-full">
+                <input type="text" name="role" placeholder="Role" class="input input-bordered input-sm w-full">
                 <input type="text" name="department" placeholder="Department" class="input input-bordered input-sm w-full">
             </div>
         `;
@@ -567,6 +568,7 @@
 
             const currentCompanyName = row.querySelector('[name="company_name"]').value;
             const currentJobTitle = row.querySelector('[name="job_title"]').value;
+            const currentRole = row.querySelector('[name="role"]').value;
             const currentDepartment = row.querySelector('[name="department"]').value;
             const currentPhoneticName = row.querySelector('[name="phonetic_name"]').value;
 
@@ -576,6 +578,7 @@
                 name: currentCompanyName,
                 phonetic_name: currentPhoneticName,
                 title: currentJobTitle,
+                role: currentRole,
                 department: currentDepartment,
             };
 
@@ -584,6 +587,7 @@
                 const isDirty =
                     currentCompanyName !== row.getAttribute('data-original-companyname') ||
                     currentJobTitle !== row.getAttribute('data-original-jobtitle') ||
+                    currentRole !== row.getAttribute('data-original-role') ||
                     currentDepartment !== row.getAttribute('data-original-department') ||
                     currentPhoneticName !== row.getAttribute('data-original-phoneticname')
 
@@ -882,7 +886,7 @@
             requests.push(fetch(`/api/v1/contacts/${contactId}/birthday`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify(formatEndpointBody(getGroupValues(document.getElementById('datesCard'), 'birthday')))
+                body: JSON.stringify(formatEndpointBody(getGroupValues(document.getElementById('datesCard'), 'birthday'), true))
             }));
         }
 
@@ -891,7 +895,7 @@
             requests.push(fetch(`/api/v1/contacts/${contactId}/anniversary`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify(formatEndpointBody(getGroupValues(document.getElementById('datesCard'), 'anniversary')))
+                body: JSON.stringify(formatEndpointBody(getGroupValues(document.getElementById('datesCard'), 'anniversary'), true))
             }));
         }
 
@@ -905,10 +909,17 @@
 
             if (id) {
                 if (isOtherDateRowDirty(row)) {
+                    const patch = { event_name: data.date_type };
+                    if (data.date) {
+                        patch.event_date = data.date.slice(0, 10);
+                    } else if (data.date_month && data.date_day) {
+                        patch.event_date_month = data.date_month;
+                        patch.event_date_day = data.date_day;
+                    }
                     requests.push(fetch(`/api/v1/other-dates/${id}`, {
                         method: 'PATCH',
                         headers: { 'Content-Type': 'application/json' },
-                        body: JSON.stringify(data)
+                        body: JSON.stringify(patch)
                     }));
                 }
             } else {
@@ -974,7 +985,17 @@
         };
     }
 
-    function formatEndpointBody(vals) {
+    function formatEndpointBody(vals, allowReducedPrecision = false) {
+        // Birthday/anniversary may be year-only or year+month when the day is unknown
+        if (allowReducedPrecision && !vals.day && vals.year && vals.year > 0) {
+            return {
+                date: null,
+                date_year: parseInt(vals.year),
+                date_month: vals.month ? parseInt(vals.month) : null,
+                date_day: null
+            };
+        }
+
         // if the date birthday or anniversary has been cleared out
         if (!vals.month || !vals.day) {
             return {
@@ -1346,7 +1367,11 @@
             });
             
             if (response.ok) {
-                showNotification('Relationship added successfully', 'success');
+                if (response.status === 201) {
+                    showNotification('Relationship added successfully', 'success');
+                } else {
+                    showNotification('Relationship already exists', 'info');
+                }
                 closeRelationshipModal();
                 setTimeout(() => window.location.reload(), 500);
             } else {
@@ -17,7 +17,7 @@
         const payload = Object.fromEntries(formData.entries());
 
         try {
-            const res = await fetch('/api/v1/settings/labels', {
+            const res = await fetch('/api/v1/labels', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(payload)
@@ -28,9 +28,8 @@
                 showNotification('Label created', 'success');
                 setTimeout(() => location.reload(), 500);
             } else {
-                const err = await res.json();
-                alert(err.message || 'Error creating label');
-                showNotification('Label creation failed', 'error');
+                const msg = await res.text();
+                showNotification(msg.trim() || 'Label creation failed', 'error');
             }
         } catch (e) {
             showNotification('Label creation failed', 'error');
@@ -42,12 +41,15 @@
         if (!confirm('Are you sure you want to delete this custom label?')) return;
 
         try {
-            const res = await fetch(`/api/v1/settings/labels/${id}`, {
+            const res = await fetch(`/api/v1/labels/${id}`, {
                 method: 'DELETE'
             });
             if (res.ok) {
                 showNotification('Label deleted', 'success');
                 setTimeout(() => location.reload(), 500);
+            } else {
+                const msg = await res.text();
+                showNotification(msg.trim() || 'Label deletion failed', 'error');
             }
         } catch (e) {
             showNotification('Label deletion failed', 'error');
@@ -104,18 +104,32 @@
             return;
         }
 
+        // CSV files (e.g. Google Contacts exports) go to the CSV importer
+        const isCSV = file.name.toLowerCase().endsWith('.csv');
+
         const formData = new FormData();
-        formData.append('vcard', file);
+        if (isCSV) {
+            formData.append('csv', file);
+        } else {
+            formData.append('vcard', file);
+            if (document.getElementById('vcardImportSeparateAnniversary')?.checked) {
+                formData.append('anniversary_mode', 'separate');
+            }
+        }
 
         try {
-            const response = await fetch('/api/v1/contacts/import', {
+            const response = await fetch(isCSV ? '/api/v1/contacts/import/csv' : '/api/v1/contacts/import', {
                 method: 'POST',
                 body: formData
             });
 
             if (response.ok) {
                 const result = await response.json();
-                showNotification(`Imported ${result.count} contact(s)`, 'success');
+                if (isCSV) {
+                    showNotification(`Imported ${result.imported} contact(s), skipped ${result.skipped}`, 'success');
+                } else {
+                    showNotification(`Imported ${result.count} contact(s)`, 'success');
+                }
                 fileInput.value = '';
                 setTimeout(() => location.reload(), 1500);
             } else {
@@ -128,11 +142,12 @@
     };
 
     // Export all contacts
-    window.exportAllContacts = async function(asJSON) {
+    window.exportAllContacts = async function(format) {
         try {
-            // 1. Determine configuration based on format
-            const endpoint = asJSON ? '/api/v1/contacts/export/json' : '/api/v1/contacts/export/vcard';
-            const extension = asJSON ? 'json' : 'vcf';
+            // 1. Determine configuration based on format ('vcard', 'json', or 'csv')
+            const extensions = { vcard: 'vcf', json: 'json', csv: 'csv' };
+            const endpoint = `/api/v1/contacts/export/${format}`;
+            const extension = extensions[format];
             const date = new Date().toISOString().split('T')[0];
 
             // 2. Single fetch call
@@ -160,6 +175,61 @@
         }
     };
 
+    // Display preferences
+    window.setNicknameAsDisplayName = async function(enabled) {
+        try {
+            const response = await fetch('/api/v1/user/preferences', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ nickname_as_display_name: enabled })
+            });
+            if (!response.ok) throw new Error('Failed to save preference');
+
+            showNotification(enabled ? 'Showing nicknames' : 'Showing full names', 'success');
+        } catch (error) {
+            console.error('Preference error:', error);
+            showNotification('Failed to save preference', 'error');
+        }
+    };
+
+    window.saveCardDAVDisplayName = async function() {
+        const name = document.getElementById('carddavDisplayName').value.trim();
+        try {
+            const response = await fetch('/api/v1/user/preferences', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ carddav_display_name: name })
+            });
+            if (!response.ok) throw new Error('Failed to save preference');
+
+            showNotification('CardDAV account name saved', 'success');
+        } catch (error) {
+            console.error('Preference error:', error);
+            showNotification('Failed to save preference', 'error');
+        }
+    };
+
+    window.saveEventLookaheadDays = async function() {
+        const days = parseInt(document.getElementById('eventLookaheadDays').value, 10);
+        if (isNaN(days) || days < 1 || days > 365) {
+            showNotification('Enter a number of days between 1 and 365', 'error');
+            return;
+        }
+        try {
+            const response = await fetch('/api/v1/user/preferences', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ default_event_lookahead_days: days })
+            });
+            if (!response.ok) throw new Error('Failed to save preference');
+
+            showNotification('Upcoming events window saved', 'success');
+        } catch (error) {
+            console.error('Preference error:', error);
+            showNotification('Failed to save preference', 'error');
+        }
+    };
+
     // Delete all contacts
     window.deleteAllContacts = async function() {
         const confirmed = confirm('⚠️ DELETE ALL CONTACTS?\n\nThis will permanently delete ALL contacts and cannot be undone.\n\nType "DELETE ALL" in the next prompt to confirm.');
@@ -174,7 +244,15 @@
         }
 
         try {
-            const response = await fetch('/api/v1/contacts', {
+            const preview = await fetch('/api/v1/contacts/delete-preview');
+            if (!preview.ok) {
+                throw new Error('Preview failed');
+            }
+            const { count, confirmation } = await preview.json();
+
+            if (!confirm(`This will delete ${count} contact(s). Continue?`)) return;
+
+            const response = await fetch(`/api/v1/contacts?confirm=${encodeURIComponent(confirmation)}`, {
                 method: 'DELETE',
                 headers: { 'Content-Type': 'application/json' }
             });
@@ -219,64 +297,83 @@
     // NOTIFICATIONS TAB FUNCTIONS
     // ========================================
 
-    // Open add notification modal
-    window.openAddWebhookModal = function() {
-        const modal = document.getElementById('notificationModal');
-        const form = document.getElementById('notificationForm');
-        
-        if (form) {
-            form.reset();
-            document.getElementById('notification_id').value = '';
-            document.getElementById('notification_provider_type').value = "discord";
-            document.getElementById('notification_enabled').checked = true;
-            document.getElementById('notification_include_birthdays').checked = true;
-            document.getElementById('notification_include_anniversaries').checked = true;
-
-            //show the webhook_url, hide target_address
-            document.getElementById('notification_webhook_url').classList.remove('hidden');
-            document.getElementById('notification_target_address').classList.add('hidden');
-
-            //update requirements
-            document.querySelector('input[name="notification_webhook_url"]').required = true;
-            document.querySelector('input[name="notification_target_address"]').required = false;
+    // Per-provider labels for the shared webhook_url / target_address fields (null hides the field)
+    const notificationProviders = {
+        discord: {
+            title: 'Discord Webhook',
+            webhook: { label: 'Discord Webhook URL*', type: 'url', placeholder: 'https://discord.com/api/webhooks/...', help: 'Get this from Discord Server Settings → Integrations → Webhooks' },
+            target: null,
+        },
+        smtp: {
+            title: 'Email Notification',
+            webhook: null,
+            target: { label: 'Recipient Email Address*', type: 'email', placeholder: 'you@example.com' },
+        },
+        telegram: {
+            title: 'Telegram Notification',
+            webhook: { label: 'Telegram Bot Token*', type: 'text', placeholder: '123456789:AAE...', help: 'Create a bot with @BotFather, then message it once so it can reply to you' },
+            target: { label: 'Telegram Chat ID*', type: 'text', placeholder: '123456789' },
+        },
+        ntfy: {
+            title: 'ntfy Notification',
+            webhook: { label: 'ntfy Topic URL*', type: 'url', placeholder: 'https://ntfy.sh/my-topic', help: 'The full URL of the topic to publish to' },
+            target: null,
+        },
+    };
+    notificationProviders.email = notificationProviders.smtp;
+
+    // Show, label, and require the modal fields the provider uses
+    function configureNotificationFields(provider) {
+        const config = notificationProviders[provider] || notificationProviders.discord;
+        const fields = [
+            ['notification_webhook_url', config.webhook],
+            ['notification_target_address', config.target],
+        ];
+
+        for (const [id, field] of fields) {
+            const container = document.getElementById(id);
+            const input = container.querySelector('input');
+            container.classList.toggle('hidden', !field);
+            input.required = !!field;
+            if (!field) continue;
+
+            input.type = field.type;
+            input.placeholder = field.placeholder;
+            container.querySelector('.label-text').textContent = field.label;
+            const help = container.querySelector('.label-text-alt');
+            if (help) help.textContent = field.help || '';
         }
 
-        // Update title
-        document.getElementById('notificationModalTitle').textContent = 'Add Discord Webhook';
-        document.getElementById('notificationSubmitText').textContent = 'Save Webhook';
-        
-        if (modal) modal.showModal();
-    };
+        return config;
+    }
 
-    // Open add notification modal
-    window.openAddEmailModal = function() {
+    // Open add notification modal for the given provider
+    window.openAddNotificationModal = function(provider) {
         const modal = document.getElementById('notificationModal');
         const form = document.getElementById('notificationForm');
+        let config = notificationProviders[provider] || notificationProviders.discord;
         
         if (form) {
             form.reset();
             document.getElementById('notification_id').value = '';
-            document.getElementById('notification_provider_type').value = "smtp";
+            document.getElementById('notification_provider_type').value = provider;
             document.getElementById('notification_enabled').checked = true;
             document.getElementById('notification_include_birthdays').checked = true;
             document.getElementById('notification_include_anniversaries').checked = true;
 
-            //hide the webhook_url, show target_address
-            document.getElementById('notification_webhook_url').classList.add('hidden');
-            document.getElementById('notification_target_address').classList.remove('hidden');
-
-            //update requirements
-            document.querySelector('input[name="notification_webhook_url"]').required = false;
-            document.querySelector('input[name="notification_target_address"]').required = true;
+            config = configureNotificationFields(provider);
         }
 
         // Update title
-        document.getElementById('notificationModalTitle').textContent = 'Add Email Notification';
+        document.getElementById('notificationModalTitle').textContent = `Add ${config.title}`;
         document.getElementById('notificationSubmitText').textContent = 'Save Notification';
         
         if (modal) modal.showModal();
     };
 
+    window.openAddWebhookModal = () => openAddNotificationModal('discord');
+    window.openAddEmailModal = () => openAddNotificationModal('smtp');
+
     // Close notification modal
     window.closeNotificationModal = function() {
         const modal = document.getElementById('notificationModal');
@@ -309,19 +406,9 @@
             document.getElementById('notification_enabled').checked = notification.enabled || false;
             
             // Update modal based on type
-            let modalTitle;
-            let submitText;
-            if ( notification.provider_type == "smtp" ) {
-                modalTitle = 'Edit Email Target';
-                submitText = 'Update Notification';
-                webhookUrlField.classList.add('hidden');
-                targetAddressField.classList.remove('hidden');
-            } else {
-                modalTitle = 'Edit Discord Webhook';
-                submitText = 'Update Webhook';
-                webhookUrlField.classList.remove('hidden');
-                targetAddressField.classList.add('hidden');
-            }
+            const config = configureNotificationFields(notification.provider_type ?? "discord");
+            const modalTitle = `Edit ${config.title}`;
+            const submitText = 'Update Notification';
 
             document.getElementById('notificationModalTitle').textContent = modalTitle;
             document.getElementById('notificationSubmitText').textContent = submitText;
@@ -414,11 +501,16 @@
             return;
         }
         
-        if (data.provider_type === "smtp" && !data.target_address.includes('@')) {
+        if ((data.provider_type === "smtp" || data.provider_type === "email") && !data.target_address.includes('@')) {
             showNotification('Invalid email address', 'error');
             return;
         }
 
+        if (data.provider_type === "ntfy" && !/^https?:\/\//.test(data.webhook_url)) {
+            showNotification('Invalid ntfy topic URL', 'error');
+            return;
+        }
+
         try {
             const url = isEdit 
                 ? `/api/v1/notification-settings/${notificationId}`
@@ -11,29 +11,18 @@
 (function() {
     'use strict';
     
-    window.syncAnniversary = async function(e, targetId, val) {
+    window.syncAnniversary = async function(e, targetId, sourceId) {
         const btn = e.currentTarget;
         const card = btn.closest('.util-card');
         btn.disabled = true;
 
-        let body = {};
-
-        const parts = val.split('-');
-        if (parts.length === 3) {
-            body = {
-                date: new Date(val).toISOString()
-            };
-        } else { 
-            body = {
-                date_month: parseInt(parts[0]),
-                date_day: parseInt(parts[1])
-            };
-        }
-
-        const res = await fetch(`/api/v1/contacts/${targetId}/anniversary`, {
-            method: 'PATCH',
+        const res = await fetch('/api/v1/suggestions/anniversaries/accept', {
+            method: 'POST',
             headers: {'Content-Type': 'application/json'},
-            body: JSON.stringify(body)
+            body: JSON.stringify({
+                target_id: parseInt(targetId),
+                source_id: parseInt(sourceId)
+            })
         });
 
         if (res.ok) UtilCommon.showNext(card);
@@ -44,6 +44,22 @@
             document.querySelectorAll('.side-action-btn').forEach(el => el.classList.add('hidden'));
             
             const deck = document.getElementById('contact-deck');
+
+            // Paged utilities can offer the next batch instead of finishing
+            const nextURL = window.nextPageURL ? window.nextPageURL() : null;
+            if (nextURL) {
+                deck.innerHTML = `
+                <div class="text-center py-16 bg-base-200 rounded-3xl border-2 border-dashed border-base-300 animate-in fade-in zoom-in duration-300">
+                    <h3 class="text-2xl font-bold">Page Complete</h3>
+                    <p class="opacity-60 mb-8">More contacts are waiting in the next batch.</p>
+                    <div class="flex justify-center gap-4">
+                        <a href="${nextURL}" class="btn btn-primary px-8">Load More</a>
+                        <a href="/settings" class="btn btn-ghost">Settings</a>
+                    </div>
+                </div>`;
+                return;
+            }
+
             deck.innerHTML = `
                 <div class="text-center py-16 bg-base-200 rounded-3xl border-2 border-dashed border-base-300 animate-in fade-in zoom-in duration-300">
                     <div class="text-6xl mb-4">🎉</div>
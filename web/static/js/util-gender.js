@@ -11,6 +11,28 @@
 (function() {
     'use strict';
     
+    // Skipped contacts keep their place in the missing-gender list, so the next
+    // page starts after them rather than after the whole current page
+    let skipped = 0;
+
+    window.skipGender = function(card) {
+        skipped++;
+        UtilCommon.showNext(card);
+    };
+
+    window.nextPageURL = function() {
+        const deck = document.getElementById('contact-deck');
+        if (!deck) return null;
+
+        const total = parseInt(deck.dataset.total) || 0;
+        const offset = parseInt(deck.dataset.offset) || 0;
+        const count = parseInt(deck.dataset.count) || 0;
+        const limit = parseInt(deck.dataset.limit) || 50;
+
+        if (total <= offset + count) return null;
+        return `?offset=${offset + skipped}&limit=${limit}`;
+    };
+
     window.assignGender = function(gender) {
         const card = document.querySelector('.util-card:not(.hidden)');
         if (!card) return;
@@ -15,11 +15,12 @@
         const card = e.currentTarget.closest('.util-card');
         UtilCommon.showNext(card);
         
-        fetch(`/api/v1/contacts/${sourceId}/relationships`, {
+        fetch('/api/v1/relationships/suggestions/accept', {
             method: 'POST',
             headers: {'Content-Type': 'application/json'},
             body: JSON.stringify({
-                related_contact_id: parseInt(targetId),
+                proposed_id: parseInt(sourceId),
+                target_id: parseInt(targetId),
                 relationship_type_id: parseInt(typeId)
             })
         });